@@ -27,17 +27,23 @@ import (
 	"github.com/welldanyogia/persistent-temp-mail/backend/internal/domain"
 	"github.com/welldanyogia/persistent-temp-mail/backend/internal/email"
 	"github.com/welldanyogia/persistent-temp-mail/backend/internal/events"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/export"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/importer"
 	"github.com/welldanyogia/persistent-temp-mail/backend/internal/health"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/label"
 	"github.com/welldanyogia/persistent-temp-mail/backend/internal/logger"
 	"github.com/welldanyogia/persistent-temp-mail/backend/internal/metrics"
 	authmw "github.com/welldanyogia/persistent-temp-mail/backend/internal/middleware"
 	"github.com/welldanyogia/persistent-temp-mail/backend/internal/parser"
 	"github.com/welldanyogia/persistent-temp-mail/backend/internal/repository"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/retention"
 	"github.com/welldanyogia/persistent-temp-mail/backend/internal/sanitizer"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/savedsearch"
 	"github.com/welldanyogia/persistent-temp-mail/backend/internal/smtp"
 	"github.com/welldanyogia/persistent-temp-mail/backend/internal/sse"
 	"github.com/welldanyogia/persistent-temp-mail/backend/internal/ssl"
 	"github.com/welldanyogia/persistent-temp-mail/backend/internal/storage"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/transcript"
 )
 
 func main() {
@@ -188,6 +194,10 @@ func main() {
 		passwordValidator,
 	)
 
+	// App passwords authenticate mail clients on the submission listener
+	appPasswordRepo := repository.NewAppPasswordRepository(dbPool)
+	appPasswordService := auth.NewAppPasswordService(userRepo, appPasswordRepo, sessionRepo, passwordValidator)
+
 	// Initialize domain services
 	dnsService := domain.NewDNSService(domain.DNSServiceConfig{
 		MailServer: cfg.Domain.MailServer,
@@ -218,24 +228,6 @@ func main() {
 		Logger:          appLogger,
 	})
 
-	// Initialize email service
-	// Requirements: All email inbox API requirements (1.1-1.9, 2.1-2.8, 3.1-3.7, 4.1-4.5, 5.1-5.5, 6.1-6.5, 7.1-7.5)
-	// Task 8.1: Wire all components together - Connect handlers → service → repositories → storage
-	htmlSanitizer := sanitizer.NewHTMLSanitizer()
-	baseURL := fmt.Sprintf("https://%s:%s/api/v1", cfg.Server.Host, cfg.Server.Port)
-	if cfg.Server.Host == "0.0.0.0" || cfg.Server.Host == "localhost" {
-		baseURL = fmt.Sprintf("http://localhost:%s/api/v1", cfg.Server.Port)
-	}
-
-	emailService := email.NewService(email.ServiceConfig{
-		EmailRepo:      emailRepo,
-		AttachmentRepo: attachmentRepo,
-		StorageService: storageService,
-		Sanitizer:      htmlSanitizer,
-		Logger:         appLogger,
-		BaseURL:        baseURL,
-	})
-
 	// Initialize SSE components for real-time notifications
 	// Requirements: All realtime-notifications requirements
 	// Task 11.1: Wire all components together - Connect SSE handler → connection manager → event bus
@@ -279,6 +271,63 @@ func main() {
 		slog.Int("buffer_size", sseConfig.EventBufferSize),
 	)
 
+	// Initialize email service
+	// Requirements: All email inbox API requirements (1.1-1.9, 2.1-2.8, 3.1-3.7, 4.1-4.5, 5.1-5.5, 6.1-6.5, 7.1-7.5)
+	// Task 8.1: Wire all components together - Connect handlers → service → repositories → storage
+	htmlSanitizer := sanitizer.NewHTMLSanitizer()
+	baseURL := fmt.Sprintf("https://%s:%s/api/v1", cfg.Server.Host, cfg.Server.Port)
+	if cfg.Server.Host == "0.0.0.0" || cfg.Server.Host == "localhost" {
+		baseURL = fmt.Sprintf("http://localhost:%s/api/v1", cfg.Server.Port)
+	}
+
+	emailService := email.NewService(email.ServiceConfig{
+		EmailRepo:      emailRepo,
+		AttachmentRepo: attachmentRepo,
+		StorageService: storageService,
+		Sanitizer:      htmlSanitizer,
+		EventBus:       eventBus,
+		Logger:         appLogger,
+		BaseURL:        baseURL,
+		TrashRetention: time.Duration(cfg.Email.TrashRetentionDays) * 24 * time.Hour,
+	})
+
+	// Return snoozed emails to the inbox once their time passes
+	snoozeScheduler := email.NewSnoozeScheduler(email.SnoozeSchedulerConfig{
+		Repository: emailRepo,
+		EventBus:   eventBus,
+		Interval:   cfg.Email.SnoozeInterval,
+		Logger:     appLogger,
+	})
+	if err := snoozeScheduler.Start(); err != nil {
+		appLogger.Warn("Failed to start snooze scheduler",
+			slog.String("error", err.Error()),
+		)
+	}
+
+	// Permanently delete trashed emails once the trash retention passes
+	trashPurger := email.NewTrashPurger(email.TrashPurgerConfig{
+		Service:  emailService,
+		Interval: cfg.Email.TrashPurgeInterval,
+		Logger:   appLogger,
+	})
+	if err := trashPurger.Start(); err != nil {
+		appLogger.Warn("Failed to start trash purger",
+			slog.String("error", err.Error()),
+		)
+	}
+
+	// Permanently delete emails expired by alias and domain retention policies
+	retentionScheduler := email.NewRetentionScheduler(email.RetentionSchedulerConfig{
+		Service:  emailService,
+		Interval: cfg.Email.RetentionInterval,
+		Logger:   appLogger,
+	})
+	if err := retentionScheduler.Start(); err != nil {
+		appLogger.Warn("Failed to start retention scheduler",
+			slog.String("error", err.Error()),
+		)
+	}
+
 	// Initialize SSL certificate management components
 	// Requirements: 1.1, 3.1, 4.1 - SSL certificate provisioning, renewal, and STARTTLS
 	var certMgmtService ssl.SSLService
@@ -293,11 +342,99 @@ func main() {
 	}
 
 	// Initialize handlers
-	authHandler := auth.NewAuthHandler(authService)
+	authHandler := auth.NewAuthHandlerWithAppPasswords(authService, appPasswordService)
 	domainHandler := api.NewDomainHandler(domainService, appLogger)
 	aliasHandler := alias.NewHandler(aliasService, appLogger)
 	emailHandler := email.NewHandler(emailService, appLogger)
 
+	// Initialize SMTP transcript search (domain owners only)
+	transcriptService := transcript.NewService(transcript.ServiceConfig{
+		Repository: transcript.NewPostgresRepository(dbPool, cfg.SMTP.TranscriptMaxPerDomain),
+		DomainRepo: domainRepo,
+		Logger:     appLogger,
+	})
+	transcriptHandler := transcript.NewHandler(transcriptService, appLogger)
+
+	// Initialize user-defined email labels
+	labelService := label.NewService(label.ServiceConfig{
+		Repository: label.NewPostgresRepository(dbPool),
+		EventBus:   eventBus,
+		Logger:     appLogger,
+	})
+	labelHandler := label.NewHandler(labelService, appLogger)
+
+	// Initialize alias retention policies with domain defaults
+	retentionService := retention.NewService(retention.ServiceConfig{
+		Repository: retention.NewPostgresRepository(dbPool),
+		Logger:     appLogger,
+	})
+	retentionHandler := retention.NewHandler(retentionService, appLogger)
+
+	// Initialize saved searches, run through the email service and matched against new emails
+	savedSearchService := savedsearch.NewService(savedsearch.ServiceConfig{
+		Repository: savedsearch.NewPostgresRepository(dbPool),
+		Emails:     emailService,
+		Logger:     appLogger,
+	})
+	savedSearchHandler := savedsearch.NewHandler(savedSearchService, appLogger)
+
+	// Initialize mailbox exports, built by background workers and stored next to attachments
+	exportConfig := export.ServiceConfig{
+		Repository:     export.NewPostgresRepository(dbPool),
+		EmailRepo:      emailRepo,
+		AttachmentRepo: attachmentRepo,
+		AliasRepo:      aliasRepo,
+		EventBus:       eventBus,
+		Workers:        cfg.Export.Workers,
+		Retention:      cfg.Export.Retention,
+		URLExpiry:      cfg.Export.URLExpiry,
+		Logger:         appLogger,
+	}
+	if storageService != nil {
+		exportConfig.Storage = storageService
+	}
+	exportService := export.NewService(exportConfig)
+	if err := exportService.Start(); err != nil {
+		appLogger.Warn("Failed to start export workers",
+			slog.String("error", err.Error()),
+		)
+	}
+	exportHandler := export.NewHandler(exportService, appLogger)
+
+	// Initialize mailbox imports, stored through the same processor as received mail
+	// Imported messages publish no new_email events; progress is sent as import_progress events instead.
+	importConfig := importer.ServiceConfig{
+		Repository:     importer.NewPostgresRepository(dbPool),
+		AliasRepo:      aliasRepo,
+		EventBus:       eventBus,
+		Workers:        cfg.Import.Workers,
+		MaxUploadSize:  cfg.Import.MaxUploadSize,
+		MaxMessageSize: cfg.SMTP.MaxMessageSize,
+		Logger:         appLogger,
+	}
+	if storageService != nil {
+		importConfig.Storage = storageService
+		importConfig.Processor = smtp.NewEmailProcessor(smtp.ProcessorConfig{
+			Parser: parser.NewEmailParser(),
+			AttachmentHandler: attachment.NewHandler(
+				storageService.GetClient(),
+				storageService.GetBucket(),
+			),
+			EmailRepo:      smtp.NewPgxEmailRepository(dbPool),
+			AttachmentRepo: smtp.NewPgxAttachmentRepository(dbPool),
+			AliasRepo:      smtp.NewPgxAliasRepository(dbPool),
+			EventPublisher: smtp.NewNoOpEventPublisher(),
+			Logger:         slog.NewLogLogger(appLogger.Handler(), slog.LevelInfo),
+		})
+	}
+	importService := importer.NewService(importConfig)
+	if err := importService.Start(); err != nil {
+		appLogger.Warn("Failed to start import workers",
+			slog.String("error", err.Error()),
+		)
+	}
+	importHandler := importer.NewHandler(importService, appLogger)
+
 	// Initialize SSL handler if SSL is enabled
 	// Requirements: 3.7 - SSL API endpoints
 	var sslHandler *ssl.SSLHandler
@@ -362,6 +499,9 @@ func main() {
 		// Requirements: 1.1, 1.2 - SSE Connection endpoint with authentication
 		sse.RegisterRoutes(r, sseHandler)
 
+		// Import routes WITHOUT timeout middleware (archive uploads can take minutes)
+		importer.RegisterRoutes(r, importHandler, authMiddleware.Authenticate)
+
 		// All other routes WITH timeout middleware
 		r.Group(func(r chi.Router) {
 			r.Use(middleware.Timeout(60 * time.Second))
@@ -389,6 +529,9 @@ func main() {
 					r.Post("/{id}/ssl/provision", sslHandler.ProvisionSSL)
 					r.Post("/{id}/ssl/renew", sslHandler.RenewSSL)
 				}
+
+				// SMTP session transcript settings and search
+				transcript.RegisterDomainRoutes(r, transcriptHandler)
 			})
 
 			// SSL health check endpoint (public)
@@ -405,6 +548,18 @@ func main() {
 			// Requirements: All email inbox API endpoints (1.1-1.9, 2.1-2.8, 3.1-3.7, 4.1-4.5, 5.1-5.5, 6.1-6.5, 7.1-7.5)
 			// Requirements: 6.7 - Rate limit downloads to 100 per user per hour
 			email.RegisterRoutesWithRateLimit(r, emailHandler, authMiddleware.Authenticate, attachmentDownloadRateLimiter.RateLimitDownload)
+
+			// Register label routes
+			label.RegisterRoutes(r, labelHandler, authMiddleware.Authenticate)
+
+			// Register alias and domain retention policy routes
+			retention.RegisterRoutes(r, retentionHandler, authMiddleware.Authenticate)
+
+			// Register saved search routes
+			savedsearch.RegisterRoutes(r, savedSearchHandler, authMiddleware.Authenticate)
+
+			// Register mailbox export routes
+			export.RegisterRoutes(r, exportHandler, authMiddleware.Authenticate)
 		})
 	})
 
@@ -436,7 +591,7 @@ func main() {
 	// Task 11.1: Wire all components together
 	var smtpServer *smtp.SMTPServer
 	if cfg.SMTP.Port > 0 {
-		smtpServer, err = setupSMTPServer(cfg, dbPool, storageService, eventBus, certMgmtService, appLogger)
+		smtpServer, err = setupSMTPServer(cfg, dbPool, storageService, eventBus, certMgmtService, savedSearchService, appLogger)
 		if err != nil {
 			appLogger.Warn("Failed to initialize SMTP server",
 				slog.String("error", err.Error()),
@@ -457,6 +612,27 @@ func main() {
 		appLogger.Info("SMTP server disabled (SMTP_PORT not configured)")
 	}
 
+	// Initialize authenticated submission listener (STARTTLS + AUTH with app passwords)
+	// Shares the inbound server's TLS configuration
+	var submissionServer *smtp.SMTPServer
+	if cfg.SMTP.SubmissionPort > 0 && smtpServer != nil {
+		submissionServer, err = setupSubmissionServer(cfg, dbPool, smtpServer.GetTLSConfig(), appPasswordService, appLogger)
+		if err != nil {
+			appLogger.Warn("Failed to initialize SMTP submission server",
+				slog.String("error", err.Error()),
+			)
+		} else if err := submissionServer.Start(); err != nil {
+			appLogger.Warn("Failed to start SMTP submission server",
+				slog.String("error", err.Error()),
+			)
+			submissionServer = nil
+		} else {
+			appLogger.Info("SMTP submission server started",
+				slog.Int("port", cfg.SMTP.SubmissionPort),
+			)
+		}
+	}
+
 	// Register SMTP health endpoint after SMTP server is initialized
 	// Requirements: 10.5 - SMTP health check endpoint
 	smtpHealthHandler := health.NewSMTPHandler(health.SMTPHandlerConfig{
@@ -488,12 +664,28 @@ func main() {
 		orphanCleanupJob.Stop()
 	}
 
+	// Stop export workers; interrupted exports are requeued on the next start
+	exportService.Stop()
+
+	// Stop import workers; interrupted imports resume on the next start
+	importService.Stop()
+
+	// Stop snooze scheduler; emails still due are woken on the next start
+	snoozeScheduler.Stop()
+
+	// Stop trash purger; expired emails are purged on the next start
+	trashPurger.Stop()
+
+	// Stop retention scheduler; expired emails are deleted on the next start
+	retentionScheduler.Stop()
+
 	// Stop SSL renewal scheduler
 	if renewalScheduler != nil {
 		renewalScheduler.Stop()
 	}
 
-	// Stop SMTP server first
+	// Drain SMTP server first: new sessions get 421, in-flight transactions may
+	// finish within SMTP_DRAIN_TIMEOUT
 	if smtpServer != nil {
 		if err := smtpServer.Stop(); err != nil {
 			appLogger.Error("Error stopping SMTP server",
@@ -501,6 +693,13 @@ func main() {
 			)
 		}
 	}
+	if submissionServer != nil {
+		if err := submissionServer.Stop(); err != nil {
+			appLogger.Error("Error stopping SMTP submission server",
+				slog.String("error", err.Error()),
+			)
+		}
+	}
 
 	if err := srv.Shutdown(ctx); err != nil {
 		appLogger.Error("HTTP server forced to shutdown",
@@ -568,7 +767,7 @@ func setupDatabase(cfg *config.Config, log *slog.Logger) (*pgxpool.Pool, error)
 // setupSMTPServer creates and configures the SMTP server with all components wired together
 // Requirements: All SMTP email receiver requirements
 // Task 11.1: Wire all components together - Connect SMTP server → parser → attachment handler → repositories → event bus
-func setupSMTPServer(cfg *config.Config, dbPool *pgxpool.Pool, storageService *storage.StorageService, eventBus *events.InMemoryEventBus, sslService ssl.SSLService, log *slog.Logger) (*smtp.SMTPServer, error) {
+func setupSMTPServer(cfg *config.Config, dbPool *pgxpool.Pool, storageService *storage.StorageService, eventBus *events.InMemoryEventBus, sslService ssl.SSLService, searchMatcher smtp.SavedSearchMatcher, log *slog.Logger) (*smtp.SMTPServer, error) {
 	// Create SMTP configuration from app config
 	smtpConfig := &smtp.SMTPConfig{
 		Port:                cfg.SMTP.Port,
@@ -579,6 +778,7 @@ func setupSMTPServer(cfg *config.Config, dbPool *pgxpool.Pool, storageService *s
 		MaxMessageSize:      cfg.SMTP.MaxMessageSize,
 		MaxRecipients:       cfg.SMTP.MaxRecipients,
 		RateLimitPerMinute:  cfg.SMTP.RateLimitPerMinute,
+		DrainTimeout:        cfg.SMTP.DrainTimeout,
 	}
 
 	// Setup TLS configuration if enabled
@@ -610,6 +810,12 @@ func setupSMTPServer(cfg *config.Config, dbPool *pgxpool.Pool, storageService *s
 	// Create SMTP server
 	smtpServer := smtp.NewSMTPServer(smtpConfig, tlsConfig, aliasRepo)
 
+	// Enable opt-in session transcript capture
+	if cfg.SMTP.TranscriptEnabled {
+		smtpServer.SetTranscriptStore(transcript.NewPostgresRepository(dbPool, cfg.SMTP.TranscriptMaxPerDomain))
+		log.Info("SMTP transcript capture enabled for opted-in domains and sender IPs")
+	}
+
 	// Create email processor components
 	// Requirements: 4.1-4.12 - Email parsing
 	emailParser := parser.NewEmailParser()
@@ -653,6 +859,7 @@ func setupSMTPServer(cfg *config.Config, dbPool *pgxpool.Pool, storageService *s
 		AttachmentRepo:    attachmentRepo,
 		AliasRepo:         aliasRepo,
 		EventPublisher:    eventPublisher,
+		SearchMatcher:     searchMatcher,
 		Logger:            stdLogger,
 	})
 
@@ -848,3 +1055,38 @@ func setupRedis(cfg *config.Config, log *slog.Logger) *redis.Client {
 
 	return client
 }
+
+// setupSubmissionServer creates the authenticated submission listener
+// Clients must STARTTLS and AUTH with an app password; MAIL FROM is limited to the
+// user's own aliases and accepted messages are queued in outbound_messages
+func setupSubmissionServer(cfg *config.Config, dbPool *pgxpool.Pool, tlsConfig *tls.Config, authenticator smtp.SubmissionAuthenticator, log *slog.Logger) (*smtp.SMTPServer, error) {
+	if tlsConfig == nil {
+		return nil, fmt.Errorf("submission requires STARTTLS but no TLS configuration is available")
+	}
+
+	submissionConfig := &smtp.SMTPConfig{
+		Port:                cfg.SMTP.SubmissionPort,
+		Hostname:            cfg.SMTP.Hostname,
+		MaxConnections:      cfg.SMTP.MaxConnections,
+		MaxConnectionsPerIP: cfg.SMTP.MaxConnectionsPerIP,
+		ConnectionTimeout:   cfg.SMTP.ConnectionTimeout,
+		MaxMessageSize:      cfg.SMTP.MaxMessageSize,
+		MaxRecipients:       cfg.SMTP.MaxRecipients,
+		RateLimitPerMinute:  cfg.SMTP.RateLimitPerMinute,
+		DrainTimeout:        cfg.SMTP.DrainTimeout,
+	}
+
+	aliasRepo := smtp.NewPgxAliasRepository(dbPool)
+	submissionServer := smtp.NewSMTPServer(submissionConfig, tlsConfig, aliasRepo)
+	submissionServer.SetSubmission(&smtp.SubmissionOptions{
+		Authenticator: authenticator,
+		Senders:       aliasRepo,
+		Queue:         smtp.NewPgxOutboundQueue(dbPool),
+	})
+
+	log.Info("SMTP submission server configured",
+		slog.Int("port", cfg.SMTP.SubmissionPort),
+	)
+
+	return submissionServer, nil
+}
@@ -11,14 +11,23 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	_ "github.com/jackc/pgx/v5/stdlib" // pgx driver for sqlx
+	"github.com/jmoiron/sqlx"
 	"github.com/welldanyogia/persistent-temp-mail/backend/internal/attachment"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/auth"
 	"github.com/welldanyogia/persistent-temp-mail/backend/internal/config"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/email"
 	"github.com/welldanyogia/persistent-temp-mail/backend/internal/events"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/imap"
 	"github.com/welldanyogia/persistent-temp-mail/backend/internal/logger"
 	"github.com/welldanyogia/persistent-temp-mail/backend/internal/parser"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/pop3"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/repository"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/savedsearch"
 	"github.com/welldanyogia/persistent-temp-mail/backend/internal/smtp"
 	"github.com/welldanyogia/persistent-temp-mail/backend/internal/ssl"
 	"github.com/welldanyogia/persistent-temp-mail/backend/internal/storage"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/transcript"
 )
 
 func main() {
@@ -75,8 +84,35 @@ func main() {
 		sslService = setupSSLService(cfg, dbPool, appLogger)
 	}
 
+	// The email service runs saved searches against new mail, and IMAP and POP3 delete mail through it so
+	// deleted mail goes to the trash exactly as it does when deleting through the API
+	var emailService *email.Service
+	sqlxDB, err := setupSqlxDatabase(cfg, appLogger)
+	if err != nil {
+		appLogger.Warn("Failed to connect to database with sqlx - saved search matching, IMAP and POP3 will be disabled", slog.String("error", err.Error()))
+	} else {
+		defer sqlxDB.Close()
+		emailService = email.NewService(email.ServiceConfig{
+			EmailRepo:      repository.NewEmailRepo(sqlxDB),
+			AttachmentRepo: repository.NewAttachmentRepository(sqlxDB),
+			StorageService: storageService,
+			EventBus:       eventBus,
+			Logger:         appLogger,
+		})
+	}
+
+	// new_email events list the saved searches that match the email, as they do in the API server
+	var searchMatcher smtp.SavedSearchMatcher
+	if emailService != nil {
+		searchMatcher = savedsearch.NewService(savedsearch.ServiceConfig{
+			Repository: savedsearch.NewPostgresRepository(dbPool),
+			Emails:     emailService,
+			Logger:     appLogger,
+		})
+	}
+
 	// Setup and start SMTP server
-	smtpServer, err := setupSMTPServer(cfg, dbPool, storageService, eventBus, sslService, appLogger)
+	smtpServer, err := setupSMTPServer(cfg, dbPool, storageService, eventBus, sslService, searchMatcher, appLogger)
 	if err != nil {
 		appLogger.Error("Failed to initialize SMTP server", slog.String("error", err.Error()))
 		os.Exit(1)
@@ -92,12 +128,86 @@ func main() {
 		slog.String("hostname", cfg.SMTP.Hostname),
 	)
 
+	// Mail clients (submission, IMAP and POP3) authenticate with app passwords, verified with the
+	// same repositories the API uses
+	appPasswordService := auth.NewAppPasswordService(
+		repository.NewUserRepository(dbPool),
+		repository.NewAppPasswordRepository(dbPool),
+		repository.NewSessionRepository(dbPool),
+		auth.NewPasswordValidator(),
+	)
+
+	// Setup and start the authenticated submission listener
+	var submissionServer *smtp.SMTPServer
+	if cfg.SMTP.SubmissionPort > 0 {
+		submissionServer, err = setupSubmissionServer(cfg, dbPool, smtpServer.GetTLSConfig(), appPasswordService, appLogger)
+		if err != nil {
+			appLogger.Warn("Failed to initialize SMTP submission server", slog.String("error", err.Error()))
+		} else if err := submissionServer.Start(); err != nil {
+			appLogger.Warn("Failed to start SMTP submission server", slog.String("error", err.Error()))
+			submissionServer = nil
+		} else {
+			appLogger.Info("SMTP submission server started", slog.Int("port", cfg.SMTP.SubmissionPort))
+		}
+	}
+
+	// Setup and start the IMAP server; it runs in this process so IDLE sees new mail
+	// as soon as the SMTP processor publishes it on the event bus. The bus is in-memory, so
+	// changes made through the API server only show up on IDLE's periodic rescan.
+	var imapServer *imap.Server
+	if cfg.IMAP.Port > 0 && emailService != nil {
+		imapServer, err = setupIMAPServer(cfg, dbPool, emailService, eventBus, smtpServer.GetTLSConfig(), appPasswordService, appLogger)
+		if err != nil {
+			appLogger.Warn("Failed to initialize IMAP server", slog.String("error", err.Error()))
+		} else if err := imapServer.Start(); err != nil {
+			appLogger.Warn("Failed to start IMAP server", slog.String("error", err.Error()))
+			imapServer = nil
+		} else {
+			appLogger.Info("IMAP server started", slog.Int("port", cfg.IMAP.Port))
+		}
+	}
+
+	// Setup and start the POP3 server
+	var pop3Server *pop3.Server
+	if cfg.POP3.Port > 0 && emailService != nil {
+		pop3Server, err = setupPOP3Server(cfg, dbPool, emailService, smtpServer.GetTLSConfig(), appPasswordService, appLogger)
+		if err != nil {
+			appLogger.Warn("Failed to initialize POP3 server", slog.String("error", err.Error()))
+		} else if err := pop3Server.Start(); err != nil {
+			appLogger.Warn("Failed to start POP3 server", slog.String("error", err.Error()))
+			pop3Server = nil
+		} else {
+			appLogger.Info("POP3 server started", slog.Int("port", cfg.POP3.Port))
+		}
+	}
+
 	// Graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	appLogger.Info("Shutting down SMTP server...")
+	appLogger.Info("Draining SMTP server...",
+		slog.Duration("drain_timeout", cfg.SMTP.DrainTimeout),
+		slog.Int64("active_transactions", smtpServer.GetActiveTransactions()),
+	)
+
+	if pop3Server != nil {
+		if err := pop3Server.Stop(); err != nil {
+			appLogger.Error("Error stopping POP3 server", slog.String("error", err.Error()))
+		}
+	}
+
+	if imapServer != nil {
+		if err := imapServer.Stop(); err != nil {
+			appLogger.Error("Error stopping IMAP server", slog.String("error", err.Error()))
+		}
+	}
+
+	if submissionServer != nil {
+		if err := submissionServer.Stop(); err != nil {
+			appLogger.Error("Error stopping SMTP submission server", slog.String("error", err.Error()))
+		}
+	}
 
 	if err := smtpServer.Stop(); err != nil {
 		appLogger.Error("Error stopping SMTP server", slog.String("error", err.Error()))
@@ -152,7 +262,7 @@ func setupDatabase(cfg *config.Config, log *slog.Logger) (*pgxpool.Pool, error)
 }
 
 // setupSMTPServer creates and configures the SMTP server
-func setupSMTPServer(cfg *config.Config, dbPool *pgxpool.Pool, storageService *storage.StorageService, eventBus *events.InMemoryEventBus, sslService ssl.SSLService, log *slog.Logger) (*smtp.SMTPServer, error) {
+func setupSMTPServer(cfg *config.Config, dbPool *pgxpool.Pool, storageService *storage.StorageService, eventBus *events.InMemoryEventBus, sslService ssl.SSLService, searchMatcher smtp.SavedSearchMatcher, log *slog.Logger) (*smtp.SMTPServer, error) {
 	smtpConfig := &smtp.SMTPConfig{
 		Port:                cfg.SMTP.Port,
 		Hostname:            cfg.SMTP.Hostname,
@@ -162,6 +272,7 @@ func setupSMTPServer(cfg *config.Config, dbPool *pgxpool.Pool, storageService *s
 		MaxMessageSize:      cfg.SMTP.MaxMessageSize,
 		MaxRecipients:       cfg.SMTP.MaxRecipients,
 		RateLimitPerMinute:  cfg.SMTP.RateLimitPerMinute,
+		DrainTimeout:        cfg.SMTP.DrainTimeout,
 	}
 
 	// Setup TLS configuration
@@ -185,6 +296,11 @@ func setupSMTPServer(cfg *config.Config, dbPool *pgxpool.Pool, storageService *s
 	// Create SMTP server
 	smtpServer := smtp.NewSMTPServer(smtpConfig, tlsConfig, aliasRepo)
 
+	// Enable opt-in session transcript capture
+	if cfg.SMTP.TranscriptEnabled {
+		smtpServer.SetTranscriptStore(transcript.NewPostgresRepository(dbPool, cfg.SMTP.TranscriptMaxPerDomain))
+	}
+
 	// Create email processor components
 	emailParser := parser.NewEmailParser()
 
@@ -218,6 +334,7 @@ func setupSMTPServer(cfg *config.Config, dbPool *pgxpool.Pool, storageService *s
 		AttachmentRepo:    attachmentRepo,
 		AliasRepo:         aliasRepo,
 		EventPublisher:    eventPublisher,
+		SearchMatcher:     searchMatcher,
 		Logger:            stdLogger,
 	})
 
@@ -239,6 +356,131 @@ func setupSMTPServer(cfg *config.Config, dbPool *pgxpool.Pool, storageService *s
 	return smtpServer, nil
 }
 
+// setupSubmissionServer creates the authenticated submission listener
+// Clients must STARTTLS and AUTH with an app password; accepted mail is queued for outbound delivery
+func setupSubmissionServer(cfg *config.Config, dbPool *pgxpool.Pool, tlsConfig *tls.Config, authenticator smtp.SubmissionAuthenticator, log *slog.Logger) (*smtp.SMTPServer, error) {
+	if tlsConfig == nil {
+		return nil, fmt.Errorf("submission requires STARTTLS but no TLS configuration is available")
+	}
+
+	submissionConfig := &smtp.SMTPConfig{
+		Port:                cfg.SMTP.SubmissionPort,
+		Hostname:            cfg.SMTP.Hostname,
+		MaxConnections:      cfg.SMTP.MaxConnections,
+		MaxConnectionsPerIP: cfg.SMTP.MaxConnectionsPerIP,
+		ConnectionTimeout:   cfg.SMTP.ConnectionTimeout,
+		MaxMessageSize:      cfg.SMTP.MaxMessageSize,
+		MaxRecipients:       cfg.SMTP.MaxRecipients,
+		RateLimitPerMinute:  cfg.SMTP.RateLimitPerMinute,
+		DrainTimeout:        cfg.SMTP.DrainTimeout,
+	}
+
+	aliasRepo := smtp.NewPgxAliasRepository(dbPool)
+	submissionServer := smtp.NewSMTPServer(submissionConfig, tlsConfig, aliasRepo)
+	submissionServer.SetSubmission(&smtp.SubmissionOptions{
+		Authenticator: authenticator,
+		Senders:       aliasRepo,
+		Queue:         smtp.NewPgxOutboundQueue(dbPool),
+	})
+
+	log.Info("SMTP submission server configured", slog.Int("port", cfg.SMTP.SubmissionPort))
+
+	return submissionServer, nil
+}
+
+// setupIMAPServer creates the IMAP server exposing one mailbox per alias
+// LOGIN accepts app passwords or JWT access tokens; EXPUNGE moves mail to the trash through the email
+// service and publishes email_deleted; the API server's trash purger removes it and its attachments later
+func setupIMAPServer(cfg *config.Config, dbPool *pgxpool.Pool, emailService *email.Service, eventBus *events.InMemoryEventBus, tlsConfig *tls.Config, appPasswordService *auth.AppPasswordService, log *slog.Logger) (*imap.Server, error) {
+	if tlsConfig == nil {
+		return nil, fmt.Errorf("IMAP requires STARTTLS but no TLS configuration is available")
+	}
+
+	tokenService := auth.NewTokenService(auth.TokenServiceConfig{
+		AccessSecret:       cfg.JWT.AccessSecret,
+		RefreshSecret:      cfg.JWT.RefreshSecret,
+		AccessTokenExpiry:  cfg.JWT.AccessTokenExpiry,
+		RefreshTokenExpiry: cfg.JWT.RefreshTokenExpiry,
+		Issuer:             cfg.JWT.Issuer,
+	})
+
+	imapConfig := &imap.Config{
+		Hostname:            cfg.SMTP.Hostname,
+		Port:                cfg.IMAP.Port,
+		IdleTimeout:         cfg.IMAP.IdleTimeout,
+		MaxConnections:      cfg.IMAP.MaxConnections,
+		MaxConnectionsPerIP: cfg.IMAP.MaxConnectionsPerIP,
+	}
+
+	imapServer := imap.NewServer(
+		imapConfig,
+		tlsConfig,
+		imap.NewPgxStore(dbPool, emailService),
+		auth.NewMailClientAuthenticator(appPasswordService, tokenService),
+		eventBus,
+	)
+
+	log.Info("IMAP server configured", slog.Int("port", cfg.IMAP.Port))
+
+	return imapServer, nil
+}
+
+// setupPOP3Server creates the POP3 server exposing a user's mail, or one alias, as a maildrop
+// USER/PASS accepts app passwords only; DELE is applied through the email service at QUIT
+func setupPOP3Server(cfg *config.Config, dbPool *pgxpool.Pool, emailService *email.Service, tlsConfig *tls.Config, appPasswordService *auth.AppPasswordService, log *slog.Logger) (*pop3.Server, error) {
+	if tlsConfig == nil {
+		return nil, fmt.Errorf("POP3 requires STLS but no TLS configuration is available")
+	}
+
+	pop3Config := &pop3.Config{
+		Hostname:            cfg.SMTP.Hostname,
+		Port:                cfg.POP3.Port,
+		IdleTimeout:         cfg.POP3.IdleTimeout,
+		MaxConnections:      cfg.POP3.MaxConnections,
+		MaxConnectionsPerIP: cfg.POP3.MaxConnectionsPerIP,
+	}
+
+	pop3Server := pop3.NewServer(
+		pop3Config,
+		tlsConfig,
+		pop3.NewPgxStore(dbPool, emailService),
+		appPasswordService,
+	)
+
+	log.Info("POP3 server configured", slog.Int("port", cfg.POP3.Port))
+
+	return pop3Server, nil
+}
+
+// setupSqlxDatabase creates the sqlx connection used by the email service
+func setupSqlxDatabase(cfg *config.Config, log *slog.Logger) (*sqlx.DB, error) {
+	// Simple protocol mode for Supabase/PgBouncer compatibility
+	connString := fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s default_query_exec_mode=simple_protocol",
+		cfg.Database.Host,
+		cfg.Database.Port,
+		cfg.Database.User,
+		cfg.Database.Password,
+		cfg.Database.DBName,
+		cfg.Database.SSLMode,
+	)
+
+	db, err := sqlx.Connect("pgx", connString)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database with sqlx: %w", err)
+	}
+
+	db.SetMaxOpenConns(10)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(5 * time.Minute)
+
+	log.Info("Connected to database with sqlx",
+		slog.String("database", cfg.Database.DBName),
+		slog.String("host", cfg.Database.Host),
+	)
+	return db, nil
+}
+
 // setupSSLService creates the SSL service for TLS certificates
 func setupSSLService(cfg *config.Config, dbPool *pgxpool.Pool, log *slog.Logger) ssl.SSLService {
 	encryptionKey := cfg.SSL.GetEncryptionKey()
@@ -89,6 +89,7 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 }
 
 // List handles GET /api/v1/aliases
+// The cursor parameter pages from a previous response's next_cursor or prev_cursor instead of by page number
 // Requirements: 2.1-2.6
 func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 	userIDStr, ok := appctx.ExtractUserID(r.Context())
@@ -128,6 +129,10 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 		params.DomainID = domainID
 	}
 
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		params.Cursor = cursor
+	}
+
 	if search := r.URL.Query().Get("search"); search != "" {
 		if len(search) <= 64 {
 			params.Search = search
@@ -148,6 +153,10 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 
 	response, err := h.aliasService.List(r.Context(), userID, params)
 	if err != nil {
+		if errors.Is(err, ErrInvalidCursor) {
+			h.writeError(w, http.StatusBadRequest, CodeValidationError, "Invalid or expired cursor for this sort", nil)
+			return
+		}
 		h.logger.Error("Failed to list aliases", "error", err, "user_id", userID)
 		h.writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list aliases", nil)
 		return
@@ -29,6 +29,7 @@ var (
 	ErrDomainNotVerified  = errors.New("domain not verified")
 	ErrAccessDenied       = errors.New("access denied")
 	ErrValidationFailed   = errors.New("validation failed")
+	ErrInvalidCursor      = errors.New("invalid pagination cursor")
 )
 
 // Error codes for API responses
@@ -69,6 +70,7 @@ type ListAliasParams struct {
 	Search   string `json:"search,omitempty" validate:"omitempty,max=64"`
 	Sort     string `json:"sort,omitempty" validate:"omitempty,oneof=created_at email_count"`
 	Order    string `json:"order,omitempty" validate:"omitempty,oneof=asc desc"`
+	Cursor   string `json:"cursor,omitempty"` // next_cursor or prev_cursor of a previous page; Page is ignored when set
 }
 
 
@@ -114,11 +116,14 @@ type AliasListResponse struct {
 }
 
 // Pagination represents pagination metadata
+// Lists page by offset or by cursor; the cursors of the neighboring pages are returned in both modes.
 type Pagination struct {
-	CurrentPage int `json:"current_page"`
-	PerPage     int `json:"per_page"`
-	TotalPages  int `json:"total_pages"`
-	TotalCount  int `json:"total_count"`
+	CurrentPage int    `json:"current_page"` // 0 when paging by cursor
+	PerPage     int    `json:"per_page"`
+	TotalPages  int    `json:"total_pages"`
+	TotalCount  int    `json:"total_count"`
+	NextCursor  string `json:"next_cursor,omitempty"`
+	PrevCursor  string `json:"prev_cursor,omitempty"`
 }
 
 // DeleteAliasResponse represents the response after deleting an alias
@@ -333,9 +338,23 @@ func (s *Service) List(ctx context.Context, userID uuid.UUID, params ListAliasPa
 		repoParams.DomainID = &domainID
 	}
 
+	// Parse cursor if provided
+	currentPage := params.Page
+	if params.Cursor != "" {
+		cursor, err := repository.DecodeCursor(params.Cursor)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		repoParams.Cursor = cursor
+		currentPage = 0
+	}
+
 	// Get aliases from repository
-	aliases, totalCount, err := s.aliasRepo.List(ctx, userID, repoParams)
+	aliases, totalCount, cursors, err := s.aliasRepo.List(ctx, userID, repoParams)
 	if err != nil {
+		if errors.Is(err, repository.ErrInvalidCursor) {
+			return nil, ErrInvalidCursor
+		}
 		return nil, fmt.Errorf("failed to list aliases: %w", err)
 	}
 
@@ -366,10 +385,12 @@ func (s *Service) List(ctx context.Context, userID uuid.UUID, params ListAliasPa
 	return &AliasListResponse{
 		Aliases: aliasResponses,
 		Pagination: Pagination{
-			CurrentPage: params.Page,
+			CurrentPage: currentPage,
 			PerPage:     params.Limit,
 			TotalPages:  totalPages,
 			TotalCount:  totalCount,
+			NextCursor:  cursors.Next,
+			PrevCursor:  cursors.Prev,
 		},
 	}, nil
 }
@@ -146,7 +146,7 @@ func (m *MockAliasRepository) GetByFullAddress(ctx context.Context, fullAddress
 	}, nil
 }
 
-func (m *MockAliasRepository) List(ctx context.Context, userID uuid.UUID, params repository.ListAliasParams) ([]repository.AliasWithStats, int, error) {
+func (m *MockAliasRepository) List(ctx context.Context, userID uuid.UUID, params repository.ListAliasParams) ([]repository.AliasWithStats, int, repository.PageCursors, error) {
 	var result []repository.AliasWithStats
 	for _, alias := range m.aliases {
 		if alias.UserID != userID {
@@ -185,13 +185,13 @@ func (m *MockAliasRepository) List(ctx context.Context, userID uuid.UUID, params
 	end := start + limit
 
 	if start >= len(result) {
-		return []repository.AliasWithStats{}, totalCount, nil
+		return []repository.AliasWithStats{}, totalCount, repository.PageCursors{}, nil
 	}
 	if end > len(result) {
 		end = len(result)
 	}
 
-	return result[start:end], totalCount, nil
+	return result[start:end], totalCount, repository.PageCursors{}, nil
 }
 
 func (m *MockAliasRepository) Update(ctx context.Context, alias *repository.Alias) error {
@@ -281,7 +281,7 @@ type AliasRepositoryInterface interface {
 	Create(ctx context.Context, alias *repository.Alias) error
 	GetByID(ctx context.Context, id uuid.UUID) (*repository.AliasWithStats, error)
 	GetByFullAddress(ctx context.Context, fullAddress string) (*repository.AliasWithStats, error)
-	List(ctx context.Context, userID uuid.UUID, params repository.ListAliasParams) ([]repository.AliasWithStats, int, error)
+	List(ctx context.Context, userID uuid.UUID, params repository.ListAliasParams) ([]repository.AliasWithStats, int, repository.PageCursors, error)
 	Update(ctx context.Context, alias *repository.Alias) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	CountByUserID(ctx context.Context, userID uuid.UUID) (int, error)
@@ -832,7 +832,7 @@ func (s *TestableService) List(ctx context.Context, userID uuid.UUID, params Lis
 	}
 
 	// Get aliases from repository
-	aliases, totalCount, err := s.aliasRepo.List(ctx, userID, repoParams)
+	aliases, totalCount, _, err := s.aliasRepo.List(ctx, userID, repoParams)
 	if err != nil {
 		return nil, err
 	}
@@ -10,7 +10,6 @@ import (
 	"io"
 	"log"
 	"math/rand"
-	"mime"
 	"mime/multipart"
 	"net/mail"
 	"path/filepath"
@@ -56,7 +55,7 @@ func (h *Handler) ExtractAttachments(raw []byte) ([]*parser.Attachment, error) {
 		return nil, nil // No content type, no attachments
 	}
 
-	mediaType, params, err := mime.ParseMediaType(contentType)
+	mediaType, params, err := parser.ParseMediaType(contentType)
 	if err != nil {
 		return nil, nil // Invalid content type, no attachments
 	}
@@ -92,43 +91,16 @@ func (h *Handler) extractFromMultipart(body io.Reader, boundary string) ([]*pars
 		// Check if this part is an attachment
 		disposition := part.Header.Get("Content-Disposition")
 		contentType := part.Header.Get("Content-Type")
+		mediaType, contentParams, _ := parser.ParseMediaType(contentType)
 
 		// Check for nested multipart
-		if strings.HasPrefix(contentType, "multipart/") {
-			mediaType, params, _ := mime.ParseMediaType(contentType)
-			if strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != "" {
-				nestedAttachments, _ := h.extractFromMultipart(part, params["boundary"])
-				attachments = append(attachments, nestedAttachments...)
-				continue
-			}
-		}
-
-		// Determine if this is an attachment
-		isAttachment := false
-		filename := ""
-
-		if strings.HasPrefix(disposition, "attachment") {
-			isAttachment = true
-			_, params, _ := mime.ParseMediaType(disposition)
-			filename = params["filename"]
-		} else if strings.HasPrefix(disposition, "inline") {
-			// Inline attachments with filename are also attachments
-			_, params, _ := mime.ParseMediaType(disposition)
-			if params["filename"] != "" {
-				isAttachment = true
-				filename = params["filename"]
-			}
-		}
-
-		// Also check Content-Type for name parameter
-		if filename == "" && contentType != "" {
-			_, params, _ := mime.ParseMediaType(contentType)
-			if params["name"] != "" {
-				filename = params["name"]
-				isAttachment = true
-			}
+		if strings.HasPrefix(mediaType, "multipart/") && contentParams["boundary"] != "" {
+			nestedAttachments, _ := h.extractFromMultipart(part, contentParams["boundary"])
+			attachments = append(attachments, nestedAttachments...)
+			continue
 		}
 
+		filename, isAttachment := attachmentFilename(mediaType, contentParams, disposition)
 		if !isAttachment {
 			continue
 		}
@@ -147,22 +119,10 @@ func (h *Handler) extractFromMultipart(body io.Reader, boundary string) ([]*pars
 		}
 
 		// Determine content type
-		if contentType == "" {
-			contentType = "application/octet-stream"
-		}
-		mediaType, _, _ := mime.ParseMediaType(contentType)
 		if mediaType == "" {
 			mediaType = "application/octet-stream"
 		}
 
-		// Decode filename if MIME encoded
-		if filename != "" {
-			decoder := new(mime.WordDecoder)
-			if decoded, err := decoder.DecodeHeader(filename); err == nil {
-				filename = decoded
-			}
-		}
-
 		attachment := &parser.Attachment{
 			Filename:    filename,
 			ContentType: mediaType,
@@ -171,11 +131,90 @@ func (h *Handler) extractFromMultipart(body io.Reader, boundary string) ([]*pars
 		}
 
 		attachments = append(attachments, attachment)
+
+		// The original winmail.dat is kept; the files inside it become attachments as well
+		if parser.IsTNEF(mediaType, filename) {
+			attachments = append(attachments, h.extractTNEF(filename, decodedData)...)
+		}
 	}
 
 	return attachments, nil
 }
 
+// attachmentFilename reports whether a leaf MIME part is an attachment, and its filename
+// Filenames are fully decoded here: RFC 2231 continuations and charsets, and RFC 2047 words
+func attachmentFilename(mediaType string, contentParams map[string]string, disposition string) (string, bool) {
+	isAttachment := false
+	dispositionType, dispositionParams, _ := parser.ParseMediaType(disposition)
+	filename := dispositionParams["filename"]
+
+	switch dispositionType {
+	case "attachment":
+		isAttachment = true
+	case "inline":
+		// Inline attachments with filename are also attachments
+		isAttachment = filename != ""
+	}
+
+	// Also check Content-Type for name parameter
+	if filename == "" && contentParams["name"] != "" {
+		filename = contentParams["name"]
+		isAttachment = true
+	}
+
+	// Forwarded messages are kept as .eml files even when sent inline
+	if parser.IsEmbeddedMessage(mediaType) {
+		isAttachment = true
+		if filename == "" {
+			filename = "message.eml"
+		}
+	}
+
+	// Outlook sometimes sends winmail.dat without any disposition
+	if parser.IsTNEF(mediaType, filename) {
+		isAttachment = true
+		if filename == "" {
+			filename = "winmail.dat"
+		}
+	}
+
+	return filename, isAttachment
+}
+
+// extractTNEF returns the embedded files of an Outlook winmail.dat
+// An RTF body that is not encapsulated HTML is returned as body.rtf so its formatting is not lost
+func (h *Handler) extractTNEF(filename string, data []byte) []*parser.Attachment {
+	tnef, err := parser.DecodeTNEF(data)
+	if tnef == nil {
+		log.Printf("Failed to decode TNEF attachment %s: %v", filename, err)
+		return nil
+	}
+	if err != nil {
+		log.Printf("TNEF attachment %s is damaged, keeping %d recovered files: %v", filename, len(tnef.Attachments), err)
+	}
+
+	attachments := tnef.Attachments
+	for _, att := range attachments {
+		// Outlook rarely records a MIME tag; use the type expected for the extension
+		if att.ContentType == parser.ContentTypeOctetStream {
+			if types := ExtensionContentTypeMap[strings.ToLower(filepath.Ext(att.Filename))]; len(types) > 0 {
+				att.ContentType = types[0]
+			}
+		}
+	}
+
+	if tnef.BodyHTML == "" && len(tnef.BodyRTF) > 0 {
+		attachments = append(attachments, &parser.Attachment{
+			Filename:    "body.rtf",
+			ContentType: "application/rtf",
+			Data:        tnef.BodyRTF,
+			SizeBytes:   int64(len(tnef.BodyRTF)),
+		})
+	}
+
+	return attachments
+}
+
 // GenerateStorageKey generates a unique storage key for an attachment
 // Requirements: 5.3 - Generate unique storage key for each attachment
 // Property 10: Attachment Storage - generates unique storage key
@@ -431,57 +470,70 @@ func (h *Handler) Process(ctx context.Context, emailID string, attachments []*pa
 	}
 
 	for _, att := range attachments {
-		// Validate individual attachment
-		if err := h.ValidateAttachment(att); err != nil {
-			if validErr, ok := err.(*AttachmentValidationError); ok {
-				// Log blocked attachment (Requirement 5.10)
-				h.logBlockedAttachment(att.Filename, validErr.Reason)
-				errors = append(errors, *validErr)
-			}
+		processedAtt, validErr := h.processAttachment(ctx, emailID, att)
+		if validErr != nil {
+			errors = append(errors, *validErr)
 			continue
 		}
+		processed = append(processed, processedAtt)
+	}
 
-		// Sanitize filename (Requirement 5.8)
-		sanitizedFilename := h.SanitizeFilename(att.Filename)
-		if sanitizedFilename == "" {
-			sanitizedFilename = "attachment"
-		}
-
-		// Generate storage key (Requirement 5.3)
-		storageKey := h.GenerateStorageKey(emailID, sanitizedFilename)
-
-		// Calculate checksum (Requirement 5.4)
-		checksum := h.CalculateChecksum(att.Data)
+	return processed, errors
+}
 
-		// Store to S3 with retry (Requirements 5.2, 1.9)
-		storageURL, uploadErr := h.storeToS3WithRetry(ctx, storageKey, att.Data, att.ContentType, att.Filename)
-		if uploadErr != nil {
-			// Log failed attachment (Requirement 1.10)
-			h.logFailedAttachment(att.Filename, uploadErr)
-			errors = append(errors, AttachmentValidationError{
-				Filename: att.Filename,
-				Reason:   fmt.Sprintf("failed to store attachment after %d attempts: %v", uploadErr.Attempts, uploadErr.LastError),
-			})
-			continue
-		}
-
-		processedAtt := &ProcessedAttachment{
-			ID:          uuid.New().String(),
-			EmailID:     emailID,
-			Filename:    sanitizedFilename,
-			ContentType: att.ContentType,
-			SizeBytes:   att.SizeBytes,
-			StorageKey:  storageKey,
-			StorageURL:  storageURL,
-			Checksum:    checksum,
-			Status:      AttachmentStatusActive,
-			CreatedAt:   time.Now().UTC(),
+// processAttachment validates a single attachment and stores it to S3
+func (h *Handler) processAttachment(ctx context.Context, emailID string, att *parser.Attachment) (*ProcessedAttachment, *AttachmentValidationError) {
+	// Validate individual attachment
+	if err := h.ValidateAttachment(att); err != nil {
+		validErr, ok := err.(*AttachmentValidationError)
+		if !ok {
+			validErr = &AttachmentValidationError{Filename: att.Filename, Reason: err.Error()}
 		}
+		// Log blocked attachment (Requirement 5.10)
+		h.logBlockedAttachment(att.Filename, validErr.Reason)
+		return nil, validErr
+	}
 
-		processed = append(processed, processedAtt)
+	// Sanitize filename (Requirement 5.8)
+	sanitizedFilename := h.SanitizeFilename(att.Filename)
+	if sanitizedFilename == "" {
+		sanitizedFilename = "attachment"
 	}
 
-	return processed, errors
+	// Generate storage key (Requirement 5.3)
+	storageKey := h.GenerateStorageKey(emailID, sanitizedFilename)
+
+	// Calculate checksum (Requirement 5.4)
+	checksum := h.CalculateChecksum(att.Data)
+
+	// Store to S3 with retry (Requirements 5.2, 1.9)
+	storageURL, uploadErr := h.storeToS3WithRetry(ctx, storageKey, att.Data, att.ContentType, att.Filename)
+	if uploadErr != nil {
+		// Log failed attachment (Requirement 1.10)
+		h.logFailedAttachment(att.Filename, uploadErr)
+		return nil, failedUploadError(att.Filename, uploadErr)
+	}
+
+	return &ProcessedAttachment{
+		ID:          uuid.New().String(),
+		EmailID:     emailID,
+		Filename:    sanitizedFilename,
+		ContentType: att.ContentType,
+		SizeBytes:   att.SizeBytes,
+		StorageKey:  storageKey,
+		StorageURL:  storageURL,
+		Checksum:    checksum,
+		Status:      AttachmentStatusActive,
+		CreatedAt:   time.Now().UTC(),
+	}, nil
+}
+
+// failedUploadError reports an attachment that could not be stored
+func failedUploadError(filename string, uploadErr *UploadError) *AttachmentValidationError {
+	return &AttachmentValidationError{
+		Filename: filename,
+		Reason:   fmt.Sprintf("failed to store attachment after %d attempts: %v", uploadErr.Attempts, uploadErr.LastError),
+	}
 }
 
 // storeToS3 stores attachment data to S3/MinIO
@@ -519,25 +571,36 @@ func (h *Handler) storeToS3WithRetry(ctx context.Context, key string, data []byt
 		}
 	}
 
-	var lastErr error
-	delay := time.Duration(InitialRetryDelay) * time.Millisecond
-
-	for attempt := 1; attempt <= MaxUploadRetries; attempt++ {
-		// Attempt upload
+	uploadErr := h.retryUpload(ctx, filename, func() error {
 		_, err := h.s3Client.PutObject(ctx, &s3.PutObjectInput{
 			Bucket:      aws.String(h.bucket),
 			Key:         aws.String(key),
 			Body:        bytes.NewReader(data),
 			ContentType: aws.String(contentType),
 		})
+		return err
+	})
+	if uploadErr != nil {
+		return "", uploadErr
+	}
 
+	return fmt.Sprintf("s3://%s/%s", h.bucket, key), nil
+}
+
+// retryUpload runs an upload operation with exponential backoff and jitter
+// Requirements: 1.9 - Retry up to 3 times with exponential backoff
+func (h *Handler) retryUpload(ctx context.Context, filename string, upload func() error) *UploadError {
+	var lastErr error
+	delay := time.Duration(InitialRetryDelay) * time.Millisecond
+
+	for attempt := 1; attempt <= MaxUploadRetries; attempt++ {
+		// Attempt upload
+		err := upload()
 		if err == nil {
-			// Success
-			storageURL := fmt.Sprintf("s3://%s/%s", h.bucket, key)
 			if attempt > 1 {
 				log.Printf("Upload succeeded for %s on attempt %d", filename, attempt)
 			}
-			return storageURL, nil
+			return nil
 		}
 
 		lastErr = err
@@ -545,7 +608,7 @@ func (h *Handler) storeToS3WithRetry(ctx context.Context, key string, data []byt
 
 		// Check if context is cancelled
 		if ctx.Err() != nil {
-			return "", &UploadError{
+			return &UploadError{
 				Filename:    filename,
 				Attempts:    attempt,
 				LastError:   ctx.Err(),
@@ -566,7 +629,7 @@ func (h *Handler) storeToS3WithRetry(ctx context.Context, key string, data []byt
 
 			select {
 			case <-ctx.Done():
-				return "", &UploadError{
+				return &UploadError{
 					Filename:    filename,
 					Attempts:    attempt,
 					LastError:   ctx.Err(),
@@ -582,7 +645,7 @@ func (h *Handler) storeToS3WithRetry(ctx context.Context, key string, data []byt
 	}
 
 	// All retries exhausted - permanent failure
-	return "", &UploadError{
+	return &UploadError{
 		Filename:    filename,
 		Attempts:    MaxUploadRetries,
 		LastError:   lastErr,
@@ -1,9 +1,11 @@
 package attachment
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"os"
 	"strings"
 	"testing"
 
@@ -314,6 +316,166 @@ func TestAttachmentExtractionFromMultipart(t *testing.T) {
 }
 
 
+// TestAttachmentExtraction_FilenameCorpus verifies encoded filenames from real clients reach
+// SanitizeFilename decoded, using the corpus shared with the parser package
+func TestAttachmentExtraction_FilenameCorpus(t *testing.T) {
+	handler := NewHandler(nil, "test-bucket")
+
+	data, err := os.ReadFile("../parser/testdata/filename_corpus.txt")
+	if err != nil {
+		t.Fatalf("Failed to read corpus: %v", err)
+	}
+
+	var email strings.Builder
+	email.WriteString("From: sender@example.com\r\n" +
+		"To: recipient@example.com\r\n" +
+		"Content-Type: multipart/mixed; boundary=\"corpus\"\r\n" +
+		"\r\n")
+
+	var expected []string
+	var header []string
+	for _, line := range strings.Split(string(data), "\n") {
+		switch {
+		case line == "", strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, "=> "):
+			email.WriteString("--corpus\r\n")
+			if strings.HasPrefix(strings.ToLower(header[0]), "content-type:") {
+				email.WriteString("Content-Disposition: attachment\r\n")
+			} else {
+				email.WriteString("Content-Type: application/octet-stream\r\n")
+			}
+			email.WriteString(strings.Join(header, "\r\n") + "\r\n\r\ndata\r\n")
+			expected = append(expected, strings.TrimPrefix(line, "=> "))
+			header = nil
+		default:
+			header = append(header, line)
+		}
+	}
+	email.WriteString("--corpus--\r\n")
+
+	attachments, err := handler.ExtractAttachments([]byte(email.String()))
+	if err != nil {
+		t.Fatalf("ExtractAttachments failed: %v", err)
+	}
+	if len(attachments) != len(expected) {
+		t.Fatalf("Expected %d attachments, got %d", len(expected), len(attachments))
+	}
+
+	for i, want := range expected {
+		if attachments[i].Filename != want {
+			t.Errorf("Attachment %d filename = %q, want %q", i, attachments[i].Filename, want)
+		}
+		if sanitized := handler.SanitizeFilename(attachments[i].Filename); sanitized == "" {
+			t.Errorf("Attachment %d lost its name after sanitizing: %q", i, sanitized)
+		}
+	}
+}
+
+// TestAttachmentExtraction_TNEF verifies winmail.dat is kept and its embedded files are extracted
+func TestAttachmentExtraction_TNEF(t *testing.T) {
+	handler := NewHandler(nil, "test-bucket")
+
+	winmail, err := os.ReadFile("../parser/testdata/winmail.dat")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	email := "From: zoe@example.fr\r\n" +
+		"To: recipient@example.com\r\n" +
+		"Content-Type: multipart/mixed; boundary=\"tnef\"\r\n" +
+		"\r\n" +
+		"--tnef\r\n" +
+		"Content-Type: text/plain\r\n" +
+		"\r\n" +
+		"Body\r\n" +
+		"--tnef\r\n" +
+		"Content-Type: application/ms-tnef\r\n" +
+		"Content-Transfer-Encoding: base64\r\n" +
+		"\r\n" +
+		string(parser.EncodeBase64(winmail)) + "\r\n" +
+		"--tnef--\r\n"
+
+	attachments, err := handler.ExtractAttachments([]byte(email))
+	if err != nil {
+		t.Fatalf("ExtractAttachments failed: %v", err)
+	}
+
+	want := []struct {
+		filename    string
+		contentType string
+	}{
+		{"winmail.dat", parser.ContentTypeTNEF},
+		{"Rapport trimestriel.pdf", "application/pdf"},
+		{"café.txt", "text/plain"},
+	}
+	if len(attachments) != len(want) {
+		t.Fatalf("Expected %d attachments, got %d", len(want), len(attachments))
+	}
+	for i, w := range want {
+		if attachments[i].Filename != w.filename || attachments[i].ContentType != w.contentType {
+			t.Errorf("Attachment %d = %s (%s), want %s (%s)", i, attachments[i].Filename, attachments[i].ContentType, w.filename, w.contentType)
+		}
+		if err := handler.ValidateAttachment(attachments[i]); err != nil {
+			t.Errorf("Attachment %d failed validation: %v", i, err)
+		}
+	}
+	if !bytes.Equal(attachments[0].Data, winmail) {
+		t.Error("Original winmail.dat must be kept unchanged")
+	}
+}
+
+// TestAttachmentExtraction_EmbeddedMessage verifies forwarded messages are kept whole as .eml files
+func TestAttachmentExtraction_EmbeddedMessage(t *testing.T) {
+	handler := NewHandler(nil, "test-bucket")
+
+	forwarded := "From: alice@example.com\r\n" +
+		"Subject: Original\r\n" +
+		"Content-Type: multipart/mixed; boundary=\"inner\"\r\n" +
+		"\r\n" +
+		"--inner\r\n" +
+		"Content-Type: text/plain\r\n" +
+		"\r\n" +
+		"Original body\r\n" +
+		"--inner\r\n" +
+		"Content-Type: application/pdf; name=\"invoice.pdf\"\r\n" +
+		"Content-Disposition: attachment; filename=\"invoice.pdf\"\r\n" +
+		"\r\n" +
+		"%PDF-1.4\r\n" +
+		"--inner--\r\n"
+
+	email := "From: bob@example.com\r\n" +
+		"To: recipient@example.com\r\n" +
+		"Content-Type: multipart/mixed; boundary=\"outer\"\r\n" +
+		"\r\n" +
+		"--outer\r\n" +
+		"Content-Type: text/plain\r\n" +
+		"\r\n" +
+		"See below\r\n" +
+		"--outer\r\n" +
+		"Content-Type: message/rfc822\r\n" +
+		"Content-Disposition: inline\r\n" +
+		"\r\n" +
+		forwarded +
+		"--outer--\r\n"
+
+	attachments, err := handler.ExtractAttachments([]byte(email))
+	if err != nil {
+		t.Fatalf("ExtractAttachments failed: %v", err)
+	}
+	if len(attachments) != 1 {
+		t.Fatalf("Expected only the forwarded message, got %d attachments", len(attachments))
+	}
+	if attachments[0].Filename != "message.eml" || attachments[0].ContentType != parser.ContentTypeMessage {
+		t.Errorf("Attachment = %s (%s)", attachments[0].Filename, attachments[0].ContentType)
+	}
+	if !bytes.Contains(attachments[0].Data, []byte("invoice.pdf")) {
+		t.Error("Forwarded message must keep its own attachments")
+	}
+	if err := handler.ValidateAttachment(attachments[0]); err != nil {
+		t.Errorf("Forwarded message failed validation: %v", err)
+	}
+}
+
 // TestProperty11_AttachmentSizeLimits tests Property 11: Attachment Size Limits
 // Feature: smtp-email-receiver, Property 11: Attachment Size Limits
 // *For any* attachment exceeding 10 MB individually or 25 MB total per email,
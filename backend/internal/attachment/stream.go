@@ -0,0 +1,319 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/parser"
+)
+
+// StreamPartSize is the size of the buffers attachments are streamed through
+// It is the smallest part S3 accepts in a multipart upload; attachments that fit in one
+// buffer are stored with a single PutObject.
+const StreamPartSize = 5 * 1024 * 1024
+
+// partBufferPool reuses part buffers across messages
+var partBufferPool = sync.Pool{
+	New: func() any {
+		buf := make([]byte, StreamPartSize)
+		return &buf
+	},
+}
+
+// errTotalSizeExceeded stops streaming once the attachments exceed MaxTotalAttachmentSize
+var errTotalSizeExceeded = errors.New("total attachment size exceeded")
+
+// ProcessStream extracts, validates and stores the attachments of a message read from r
+// It gives the same results as ProcessAndValidate without copying the message or decoding whole
+// attachments in memory: parts are decoded as they are read from r and each attachment is uploaded
+// from a pooled buffer of StreamPartSize bytes, with an S3 multipart upload when it does not fit in
+// one buffer. Whether the message itself is in memory is up to the caller's reader.
+// Requirements: 5.1-5.10
+func (h *Handler) ProcessStream(ctx context.Context, emailID string, r io.Reader) ([]*ProcessedAttachment, []AttachmentValidationError) {
+	var processed []*ProcessedAttachment
+	var validationErrors []AttachmentValidationError
+	var totalSize int64
+
+	collect := func(processedAtt *ProcessedAttachment, validErr *AttachmentValidationError) {
+		if validErr != nil {
+			validationErrors = append(validationErrors, *validErr)
+			return
+		}
+		processed = append(processed, processedAtt)
+	}
+
+	err := parser.StreamParts(r, func(part *parser.StreamPart) error {
+		// Only multipart emails can have attachments
+		if !part.Nested {
+			return nil
+		}
+
+		filename, isAttachment := attachmentFilename(part.MediaType, part.Params, part.Header.Get("Content-Disposition"))
+		if !isAttachment {
+			return nil
+		}
+
+		contentType := part.MediaType
+		if contentType == "" {
+			contentType = parser.ContentTypeOctetStream
+		}
+
+		// winmail.dat is decoded in memory, bounded by the attachment size limit
+		if parser.IsTNEF(contentType, filename) {
+			data, _ := io.ReadAll(io.LimitReader(part.Body, MaxAttachmentSize+1))
+			drained, _ := io.Copy(io.Discard, part.Body)
+			attachments := []*parser.Attachment{{
+				Filename:    filename,
+				ContentType: contentType,
+				Data:        data,
+				SizeBytes:   int64(len(data)) + drained,
+			}}
+			if attachments[0].SizeBytes <= MaxAttachmentSize {
+				attachments = append(attachments, h.extractTNEF(filename, data)...)
+			}
+
+			for _, att := range attachments {
+				totalSize += att.SizeBytes
+			}
+			if totalSize > MaxTotalAttachmentSize {
+				return errTotalSizeExceeded
+			}
+			for _, att := range attachments {
+				collect(h.processAttachment(ctx, emailID, att))
+			}
+			return nil
+		}
+
+		buf := partBufferPool.Get().(*[]byte)
+		defer partBufferPool.Put(buf)
+
+		n, err := io.ReadFull(part.Body, *buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil // Unreadable parts are skipped, as in ExtractAttachments
+		}
+		att := &parser.Attachment{
+			Filename:    filename,
+			ContentType: contentType,
+			Data:        (*buf)[:n],
+			SizeBytes:   int64(n),
+		}
+
+		// The whole attachment fits in one buffer
+		if n < len(*buf) {
+			totalSize += att.SizeBytes
+			if totalSize > MaxTotalAttachmentSize {
+				return errTotalSizeExceeded
+			}
+			collect(h.processAttachment(ctx, emailID, att))
+			return nil
+		}
+
+		// Larger attachments are validated from their headers and leading bytes before uploading
+		if err := h.ValidateAttachment(att); err != nil {
+			validErr, ok := err.(*AttachmentValidationError)
+			if !ok {
+				validErr = &AttachmentValidationError{Filename: filename, Reason: err.Error()}
+			}
+			h.logBlockedAttachment(filename, validErr.Reason)
+			validationErrors = append(validationErrors, *validErr)
+
+			drained, _ := io.Copy(io.Discard, part.Body)
+			totalSize += att.SizeBytes + drained
+			if totalSize > MaxTotalAttachmentSize {
+				return errTotalSizeExceeded
+			}
+			return nil
+		}
+
+		processedAtt, size, err := h.storeStreamed(ctx, emailID, att, *buf, part.Body, MaxTotalAttachmentSize-totalSize)
+		totalSize += size
+		switch e := err.(type) {
+		case nil:
+			processed = append(processed, processedAtt)
+		case *AttachmentValidationError:
+			h.logBlockedAttachment(filename, e.Reason)
+			validationErrors = append(validationErrors, *e)
+		case *UploadError:
+			h.logFailedAttachment(filename, e)
+			validationErrors = append(validationErrors, *failedUploadError(filename, e))
+		default:
+			if err == errTotalSizeExceeded {
+				return err
+			}
+			validationErrors = append(validationErrors, AttachmentValidationError{
+				Filename: filename,
+				Reason:   fmt.Sprintf("failed to read attachment: %v", err),
+			})
+		}
+
+		if totalSize > MaxTotalAttachmentSize {
+			return errTotalSizeExceeded
+		}
+		return nil
+	})
+
+	if err == errTotalSizeExceeded {
+		// Nothing is kept when the total is over the limit (Requirement 5.7)
+		h.deleteProcessed(ctx, processed)
+		return nil, []AttachmentValidationError{{
+			Filename: "",
+			Reason:   fmt.Sprintf("total attachment size exceeds maximum of %d bytes", MaxTotalAttachmentSize),
+		}}
+	}
+	if err != nil {
+		validationErrors = append(validationErrors, AttachmentValidationError{
+			Filename: "",
+			Reason:   fmt.Sprintf("failed to extract attachments: %v", err),
+		})
+	}
+
+	return processed, validationErrors
+}
+
+// storeStreamed stores an attachment larger than one buffer with an S3 multipart upload
+// att holds the first buffer of content; buf is reused to read the rest from body.
+// The upload is aborted once the attachment exceeds MaxAttachmentSize or the remaining budget.
+// It returns the number of bytes read, even when the attachment was not stored.
+func (h *Handler) storeStreamed(ctx context.Context, emailID string, att *parser.Attachment, buf []byte, body io.Reader, budget int64) (*ProcessedAttachment, int64, error) {
+	if h.s3Client == nil {
+		drained, _ := io.Copy(io.Discard, body)
+		return nil, att.SizeBytes + drained, &UploadError{
+			Filename:    att.Filename,
+			Attempts:    0,
+			LastError:   errors.New("S3 client not initialized"),
+			IsPermanent: true,
+		}
+	}
+
+	// Sanitize filename and generate storage key (Requirements 5.3, 5.8)
+	sanitizedFilename := h.SanitizeFilename(att.Filename)
+	if sanitizedFilename == "" {
+		sanitizedFilename = "attachment"
+	}
+	storageKey := h.GenerateStorageKey(emailID, sanitizedFilename)
+
+	var uploadID *string
+	if uploadErr := h.retryUpload(ctx, att.Filename, func() error {
+		out, err := h.s3Client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+			Bucket:      aws.String(h.bucket),
+			Key:         aws.String(storageKey),
+			ContentType: aws.String(att.ContentType),
+		})
+		if err == nil {
+			uploadID = out.UploadId
+		}
+		return err
+	}); uploadErr != nil {
+		drained, _ := io.Copy(io.Discard, body)
+		return nil, att.SizeBytes + drained, uploadErr
+	}
+
+	hash := sha256.New()
+	var parts []types.CompletedPart
+	var size int64
+	chunk := att.Data
+
+	for partNumber := int32(1); len(chunk) > 0; partNumber++ {
+		size += int64(len(chunk))
+		if size > budget {
+			h.abortMultipart(ctx, storageKey, uploadID)
+			return nil, size, errTotalSizeExceeded
+		}
+		// Check individual size limit (Requirement 5.6)
+		if size > MaxAttachmentSize {
+			h.abortMultipart(ctx, storageKey, uploadID)
+			drained, _ := io.Copy(io.Discard, body)
+			return nil, size + drained, &AttachmentValidationError{
+				Filename: att.Filename,
+				Reason:   fmt.Sprintf("attachment exceeds maximum size of %d bytes", MaxAttachmentSize),
+			}
+		}
+
+		hash.Write(chunk)
+		var etag *string
+		if uploadErr := h.retryUpload(ctx, att.Filename, func() error {
+			out, err := h.s3Client.UploadPart(ctx, &s3.UploadPartInput{
+				Bucket:     aws.String(h.bucket),
+				Key:        aws.String(storageKey),
+				UploadId:   uploadID,
+				PartNumber: aws.Int32(partNumber),
+				Body:       bytes.NewReader(chunk),
+			})
+			if err == nil {
+				etag = out.ETag
+			}
+			return err
+		}); uploadErr != nil {
+			h.abortMultipart(ctx, storageKey, uploadID)
+			drained, _ := io.Copy(io.Discard, body)
+			return nil, size + drained, uploadErr
+		}
+		parts = append(parts, types.CompletedPart{ETag: etag, PartNumber: aws.Int32(partNumber)})
+
+		n, err := io.ReadFull(body, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			h.abortMultipart(ctx, storageKey, uploadID)
+			return nil, size, err
+		}
+		chunk = buf[:n]
+	}
+
+	if uploadErr := h.retryUpload(ctx, att.Filename, func() error {
+		_, err := h.s3Client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+			Bucket:          aws.String(h.bucket),
+			Key:             aws.String(storageKey),
+			UploadId:        uploadID,
+			MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+		})
+		return err
+	}); uploadErr != nil {
+		h.abortMultipart(ctx, storageKey, uploadID)
+		return nil, size, uploadErr
+	}
+
+	return &ProcessedAttachment{
+		ID:          uuid.New().String(),
+		EmailID:     emailID,
+		Filename:    sanitizedFilename,
+		ContentType: att.ContentType,
+		SizeBytes:   size,
+		StorageKey:  storageKey,
+		StorageURL:  fmt.Sprintf("s3://%s/%s", h.bucket, storageKey),
+		Checksum:    hex.EncodeToString(hash.Sum(nil)),
+		Status:      AttachmentStatusActive,
+		CreatedAt:   time.Now().UTC(),
+	}, size, nil
+}
+
+// abortMultipart discards the parts of an unfinished upload so they are not billed
+func (h *Handler) abortMultipart(ctx context.Context, key string, uploadID *string) {
+	_, err := h.s3Client.AbortMultipartUpload(context.WithoutCancel(ctx), &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(h.bucket),
+		Key:      aws.String(key),
+		UploadId: uploadID,
+	})
+	if err != nil {
+		log.Printf("Failed to abort multipart upload of %s: %v", key, err)
+	}
+}
+
+// deleteProcessed removes attachments that were stored before processing was abandoned
+func (h *Handler) deleteProcessed(ctx context.Context, processed []*ProcessedAttachment) {
+	for _, att := range processed {
+		if err := h.DeleteAttachment(context.WithoutCancel(ctx), att.StorageKey); err != nil {
+			log.Printf("Failed to delete attachment %s: %v", att.StorageKey, err)
+		}
+	}
+}
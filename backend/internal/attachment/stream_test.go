@@ -0,0 +1,306 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"runtime/metrics"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// fakeS3 is an in-memory S3 endpoint supporting the calls made by the attachment handler
+// With discard set, object content is hashed instead of kept, so benchmarks measure the handler.
+type fakeS3 struct {
+	mu       sync.Mutex
+	discard  bool
+	objects  map[string][]byte
+	uploads  map[string]map[int][]byte
+	nextID   int
+	parts    int
+	aborted  int
+	received int64
+}
+
+// newFakeS3 starts a fake S3 server and returns a handler storing to it
+func newFakeS3(t testing.TB, discard bool) (*fakeS3, *Handler) {
+	fake := &fakeS3{discard: discard, objects: map[string][]byte{}, uploads: map[string]map[int][]byte{}}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	client := s3.New(s3.Options{
+		BaseEndpoint: aws.String(srv.URL),
+		UsePathStyle: true,
+		Region:       "us-east-1",
+		Credentials:  credentials.NewStaticCredentialsProvider("access", "secret", ""),
+	})
+	return fake, NewHandler(client, "bucket")
+}
+
+// ServeHTTP implements http.Handler
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+	query := r.URL.Query()
+	uploadID := query.Get("uploadId")
+
+	switch {
+	case r.Method == http.MethodPut:
+		body := f.readBody(r.Body)
+		if uploadID != "" {
+			part, _ := strconv.Atoi(query.Get("partNumber"))
+			f.uploads[uploadID][part] = body
+			f.parts++
+		} else {
+			f.objects[key] = body
+		}
+		w.Header().Set("ETag", `"etag"`)
+
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		f.nextID++
+		id := fmt.Sprintf("upload-%d", f.nextID)
+		f.uploads[id] = map[int][]byte{}
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><Bucket>bucket</Bucket><Key>%s</Key><UploadId>%s</UploadId></InitiateMultipartUploadResult>", key, id)
+
+	case r.Method == http.MethodPost && uploadID != "":
+		io.Copy(io.Discard, r.Body)
+		numbers := make([]int, 0, len(f.uploads[uploadID]))
+		for n := range f.uploads[uploadID] {
+			numbers = append(numbers, n)
+		}
+		sort.Ints(numbers)
+		var object []byte
+		for _, n := range numbers {
+			object = append(object, f.uploads[uploadID][n]...)
+		}
+		f.objects[key] = object
+		delete(f.uploads, uploadID)
+		fmt.Fprintf(w, "<CompleteMultipartUploadResult><Bucket>bucket</Bucket><Key>%s</Key><ETag>\"etag\"</ETag></CompleteMultipartUploadResult>", key)
+
+	case r.Method == http.MethodDelete:
+		if uploadID != "" {
+			delete(f.uploads, uploadID)
+			f.aborted++
+		} else {
+			delete(f.objects, key)
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+// readBody keeps a request body, or only counts it when discarding
+func (f *fakeS3) readBody(body io.Reader) []byte {
+	if f.discard {
+		n, _ := io.Copy(io.Discard, body)
+		f.received += n
+		return nil
+	}
+	data, _ := io.ReadAll(body)
+	f.received += int64(len(data))
+	return data
+}
+
+// testPart is an attachment of a generated message
+type testPart struct {
+	filename    string
+	contentType string
+	data        []byte
+}
+
+// pdfData returns deterministic PDF-like content of the given size
+func pdfData(size int, seed int64) []byte {
+	data := make([]byte, size)
+	rand.New(rand.NewSource(seed)).Read(data)
+	copy(data, "%PDF-1.4\n")
+	return data
+}
+
+// attachmentMessage builds a multipart message with a text body and base64 attachments
+func attachmentMessage(parts ...testPart) []byte {
+	var b bytes.Buffer
+	b.WriteString("From: sender@example.com\r\nSubject: Files\r\nContent-Type: multipart/mixed; boundary=\"b\"\r\n\r\n")
+	b.WriteString("--b\r\nContent-Type: text/plain\r\n\r\nSee attached\r\n")
+	for _, part := range parts {
+		fmt.Fprintf(&b, "--b\r\nContent-Type: %s\r\nContent-Disposition: attachment; filename=\"%s\"\r\nContent-Transfer-Encoding: base64\r\n\r\n", part.contentType, part.filename)
+		encoded := base64.StdEncoding.EncodeToString(part.data)
+		for len(encoded) > 76 {
+			b.WriteString(encoded[:76] + "\r\n")
+			encoded = encoded[76:]
+		}
+		b.WriteString(encoded + "\r\n")
+	}
+	b.WriteString("--b--\r\n")
+	return b.Bytes()
+}
+
+// TestProcessStream_MatchesProcessAndValidate verifies streaming stores the same attachments as the buffered path
+func TestProcessStream_MatchesProcessAndValidate(t *testing.T) {
+	fake, handler := newFakeS3(t, false)
+	large := pdfData(StreamPartSize+StreamPartSize/2, 1)
+	raw := attachmentMessage(
+		testPart{"notes.txt", "text/plain", []byte("hello")},
+		testPart{"report.pdf", "application/pdf", large},
+		testPart{"setup.exe", "application/octet-stream", []byte("MZ payload")},
+		testPart{"invoice.pdf", "application/pdf", append([]byte{'M', 'Z'}, large[2:]...)},
+	)
+	ctx := context.Background()
+
+	buffered, bufferedErrs := handler.ProcessAndValidate(ctx, "email-1", raw)
+	streamed, streamedErrs := handler.ProcessStream(ctx, "email-2", bytes.NewReader(raw))
+
+	if len(streamed) != 2 || len(buffered) != len(streamed) {
+		t.Fatalf("Stored %d attachments streaming, %d buffered", len(streamed), len(buffered))
+	}
+	for i, att := range streamed {
+		want := buffered[i]
+		if att.Filename != want.Filename || att.ContentType != want.ContentType || att.SizeBytes != want.SizeBytes || att.Checksum != want.Checksum {
+			t.Errorf("Attachment %d = %+v, buffered %+v", i, att, want)
+		}
+		stored := fake.objects[att.StorageKey]
+		sum := sha256.Sum256(stored)
+		if int64(len(stored)) != att.SizeBytes || hex.EncodeToString(sum[:]) != att.Checksum {
+			t.Errorf("Stored object %s does not match its checksum", att.StorageKey)
+		}
+	}
+	if fake.parts != 2 {
+		t.Errorf("Large attachment should be uploaded in 2 parts, got %d", fake.parts)
+	}
+
+	if len(streamedErrs) != 2 || len(bufferedErrs) != 2 {
+		t.Fatalf("Errors streaming %+v, buffered %+v", streamedErrs, bufferedErrs)
+	}
+	for i := range streamedErrs {
+		if streamedErrs[i] != bufferedErrs[i] {
+			t.Errorf("Error %d = %+v, buffered %+v", i, streamedErrs[i], bufferedErrs[i])
+		}
+	}
+}
+
+// TestProcessStream_SizeLimits verifies oversized attachments are aborted and over-limit emails store nothing
+func TestProcessStream_SizeLimits(t *testing.T) {
+	ctx := context.Background()
+
+	fake, handler := newFakeS3(t, false)
+	processed, errs := handler.ProcessStream(ctx, "email-1", bytes.NewReader(attachmentMessage(
+		testPart{"huge.pdf", "application/pdf", pdfData(MaxAttachmentSize+1, 2)},
+		testPart{"small.txt", "text/plain", []byte("kept")},
+	)))
+	if len(processed) != 1 || processed[0].Filename != "small.txt" {
+		t.Errorf("Processed = %+v", processed)
+	}
+	if len(errs) != 1 || errs[0].Filename != "huge.pdf" || !strings.Contains(errs[0].Reason, "exceeds maximum size") {
+		t.Errorf("Errors = %+v", errs)
+	}
+	if fake.aborted != 1 || len(fake.uploads) != 0 || len(fake.objects) != 1 {
+		t.Errorf("Oversized upload should be aborted: %d aborted, %d open, %d objects", fake.aborted, len(fake.uploads), len(fake.objects))
+	}
+
+	fake, handler = newFakeS3(t, false)
+	processed, errs = handler.ProcessStream(ctx, "email-2", bytes.NewReader(attachmentMessage(
+		testPart{"a.pdf", "application/pdf", pdfData(9*1024*1024, 3)},
+		testPart{"b.pdf", "application/pdf", pdfData(9*1024*1024, 4)},
+		testPart{"c.pdf", "application/pdf", pdfData(9*1024*1024, 5)},
+	)))
+	if processed != nil || len(errs) != 1 || !strings.Contains(errs[0].Reason, "total attachment size") {
+		t.Errorf("Over-limit email: processed %+v, errors %+v", processed, errs)
+	}
+	if len(fake.objects) != 0 || len(fake.uploads) != 0 {
+		t.Errorf("Stored attachments should be removed: %d objects, %d open uploads", len(fake.objects), len(fake.uploads))
+	}
+}
+
+// BenchmarkProcessAttachments compares memory use of buffered and streamed attachment processing
+// The message is about 20 MB of base64 attachments; peak-heap-B is the highest live heap above the
+// baseline while processing, and shows the streamed path staying near a single part buffer.
+// It covers the attachment step only; BenchmarkProcessEmail in the smtp package measures a whole message.
+func BenchmarkProcessAttachments(b *testing.B) {
+	raw := attachmentMessage(
+		testPart{"one.pdf", "application/pdf", pdfData(6*1024*1024, 1)},
+		testPart{"two.pdf", "application/pdf", pdfData(6*1024*1024, 2)},
+		testPart{"three.pdf", "application/pdf", pdfData(3*1024*1024, 3)},
+	)
+	ctx := context.Background()
+
+	run := func(b *testing.B, process func(h *Handler)) {
+		_, handler := newFakeS3(b, true)
+		process(handler) // Fill the buffer pool and connection pool
+
+		b.SetBytes(int64(len(raw)))
+		b.ReportAllocs()
+		b.ResetTimer()
+		var peak uint64
+		for i := 0; i < b.N; i++ {
+			peak = max(peak, peakHeap(func() { process(handler) }))
+		}
+		b.ReportMetric(float64(peak), "peak-heap-B")
+	}
+
+	b.Run("buffered", func(b *testing.B) {
+		run(b, func(h *Handler) {
+			if processed, errs := h.ProcessAndValidate(ctx, "email", raw); len(processed) != 3 {
+				b.Fatalf("Processed %d attachments: %+v", len(processed), errs)
+			}
+		})
+	})
+	b.Run("streamed", func(b *testing.B) {
+		run(b, func(h *Handler) {
+			if processed, errs := h.ProcessStream(ctx, "email", bytes.NewReader(raw)); len(processed) != 3 {
+				b.Fatalf("Processed %d attachments: %+v", len(processed), errs)
+			}
+		})
+	})
+}
+
+// peakHeap runs fn and returns the highest live heap it reached above the heap before it started
+func peakHeap(fn func()) uint64 {
+	sample := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	read := func() uint64 {
+		metrics.Read(sample)
+		return sample[0].Value.Uint64()
+	}
+
+	runtime.GC()
+	base := read()
+	peak := base
+	done := make(chan struct{})
+	sampled := make(chan struct{})
+	go func() {
+		defer close(sampled)
+		ticker := time.NewTicker(200 * time.Microsecond)
+		defer ticker.Stop()
+		for {
+			peak = max(peak, read())
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	fn()
+	close(done)
+	<-sampled
+	return max(peak, read()) - base
+}
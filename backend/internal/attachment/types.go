@@ -132,6 +132,7 @@ var ExtensionContentTypeMap = map[string][]string{
 	".htm":  {"text/html"},
 	".xml":  {"text/xml", "application/xml"},
 	".json": {"application/json", "text/json"},
+	".eml":  {"message/rfc822", "message/global"},
 	
 	// Images
 	".jpg":  {"image/jpeg"},
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	appctx "github.com/welldanyogia/persistent-temp-mail/backend/internal/context"
+)
+
+// ListAppPasswords handles listing the current user's app passwords
+// GET /api/v1/auth/app-passwords
+func (h *AuthHandler) ListAppPasswords(w http.ResponseWriter, r *http.Request) {
+	userID, ok := appctx.ExtractUserID(r.Context())
+	if !ok {
+		h.writeError(w, http.StatusUnauthorized, CodeAuthTokenInvalid, "Invalid or expired token", nil)
+		return
+	}
+
+	appPasswords, err := h.appPasswordService.List(r.Context(), userID)
+	if err != nil {
+		h.handleAppPasswordError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, map[string]interface{}{
+		"app_passwords": appPasswords,
+	})
+}
+
+// CreateAppPassword handles generating a new app password for mail clients
+// POST /api/v1/auth/app-passwords
+// The plaintext password is only returned in this response
+func (h *AuthHandler) CreateAppPassword(w http.ResponseWriter, r *http.Request) {
+	userID, ok := appctx.ExtractUserID(r.Context())
+	if !ok {
+		h.writeError(w, http.StatusUnauthorized, CodeAuthTokenInvalid, "Invalid or expired token", nil)
+		return
+	}
+
+	var req CreateAppPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "Invalid request body", nil)
+		return
+	}
+
+	response, err := h.appPasswordService.Create(r.Context(), userID, req)
+	if err != nil {
+		h.handleAppPasswordError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusCreated, response)
+}
+
+// RevokeAppPassword handles revoking one of the current user's app passwords
+// DELETE /api/v1/auth/app-passwords/{id}
+func (h *AuthHandler) RevokeAppPassword(w http.ResponseWriter, r *http.Request) {
+	userID, ok := appctx.ExtractUserID(r.Context())
+	if !ok {
+		h.writeError(w, http.StatusUnauthorized, CodeAuthTokenInvalid, "Invalid or expired token", nil)
+		return
+	}
+
+	if err := h.appPasswordService.Revoke(r.Context(), userID, chi.URLParam(r, "id")); err != nil {
+		h.handleAppPasswordError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, map[string]interface{}{
+		"message": "App password revoked",
+	})
+}
+
+// handleAppPasswordError maps app password service errors to HTTP responses
+func (h *AuthHandler) handleAppPasswordError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidAppPasswordName):
+		details := map[string][]string{
+			"name": {"Name is required and must be at most 100 characters"},
+		}
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "Request validation failed", details)
+	case errors.Is(err, ErrTooManyAppPasswords):
+		h.writeError(w, http.StatusConflict, CodeAppPasswordLimit, "Maximum of 10 app passwords reached", nil)
+	case errors.Is(err, ErrAppPasswordNotFound):
+		h.writeError(w, http.StatusNotFound, CodeAppPasswordNotFound, "App password not found", nil)
+	case errors.Is(err, ErrUserNotFound):
+		h.writeError(w, http.StatusUnauthorized, CodeAuthTokenInvalid, "Invalid user ID", nil)
+	default:
+		h.writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "An unexpected error occurred", nil)
+	}
+}
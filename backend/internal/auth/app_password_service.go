@@ -0,0 +1,246 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"log/slog"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/repository"
+)
+
+// App password errors
+var (
+	ErrAppPasswordNotFound    = errors.New("app password not found")
+	ErrTooManyAppPasswords    = errors.New("app password limit reached")
+	ErrInvalidAppPasswordName = errors.New("invalid app password name")
+)
+
+// App password error codes for API responses
+const (
+	CodeAppPasswordNotFound = "APP_PASSWORD_NOT_FOUND"
+	CodeAppPasswordLimit    = "APP_PASSWORD_LIMIT_REACHED"
+)
+
+// App password constants
+const (
+	MaxAppPasswordsPerUser = 10
+	MaxAppPasswordNameLen  = 100
+	appPasswordLength      = 16
+	appPasswordGroupSize   = 4
+	appPasswordAlphabet    = "abcdefghijklmnopqrstuvwxyz"
+)
+
+// CreateAppPasswordRequest represents the app password creation payload
+type CreateAppPasswordRequest struct {
+	Name string `json:"name"`
+}
+
+// AppPasswordResponse represents an app password without its secret
+type AppPasswordResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// CreateAppPasswordResponse includes the generated password, which is only shown once
+type CreateAppPasswordResponse struct {
+	AppPasswordResponse
+	Password string `json:"password"`
+}
+
+// AppPasswordService manages per-user app passwords for mail clients
+// App passwords are generated server-side, bcrypt-hashed and never stored in plaintext
+type AppPasswordService struct {
+	userRepo          repository.UserRepository
+	appPasswordRepo   repository.AppPasswordRepository
+	sessionRepo       repository.SessionRepository
+	passwordValidator *PasswordValidator
+	logger            *slog.Logger
+}
+
+// NewAppPasswordService creates a new AppPasswordService instance
+func NewAppPasswordService(
+	userRepo repository.UserRepository,
+	appPasswordRepo repository.AppPasswordRepository,
+	sessionRepo repository.SessionRepository,
+	passwordValidator *PasswordValidator,
+) *AppPasswordService {
+	return &AppPasswordService{
+		userRepo:          userRepo,
+		appPasswordRepo:   appPasswordRepo,
+		sessionRepo:       sessionRepo,
+		passwordValidator: passwordValidator,
+		logger:            slog.Default(),
+	}
+}
+
+// Create generates a new app password for the user and returns it in plaintext once
+func (s *AppPasswordService) Create(ctx context.Context, userID string, req CreateAppPasswordRequest) (*CreateAppPasswordResponse, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > MaxAppPasswordNameLen {
+		return nil, ErrInvalidAppPasswordName
+	}
+
+	count, err := s.appPasswordRepo.CountByUserID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if count >= MaxAppPasswordsPerUser {
+		return nil, ErrTooManyAppPasswords
+	}
+
+	password, err := generateAppPassword()
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := s.passwordValidator.HashPassword(normalizeAppPassword(password))
+	if err != nil {
+		return nil, err
+	}
+
+	appPassword := &repository.AppPassword{
+		UserID:       uid,
+		Name:         name,
+		PasswordHash: hash,
+	}
+	if err := s.appPasswordRepo.Create(ctx, appPassword); err != nil {
+		return nil, err
+	}
+
+	return &CreateAppPasswordResponse{
+		AppPasswordResponse: toAppPasswordResponse(appPassword),
+		Password:            password,
+	}, nil
+}
+
+// List returns the user's app passwords without their hashes
+func (s *AppPasswordService) List(ctx context.Context, userID string) ([]AppPasswordResponse, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	appPasswords, err := s.appPasswordRepo.ListByUserID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]AppPasswordResponse, 0, len(appPasswords))
+	for i := range appPasswords {
+		responses = append(responses, toAppPasswordResponse(&appPasswords[i]))
+	}
+	return responses, nil
+}
+
+// Revoke deletes one of the user's app passwords
+func (s *AppPasswordService) Revoke(ctx context.Context, userID, appPasswordID string) error {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	id, err := uuid.Parse(appPasswordID)
+	if err != nil {
+		return ErrAppPasswordNotFound
+	}
+
+	if err := s.appPasswordRepo.Delete(ctx, id, uid); err != nil {
+		if errors.Is(err, repository.ErrAppPasswordNotFound) {
+			return ErrAppPasswordNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// AuthenticateAppPassword verifies mail client credentials and returns the user ID
+// Failed attempts share the login brute force protection window
+func (s *AppPasswordService) AuthenticateAppPassword(ctx context.Context, username, password, ipAddress string) (string, error) {
+	email := strings.TrimSpace(strings.ToLower(username))
+
+	since := time.Now().UTC().Add(-FailedAttemptWindow)
+	failedAttempts, err := s.sessionRepo.CountFailedAttempts(ctx, email, since)
+	if err != nil {
+		return "", err
+	}
+	if failedAttempts >= MaxFailedAttempts {
+		return "", ErrTooManyAttempts
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			_ = s.sessionRepo.RecordFailedAttempt(ctx, email, ipAddress)
+			return "", ErrInvalidCredentials
+		}
+		return "", err
+	}
+
+	normalized := normalizeAppPassword(password)
+	if !user.IsActive || len(normalized) != appPasswordLength {
+		_ = s.sessionRepo.RecordFailedAttempt(ctx, email, ipAddress)
+		return "", ErrInvalidCredentials
+	}
+
+	appPasswords, err := s.appPasswordRepo.ListByUserID(ctx, user.ID)
+	if err != nil {
+		return "", err
+	}
+
+	for _, appPassword := range appPasswords {
+		if s.passwordValidator.VerifyPassword(normalized, appPassword.PasswordHash) != nil {
+			continue
+		}
+		if err := s.appPasswordRepo.UpdateLastUsed(ctx, appPassword.ID); err != nil {
+			s.logger.Warn("Failed to update app password last use", "app_password_id", appPassword.ID, "error", err)
+		}
+		return user.ID.String(), nil
+	}
+
+	_ = s.sessionRepo.RecordFailedAttempt(ctx, email, ipAddress)
+	return "", ErrInvalidCredentials
+}
+
+// generateAppPassword returns a random password formatted as xxxx-xxxx-xxxx-xxxx
+func generateAppPassword() (string, error) {
+	var b strings.Builder
+	max := big.NewInt(int64(len(appPasswordAlphabet)))
+	for i := 0; i < appPasswordLength; i++ {
+		if i > 0 && i%appPasswordGroupSize == 0 {
+			b.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(appPasswordAlphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
+
+// normalizeAppPassword strips the separators mail clients may or may not keep
+func normalizeAppPassword(password string) string {
+	replacer := strings.NewReplacer("-", "", " ", "")
+	return strings.ToLower(replacer.Replace(password))
+}
+
+// toAppPasswordResponse converts a repository app password to its API shape
+func toAppPasswordResponse(p *repository.AppPassword) AppPasswordResponse {
+	return AppPasswordResponse{
+		ID:         p.ID.String(),
+		Name:       p.Name,
+		CreatedAt:  p.CreatedAt,
+		LastUsedAt: p.LastUsedAt,
+	}
+}
@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/repository"
+	"pgregory.net/rapid"
+)
+
+// mockAppPasswordRepository implements repository.AppPasswordRepository for testing
+type mockAppPasswordRepository struct {
+	passwords map[uuid.UUID]*repository.AppPassword
+}
+
+func newMockAppPasswordRepository() *mockAppPasswordRepository {
+	return &mockAppPasswordRepository{passwords: make(map[uuid.UUID]*repository.AppPassword)}
+}
+
+func (m *mockAppPasswordRepository) Create(ctx context.Context, p *repository.AppPassword) error {
+	p.ID = uuid.New()
+	p.CreatedAt = time.Now().UTC()
+	m.passwords[p.ID] = p
+	return nil
+}
+
+func (m *mockAppPasswordRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]repository.AppPassword, error) {
+	var result []repository.AppPassword
+	for _, p := range m.passwords {
+		if p.UserID == userID {
+			result = append(result, *p)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockAppPasswordRepository) CountByUserID(ctx context.Context, userID uuid.UUID) (int, error) {
+	list, _ := m.ListByUserID(ctx, userID)
+	return len(list), nil
+}
+
+func (m *mockAppPasswordRepository) Delete(ctx context.Context, id, userID uuid.UUID) error {
+	p, ok := m.passwords[id]
+	if !ok || p.UserID != userID {
+		return repository.ErrAppPasswordNotFound
+	}
+	delete(m.passwords, id)
+	return nil
+}
+
+func (m *mockAppPasswordRepository) UpdateLastUsed(ctx context.Context, id uuid.UUID) error {
+	if p, ok := m.passwords[id]; ok {
+		now := time.Now().UTC()
+		p.LastUsedAt = &now
+	}
+	return nil
+}
+
+func newTestAppPasswordService() (*AppPasswordService, *repository.User, *mockAppPasswordRepository, *mockSessionRepository) {
+	userRepo := newMockUserRepository()
+	user := &repository.User{Email: "owner@example.com", IsActive: true}
+	userRepo.Create(context.Background(), user)
+
+	appPasswordRepo := newMockAppPasswordRepository()
+	sessionRepo := newMockSessionRepository()
+	service := NewAppPasswordService(userRepo, appPasswordRepo, sessionRepo, NewPasswordValidator())
+	return service, user, appPasswordRepo, sessionRepo
+}
+
+// TestGenerateAppPassword_Format verifies generated passwords are grouped lowercase letters
+func TestGenerateAppPassword_Format(t *testing.T) {
+	pattern := regexp.MustCompile(`^[a-z]{4}-[a-z]{4}-[a-z]{4}-[a-z]{4}$`)
+	rapid.Check(t, func(t *rapid.T) {
+		password, err := generateAppPassword()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !pattern.MatchString(password) {
+			t.Fatalf("Unexpected app password format %q", password)
+		}
+		if normalized := normalizeAppPassword(strings.ToUpper(strings.ReplaceAll(password, "-", " "))); len(normalized) != appPasswordLength {
+			t.Fatalf("Expected normalized password of %d letters, got %q", appPasswordLength, normalized)
+		}
+	})
+}
+
+// TestAppPassword_CreateAuthenticateRevoke verifies the plaintext is shown once, stored hashed and revocable
+func TestAppPassword_CreateAuthenticateRevoke(t *testing.T) {
+	service, user, repo, _ := newTestAppPasswordService()
+	ctx := context.Background()
+
+	created, err := service.Create(ctx, user.ID.String(), CreateAppPasswordRequest{Name: "  Laptop  "})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if created.Name != "Laptop" || created.Password == "" {
+		t.Fatalf("Unexpected create response: %+v", created)
+	}
+
+	stored := repo.passwords[uuid.MustParse(created.ID)]
+	if stored.PasswordHash == created.Password || strings.Contains(stored.PasswordHash, normalizeAppPassword(created.Password)) {
+		t.Fatal("App password must not be stored in plaintext")
+	}
+
+	// Separators and case are ignored so clients may paste the password either way
+	userID, err := service.AuthenticateAppPassword(ctx, "Owner@Example.com", strings.ToUpper(normalizeAppPassword(created.Password)), "203.0.113.7")
+	if err != nil || userID != user.ID.String() {
+		t.Fatalf("Expected authentication to succeed, got %q, %v", userID, err)
+	}
+	if stored.LastUsedAt == nil {
+		t.Fatal("Expected last_used_at to be updated")
+	}
+
+	list, err := service.List(ctx, user.ID.String())
+	if err != nil || len(list) != 1 || list[0].LastUsedAt == nil {
+		t.Fatalf("Unexpected list result: %+v, %v", list, err)
+	}
+
+	if err := service.Revoke(ctx, uuid.New().String(), created.ID); !errors.Is(err, ErrAppPasswordNotFound) {
+		t.Fatalf("Expected ErrAppPasswordNotFound for another user, got %v", err)
+	}
+	if err := service.Revoke(ctx, user.ID.String(), created.ID); err != nil {
+		t.Fatalf("Revoke failed: %v", err)
+	}
+	if _, err := service.AuthenticateAppPassword(ctx, user.Email, created.Password, "203.0.113.7"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("Expected revoked password to fail, got %v", err)
+	}
+}
+
+// TestAppPassword_Validation verifies name validation and the per-user limit
+func TestAppPassword_Validation(t *testing.T) {
+	service, user, repo, _ := newTestAppPasswordService()
+	ctx := context.Background()
+
+	for _, name := range []string{"", "   ", strings.Repeat("x", MaxAppPasswordNameLen+1)} {
+		if _, err := service.Create(ctx, user.ID.String(), CreateAppPasswordRequest{Name: name}); !errors.Is(err, ErrInvalidAppPasswordName) {
+			t.Errorf("Expected ErrInvalidAppPasswordName for %q, got %v", name, err)
+		}
+	}
+
+	for i := 0; i < MaxAppPasswordsPerUser; i++ {
+		repo.Create(ctx, &repository.AppPassword{UserID: user.ID, Name: "existing", PasswordHash: "x"})
+	}
+	if _, err := service.Create(ctx, user.ID.String(), CreateAppPasswordRequest{Name: "one too many"}); !errors.Is(err, ErrTooManyAppPasswords) {
+		t.Fatalf("Expected ErrTooManyAppPasswords, got %v", err)
+	}
+}
+
+// TestAppPassword_BruteForceProtection verifies failed attempts lock authentication
+func TestAppPassword_BruteForceProtection(t *testing.T) {
+	service, user, _, sessionRepo := newTestAppPasswordService()
+	ctx := context.Background()
+
+	if _, err := service.AuthenticateAppPassword(ctx, "nobody@example.com", "abcdabcdabcdabcd", "203.0.113.7"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("Expected ErrInvalidCredentials for unknown user, got %v", err)
+	}
+
+	for i := 0; i < MaxFailedAttempts; i++ {
+		if _, err := service.AuthenticateAppPassword(ctx, user.Email, "wrong", "203.0.113.7"); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("Expected ErrInvalidCredentials, got %v", err)
+		}
+	}
+	if len(sessionRepo.failedAttempts[user.Email]) != MaxFailedAttempts {
+		t.Fatalf("Expected %d recorded failures, got %d", MaxFailedAttempts, len(sessionRepo.failedAttempts[user.Email]))
+	}
+	if _, err := service.AuthenticateAppPassword(ctx, user.Email, "wrong", "203.0.113.7"); !errors.Is(err, ErrTooManyAttempts) {
+		t.Fatalf("Expected ErrTooManyAttempts, got %v", err)
+	}
+}
@@ -26,7 +26,8 @@ type APIError struct {
 
 // AuthHandler handles HTTP requests for authentication endpoints
 type AuthHandler struct {
-	authService *AuthService
+	authService        *AuthService
+	appPasswordService *AppPasswordService // Optional; app password routes are only registered when set
 }
 
 // NewAuthHandler creates a new AuthHandler instance
@@ -36,6 +37,14 @@ func NewAuthHandler(authService *AuthService) *AuthHandler {
 	}
 }
 
+// NewAuthHandlerWithAppPasswords creates a new AuthHandler that also manages app passwords
+func NewAuthHandlerWithAppPasswords(authService *AuthService, appPasswordService *AppPasswordService) *AuthHandler {
+	return &AuthHandler{
+		authService:        authService,
+		appPasswordService: appPasswordService,
+	}
+}
+
 // Register handles user registration
 // POST /api/v1/auth/register
 // Requirements: 1.1-1.7
@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"context"
+	"strings"
+)
+
+// MailClientAuthenticator verifies mail client (IMAP) credentials
+// The password is either an app password or a JWT access token issued to the same account
+type MailClientAuthenticator struct {
+	appPasswords *AppPasswordService
+	tokenService *TokenService
+}
+
+// NewMailClientAuthenticator creates a new MailClientAuthenticator
+func NewMailClientAuthenticator(appPasswords *AppPasswordService, tokenService *TokenService) *MailClientAuthenticator {
+	return &MailClientAuthenticator{
+		appPasswords: appPasswords,
+		tokenService: tokenService,
+	}
+}
+
+// Authenticate returns the user ID for valid credentials
+// A JWT is only accepted when its email claim matches the username, so a leaked token
+// cannot be replayed against another account name
+func (a *MailClientAuthenticator) Authenticate(ctx context.Context, username, password, remoteIP string) (string, error) {
+	if a.tokenService != nil && looksLikeJWT(password) {
+		claims, err := a.tokenService.ValidateAccessToken(password)
+		if err != nil || !strings.EqualFold(claims.Email, strings.TrimSpace(username)) {
+			return "", ErrInvalidCredentials
+		}
+		return claims.UserID(), nil
+	}
+
+	return a.appPasswords.AuthenticateAppPassword(ctx, username, password, remoteIP)
+}
+
+// looksLikeJWT reports whether s has the three dot-separated segments of a compact JWT
+// App passwords only contain letters, dashes and spaces
+func looksLikeJWT(s string) bool {
+	return strings.Count(s, ".") == 2 && !strings.ContainsAny(s, " \t")
+}
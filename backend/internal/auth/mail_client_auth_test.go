@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestMailClientAuthenticator verifies IMAP logins with app passwords and access tokens
+func TestMailClientAuthenticator(t *testing.T) {
+	appPasswords, user, _, _ := newTestAppPasswordService()
+	tokenService := newTestTokenService()
+	authenticator := NewMailClientAuthenticator(appPasswords, tokenService)
+	ctx := context.Background()
+
+	created, err := appPasswords.Create(ctx, user.ID.String(), CreateAppPasswordRequest{Name: "Phone"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if userID, err := authenticator.Authenticate(ctx, user.Email, created.Password, "203.0.113.7"); err != nil || userID != user.ID.String() {
+		t.Fatalf("Expected app password login to succeed, got %q, %v", userID, err)
+	}
+
+	accessToken, err := tokenService.GenerateAccessToken(user.ID.String(), user.Email)
+	if err != nil {
+		t.Fatalf("GenerateAccessToken failed: %v", err)
+	}
+	if userID, err := authenticator.Authenticate(ctx, "OWNER@example.com", accessToken, "203.0.113.7"); err != nil || userID != user.ID.String() {
+		t.Fatalf("Expected access token login to succeed, got %q, %v", userID, err)
+	}
+
+	// The token must belong to the account named in LOGIN
+	if _, err := authenticator.Authenticate(ctx, "someone@example.com", accessToken, "203.0.113.7"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("Expected ErrInvalidCredentials for mismatched username, got %v", err)
+	}
+
+	// Refresh tokens are not access tokens
+	refreshToken, err := tokenService.GenerateRefreshToken(user.ID.String())
+	if err != nil {
+		t.Fatalf("GenerateRefreshToken failed: %v", err)
+	}
+	if _, err := authenticator.Authenticate(ctx, user.Email, refreshToken, "203.0.113.7"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("Expected ErrInvalidCredentials for refresh token, got %v", err)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"testing"
 	"unicode"
 
-	"golang.org/x/crypto/bcrypt"
 	"pgregory.net/rapid"
 )
 
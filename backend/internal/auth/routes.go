@@ -11,7 +11,7 @@ type Middleware func(http.Handler) http.Handler
 
 // RegisterRoutes registers all authentication routes with the Chi router
 // Public routes: /register, /login, /refresh
-// Protected routes: /logout, /me
+// Protected routes: /logout, /me, /app-passwords (when app passwords are enabled)
 func RegisterRoutes(r chi.Router, handler *AuthHandler, authMiddleware Middleware) {
 	r.Route("/auth", func(r chi.Router) {
 		// Public routes (no authentication required)
@@ -25,6 +25,13 @@ func RegisterRoutes(r chi.Router, handler *AuthHandler, authMiddleware Middlewar
 			r.Post("/logout", handler.Logout)
 			r.Get("/me", handler.GetMe)
 			r.Delete("/me", handler.DeleteMe) // Delete user account with cascade delete
+
+			// App passwords for mail clients (SMTP submission)
+			if handler.appPasswordService != nil {
+				r.Get("/app-passwords", handler.ListAppPasswords)
+				r.Post("/app-passwords", handler.CreateAppPassword)
+				r.Delete("/app-passwords/{id}", handler.RevokeAppPassword)
+			}
 		})
 	})
 }
@@ -15,7 +15,12 @@ type Config struct {
 	Domain   DomainConfig
 	Storage  StorageConfig
 	Alias    AliasConfig
+	Email    EmailConfig
+	Export   ExportConfig
+	Import   ImportConfig
 	SMTP     SMTPConfig
+	IMAP     IMAPConfig
+	POP3     POP3Config
 	SSE      SSEConfig
 	SSL      SSLConfig
 	Redis    RedisConfig
@@ -90,6 +95,30 @@ type SMTPConfig struct {
 	TLSCertFile         string        // Path to TLS certificate file
 	TLSKeyFile          string        // Path to TLS private key file
 	TLSEnabled          bool          // Whether STARTTLS is enabled
+	DrainTimeout        time.Duration // Time in-flight transactions may run during shutdown (default: 30 seconds)
+
+	TranscriptEnabled      bool // Whether opt-in session transcript capture is active (default: true)
+	TranscriptMaxPerDomain int  // Transcripts kept per domain before the oldest are trimmed (default: 500)
+
+	SubmissionPort int // Authenticated submission port requiring STARTTLS + AUTH (default: 587, 0 disables)
+}
+
+// IMAPConfig holds IMAP server configuration
+// The IMAP server runs in the SMTP process and shares its hostname and TLS certificate
+type IMAPConfig struct {
+	Port                int           // IMAP port requiring STARTTLS before LOGIN (default: 143, 0 disables)
+	IdleTimeout         time.Duration // Autologout timer, also bounds a single IDLE (default: 30 minutes)
+	MaxConnections      int           // Maximum concurrent connections (default: 100)
+	MaxConnectionsPerIP int           // Maximum connections per IP (default: 10)
+}
+
+// POP3Config holds POP3 server configuration
+// The POP3 server runs in the SMTP process and shares its hostname and TLS certificate
+type POP3Config struct {
+	Port                int           // POP3 port requiring STLS before USER/PASS (default: 110, 0 disables)
+	IdleTimeout         time.Duration // Autologout timer (default: 10 minutes, the RFC 1939 minimum)
+	MaxConnections      int           // Maximum concurrent connections (default: 100)
+	MaxConnectionsPerIP int           // Maximum connections per IP (default: 10)
 }
 
 // ServerConfig holds HTTP server configuration
@@ -145,6 +174,27 @@ type AliasConfig struct {
 	MaxAliasesPerUser int // Maximum number of aliases per user (default: 50)
 }
 
+// EmailConfig holds email inbox configuration
+type EmailConfig struct {
+	SnoozeInterval     time.Duration // Time between checks for snoozed emails to wake (default: 1 minute)
+	TrashRetentionDays int           // Days trashed emails are kept before being permanently deleted (default: 30)
+	TrashPurgeInterval time.Duration // Time between purges of expired trashed emails (default: 60 minutes)
+	RetentionInterval  time.Duration // Time between deletions of emails expired by retention policies (default: 15 minutes)
+}
+
+// ExportConfig holds mailbox export configuration
+type ExportConfig struct {
+	Workers   int           // Exports built at the same time (default: 2)
+	Retention time.Duration // How long finished archives stay downloadable (default: 7 days)
+	URLExpiry time.Duration // Lifetime of export download URLs (default: 1 hour)
+}
+
+// ImportConfig holds mailbox import configuration
+type ImportConfig struct {
+	Workers       int   // Imports processed at the same time (default: 1)
+	MaxUploadSize int64 // Maximum uploaded archive size in bytes (default: 2 GB)
+}
+
 // Load reads configuration from environment variables
 func Load() *Config {
 	return &Config{
@@ -189,6 +239,21 @@ func Load() *Config {
 		Alias: AliasConfig{
 			MaxAliasesPerUser: getIntEnv("ALIAS_MAX_PER_USER", 50),
 		},
+		Email: EmailConfig{
+			SnoozeInterval:     getDurationEnv("EMAIL_SNOOZE_INTERVAL", time.Minute),
+			TrashRetentionDays: getIntEnv("EMAIL_TRASH_RETENTION_DAYS", 30),
+			TrashPurgeInterval: getDurationEnv("EMAIL_TRASH_PURGE_INTERVAL", 60*time.Minute),
+			RetentionInterval:  getDurationEnv("EMAIL_RETENTION_INTERVAL", 15*time.Minute),
+		},
+		Export: ExportConfig{
+			Workers:   getIntEnv("EXPORT_WORKERS", 2),
+			Retention: getDurationEnv("EXPORT_RETENTION", 7*24*time.Hour), // 7 days
+			URLExpiry: getDurationEnv("EXPORT_URL_EXPIRY", time.Hour),
+		},
+		Import: ImportConfig{
+			Workers:       getIntEnv("IMPORT_WORKERS", 1),
+			MaxUploadSize: getInt64Env("IMPORT_MAX_UPLOAD_SIZE", 2*1024*1024*1024), // 2 GB
+		},
 		SMTP: SMTPConfig{
 			Port:                getIntEnv("SMTP_PORT", 25),
 			Hostname:            getEnv("SMTP_HOSTNAME", "mail.webrana.id"),
@@ -201,6 +266,24 @@ func Load() *Config {
 			TLSCertFile:         getEnv("SMTP_TLS_CERT_FILE", ""),
 			TLSKeyFile:          getEnv("SMTP_TLS_KEY_FILE", ""),
 			TLSEnabled:          getBoolEnv("SMTP_TLS_ENABLED", false),
+			DrainTimeout:        getDurationEnv("SMTP_DRAIN_TIMEOUT", 30*time.Second),
+
+			TranscriptEnabled:      getBoolEnv("SMTP_TRANSCRIPT_ENABLED", true),
+			TranscriptMaxPerDomain: getIntEnv("SMTP_TRANSCRIPT_MAX_PER_DOMAIN", 500),
+
+			SubmissionPort: getIntEnv("SMTP_SUBMISSION_PORT", 587),
+		},
+		IMAP: IMAPConfig{
+			Port:                getIntEnv("IMAP_PORT", 143),
+			IdleTimeout:         getDurationEnv("IMAP_IDLE_TIMEOUT", 30*time.Minute),
+			MaxConnections:      getIntEnv("IMAP_MAX_CONNECTIONS", 100),
+			MaxConnectionsPerIP: getIntEnv("IMAP_MAX_CONNECTIONS_PER_IP", 10),
+		},
+		POP3: POP3Config{
+			Port:                getIntEnv("POP3_PORT", 110),
+			IdleTimeout:         getDurationEnv("POP3_IDLE_TIMEOUT", 10*time.Minute),
+			MaxConnections:      getIntEnv("POP3_MAX_CONNECTIONS", 100),
+			MaxConnectionsPerIP: getIntEnv("POP3_MAX_CONNECTIONS_PER_IP", 10),
 		},
 		SSE: SSEConfig{
 			HeartbeatInterval:     getDurationEnv("SSE_HEARTBEAT_INTERVAL", 30*time.Second),
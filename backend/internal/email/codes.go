@@ -0,0 +1,60 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/parser"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/repository"
+)
+
+// CodesResponse lists the one-time codes and account links found in an email
+type CodesResponse struct {
+	EmailID string            `json:"email_id"`
+	OTPs    []parser.OTPCode  `json:"otps"`
+	Links   []parser.CodeLink `json:"links"`
+}
+
+// GetCodes returns the one-time codes and verification, magic sign-in and password reset links of an email
+// Codes are extracted at ingest; emails stored before that are searched on demand.
+func (s *Service) GetCodes(ctx context.Context, userID uuid.UUID, emailID string) (*CodesResponse, error) {
+	email, err := s.getOwnedEmail(ctx, userID, emailID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &CodesResponse{
+		EmailID: email.ID.String(),
+		OTPs:    []parser.OTPCode{},
+		Links:   []parser.CodeLink{},
+	}
+	if codes := emailCodes(email); codes != nil {
+		if codes.OTPs != nil {
+			response.OTPs = codes.OTPs
+		}
+		if codes.Links != nil {
+			response.Links = codes.Links
+		}
+	}
+	return response, nil
+}
+
+// emailCodes returns the stored codes of an email, extracting them if none were stored
+func emailCodes(email *repository.Email) *parser.Codes {
+	if len(email.Codes) > 0 {
+		var codes parser.Codes
+		if err := json.Unmarshal(email.Codes, &codes); err == nil {
+			return &codes
+		}
+	}
+	return parser.ExtractCodes(email.SenderAddress, derefString(email.Subject), derefString(email.BodyText), derefString(email.BodyHTML))
+}
+
+// derefString returns the value of a nullable column, or ""
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
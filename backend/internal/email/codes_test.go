@@ -0,0 +1,40 @@
+package email
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/parser"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/repository"
+)
+
+// TestEmailCodes verifies stored codes are returned and older emails are searched on demand
+func TestEmailCodes(t *testing.T) {
+	stored := &repository.Email{
+		SenderAddress: "no-reply@example.com",
+		Codes:         json.RawMessage(`{"otps":[{"code":"111222","source":"subject"}]}`),
+	}
+	codes := emailCodes(stored)
+	if codes == nil || len(codes.OTPs) != 1 || codes.OTPs[0].Code != "111222" {
+		t.Errorf("Stored codes = %+v", codes)
+	}
+
+	subject := "Your login code"
+	html := `<p>Use 482913 to sign in.</p><a href="https://example.com/verify?token=abc">Verify email</a>`
+	legacy := &repository.Email{
+		SenderAddress: "no-reply@example.com",
+		Subject:       &subject,
+		BodyHTML:      &html,
+	}
+	codes = emailCodes(legacy)
+	if codes == nil || len(codes.OTPs) != 1 || codes.OTPs[0].Code != "482913" {
+		t.Fatalf("Extracted codes = %+v", codes)
+	}
+	if len(codes.Links) != 1 || codes.Links[0].Kind != parser.LinkVerification {
+		t.Errorf("Extracted links = %+v", codes.Links)
+	}
+
+	if codes := emailCodes(&repository.Email{SenderAddress: "a@example.com"}); codes != nil {
+		t.Errorf("Email without codes = %+v", codes)
+	}
+}
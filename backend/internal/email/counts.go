@@ -0,0 +1,45 @@
+package email
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// EmailCountResponse represents how many emails a listing returns
+type EmailCountResponse struct {
+	TotalCount  int `json:"total_count"`
+	UnreadCount int `json:"unread_count"`
+}
+
+// Count returns how many emails List returns for params, and how many of them are unread
+// Pagination, sort and cursor params are ignored.
+func (s *Service) Count(ctx context.Context, userID uuid.UUID, params ListEmailParams) (*EmailCountResponse, error) {
+	repoParams, err := toRepositoryParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	total, unread, err := s.emailRepo.CountEmails(ctx, userID, repoParams)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count emails: %w", err)
+	}
+
+	return &EmailCountResponse{TotalCount: total, UnreadCount: unread}, nil
+}
+
+// Matches reports whether List returns an email of the user for params
+func (s *Service) Matches(ctx context.Context, userID, emailID uuid.UUID, params ListEmailParams) (bool, error) {
+	repoParams, err := toRepositoryParams(params)
+	if err != nil {
+		return false, err
+	}
+	repoParams.EmailID = &emailID
+
+	total, _, err := s.emailRepo.CountEmails(ctx, userID, repoParams)
+	if err != nil {
+		return false, fmt.Errorf("failed to match email: %w", err)
+	}
+	return total > 0, nil
+}
@@ -4,6 +4,7 @@
 package email
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
@@ -17,6 +18,7 @@ import (
 	"github.com/google/uuid"
 
 	appctx "github.com/welldanyogia/persistent-temp-mail/backend/internal/context"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/parser"
 )
 
 // APIResponse represents the standard API response format
@@ -53,6 +55,8 @@ func NewHandler(emailService *Service, logger *slog.Logger) *Handler {
 
 
 // List handles GET /api/v1/emails
+// The search parameter takes the query language of ParseSearch; text searches are ranked and return snippets
+// The cursor parameter pages from a previous response's next_cursor or prev_cursor instead of by page number
 // Requirements: 1.1-1.9 (List emails with filters)
 func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 	userIDStr, ok := appctx.ExtractUserID(r.Context())
@@ -92,10 +96,20 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 		params.AliasID = aliasID
 	}
 
+	if labelID := r.URL.Query().Get("label_id"); labelID != "" {
+		params.LabelID = labelID
+	}
+
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		params.Cursor = cursor
+	}
+
 	if search := r.URL.Query().Get("search"); search != "" {
-		if len(search) <= 100 {
-			params.Search = search
+		if len(search) > MaxSearchLength {
+			h.writeError(w, http.StatusBadRequest, CodeValidationError, "Search query is too long", nil)
+			return
 		}
+		params.Search = search
 	}
 
 	// Parse date filters
@@ -123,6 +137,25 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 		params.IsRead = &isRead
 	}
 
+	// Parse star, archive and snooze filters
+	if isStarredStr := r.URL.Query().Get("is_starred"); isStarredStr != "" {
+		isStarred := isStarredStr == "true"
+		params.IsStarred = &isStarred
+	}
+
+	if isArchivedStr := r.URL.Query().Get("is_archived"); isArchivedStr != "" {
+		isArchived := isArchivedStr == "true"
+		params.IsArchived = &isArchived
+	}
+
+	if isSnoozedStr := r.URL.Query().Get("is_snoozed"); isSnoozedStr != "" {
+		isSnoozed := isSnoozedStr == "true"
+		params.IsSnoozed = &isSnoozed
+	}
+
+	// Parse in_trash to list the trash instead of the inbox
+	params.InTrash = r.URL.Query().Get("in_trash") == "true"
+
 	// Parse sort and order
 	if sort := r.URL.Query().Get("sort"); sort != "" {
 		if sort == "received_at" || sort == "size" {
@@ -138,6 +171,14 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 
 	response, err := h.emailService.List(r.Context(), userID, params)
 	if err != nil {
+		if errors.Is(err, ErrInvalidSearch) {
+			h.writeError(w, http.StatusBadRequest, CodeValidationError, err.Error(), nil)
+			return
+		}
+		if errors.Is(err, ErrInvalidCursor) {
+			h.writeError(w, http.StatusBadRequest, CodeValidationError, "Invalid or expired cursor for this sort", nil)
+			return
+		}
 		h.logger.Error("Failed to list emails", "error", err, "user_id", userID)
 		h.writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list emails", nil)
 		return
@@ -185,8 +226,268 @@ func (h *Handler) GetByID(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// GetCodes handles GET /api/v1/emails/:id/codes
+// Returns the one-time codes and verification, magic sign-in and password reset links of an email
+func (h *Handler) GetCodes(w http.ResponseWriter, r *http.Request) {
+	userIDStr, ok := appctx.ExtractUserID(r.Context())
+	if !ok {
+		h.writeError(w, http.StatusUnauthorized, "AUTH_TOKEN_INVALID", "Invalid or expired token", nil)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		h.writeError(w, http.StatusUnauthorized, "AUTH_TOKEN_INVALID", "Invalid user ID", nil)
+		return
+	}
+
+	emailID := chi.URLParam(r, "id")
+	if emailID == "" {
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "Email ID is required", nil)
+		return
+	}
+
+	codes, err := h.emailService.GetCodes(r.Context(), userID, emailID)
+	if err != nil {
+		h.handleEmailError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, codes)
+}
+
+// GetEmbedded handles GET /api/v1/emails/:id/embedded/:path
+// Returns a forwarded message (message/rfc822 part) in the same shape as the email details
+func (h *Handler) GetEmbedded(w http.ResponseWriter, r *http.Request) {
+	userIDStr, ok := appctx.ExtractUserID(r.Context())
+	if !ok {
+		h.writeError(w, http.StatusUnauthorized, "AUTH_TOKEN_INVALID", "Invalid or expired token", nil)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		h.writeError(w, http.StatusUnauthorized, "AUTH_TOKEN_INVALID", "Invalid user ID", nil)
+		return
+	}
+
+	emailID := chi.URLParam(r, "id")
+	path := chi.URLParam(r, "path")
+	if emailID == "" || path == "" {
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "Email ID and embedded message path are required", nil)
+		return
+	}
+
+	email, err := h.emailService.GetEmbedded(r.Context(), userID, emailID, path)
+	if err != nil {
+		h.handleEmailError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, map[string]interface{}{
+		"email": email,
+	})
+}
+
+// DownloadEmbeddedAttachment handles GET /api/v1/emails/:id/embedded/:path/attachments/:attachmentId
+// Streams an attachment of a forwarded message; attachment IDs are positions in its attachment list
+// Query parameters:
+//   - inline: "true" to display inline (Content-Disposition: inline)
+func (h *Handler) DownloadEmbeddedAttachment(w http.ResponseWriter, r *http.Request) {
+	userIDStr, ok := appctx.ExtractUserID(r.Context())
+	if !ok {
+		h.writeError(w, http.StatusUnauthorized, "AUTH_TOKEN_INVALID", "Invalid or expired token", nil)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		h.writeError(w, http.StatusUnauthorized, "AUTH_TOKEN_INVALID", "Invalid user ID", nil)
+		return
+	}
+
+	emailID := chi.URLParam(r, "id")
+	path := chi.URLParam(r, "path")
+	attachmentID := chi.URLParam(r, "attachmentId")
+	if emailID == "" || path == "" || attachmentID == "" {
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "Email ID, embedded message path and attachment ID are required", nil)
+		return
+	}
+	logID := "embedded/" + path + "/" + attachmentID
+
+	attachment, err := h.emailService.GetEmbeddedAttachment(r.Context(), userID, emailID, path, attachmentID)
+	if err != nil {
+		h.logDownloadAttempt(r, userIDStr, emailID, logID, false, h.getErrorCode(err))
+		h.handleEmailError(w, err)
+		return
+	}
+	defer attachment.Data.Close()
+
+	h.logDownloadAttempt(r, userIDStr, emailID, logID, true, "")
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.SizeBytes, 10))
+	w.Header().Set("X-File-Size", strconv.FormatInt(attachment.SizeBytes, 10))
+	w.Header().Set("X-File-Hash", "sha256:"+attachment.Checksum)
+
+	disposition := "attachment"
+	if r.URL.Query().Get("inline") == "true" {
+		disposition = "inline"
+	}
+	w.Header().Set("Content-Disposition", parser.FormatContentDisposition(disposition, attachment.Filename))
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, attachment.Data); err != nil {
+		h.logger.Error("Failed to stream embedded attachment", "error", err, "attachment_id", logID)
+	}
+}
+
+// GetMIME handles GET /api/v1/emails/:id/mime
+// Returns the MIME tree with part IDs and the parts chosen as the HTML and text bodies
+func (h *Handler) GetMIME(w http.ResponseWriter, r *http.Request) {
+	userIDStr, ok := appctx.ExtractUserID(r.Context())
+	if !ok {
+		h.writeError(w, http.StatusUnauthorized, "AUTH_TOKEN_INVALID", "Invalid or expired token", nil)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		h.writeError(w, http.StatusUnauthorized, "AUTH_TOKEN_INVALID", "Invalid user ID", nil)
+		return
+	}
+
+	emailID := chi.URLParam(r, "id")
+	if emailID == "" {
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "Email ID is required", nil)
+		return
+	}
+
+	structure, err := h.emailService.GetMIMEStructure(r.Context(), userID, emailID)
+	if err != nil {
+		h.handleEmailError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, structure)
+}
+
+// DownloadMIMEPart handles GET /api/v1/emails/:id/mime/:partId
+// Downloads a leaf part by its MIME part ID, always as an attachment
+// Query parameters:
+//   - raw: "true" for the part as sent, without transfer decoding
+func (h *Handler) DownloadMIMEPart(w http.ResponseWriter, r *http.Request) {
+	userIDStr, ok := appctx.ExtractUserID(r.Context())
+	if !ok {
+		h.writeError(w, http.StatusUnauthorized, "AUTH_TOKEN_INVALID", "Invalid or expired token", nil)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		h.writeError(w, http.StatusUnauthorized, "AUTH_TOKEN_INVALID", "Invalid user ID", nil)
+		return
+	}
+
+	emailID := chi.URLParam(r, "id")
+	partID := chi.URLParam(r, "partId")
+	if emailID == "" || partID == "" {
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "Email ID and part ID are required", nil)
+		return
+	}
+	logID := "mime/" + partID
+
+	part, err := h.emailService.GetMIMEPart(r.Context(), userID, emailID, partID, r.URL.Query().Get("raw") == "true")
+	if err != nil {
+		h.logDownloadAttempt(r, userIDStr, emailID, logID, false, h.getErrorCode(err))
+		h.handleEmailError(w, err)
+		return
+	}
+	defer part.Data.Close()
+
+	h.logDownloadAttempt(r, userIDStr, emailID, logID, true, "")
+
+	// Parts may be HTML from the sender, so never let the browser render or sniff them
+	w.Header().Set("Content-Type", part.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(part.SizeBytes, 10))
+	w.Header().Set("X-File-Size", strconv.FormatInt(part.SizeBytes, 10))
+	w.Header().Set("X-File-Hash", "sha256:"+part.Checksum)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Disposition", parser.FormatContentDisposition("attachment", part.Filename))
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, part.Data); err != nil {
+		h.logger.Error("Failed to stream MIME part", "error", err, "part_id", logID)
+	}
+}
+
+// DownloadRaw handles GET /api/v1/emails/:id/raw
+// Returns the original message as received, for forwarding to abuse desks or debugging rendering
+// Query parameters:
+//   - view: "eml" (default) downloads message/rfc822, "source" shows the whole message as text,
+//     "headers" shows the header section only
+func (h *Handler) DownloadRaw(w http.ResponseWriter, r *http.Request) {
+	userIDStr, ok := appctx.ExtractUserID(r.Context())
+	if !ok {
+		h.writeError(w, http.StatusUnauthorized, "AUTH_TOKEN_INVALID", "Invalid or expired token", nil)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		h.writeError(w, http.StatusUnauthorized, "AUTH_TOKEN_INVALID", "Invalid user ID", nil)
+		return
+	}
+
+	emailID := chi.URLParam(r, "id")
+	if emailID == "" {
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "Email ID is required", nil)
+		return
+	}
+
+	view := RawView(r.URL.Query().Get("view"))
+	switch view {
+	case "":
+		view = RawViewEML
+	case RawViewEML, RawViewSource, RawViewHeaders:
+	default:
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "view must be eml, source or headers", nil)
+		return
+	}
+	logID := "raw/" + string(view)
+
+	raw, err := h.emailService.GetRaw(r.Context(), userID, emailID, view)
+	if err != nil {
+		h.logDownloadAttempt(r, userIDStr, emailID, logID, false, h.getErrorCode(err))
+		h.handleEmailError(w, err)
+		return
+	}
+	defer raw.Data.Close()
+
+	h.logDownloadAttempt(r, userIDStr, emailID, logID, true, "")
+
+	// Text views are shown in the browser; the message itself may contain HTML, so never sniff it
+	disposition := "attachment"
+	if view != RawViewEML {
+		disposition = "inline"
+	}
+	w.Header().Set("Content-Type", raw.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(raw.SizeBytes, 10))
+	w.Header().Set("X-File-Size", strconv.FormatInt(raw.SizeBytes, 10))
+	w.Header().Set("X-File-Hash", "sha256:"+raw.Checksum)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Disposition", parser.FormatContentDisposition(disposition, raw.Filename))
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, raw.Data); err != nil {
+		h.logger.Error("Failed to stream raw email", "error", err, "email_id", emailID)
+	}
+}
+
 // Delete handles DELETE /api/v1/emails/:id
 // Requirements: 4.1-4.5 (Delete email)
+// Query parameters:
+//   - permanent: "true" to delete the email for good instead of moving it to the trash
 func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	userIDStr, ok := appctx.ExtractUserID(r.Context())
 	if !ok {
@@ -206,7 +507,12 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response, err := h.emailService.Delete(r.Context(), userID, emailID)
+	deleteEmail := h.emailService.Delete
+	if r.URL.Query().Get("permanent") == "true" {
+		deleteEmail = h.emailService.DeletePermanently
+	}
+
+	response, err := deleteEmail(r.Context(), userID, emailID)
 	if err != nil {
 		h.handleEmailError(w, err)
 		return
@@ -216,12 +522,68 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 		"message": response.Message,
 		"deleted_resources": map[string]interface{}{
 			"email_id":               response.EmailID,
+			"permanent":              response.Permanent,
+			"purge_at":               response.PurgeAt,
 			"attachments_deleted":    response.AttachmentsDeleted,
 			"total_size_freed_bytes": response.TotalSizeFreedBytes,
 		},
 	})
 }
 
+// Restore handles POST /api/v1/emails/:id/restore
+// Moves an email out of the trash
+func (h *Handler) Restore(w http.ResponseWriter, r *http.Request) {
+	userIDStr, ok := appctx.ExtractUserID(r.Context())
+	if !ok {
+		h.writeError(w, http.StatusUnauthorized, "AUTH_TOKEN_INVALID", "Invalid or expired token", nil)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		h.writeError(w, http.StatusUnauthorized, "AUTH_TOKEN_INVALID", "Invalid user ID", nil)
+		return
+	}
+
+	emailID := chi.URLParam(r, "id")
+	if emailID == "" {
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "Email ID is required", nil)
+		return
+	}
+
+	response, err := h.emailService.Restore(r.Context(), userID, emailID)
+	if err != nil {
+		h.handleEmailError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, response)
+}
+
+// EmptyTrash handles DELETE /api/v1/emails/trash
+// Permanently deletes every email in the trash
+func (h *Handler) EmptyTrash(w http.ResponseWriter, r *http.Request) {
+	userIDStr, ok := appctx.ExtractUserID(r.Context())
+	if !ok {
+		h.writeError(w, http.StatusUnauthorized, "AUTH_TOKEN_INVALID", "Invalid or expired token", nil)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		h.writeError(w, http.StatusUnauthorized, "AUTH_TOKEN_INVALID", "Invalid user ID", nil)
+		return
+	}
+
+	response, err := h.emailService.EmptyTrash(r.Context(), userID)
+	if err != nil {
+		h.handleEmailError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, response)
+}
+
 
 // DownloadAttachment handles GET /api/v1/emails/:id/attachments/:attachmentId
 // Requirements: 3.1-3.7 (Download attachment)
@@ -319,7 +681,8 @@ func (h *Handler) DownloadAttachment(w http.ResponseWriter, r *http.Request) {
 	if inline {
 		disposition = "inline"
 	}
-	w.Header().Set("Content-Disposition", disposition+"; filename=\""+attachment.Filename+"\"")
+	// Non-ASCII filenames are sent as RFC 5987 filename* with an ASCII fallback
+	w.Header().Set("Content-Disposition", parser.FormatContentDisposition(disposition, attachment.Filename))
 
 	// Stream the file content
 	w.WriteHeader(http.StatusOK)
@@ -373,6 +736,7 @@ func (h *Handler) GetAttachmentURL(w http.ResponseWriter, r *http.Request) {
 
 // BulkDelete handles POST /api/v1/emails/bulk/delete
 // Requirements: 5.1, 5.3, 5.4, 5.5 (Bulk delete)
+// Emails go to the trash unless the request sets permanent.
 func (h *Handler) BulkDelete(w http.ResponseWriter, r *http.Request) {
 	userIDStr, ok := appctx.ExtractUserID(r.Context())
 	if !ok {
@@ -386,7 +750,7 @@ func (h *Handler) BulkDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req BulkOperationRequest
+	var req BulkDeleteRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.writeError(w, http.StatusBadRequest, CodeValidationError, "Invalid request body", nil)
 		return
@@ -403,7 +767,7 @@ func (h *Handler) BulkDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response, err := h.emailService.BulkDelete(r.Context(), userID, req.EmailIDs)
+	response, err := h.emailService.BulkDelete(r.Context(), userID, req.EmailIDs, req.Permanent)
 	if err != nil {
 		if errors.Is(err, ErrBulkLimitExceeded) {
 			h.writeError(w, http.StatusBadRequest, CodeBulkLimitExceeded, "Bulk operation limit exceeded (max 100 items)", nil)
@@ -463,6 +827,189 @@ func (h *Handler) BulkMarkAsRead(w http.ResponseWriter, r *http.Request) {
 	h.writeSuccess(w, http.StatusOK, response)
 }
 
+// BulkApplyLabels handles POST /api/v1/emails/bulk/labels/apply
+func (h *Handler) BulkApplyLabels(w http.ResponseWriter, r *http.Request) {
+	h.bulkLabel(w, r, h.emailService.BulkApplyLabels)
+}
+
+// BulkRemoveLabels handles POST /api/v1/emails/bulk/labels/remove
+func (h *Handler) BulkRemoveLabels(w http.ResponseWriter, r *http.Request) {
+	h.bulkLabel(w, r, h.emailService.BulkRemoveLabels)
+}
+
+// BulkStar handles POST /api/v1/emails/bulk/star
+func (h *Handler) BulkStar(w http.ResponseWriter, r *http.Request) {
+	h.bulkState(w, r, func(ctx context.Context, userID uuid.UUID, emailIDs []string) (*BulkOperationResponse, error) {
+		return h.emailService.BulkStar(ctx, userID, emailIDs, true)
+	})
+}
+
+// BulkUnstar handles POST /api/v1/emails/bulk/unstar
+func (h *Handler) BulkUnstar(w http.ResponseWriter, r *http.Request) {
+	h.bulkState(w, r, func(ctx context.Context, userID uuid.UUID, emailIDs []string) (*BulkOperationResponse, error) {
+		return h.emailService.BulkStar(ctx, userID, emailIDs, false)
+	})
+}
+
+// BulkArchive handles POST /api/v1/emails/bulk/archive
+func (h *Handler) BulkArchive(w http.ResponseWriter, r *http.Request) {
+	h.bulkState(w, r, func(ctx context.Context, userID uuid.UUID, emailIDs []string) (*BulkOperationResponse, error) {
+		return h.emailService.BulkArchive(ctx, userID, emailIDs, true)
+	})
+}
+
+// BulkUnarchive handles POST /api/v1/emails/bulk/unarchive
+func (h *Handler) BulkUnarchive(w http.ResponseWriter, r *http.Request) {
+	h.bulkState(w, r, func(ctx context.Context, userID uuid.UUID, emailIDs []string) (*BulkOperationResponse, error) {
+		return h.emailService.BulkArchive(ctx, userID, emailIDs, false)
+	})
+}
+
+// BulkUnsnooze handles POST /api/v1/emails/bulk/unsnooze
+func (h *Handler) BulkUnsnooze(w http.ResponseWriter, r *http.Request) {
+	h.bulkState(w, r, h.emailService.BulkUnsnooze)
+}
+
+// BulkRestore handles POST /api/v1/emails/bulk/restore
+func (h *Handler) BulkRestore(w http.ResponseWriter, r *http.Request) {
+	h.bulkState(w, r, h.emailService.BulkRestore)
+}
+
+// BulkSnooze handles POST /api/v1/emails/bulk/snooze
+func (h *Handler) BulkSnooze(w http.ResponseWriter, r *http.Request) {
+	userIDStr, ok := appctx.ExtractUserID(r.Context())
+	if !ok {
+		h.writeError(w, http.StatusUnauthorized, "AUTH_TOKEN_INVALID", "Invalid or expired token", nil)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		h.writeError(w, http.StatusUnauthorized, "AUTH_TOKEN_INVALID", "Invalid user ID", nil)
+		return
+	}
+
+	var req BulkSnoozeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "Invalid request body", nil)
+		return
+	}
+
+	// Validate request
+	if len(req.EmailIDs) == 0 {
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "email_ids is required", nil)
+		return
+	}
+
+	if req.SnoozeUntil.IsZero() {
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "snooze_until is required", nil)
+		return
+	}
+
+	if len(req.EmailIDs) > MaxBulkOperationItems {
+		h.writeError(w, http.StatusBadRequest, CodeBulkLimitExceeded, "Bulk operation limit exceeded (max 100 items)", nil)
+		return
+	}
+
+	response, err := h.emailService.BulkSnooze(r.Context(), userID, req.EmailIDs, req.SnoozeUntil)
+	if err != nil {
+		h.handleEmailError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, response)
+}
+
+// bulkState validates a bulk operation request and runs a star, archive, snooze or restore update
+func (h *Handler) bulkState(w http.ResponseWriter, r *http.Request, update func(ctx context.Context, userID uuid.UUID, emailIDs []string) (*BulkOperationResponse, error)) {
+	userIDStr, ok := appctx.ExtractUserID(r.Context())
+	if !ok {
+		h.writeError(w, http.StatusUnauthorized, "AUTH_TOKEN_INVALID", "Invalid or expired token", nil)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		h.writeError(w, http.StatusUnauthorized, "AUTH_TOKEN_INVALID", "Invalid user ID", nil)
+		return
+	}
+
+	var req BulkOperationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "Invalid request body", nil)
+		return
+	}
+
+	// Validate request
+	if len(req.EmailIDs) == 0 {
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "email_ids is required", nil)
+		return
+	}
+
+	if len(req.EmailIDs) > MaxBulkOperationItems {
+		h.writeError(w, http.StatusBadRequest, CodeBulkLimitExceeded, "Bulk operation limit exceeded (max 100 items)", nil)
+		return
+	}
+
+	response, err := update(r.Context(), userID, req.EmailIDs)
+	if err != nil {
+		h.handleEmailError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, response)
+}
+
+// bulkLabel validates a bulk label request and runs apply or remove
+func (h *Handler) bulkLabel(w http.ResponseWriter, r *http.Request, apply func(ctx context.Context, userID uuid.UUID, emailIDs, labelIDs []string) (*BulkOperationResponse, error)) {
+	userIDStr, ok := appctx.ExtractUserID(r.Context())
+	if !ok {
+		h.writeError(w, http.StatusUnauthorized, "AUTH_TOKEN_INVALID", "Invalid or expired token", nil)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		h.writeError(w, http.StatusUnauthorized, "AUTH_TOKEN_INVALID", "Invalid user ID", nil)
+		return
+	}
+
+	var req BulkLabelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "Invalid request body", nil)
+		return
+	}
+
+	// Validate request
+	if len(req.EmailIDs) == 0 {
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "email_ids is required", nil)
+		return
+	}
+
+	if len(req.LabelIDs) == 0 {
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "label_ids is required", nil)
+		return
+	}
+
+	if len(req.EmailIDs) > MaxBulkOperationItems {
+		h.writeError(w, http.StatusBadRequest, CodeBulkLimitExceeded, "Bulk operation limit exceeded (max 100 items)", nil)
+		return
+	}
+
+	if len(req.LabelIDs) > MaxBulkLabels {
+		h.writeError(w, http.StatusBadRequest, CodeBulkLimitExceeded, "Bulk label limit exceeded (max 20 labels)", nil)
+		return
+	}
+
+	response, err := apply(r.Context(), userID, req.EmailIDs, req.LabelIDs)
+	if err != nil {
+		h.handleEmailError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, response)
+}
+
 
 // GetStats handles GET /api/v1/emails/stats
 // Requirements: 6.1-6.5 (Email statistics)
@@ -489,6 +1036,158 @@ func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) {
 	h.writeSuccess(w, http.StatusOK, stats)
 }
 
+// ListThreads handles GET /api/v1/threads
+// Lists conversations with their last message preview and unread count
+func (h *Handler) ListThreads(w http.ResponseWriter, r *http.Request) {
+	userIDStr, ok := appctx.ExtractUserID(r.Context())
+	if !ok {
+		h.writeError(w, http.StatusUnauthorized, "AUTH_TOKEN_INVALID", "Invalid or expired token", nil)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		h.writeError(w, http.StatusUnauthorized, "AUTH_TOKEN_INVALID", "Invalid user ID", nil)
+		return
+	}
+
+	// Parse query parameters
+	params := ListThreadParams{
+		Page:  1,
+		Limit: 20,
+	}
+
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		if page, err := strconv.Atoi(pageStr); err == nil && page > 0 {
+			params.Page = page
+		}
+	}
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 {
+			params.Limit = limit
+			if params.Limit > 100 {
+				params.Limit = 100
+			}
+		}
+	}
+
+	if aliasID := r.URL.Query().Get("alias_id"); aliasID != "" {
+		params.AliasID = aliasID
+	}
+
+	// Parse is_read filter (is_read=false lists threads with unread emails)
+	if isReadStr := r.URL.Query().Get("is_read"); isReadStr != "" {
+		isRead := isReadStr == "true"
+		params.IsRead = &isRead
+	}
+
+	response, err := h.emailService.ListThreads(r.Context(), userID, params)
+	if err != nil {
+		h.logger.Error("Failed to list threads", "error", err, "user_id", userID)
+		h.writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list threads", nil)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, response)
+}
+
+// GetThread handles GET /api/v1/threads/:id
+// Returns a conversation with its emails, oldest first
+func (h *Handler) GetThread(w http.ResponseWriter, r *http.Request) {
+	userIDStr, ok := appctx.ExtractUserID(r.Context())
+	if !ok {
+		h.writeError(w, http.StatusUnauthorized, "AUTH_TOKEN_INVALID", "Invalid or expired token", nil)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		h.writeError(w, http.StatusUnauthorized, "AUTH_TOKEN_INVALID", "Invalid user ID", nil)
+		return
+	}
+
+	threadID := chi.URLParam(r, "id")
+	if threadID == "" {
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "Thread ID is required", nil)
+		return
+	}
+
+	thread, err := h.emailService.GetThread(r.Context(), userID, threadID)
+	if err != nil {
+		h.handleEmailError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, map[string]interface{}{
+		"thread": thread,
+	})
+}
+
+// DeleteThread handles DELETE /api/v1/threads/:id
+// Moves every email of a conversation to the trash
+// Query parameters:
+//   - permanent: "true" to delete the emails for good instead
+func (h *Handler) DeleteThread(w http.ResponseWriter, r *http.Request) {
+	userIDStr, ok := appctx.ExtractUserID(r.Context())
+	if !ok {
+		h.writeError(w, http.StatusUnauthorized, "AUTH_TOKEN_INVALID", "Invalid or expired token", nil)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		h.writeError(w, http.StatusUnauthorized, "AUTH_TOKEN_INVALID", "Invalid user ID", nil)
+		return
+	}
+
+	threadID := chi.URLParam(r, "id")
+	if threadID == "" {
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "Thread ID is required", nil)
+		return
+	}
+
+	permanent := r.URL.Query().Get("permanent") == "true"
+
+	response, err := h.emailService.DeleteThread(r.Context(), userID, threadID, permanent)
+	if err != nil {
+		h.handleEmailError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, response)
+}
+
+// MarkThreadAsRead handles POST /api/v1/threads/:id/mark-read
+// Marks every email of a conversation as read
+func (h *Handler) MarkThreadAsRead(w http.ResponseWriter, r *http.Request) {
+	userIDStr, ok := appctx.ExtractUserID(r.Context())
+	if !ok {
+		h.writeError(w, http.StatusUnauthorized, "AUTH_TOKEN_INVALID", "Invalid or expired token", nil)
+		return
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		h.writeError(w, http.StatusUnauthorized, "AUTH_TOKEN_INVALID", "Invalid user ID", nil)
+		return
+	}
+
+	threadID := chi.URLParam(r, "id")
+	if threadID == "" {
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "Thread ID is required", nil)
+		return
+	}
+
+	response, err := h.emailService.MarkThreadAsRead(r.Context(), userID, threadID)
+	if err != nil {
+		h.handleEmailError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, response)
+}
+
 // handleEmailError maps email service errors to HTTP responses
 func (h *Handler) handleEmailError(w http.ResponseWriter, err error) {
 	switch {
@@ -504,6 +1203,18 @@ func (h *Handler) handleEmailError(w http.ResponseWriter, err error) {
 		h.writeError(w, http.StatusInternalServerError, CodeChecksumMismatch, "Attachment integrity check failed", nil)
 	case errors.Is(err, ErrBulkLimitExceeded):
 		h.writeError(w, http.StatusBadRequest, CodeBulkLimitExceeded, "Bulk operation limit exceeded (max 100 items)", nil)
+	case errors.Is(err, ErrEmbeddedNotFound):
+		h.writeError(w, http.StatusNotFound, CodeEmbeddedNotFound, "Embedded message not found", nil)
+	case errors.Is(err, ErrThreadNotFound):
+		h.writeError(w, http.StatusNotFound, CodeThreadNotFound, "Thread not found", nil)
+	case errors.Is(err, ErrMIMEPartNotFound):
+		h.writeError(w, http.StatusNotFound, CodeMIMEPartNotFound, "MIME part not found", nil)
+	case errors.Is(err, ErrRawEmailNotFound):
+		h.writeError(w, http.StatusNotFound, CodeRawEmailNotFound, "Original message not stored", nil)
+	case errors.Is(err, ErrLabelNotFound):
+		h.writeError(w, http.StatusNotFound, CodeLabelNotFound, "Label not found", nil)
+	case errors.Is(err, ErrInvalidSnoozeTime):
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, err.Error(), nil)
 	default:
 		h.logger.Error("Unexpected email error", "error", err)
 		h.writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "An unexpected error occurred", nil)
@@ -525,6 +1236,18 @@ func (h *Handler) getErrorCode(err error) string {
 		return CodeChecksumMismatch
 	case errors.Is(err, ErrBulkLimitExceeded):
 		return CodeBulkLimitExceeded
+	case errors.Is(err, ErrEmbeddedNotFound):
+		return CodeEmbeddedNotFound
+	case errors.Is(err, ErrThreadNotFound):
+		return CodeThreadNotFound
+	case errors.Is(err, ErrMIMEPartNotFound):
+		return CodeMIMEPartNotFound
+	case errors.Is(err, ErrRawEmailNotFound):
+		return CodeRawEmailNotFound
+	case errors.Is(err, ErrLabelNotFound):
+		return CodeLabelNotFound
+	case errors.Is(err, ErrInvalidSnoozeTime):
+		return CodeValidationError
 	default:
 		return "INTERNAL_ERROR"
 	}
@@ -18,7 +18,9 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/attachment"
 	"github.com/welldanyogia/persistent-temp-mail/backend/internal/events"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/parser"
 	"github.com/welldanyogia/persistent-temp-mail/backend/internal/repository"
 	"github.com/welldanyogia/persistent-temp-mail/backend/internal/sanitizer"
 	"github.com/welldanyogia/persistent-temp-mail/backend/internal/storage"
@@ -32,6 +34,13 @@ var (
 	ErrAttachmentGone      = errors.New("attachment file missing from storage")
 	ErrChecksumMismatch    = errors.New("attachment checksum mismatch")
 	ErrBulkLimitExceeded   = errors.New("bulk operation limit exceeded")
+	ErrEmbeddedNotFound    = errors.New("embedded message not found")
+	ErrThreadNotFound      = errors.New("thread not found")
+	ErrMIMEPartNotFound    = errors.New("MIME part not found")
+	ErrInvalidCursor       = errors.New("invalid pagination cursor")
+	ErrRawEmailNotFound    = errors.New("original message not stored")
+	ErrLabelNotFound       = errors.New("label not found")
+	ErrInvalidSnoozeTime   = errors.New("snooze_until must be in the future and within a year")
 )
 
 // Error codes for API responses
@@ -43,6 +52,11 @@ const (
 	CodeAttachmentDeleted   = "ATTACHMENT_DELETED"
 	CodeBulkLimitExceeded   = "BULK_LIMIT_EXCEEDED"
 	CodeChecksumMismatch    = "CHECKSUM_MISMATCH"
+	CodeEmbeddedNotFound    = "EMBEDDED_MESSAGE_NOT_FOUND"
+	CodeThreadNotFound      = "THREAD_NOT_FOUND"
+	CodeMIMEPartNotFound    = "MIME_PART_NOT_FOUND"
+	CodeRawEmailNotFound    = "RAW_EMAIL_NOT_FOUND"
+	CodeLabelNotFound       = "LABEL_NOT_FOUND"
 )
 
 // MaxBulkOperationItems is the maximum number of items in a bulk operation
@@ -53,13 +67,19 @@ type ListEmailParams struct {
 	Page           int        `json:"page" validate:"min=1"`
 	Limit          int        `json:"limit" validate:"min=1,max=100"`
 	AliasID        string     `json:"alias_id,omitempty" validate:"omitempty,uuid"`
-	Search         string     `json:"search,omitempty" validate:"omitempty,max=100"`
+	LabelID        string     `json:"label_id,omitempty" validate:"omitempty,uuid"`
+	Search         string     `json:"search,omitempty" validate:"omitempty,max=500"` // Query parsed by ParseSearch
 	FromDate       *time.Time `json:"from_date,omitempty"`
 	ToDate         *time.Time `json:"to_date,omitempty"`
 	HasAttachments *bool      `json:"has_attachments,omitempty"`
 	IsRead         *bool      `json:"is_read,omitempty"`
+	IsStarred      *bool      `json:"is_starred,omitempty"`
+	IsArchived     *bool      `json:"is_archived,omitempty"` // Archived emails are hidden unless set
+	IsSnoozed      *bool      `json:"is_snoozed,omitempty"`  // Snoozed emails are hidden unless set
+	InTrash        bool       `json:"in_trash,omitempty"`    // Lists the trash instead of the inbox
 	Sort           string     `json:"sort,omitempty" validate:"omitempty,oneof=received_at size"`
 	Order          string     `json:"order,omitempty" validate:"omitempty,oneof=asc desc"`
+	Cursor         string     `json:"cursor,omitempty"` // next_cursor or prev_cursor of a previous page; Page is ignored when set
 }
 
 // EmailListResponse represents the paginated list of emails
@@ -70,31 +90,44 @@ type EmailListResponse struct {
 
 // EmailWithPreview represents an email with preview text for list responses
 type EmailWithPreview struct {
-	ID              string     `json:"id"`
-	AliasID         string     `json:"alias_id"`
-	AliasEmail      string     `json:"alias_email"`
-	FromAddress     string     `json:"from_address"`
-	FromName        *string    `json:"from_name,omitempty"`
-	Subject         *string    `json:"subject,omitempty"`
-	PreviewText     string     `json:"preview_text"`
-	ReceivedAt      time.Time  `json:"received_at"`
-	HasAttachments  bool       `json:"has_attachments"`
-	AttachmentCount int        `json:"attachment_count"`
-	SizeBytes       int64      `json:"size_bytes"`
-	IsRead          bool       `json:"is_read"`
+	ID              string         `json:"id"`
+	ThreadID        string         `json:"thread_id"`
+	AliasID         string         `json:"alias_id"`
+	AliasEmail      string         `json:"alias_email"`
+	FromAddress     string         `json:"from_address"`
+	FromName        *string        `json:"from_name,omitempty"`
+	Subject         *string        `json:"subject,omitempty"`
+	PreviewText     string         `json:"preview_text"`
+	ReceivedAt      time.Time      `json:"received_at"`
+	HasAttachments  bool           `json:"has_attachments"`
+	AttachmentCount int            `json:"attachment_count"`
+	SizeBytes       int64          `json:"size_bytes"`
+	IsRead          bool           `json:"is_read"`
+	IsStarred       bool           `json:"is_starred"`
+	IsArchived      bool           `json:"is_archived"`
+	SnoozedUntil    *time.Time     `json:"snoozed_until,omitempty"`
+	DeletedAt       *time.Time     `json:"deleted_at,omitempty"` // Moved to the trash then
+	PurgeAt         *time.Time     `json:"purge_at,omitempty"`   // Permanently deleted from the trash then
+	ExpiresAt       *time.Time     `json:"expires_at,omitempty"` // Deleted by the retention policy of its alias then
+	Snippet         *string        `json:"snippet,omitempty"` // Search match excerpt, HTML-escaped with matches in <mark>
+	Labels          []LabelSummary `json:"labels"`
 }
 
 // Pagination represents pagination metadata
+// Lists page by offset or by cursor; the cursors of the neighboring pages are returned in both modes.
 type Pagination struct {
-	CurrentPage int `json:"current_page"`
-	PerPage     int `json:"per_page"`
-	TotalPages  int `json:"total_pages"`
-	TotalCount  int `json:"total_count"`
+	CurrentPage int    `json:"current_page"` // 0 when paging by cursor
+	PerPage     int    `json:"per_page"`
+	TotalPages  int    `json:"total_pages"`
+	TotalCount  int    `json:"total_count"`
+	NextCursor  string `json:"next_cursor,omitempty"`
+	PrevCursor  string `json:"prev_cursor,omitempty"`
 }
 
 // EmailDetailResponse represents complete email content
 type EmailDetailResponse struct {
 	ID             string               `json:"id"`
+	ThreadID       string               `json:"thread_id"`
 	AliasID        string               `json:"alias_id"`
 	AliasEmail     string               `json:"alias_email"`
 	FromAddress    string               `json:"from_address"`
@@ -102,12 +135,43 @@ type EmailDetailResponse struct {
 	Subject        *string              `json:"subject,omitempty"`
 	BodyHTML       *string              `json:"body_html,omitempty"`
 	BodyText       *string              `json:"body_text,omitempty"`
-	Headers        map[string]string    `json:"headers"`
+	Headers        map[string]string    `json:"headers"`     // First value of each field, MIME-decoded
+	HeaderList     []parser.Header      `json:"header_list"` // Every field in message order, as written
+	Calendar       json.RawMessage      `json:"calendar,omitempty"`
+	BodyCharset    *string              `json:"body_charset,omitempty"` // Charset the body was decoded from
 	ReceivedAt     time.Time            `json:"received_at"`
 	SizeBytes      int64                `json:"size_bytes"`
 	IsRead         bool                 `json:"is_read"`
+	IsStarred      bool                 `json:"is_starred"`
+	IsArchived     bool                 `json:"is_archived"`
+	SnoozedUntil   *time.Time           `json:"snoozed_until,omitempty"`
+	DeletedAt      *time.Time           `json:"deleted_at,omitempty"`
+	PurgeAt        *time.Time           `json:"purge_at,omitempty"`
+	ExpiresAt      *time.Time           `json:"expires_at,omitempty"` // Deleted by the retention policy of its alias then
 	HasAttachments bool                 `json:"has_attachments"`
 	Attachments    []AttachmentResponse `json:"attachments"`
+	Labels         []LabelSummary       `json:"labels"`
+
+	// Forwarded messages attached to this email, and the path of this email when it is one of them
+	Embedded     []EmbeddedMessageResponse `json:"embedded,omitempty"`
+	EmbeddedPath string                    `json:"embedded_path,omitempty"`
+
+	// Unknown charsets and invalid sequences replaced while decoding the email at ingest
+	CharsetWarnings json.RawMessage `json:"charset_warnings,omitempty"`
+
+	// Message properties of an Outlook winmail.dat part, such as the original sender and sent time
+	TNEFProperties json.RawMessage `json:"tnef_properties,omitempty"`
+}
+
+// EmbeddedMessageResponse summarizes a forwarded message with the URL of its full details
+type EmbeddedMessageResponse struct {
+	Path        string    `json:"path"`
+	Filename    string    `json:"filename,omitempty"`
+	FromAddress string    `json:"from_address"`
+	FromName    *string   `json:"from_name,omitempty"`
+	Subject     *string   `json:"subject,omitempty"`
+	ReceivedAt  time.Time `json:"received_at"`
+	URL         string    `json:"url"`
 }
 
 // AttachmentResponse represents attachment metadata with download URL
@@ -141,11 +205,14 @@ type AttachmentURLResponse struct {
 }
 
 // DeleteEmailResponse represents the response after deleting an email
+// Emails moved to the trash free no storage until they are purged at PurgeAt.
 type DeleteEmailResponse struct {
-	Message             string `json:"message"`
-	EmailID             string `json:"email_id"`
-	AttachmentsDeleted  int    `json:"attachments_deleted"`
-	TotalSizeFreedBytes int64  `json:"total_size_freed_bytes"`
+	Message             string     `json:"message"`
+	EmailID             string     `json:"email_id"`
+	Permanent           bool       `json:"permanent"`
+	PurgeAt             *time.Time `json:"purge_at,omitempty"`
+	AttachmentsDeleted  int        `json:"attachments_deleted"`
+	TotalSizeFreedBytes int64      `json:"total_size_freed_bytes"`
 }
 
 // BulkOperationRequest represents a bulk operation request
@@ -164,11 +231,13 @@ type BulkOperationResponse struct {
 type InboxStatsResponse struct {
 	TotalEmails     int               `json:"total_emails"`
 	UnreadEmails    int               `json:"unread_emails"`
+	TrashedEmails   int               `json:"trashed_emails"`
 	TotalSizeBytes  int64             `json:"total_size_bytes"`
 	EmailsToday     int               `json:"emails_today"`
 	EmailsThisWeek  int               `json:"emails_this_week"`
 	EmailsThisMonth int               `json:"emails_this_month"`
 	EmailsPerAlias  []AliasEmailCount `json:"emails_per_alias"`
+	EmailsPerLabel  []LabelEmailCount `json:"emails_per_label"`
 }
 
 // AliasEmailCount represents email count per alias
@@ -178,6 +247,15 @@ type AliasEmailCount struct {
 	Count      int    `json:"count"`
 }
 
+// LabelEmailCount represents email counts per label
+type LabelEmailCount struct {
+	LabelID     string `json:"label_id"`
+	Name        string `json:"name"`
+	Color       string `json:"color"`
+	Count       int    `json:"count"`
+	UnreadCount int    `json:"unread_count"`
+}
+
 
 // Service handles email business logic
 // Feature: email-inbox-api
@@ -188,7 +266,9 @@ type Service struct {
 	sanitizer      sanitizer.HTMLSanitizer
 	eventBus       events.EventBus
 	logger         *slog.Logger
-	baseURL        string // Base URL for generating download URLs
+	baseURL        string              // Base URL for generating download URLs
+	extractor      *attachment.Handler // Extracts and validates attachments of embedded messages
+	trashRetention time.Duration       // How long trashed emails are kept before being purged
 }
 
 // ServiceConfig contains configuration for the email Service
@@ -199,7 +279,8 @@ type ServiceConfig struct {
 	Sanitizer      sanitizer.HTMLSanitizer
 	EventBus       events.EventBus
 	Logger         *slog.Logger
-	BaseURL        string // Base URL for generating download URLs (e.g., "https://api.webrana.id/v1")
+	BaseURL        string        // Base URL for generating download URLs (e.g., "https://api.webrana.id/v1")
+	TrashRetention time.Duration // How long trashed emails are kept before being purged (default: 30 days)
 }
 
 // NewService creates a new email Service instance
@@ -210,6 +291,9 @@ func NewService(cfg ServiceConfig) *Service {
 	if cfg.Sanitizer == nil {
 		cfg.Sanitizer = sanitizer.NewHTMLSanitizer()
 	}
+	if cfg.TrashRetention <= 0 {
+		cfg.TrashRetention = DefaultTrashRetention
+	}
 
 	return &Service{
 		emailRepo:      cfg.EmailRepo,
@@ -219,6 +303,8 @@ func NewService(cfg ServiceConfig) *Service {
 		eventBus:       cfg.EventBus,
 		logger:         cfg.Logger,
 		baseURL:        cfg.BaseURL,
+		extractor:      attachment.NewHandler(nil, ""),
+		trashRetention: cfg.TrashRetention,
 	}
 }
 
@@ -240,51 +326,42 @@ func (s *Service) List(ctx context.Context, userID uuid.UUID, params ListEmailPa
 		params.Limit = 100
 	}
 
-	// Convert params to repository params
-	repoParams := repository.ListEmailParams{
-		Page:           params.Page,
-		Limit:          params.Limit,
-		Search:         params.Search,
-		FromDate:       params.FromDate,
-		ToDate:         params.ToDate,
-		HasAttachments: params.HasAttachments,
-		IsRead:         params.IsRead,
-		Sort:           params.Sort,
-		Order:          params.Order,
+	repoParams, err := toRepositoryParams(params)
+	if err != nil {
+		return nil, err
 	}
 
-	// Parse alias filter if provided (Requirement: 1.2)
-	if params.AliasID != "" {
-		aliasID, err := uuid.Parse(params.AliasID)
+	// Parse cursor if provided
+	currentPage := params.Page
+	if params.Cursor != "" {
+		cursor, err := repository.DecodeCursor(params.Cursor)
 		if err != nil {
-			return nil, fmt.Errorf("invalid alias_id format: %w", err)
+			return nil, ErrInvalidCursor
 		}
-		repoParams.AliasID = &aliasID
+		repoParams.Cursor = cursor
+		currentPage = 0
 	}
 
 	// Get emails from repository (Requirement: 1.9 - only returns emails for user's aliases)
-	emails, totalCount, err := s.emailRepo.List(ctx, userID, repoParams)
+	emails, totalCount, cursors, err := s.emailRepo.List(ctx, userID, repoParams)
 	if err != nil {
+		if errors.Is(err, repository.ErrInvalidCursor) {
+			return nil, ErrInvalidCursor
+		}
 		return nil, fmt.Errorf("failed to list emails: %w", err)
 	}
 
 	// Convert to response format
 	emailResponses := make([]EmailWithPreview, len(emails))
 	for i, e := range emails {
-		emailResponses[i] = EmailWithPreview{
-			ID:              e.ID.String(),
-			AliasID:         e.AliasID.String(),
-			AliasEmail:      e.AliasEmail,
-			FromAddress:     e.FromAddress,
-			FromName:        e.FromName,
-			Subject:         e.Subject,
-			PreviewText:     e.PreviewText,
-			ReceivedAt:      e.ReceivedAt,
-			HasAttachments:  e.HasAttachments,
-			AttachmentCount: e.AttachmentCount,
-			SizeBytes:       e.SizeBytes,
-			IsRead:          e.IsRead,
-		}
+		emailResponses[i] = toEmailWithPreview(e)
+		emailResponses[i].PurgeAt = s.purgeAt(e.DeletedAt)
+	}
+	if err := s.attachLabels(ctx, emailResponses); err != nil {
+		return nil, err
+	}
+	if err := s.attachExpiry(ctx, emailResponses); err != nil {
+		return nil, err
 	}
 
 	// Calculate pagination
@@ -296,15 +373,99 @@ func (s *Service) List(ctx context.Context, userID uuid.UUID, params ListEmailPa
 	return &EmailListResponse{
 		Emails: emailResponses,
 		Pagination: Pagination{
-			CurrentPage: params.Page,
+			CurrentPage: currentPage,
 			PerPage:     params.Limit,
 			TotalPages:  totalPages,
 			TotalCount:  totalCount,
+			NextCursor:  cursors.Next,
+			PrevCursor:  cursors.Prev,
 		},
 	}, nil
 }
 
 
+// toRepositoryParams converts list params to repository params, except for the cursor
+// Archived and snoozed emails are excluded unless asked for.
+func toRepositoryParams(params ListEmailParams) (repository.ListEmailParams, error) {
+	// Parse the search query
+	search, err := ParseSearch(params.Search)
+	if err != nil {
+		return repository.ListEmailParams{}, err
+	}
+
+	// Convert params to repository params
+	repoParams := repository.ListEmailParams{
+		Page:           params.Page,
+		Limit:          params.Limit,
+		Search:         search,
+		FromDate:       params.FromDate,
+		ToDate:         params.ToDate,
+		HasAttachments: params.HasAttachments,
+		IsRead:         params.IsRead,
+		IsStarred:      params.IsStarred,
+		IsArchived:     params.IsArchived,
+		IsSnoozed:      params.IsSnoozed,
+		InTrash:        params.InTrash,
+		Sort:           params.Sort,
+		Order:          params.Order,
+	}
+
+	// Parse alias filter if provided (Requirement: 1.2)
+	if params.AliasID != "" {
+		aliasID, err := uuid.Parse(params.AliasID)
+		if err != nil {
+			return repository.ListEmailParams{}, fmt.Errorf("invalid alias_id format: %w", err)
+		}
+		repoParams.AliasID = &aliasID
+	}
+
+	// Archived and snoozed emails stay out of the list unless asked for; the trash lists them all
+	if repoParams.IsArchived == nil && !params.InTrash {
+		notArchived := false
+		repoParams.IsArchived = &notArchived
+	}
+	if repoParams.IsSnoozed == nil && !params.InTrash {
+		notSnoozed := false
+		repoParams.IsSnoozed = &notSnoozed
+	}
+
+	// Parse label filter if provided
+	if params.LabelID != "" {
+		labelID, err := uuid.Parse(params.LabelID)
+		if err != nil {
+			return repository.ListEmailParams{}, fmt.Errorf("invalid label_id format: %w", err)
+		}
+		repoParams.LabelID = &labelID
+	}
+
+	return repoParams, nil
+}
+
+// toEmailWithPreview converts a repository email preview to its response format
+func toEmailWithPreview(e repository.EmailWithPreview) EmailWithPreview {
+	return EmailWithPreview{
+		ID:              e.ID.String(),
+		ThreadID:        e.ThreadID.String(),
+		AliasID:         e.AliasID.String(),
+		AliasEmail:      e.AliasEmail,
+		FromAddress:     e.FromAddress,
+		FromName:        e.FromName,
+		Subject:         e.Subject,
+		PreviewText:     e.PreviewText,
+		ReceivedAt:      e.ReceivedAt,
+		HasAttachments:  e.HasAttachments,
+		AttachmentCount: e.AttachmentCount,
+		SizeBytes:       e.SizeBytes,
+		IsRead:          e.IsRead,
+		IsStarred:       e.IsStarred,
+		IsArchived:      e.IsArchived,
+		SnoozedUntil:    e.SnoozedUntil,
+		DeletedAt:       e.DeletedAt,
+		Snippet:         e.Snippet,
+		Labels:          []LabelSummary{},
+	}
+}
+
 // GetByID retrieves an email by ID with ownership check
 // Requirements: 2.1-2.8 (Get email details)
 // Property 5: Authorization Enforcement (get part)
@@ -360,6 +521,18 @@ func (s *Service) GetByID(ctx context.Context, userID uuid.UUID, emailID string,
 		return nil, fmt.Errorf("failed to get attachments: %w", err)
 	}
 
+	// Get labels
+	labels, err := s.emailRepo.GetLabelsByEmailIDs(ctx, []uuid.UUID{id})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email labels: %w", err)
+	}
+
+	// Get expiry under the alias retention policy
+	expiresAt, err := s.getExpiry(ctx, email.AliasID, email.ReceivedAt)
+	if err != nil {
+		return nil, err
+	}
+
 	// Get alias email address
 	aliasEmail := s.getAliasEmail(ctx, email.AliasID)
 
@@ -385,6 +558,7 @@ func (s *Service) GetByID(ctx context.Context, userID uuid.UUID, emailID string,
 
 	return &EmailDetailResponse{
 		ID:             email.ID.String(),
+		ThreadID:       email.ThreadID.String(),
 		AliasID:        email.AliasID.String(),
 		AliasEmail:     aliasEmail,
 		FromAddress:    email.SenderAddress,
@@ -393,14 +567,65 @@ func (s *Service) GetByID(ctx context.Context, userID uuid.UUID, emailID string,
 		BodyHTML:       sanitizedHTML,
 		BodyText:       email.BodyText,
 		Headers:        email.Headers,
+		HeaderList:     emailHeaderList(email),
+		Calendar:       email.Calendar,
+		BodyCharset:    email.BodyCharset,
 		ReceivedAt:     email.ReceivedAt,
 		SizeBytes:      email.SizeBytes,
 		IsRead:         email.IsRead,
+		IsStarred:      email.IsStarred,
+		IsArchived:     email.IsArchived,
+		SnoozedUntil:   email.SnoozedUntil,
+		DeletedAt:      email.DeletedAt,
+		PurgeAt:        s.purgeAt(email.DeletedAt),
+		ExpiresAt:      expiresAt,
 		HasAttachments: len(attachments) > 0,
 		Attachments:    attachmentResponses,
+		Labels:         toLabelSummaries(labels[id]),
+		Embedded:       s.embeddedSummaries(email),
+
+		CharsetWarnings: email.CharsetWarnings,
+		TNEFProperties:  email.TNEFProperties,
 	}, nil
 }
 
+// getOwnedEmail loads an email, returning ErrAccessDenied if it belongs to another user
+func (s *Service) getOwnedEmail(ctx context.Context, userID uuid.UUID, emailID string) (*repository.Email, error) {
+	id, err := uuid.Parse(emailID)
+	if err != nil {
+		return nil, ErrEmailNotFound
+	}
+
+	owned, err := s.emailRepo.IsOwnedByUser(ctx, id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check email ownership: %w", err)
+	}
+
+	email, err := s.emailRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrEmailNotFound) {
+			return nil, ErrEmailNotFound
+		}
+		return nil, fmt.Errorf("failed to get email: %w", err)
+	}
+	if !owned {
+		return nil, ErrAccessDenied
+	}
+	return email, nil
+}
+
+// emailHeaderList returns the stored ordered header fields of an email
+// Emails stored without them are parsed from the raw message
+func emailHeaderList(email *repository.Email) []parser.Header {
+	if len(email.HeaderList) > 0 {
+		var headers []parser.Header
+		if err := json.Unmarshal(email.HeaderList, &headers); err == nil && headers != nil {
+			return headers
+		}
+	}
+	return parser.ParseHeaderList(email.RawEmail)
+}
+
 // getAliasEmail retrieves the alias email address for an email
 func (s *Service) getAliasEmail(ctx context.Context, aliasID uuid.UUID) string {
 	// This is a simplified implementation - in production, you might want to cache this
@@ -639,11 +864,11 @@ func VerifyChecksum(data []byte, expectedChecksum string) bool {
 }
 
 
-// Delete deletes an email and its attachments
+// DeletePermanently deletes an email and its attachments without going through the trash
 // Requirements: 4.1-4.5 (Delete email)
 // Property 5: Authorization Enforcement (delete part)
 // Property 11: Email Deletion
-func (s *Service) Delete(ctx context.Context, userID uuid.UUID, emailID string) (*DeleteEmailResponse, error) {
+func (s *Service) DeletePermanently(ctx context.Context, userID uuid.UUID, emailID string) (*DeleteEmailResponse, error) {
 	// Parse email ID
 	id, err := uuid.Parse(emailID)
 	if err != nil {
@@ -729,27 +954,32 @@ func (s *Service) Delete(ctx context.Context, userID uuid.UUID, emailID string)
 	// Publish email_deleted event
 	// Requirements: 4.1, 4.2 - Real-time notification for email deletion
 	if s.eventBus != nil {
-		s.publishEmailDeletedEvent(userID.String(), emailID, aliasID.String(), time.Now().UTC())
+		s.publishEmailDeletedEvent(userID.String(), emailID, aliasID.String(), time.Now().UTC(), true)
 	}
 
 	return &DeleteEmailResponse{
 		Message:             "Email deleted successfully",
 		EmailID:             emailID,
+		Permanent:           true,
 		AttachmentsDeleted:  attachmentsDeleted,
 		TotalSizeFreedBytes: totalSizeFreed,
 	}, nil
 }
 
 
-// BulkDelete deletes multiple emails
+// BulkDelete moves multiple emails to the trash, or deletes them for good when permanent is set
 // Requirements: 5.1, 5.3, 5.4, 5.5 (Bulk delete)
 // Property 12: Bulk Operations
-func (s *Service) BulkDelete(ctx context.Context, userID uuid.UUID, emailIDs []string) (*BulkOperationResponse, error) {
+func (s *Service) BulkDelete(ctx context.Context, userID uuid.UUID, emailIDs []string, permanent bool) (*BulkOperationResponse, error) {
 	// Check bulk limit (Requirement: 5.5)
 	if len(emailIDs) > MaxBulkOperationItems {
 		return nil, ErrBulkLimitExceeded
 	}
 
+	if !permanent {
+		return s.bulkTrash(ctx, userID, emailIDs)
+	}
+
 	if len(emailIDs) == 0 {
 		return &BulkOperationResponse{
 			SuccessCount: 0,
@@ -796,15 +1026,38 @@ func (s *Service) BulkDelete(ctx context.Context, userID uuid.UUID, emailIDs []s
 		}, nil
 	}
 
+	// Delete emails (Requirement: 5.1)
+	deletedCount, totalSize, err := s.deleteEmailBatch(ctx, ownedIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Bulk delete completed",
+		"user_id", userID,
+		"requested", len(emailIDs),
+		"deleted", deletedCount,
+		"size_freed", totalSize,
+	)
+
+	return &BulkOperationResponse{
+		SuccessCount: deletedCount,
+		FailedCount:  len(emailIDs) - deletedCount,
+		FailedIDs:    failedIDs,
+	}, nil
+}
+
+// deleteEmailBatch deletes owned emails with their stored attachments
+// Returns the number of emails deleted and the size of the deleted emails
+func (s *Service) deleteEmailBatch(ctx context.Context, ids []uuid.UUID) (int, int64, error) {
 	// Get total size before deletion
-	totalSize, err := s.emailRepo.GetTotalSizeByIDs(ctx, ownedIDs)
+	totalSize, err := s.emailRepo.GetTotalSizeByIDs(ctx, ids)
 	if err != nil {
 		s.logger.Warn("Failed to get total size for bulk delete", "error", err)
 	}
 
 	// Get all attachment storage keys
 	var allStorageKeys []string
-	for _, id := range ownedIDs {
+	for _, id := range ids {
 		keys, err := s.attachmentRepo.GetStorageKeysByEmailID(ctx, id)
 		if err != nil {
 			s.logger.Warn("Failed to get storage keys for email", "email_id", id, "error", err)
@@ -821,24 +1074,12 @@ func (s *Service) BulkDelete(ctx context.Context, userID uuid.UUID, emailIDs []s
 		}
 	}
 
-	// Delete emails (Requirement: 5.1)
-	deletedCount, err := s.emailRepo.DeleteBatch(ctx, ownedIDs)
+	deletedCount, err := s.emailRepo.DeleteBatch(ctx, ids)
 	if err != nil {
-		return nil, fmt.Errorf("failed to delete emails: %w", err)
+		return 0, 0, fmt.Errorf("failed to delete emails: %w", err)
 	}
 
-	s.logger.Info("Bulk delete completed",
-		"user_id", userID,
-		"requested", len(emailIDs),
-		"deleted", deletedCount,
-		"size_freed", totalSize,
-	)
-
-	return &BulkOperationResponse{
-		SuccessCount: deletedCount,
-		FailedCount:  len(emailIDs) - deletedCount,
-		FailedIDs:    failedIDs,
-	}, nil
+	return deletedCount, totalSize, nil
 }
 
 // BulkMarkAsRead marks multiple emails as read
@@ -935,14 +1176,27 @@ func (s *Service) GetStats(ctx context.Context, userID uuid.UUID) (*InboxStatsRe
 		}
 	}
 
+	emailsPerLabel := make([]LabelEmailCount, len(stats.EmailsPerLabel))
+	for i, l := range stats.EmailsPerLabel {
+		emailsPerLabel[i] = LabelEmailCount{
+			LabelID:     l.LabelID.String(),
+			Name:        l.Name,
+			Color:       l.Color,
+			Count:       l.Count,
+			UnreadCount: l.UnreadCount,
+		}
+	}
+
 	return &InboxStatsResponse{
 		TotalEmails:     stats.TotalEmails,
 		UnreadEmails:    stats.UnreadEmails,
+		TrashedEmails:   stats.TrashedEmails,
 		TotalSizeBytes:  stats.TotalSizeBytes,
 		EmailsToday:     stats.EmailsToday,
 		EmailsThisWeek:  stats.EmailsThisWeek,
 		EmailsThisMonth: stats.EmailsThisMonth,
 		EmailsPerAlias:  emailsPerAlias,
+		EmailsPerLabel:  emailsPerLabel,
 	}, nil
 }
 
@@ -960,11 +1214,12 @@ func (s *Service) IsOwnedByUser(ctx context.Context, emailID, userID uuid.UUID)
 
 // publishEmailDeletedEvent publishes an email_deleted event to the event bus
 // Requirements: 4.1, 4.2 - Real-time notification for email deletion
-func (s *Service) publishEmailDeletedEvent(userID, emailID, aliasID string, deletedAt time.Time) {
+func (s *Service) publishEmailDeletedEvent(userID, emailID, aliasID string, deletedAt time.Time, permanent bool) {
 	eventData := events.EmailDeletedEvent{
 		ID:        emailID,
 		AliasID:   aliasID,
 		DeletedAt: deletedAt,
+		Permanent: permanent,
 	}
 
 	data, err := json.Marshal(eventData)
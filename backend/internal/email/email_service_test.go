@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"strings"
 	"testing"
@@ -39,7 +40,31 @@ func (m *MockEmailRepository) AddEmail(email *repository.Email) {
 	m.emailsByAlias[email.AliasID] = append(m.emailsByAlias[email.AliasID], email)
 }
 
-func (m *MockEmailRepository) List(ctx context.Context, userID uuid.UUID, params repository.ListEmailParams) ([]repository.EmailWithPreview, int, error) {
+// mockSearchMatches reports whether an email matches the text, from and subject terms of a search
+func mockSearchMatches(email *repository.Email, search *repository.EmailSearch) bool {
+	for _, term := range search.Terms {
+		value := strings.ToLower(term.Value)
+		var match bool
+		switch term.Op {
+		case repository.SearchText:
+			match = (email.Subject != nil && strings.Contains(strings.ToLower(*email.Subject), value)) ||
+				strings.Contains(strings.ToLower(email.SenderAddress), value) ||
+				(email.BodyText != nil && strings.Contains(strings.ToLower(*email.BodyText), value))
+		case repository.SearchFrom:
+			match = strings.Contains(strings.ToLower(email.SenderAddress), value)
+		case repository.SearchSubject:
+			match = email.Subject != nil && strings.Contains(strings.ToLower(*email.Subject), value)
+		default:
+			continue
+		}
+		if match == term.Negated {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *MockEmailRepository) List(ctx context.Context, userID uuid.UUID, params repository.ListEmailParams) ([]repository.EmailWithPreview, int, repository.PageCursors, error) {
 	var result []repository.EmailWithPreview
 
 	// Apply defaults
@@ -65,15 +90,9 @@ func (m *MockEmailRepository) List(ctx context.Context, userID uuid.UUID, params
 			continue
 		}
 
-		// Apply search filter
-		if params.Search != "" {
-			searchLower := strings.ToLower(params.Search)
-			subjectMatch := email.Subject != nil && strings.Contains(strings.ToLower(*email.Subject), searchLower)
-			senderMatch := strings.Contains(strings.ToLower(email.SenderAddress), searchLower)
-			bodyMatch := email.BodyText != nil && strings.Contains(strings.ToLower(*email.BodyText), searchLower)
-			if !subjectMatch && !senderMatch && !bodyMatch {
-				continue
-			}
+		// Apply search filter (text, from and subject terms by substring)
+		if params.Search != nil && !mockSearchMatches(email, params.Search) {
+			continue
 		}
 
 		// Apply date range filter
@@ -148,13 +167,13 @@ func (m *MockEmailRepository) List(ctx context.Context, userID uuid.UUID, params
 	end := start + params.Limit
 
 	if start >= len(result) {
-		return []repository.EmailWithPreview{}, totalCount, nil
+		return []repository.EmailWithPreview{}, totalCount, repository.PageCursors{}, nil
 	}
 	if end > len(result) {
 		end = len(result)
 	}
 
-	return result[start:end], totalCount, nil
+	return result[start:end], totalCount, repository.PageCursors{}, nil
 }
 
 func (m *MockEmailRepository) GetByID(ctx context.Context, id uuid.UUID) (*repository.Email, error) {
@@ -428,11 +447,17 @@ func (s *TestableEmailService) List(ctx context.Context, userID uuid.UUID, param
 		params.Limit = 100
 	}
 
+	// Parse the search query
+	search, err := ParseSearch(params.Search)
+	if err != nil {
+		return nil, err
+	}
+
 	// Convert params to repository params
 	repoParams := repository.ListEmailParams{
 		Page:           params.Page,
 		Limit:          params.Limit,
-		Search:         params.Search,
+		Search:         search,
 		FromDate:       params.FromDate,
 		ToDate:         params.ToDate,
 		HasAttachments: params.HasAttachments,
@@ -451,7 +476,7 @@ func (s *TestableEmailService) List(ctx context.Context, userID uuid.UUID, param
 	}
 
 	// Get emails from repository
-	emails, totalCount, err := s.emailRepo.List(ctx, userID, repoParams)
+	emails, totalCount, _, err := s.emailRepo.List(ctx, userID, repoParams)
 	if err != nil {
 		return nil, err
 	}
@@ -1183,11 +1208,16 @@ func (s *TestableEmailService) GetByID(ctx context.Context, userID uuid.UUID, em
 		BodyHTML:       sanitizedHTML,
 		BodyText:       email.BodyText,
 		Headers:        email.Headers,
+		Calendar:       email.Calendar,
+		BodyCharset:    email.BodyCharset,
 		ReceivedAt:     email.ReceivedAt,
 		SizeBytes:      email.SizeBytes,
 		IsRead:         email.IsRead,
 		HasAttachments: len(attachments) > 0,
 		Attachments:    attachmentResponses,
+
+		CharsetWarnings: email.CharsetWarnings,
+		TNEFProperties:  email.TNEFProperties,
 	}, nil
 }
 
@@ -1335,6 +1365,80 @@ func TestProperty6_EmailDetailsContent(t *testing.T) {
 	})
 }
 
+// TestEmailDetails_Calendar verifies parsed invitations are returned for event cards
+func TestEmailDetails_Calendar(t *testing.T) {
+	ctx := context.Background()
+	service := NewTestableEmailService()
+
+	userID := uuid.New()
+	aliasID := uuid.New()
+	service.emailRepo.SetAliasOwnership(aliasID, userID)
+
+	invite := &repository.Email{
+		ID:            uuid.New(),
+		AliasID:       aliasID,
+		SenderAddress: "organizer@example.com",
+		ReceivedAt:    time.Now().UTC(),
+		Calendar:      json.RawMessage(`{"method":"REQUEST","events":[{"summary":"Planning"}]}`),
+	}
+	plain := &repository.Email{
+		ID:            uuid.New(),
+		AliasID:       aliasID,
+		SenderAddress: "sender@example.com",
+		ReceivedAt:    time.Now().UTC(),
+	}
+	service.emailRepo.AddEmail(invite)
+	service.emailRepo.AddEmail(plain)
+
+	resp, err := service.GetByID(ctx, userID, invite.ID.String(), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, _ := json.Marshal(resp)
+	if !strings.Contains(string(body), `"calendar":{"method":"REQUEST","events":[{"summary":"Planning"}]}`) {
+		t.Errorf("expected calendar in response, got %s", body)
+	}
+
+	resp, err = service.GetByID(ctx, userID, plain.ID.String(), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, _ = json.Marshal(resp)
+	if strings.Contains(string(body), `"calendar"`) {
+		t.Errorf("expected calendar to be omitted, got %s", body)
+	}
+}
+
+// TestEmailDetails_Charset verifies the body charset and charset warnings found at ingest are returned
+func TestEmailDetails_Charset(t *testing.T) {
+	ctx := context.Background()
+	service := NewTestableEmailService()
+
+	userID := uuid.New()
+	aliasID := uuid.New()
+	service.emailRepo.SetAliasOwnership(aliasID, userID)
+
+	charset := "windows-1252"
+	email := &repository.Email{
+		ID:              uuid.New(),
+		AliasID:         aliasID,
+		SenderAddress:   "sender@example.com",
+		ReceivedAt:      time.Now().UTC(),
+		BodyCharset:     &charset,
+		CharsetWarnings: json.RawMessage(`["body: unknown charset \"x-unknown\" decoded as windows-1252"]`),
+	}
+	service.emailRepo.AddEmail(email)
+
+	resp, err := service.GetByID(ctx, userID, email.ID.String(), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, _ := json.Marshal(resp)
+	if !strings.Contains(string(body), `"body_charset":"windows-1252"`) || !strings.Contains(string(body), `"charset_warnings":["body: unknown charset`) {
+		t.Errorf("expected charset diagnostics in response, got %s", body)
+	}
+}
+
 // Feature: email-inbox-api, Property 7: Mark As Read Behavior
 // **Validates: Requirements 2.7**
 //
@@ -0,0 +1,191 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/parser"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/repository"
+)
+
+// GetEmbedded returns a forwarded message (message/rfc822 part) of an email as a sub-email
+// The message is parsed from the stored raw email, so its headers, bodies and attachments need no extra storage.
+// Path is the embedded message's position, e.g. "1" or "1.2" for a forward inside a forward.
+// Only the forwarded message is parsed; the messages around it are just searched for its part.
+func (s *Service) GetEmbedded(ctx context.Context, userID uuid.UUID, emailID, path string) (*EmailDetailResponse, error) {
+	email, data, filename, err := s.getEmbedded(ctx, userID, emailID, path)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := parser.NewEmailParser().ParseEmbedded(data, path)
+	if err != nil {
+		return nil, ErrEmbeddedNotFound
+	}
+	embedded := &parser.EmbeddedMessage{Path: path, Filename: filename, Email: msg}
+	return s.embeddedDetail(email, s.getAliasEmail(ctx, email.AliasID), embedded), nil
+}
+
+// GetEmbeddedAttachment returns an attachment of a forwarded message for download
+// Attachment IDs are 1-based positions in the embedded message's attachment list
+func (s *Service) GetEmbeddedAttachment(ctx context.Context, userID uuid.UUID, emailID, path, attachmentID string) (*AttachmentDownload, error) {
+	_, data, _, err := s.getEmbedded(ctx, userID, emailID, path)
+	if err != nil {
+		return nil, err
+	}
+
+	attachments := s.embeddedAttachments(data)
+	index, err := strconv.Atoi(attachmentID)
+	if err != nil || index < 1 || index > len(attachments) {
+		return nil, ErrAttachmentNotFound
+	}
+	att := attachments[index-1]
+
+	return &AttachmentDownload{
+		Filename:    att.Filename,
+		ContentType: att.ContentType,
+		SizeBytes:   att.SizeBytes,
+		Data:        io.NopCloser(bytes.NewReader(att.Data)),
+		Checksum:    s.extractor.CalculateChecksum(att.Data),
+	}, nil
+}
+
+// getEmbedded loads an email owned by the user and returns the content and filename of the embedded message at path
+func (s *Service) getEmbedded(ctx context.Context, userID uuid.UUID, emailID, path string) (*repository.Email, []byte, string, error) {
+	email, err := s.getOwnedEmail(ctx, userID, emailID)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	data, filename, ok := parser.EmbeddedMessageData(email.RawEmail, path)
+	if !ok {
+		return nil, nil, "", ErrEmbeddedNotFound
+	}
+	return email, data, filename, nil
+}
+
+// embeddedDetail builds the sub-email response for an embedded message
+// The body is sanitized and attachments are validated exactly like those of stored emails.
+func (s *Service) embeddedDetail(email *repository.Email, aliasEmail string, embedded *parser.EmbeddedMessage) *EmailDetailResponse {
+	msg := embedded.Email
+	baseURL := s.embeddedURL(email.ID.String(), embedded.Path)
+
+	var sanitizedHTML *string
+	if msg.BodyHTML != "" {
+		sanitized := s.sanitizer.Sanitize(msg.BodyHTML)
+		sanitizedHTML = &sanitized
+	}
+
+	attachments := s.embeddedAttachments(msg.RawEmail)
+	attachmentResponses := make([]AttachmentResponse, len(attachments))
+	for i, att := range attachments {
+		id := strconv.Itoa(i + 1)
+		attachmentResponses[i] = AttachmentResponse{
+			ID:          id,
+			Filename:    att.Filename,
+			ContentType: att.ContentType,
+			SizeBytes:   att.SizeBytes,
+			DownloadURL: baseURL + "/attachments/" + id,
+			CreatedAt:   email.CreatedAt,
+		}
+	}
+
+	var calendar json.RawMessage
+	if msg.Calendar != nil {
+		calendar, _ = json.Marshal(msg.Calendar)
+	}
+
+	return &EmailDetailResponse{
+		ID:             email.ID.String(),
+		AliasID:        email.AliasID.String(),
+		AliasEmail:     aliasEmail,
+		FromAddress:    msg.From,
+		FromName:       optionalString(msg.FromName),
+		Subject:        optionalString(msg.Subject),
+		BodyHTML:       sanitizedHTML,
+		BodyText:       optionalString(msg.BodyText),
+		Headers:        msg.Headers,
+		HeaderList:     msg.HeaderList,
+		Calendar:       calendar,
+		ReceivedAt:     msg.ReceivedAt,
+		SizeBytes:      int64(len(msg.RawEmail)),
+		IsRead:         email.IsRead,
+		HasAttachments: len(attachments) > 0,
+		Attachments:    attachmentResponses,
+		Embedded:       s.embeddedResponses(email.ID.String(), parser.SummarizeEmbedded(msg.Embedded)),
+		EmbeddedPath:   embedded.Path,
+	}
+}
+
+// embeddedSummaries lists the forwarded messages of a stored email for its detail response
+// The summaries are stored at ingest, so the raw email is not parsed.
+func (s *Service) embeddedSummaries(email *repository.Email) []EmbeddedMessageResponse {
+	if len(email.Embedded) == 0 {
+		return nil
+	}
+	var summaries []parser.EmbeddedSummary
+	if err := json.Unmarshal(email.Embedded, &summaries); err != nil {
+		return nil
+	}
+	return s.embeddedResponses(email.ID.String(), summaries)
+}
+
+// embeddedResponses adds the URL of their details to summaries of one level of embedded messages
+func (s *Service) embeddedResponses(emailID string, summaries []parser.EmbeddedSummary) []EmbeddedMessageResponse {
+	if len(summaries) == 0 {
+		return nil
+	}
+	responses := make([]EmbeddedMessageResponse, len(summaries))
+	for i, e := range summaries {
+		responses[i] = EmbeddedMessageResponse{
+			Path:        e.Path,
+			Filename:    e.Filename,
+			FromAddress: e.From,
+			FromName:    optionalString(e.FromName),
+			Subject:     optionalString(e.Subject),
+			ReceivedAt:  e.ReceivedAt,
+			URL:         s.embeddedURL(emailID, e.Path),
+		}
+	}
+	return responses
+}
+
+// embeddedAttachments extracts the attachments of an embedded message that pass validation
+// Blocked attachments are left out, as they would not have been stored for a received email
+func (s *Service) embeddedAttachments(raw []byte) []*parser.Attachment {
+	extracted, err := s.extractor.ExtractAttachments(raw)
+	if err != nil && len(extracted) == 0 {
+		return nil
+	}
+
+	var attachments []*parser.Attachment
+	for _, att := range extracted {
+		if s.extractor.ValidateAttachment(att) != nil {
+			continue
+		}
+		att.Filename = s.extractor.SanitizeFilename(att.Filename)
+		if att.Filename == "" {
+			att.Filename = "attachment"
+		}
+		attachments = append(attachments, att)
+	}
+	return attachments
+}
+
+// embeddedURL returns the URL of an embedded message's details
+func (s *Service) embeddedURL(emailID, path string) string {
+	return fmt.Sprintf("%s/emails/%s/embedded/%s", s.baseURL, emailID, path)
+}
+
+// optionalString returns nil for an empty string, matching nullable email columns
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
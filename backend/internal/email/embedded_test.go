@@ -0,0 +1,177 @@
+package email
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/parser"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/repository"
+)
+
+// forwardedInvoice is an email forwarding a message that carries an attachment and a nested forward
+const forwardedInvoice = "From: bob@example.com\r\n" +
+	"Subject: Fwd: Invoice\r\n" +
+	"Content-Type: multipart/mixed; boundary=\"outer\"\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: text/plain\r\n" +
+	"\r\n" +
+	"See the forwarded message\r\n" +
+	"--outer\r\n" +
+	"Content-Type: message/rfc822\r\n" +
+	"Content-Disposition: attachment; filename=\"Invoice.eml\"\r\n" +
+	"\r\n" +
+	"From: Billing <billing@example.com>\r\n" +
+	"Date: Mon, 01 Apr 2024 09:00:00 +0000\r\n" +
+	"Subject: Invoice\r\n" +
+	"Content-Type: multipart/mixed; boundary=\"inner\"\r\n" +
+	"\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/html\r\n" +
+	"\r\n" +
+	"<p onclick=\"steal()\">Your invoice</p><script>alert(1)</script>\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/plain; name=\"invoice.txt\"\r\n" +
+	"Content-Disposition: attachment; filename=\"../invoice.txt\"\r\n" +
+	"\r\n" +
+	"Total: 42\r\n" +
+	"--inner\r\n" +
+	"Content-Type: application/octet-stream\r\n" +
+	"Content-Disposition: attachment; filename=\"setup.exe\"\r\n" +
+	"\r\n" +
+	"MZ\r\n" +
+	"--inner\r\n" +
+	"Content-Type: message/rfc822\r\n" +
+	"\r\n" +
+	"From: origin@example.com\r\n" +
+	"Subject: Original order\r\n" +
+	"\r\n" +
+	"Order 7\r\n" +
+	"--inner--\r\n" +
+	"--outer--\r\n"
+
+// ingestEmbedded returns the embedded message summaries stored for raw at ingest
+func ingestEmbedded(t *testing.T, raw string) json.RawMessage {
+	t.Helper()
+	parsed, err := parser.NewEmailParser().Parse([]byte(raw))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	summaries, err := json.Marshal(parser.SummarizeEmbedded(parsed.Embedded))
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	return summaries
+}
+
+// findEmbedded returns the embedded message at path the way GetEmbedded parses it, or nil
+func findEmbedded(raw []byte, path string) *parser.EmbeddedMessage {
+	data, filename, ok := parser.EmbeddedMessageData(raw, path)
+	if !ok {
+		return nil
+	}
+	msg, err := parser.NewEmailParser().ParseEmbedded(data, path)
+	if err != nil {
+		return nil
+	}
+	return &parser.EmbeddedMessage{Path: path, Filename: filename, Email: msg}
+}
+
+// TestEmbeddedDetail verifies forwarded messages are returned as sanitized sub-emails
+func TestEmbeddedDetail(t *testing.T) {
+	service := NewService(ServiceConfig{BaseURL: "https://api.example.com/v1"})
+	email := &repository.Email{
+		ID:        uuid.New(),
+		AliasID:   uuid.New(),
+		RawEmail:  []byte(forwardedInvoice),
+		Embedded:  ingestEmbedded(t, forwardedInvoice),
+		CreatedAt: time.Now().UTC(),
+	}
+	base := "https://api.example.com/v1/emails/" + email.ID.String() + "/embedded/"
+
+	summaries := service.embeddedSummaries(email)
+	if len(summaries) != 1 || summaries[0].Path != "1" || summaries[0].Filename != "Invoice.eml" || summaries[0].URL != base+"1" {
+		t.Fatalf("Summaries = %+v", summaries)
+	}
+	if summaries[0].Subject == nil || *summaries[0].Subject != "Invoice" {
+		t.Errorf("Summary subject = %v", summaries[0].Subject)
+	}
+
+	embedded := findEmbedded(email.RawEmail, "1")
+	if embedded == nil {
+		t.Fatal("Expected embedded message at path 1")
+	}
+	if embedded.Filename != "Invoice.eml" {
+		t.Errorf("Filename = %q", embedded.Filename)
+	}
+	detail := service.embeddedDetail(email, "alias@example.com", embedded)
+
+	if detail.EmbeddedPath != "1" || detail.FromAddress != "billing@example.com" || *detail.FromName != "Billing" {
+		t.Errorf("Detail = %+v", detail)
+	}
+	if !detail.ReceivedAt.Equal(time.Date(2024, 4, 1, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("ReceivedAt = %s", detail.ReceivedAt)
+	}
+	if detail.BodyHTML == nil || strings.Contains(*detail.BodyHTML, "script") || strings.Contains(*detail.BodyHTML, "onclick") {
+		t.Errorf("Embedded HTML must be sanitized, got %v", detail.BodyHTML)
+	}
+
+	// The executable is blocked and the forwarded .eml is listed alongside the text file
+	if len(detail.Attachments) != 2 {
+		t.Fatalf("Attachments = %+v", detail.Attachments)
+	}
+	if att := detail.Attachments[0]; att.ID != "1" || att.Filename != "invoice.txt" || att.DownloadURL != base+"1/attachments/1" {
+		t.Errorf("First attachment = %+v", att)
+	}
+	if att := detail.Attachments[1]; att.Filename != "message.eml" {
+		t.Errorf("Second attachment = %+v", att)
+	}
+
+	if len(detail.Embedded) != 1 || detail.Embedded[0].Path != "1.1" || detail.Embedded[0].URL != base+"1.1" {
+		t.Errorf("Nested summaries = %+v", detail.Embedded)
+	}
+	nested := findEmbedded(email.RawEmail, "1.1")
+	if nested == nil || strings.TrimSpace(nested.Email.BodyText) != "Order 7" {
+		t.Errorf("Nested message = %+v", nested)
+	}
+
+	for _, path := range []string{"2", "1.2", "x"} {
+		if findEmbedded(email.RawEmail, path) != nil {
+			t.Errorf("findEmbedded(%q) should be nil", path)
+		}
+	}
+}
+
+// TestEmbeddedAttachments verifies attachment IDs match between listing and download
+func TestEmbeddedAttachments(t *testing.T) {
+	service := NewService(ServiceConfig{})
+	embedded := findEmbedded([]byte(forwardedInvoice), "1")
+
+	attachments := service.embeddedAttachments(embedded.Email.RawEmail)
+	if len(attachments) != 2 {
+		t.Fatalf("Expected 2 attachments, got %d", len(attachments))
+	}
+	if string(attachments[0].Data) != "Total: 42" {
+		t.Errorf("Attachment data = %q", attachments[0].Data)
+	}
+
+	// Download re-extracts the attachments, so IDs must be stable between calls
+	again := service.embeddedAttachments(embedded.Email.RawEmail)
+	for i := range attachments {
+		if again[i].Filename != attachments[i].Filename || service.extractor.CalculateChecksum(again[i].Data) != service.extractor.CalculateChecksum(attachments[i].Data) {
+			t.Errorf("Attachment %d changed between extractions", i+1)
+		}
+	}
+
+	if plain := service.embeddedSummaries(&repository.Email{RawEmail: []byte("Subject: hi\r\n\r\nbody")}); plain != nil {
+		t.Errorf("Plain email should have no embedded messages, got %+v", plain)
+	}
+
+	// Summaries come from ingest, not from the raw email
+	if stale := service.embeddedSummaries(&repository.Email{RawEmail: []byte(forwardedInvoice)}); stale != nil {
+		t.Errorf("Summaries should not be parsed from the raw email, got %+v", stale)
+	}
+}
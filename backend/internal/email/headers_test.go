@@ -0,0 +1,30 @@
+package email
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/repository"
+)
+
+// TestEmailHeaderList verifies the stored list is returned and older emails are parsed from the raw message
+func TestEmailHeaderList(t *testing.T) {
+	stored := &repository.Email{
+		HeaderList: json.RawMessage(`[{"name":"Received","value":"from b"},{"name":"Received","value":"from a"}]`),
+		RawEmail:   []byte("Subject: ignored\r\n\r\n"),
+	}
+	headers := emailHeaderList(stored)
+	if len(headers) != 2 || headers[0].Value != "from b" || headers[1].Value != "from a" {
+		t.Errorf("Stored header list = %+v", headers)
+	}
+
+	legacy := &repository.Email{RawEmail: []byte("Received: from x\r\nReceived: from y\r\n\r\nBody\r\n")}
+	headers = emailHeaderList(legacy)
+	if len(headers) != 2 || headers[1].Value != "from y" {
+		t.Errorf("Parsed header list = %+v", headers)
+	}
+
+	if headers := emailHeaderList(&repository.Email{}); headers == nil || len(headers) != 0 {
+		t.Errorf("Email without raw message = %#v, want empty list", headers)
+	}
+}
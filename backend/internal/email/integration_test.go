@@ -599,6 +599,128 @@ func TestIntegration_BulkOperations(t *testing.T) {
 }
 
 
+// TestIntegration_Threads tests listing, reading, marking and deleting conversations
+func TestIntegration_Threads(t *testing.T) {
+	cleanupTestData(t)
+	defer cleanupTestData(t)
+
+	userEmail := fmt.Sprintf("thread_test_%d@example.com", time.Now().UnixNano())
+	password := "ValidPass1!"
+	domainName := fmt.Sprintf("thread%d.example.com", time.Now().UnixNano())
+
+	// Setup
+	accessToken := registerAndLogin(t, userEmail, password)
+	userID := getUserIDFromToken(t, accessToken)
+	domainID := createTestDomain(t, userID, domainName)
+	aliasID := createTestAlias(t, userID, domainID, "thread", domainName)
+
+	// Two emails of one conversation and a standalone email
+	first := createTestEmail(t, aliasID, "Lunch?", "Are you free?", "<p>Are you free?</p>")
+	reply := createTestEmail(t, aliasID, "Re: Lunch?", "Sure.", "<p>Sure.</p>")
+	createTestEmail(t, aliasID, "Newsletter", "News", "<p>News</p>")
+
+	ctx := context.Background()
+	if _, err := testDB.Exec(ctx, `UPDATE emails SET thread_id = $1 WHERE id = $2`, first, reply); err != nil {
+		t.Fatalf("Failed to thread reply: %v", err)
+	}
+
+	t.Run("ListThreads", func(t *testing.T) {
+		rr := makeRequest(t, "GET", "/api/v1/threads", nil, accessToken)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", rr.Code, rr.Body.String())
+		}
+
+		var resp APIResponse
+		json.Unmarshal(rr.Body.Bytes(), &resp)
+
+		var data struct {
+			Threads []struct {
+				ID           string `json:"id"`
+				MessageCount int    `json:"message_count"`
+				UnreadCount  int    `json:"unread_count"`
+			} `json:"threads"`
+		}
+		json.Unmarshal(resp.Data, &data)
+
+		if len(data.Threads) != 2 {
+			t.Fatalf("Expected 2 threads, got %d", len(data.Threads))
+		}
+		for _, thread := range data.Threads {
+			if thread.ID == first.String() && (thread.MessageCount != 2 || thread.UnreadCount != 2) {
+				t.Errorf("Expected 2 messages and 2 unread, got %d and %d", thread.MessageCount, thread.UnreadCount)
+			}
+		}
+	})
+
+	t.Run("GetThread", func(t *testing.T) {
+		rr := makeRequest(t, "GET", "/api/v1/threads/"+first.String(), nil, accessToken)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", rr.Code, rr.Body.String())
+		}
+
+		var resp APIResponse
+		json.Unmarshal(rr.Body.Bytes(), &resp)
+
+		var data struct {
+			Thread struct {
+				Emails []struct {
+					ID string `json:"id"`
+				} `json:"emails"`
+			} `json:"thread"`
+		}
+		json.Unmarshal(resp.Data, &data)
+
+		if len(data.Thread.Emails) != 2 {
+			t.Errorf("Expected 2 emails in thread, got %d", len(data.Thread.Emails))
+		}
+	})
+
+	t.Run("GetThread_NotFound", func(t *testing.T) {
+		rr := makeRequest(t, "GET", "/api/v1/threads/"+uuid.New().String(), nil, accessToken)
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", rr.Code)
+		}
+	})
+
+	t.Run("MarkThreadAsRead", func(t *testing.T) {
+		rr := makeRequest(t, "POST", "/api/v1/threads/"+first.String()+"/mark-read", nil, accessToken)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", rr.Code, rr.Body.String())
+		}
+
+		var resp APIResponse
+		json.Unmarshal(rr.Body.Bytes(), &resp)
+
+		var data struct {
+			UpdatedCount int `json:"updated_count"`
+		}
+		json.Unmarshal(resp.Data, &data)
+
+		if data.UpdatedCount != 2 {
+			t.Errorf("Expected 2 emails marked as read, got %d", data.UpdatedCount)
+		}
+	})
+
+	t.Run("DeleteThread", func(t *testing.T) {
+		rr := makeRequest(t, "DELETE", "/api/v1/threads/"+first.String(), nil, accessToken)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", rr.Code, rr.Body.String())
+		}
+
+		var resp APIResponse
+		json.Unmarshal(rr.Body.Bytes(), &resp)
+
+		var data struct {
+			EmailsDeleted int `json:"emails_deleted"`
+		}
+		json.Unmarshal(resp.Data, &data)
+
+		if data.EmailsDeleted != 2 {
+			t.Errorf("Expected 2 emails deleted, got %d", data.EmailsDeleted)
+		}
+	})
+}
+
 // TestIntegration_Statistics tests inbox statistics calculation
 // Requirements: 6.1-6.5
 func TestIntegration_Statistics(t *testing.T) {
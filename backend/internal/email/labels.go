@@ -0,0 +1,199 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/events"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/repository"
+)
+
+// MaxBulkLabels is the maximum number of labels in a bulk label operation
+const MaxBulkLabels = 20
+
+// Actions reported in emails_labeled events
+const (
+	LabelActionApplied = "applied"
+	LabelActionRemoved = "removed"
+)
+
+// LabelSummary represents a label applied to an email
+type LabelSummary struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+// BulkLabelRequest represents a request to apply labels to, or remove them from, emails
+type BulkLabelRequest struct {
+	EmailIDs []string `json:"email_ids" validate:"required,min=1,max=100"`
+	LabelIDs []string `json:"label_ids" validate:"required,min=1,max=20"`
+}
+
+// BulkApplyLabels applies labels to multiple emails
+// Emails that already have a label count as successful.
+func (s *Service) BulkApplyLabels(ctx context.Context, userID uuid.UUID, emailIDs, labelIDs []string) (*BulkOperationResponse, error) {
+	return s.bulkLabel(ctx, userID, emailIDs, labelIDs, LabelActionApplied)
+}
+
+// BulkRemoveLabels removes labels from multiple emails
+// Emails that do not have a label count as successful.
+func (s *Service) BulkRemoveLabels(ctx context.Context, userID uuid.UUID, emailIDs, labelIDs []string) (*BulkOperationResponse, error) {
+	return s.bulkLabel(ctx, userID, emailIDs, labelIDs, LabelActionRemoved)
+}
+
+// bulkLabel applies or removes labels on the user's emails
+// Every label must belong to the user; emails the user does not own are reported as failed.
+func (s *Service) bulkLabel(ctx context.Context, userID uuid.UUID, emailIDs, labelIDs []string, action string) (*BulkOperationResponse, error) {
+	// Check bulk limits
+	if len(emailIDs) > MaxBulkOperationItems || len(labelIDs) > MaxBulkLabels {
+		return nil, ErrBulkLimitExceeded
+	}
+
+	if len(emailIDs) == 0 || len(labelIDs) == 0 {
+		return &BulkOperationResponse{
+			SuccessCount: 0,
+			FailedCount:  0,
+			FailedIDs:    []string{},
+		}, nil
+	}
+
+	// Parse and check labels; a label the user does not own fails the whole request
+	parsedLabels := make([]uuid.UUID, 0, len(labelIDs))
+	seenLabels := make(map[uuid.UUID]bool)
+	for _, idStr := range labelIDs {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			return nil, ErrLabelNotFound
+		}
+		if !seenLabels[id] {
+			seenLabels[id] = true
+			parsedLabels = append(parsedLabels, id)
+		}
+	}
+
+	ownedLabels, err := s.emailRepo.GetLabelIDsOwnedByUser(ctx, parsedLabels, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter owned labels: %w", err)
+	}
+	if len(ownedLabels) != len(parsedLabels) {
+		return nil, ErrLabelNotFound
+	}
+
+	ownedIDs, failedIDs, err := s.filterOwnedEmails(ctx, userID, emailIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ownedIDs) == 0 {
+		return &BulkOperationResponse{
+			SuccessCount: 0,
+			FailedCount:  len(emailIDs),
+			FailedIDs:    failedIDs,
+		}, nil
+	}
+
+	var changed int
+	if action == LabelActionApplied {
+		changed, err = s.emailRepo.AddLabels(ctx, ownedIDs, ownedLabels)
+	} else {
+		changed, err = s.emailRepo.RemoveLabels(ctx, ownedIDs, ownedLabels)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update email labels: %w", err)
+	}
+
+	s.logger.Info("Bulk label operation completed",
+		"user_id", userID,
+		"action", action,
+		"emails", len(ownedIDs),
+		"labels", len(ownedLabels),
+		"changed", changed,
+	)
+
+	// Only notify when something actually changed
+	if changed > 0 && s.eventBus != nil {
+		s.publishEmailsLabeledEvent(userID.String(), action, ownedIDs, ownedLabels)
+	}
+
+	return &BulkOperationResponse{
+		SuccessCount: len(ownedIDs),
+		FailedCount:  len(emailIDs) - len(ownedIDs),
+		FailedIDs:    failedIDs,
+	}, nil
+}
+
+// attachLabels fills in the labels of listed emails
+func (s *Service) attachLabels(ctx context.Context, emails []EmailWithPreview) error {
+	if len(emails) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(emails))
+	for _, e := range emails {
+		if id, err := uuid.Parse(e.ID); err == nil {
+			ids = append(ids, id)
+		}
+	}
+
+	labels, err := s.emailRepo.GetLabelsByEmailIDs(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to get email labels: %w", err)
+	}
+
+	for i := range emails {
+		if id, err := uuid.Parse(emails[i].ID); err == nil {
+			emails[i].Labels = toLabelSummaries(labels[id])
+		}
+	}
+	return nil
+}
+
+// toLabelSummaries converts labels to their response format
+func toLabelSummaries(labels []repository.Label) []LabelSummary {
+	summaries := make([]LabelSummary, len(labels))
+	for i, l := range labels {
+		summaries[i] = LabelSummary{
+			ID:    l.ID.String(),
+			Name:  l.Name,
+			Color: l.Color,
+		}
+	}
+	return summaries
+}
+
+// publishEmailsLabeledEvent publishes an emails_labeled event to the event bus
+func (s *Service) publishEmailsLabeledEvent(userID, action string, emailIDs, labelIDs []uuid.UUID) {
+	eventData := events.EmailsLabeledEvent{
+		Action:   action,
+		EmailIDs: make([]string, len(emailIDs)),
+		LabelIDs: make([]string, len(labelIDs)),
+	}
+	for i, id := range emailIDs {
+		eventData.EmailIDs[i] = id.String()
+	}
+	for i, id := range labelIDs {
+		eventData.LabelIDs[i] = id.String()
+	}
+
+	data, err := json.Marshal(eventData)
+	if err != nil {
+		s.logger.Warn("Failed to marshal emails_labeled event", "error", err)
+		return
+	}
+
+	event := events.Event{
+		ID:        uuid.New().String(),
+		Type:      events.EventTypeEmailsLabeled,
+		UserID:    userID,
+		Data:      data,
+		Timestamp: time.Now().UTC(),
+	}
+
+	if err := s.eventBus.Publish(event); err != nil {
+		s.logger.Warn("Failed to publish emails_labeled event", "action", action, "error", err)
+	}
+}
@@ -0,0 +1,72 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+
+	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/parser"
+)
+
+// GetMIMEStructure returns the MIME tree of an email and the parts its bodies were taken from
+func (s *Service) GetMIMEStructure(ctx context.Context, userID uuid.UUID, emailID string) (*parser.MIMEStructure, error) {
+	email, err := s.getOwnedEmail(ctx, userID, emailID)
+	if err != nil {
+		return nil, err
+	}
+
+	structure, err := parser.InspectMIME(email.RawEmail)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect MIME structure: %w", err)
+	}
+	return structure, nil
+}
+
+// GetMIMEPart returns a leaf part of an email by part ID
+// Decoded parts keep their content type; raw parts are served as sent with application/octet-stream
+func (s *Service) GetMIMEPart(ctx context.Context, userID uuid.UUID, emailID, partID string, raw bool) (*AttachmentDownload, error) {
+	email, err := s.getOwnedEmail(ctx, userID, emailID)
+	if err != nil {
+		return nil, err
+	}
+
+	part, data, err := parser.ExtractMIMEPart(email.RawEmail, partID, !raw)
+	if err != nil {
+		if errors.Is(err, parser.ErrMIMEPartNotFound) {
+			return nil, ErrMIMEPartNotFound
+		}
+		return nil, fmt.Errorf("failed to extract MIME part: %w", err)
+	}
+
+	return mimePartDownload(part, data, raw, s.extractor.CalculateChecksum(data)), nil
+}
+
+// mimePartDownload describes the content of a MIME part for download
+func mimePartDownload(part *parser.MIMEPart, data []byte, raw bool, checksum string) *AttachmentDownload {
+	filename := part.Filename
+	if filename == "" {
+		filename = "part-" + part.ID
+	}
+
+	contentType := part.ContentType
+	if part.Charset != "" {
+		if withCharset := mime.FormatMediaType(contentType, map[string]string{"charset": part.Charset}); withCharset != "" {
+			contentType = withCharset
+		}
+	}
+	if raw {
+		contentType = "application/octet-stream"
+	}
+
+	return &AttachmentDownload{
+		Filename:    filename,
+		ContentType: contentType,
+		SizeBytes:   int64(len(data)),
+		Data:        io.NopCloser(bytes.NewReader(data)),
+		Checksum:    checksum,
+	}
+}
@@ -0,0 +1,32 @@
+package email
+
+import (
+	"io"
+	"testing"
+
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/parser"
+)
+
+// TestMIMEPartDownload verifies part downloads are named and typed by how they are served
+func TestMIMEPartDownload(t *testing.T) {
+	part := &parser.MIMEPart{ID: "1.2", ContentType: "text/html", Charset: "utf-8"}
+
+	download := mimePartDownload(part, []byte("<p>Hi</p>"), false, "abc")
+	if download.Filename != "part-1.2" || download.ContentType != "text/html; charset=utf-8" || download.SizeBytes != 9 {
+		t.Errorf("Decoded download = %+v", download)
+	}
+	data, _ := io.ReadAll(download.Data)
+	if string(data) != "<p>Hi</p>" {
+		t.Errorf("Data = %q", data)
+	}
+
+	download = mimePartDownload(part, []byte("PHA+SGk8L3A+"), true, "abc")
+	if download.ContentType != "application/octet-stream" {
+		t.Errorf("Raw content type = %q", download.ContentType)
+	}
+
+	part = &parser.MIMEPart{ID: "2", ContentType: "application/pdf", Filename: "report.pdf"}
+	if download = mimePartDownload(part, nil, false, "abc"); download.Filename != "report.pdf" {
+		t.Errorf("Filename = %q", download.Filename)
+	}
+}
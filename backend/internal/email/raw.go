@@ -0,0 +1,97 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/parser"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/repository"
+)
+
+// RawView selects how GET /emails/{id}/raw returns the original message
+type RawView string
+
+// Raw message views
+const (
+	RawViewEML     RawView = "eml"     // Download as message/rfc822
+	RawViewSource  RawView = "source"  // Whole message as plain text
+	RawViewHeaders RawView = "headers" // Header section only, as plain text
+)
+
+// maxEMLSubjectLength limits the subject part of .eml filenames, in characters
+const maxEMLSubjectLength = 80
+
+// GetRaw returns the original RFC 5322 bytes of an email as received, or its header section
+func (s *Service) GetRaw(ctx context.Context, userID uuid.UUID, emailID string, view RawView) (*AttachmentDownload, error) {
+	email, err := s.getOwnedEmail(ctx, userID, emailID)
+	if err != nil {
+		return nil, err
+	}
+	if len(email.RawEmail) == 0 {
+		return nil, ErrRawEmailNotFound
+	}
+
+	return rawDownload(email, view, s.extractor.CalculateChecksum), nil
+}
+
+// rawDownload describes the original message of an email, or its header section, for download
+func rawDownload(email *repository.Email, view RawView, checksum func([]byte) string) *AttachmentDownload {
+	data := email.RawEmail
+	filename := emlFilename(email)
+	contentType := "message/rfc822"
+
+	switch view {
+	case RawViewSource:
+		contentType = "text/plain; charset=utf-8"
+		filename = strings.TrimSuffix(filename, ".eml") + ".txt"
+	case RawViewHeaders:
+		data = parser.HeaderBlock(data)
+		contentType = "text/plain; charset=utf-8"
+		filename = strings.TrimSuffix(filename, ".eml") + "-headers.txt"
+	}
+
+	return &AttachmentDownload{
+		Filename:    filename,
+		ContentType: contentType,
+		SizeBytes:   int64(len(data)),
+		Data:        io.NopCloser(bytes.NewReader(data)),
+		Checksum:    checksum(data),
+	}
+}
+
+// emlFilename names a downloaded message after its subject, or its ID when it has none
+// Characters that are unsafe in filenames on common systems are replaced.
+func emlFilename(email *repository.Email) string {
+	var b strings.Builder
+	space := false
+	count := 0
+	for _, r := range derefString(email.Subject) {
+		if count == maxEMLSubjectLength {
+			break
+		}
+		switch {
+		case unicode.IsSpace(r):
+			space = b.Len() > 0
+			continue
+		case unicode.IsControl(r) || strings.ContainsRune(`/\:*?"<>|`, r):
+			r = '_'
+		}
+		if space {
+			b.WriteByte(' ')
+			count++
+			space = false
+		}
+		b.WriteRune(r)
+		count++
+	}
+
+	name := strings.Trim(b.String(), ". ")
+	if name == "" {
+		name = "email-" + email.ID.String()
+	}
+	return name + ".eml"
+}
@@ -0,0 +1,63 @@
+package email
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/repository"
+)
+
+// TestRawDownload verifies each view's content, type and filename
+func TestRawDownload(t *testing.T) {
+	subject := "Re: Invoice #42 / March"
+	email := &repository.Email{
+		ID:       uuid.New(),
+		Subject:  &subject,
+		RawEmail: []byte("Subject: Re: Invoice #42 / March\r\n\r\n<p>Body</p>\r\n"),
+	}
+	checksum := func(data []byte) string { return "sum" }
+
+	download := rawDownload(email, RawViewEML, checksum)
+	data, _ := io.ReadAll(download.Data)
+	if download.ContentType != "message/rfc822" || download.Filename != "Re_ Invoice #42 _ March.eml" || string(data) != string(email.RawEmail) {
+		t.Errorf("EML download = %+v, %q", download, data)
+	}
+
+	download = rawDownload(email, RawViewSource, checksum)
+	if !strings.HasPrefix(download.ContentType, "text/plain") || download.Filename != "Re_ Invoice #42 _ March.txt" || download.SizeBytes != int64(len(email.RawEmail)) {
+		t.Errorf("Source download = %+v", download)
+	}
+
+	download = rawDownload(email, RawViewHeaders, checksum)
+	data, _ = io.ReadAll(download.Data)
+	if download.Filename != "Re_ Invoice #42 _ March-headers.txt" || string(data) != "Subject: Re: Invoice #42 / March\r\n\r\n" {
+		t.Errorf("Headers download = %+v, %q", download, data)
+	}
+}
+
+// TestEMLFilename verifies whitespace is collapsed, long subjects are cut and empty subjects fall back to the ID
+func TestEMLFilename(t *testing.T) {
+	id := uuid.New()
+	long := strings.Repeat("é", 100)
+	blank := " \t.. "
+	tests := []struct {
+		subject *string
+		want    string
+	}{
+		{nil, "email-" + id.String() + ".eml"},
+		{&blank, "email-" + id.String() + ".eml"},
+		{&long, strings.Repeat("é", maxEMLSubjectLength) + ".eml"},
+	}
+	for _, tt := range tests {
+		if got := emlFilename(&repository.Email{ID: id, Subject: tt.subject}); got != tt.want {
+			t.Errorf("emlFilename(%v) = %q, want %q", tt.subject, got, tt.want)
+		}
+	}
+
+	folded := "Hello\r\n  world\x00"
+	if got := emlFilename(&repository.Email{ID: id, Subject: &folded}); got != "Hello world_.eml" {
+		t.Errorf("emlFilename(%q) = %q", folded, got)
+	}
+}
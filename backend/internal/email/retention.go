@@ -0,0 +1,105 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/metrics"
+)
+
+// retentionBatchSize is the number of expired emails deleted per batch
+const retentionBatchSize = 100
+
+// attachExpiry fills in when listed emails expire under the retention policy of their alias
+func (s *Service) attachExpiry(ctx context.Context, emails []EmailWithPreview) error {
+	if len(emails) == 0 {
+		return nil
+	}
+
+	seen := make(map[uuid.UUID]bool)
+	aliasIDs := make([]uuid.UUID, 0)
+	for _, e := range emails {
+		if id, err := uuid.Parse(e.AliasID); err == nil && !seen[id] {
+			seen[id] = true
+			aliasIDs = append(aliasIDs, id)
+		}
+	}
+
+	policies, err := s.emailRepo.GetRetentionPolicies(ctx, aliasIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get retention policies: %w", err)
+	}
+
+	// Aliases missing from the lookup get the zero policy, which never expires mail
+	for i := range emails {
+		if id, err := uuid.Parse(emails[i].AliasID); err == nil {
+			emails[i].ExpiresAt = policies[id].ExpiresAt(emails[i].ReceivedAt)
+		}
+	}
+	return nil
+}
+
+// getExpiry returns when an email of an alias received at receivedAt expires under the alias retention policy
+func (s *Service) getExpiry(ctx context.Context, aliasID uuid.UUID, receivedAt time.Time) (*time.Time, error) {
+	policies, err := s.emailRepo.GetRetentionPolicies(ctx, []uuid.UUID{aliasID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get retention policy: %w", err)
+	}
+	return policies[aliasID].ExpiresAt(receivedAt), nil
+}
+
+// ExpireEmails permanently deletes every email expired under the retention policy of its alias
+// Attachments are removed from storage and an email_deleted event is sent for each email.
+// Returns the number of emails deleted.
+func (s *Service) ExpireEmails(ctx context.Context) (int, error) {
+	start := time.Now()
+	defer func() {
+		metrics.RetentionRunDuration.Observe(time.Since(start).Seconds())
+	}()
+
+	deletedCount := 0
+	for {
+		expired, err := s.emailRepo.GetExpiredEmails(ctx, time.Now().UTC(), retentionBatchSize)
+		if err != nil {
+			metrics.RetentionRunErrors.Inc()
+			return deletedCount, fmt.Errorf("failed to get expired emails: %w", err)
+		}
+		if len(expired) == 0 {
+			break
+		}
+
+		ids := make([]uuid.UUID, len(expired))
+		for i, e := range expired {
+			ids[i] = e.ID
+		}
+
+		count, size, err := s.deleteEmailBatch(ctx, ids)
+		if err != nil {
+			metrics.RetentionRunErrors.Inc()
+			return deletedCount, err
+		}
+		deletedCount += count
+
+		metrics.RetentionBytesFreed.Add(float64(size))
+		for _, e := range expired {
+			metrics.RetentionEmailsDeleted.WithLabelValues(e.Mode).Inc()
+		}
+
+		// Publish email_deleted events so clients drop each expired email
+		if s.eventBus != nil {
+			deletedAt := time.Now().UTC()
+			for _, e := range expired {
+				s.publishEmailDeletedEvent(e.UserID.String(), e.ID.String(), e.AliasID.String(), deletedAt, true)
+			}
+		}
+
+		// Stop when the last batch was short, or when nothing could be deleted
+		if len(expired) < retentionBatchSize || count == 0 {
+			break
+		}
+	}
+
+	return deletedCount, nil
+}
@@ -0,0 +1,122 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DefaultRetentionInterval is how often emails expired under retention policies are deleted
+const DefaultRetentionInterval = 15 * time.Minute
+
+// RetentionService permanently deletes emails expired under the retention policy of their alias
+type RetentionService interface {
+	ExpireEmails(ctx context.Context) (int, error)
+}
+
+// RetentionScheduler deletes emails once their alias or domain retention policy expires them
+type RetentionScheduler struct {
+	service  RetentionService
+	interval time.Duration
+	logger   *slog.Logger
+
+	mu       sync.Mutex
+	running  bool
+	cancel   context.CancelFunc
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+// RetentionSchedulerConfig contains configuration for the RetentionScheduler
+type RetentionSchedulerConfig struct {
+	Service  RetentionService
+	Interval time.Duration // Time between retention runs (default: 15 minutes)
+	Logger   *slog.Logger
+}
+
+// NewRetentionScheduler creates a new RetentionScheduler instance
+func NewRetentionScheduler(cfg RetentionSchedulerConfig) *RetentionScheduler {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultRetentionInterval
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+
+	return &RetentionScheduler{
+		service:  cfg.Service,
+		interval: cfg.Interval,
+		logger:   cfg.Logger,
+	}
+}
+
+// Start begins deleting expired emails
+func (s *RetentionScheduler) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		return fmt.Errorf("retention scheduler is already running")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.running = true
+	s.cancel = cancel
+	s.stopChan = make(chan struct{})
+
+	s.wg.Add(1)
+	go s.run(ctx)
+
+	s.logger.Info("Retention scheduler started", "interval", s.interval)
+	return nil
+}
+
+// Stop stops the scheduler and waits for the current run to finish
+func (s *RetentionScheduler) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	close(s.stopChan)
+	s.cancel()
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	s.logger.Info("Retention scheduler stopped")
+}
+
+// run deletes expired emails on every tick until stopped
+func (s *RetentionScheduler) run(ctx context.Context) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.Expire(ctx)
+
+		select {
+		case <-s.stopChan:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Expire deletes every email expired under its retention policy
+// Returns the number of emails deleted.
+func (s *RetentionScheduler) Expire(ctx context.Context) int {
+	deleted, err := s.service.ExpireEmails(ctx)
+	if err != nil && ctx.Err() == nil {
+		s.logger.Error("Failed to delete expired emails", "deleted", deleted, "error", err)
+	}
+
+	if deleted > 0 {
+		s.logger.Info("Expired emails deleted", "count", deleted)
+	}
+	return deleted
+}
@@ -0,0 +1,58 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// mockRetentionService counts expiry runs and reports each on a channel
+type mockRetentionService struct {
+	deleted int
+	err     error
+	expired chan struct{}
+}
+
+func (m *mockRetentionService) ExpireEmails(ctx context.Context) (int, error) {
+	select {
+	case m.expired <- struct{}{}:
+	default:
+	}
+	return m.deleted, m.err
+}
+
+// TestRetentionScheduler_ExpiresOnStart verifies the scheduler runs right away and stops cleanly
+func TestRetentionScheduler_ExpiresOnStart(t *testing.T) {
+	service := &mockRetentionService{deleted: 2, expired: make(chan struct{}, 1)}
+	scheduler := NewRetentionScheduler(RetentionSchedulerConfig{Service: service, Interval: time.Hour})
+
+	if err := scheduler.Start(); err != nil {
+		t.Fatalf("Failed to start scheduler: %v", err)
+	}
+	if err := scheduler.Start(); err == nil {
+		t.Error("Expected an error starting a running scheduler")
+	}
+
+	select {
+	case <-service.expired:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected expired emails to be deleted on start")
+	}
+
+	scheduler.Stop()
+	scheduler.Stop()
+}
+
+// TestRetentionScheduler_Expire verifies the number of deleted emails is returned, even when the run fails part way
+func TestRetentionScheduler_Expire(t *testing.T) {
+	service := &mockRetentionService{deleted: 4, err: errors.New("storage unavailable"), expired: make(chan struct{}, 1)}
+	scheduler := NewRetentionScheduler(RetentionSchedulerConfig{Service: service})
+
+	if scheduler.interval != DefaultRetentionInterval {
+		t.Errorf("Expected default interval %v, got %v", DefaultRetentionInterval, scheduler.interval)
+	}
+	if deleted := scheduler.Expire(context.Background()); deleted != 4 {
+		t.Errorf("Expected 4 emails deleted, got %d", deleted)
+	}
+}
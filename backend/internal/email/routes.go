@@ -17,6 +17,7 @@ func RegisterRoutes(r chi.Router, handler *Handler, authMiddleware func(next htt
 }
 
 // RegisterRoutesWithRateLimit registers email management routes with optional attachment download rate limiting
+// Conversation routes are registered under /threads
 // All routes require authentication via auth middleware
 // Requirements: All email management endpoints, 6.7 (Download rate limiting)
 func RegisterRoutesWithRateLimit(r chi.Router, handler *Handler, authMiddleware func(next http.Handler) http.Handler, attachmentRateLimiter func(next http.Handler) http.Handler) {
@@ -32,22 +33,65 @@ func RegisterRoutesWithRateLimit(r chi.Router, handler *Handler, authMiddleware
 		// Requirements: 6.1-6.5
 		r.Get("/stats", handler.GetStats)
 
-		// POST /api/v1/emails/bulk/delete - Bulk delete emails
+		// POST /api/v1/emails/bulk/delete - Bulk move emails to the trash, or delete them with permanent=true
 		// Requirements: 5.1, 5.3, 5.4, 5.5
 		r.Post("/bulk/delete", handler.BulkDelete)
 
+		// POST /api/v1/emails/bulk/restore - Move emails out of the trash
+		r.Post("/bulk/restore", handler.BulkRestore)
+
+		// DELETE /api/v1/emails/trash - Permanently delete every email in the trash
+		r.Delete("/trash", handler.EmptyTrash)
+
 		// POST /api/v1/emails/bulk/mark-read - Bulk mark emails as read
 		// Requirements: 5.2, 5.3, 5.4, 5.5
 		r.Post("/bulk/mark-read", handler.BulkMarkAsRead)
 
+		// POST /api/v1/emails/bulk/star, /bulk/unstar - Star or unstar emails
+		r.Post("/bulk/star", handler.BulkStar)
+		r.Post("/bulk/unstar", handler.BulkUnstar)
+
+		// POST /api/v1/emails/bulk/archive, /bulk/unarchive - Hide emails from the list, or return them
+		r.Post("/bulk/archive", handler.BulkArchive)
+		r.Post("/bulk/unarchive", handler.BulkUnarchive)
+
+		// POST /api/v1/emails/bulk/snooze - Hide emails until snooze_until, then return them as unread
+		r.Post("/bulk/snooze", handler.BulkSnooze)
+
+		// POST /api/v1/emails/bulk/unsnooze - Return snoozed emails to the list now
+		r.Post("/bulk/unsnooze", handler.BulkUnsnooze)
+
+		// POST /api/v1/emails/bulk/labels/apply - Apply labels to emails
+		r.Post("/bulk/labels/apply", handler.BulkApplyLabels)
+
+		// POST /api/v1/emails/bulk/labels/remove - Remove labels from emails
+		r.Post("/bulk/labels/remove", handler.BulkRemoveLabels)
+
 		// GET /api/v1/emails/:id - Get email details
 		// Requirements: 2.1-2.8
 		r.Get("/{id}", handler.GetByID)
 
-		// DELETE /api/v1/emails/:id - Delete email
+		// GET /api/v1/emails/:id/codes - Get one-time codes and verification links
+		r.Get("/{id}/codes", handler.GetCodes)
+
+		// GET /api/v1/emails/:id/embedded/:path - Get a forwarded message as a sub-email
+		r.Get("/{id}/embedded/{path}", handler.GetEmbedded)
+
+		// GET /api/v1/emails/:id/mime - Get the MIME tree and the parts chosen as bodies
+		r.Get("/{id}/mime", handler.GetMIME)
+
+		// GET /api/v1/emails/:id/raw - Download the original message as .eml, or view its source or headers
+		// Query params: view=eml|source|headers
+		r.Get("/{id}/raw", handler.DownloadRaw)
+
+		// DELETE /api/v1/emails/:id - Move email to the trash
+		// Query params: permanent=true (delete for good)
 		// Requirements: 4.1-4.5
 		r.Delete("/{id}", handler.Delete)
 
+		// POST /api/v1/emails/:id/restore - Move email out of the trash
+		r.Post("/{id}/restore", handler.Restore)
+
 		// Attachment download routes with optional rate limiting
 		// Requirements: 3.1-3.7, 6.7 (Download rate limiting)
 		if attachmentRateLimiter != nil {
@@ -58,6 +102,13 @@ func RegisterRoutesWithRateLimit(r chi.Router, handler *Handler, authMiddleware
 			// GET /api/v1/emails/:id/attachments/:attachmentId/url - Get pre-signed download URL (rate limited)
 			// Requirements: 3.2 (Generate pre-signed URL with 15-minute expiration)
 			r.With(attachmentRateLimiter).Get("/{id}/attachments/{attachmentId}/url", handler.GetAttachmentURL)
+
+			// GET /api/v1/emails/:id/embedded/:path/attachments/:attachmentId - Download a forwarded message's attachment (rate limited)
+			r.With(attachmentRateLimiter).Get("/{id}/embedded/{path}/attachments/{attachmentId}", handler.DownloadEmbeddedAttachment)
+
+			// GET /api/v1/emails/:id/mime/:partId - Download a MIME part, decoded or raw (rate limited)
+			// Query params: raw=true (skip transfer decoding)
+			r.With(attachmentRateLimiter).Get("/{id}/mime/{partId}", handler.DownloadMIMEPart)
 		} else {
 			// GET /api/v1/emails/:id/attachments/:attachmentId - Download attachment
 			// Query params: inline=true (display inline), url_only=true (return pre-signed URL)
@@ -66,6 +117,31 @@ func RegisterRoutesWithRateLimit(r chi.Router, handler *Handler, authMiddleware
 			// GET /api/v1/emails/:id/attachments/:attachmentId/url - Get pre-signed download URL
 			// Requirements: 3.2 (Generate pre-signed URL with 15-minute expiration)
 			r.Get("/{id}/attachments/{attachmentId}/url", handler.GetAttachmentURL)
+
+			// GET /api/v1/emails/:id/embedded/:path/attachments/:attachmentId - Download a forwarded message's attachment
+			r.Get("/{id}/embedded/{path}/attachments/{attachmentId}", handler.DownloadEmbeddedAttachment)
+
+			// GET /api/v1/emails/:id/mime/:partId - Download a MIME part, decoded or raw
+			// Query params: raw=true (skip transfer decoding)
+			r.Get("/{id}/mime/{partId}", handler.DownloadMIMEPart)
 		}
 	})
+
+	r.Route("/threads", func(r chi.Router) {
+		// Apply auth middleware to all thread routes
+		r.Use(authMiddleware)
+
+		// GET /api/v1/threads - List conversations (paginated, last message preview and unread counts)
+		r.Get("/", handler.ListThreads)
+
+		// GET /api/v1/threads/:id - Get a conversation with its emails
+		r.Get("/{id}", handler.GetThread)
+
+		// POST /api/v1/threads/:id/mark-read - Mark every email of a conversation as read
+		r.Post("/{id}/mark-read", handler.MarkThreadAsRead)
+
+		// DELETE /api/v1/threads/:id - Move every email of a conversation to the trash
+		// Query params: permanent=true (delete for good)
+		r.Delete("/{id}", handler.DeleteThread)
+	})
 }
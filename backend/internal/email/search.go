@@ -0,0 +1,200 @@
+package email
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/repository"
+)
+
+// ErrInvalidSearch is returned when a search query has an operator with a malformed value
+var ErrInvalidSearch = errors.New("invalid search query")
+
+// MaxSearchLength is the maximum length of a search query
+const MaxSearchLength = 500
+
+// searchDateLayouts are the accepted before: and after: date formats
+var searchDateLayouts = []string{"2006/01/02", "2006-01-02"}
+
+// ParseSearch parses a Gmail-like search query
+// Bare words and "quoted phrases" match subject, sender and bodies; from:, to: and subject: match those fields;
+// has:attachment, is:unread and is:read filter by state; before: and after: take a YYYY/MM/DD date;
+// larger: takes a size such as 500K or 10M. A leading "-" negates any term.
+// Words with an unknown operator, such as "re:", are searched as text.
+func ParseSearch(query string) (*repository.EmailSearch, error) {
+	search := &repository.EmailSearch{}
+
+	for _, token := range tokenizeSearch(query) {
+		term, err := parseSearchToken(token)
+		if err != nil {
+			return nil, err
+		}
+		if term != nil {
+			search.Terms = append(search.Terms, *term)
+		}
+	}
+
+	if len(search.Terms) == 0 {
+		return nil, nil
+	}
+	return search, nil
+}
+
+// searchToken is a query word before its operator is interpreted
+type searchToken struct {
+	negated bool
+	op      string // Text before the colon, lowercased, "" for bare words and phrases
+	value   string
+	quoted  bool
+}
+
+// tokenizeSearch splits a query at whitespace, keeping quoted phrases and quoted operator values together
+func tokenizeSearch(query string) []searchToken {
+	var tokens []searchToken
+	runes := []rune(query)
+
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		var token searchToken
+		if runes[i] == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) {
+			token.negated = true
+			i++
+		}
+
+		if runes[i] != '"' {
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '"' {
+				i++
+			}
+			word := string(runes[start:i])
+			if colon := strings.IndexByte(word, ':'); colon > 0 {
+				token.op = strings.ToLower(word[:colon])
+				word = word[colon+1:]
+			}
+			token.value = word
+		}
+
+		// A quote starts a phrase, either on its own or as an operator value
+		if i < len(runes) && runes[i] == '"' && token.value == "" {
+			start := i + 1
+			i = start
+			for i < len(runes) && runes[i] != '"' {
+				i++
+			}
+			token.value = string(runes[start:i])
+			token.quoted = true
+			if i < len(runes) {
+				i++
+			}
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	return tokens
+}
+
+// parseSearchToken interprets a token's operator, returning nil for tokens with nothing to match
+func parseSearchToken(token searchToken) (*repository.SearchTerm, error) {
+	value := strings.TrimSpace(token.value)
+	term := &repository.SearchTerm{Value: value, Negated: token.negated}
+
+	switch token.op {
+	case "from":
+		term.Op = repository.SearchFrom
+	case "to":
+		term.Op = repository.SearchTo
+	case "subject":
+		term.Op = repository.SearchSubject
+	case "has":
+		if !strings.EqualFold(value, "attachment") {
+			return nil, fmt.Errorf("%w: unknown has:%s", ErrInvalidSearch, value)
+		}
+		return &repository.SearchTerm{Op: repository.SearchHasAttachment, Negated: token.negated}, nil
+	case "is":
+		switch strings.ToLower(value) {
+		case "unread":
+			return &repository.SearchTerm{Op: repository.SearchUnread, Negated: token.negated}, nil
+		case "read":
+			return &repository.SearchTerm{Op: repository.SearchUnread, Negated: !token.negated}, nil
+		}
+		return nil, fmt.Errorf("%w: unknown is:%s", ErrInvalidSearch, value)
+	case "before", "after":
+		day, err := parseSearchDate(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s:%s is not a YYYY/MM/DD date", ErrInvalidSearch, token.op, value)
+		}
+		term.Op = repository.SearchAfter
+		if token.op == "before" {
+			term.Op = repository.SearchBefore
+		}
+		term.Value = ""
+		term.Time = day
+		return term, nil
+	case "larger":
+		size, err := parseSearchSize(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: larger:%s is not a size", ErrInvalidSearch, value)
+		}
+		term.Op = repository.SearchLarger
+		term.Value = ""
+		term.Size = size
+		return term, nil
+	case "":
+		term.Op = repository.SearchText
+	default:
+		// Not an operator: search the whole word, colon included
+		term.Op = repository.SearchText
+		if !token.quoted {
+			term.Value = token.op + ":" + value
+		}
+	}
+
+	if term.Value == "" {
+		return nil, nil
+	}
+	return term, nil
+}
+
+// parseSearchDate parses a before: or after: date as the start of that day in UTC
+func parseSearchDate(value string) (time.Time, error) {
+	var err error
+	for _, layout := range searchDateLayouts {
+		var day time.Time
+		if day, err = time.Parse(layout, value); err == nil {
+			return day, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// parseSearchSize parses a larger: size in bytes, with an optional K, M or G suffix (powers of 1024)
+func parseSearchSize(value string) (int64, error) {
+	upper := strings.TrimSuffix(strings.ToUpper(value), "B")
+	multiplier := int64(1)
+	switch {
+	case strings.HasSuffix(upper, "K"):
+		multiplier = 1 << 10
+	case strings.HasSuffix(upper, "M"):
+		multiplier = 1 << 20
+	case strings.HasSuffix(upper, "G"):
+		multiplier = 1 << 30
+	}
+	if multiplier > 1 {
+		upper = upper[:len(upper)-1]
+	}
+
+	n, err := strconv.ParseFloat(upper, 64)
+	if err != nil || !(n >= 0) || n*float64(multiplier) > float64(1<<50) {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	return int64(n * float64(multiplier)), nil
+}
@@ -0,0 +1,47 @@
+package email
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/repository"
+)
+
+// TestParseSearch verifies operators, quoted phrases and negation are parsed into terms
+func TestParseSearch(t *testing.T) {
+	search, err := ParseSearch(`invoice -"payment due" from:billing@example.com subject:"March report" has:attachment is:read before:2024/03/01 after:2024-01-15 larger:1.5M re:`)
+	if err != nil {
+		t.Fatalf("ParseSearch() error = %v", err)
+	}
+
+	want := []repository.SearchTerm{
+		{Op: repository.SearchText, Value: "invoice"},
+		{Op: repository.SearchText, Value: "payment due", Negated: true},
+		{Op: repository.SearchFrom, Value: "billing@example.com"},
+		{Op: repository.SearchSubject, Value: "March report"},
+		{Op: repository.SearchHasAttachment},
+		{Op: repository.SearchUnread, Negated: true},
+		{Op: repository.SearchBefore, Time: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{Op: repository.SearchAfter, Time: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{Op: repository.SearchLarger, Size: 3 << 19},
+		{Op: repository.SearchText, Value: "re:"},
+	}
+	if !reflect.DeepEqual(search.Terms, want) {
+		t.Errorf("Terms = %+v\nwant %+v", search.Terms, want)
+	}
+	if !search.HasText() {
+		t.Error("HasText() = false")
+	}
+
+	if search, err := ParseSearch(`  from:  "" `); err != nil || search != nil {
+		t.Errorf("Empty query = %+v, %v", search, err)
+	}
+
+	for _, query := range []string{"has:pdf", "is:flagged", "before:yesterday", "larger:huge"} {
+		if _, err := ParseSearch(query); !errors.Is(err, ErrInvalidSearch) {
+			t.Errorf("ParseSearch(%q) error = %v, want ErrInvalidSearch", query, err)
+		}
+	}
+}
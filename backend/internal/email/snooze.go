@@ -0,0 +1,182 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/events"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/repository"
+)
+
+const (
+	// DefaultSnoozeInterval is how often due snoozed emails are woken
+	DefaultSnoozeInterval = time.Minute
+	// snoozeBatchSize is the number of emails woken per query
+	snoozeBatchSize = 500
+)
+
+// SnoozeRepository defines the data access of the snooze scheduler
+type SnoozeRepository interface {
+	WakeSnoozed(ctx context.Context, now time.Time, limit int) ([]repository.WokenEmail, error)
+}
+
+// SnoozeScheduler returns snoozed emails to the inbox once their time passes
+// Each woken email is marked unread and sent to the user as an email_unsnoozed event.
+type SnoozeScheduler struct {
+	repo     SnoozeRepository
+	eventBus events.EventBus
+	interval time.Duration
+	logger   *slog.Logger
+
+	mu       sync.Mutex
+	running  bool
+	cancel   context.CancelFunc
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+// SnoozeSchedulerConfig contains configuration for the SnoozeScheduler
+type SnoozeSchedulerConfig struct {
+	Repository SnoozeRepository
+	EventBus   events.EventBus
+	Interval   time.Duration // Time between wake-up checks (default: 1 minute)
+	Logger     *slog.Logger
+}
+
+// NewSnoozeScheduler creates a new SnoozeScheduler instance
+func NewSnoozeScheduler(cfg SnoozeSchedulerConfig) *SnoozeScheduler {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultSnoozeInterval
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+
+	return &SnoozeScheduler{
+		repo:     cfg.Repository,
+		eventBus: cfg.EventBus,
+		interval: cfg.Interval,
+		logger:   cfg.Logger,
+	}
+}
+
+// Start begins checking for due snoozed emails
+func (s *SnoozeScheduler) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		return fmt.Errorf("snooze scheduler is already running")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.running = true
+	s.cancel = cancel
+	s.stopChan = make(chan struct{})
+
+	s.wg.Add(1)
+	go s.run(ctx)
+
+	s.logger.Info("Snooze scheduler started", "interval", s.interval)
+	return nil
+}
+
+// Stop stops the scheduler and waits for the current check to finish
+func (s *SnoozeScheduler) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	close(s.stopChan)
+	s.cancel()
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	s.logger.Info("Snooze scheduler stopped")
+}
+
+// run wakes due emails on every tick until stopped
+func (s *SnoozeScheduler) run(ctx context.Context) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.WakeDue(ctx)
+
+		select {
+		case <-s.stopChan:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// WakeDue wakes every email snoozed until now or earlier
+// Returns the number of emails woken.
+func (s *SnoozeScheduler) WakeDue(ctx context.Context) int {
+	total := 0
+	for {
+		woken, err := s.repo.WakeSnoozed(ctx, time.Now().UTC(), snoozeBatchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.logger.Error("Failed to wake snoozed emails", "error", err)
+			}
+			return total
+		}
+
+		for _, email := range woken {
+			s.publishUnsnoozedEvent(email)
+		}
+		total += len(woken)
+
+		if len(woken) < snoozeBatchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		s.logger.Info("Snoozed emails woken", "count", total)
+	}
+	return total
+}
+
+// publishUnsnoozedEvent publishes an email_unsnoozed event to the event bus
+func (s *SnoozeScheduler) publishUnsnoozedEvent(email repository.WokenEmail) {
+	if s.eventBus == nil {
+		return
+	}
+
+	data, err := json.Marshal(events.EmailUnsnoozedEvent{
+		ID:           email.ID.String(),
+		AliasID:      email.AliasID.String(),
+		ThreadID:     email.ThreadID.String(),
+		FromAddress:  email.SenderAddress,
+		Subject:      email.Subject,
+		SnoozedUntil: email.SnoozedUntil,
+	})
+	if err != nil {
+		s.logger.Warn("Failed to marshal email_unsnoozed event", "error", err)
+		return
+	}
+
+	event := events.Event{
+		ID:        uuid.New().String(),
+		Type:      events.EventTypeEmailUnsnoozed,
+		UserID:    email.UserID.String(),
+		Data:      data,
+		Timestamp: time.Now().UTC(),
+	}
+
+	if err := s.eventBus.Publish(event); err != nil {
+		s.logger.Warn("Failed to publish email_unsnoozed event", "email_id", email.ID, "error", err)
+	}
+}
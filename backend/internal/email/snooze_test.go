@@ -0,0 +1,109 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/events"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/repository"
+	"pgregory.net/rapid"
+)
+
+// mockSnoozeRepository wakes emails from an in-memory snooze list
+type mockSnoozeRepository struct {
+	snoozed []repository.WokenEmail
+	calls   int
+}
+
+func (m *mockSnoozeRepository) WakeSnoozed(ctx context.Context, now time.Time, limit int) ([]repository.WokenEmail, error) {
+	m.calls++
+	var woken, remaining []repository.WokenEmail
+	for _, e := range m.snoozed {
+		if len(woken) < limit && !e.SnoozedUntil.After(now) {
+			woken = append(woken, e)
+		} else {
+			remaining = append(remaining, e)
+		}
+	}
+	m.snoozed = remaining
+	return woken, nil
+}
+
+// recordingEventBus records published events
+type recordingEventBus struct {
+	published []events.Event
+}
+
+func (b *recordingEventBus) Publish(event events.Event) error {
+	b.published = append(b.published, event)
+	return nil
+}
+
+func (b *recordingEventBus) Subscribe(userID string, handler events.EventHandler) func() {
+	return func() {}
+}
+
+func (b *recordingEventBus) GetEventsSince(userID string, lastEventID string) ([]events.Event, error) {
+	return nil, nil
+}
+
+// TestSnoozeScheduler_WakesOnlyDueEmails verifies due emails are woken in batches, each with an event for its owner
+func TestSnoozeScheduler_WakesOnlyDueEmails(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		due := rapid.IntRange(0, 2*snoozeBatchSize+10).Draw(t, "due")
+		future := rapid.IntRange(0, 20).Draw(t, "future")
+
+		repo := &mockSnoozeRepository{}
+		now := time.Now().UTC()
+		for i := 0; i < due; i++ {
+			repo.snoozed = append(repo.snoozed, repository.WokenEmail{ID: uuid.New(), UserID: uuid.New(), SnoozedUntil: now.Add(-time.Minute)})
+		}
+		for i := 0; i < future; i++ {
+			repo.snoozed = append(repo.snoozed, repository.WokenEmail{ID: uuid.New(), UserID: uuid.New(), SnoozedUntil: now.Add(time.Hour)})
+		}
+
+		bus := &recordingEventBus{}
+		scheduler := NewSnoozeScheduler(SnoozeSchedulerConfig{Repository: repo, EventBus: bus})
+
+		if woken := scheduler.WakeDue(context.Background()); woken != due {
+			t.Fatalf("Expected %d emails woken, got %d", due, woken)
+		}
+		if len(repo.snoozed) != future {
+			t.Fatalf("Expected %d emails still snoozed, got %d", future, len(repo.snoozed))
+		}
+		if want := due/snoozeBatchSize + 1; repo.calls != want {
+			t.Fatalf("Expected %d batches, got %d", want, repo.calls)
+		}
+		if len(bus.published) != due {
+			t.Fatalf("Expected %d events, got %d", due, len(bus.published))
+		}
+		for _, event := range bus.published {
+			if event.Type != events.EventTypeEmailUnsnoozed {
+				t.Fatalf("Expected email_unsnoozed event, got %s", event.Type)
+			}
+			var data events.EmailUnsnoozedEvent
+			if err := json.Unmarshal(event.Data, &data); err != nil || data.ID == "" {
+				t.Fatalf("Invalid event data %s: %v", event.Data, err)
+			}
+		}
+	})
+}
+
+// TestBulkSnooze_RejectsInvalidTimes verifies snooze times must be in the future and within a year
+func TestBulkSnooze_RejectsInvalidTimes(t *testing.T) {
+	service := NewService(ServiceConfig{})
+	ids := []string{uuid.New().String()}
+
+	for _, until := range []time.Time{
+		time.Now().Add(-time.Minute),
+		time.Now().Add(MaxSnoozeDuration + time.Hour),
+	} {
+		if _, err := service.BulkSnooze(context.Background(), uuid.New(), ids, until); !errors.Is(err, ErrInvalidSnoozeTime) {
+			t.Errorf("Expected ErrInvalidSnoozeTime for %v, got %v", until, err)
+		}
+	}
+}
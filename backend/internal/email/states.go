@@ -0,0 +1,194 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/events"
+)
+
+// MaxSnoozeDuration is how far ahead an email can be snoozed
+const MaxSnoozeDuration = 365 * 24 * time.Hour
+
+// Actions reported in emails_updated events
+const (
+	StateActionStarred    = "starred"
+	StateActionUnstarred  = "unstarred"
+	StateActionArchived   = "archived"
+	StateActionUnarchived = "unarchived"
+	StateActionSnoozed    = "snoozed"
+	StateActionUnsnoozed  = "unsnoozed"
+	StateActionTrashed    = "trashed"
+	StateActionRestored   = "restored"
+)
+
+// BulkSnoozeRequest represents a request to snooze emails until a time
+type BulkSnoozeRequest struct {
+	EmailIDs    []string  `json:"email_ids" validate:"required,min=1,max=100"`
+	SnoozeUntil time.Time `json:"snooze_until" validate:"required"`
+}
+
+// BulkStar stars or unstars multiple emails
+func (s *Service) BulkStar(ctx context.Context, userID uuid.UUID, emailIDs []string, starred bool) (*BulkOperationResponse, error) {
+	action := StateActionUnstarred
+	if starred {
+		action = StateActionStarred
+	}
+	return s.bulkUpdateState(ctx, userID, emailIDs, action, nil, func(ids []uuid.UUID) (int, error) {
+		return s.emailRepo.SetStarredBatch(ctx, ids, starred)
+	})
+}
+
+// BulkArchive archives or unarchives multiple emails
+// Archived emails are hidden from the email list unless is_archived is requested.
+func (s *Service) BulkArchive(ctx context.Context, userID uuid.UUID, emailIDs []string, archived bool) (*BulkOperationResponse, error) {
+	action := StateActionUnarchived
+	if archived {
+		action = StateActionArchived
+	}
+	return s.bulkUpdateState(ctx, userID, emailIDs, action, nil, func(ids []uuid.UUID) (int, error) {
+		return s.emailRepo.SetArchivedBatch(ctx, ids, archived)
+	})
+}
+
+// BulkSnooze hides multiple emails until a time
+// The snooze scheduler returns them to the inbox as unread once the time passes.
+func (s *Service) BulkSnooze(ctx context.Context, userID uuid.UUID, emailIDs []string, until time.Time) (*BulkOperationResponse, error) {
+	now := time.Now()
+	if !until.After(now) || until.After(now.Add(MaxSnoozeDuration)) {
+		return nil, ErrInvalidSnoozeTime
+	}
+	until = until.UTC()
+
+	return s.bulkUpdateState(ctx, userID, emailIDs, StateActionSnoozed, &until, func(ids []uuid.UUID) (int, error) {
+		return s.emailRepo.SnoozeBatch(ctx, ids, &until)
+	})
+}
+
+// BulkUnsnooze returns multiple snoozed emails to the inbox right away
+// Unlike a scheduled wake-up, their read state is kept.
+func (s *Service) BulkUnsnooze(ctx context.Context, userID uuid.UUID, emailIDs []string) (*BulkOperationResponse, error) {
+	return s.bulkUpdateState(ctx, userID, emailIDs, StateActionUnsnoozed, nil, func(ids []uuid.UUID) (int, error) {
+		return s.emailRepo.SnoozeBatch(ctx, ids, nil)
+	})
+}
+
+// bulkUpdateState runs update on the user's emails and publishes an emails_updated event
+func (s *Service) bulkUpdateState(ctx context.Context, userID uuid.UUID, emailIDs []string, action string, snoozedUntil *time.Time, update func(ids []uuid.UUID) (int, error)) (*BulkOperationResponse, error) {
+	// Check bulk limit
+	if len(emailIDs) > MaxBulkOperationItems {
+		return nil, ErrBulkLimitExceeded
+	}
+
+	if len(emailIDs) == 0 {
+		return &BulkOperationResponse{
+			SuccessCount: 0,
+			FailedCount:  0,
+			FailedIDs:    []string{},
+		}, nil
+	}
+
+	ownedIDs, failedIDs, err := s.filterOwnedEmails(ctx, userID, emailIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ownedIDs) == 0 {
+		return &BulkOperationResponse{
+			SuccessCount: 0,
+			FailedCount:  len(emailIDs),
+			FailedIDs:    failedIDs,
+		}, nil
+	}
+
+	updatedCount, err := update(ownedIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update emails: %w", err)
+	}
+
+	s.logger.Info("Bulk email update completed",
+		"user_id", userID,
+		"action", action,
+		"requested", len(emailIDs),
+		"updated", updatedCount,
+	)
+
+	if s.eventBus != nil {
+		s.publishEmailsUpdatedEvent(userID.String(), action, ownedIDs, snoozedUntil)
+	}
+
+	return &BulkOperationResponse{
+		SuccessCount: updatedCount,
+		FailedCount:  len(emailIDs) - updatedCount,
+		FailedIDs:    failedIDs,
+	}, nil
+}
+
+// filterOwnedEmails parses email IDs and keeps those owned by the user
+// Returns the owned IDs and the IDs that are invalid or owned by someone else.
+func (s *Service) filterOwnedEmails(ctx context.Context, userID uuid.UUID, emailIDs []string) ([]uuid.UUID, []string, error) {
+	// Parse all email IDs
+	parsedIDs := make([]uuid.UUID, 0, len(emailIDs))
+	failedIDs := make([]string, 0)
+
+	for _, idStr := range emailIDs {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			failedIDs = append(failedIDs, idStr)
+			continue
+		}
+		parsedIDs = append(parsedIDs, id)
+	}
+
+	// Filter to only owned emails
+	ownedIDs, err := s.emailRepo.GetEmailIDsOwnedByUser(ctx, parsedIDs, userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to filter owned emails: %w", err)
+	}
+
+	// Track which IDs were not owned
+	ownedIDSet := make(map[uuid.UUID]bool)
+	for _, id := range ownedIDs {
+		ownedIDSet[id] = true
+	}
+	for _, id := range parsedIDs {
+		if !ownedIDSet[id] {
+			failedIDs = append(failedIDs, id.String())
+		}
+	}
+
+	return ownedIDs, failedIDs, nil
+}
+
+// publishEmailsUpdatedEvent publishes an emails_updated event to the event bus
+func (s *Service) publishEmailsUpdatedEvent(userID, action string, emailIDs []uuid.UUID, snoozedUntil *time.Time) {
+	eventData := events.EmailsUpdatedEvent{
+		Action:       action,
+		EmailIDs:     make([]string, len(emailIDs)),
+		SnoozedUntil: snoozedUntil,
+	}
+	for i, id := range emailIDs {
+		eventData.EmailIDs[i] = id.String()
+	}
+
+	data, err := json.Marshal(eventData)
+	if err != nil {
+		s.logger.Warn("Failed to marshal emails_updated event", "error", err)
+		return
+	}
+
+	event := events.Event{
+		ID:        uuid.New().String(),
+		Type:      events.EventTypeEmailsUpdated,
+		UserID:    userID,
+		Data:      data,
+		Timestamp: time.Now().UTC(),
+	}
+
+	if err := s.eventBus.Publish(event); err != nil {
+		s.logger.Warn("Failed to publish emails_updated event", "action", action, "error", err)
+	}
+}
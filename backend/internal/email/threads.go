@@ -0,0 +1,279 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/repository"
+)
+
+// ListThreadParams holds parameters for listing threads
+type ListThreadParams struct {
+	Page    int    `json:"page" validate:"min=1"`
+	Limit   int    `json:"limit" validate:"min=1,max=100"`
+	AliasID string `json:"alias_id,omitempty" validate:"omitempty,uuid"`
+	IsRead  *bool  `json:"is_read,omitempty"`
+}
+
+// ThreadListResponse represents the paginated list of threads
+type ThreadListResponse struct {
+	Threads    []ThreadSummary `json:"threads"`
+	Pagination Pagination      `json:"pagination"`
+}
+
+// ThreadSummary represents a conversation with a preview of its latest email
+type ThreadSummary struct {
+	ID             string          `json:"id"`
+	AliasID        string          `json:"alias_id"`
+	AliasEmail     string          `json:"alias_email"`
+	Subject        *string         `json:"subject,omitempty"`
+	MessageCount   int             `json:"message_count"`
+	UnreadCount    int             `json:"unread_count"`
+	HasAttachments bool            `json:"has_attachments"`
+	LastMessage    ThreadLastEmail `json:"last_message"`
+}
+
+// ThreadLastEmail previews the most recent email of a thread
+type ThreadLastEmail struct {
+	ID          string    `json:"id"`
+	FromAddress string    `json:"from_address"`
+	FromName    *string   `json:"from_name,omitempty"`
+	PreviewText string    `json:"preview_text"`
+	ReceivedAt  time.Time `json:"received_at"`
+}
+
+// ThreadDetailResponse represents a conversation with its emails, oldest first
+type ThreadDetailResponse struct {
+	ID           string             `json:"id"`
+	Subject      *string            `json:"subject,omitempty"`
+	MessageCount int                `json:"message_count"`
+	UnreadCount  int                `json:"unread_count"`
+	Emails       []EmailWithPreview `json:"emails"`
+}
+
+// DeleteThreadResponse represents the response after deleting a thread
+type DeleteThreadResponse struct {
+	Message             string     `json:"message"`
+	ThreadID            string     `json:"thread_id"`
+	Permanent           bool       `json:"permanent"`
+	PurgeAt             *time.Time `json:"purge_at,omitempty"`
+	EmailsDeleted       int        `json:"emails_deleted"`
+	TotalSizeFreedBytes int64      `json:"total_size_freed_bytes"`
+}
+
+// MarkThreadReadResponse represents the response after marking a thread as read
+type MarkThreadReadResponse struct {
+	ThreadID     string `json:"thread_id"`
+	UpdatedCount int    `json:"updated_count"`
+}
+
+// ListThreads retrieves a user's conversations, most recently active first
+func (s *Service) ListThreads(ctx context.Context, userID uuid.UUID, params ListThreadParams) (*ThreadListResponse, error) {
+	// Apply defaults
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	if params.Limit < 1 {
+		params.Limit = 20
+	}
+	if params.Limit > 100 {
+		params.Limit = 100
+	}
+
+	repoParams := repository.ListThreadParams{
+		Page:   params.Page,
+		Limit:  params.Limit,
+		IsRead: params.IsRead,
+	}
+
+	if params.AliasID != "" {
+		aliasID, err := uuid.Parse(params.AliasID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid alias_id format: %w", err)
+		}
+		repoParams.AliasID = &aliasID
+	}
+
+	threads, totalCount, err := s.emailRepo.ListThreads(ctx, userID, repoParams)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list threads: %w", err)
+	}
+
+	threadResponses := make([]ThreadSummary, len(threads))
+	for i, t := range threads {
+		threadResponses[i] = ThreadSummary{
+			ID:             t.ID.String(),
+			AliasID:        t.AliasID.String(),
+			AliasEmail:     t.AliasEmail,
+			Subject:        t.Subject,
+			MessageCount:   t.MessageCount,
+			UnreadCount:    t.UnreadCount,
+			HasAttachments: t.HasAttachments,
+			LastMessage: ThreadLastEmail{
+				ID:          t.LastEmailID.String(),
+				FromAddress: t.LastFromAddress,
+				FromName:    t.LastFromName,
+				PreviewText: t.PreviewText,
+				ReceivedAt:  t.LastReceivedAt,
+			},
+		}
+	}
+
+	// Calculate pagination
+	totalPages := (totalCount + params.Limit - 1) / params.Limit
+	if totalPages < 1 {
+		totalPages = 1
+	}
+
+	return &ThreadListResponse{
+		Threads: threadResponses,
+		Pagination: Pagination{
+			CurrentPage: params.Page,
+			PerPage:     params.Limit,
+			TotalPages:  totalPages,
+			TotalCount:  totalCount,
+		},
+	}, nil
+}
+
+// GetThread retrieves a conversation with its emails
+func (s *Service) GetThread(ctx context.Context, userID uuid.UUID, threadID string) (*ThreadDetailResponse, error) {
+	id, err := s.getOwnedThread(ctx, userID, threadID)
+	if err != nil {
+		return nil, err
+	}
+
+	emails, err := s.emailRepo.GetThreadEmails(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrThreadNotFound) {
+			return nil, ErrThreadNotFound
+		}
+		return nil, fmt.Errorf("failed to get thread emails: %w", err)
+	}
+
+	detail := threadDetail(id, emails)
+	if err := s.attachLabels(ctx, detail.Emails); err != nil {
+		return nil, err
+	}
+	if err := s.attachExpiry(ctx, detail.Emails); err != nil {
+		return nil, err
+	}
+	return detail, nil
+}
+
+// threadDetail builds the thread response from its emails in received order
+func threadDetail(threadID uuid.UUID, emails []repository.EmailWithPreview) *ThreadDetailResponse {
+	detail := &ThreadDetailResponse{
+		ID:           threadID.String(),
+		MessageCount: len(emails),
+		Emails:       make([]EmailWithPreview, len(emails)),
+	}
+
+	for i, e := range emails {
+		if detail.Subject == nil && e.Subject != nil {
+			detail.Subject = e.Subject
+		}
+		if !e.IsRead {
+			detail.UnreadCount++
+		}
+		detail.Emails[i] = toEmailWithPreview(e)
+	}
+
+	return detail
+}
+
+// DeleteThread moves every email of a conversation to the trash
+// When permanent is set, the emails and their attachments are deleted for good instead.
+func (s *Service) DeleteThread(ctx context.Context, userID uuid.UUID, threadID string, permanent bool) (*DeleteThreadResponse, error) {
+	id, err := s.getOwnedThread(ctx, userID, threadID)
+	if err != nil {
+		return nil, err
+	}
+
+	emails, err := s.emailRepo.GetThreadEmails(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrThreadNotFound) {
+			return nil, ErrThreadNotFound
+		}
+		return nil, fmt.Errorf("failed to get thread emails: %w", err)
+	}
+
+	emailIDs := make([]uuid.UUID, len(emails))
+	for i, e := range emails {
+		emailIDs[i] = e.ID
+	}
+
+	if !permanent {
+		return s.trashThread(ctx, userID, id, emails, emailIDs)
+	}
+
+	deletedCount, totalSize, err := s.deleteEmailBatch(ctx, emailIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Thread deleted",
+		"thread_id", id,
+		"user_id", userID,
+		"deleted", deletedCount,
+		"size_freed", totalSize,
+	)
+
+	// Publish email_deleted events so clients drop each email of the thread
+	if s.eventBus != nil {
+		deletedAt := time.Now().UTC()
+		for _, e := range emails {
+			s.publishEmailDeletedEvent(userID.String(), e.ID.String(), e.AliasID.String(), deletedAt, true)
+		}
+	}
+
+	return &DeleteThreadResponse{
+		Message:             "Thread deleted successfully",
+		ThreadID:            id.String(),
+		Permanent:           true,
+		EmailsDeleted:       deletedCount,
+		TotalSizeFreedBytes: totalSize,
+	}, nil
+}
+
+// MarkThreadAsRead marks every email of a conversation as read
+func (s *Service) MarkThreadAsRead(ctx context.Context, userID uuid.UUID, threadID string) (*MarkThreadReadResponse, error) {
+	id, err := s.getOwnedThread(ctx, userID, threadID)
+	if err != nil {
+		return nil, err
+	}
+
+	updatedCount, err := s.emailRepo.MarkThreadAsRead(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark thread as read: %w", err)
+	}
+
+	return &MarkThreadReadResponse{
+		ThreadID:     id.String(),
+		UpdatedCount: updatedCount,
+	}, nil
+}
+
+// getOwnedThread parses a thread ID, returning ErrAccessDenied if the thread belongs to another user
+func (s *Service) getOwnedThread(ctx context.Context, userID uuid.UUID, threadID string) (uuid.UUID, error) {
+	id, err := uuid.Parse(threadID)
+	if err != nil {
+		return uuid.Nil, ErrThreadNotFound
+	}
+
+	ownerID, err := s.emailRepo.GetThreadOwner(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrThreadNotFound) {
+			return uuid.Nil, ErrThreadNotFound
+		}
+		return uuid.Nil, fmt.Errorf("failed to get thread owner: %w", err)
+	}
+	if ownerID != userID {
+		return uuid.Nil, ErrAccessDenied
+	}
+
+	return id, nil
+}
@@ -0,0 +1,55 @@
+package email
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/repository"
+	"pgregory.net/rapid"
+)
+
+// TestThreadDetail verifies the thread takes its subject from the first email and counts unread emails
+func TestThreadDetail(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		threadID := uuid.New()
+		n := rapid.IntRange(1, 10).Draw(t, "count")
+
+		emails := make([]repository.EmailWithPreview, n)
+		wantUnread := 0
+		for i := range emails {
+			isRead := rapid.Bool().Draw(t, "is_read")
+			if !isRead {
+				wantUnread++
+			}
+			subject := "Re: Lunch?"
+			emails[i] = repository.EmailWithPreview{
+				ID:         uuid.New(),
+				ThreadID:   threadID,
+				AliasID:    uuid.New(),
+				Subject:    &subject,
+				ReceivedAt: time.Now().Add(time.Duration(i) * time.Minute),
+				IsRead:     isRead,
+			}
+		}
+		first := "Lunch?"
+		emails[0].Subject = &first
+
+		detail := threadDetail(threadID, emails)
+
+		if detail.ID != threadID.String() || detail.MessageCount != n {
+			t.Fatalf("Thread %s with %d messages, want %s with %d", detail.ID, detail.MessageCount, threadID, n)
+		}
+		if detail.UnreadCount != wantUnread {
+			t.Errorf("UnreadCount = %d, want %d", detail.UnreadCount, wantUnread)
+		}
+		if detail.Subject == nil || *detail.Subject != first {
+			t.Errorf("Subject = %v, want %q", detail.Subject, first)
+		}
+		for i, e := range detail.Emails {
+			if e.ID != emails[i].ID.String() || e.ThreadID != threadID.String() {
+				t.Fatalf("Email %d = %s in thread %s", i, e.ID, e.ThreadID)
+			}
+		}
+	})
+}
@@ -0,0 +1,224 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/repository"
+)
+
+const (
+	// DefaultTrashRetention is how long trashed emails are kept before being purged
+	DefaultTrashRetention = 30 * 24 * time.Hour
+	// trashPurgeBatchSize is the number of trashed emails deleted per batch
+	trashPurgeBatchSize = 100
+)
+
+// BulkDeleteRequest represents a request to delete multiple emails
+// Emails go to the trash unless permanent is set.
+type BulkDeleteRequest struct {
+	EmailIDs  []string `json:"email_ids" validate:"required,min=1,max=100"`
+	Permanent bool     `json:"permanent"`
+}
+
+// RestoreEmailResponse represents the response after restoring an email from the trash
+type RestoreEmailResponse struct {
+	Message string `json:"message"`
+	EmailID string `json:"email_id"`
+}
+
+// EmptyTrashResponse represents the response after emptying the trash
+type EmptyTrashResponse struct {
+	Message             string `json:"message"`
+	EmailsDeleted       int    `json:"emails_deleted"`
+	TotalSizeFreedBytes int64  `json:"total_size_freed_bytes"`
+}
+
+// Delete moves an email to the trash
+// It can be restored until the trash retention passes, after which the trash purger deletes it for good.
+// Emails already in the trash keep their original deletion time.
+func (s *Service) Delete(ctx context.Context, userID uuid.UUID, emailID string) (*DeleteEmailResponse, error) {
+	email, err := s.getOwnedEmail(ctx, userID, emailID)
+	if err != nil {
+		return nil, err
+	}
+
+	deletedAt := time.Now().UTC()
+	if email.DeletedAt != nil {
+		deletedAt = *email.DeletedAt
+	}
+
+	if _, err := s.emailRepo.TrashBatch(ctx, []uuid.UUID{email.ID}, deletedAt); err != nil {
+		return nil, fmt.Errorf("failed to move email to trash: %w", err)
+	}
+
+	s.logger.Info("Email moved to trash",
+		"email_id", email.ID,
+		"user_id", userID,
+	)
+
+	// Publish email_deleted event so clients drop the email from the inbox
+	if s.eventBus != nil {
+		s.publishEmailDeletedEvent(userID.String(), email.ID.String(), email.AliasID.String(), deletedAt, false)
+	}
+
+	return &DeleteEmailResponse{
+		Message: "Email moved to trash",
+		EmailID: email.ID.String(),
+		PurgeAt: s.purgeAt(&deletedAt),
+	}, nil
+}
+
+// Restore moves an email out of the trash
+// Restoring an email that is not in the trash does nothing.
+func (s *Service) Restore(ctx context.Context, userID uuid.UUID, emailID string) (*RestoreEmailResponse, error) {
+	email, err := s.getOwnedEmail(ctx, userID, emailID)
+	if err != nil {
+		return nil, err
+	}
+
+	if email.DeletedAt != nil {
+		if _, err := s.emailRepo.RestoreBatch(ctx, []uuid.UUID{email.ID}); err != nil {
+			return nil, fmt.Errorf("failed to restore email: %w", err)
+		}
+
+		s.logger.Info("Email restored from trash",
+			"email_id", email.ID,
+			"user_id", userID,
+		)
+
+		if s.eventBus != nil {
+			s.publishEmailsUpdatedEvent(userID.String(), StateActionRestored, []uuid.UUID{email.ID}, nil)
+		}
+	}
+
+	return &RestoreEmailResponse{
+		Message: "Email restored",
+		EmailID: email.ID.String(),
+	}, nil
+}
+
+// BulkRestore moves multiple emails out of the trash
+func (s *Service) BulkRestore(ctx context.Context, userID uuid.UUID, emailIDs []string) (*BulkOperationResponse, error) {
+	return s.bulkUpdateState(ctx, userID, emailIDs, StateActionRestored, nil, func(ids []uuid.UUID) (int, error) {
+		return s.emailRepo.RestoreBatch(ctx, ids)
+	})
+}
+
+// bulkTrash moves multiple emails to the trash
+func (s *Service) bulkTrash(ctx context.Context, userID uuid.UUID, emailIDs []string) (*BulkOperationResponse, error) {
+	deletedAt := time.Now().UTC()
+	return s.bulkUpdateState(ctx, userID, emailIDs, StateActionTrashed, nil, func(ids []uuid.UUID) (int, error) {
+		return s.emailRepo.TrashBatch(ctx, ids, deletedAt)
+	})
+}
+
+// trashThread moves the emails of a conversation to the trash
+func (s *Service) trashThread(ctx context.Context, userID, threadID uuid.UUID, emails []repository.EmailWithPreview, emailIDs []uuid.UUID) (*DeleteThreadResponse, error) {
+	deletedAt := time.Now().UTC()
+	trashedCount, err := s.emailRepo.TrashBatch(ctx, emailIDs, deletedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to move thread to trash: %w", err)
+	}
+
+	s.logger.Info("Thread moved to trash",
+		"thread_id", threadID,
+		"user_id", userID,
+		"trashed", trashedCount,
+	)
+
+	// Publish email_deleted events so clients drop each email of the thread
+	if s.eventBus != nil {
+		for _, e := range emails {
+			s.publishEmailDeletedEvent(userID.String(), e.ID.String(), e.AliasID.String(), deletedAt, false)
+		}
+	}
+
+	return &DeleteThreadResponse{
+		Message:       "Thread moved to trash",
+		ThreadID:      threadID.String(),
+		PurgeAt:       s.purgeAt(&deletedAt),
+		EmailsDeleted: trashedCount,
+	}, nil
+}
+
+// EmptyTrash permanently deletes every email in the user's trash
+func (s *Service) EmptyTrash(ctx context.Context, userID uuid.UUID) (*EmptyTrashResponse, error) {
+	deletedCount, totalSize, err := s.purgeTrash(ctx, userID, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Trash emptied",
+		"user_id", userID,
+		"deleted", deletedCount,
+		"size_freed", totalSize,
+	)
+
+	return &EmptyTrashResponse{
+		Message:             "Trash emptied successfully",
+		EmailsDeleted:       deletedCount,
+		TotalSizeFreedBytes: totalSize,
+	}, nil
+}
+
+// PurgeExpiredTrash permanently deletes the emails of every user trashed longer than the trash retention
+// Returns the number of emails deleted.
+func (s *Service) PurgeExpiredTrash(ctx context.Context) (int, error) {
+	deletedCount, _, err := s.purgeTrash(ctx, uuid.Nil, time.Now().UTC().Add(-s.trashRetention))
+	return deletedCount, err
+}
+
+// purgeTrash permanently deletes emails trashed at or before a time, with their stored attachments
+// userID restricts the purge to one user's trash; uuid.Nil purges the trash of every user.
+func (s *Service) purgeTrash(ctx context.Context, userID uuid.UUID, before time.Time) (int, int64, error) {
+	var deletedCount int
+	var totalSize int64
+	for {
+		trashed, err := s.emailRepo.GetTrashedEmails(ctx, userID, before, trashPurgeBatchSize)
+		if err != nil {
+			return deletedCount, totalSize, fmt.Errorf("failed to get trashed emails: %w", err)
+		}
+		if len(trashed) == 0 {
+			break
+		}
+
+		ids := make([]uuid.UUID, len(trashed))
+		for i, e := range trashed {
+			ids[i] = e.ID
+		}
+
+		count, size, err := s.deleteEmailBatch(ctx, ids)
+		if err != nil {
+			return deletedCount, totalSize, err
+		}
+		deletedCount += count
+		totalSize += size
+
+		// Publish email_deleted events so clients drop each email from the trash
+		if s.eventBus != nil {
+			deletedAt := time.Now().UTC()
+			for _, e := range trashed {
+				s.publishEmailDeletedEvent(e.UserID.String(), e.ID.String(), e.AliasID.String(), deletedAt, true)
+			}
+		}
+
+		// Stop when the last batch was short, or when nothing could be deleted
+		if len(trashed) < trashPurgeBatchSize || count == 0 {
+			break
+		}
+	}
+
+	return deletedCount, totalSize, nil
+}
+
+// purgeAt returns when an email trashed at deletedAt is permanently deleted, or nil when it is not trashed
+func (s *Service) purgeAt(deletedAt *time.Time) *time.Time {
+	if deletedAt == nil {
+		return nil
+	}
+	purgeAt := deletedAt.Add(s.trashRetention)
+	return &purgeAt
+}
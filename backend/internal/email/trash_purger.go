@@ -0,0 +1,123 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DefaultTrashPurgeInterval is how often expired trashed emails are purged
+const DefaultTrashPurgeInterval = time.Hour
+
+// TrashPurgeService permanently deletes emails trashed longer than the trash retention
+type TrashPurgeService interface {
+	PurgeExpiredTrash(ctx context.Context) (int, error)
+}
+
+// TrashPurger permanently deletes trashed emails once the trash retention passes
+// Their attachments are removed from storage the same way as a permanent delete.
+type TrashPurger struct {
+	service  TrashPurgeService
+	interval time.Duration
+	logger   *slog.Logger
+
+	mu       sync.Mutex
+	running  bool
+	cancel   context.CancelFunc
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+// TrashPurgerConfig contains configuration for the TrashPurger
+type TrashPurgerConfig struct {
+	Service  TrashPurgeService
+	Interval time.Duration // Time between purges (default: 1 hour)
+	Logger   *slog.Logger
+}
+
+// NewTrashPurger creates a new TrashPurger instance
+func NewTrashPurger(cfg TrashPurgerConfig) *TrashPurger {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultTrashPurgeInterval
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+
+	return &TrashPurger{
+		service:  cfg.Service,
+		interval: cfg.Interval,
+		logger:   cfg.Logger,
+	}
+}
+
+// Start begins purging expired trashed emails
+func (p *TrashPurger) Start() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.running {
+		return fmt.Errorf("trash purger is already running")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.running = true
+	p.cancel = cancel
+	p.stopChan = make(chan struct{})
+
+	p.wg.Add(1)
+	go p.run(ctx)
+
+	p.logger.Info("Trash purger started", "interval", p.interval)
+	return nil
+}
+
+// Stop stops the purger and waits for the current purge to finish
+func (p *TrashPurger) Stop() {
+	p.mu.Lock()
+	if !p.running {
+		p.mu.Unlock()
+		return
+	}
+	p.running = false
+	close(p.stopChan)
+	p.cancel()
+	p.mu.Unlock()
+
+	p.wg.Wait()
+	p.logger.Info("Trash purger stopped")
+}
+
+// run purges expired trashed emails on every tick until stopped
+func (p *TrashPurger) run(ctx context.Context) {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.Purge(ctx)
+
+		select {
+		case <-p.stopChan:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge permanently deletes every expired trashed email
+// Returns the number of emails deleted.
+func (p *TrashPurger) Purge(ctx context.Context) int {
+	deleted, err := p.service.PurgeExpiredTrash(ctx)
+	if err != nil && ctx.Err() == nil {
+		p.logger.Error("Failed to purge trashed emails", "deleted", deleted, "error", err)
+	}
+
+	if deleted > 0 {
+		p.logger.Info("Trashed emails purged", "count", deleted)
+	}
+	return deleted
+}
@@ -0,0 +1,103 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"pgregory.net/rapid"
+)
+
+// mockTrashPurgeService counts purges and reports each on a channel
+type mockTrashPurgeService struct {
+	deleted int
+	err     error
+	purged  chan struct{}
+}
+
+func (m *mockTrashPurgeService) PurgeExpiredTrash(ctx context.Context) (int, error) {
+	select {
+	case m.purged <- struct{}{}:
+	default:
+	}
+	return m.deleted, m.err
+}
+
+// TestService_PurgeAt verifies trashed emails are purged after the retention and others never are
+func TestService_PurgeAt(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		days := rapid.IntRange(1, 365).Draw(t, "days")
+		offset := rapid.Int64Range(0, 10*365*24*3600).Draw(t, "offset")
+
+		service := NewService(ServiceConfig{TrashRetention: time.Duration(days) * 24 * time.Hour})
+		deletedAt := time.Unix(offset, 0).UTC()
+
+		purgeAt := service.purgeAt(&deletedAt)
+		if purgeAt == nil || !purgeAt.Equal(deletedAt.AddDate(0, 0, days)) {
+			t.Fatalf("Expected purge %d days after %v, got %v", days, deletedAt, purgeAt)
+		}
+		if service.purgeAt(nil) != nil {
+			t.Fatal("Expected no purge time for an email not in the trash")
+		}
+	})
+}
+
+// TestNewService_DefaultTrashRetention verifies the trash keeps emails 30 days unless configured
+func TestNewService_DefaultTrashRetention(t *testing.T) {
+	service := NewService(ServiceConfig{})
+	if service.trashRetention != DefaultTrashRetention {
+		t.Errorf("Expected default retention %v, got %v", DefaultTrashRetention, service.trashRetention)
+	}
+}
+
+// TestBulkTrashOperations_LimitExceeded verifies trash, permanent delete and restore enforce the bulk limit
+func TestBulkTrashOperations_LimitExceeded(t *testing.T) {
+	service := NewService(ServiceConfig{})
+	ids := make([]string, MaxBulkOperationItems+1)
+	for i := range ids {
+		ids[i] = uuid.New().String()
+	}
+
+	for _, permanent := range []bool{false, true} {
+		if _, err := service.BulkDelete(context.Background(), uuid.New(), ids, permanent); !errors.Is(err, ErrBulkLimitExceeded) {
+			t.Errorf("Expected ErrBulkLimitExceeded for permanent=%v, got %v", permanent, err)
+		}
+	}
+	if _, err := service.BulkRestore(context.Background(), uuid.New(), ids); !errors.Is(err, ErrBulkLimitExceeded) {
+		t.Errorf("Expected ErrBulkLimitExceeded for restore, got %v", err)
+	}
+}
+
+// TestTrashPurger_PurgesOnStart verifies the purger runs right away and stops cleanly
+func TestTrashPurger_PurgesOnStart(t *testing.T) {
+	service := &mockTrashPurgeService{deleted: 3, purged: make(chan struct{}, 1)}
+	purger := NewTrashPurger(TrashPurgerConfig{Service: service, Interval: time.Hour})
+
+	if err := purger.Start(); err != nil {
+		t.Fatalf("Failed to start purger: %v", err)
+	}
+	if err := purger.Start(); err == nil {
+		t.Error("Expected an error starting a running purger")
+	}
+
+	select {
+	case <-service.purged:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a purge on start")
+	}
+
+	purger.Stop()
+	purger.Stop()
+}
+
+// TestTrashPurger_Purge verifies the number of purged emails is returned, even when the purge fails part way
+func TestTrashPurger_Purge(t *testing.T) {
+	service := &mockTrashPurgeService{deleted: 7, err: errors.New("storage unavailable"), purged: make(chan struct{}, 1)}
+	purger := NewTrashPurger(TrashPurgerConfig{Service: service})
+
+	if deleted := purger.Purge(context.Background()); deleted != 7 {
+		t.Errorf("Expected 7 emails purged, got %d", deleted)
+	}
+}
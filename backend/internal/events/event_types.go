@@ -1,6 +1,9 @@
 package events
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // Event type constants
 const (
@@ -12,6 +15,14 @@ const (
 	EventTypeAliasDeleted    = "alias_deleted"
 	EventTypeDomainVerified  = "domain_verified"
 	EventTypeDomainDeleted   = "domain_deleted"
+	EventTypeExportProgress  = "export_progress"
+	EventTypeImportProgress  = "import_progress"
+	EventTypeLabelCreated    = "label_created"
+	EventTypeLabelUpdated    = "label_updated"
+	EventTypeLabelDeleted    = "label_deleted"
+	EventTypeEmailsLabeled   = "emails_labeled"
+	EventTypeEmailsUpdated   = "emails_updated"
+	EventTypeEmailUnsnoozed  = "email_unsnoozed"
 	EventTypeConnectionLimit = "connection_limit"
 	EventTypeError           = "error"
 )
@@ -39,13 +50,36 @@ type NewEmailEvent struct {
 	ReceivedAt     time.Time `json:"received_at"`
 	HasAttachments bool      `json:"has_attachments"`
 	SizeBytes      int64     `json:"size_bytes"`
+	ThreadID       string    `json:"thread_id,omitempty"`
+	SavedSearchIDs []string  `json:"saved_search_ids,omitempty"` // Saved searches of the user that list the email
+
+	// One-time codes and account links found in the email
+	Codes json.RawMessage `json:"codes,omitempty"`
 }
 
-// EmailDeletedEvent is sent when an email is deleted.
+// EmailDeletedEvent is sent when an email is moved to the trash or permanently deleted.
 type EmailDeletedEvent struct {
 	ID        string    `json:"id"`
 	AliasID   string    `json:"alias_id"`
 	DeletedAt time.Time `json:"deleted_at"`
+	Permanent bool      `json:"permanent"` // false when the email can still be restored from the trash
+}
+
+// EmailsUpdatedEvent is sent when emails are starred, archived, snoozed or trashed, or when that is undone.
+type EmailsUpdatedEvent struct {
+	Action       string     `json:"action"` // "starred", "unstarred", "archived", "unarchived", "snoozed", "unsnoozed", "trashed" or "restored"
+	EmailIDs     []string   `json:"email_ids"`
+	SnoozedUntil *time.Time `json:"snoozed_until,omitempty"`
+}
+
+// EmailUnsnoozedEvent is sent when a snoozed email returns to the inbox as unread.
+type EmailUnsnoozedEvent struct {
+	ID           string    `json:"id"`
+	AliasID      string    `json:"alias_id"`
+	ThreadID     string    `json:"thread_id"`
+	FromAddress  string    `json:"from_address"`
+	Subject      *string   `json:"subject,omitempty"`
+	SnoozedUntil time.Time `json:"snoozed_until"`
 }
 
 // AliasCreatedEvent is sent when a new alias is created.
@@ -64,6 +98,51 @@ type AliasDeletedEvent struct {
 	EmailsDeleted int       `json:"emails_deleted"`
 }
 
+// LabelEvent is sent when a label is created or updated.
+type LabelEvent struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Color     string    `json:"color"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// LabelDeletedEvent is sent when a label is deleted; it no longer applies to any email.
+type LabelDeletedEvent struct {
+	ID        string    `json:"id"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// EmailsLabeledEvent is sent when labels are applied to or removed from emails.
+type EmailsLabeledEvent struct {
+	Action   string   `json:"action"` // "applied" or "removed"
+	EmailIDs []string `json:"email_ids"`
+	LabelIDs []string `json:"label_ids"`
+}
+
+// ExportProgressEvent is sent while a mailbox export runs and when it completes or fails.
+type ExportProgressEvent struct {
+	ID              string `json:"id"`
+	Format          string `json:"format"`
+	Status          string `json:"status"`
+	TotalEmails     int    `json:"total_emails"`
+	ProcessedEmails int    `json:"processed_emails"`
+	SizeBytes       int64  `json:"size_bytes"`
+	Error           string `json:"error,omitempty"`
+}
+
+// ImportProgressEvent is sent while a mailbox import runs and when it completes or fails.
+type ImportProgressEvent struct {
+	ID                string `json:"id"`
+	AliasID           string `json:"alias_id"`
+	Status            string `json:"status"`
+	TotalMessages     *int   `json:"total_messages,omitempty"`
+	ProcessedMessages int    `json:"processed_messages"`
+	ImportedMessages  int    `json:"imported_messages"`
+	DuplicateMessages int    `json:"duplicate_messages"`
+	FailedMessages    int    `json:"failed_messages"`
+	Error             string `json:"error,omitempty"`
+}
+
 // DomainVerifiedEvent is sent when a domain is verified.
 type DomainVerifiedEvent struct {
 	ID         string    `json:"id"`
@@ -0,0 +1,213 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/parser"
+)
+
+// Message is one email as written to an export archive
+type Message struct {
+	ID         uuid.UUID
+	AliasEmail string
+	Sender     string
+	IsRead     bool
+	ReceivedAt time.Time
+	Raw        []byte // Original RFC 5322 message
+}
+
+// archiveWriter writes messages to an export archive in one format
+type archiveWriter interface {
+	// WriteMessage appends a message to the archive
+	WriteMessage(msg *Message) error
+	// WriteAttachment adds a stored attachment of a message, for formats that keep them separately
+	WriteAttachment(msg *Message, filename string, r io.Reader) error
+	// Close flushes the archive; the underlying writer is not closed
+	Close() error
+}
+
+// newArchiveWriter returns the writer for a format
+func newArchiveWriter(format Format, w io.Writer) archiveWriter {
+	if format == FormatMaildir {
+		return newMaildirWriter(w)
+	}
+	return newMboxWriter(w)
+}
+
+// mboxWriter writes messages in mboxrd format
+// Each message starts with a "From " separator line, lines beginning with any number of ">"
+// followed by "From " get one more ">", and line endings are LF.
+type mboxWriter struct {
+	w *bufio.Writer
+}
+
+func newMboxWriter(w io.Writer) *mboxWriter {
+	return &mboxWriter{w: bufio.NewWriter(w)}
+}
+
+// WriteMessage appends a message with its separator line, marking read messages with a Status header
+func (m *mboxWriter) WriteMessage(msg *Message) error {
+	sender := msg.Sender
+	if sender == "" || strings.ContainsAny(sender, " \t") {
+		sender = "MAILER-DAEMON"
+	}
+	fmt.Fprintf(m.w, "From %s %s\n", sender, msg.ReceivedAt.UTC().Format(time.ANSIC))
+
+	if msg.IsRead && !hasHeader(msg.Raw, "Status") {
+		m.w.WriteString("Status: RO\n")
+	}
+
+	for _, line := range splitLines(msg.Raw) {
+		if isFromLine(line) {
+			m.w.WriteByte('>')
+		}
+		m.w.Write(line)
+		m.w.WriteByte('\n')
+	}
+	m.w.WriteByte('\n')
+	return m.w.Flush()
+}
+
+// WriteAttachment does nothing: attachments are already part of the mbox message
+func (m *mboxWriter) WriteAttachment(msg *Message, filename string, r io.Reader) error {
+	return nil
+}
+
+func (m *mboxWriter) Close() error {
+	return m.w.Flush()
+}
+
+// maildirWriter writes a zip with one Maildir per alias
+// Unread messages go to <alias>/new, read ones to <alias>/cur with the Seen flag, and stored
+// attachments to attachments/<email id>/ so they can be opened without a mail client.
+type maildirWriter struct {
+	zw      *zip.Writer
+	folders map[string]bool
+	names   map[string]int
+}
+
+func newMaildirWriter(w io.Writer) *maildirWriter {
+	return &maildirWriter{
+		zw:      zip.NewWriter(w),
+		folders: make(map[string]bool),
+		names:   make(map[string]int),
+	}
+}
+
+// WriteMessage adds a message file, creating the alias Maildir on first use
+func (m *maildirWriter) WriteMessage(msg *Message) error {
+	folder := safePathSegment(msg.AliasEmail, "mail")
+	if !m.folders[folder] {
+		m.folders[folder] = true
+		for _, dir := range []string{"cur", "new", "tmp"} {
+			if _, err := m.zw.Create(folder + "/" + dir + "/"); err != nil {
+				return err
+			}
+		}
+	}
+
+	f, err := m.zw.CreateHeader(&zip.FileHeader{
+		Name:     folder + "/" + maildirName(msg),
+		Method:   zip.Deflate,
+		Modified: msg.ReceivedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	for _, line := range splitLines(msg.Raw) {
+		w.Write(line)
+		w.WriteByte('\n')
+	}
+	return w.Flush()
+}
+
+// WriteAttachment adds an attachment file, numbering repeated filenames within a message
+func (m *maildirWriter) WriteAttachment(msg *Message, filename string, r io.Reader) error {
+	name := "attachments/" + msg.ID.String() + "/" + safePathSegment(filename, "attachment")
+	if n := m.names[name]; n > 0 {
+		m.names[name] = n + 1
+		ext := path.Ext(name)
+		name = fmt.Sprintf("%s-%d%s", strings.TrimSuffix(name, ext), n+1, ext)
+	} else {
+		m.names[name] = 1
+	}
+
+	f, err := m.zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: msg.ReceivedAt,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	return err
+}
+
+func (m *maildirWriter) Close() error {
+	return m.zw.Close()
+}
+
+// maildirName returns a message's path within its Maildir
+// Names are unique per email and sort by delivery time, as Maildir names conventionally do.
+func maildirName(msg *Message) string {
+	base := fmt.Sprintf("%d.%s.export", msg.ReceivedAt.Unix(), strings.ReplaceAll(msg.ID.String(), "-", ""))
+	if msg.IsRead {
+		return "cur/" + base + ":2,S"
+	}
+	return "new/" + base
+}
+
+// splitLines splits a message into lines without their CRLF or LF endings
+func splitLines(raw []byte) [][]byte {
+	raw = bytes.TrimSuffix(bytes.TrimSuffix(raw, []byte("\n")), []byte("\r"))
+	if len(raw) == 0 {
+		return nil
+	}
+	lines := bytes.Split(raw, []byte("\n"))
+	for i, line := range lines {
+		lines[i] = bytes.TrimSuffix(line, []byte("\r"))
+	}
+	return lines
+}
+
+// isFromLine reports whether a line must be quoted in mboxrd: "From " after zero or more ">"
+func isFromLine(line []byte) bool {
+	return bytes.HasPrefix(bytes.TrimLeft(line, ">"), []byte("From "))
+}
+
+// hasHeader reports whether the header section of a message has a field with the given name
+func hasHeader(raw []byte, name string) bool {
+	prefix := strings.ToLower(name) + ":"
+	for _, line := range splitLines(parser.HeaderBlock(raw)) {
+		if strings.HasPrefix(strings.ToLower(string(line)), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// safePathSegment makes a name usable as one path segment in a zip, falling back when nothing is left
+func safePathSegment(name, fallback string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.Trim(name, ". ")
+	if name == "" {
+		return fallback
+	}
+	return name
+}
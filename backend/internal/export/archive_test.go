@@ -0,0 +1,151 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func testMessage(raw string, isRead bool) *Message {
+	return &Message{
+		ID:         uuid.MustParse("6f1c2a9e-3b4d-4c5e-8f70-112233445566"),
+		AliasEmail: "inbox@example.com",
+		Sender:     "alice@example.org",
+		IsRead:     isRead,
+		ReceivedAt: time.Date(2024, 3, 5, 9, 7, 1, 0, time.UTC),
+		Raw:        []byte(raw),
+	}
+}
+
+func TestMboxWriter_QuotesFromLines(t *testing.T) {
+	var buf bytes.Buffer
+	w := newMboxWriter(&buf)
+
+	raw := "Subject: Hi\r\n\r\nFrom here on\r\n>From quoted\r\nFromage\r\n"
+	if err := w.WriteMessage(testMessage(raw, false)); err != nil {
+		t.Fatalf("WriteMessage() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	want := "From alice@example.org Tue Mar  5 09:07:01 2024\n" +
+		"Subject: Hi\n" +
+		"\n" +
+		">From here on\n" +
+		">>From quoted\n" +
+		"Fromage\n" +
+		"\n"
+	if got := buf.String(); got != want {
+		t.Errorf("mbox = %q, want %q", got, want)
+	}
+}
+
+func TestMboxWriter_MarksReadMessages(t *testing.T) {
+	var buf bytes.Buffer
+	w := newMboxWriter(&buf)
+
+	w.WriteMessage(testMessage("Subject: One\n\nBody\n", true))
+	w.WriteMessage(testMessage("Status: O\nSubject: Two\n\nBody\n", true))
+	w.Close()
+
+	if got := strings.Count(buf.String(), "Status:"); got != 2 {
+		t.Errorf("Status headers = %d, want 2 (one added, one kept)\n%s", got, buf.String())
+	}
+	if !strings.Contains(buf.String(), "Status: RO\nSubject: One") {
+		t.Errorf("read message without Status header was not marked:\n%s", buf.String())
+	}
+}
+
+func TestMboxWriter_UnusableSender(t *testing.T) {
+	var buf bytes.Buffer
+	w := newMboxWriter(&buf)
+
+	msg := testMessage("Subject: Hi\n\nBody\n", false)
+	msg.Sender = ""
+	w.WriteMessage(msg)
+
+	if !strings.HasPrefix(buf.String(), "From MAILER-DAEMON ") {
+		t.Errorf("separator = %q, want MAILER-DAEMON sender", strings.SplitN(buf.String(), "\n", 2)[0])
+	}
+}
+
+func TestMaildirWriter_Layout(t *testing.T) {
+	var buf bytes.Buffer
+	w := newMaildirWriter(&buf)
+
+	unread := testMessage("Subject: New\r\n\r\nBody\r\n", false)
+	read := testMessage("Subject: Seen\r\n\r\nBody\r\n", true)
+	read.ID = uuid.MustParse("00000000-0000-4000-8000-000000000001")
+	read.AliasEmail = "other/alias@example.com"
+
+	if err := w.WriteMessage(unread); err != nil {
+		t.Fatalf("WriteMessage() error = %v", err)
+	}
+	if err := w.WriteMessage(read); err != nil {
+		t.Fatalf("WriteMessage() error = %v", err)
+	}
+	if err := w.WriteAttachment(read, "report.pdf", strings.NewReader("pdf")); err != nil {
+		t.Fatalf("WriteAttachment() error = %v", err)
+	}
+	if err := w.WriteAttachment(read, "report.pdf", strings.NewReader("pdf2")); err != nil {
+		t.Fatalf("WriteAttachment() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("zip.NewReader() error = %v", err)
+	}
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(data)
+	}
+
+	for name, content := range map[string]string{
+		"inbox@example.com/cur/": "",
+		"inbox@example.com/new/": "",
+		"inbox@example.com/tmp/": "",
+		"inbox@example.com/new/1709629621.6f1c2a9e3b4d4c5e8f70112233445566.export":           "Subject: New\n\nBody\n",
+		"other_alias@example.com/cur/1709629621.00000000000040008000000000000001.export:2,S": "Subject: Seen\n\nBody\n",
+		"attachments/00000000-0000-4000-8000-000000000001/report.pdf":                        "pdf",
+		"attachments/00000000-0000-4000-8000-000000000001/report-2.pdf":                      "pdf2",
+	} {
+		got, ok := files[name]
+		if !ok {
+			t.Errorf("missing %s in archive", name)
+			continue
+		}
+		if got != content {
+			t.Errorf("%s = %q, want %q", name, got, content)
+		}
+	}
+}
+
+func TestSafePathSegment(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"inbox@example.com", "inbox@example.com"},
+		{"../secret", "_secret"},
+		{"a/b\\c:d", "a_b_c_d"},
+		{"..", "fallback"},
+		{"", "fallback"},
+	}
+	for _, tt := range tests {
+		if got := safePathSegment(tt.name, "fallback"); got != tt.want {
+			t.Errorf("safePathSegment(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
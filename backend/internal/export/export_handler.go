@@ -0,0 +1,210 @@
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	appctx "github.com/welldanyogia/persistent-temp-mail/backend/internal/context"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/email"
+)
+
+// APIResponse represents the standard API response format
+type APIResponse struct {
+	Success   bool        `json:"success"`
+	Data      interface{} `json:"data,omitempty"`
+	Error     *APIError   `json:"error,omitempty"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// APIError represents the error detail in API response
+type APIError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Handler handles HTTP requests for export endpoints
+type Handler struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+// NewHandler creates a new Handler instance
+func NewHandler(service *Service, logger *slog.Logger) *Handler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Create handles POST /api/v1/exports
+// The export is built in the background; progress is sent as export_progress SSE events.
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req CreateExportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "Invalid request body")
+		return
+	}
+
+	response, err := h.service.Create(r.Context(), userID, req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusAccepted, response)
+}
+
+// List handles GET /api/v1/exports
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.service.List(r.Context(), userID)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, response)
+}
+
+// Get handles GET /api/v1/exports/:id
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, jobID, ok := h.parseIDs(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.service.Get(r.Context(), userID, jobID)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, response)
+}
+
+// Download handles GET /api/v1/exports/:id/download
+func (h *Handler) Download(w http.ResponseWriter, r *http.Request) {
+	userID, jobID, ok := h.parseIDs(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.service.GetDownload(r.Context(), userID, jobID)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, response)
+}
+
+// parseUserID extracts the authenticated user ID
+func (h *Handler) parseUserID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	userIDStr, ok := appctx.ExtractUserID(r.Context())
+	if !ok {
+		h.writeError(w, http.StatusUnauthorized, CodeAuthTokenInvalid, "Invalid or expired token")
+		return uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		h.writeError(w, http.StatusUnauthorized, CodeAuthTokenInvalid, "Invalid user ID")
+		return uuid.Nil, false
+	}
+
+	return userID, true
+}
+
+// parseIDs extracts the authenticated user ID and the export ID path parameter
+func (h *Handler) parseIDs(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	userID, ok := h.parseUserID(w, r)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	jobID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "Invalid export ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, jobID, true
+}
+
+// handleError maps service errors to HTTP responses
+func (h *Handler) handleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrExportNotFound):
+		h.writeError(w, http.StatusNotFound, CodeExportNotFound, "Export not found")
+	case errors.Is(err, ErrExportInProgress):
+		h.writeError(w, http.StatusConflict, CodeExportInProgress, "An export is already in progress")
+	case errors.Is(err, ErrExportNotReady):
+		h.writeError(w, http.StatusConflict, CodeExportNotReady, "Export is not completed")
+	case errors.Is(err, ErrExportExpired):
+		h.writeError(w, http.StatusGone, CodeExportExpired, "Export has expired")
+	case errors.Is(err, ErrInvalidFormat):
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "format must be mbox or maildir")
+	case errors.Is(err, ErrInvalidDateRange):
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "to_date must not be before from_date")
+	case errors.Is(err, email.ErrInvalidSearch):
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, err.Error())
+	case errors.Is(err, ErrAliasNotFound):
+		h.writeError(w, http.StatusNotFound, CodeAliasNotFound, "Alias not found")
+	case errors.Is(err, ErrAccessDenied):
+		h.writeError(w, http.StatusForbidden, CodeAccessDenied, "You don't have access to this alias")
+	case errors.Is(err, ErrStorageUnavailable):
+		h.writeError(w, http.StatusServiceUnavailable, CodeStorageUnavailable, "Exports are not available")
+	default:
+		h.logger.Error("Unexpected export error", "error", err)
+		h.writeError(w, http.StatusInternalServerError, CodeInternalError, "An unexpected error occurred")
+	}
+}
+
+// writeSuccess writes a successful JSON response
+func (h *Handler) writeSuccess(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := APIResponse{
+		Success:   true,
+		Data:      data,
+		Timestamp: time.Now().UTC(),
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// writeError writes an error JSON response
+func (h *Handler) writeError(w http.ResponseWriter, statusCode int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := APIResponse{
+		Success: false,
+		Error: &APIError{
+			Code:    code,
+			Message: message,
+		},
+		Timestamp: time.Now().UTC(),
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,458 @@
+// Package export provides asynchronous mailbox exports to object storage
+// Feature: mailbox-export
+// Requirements: mbox or Maildir zip archives filtered by alias, date range or search,
+// downloaded through a pre-signed URL, with progress reported over SSE
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/email"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/events"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/jobqueue"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/repository"
+)
+
+const (
+	// DefaultWorkers is the default number of exports built at the same time
+	DefaultWorkers = 2
+	// DefaultRetention is how long a finished archive stays downloadable by default
+	DefaultRetention = 7 * 24 * time.Hour
+	// DefaultURLExpiry is the default lifetime of a download URL
+	DefaultURLExpiry = time.Hour
+	// DefaultPollInterval is how often workers look for queued exports and expired archives
+	DefaultPollInterval = 30 * time.Second
+	// MaxListedJobs is the number of most recent exports listed
+	MaxListedJobs = 50
+	// staleAfter is how long a running export may go without progress before it is requeued
+	staleAfter = 10 * time.Minute
+	// progressInterval is the minimum time between progress updates of a running export
+	progressInterval = time.Second
+	// pageSize is the number of emails read per listing page while exporting
+	pageSize = 100
+)
+
+// Format is an export archive format
+type Format string
+
+// Export formats
+const (
+	FormatMbox    Format = "mbox"    // One mboxrd file
+	FormatMaildir Format = "maildir" // Zip with one Maildir per alias, plus stored attachments
+)
+
+// Status is the state of an export job
+type Status string
+
+// Export job statuses
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+	StatusExpired   Status = "expired" // The archive was deleted after the retention period
+)
+
+// Service errors
+var (
+	ErrExportNotFound     = errors.New("export not found")
+	ErrExportInProgress   = errors.New("an export is already in progress")
+	ErrExportNotReady     = errors.New("export is not completed")
+	ErrExportExpired      = errors.New("export has expired")
+	ErrInvalidFormat      = errors.New("invalid export format")
+	ErrInvalidDateRange   = errors.New("invalid date range")
+	ErrAliasNotFound      = errors.New("alias not found")
+	ErrAccessDenied       = errors.New("access denied")
+	ErrStorageUnavailable = errors.New("storage is not configured")
+)
+
+// Error codes for API responses
+const (
+	CodeValidationError    = "VALIDATION_ERROR"
+	CodeExportNotFound     = "EXPORT_NOT_FOUND"
+	CodeExportInProgress   = "EXPORT_IN_PROGRESS"
+	CodeExportNotReady     = "EXPORT_NOT_READY"
+	CodeExportExpired      = "EXPORT_EXPIRED"
+	CodeAliasNotFound      = "ALIAS_NOT_FOUND"
+	CodeAccessDenied       = "RESOURCE_ACCESS_DENIED"
+	CodeStorageUnavailable = "STORAGE_UNAVAILABLE"
+	CodeInternalError      = "INTERNAL_ERROR"
+	CodeAuthTokenInvalid   = "AUTH_TOKEN_INVALID"
+)
+
+// Job is a stored export job
+type Job struct {
+	ID              uuid.UUID
+	UserID          uuid.UUID
+	Format          Format
+	Status          Status
+	AliasID         *uuid.UUID
+	FromDate        *time.Time
+	ToDate          *time.Time
+	Search          string
+	TotalEmails     int
+	ProcessedEmails int
+	SizeBytes       int64
+	StorageKey      string
+	Error           string
+	CreatedAt       time.Time
+	StartedAt       *time.Time
+	CompletedAt     *time.Time
+	ExpiresAt       *time.Time
+}
+
+// CreateExportRequest represents the request to start an export
+type CreateExportRequest struct {
+	Format   Format     `json:"format"`             // mbox (default) or maildir
+	AliasID  *string    `json:"alias_id,omitempty"` // Only emails of this alias
+	FromDate *time.Time `json:"from_date,omitempty"`
+	ToDate   *time.Time `json:"to_date,omitempty"`
+	Search   string     `json:"search,omitempty"` // Query in the email search language
+}
+
+// JobResponse represents an export job in API responses
+type JobResponse struct {
+	ID              string     `json:"id"`
+	Format          Format     `json:"format"`
+	Status          Status     `json:"status"`
+	AliasID         *string    `json:"alias_id,omitempty"`
+	FromDate        *time.Time `json:"from_date,omitempty"`
+	ToDate          *time.Time `json:"to_date,omitempty"`
+	Search          string     `json:"search,omitempty"`
+	TotalEmails     int        `json:"total_emails"`
+	ProcessedEmails int        `json:"processed_emails"`
+	SizeBytes       int64      `json:"size_bytes"`
+	Error           string     `json:"error,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	StartedAt       *time.Time `json:"started_at,omitempty"`
+	CompletedAt     *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt       *time.Time `json:"expires_at,omitempty"`
+}
+
+// JobListResponse represents the most recent export jobs of a user
+type JobListResponse struct {
+	Exports []JobResponse `json:"exports"`
+}
+
+// DownloadResponse represents a pre-signed download URL for a finished export
+type DownloadResponse struct {
+	DownloadURL string `json:"download_url"`
+	ExpiresIn   int    `json:"expires_in"` // Expiration time in seconds
+	Filename    string `json:"filename"`
+	SizeBytes   int64  `json:"size_bytes"`
+}
+
+// Repository defines export job data access
+type Repository interface {
+	// Create stores a pending job, or returns ErrExportInProgress when the user has one pending or running
+	Create(ctx context.Context, job *Job) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Job, error)
+	ListByUser(ctx context.Context, userID uuid.UUID, limit int) ([]Job, error)
+	// ClaimNext marks the oldest pending job running and returns it, or nil when there is none
+	ClaimNext(ctx context.Context) (*Job, error)
+	UpdateProgress(ctx context.Context, id uuid.UUID, total, processed int) error
+	Complete(ctx context.Context, id uuid.UUID, storageKey string, processed int, sizeBytes int64, expiresAt time.Time) error
+	Fail(ctx context.Context, id uuid.UUID, message string) error
+	// RequeueStale returns running jobs without progress since before to pending
+	RequeueStale(ctx context.Context, before time.Time) (int, error)
+	ListExpired(ctx context.Context, now time.Time) ([]Job, error)
+	MarkExpired(ctx context.Context, id uuid.UUID) error
+}
+
+// EmailSource defines the email access used to build archives
+type EmailSource interface {
+	List(ctx context.Context, userID uuid.UUID, params repository.ListEmailParams) ([]repository.EmailWithPreview, int, repository.PageCursors, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*repository.Email, error)
+}
+
+// AttachmentSource defines the attachment lookup used for Maildir archives
+type AttachmentSource interface {
+	GetActiveAttachmentsByEmailID(ctx context.Context, emailID uuid.UUID) ([]*repository.Attachment, error)
+}
+
+// AliasRepository defines the alias lookup used for ownership checks
+type AliasRepository interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*repository.AliasWithStats, error)
+}
+
+// ObjectStore defines the object storage operations used for archives and attachments
+type ObjectStore interface {
+	UploadStream(ctx context.Context, key, contentType string, r io.Reader) (int64, error)
+	GetObject(ctx context.Context, key string) (io.ReadCloser, error)
+	DeleteObject(ctx context.Context, key string) error
+	GetPresignedURLWithExpiry(ctx context.Context, key string, expiry time.Duration) (string, time.Duration, error)
+}
+
+// Service handles export jobs and runs the workers that build them
+type Service struct {
+	repo           Repository
+	emailRepo      EmailSource
+	attachmentRepo AttachmentSource
+	aliasRepo      AliasRepository
+	storage        ObjectStore
+	eventBus       events.EventBus
+	workers        int
+	retention      time.Duration
+	urlExpiry      time.Duration
+	logger         *slog.Logger
+
+	runner *jobqueue.Runner[Job]
+}
+
+// ServiceConfig holds configuration for the export service
+type ServiceConfig struct {
+	Repository     Repository
+	EmailRepo      EmailSource
+	AttachmentRepo AttachmentSource
+	AliasRepo      AliasRepository
+	Storage        ObjectStore // nil disables exports
+	EventBus       events.EventBus
+	Workers        int
+	Retention      time.Duration
+	URLExpiry      time.Duration
+	PollInterval   time.Duration
+	Logger         *slog.Logger
+}
+
+// NewService creates a new export service
+func NewService(cfg ServiceConfig) *Service {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = DefaultWorkers
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = DefaultRetention
+	}
+	if cfg.URLExpiry <= 0 {
+		cfg.URLExpiry = DefaultURLExpiry
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = DefaultPollInterval
+	}
+	s := &Service{
+		repo:           cfg.Repository,
+		emailRepo:      cfg.EmailRepo,
+		attachmentRepo: cfg.AttachmentRepo,
+		aliasRepo:      cfg.AliasRepo,
+		storage:        cfg.Storage,
+		eventBus:       cfg.EventBus,
+		workers:        cfg.Workers,
+		retention:      cfg.Retention,
+		urlExpiry:      cfg.URLExpiry,
+		logger:         logger,
+	}
+	s.runner = jobqueue.NewRunner(jobqueue.Config[Job]{
+		Name:         "export",
+		Queue:        cfg.Repository,
+		Run:          s.runJob,
+		Sweep:        s.expireArchives,
+		Workers:      cfg.Workers,
+		PollInterval: cfg.PollInterval,
+		StaleAfter:   staleAfter,
+		Logger:       logger,
+	})
+	return s
+}
+
+// Create validates a request and queues an export job for the user
+func (s *Service) Create(ctx context.Context, userID uuid.UUID, req CreateExportRequest) (*JobResponse, error) {
+	if s.storage == nil {
+		return nil, ErrStorageUnavailable
+	}
+
+	job, err := s.newJob(ctx, userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, job); err != nil {
+		if errors.Is(err, ErrExportInProgress) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to create export: %w", err)
+	}
+
+	s.runner.Wake()
+
+	response := toJobResponse(job)
+	return &response, nil
+}
+
+// newJob validates a request and returns the pending job it describes
+func (s *Service) newJob(ctx context.Context, userID uuid.UUID, req CreateExportRequest) (*Job, error) {
+	job := &Job{
+		ID:       uuid.New(),
+		UserID:   userID,
+		Format:   req.Format,
+		Status:   StatusPending,
+		Search:   strings.TrimSpace(req.Search),
+		FromDate: req.FromDate,
+		ToDate:   req.ToDate,
+	}
+
+	switch job.Format {
+	case "":
+		job.Format = FormatMbox
+	case FormatMbox, FormatMaildir:
+	default:
+		return nil, ErrInvalidFormat
+	}
+
+	if job.FromDate != nil && job.ToDate != nil && job.ToDate.Before(*job.FromDate) {
+		return nil, ErrInvalidDateRange
+	}
+
+	if len(job.Search) > email.MaxSearchLength {
+		return nil, fmt.Errorf("%w: query is longer than %d characters", email.ErrInvalidSearch, email.MaxSearchLength)
+	}
+	if _, err := email.ParseSearch(job.Search); err != nil {
+		return nil, err
+	}
+
+	if req.AliasID != nil && *req.AliasID != "" {
+		aliasID, err := uuid.Parse(*req.AliasID)
+		if err != nil {
+			return nil, ErrAliasNotFound
+		}
+		alias, err := s.aliasRepo.GetByID(ctx, aliasID)
+		if err != nil {
+			if errors.Is(err, repository.ErrAliasNotFound) {
+				return nil, ErrAliasNotFound
+			}
+			return nil, fmt.Errorf("failed to get alias: %w", err)
+		}
+		if alias.UserID != userID {
+			return nil, ErrAccessDenied
+		}
+		job.AliasID = &aliasID
+	}
+
+	return job, nil
+}
+
+// Get returns an export job of the user
+func (s *Service) Get(ctx context.Context, userID, jobID uuid.UUID) (*JobResponse, error) {
+	job, err := s.getOwnedJob(ctx, userID, jobID)
+	if err != nil {
+		return nil, err
+	}
+	response := toJobResponse(job)
+	return &response, nil
+}
+
+// List returns the user's most recent export jobs, newest first
+func (s *Service) List(ctx context.Context, userID uuid.UUID) (*JobListResponse, error) {
+	jobs, err := s.repo.ListByUser(ctx, userID, MaxListedJobs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list exports: %w", err)
+	}
+
+	response := &JobListResponse{Exports: make([]JobResponse, len(jobs))}
+	for i := range jobs {
+		response.Exports[i] = toJobResponse(&jobs[i])
+	}
+	return response, nil
+}
+
+// GetDownload returns a pre-signed URL for the archive of a completed export
+func (s *Service) GetDownload(ctx context.Context, userID, jobID uuid.UUID) (*DownloadResponse, error) {
+	job, err := s.getOwnedJob(ctx, userID, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch job.Status {
+	case StatusCompleted:
+	case StatusExpired:
+		return nil, ErrExportExpired
+	default:
+		return nil, ErrExportNotReady
+	}
+	if s.storage == nil {
+		return nil, ErrStorageUnavailable
+	}
+
+	url, expiry, err := s.storage.GetPresignedURLWithExpiry(ctx, job.StorageKey, s.urlExpiry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate download URL: %w", err)
+	}
+
+	return &DownloadResponse{
+		DownloadURL: url,
+		ExpiresIn:   int(expiry.Seconds()),
+		Filename:    archiveFilename(job),
+		SizeBytes:   job.SizeBytes,
+	}, nil
+}
+
+// getOwnedJob returns a job, hiding jobs of other users as not found
+func (s *Service) getOwnedJob(ctx context.Context, userID, jobID uuid.UUID) (*Job, error) {
+	job, err := s.repo.GetByID(ctx, jobID)
+	if err != nil {
+		if errors.Is(err, ErrExportNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get export: %w", err)
+	}
+	if job.UserID != userID {
+		return nil, ErrExportNotFound
+	}
+	return job, nil
+}
+
+// storageKey returns where the archive of a job is stored
+func storageKey(job *Job) string {
+	return fmt.Sprintf("exports/%s/%s", job.UserID, archiveFilename(job))
+}
+
+// archiveFilename returns the download filename of a job's archive
+func archiveFilename(job *Job) string {
+	name := fmt.Sprintf("mail-export-%s-%s", job.CreatedAt.UTC().Format("20060102"), job.ID.String()[:8])
+	if job.Format == FormatMaildir {
+		return name + ".zip"
+	}
+	return name + ".mbox"
+}
+
+// archiveContentType returns the content type of a format's archive
+func archiveContentType(format Format) string {
+	if format == FormatMaildir {
+		return "application/zip"
+	}
+	return "application/mbox"
+}
+
+// toJobResponse converts a job to its API representation
+func toJobResponse(job *Job) JobResponse {
+	response := JobResponse{
+		ID:              job.ID.String(),
+		Format:          job.Format,
+		Status:          job.Status,
+		FromDate:        job.FromDate,
+		ToDate:          job.ToDate,
+		Search:          job.Search,
+		TotalEmails:     job.TotalEmails,
+		ProcessedEmails: job.ProcessedEmails,
+		SizeBytes:       job.SizeBytes,
+		Error:           job.Error,
+		CreatedAt:       job.CreatedAt,
+		StartedAt:       job.StartedAt,
+		CompletedAt:     job.CompletedAt,
+		ExpiresAt:       job.ExpiresAt,
+	}
+	if job.AliasID != nil {
+		aliasID := job.AliasID.String()
+		response.AliasID = &aliasID
+	}
+	return response
+}
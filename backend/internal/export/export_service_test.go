@@ -0,0 +1,399 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/email"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/events"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/repository"
+)
+
+// mockRepository keeps jobs in memory; it implements only the methods the tests reach
+type mockRepository struct {
+	Repository
+	jobs map[uuid.UUID]*Job
+}
+
+func newMockRepository() *mockRepository {
+	return &mockRepository{jobs: make(map[uuid.UUID]*Job)}
+}
+
+func (m *mockRepository) Create(ctx context.Context, job *Job) error {
+	for _, j := range m.jobs {
+		if j.UserID == job.UserID && (j.Status == StatusPending || j.Status == StatusRunning) {
+			return ErrExportInProgress
+		}
+	}
+	job.CreatedAt = time.Now().UTC()
+	copied := *job
+	m.jobs[job.ID] = &copied
+	return nil
+}
+
+func (m *mockRepository) GetByID(ctx context.Context, id uuid.UUID) (*Job, error) {
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, ErrExportNotFound
+	}
+	copied := *job
+	return &copied, nil
+}
+
+func (m *mockRepository) ClaimNext(ctx context.Context) (*Job, error) {
+	for _, j := range m.jobs {
+		if j.Status == StatusPending {
+			j.Status = StatusRunning
+			copied := *j
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockRepository) Complete(ctx context.Context, id uuid.UUID, storageKey string, processed int, sizeBytes int64, expiresAt time.Time) error {
+	job := m.jobs[id]
+	job.Status = StatusCompleted
+	job.StorageKey = storageKey
+	job.ProcessedEmails = processed
+	job.SizeBytes = sizeBytes
+	job.ExpiresAt = &expiresAt
+	return nil
+}
+
+func (m *mockRepository) RequeueStale(ctx context.Context, before time.Time) (int, error) {
+	return 0, nil
+}
+
+func (m *mockRepository) ListExpired(ctx context.Context, now time.Time) ([]Job, error) {
+	var jobs []Job
+	for _, j := range m.jobs {
+		if j.Status == StatusCompleted && j.ExpiresAt != nil && !j.ExpiresAt.After(now) {
+			jobs = append(jobs, *j)
+		}
+	}
+	return jobs, nil
+}
+
+func (m *mockRepository) MarkExpired(ctx context.Context, id uuid.UUID) error {
+	m.jobs[id].Status = StatusExpired
+	return nil
+}
+
+// mockEmailSource serves emails two per page through cursors
+type mockEmailSource struct {
+	emails []*repository.Email
+	alias  string
+}
+
+func (m *mockEmailSource) List(ctx context.Context, userID uuid.UUID, params repository.ListEmailParams) ([]repository.EmailWithPreview, int, repository.PageCursors, error) {
+	start := 0
+	if params.Cursor != nil {
+		for i, e := range m.emails {
+			if e.ID == params.Cursor.ID {
+				start = i + 1
+			}
+		}
+	}
+	end := min(start+2, len(m.emails))
+
+	var page []repository.EmailWithPreview
+	for _, e := range m.emails[start:end] {
+		page = append(page, repository.EmailWithPreview{ID: e.ID, AliasID: e.AliasID, AliasEmail: m.alias, ReceivedAt: e.ReceivedAt, HasAttachments: true})
+	}
+	var cursors repository.PageCursors
+	if end < len(m.emails) {
+		cursors.Next = repository.Cursor{Sort: "received_at:asc", Values: []string{"x"}, ID: m.emails[end-1].ID}.Encode()
+	}
+	return page, len(m.emails), cursors, nil
+}
+
+func (m *mockEmailSource) GetByID(ctx context.Context, id uuid.UUID) (*repository.Email, error) {
+	for _, e := range m.emails {
+		if e.ID == id {
+			return e, nil
+		}
+	}
+	return nil, repository.ErrEmailNotFound
+}
+
+// mockAttachmentSource gives every email one stored attachment
+type mockAttachmentSource struct{}
+
+func (m *mockAttachmentSource) GetActiveAttachmentsByEmailID(ctx context.Context, emailID uuid.UUID) ([]*repository.Attachment, error) {
+	return []*repository.Attachment{{ID: uuid.New(), EmailID: emailID, Filename: "file.txt", StorageKey: "attachments/" + emailID.String()}}, nil
+}
+
+// mockAliasRepository implements AliasRepository for testing
+type mockAliasRepository struct {
+	aliases map[uuid.UUID]uuid.UUID // alias ID to owner ID
+}
+
+func (m *mockAliasRepository) GetByID(ctx context.Context, id uuid.UUID) (*repository.AliasWithStats, error) {
+	owner, ok := m.aliases[id]
+	if !ok {
+		return nil, repository.ErrAliasNotFound
+	}
+	return &repository.AliasWithStats{Alias: repository.Alias{ID: id, UserID: owner}}, nil
+}
+
+// mockStore keeps uploaded objects in memory
+type mockStore struct {
+	objects map[string][]byte
+}
+
+func (m *mockStore) UploadStream(ctx context.Context, key, contentType string, r io.Reader) (int64, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+	m.objects[key] = data
+	return int64(len(data)), nil
+}
+
+func (m *mockStore) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	if strings.HasPrefix(key, "attachments/") {
+		return io.NopCloser(strings.NewReader("attachment data")), nil
+	}
+	return nil, errors.New("not found")
+}
+
+func (m *mockStore) DeleteObject(ctx context.Context, key string) error {
+	delete(m.objects, key)
+	return nil
+}
+
+func (m *mockStore) GetPresignedURLWithExpiry(ctx context.Context, key string, expiry time.Duration) (string, time.Duration, error) {
+	return "https://storage.example.com/" + key, expiry, nil
+}
+
+// mockEventBus records published events
+type mockEventBus struct {
+	events.EventBus
+	events []events.Event
+}
+
+func (m *mockEventBus) Publish(event events.Event) error {
+	m.events = append(m.events, event)
+	return nil
+}
+
+type testEnv struct {
+	service *Service
+	repo    *mockRepository
+	store   *mockStore
+	bus     *mockEventBus
+	userID  uuid.UUID
+	aliasID uuid.UUID
+}
+
+func newTestEnv(emails int) *testEnv {
+	env := &testEnv{
+		repo:    newMockRepository(),
+		store:   &mockStore{objects: make(map[string][]byte)},
+		bus:     &mockEventBus{},
+		userID:  uuid.New(),
+		aliasID: uuid.New(),
+	}
+
+	source := &mockEmailSource{alias: "inbox@example.com"}
+	received := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < emails; i++ {
+		source.emails = append(source.emails, &repository.Email{
+			ID:            uuid.New(),
+			AliasID:       env.aliasID,
+			SenderAddress: "sender@example.org",
+			IsRead:        i%2 == 0,
+			ReceivedAt:    received.Add(time.Duration(i) * time.Hour),
+			RawEmail:      []byte("Subject: Test\r\n\r\nFrom the body\r\n"),
+		})
+	}
+
+	env.service = NewService(ServiceConfig{
+		Repository:     env.repo,
+		EmailRepo:      source,
+		AttachmentRepo: &mockAttachmentSource{},
+		AliasRepo:      &mockAliasRepository{aliases: map[uuid.UUID]uuid.UUID{env.aliasID: env.userID}},
+		Storage:        env.store,
+		EventBus:       env.bus,
+	})
+	return env
+}
+
+// runNext claims and builds the next queued job like a worker would
+func (env *testEnv) runNext(t *testing.T) {
+	t.Helper()
+	job, err := env.repo.ClaimNext(context.Background())
+	if err != nil || job == nil {
+		t.Fatalf("ClaimNext() = %v, %v", job, err)
+	}
+	env.service.runJob(context.Background(), job)
+}
+
+func TestCreate_Validation(t *testing.T) {
+	env := newTestEnv(0)
+	ctx := context.Background()
+	otherAlias := uuid.New().String()
+	badSearch := "larger:huge"
+	from := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		req     CreateExportRequest
+		wantErr error
+	}{
+		{"unknown format", CreateExportRequest{Format: "pst"}, ErrInvalidFormat},
+		{"reversed dates", CreateExportRequest{FromDate: &from, ToDate: &to}, ErrInvalidDateRange},
+		{"invalid search", CreateExportRequest{Search: badSearch}, email.ErrInvalidSearch},
+		{"unknown alias", CreateExportRequest{AliasID: &otherAlias}, ErrAliasNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := env.service.Create(ctx, env.userID, tt.req); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Create() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreate_AliasOfAnotherUser(t *testing.T) {
+	env := newTestEnv(0)
+	aliasID := env.aliasID.String()
+
+	_, err := env.service.Create(context.Background(), uuid.New(), CreateExportRequest{AliasID: &aliasID})
+	if !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("Create() error = %v, want ErrAccessDenied", err)
+	}
+}
+
+func TestCreate_OneActiveExportPerUser(t *testing.T) {
+	env := newTestEnv(0)
+	ctx := context.Background()
+
+	job, err := env.service.Create(ctx, env.userID, CreateExportRequest{})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if job.Format != FormatMbox || job.Status != StatusPending {
+		t.Errorf("Create() = %s %s, want mbox pending", job.Format, job.Status)
+	}
+
+	if _, err := env.service.Create(ctx, env.userID, CreateExportRequest{}); !errors.Is(err, ErrExportInProgress) {
+		t.Errorf("second Create() error = %v, want ErrExportInProgress", err)
+	}
+	if _, err := env.service.Create(ctx, uuid.New(), CreateExportRequest{}); err != nil {
+		t.Errorf("Create() for another user error = %v", err)
+	}
+}
+
+func TestCreate_WithoutStorage(t *testing.T) {
+	service := NewService(ServiceConfig{Repository: newMockRepository()})
+
+	if _, err := service.Create(context.Background(), uuid.New(), CreateExportRequest{}); !errors.Is(err, ErrStorageUnavailable) {
+		t.Errorf("Create() error = %v, want ErrStorageUnavailable", err)
+	}
+}
+
+func TestRunJob_Mbox(t *testing.T) {
+	env := newTestEnv(5)
+	ctx := context.Background()
+
+	created, err := env.service.Create(ctx, env.userID, CreateExportRequest{Format: FormatMbox})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	env.runNext(t)
+
+	jobID := uuid.MustParse(created.ID)
+	job, _ := env.service.Get(ctx, env.userID, jobID)
+	if job.Status != StatusCompleted || job.ProcessedEmails != 5 || job.ExpiresAt == nil {
+		t.Fatalf("job = %+v, want completed with 5 emails", job)
+	}
+
+	stored := env.repo.jobs[jobID]
+	data := env.store.objects[stored.StorageKey]
+	if got := bytes.Count(data, []byte("\nFrom sender@example.org ")) + 1; got != 5 {
+		t.Errorf("mbox has %d messages, want 5", got)
+	}
+	if got := bytes.Count(data, []byte("\n>From the body\n")); got != 5 {
+		t.Errorf("mbox has %d quoted body lines, want 5", got)
+	}
+	if job.SizeBytes != int64(len(data)) {
+		t.Errorf("SizeBytes = %d, want %d", job.SizeBytes, len(data))
+	}
+
+	download, err := env.service.GetDownload(ctx, env.userID, jobID)
+	if err != nil {
+		t.Fatalf("GetDownload() error = %v", err)
+	}
+	if !strings.HasSuffix(download.DownloadURL, stored.StorageKey) || download.ExpiresIn != int(DefaultURLExpiry.Seconds()) {
+		t.Errorf("GetDownload() = %+v", download)
+	}
+	if !strings.HasSuffix(download.Filename, ".mbox") {
+		t.Errorf("Filename = %q, want .mbox", download.Filename)
+	}
+
+	var last events.ExportProgressEvent
+	json.Unmarshal(env.bus.events[len(env.bus.events)-1].Data, &last)
+	if last.Status != string(StatusCompleted) || last.ProcessedEmails != 5 || last.TotalEmails != 5 {
+		t.Errorf("last progress event = %+v, want completed 5/5", last)
+	}
+	if env.bus.events[0].UserID != env.userID.String() {
+		t.Errorf("event user = %s, want %s", env.bus.events[0].UserID, env.userID)
+	}
+}
+
+func TestRunJob_MaildirIncludesAttachments(t *testing.T) {
+	env := newTestEnv(3)
+	ctx := context.Background()
+
+	created, _ := env.service.Create(ctx, env.userID, CreateExportRequest{Format: FormatMaildir})
+	env.runNext(t)
+
+	stored := env.repo.jobs[uuid.MustParse(created.ID)]
+	if stored.Status != StatusCompleted {
+		t.Fatalf("status = %s, want completed", stored.Status)
+	}
+	data := env.store.objects[stored.StorageKey]
+	if got := bytes.Count(data, []byte("/file.txt")); got < 3 {
+		t.Errorf("archive names %d attachments, want 3", got)
+	}
+	if !strings.HasSuffix(stored.StorageKey, ".zip") {
+		t.Errorf("StorageKey = %q, want .zip", stored.StorageKey)
+	}
+}
+
+func TestGetDownload_States(t *testing.T) {
+	env := newTestEnv(1)
+	ctx := context.Background()
+
+	created, _ := env.service.Create(ctx, env.userID, CreateExportRequest{})
+	jobID := uuid.MustParse(created.ID)
+
+	if _, err := env.service.GetDownload(ctx, env.userID, jobID); !errors.Is(err, ErrExportNotReady) {
+		t.Errorf("pending GetDownload() error = %v, want ErrExportNotReady", err)
+	}
+	if _, err := env.service.GetDownload(ctx, uuid.New(), jobID); !errors.Is(err, ErrExportNotFound) {
+		t.Errorf("other user GetDownload() error = %v, want ErrExportNotFound", err)
+	}
+
+	env.runNext(t)
+	past := time.Now().UTC().Add(-time.Minute)
+	env.repo.jobs[jobID].ExpiresAt = &past
+	env.service.runner.Sweep(ctx)
+
+	if _, err := env.service.GetDownload(ctx, env.userID, jobID); !errors.Is(err, ErrExportExpired) {
+		t.Errorf("expired GetDownload() error = %v, want ErrExportExpired", err)
+	}
+	if len(env.store.objects) != 0 {
+		t.Errorf("expired archive was not deleted: %v", env.store.objects)
+	}
+}
@@ -0,0 +1,217 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/jobqueue"
+)
+
+// jobColumns are the export_jobs columns scanned by scanJob, in order
+const jobColumns = `
+	id, user_id, format, status, alias_id, from_date, to_date, COALESCE(search, ''),
+	total_emails, processed_emails, size_bytes, COALESCE(storage_key, ''), COALESCE(error, ''),
+	created_at, started_at, completed_at, expires_at
+`
+
+// PostgresRepository stores export jobs in PostgreSQL
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresRepository creates a new PostgresRepository
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{pool: pool}
+}
+
+// Create stores a pending job unless the user already has one pending or running
+// Serializing on the user row keeps two concurrent requests from both passing the check.
+func (r *PostgresRepository) Create(ctx context.Context, job *Job) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM users WHERE id = $1 FOR UPDATE`, job.UserID); err != nil {
+		return fmt.Errorf("failed to lock user: %w", err)
+	}
+
+	var active bool
+	err = tx.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM export_jobs WHERE user_id = $1 AND status IN ('pending', 'running'))
+	`, job.UserID).Scan(&active)
+	if err != nil {
+		return fmt.Errorf("failed to check active exports: %w", err)
+	}
+	if active {
+		return ErrExportInProgress
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO export_jobs (id, user_id, format, status, alias_id, from_date, to_date, search)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''))
+		RETURNING created_at
+	`, job.ID, job.UserID, string(job.Format), string(job.Status), job.AliasID, utcPtr(job.FromDate), utcPtr(job.ToDate), job.Search).Scan(&job.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert export job: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetByID returns a job
+func (r *PostgresRepository) GetByID(ctx context.Context, id uuid.UUID) (*Job, error) {
+	job, err := scanJob(r.pool.QueryRow(ctx, `SELECT `+jobColumns+` FROM export_jobs WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrExportNotFound
+		}
+		return nil, err
+	}
+	return job, nil
+}
+
+// ListByUser returns a user's most recent jobs, newest first
+func (r *PostgresRepository) ListByUser(ctx context.Context, userID uuid.UUID, limit int) ([]Job, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+jobColumns+` FROM export_jobs
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list export jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := make([]Job, 0)
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, rows.Err()
+}
+
+// ClaimNext marks the oldest pending job running and returns it, or nil when there is none
+func (r *PostgresRepository) ClaimNext(ctx context.Context) (*Job, error) {
+	job, err := scanJob(r.pool.QueryRow(ctx, jobqueue.ClaimQuery("export_jobs", "started_at = (NOW() AT TIME ZONE 'utc')", jobColumns)))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim export job: %w", err)
+	}
+	return job, nil
+}
+
+// UpdateProgress records how many emails a running job has written
+func (r *PostgresRepository) UpdateProgress(ctx context.Context, id uuid.UUID, total, processed int) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE export_jobs
+		SET total_emails = $2, processed_emails = $3, updated_at = (NOW() AT TIME ZONE 'utc')
+		WHERE id = $1 AND status = 'running'
+	`, id, total, processed)
+	return err
+}
+
+// Complete records a finished archive
+func (r *PostgresRepository) Complete(ctx context.Context, id uuid.UUID, storageKey string, processed int, sizeBytes int64, expiresAt time.Time) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE export_jobs
+		SET status = 'completed', storage_key = $2, processed_emails = $3, size_bytes = $4, expires_at = $5,
+		    error = NULL,
+		    completed_at = (NOW() AT TIME ZONE 'utc'),
+		    updated_at = (NOW() AT TIME ZONE 'utc')
+		WHERE id = $1
+	`, id, storageKey, processed, sizeBytes, expiresAt.UTC())
+	return err
+}
+
+// Fail records a job that could not be built
+func (r *PostgresRepository) Fail(ctx context.Context, id uuid.UUID, message string) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE export_jobs
+		SET status = 'failed', error = $2,
+		    completed_at = (NOW() AT TIME ZONE 'utc'),
+		    updated_at = (NOW() AT TIME ZONE 'utc')
+		WHERE id = $1
+	`, id, message)
+	return err
+}
+
+// RequeueStale returns running jobs without progress since before to pending, restarting them from scratch
+func (r *PostgresRepository) RequeueStale(ctx context.Context, before time.Time) (int, error) {
+	result, err := r.pool.Exec(ctx, jobqueue.RequeueStaleQuery("export_jobs", "processed_emails = 0"), before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return int(result.RowsAffected()), nil
+}
+
+// ListExpired returns completed jobs whose archives are past their expiry
+func (r *PostgresRepository) ListExpired(ctx context.Context, now time.Time) ([]Job, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+jobColumns+` FROM export_jobs
+		WHERE status = 'completed' AND expires_at <= $1
+		ORDER BY expires_at
+		LIMIT 100
+	`, now.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired export jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, rows.Err()
+}
+
+// MarkExpired records that a job's archive was deleted
+func (r *PostgresRepository) MarkExpired(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE export_jobs SET status = 'expired', updated_at = (NOW() AT TIME ZONE 'utc')
+		WHERE id = $1
+	`, id)
+	return err
+}
+
+// scanJob scans a row selected with jobColumns
+func scanJob(row pgx.Row) (*Job, error) {
+	var job Job
+	var format, status string
+	err := row.Scan(
+		&job.ID, &job.UserID, &format, &status, &job.AliasID, &job.FromDate, &job.ToDate, &job.Search,
+		&job.TotalEmails, &job.ProcessedEmails, &job.SizeBytes, &job.StorageKey, &job.Error,
+		&job.CreatedAt, &job.StartedAt, &job.CompletedAt, &job.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	job.Format = Format(format)
+	job.Status = Status(status)
+	return &job, nil
+}
+
+// utcPtr converts an optional time to UTC for the TIMESTAMP columns
+func utcPtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
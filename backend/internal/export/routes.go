@@ -0,0 +1,27 @@
+package export
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes registers mailbox export routes with the Chi router
+// All routes require authentication via auth middleware
+func RegisterRoutes(r chi.Router, handler *Handler, authMiddleware func(next http.Handler) http.Handler) {
+	r.Route("/exports", func(r chi.Router) {
+		r.Use(authMiddleware)
+
+		// POST /api/v1/exports - Queue an export (format, alias_id, from_date, to_date, search)
+		r.Post("/", handler.Create)
+
+		// GET /api/v1/exports - List recent exports
+		r.Get("/", handler.List)
+
+		// GET /api/v1/exports/:id - Get export status and progress
+		r.Get("/{id}", handler.Get)
+
+		// GET /api/v1/exports/:id/download - Get a pre-signed download URL for a completed export
+		r.Get("/{id}/download", handler.Download)
+	})
+}
@@ -0,0 +1,259 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/email"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/events"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/repository"
+)
+
+// Start launches the export workers and the expiry sweep
+// Jobs left running by a previous process are picked up again once they go stale.
+func (s *Service) Start() error {
+	if s.storage == nil {
+		s.logger.Info("Export workers disabled: storage is not configured")
+		return nil
+	}
+	if err := s.runner.Start(); err != nil {
+		return err
+	}
+
+	s.logger.Info("Export workers started", "workers", s.workers, "retention", s.retention)
+	return nil
+}
+
+// Stop cancels running exports and waits for the workers to exit
+// Cancelled jobs stay running in the database and are requeued once stale.
+func (s *Service) Stop() {
+	if s.runner.Stop() {
+		s.logger.Info("Export workers stopped")
+	}
+}
+
+// expireArchives deletes archives past their expiry; it runs on every sweep
+func (s *Service) expireArchives(ctx context.Context) {
+	expired, err := s.repo.ListExpired(ctx, time.Now().UTC())
+	if err != nil {
+		s.logger.Error("Failed to list expired exports", "error", err)
+		return
+	}
+	for _, job := range expired {
+		if err := s.storage.DeleteObject(ctx, job.StorageKey); err != nil {
+			s.logger.Warn("Failed to delete expired export", "export_id", job.ID, "error", err)
+			continue
+		}
+		if err := s.repo.MarkExpired(ctx, job.ID); err != nil {
+			s.logger.Warn("Failed to mark export expired", "export_id", job.ID, "error", err)
+		}
+	}
+}
+
+// runJob builds one claimed export and records the outcome
+func (s *Service) runJob(ctx context.Context, job *Job) {
+	s.logger.Info("Export started", "export_id", job.ID, "user_id", job.UserID, "format", job.Format)
+	s.publishProgress(job)
+
+	key := storageKey(job)
+	size, err := s.build(ctx, job, key)
+	if ctx.Err() != nil {
+		// Stopped: leave the job running so it is requeued
+		return
+	}
+	if err != nil {
+		s.logger.Error("Export failed", "export_id", job.ID, "error", err)
+		job.Status = StatusFailed
+		job.Error = "Export failed"
+		if failErr := s.repo.Fail(ctx, job.ID, job.Error); failErr != nil {
+			s.logger.Error("Failed to record export failure", "export_id", job.ID, "error", failErr)
+		}
+		s.publishProgress(job)
+		return
+	}
+
+	expiresAt := time.Now().UTC().Add(s.retention)
+	job.Status = StatusCompleted
+	job.SizeBytes = size
+	job.StorageKey = key
+	job.ExpiresAt = &expiresAt
+	if err := s.repo.Complete(ctx, job.ID, key, job.ProcessedEmails, size, expiresAt); err != nil {
+		s.logger.Error("Failed to record export completion", "export_id", job.ID, "error", err)
+		return
+	}
+	s.publishProgress(job)
+	s.logger.Info("Export completed", "export_id", job.ID, "emails", job.ProcessedEmails, "size_bytes", size)
+}
+
+// build streams the archive of a job to object storage under key, returning its size
+// The archive is written into a pipe that the upload reads from, so it is never held whole in memory.
+func (s *Service) build(ctx context.Context, job *Job, key string) (int64, error) {
+	search, err := email.ParseSearch(job.Search)
+	if err != nil {
+		return 0, err
+	}
+
+	pr, pw := io.Pipe()
+	type uploadResult struct {
+		size int64
+		err  error
+	}
+	uploaded := make(chan uploadResult, 1)
+	go func() {
+		size, err := s.storage.UploadStream(ctx, key, archiveContentType(job.Format), pr)
+		// Unblock the writer if the upload gave up early
+		pr.CloseWithError(err)
+		uploaded <- uploadResult{size, err}
+	}()
+
+	writeErr := s.writeArchive(ctx, job, search, newArchiveWriter(job.Format, pw))
+	pw.CloseWithError(writeErr)
+
+	result := <-uploaded
+	if writeErr != nil {
+		return 0, writeErr
+	}
+	return result.size, result.err
+}
+
+// writeArchive writes every email matching a job's filters, oldest first, updating progress as it goes
+func (s *Service) writeArchive(ctx context.Context, job *Job, search *repository.EmailSearch, w archiveWriter) error {
+	params := repository.ListEmailParams{
+		Limit:    pageSize,
+		AliasID:  job.AliasID,
+		Search:   search,
+		FromDate: job.FromDate,
+		ToDate:   job.ToDate,
+		Sort:     "received_at",
+		Order:    "asc",
+	}
+
+	job.ProcessedEmails = 0
+	lastProgress := time.Now()
+	for {
+		page, total, cursors, err := s.emailRepo.List(ctx, job.UserID, params)
+		if err != nil {
+			return fmt.Errorf("failed to list emails: %w", err)
+		}
+		if params.Cursor == nil {
+			job.TotalEmails = total
+		}
+
+		for i := range page {
+			if err := s.writeEmail(ctx, w, &page[i], job.Format); err != nil {
+				return err
+			}
+			job.ProcessedEmails++
+
+			if time.Since(lastProgress) >= progressInterval {
+				lastProgress = time.Now()
+				if err := s.repo.UpdateProgress(ctx, job.ID, job.TotalEmails, job.ProcessedEmails); err != nil {
+					s.logger.Warn("Failed to update export progress", "export_id", job.ID, "error", err)
+				}
+				s.publishProgress(job)
+			}
+		}
+
+		if cursors.Next == "" || len(page) == 0 {
+			break
+		}
+		if params.Cursor, err = repository.DecodeCursor(cursors.Next); err != nil {
+			return err
+		}
+	}
+
+	return w.Close()
+}
+
+// writeEmail writes one email, and for Maildir archives its stored attachments
+// Emails without a stored original message are skipped.
+func (s *Service) writeEmail(ctx context.Context, w archiveWriter, item *repository.EmailWithPreview, format Format) error {
+	stored, err := s.emailRepo.GetByID(ctx, item.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrEmailNotFound) {
+			// Deleted while exporting
+			return nil
+		}
+		return fmt.Errorf("failed to get email %s: %w", item.ID, err)
+	}
+	if len(stored.RawEmail) == 0 {
+		s.logger.Warn("Skipping email without original message in export", "email_id", item.ID)
+		return nil
+	}
+
+	msg := &Message{
+		ID:         stored.ID,
+		AliasEmail: item.AliasEmail,
+		Sender:     stored.SenderAddress,
+		IsRead:     stored.IsRead,
+		ReceivedAt: stored.ReceivedAt,
+		Raw:        stored.RawEmail,
+	}
+	if err := w.WriteMessage(msg); err != nil {
+		return err
+	}
+
+	if format != FormatMaildir || !item.HasAttachments {
+		return nil
+	}
+	attachments, err := s.attachmentRepo.GetActiveAttachmentsByEmailID(ctx, item.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get attachments of email %s: %w", item.ID, err)
+	}
+	for _, att := range attachments {
+		if err := s.writeAttachment(ctx, w, msg, att); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeAttachment copies a stored attachment from object storage into the archive
+func (s *Service) writeAttachment(ctx context.Context, w archiveWriter, msg *Message, att *repository.Attachment) error {
+	body, err := s.storage.GetObject(ctx, att.StorageKey)
+	if err != nil {
+		// The message itself still carries the attachment
+		s.logger.Warn("Skipping missing attachment in export", "attachment_id", att.ID, "error", err)
+		return nil
+	}
+	defer body.Close()
+
+	return w.WriteAttachment(msg, att.Filename, body)
+}
+
+// publishProgress sends the state of a job to the user's SSE connections
+func (s *Service) publishProgress(job *Job) {
+	if s.eventBus == nil {
+		return
+	}
+
+	data, err := json.Marshal(events.ExportProgressEvent{
+		ID:              job.ID.String(),
+		Format:          string(job.Format),
+		Status:          string(job.Status),
+		TotalEmails:     job.TotalEmails,
+		ProcessedEmails: job.ProcessedEmails,
+		SizeBytes:       job.SizeBytes,
+		Error:           job.Error,
+	})
+	if err != nil {
+		s.logger.Warn("Failed to marshal export_progress event", "error", err)
+		return
+	}
+
+	event := events.Event{
+		ID:        uuid.New().String(),
+		Type:      events.EventTypeExportProgress,
+		UserID:    job.UserID.String(),
+		Data:      data,
+		Timestamp: time.Now().UTC(),
+	}
+	if err := s.eventBus.Publish(event); err != nil {
+		s.logger.Warn("Failed to publish export_progress event", "export_id", job.ID, "error", err)
+	}
+}
@@ -241,6 +241,12 @@ type SMTPHealthChecker interface {
 	GetActiveConnections() int64
 }
 
+// SMTPDrainReporter is implemented by SMTP servers that support graceful drain
+type SMTPDrainReporter interface {
+	IsDraining() bool
+	GetActiveTransactions() int64
+}
+
 // SMTPHealthResponse represents the SMTP health check response
 type SMTPHealthResponse struct {
 	Status    string                 `json:"status"`
@@ -323,8 +329,22 @@ func (h *SMTPHandler) SMTPHealth(w http.ResponseWriter, r *http.Request) {
 		response.SMTP["status"] = "unhealthy"
 	}
 
+	// Report drain progress; a draining server answers EHLO with 421 so skip that check
+	draining := false
+	if reporter, ok := h.smtpServer.(SMTPDrainReporter); ok {
+		draining = reporter.IsDraining()
+		response.SMTP["draining"] = draining
+		response.SMTP["active_transactions"] = reporter.GetActiveTransactions()
+		if draining {
+			response.SMTP["status"] = "draining"
+		}
+	}
+
 	// Perform EHLO check if server is running and EHLO checker is available
-	if running && h.ehloChecker != nil {
+	if draining {
+		response.Status = "draining"
+		response.EHLOCheck = "skipped"
+	} else if running && h.ehloChecker != nil {
 		err := h.ehloChecker.PerformEHLOCheck(ctx)
 		if err != nil {
 			response.Status = "degraded"
@@ -0,0 +1,69 @@
+// Package imap provides an IMAP4rev1 server for reading alias mailboxes in mail clients
+// Feature: imap-access
+// Requirements: LOGIN with app passwords or JWT, one mailbox per alias, FETCH of raw_email,
+// SEARCH, \Seen mapped to is_read, \Deleted + EXPUNGE mapped to email deletion, IDLE via the events bus
+// of the SMTP process, with a periodic rescan for changes made through the API server
+package imap
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/events"
+)
+
+// InboxName is the mandatory IMAP mailbox; it contains the mail of all the user's aliases
+const InboxName = "INBOX"
+
+// HierarchyDelimiter is reported in LIST responses; alias mailboxes are flat
+const HierarchyDelimiter = "/"
+
+// UIDValidity is constant because UIDs come from a global sequence and are never reused
+const UIDValidity uint32 = 1
+
+// Store errors
+var (
+	ErrMailboxNotFound = errors.New("mailbox not found")
+	ErrMessageNotFound = errors.New("message not found")
+)
+
+// MailboxInfo describes a selectable mailbox
+type MailboxInfo struct {
+	Name    string // Alias full address, or INBOX
+	AliasID string // Empty for INBOX
+}
+
+// MessageInfo is the per-message metadata kept in a selected mailbox
+type MessageInfo struct {
+	ID           string
+	UID          uint32
+	AliasID      string
+	Size         int64 // Size of raw_email in octets (RFC822.SIZE)
+	Seen         bool
+	InternalDate time.Time
+}
+
+// Store provides mailbox data for IMAP sessions
+type Store interface {
+	// ListMailboxes returns the user's alias mailboxes (INBOX is added by the session)
+	ListMailboxes(ctx context.Context, userID string) ([]MailboxInfo, error)
+	// ListMessages returns the messages in a mailbox ordered by UID; an empty aliasID means INBOX
+	ListMessages(ctx context.Context, userID, aliasID string) ([]MessageInfo, error)
+	// GetRawMessage returns the stored raw_email of a message owned by the user
+	GetRawMessage(ctx context.Context, userID, emailID string) ([]byte, error)
+	// SetSeen maps the \Seen flag to is_read
+	SetSeen(ctx context.Context, userID string, emailIDs []string, seen bool) error
+	// DeleteMessage moves a message to the trash; the trash purger deletes it and its attachments later
+	DeleteMessage(ctx context.Context, userID, emailID string) error
+}
+
+// Authenticator verifies LOGIN credentials and returns the user ID
+type Authenticator interface {
+	Authenticate(ctx context.Context, username, password, remoteIP string) (string, error)
+}
+
+// EventSubscriber delivers per-user events used to drive IDLE
+type EventSubscriber interface {
+	Subscribe(userID string, handler events.EventHandler) (unsubscribe func())
+}
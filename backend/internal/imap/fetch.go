@@ -0,0 +1,329 @@
+package imap
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// internalDateLayout is the RFC 3501 date-time format used by INTERNALDATE
+const internalDateLayout = "02-Jan-2006 15:04:05 -0700"
+
+// fetchItem is a single FETCH data item
+type fetchItem struct {
+	name    string       // FLAGS, UID, ENVELOPE, BODY[...] etc.
+	section *sectionSpec // Set for BODY[...] / BODY.PEEK[...]
+	peek    bool         // BODY.PEEK does not set \Seen
+	partial []uint32     // <origin.count>, nil when not partial
+}
+
+// sectionSpec is a parsed body section such as 1.2.HEADER.FIELDS (FROM TO)
+type sectionSpec struct {
+	path      []int
+	specifier string // "", HEADER, HEADER.FIELDS, HEADER.FIELDS.NOT, TEXT or MIME
+	fields    []string
+}
+
+// parseFetchItems parses the FETCH item argument, expanding the ALL, FAST and FULL macros
+func parseFetchItems(a arg) ([]fetchItem, error) {
+	var atoms []string
+	if a.kind == argList {
+		for _, item := range a.list {
+			if item.kind != argAtom {
+				return nil, errSyntax
+			}
+			atoms = append(atoms, item.value)
+		}
+	} else {
+		switch strings.ToUpper(a.value) {
+		case "ALL":
+			atoms = []string{"FLAGS", "INTERNALDATE", "RFC822.SIZE", "ENVELOPE"}
+		case "FAST":
+			atoms = []string{"FLAGS", "INTERNALDATE", "RFC822.SIZE"}
+		case "FULL":
+			atoms = []string{"FLAGS", "INTERNALDATE", "RFC822.SIZE", "ENVELOPE", "BODY"}
+		default:
+			atoms = []string{a.value}
+		}
+	}
+
+	items := make([]fetchItem, 0, len(atoms))
+	for _, atom := range atoms {
+		item, err := parseFetchItem(atom)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// parseFetchItem parses one data item atom
+func parseFetchItem(atom string) (fetchItem, error) {
+	upper := strings.ToUpper(atom)
+	switch upper {
+	case "FLAGS", "INTERNALDATE", "RFC822.SIZE", "ENVELOPE", "BODY", "BODYSTRUCTURE", "UID",
+		"RFC822", "RFC822.HEADER", "RFC822.TEXT":
+		return fetchItem{name: upper}, nil
+	}
+
+	item := fetchItem{}
+	switch {
+	case strings.HasPrefix(upper, "BODY.PEEK["):
+		item.peek = true
+		upper = upper[len("BODY.PEEK"):]
+	case strings.HasPrefix(upper, "BODY["):
+		upper = upper[len("BODY"):]
+	default:
+		return item, errSyntax
+	}
+
+	end := strings.LastIndexByte(upper, ']')
+	if end < 0 {
+		return item, errSyntax
+	}
+	section, err := parseSection(upper[1:end])
+	if err != nil {
+		return item, err
+	}
+	item.section = section
+	item.name = "BODY"
+
+	if rest := upper[end+1:]; rest != "" {
+		if !strings.HasPrefix(rest, "<") || !strings.HasSuffix(rest, ">") {
+			return item, errSyntax
+		}
+		originText, countText, ok := strings.Cut(rest[1:len(rest)-1], ".")
+		origin, err1 := strconv.ParseUint(originText, 10, 32)
+		count, err2 := strconv.ParseUint(countText, 10, 32)
+		if !ok || err1 != nil || err2 != nil || count == 0 {
+			return item, errSyntax
+		}
+		item.partial = []uint32{uint32(origin), uint32(count)}
+	}
+
+	return item, nil
+}
+
+// parseSection parses the text between the brackets of BODY[...]
+func parseSection(text string) (*sectionSpec, error) {
+	spec := &sectionSpec{}
+	head, fieldList, hasFields := strings.Cut(text, " ")
+
+	parts := strings.Split(head, ".")
+	i := 0
+	for ; i < len(parts) && head != ""; i++ {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			break
+		}
+		if n < 1 {
+			return nil, errSyntax
+		}
+		spec.path = append(spec.path, n)
+	}
+	if head != "" {
+		spec.specifier = strings.Join(parts[i:], ".")
+	}
+
+	switch spec.specifier {
+	case "", "HEADER", "TEXT":
+	case "MIME":
+		if len(spec.path) == 0 {
+			return nil, errSyntax
+		}
+	case "HEADER.FIELDS", "HEADER.FIELDS.NOT":
+		if !hasFields {
+			return nil, errSyntax
+		}
+		fieldList = strings.TrimSpace(fieldList)
+		if !strings.HasPrefix(fieldList, "(") || !strings.HasSuffix(fieldList, ")") {
+			return nil, errSyntax
+		}
+		spec.fields = strings.Fields(fieldList[1 : len(fieldList)-1])
+		if len(spec.fields) == 0 {
+			return nil, errSyntax
+		}
+		for j, field := range spec.fields {
+			spec.fields[j] = strings.Trim(field, `"`)
+		}
+		return spec, nil
+	default:
+		return nil, errSyntax
+	}
+
+	if hasFields {
+		return nil, errSyntax
+	}
+	return spec, nil
+}
+
+// String renders the section as it appears in the FETCH response
+func (s *sectionSpec) String() string {
+	var parts []string
+	for _, n := range s.path {
+		parts = append(parts, strconv.Itoa(n))
+	}
+	if s.specifier != "" {
+		parts = append(parts, s.specifier)
+	}
+	text := strings.Join(parts, ".")
+	if len(s.fields) > 0 {
+		text += " (" + strings.Join(s.fields, " ") + ")"
+	}
+	return text
+}
+
+// needsBody reports whether the item requires the stored raw_email
+func (item fetchItem) needsBody() bool {
+	switch item.name {
+	case "FLAGS", "INTERNALDATE", "RFC822.SIZE", "UID":
+		return false
+	}
+	return true
+}
+
+// setsSeen reports whether fetching the item implicitly sets \Seen
+func (item fetchItem) setsSeen() bool {
+	return (item.section != nil && !item.peek) || item.name == "RFC822" || item.name == "RFC822.TEXT"
+}
+
+// extractSection returns the octets of a body section, or nil when the part does not exist
+func extractSection(root *entity, raw []byte, spec *sectionSpec) []byte {
+	if len(spec.path) == 0 {
+		switch spec.specifier {
+		case "":
+			return raw
+		case "HEADER":
+			return root.header
+		case "TEXT":
+			return root.body
+		default:
+			return filterHeader(root.header, spec.fields, spec.specifier == "HEADER.FIELDS.NOT")
+		}
+	}
+
+	part := root.part(spec.path)
+	if part == nil {
+		return nil
+	}
+
+	switch spec.specifier {
+	case "":
+		return part.body
+	case "MIME":
+		return part.header
+	}
+
+	// HEADER and TEXT of a part only apply to an encapsulated message
+	if !part.isMessage() {
+		return nil
+	}
+	message := part.message
+	switch spec.specifier {
+	case "HEADER":
+		return message.header
+	case "TEXT":
+		return message.body
+	default:
+		return filterHeader(message.header, spec.fields, spec.specifier == "HEADER.FIELDS.NOT")
+	}
+}
+
+// literal renders data as an IMAP literal
+func literal(data []byte) string {
+	return fmt.Sprintf("{%d}\r\n%s", len(data), data)
+}
+
+// formatFlags renders the flag list of a message
+func formatFlags(msg *sessionMessage) string {
+	var flags []string
+	if msg.Seen {
+		flags = append(flags, `\Seen`)
+	}
+	if msg.deleted {
+		flags = append(flags, `\Deleted`)
+	}
+	return "(" + strings.Join(flags, " ") + ")"
+}
+
+// fetchResponse renders the data items of one message
+// loadRaw is only called when an item needs the stored raw_email
+func fetchResponse(msg *sessionMessage, items []fetchItem, includeUID bool, loadRaw func() ([]byte, error)) (string, error) {
+	var raw []byte
+	var root *entity
+	var parts []string
+
+	if includeUID {
+		parts = append(parts, fmt.Sprintf("UID %d", msg.UID))
+	}
+
+	for _, item := range items {
+		if item.needsBody() && root == nil {
+			var err error
+			if raw, err = loadRaw(); err != nil {
+				return "", err
+			}
+			root = parseEntity(raw, 0)
+		}
+
+		switch item.name {
+		case "UID":
+			if !includeUID {
+				parts = append(parts, fmt.Sprintf("UID %d", msg.UID))
+			}
+		case "FLAGS":
+			parts = append(parts, "FLAGS "+formatFlags(msg))
+		case "INTERNALDATE":
+			parts = append(parts, fmt.Sprintf(`INTERNALDATE "%s"`, formatInternalDate(msg.InternalDate)))
+		case "RFC822.SIZE":
+			parts = append(parts, fmt.Sprintf("RFC822.SIZE %d", msg.Size))
+		case "ENVELOPE":
+			parts = append(parts, "ENVELOPE "+root.envelope())
+		case "BODYSTRUCTURE":
+			parts = append(parts, "BODYSTRUCTURE "+root.bodyStructure(true))
+		case "RFC822":
+			parts = append(parts, "RFC822 "+literal(raw))
+		case "RFC822.HEADER":
+			parts = append(parts, "RFC822.HEADER "+literal(root.header))
+		case "RFC822.TEXT":
+			parts = append(parts, "RFC822.TEXT "+literal(root.body))
+		case "BODY":
+			if item.section == nil {
+				parts = append(parts, "BODY "+root.bodyStructure(false))
+				continue
+			}
+			parts = append(parts, bodySectionResponse(root, raw, item))
+		}
+	}
+
+	return strings.Join(parts, " "), nil
+}
+
+// bodySectionResponse renders BODY[section]<origin> with its literal
+func bodySectionResponse(root *entity, raw []byte, item fetchItem) string {
+	data := extractSection(root, raw, item.section)
+	name := "BODY[" + item.section.String() + "]"
+
+	if item.partial != nil {
+		origin, count := item.partial[0], item.partial[1]
+		name += fmt.Sprintf("<%d>", origin)
+		switch {
+		case int(origin) >= len(data):
+			data = nil
+		case int(origin+count) < len(data) && origin+count > origin:
+			data = data[origin : origin+count]
+		default:
+			data = data[origin:]
+		}
+	}
+
+	// Sections that do not exist are returned empty
+	return name + " " + literal(data)
+}
+
+// formatInternalDate renders an INTERNALDATE value
+func formatInternalDate(t time.Time) string {
+	return t.UTC().Format(internalDateLayout)
+}
@@ -0,0 +1,418 @@
+package imap
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+)
+
+// maxEntityDepth bounds multipart and message/rfc822 nesting
+const maxEntityDepth = 20
+
+// entity is a MIME entity of a stored raw_email, keeping the raw bytes needed for section fetches
+type entity struct {
+	header   []byte // Raw header including the blank separator line
+	body     []byte
+	fields   textproto.MIMEHeader
+	typ      string // Lowercase media type, e.g. "text"
+	subtype  string // Lowercase media subtype, e.g. "plain"
+	params   map[string]string
+	children []*entity // Parts of a multipart entity
+	message  *entity   // Encapsulated message of a message/rfc822 entity
+}
+
+// parseEntity splits raw into header and body and recursively parses multipart and message/rfc822 bodies
+func parseEntity(raw []byte, depth int) *entity {
+	header, body := splitHeader(raw)
+	e := &entity{header: header, body: body, fields: parseHeaderFields(header)}
+
+	e.typ, e.subtype, e.params = "text", "plain", map[string]string{"charset": "us-ascii"}
+	if ct := e.fields.Get("Content-Type"); ct != "" {
+		if mediaType, params, err := mime.ParseMediaType(ct); err == nil {
+			if typ, subtype, ok := strings.Cut(mediaType, "/"); ok {
+				e.typ, e.subtype, e.params = typ, subtype, params
+			}
+		}
+	}
+
+	if depth >= maxEntityDepth {
+		return e
+	}
+
+	switch {
+	case e.typ == "multipart" && e.params["boundary"] != "":
+		for _, part := range splitMultipart(body, e.params["boundary"]) {
+			e.children = append(e.children, parseEntity(part, depth+1))
+		}
+	case e.typ == "message" && e.subtype == "rfc822":
+		e.message = parseEntity(body, depth+1)
+	}
+
+	return e
+}
+
+// splitHeader splits raw at the first blank line; the header keeps the separator
+func splitHeader(raw []byte) ([]byte, []byte) {
+	if bytes.HasPrefix(raw, []byte("\r\n")) {
+		return raw[:2], raw[2:]
+	}
+	if bytes.HasPrefix(raw, []byte("\n")) {
+		return raw[:1], raw[1:]
+	}
+	crlf := bytes.Index(raw, []byte("\r\n\r\n"))
+	lf := bytes.Index(raw, []byte("\n\n"))
+	switch {
+	case crlf >= 0 && (lf < 0 || crlf <= lf):
+		return raw[:crlf+4], raw[crlf+4:]
+	case lf >= 0:
+		return raw[:lf+2], raw[lf+2:]
+	default:
+		return raw, nil
+	}
+}
+
+// parseHeaderFields parses a raw header, tolerating malformed lines
+func parseHeaderFields(header []byte) textproto.MIMEHeader {
+	reader := textproto.NewReader(bufio.NewReader(bytes.NewReader(header)))
+	// Fields read before a malformed line are still returned and used
+	fields, _ := reader.ReadMIMEHeader()
+	if fields == nil {
+		fields = textproto.MIMEHeader{}
+	}
+	return fields
+}
+
+// splitMultipart returns the raw body parts between boundary delimiter lines
+func splitMultipart(body []byte, boundary string) [][]byte {
+	delimiter := []byte("--" + boundary)
+	var parts [][]byte
+	start := -1
+	pos := 0
+	for pos < len(body) {
+		lineEnd := bytes.IndexByte(body[pos:], '\n')
+		next := len(body)
+		if lineEnd >= 0 {
+			next = pos + lineEnd + 1
+		}
+		line := bytes.TrimRight(body[pos:next], " \t\r\n")
+
+		if bytes.HasPrefix(line, delimiter) {
+			rest := line[len(delimiter):]
+			closing := bytes.Equal(rest, []byte("--"))
+			if closing || len(rest) == 0 {
+				if start >= 0 {
+					parts = append(parts, trimPartEnd(body[start:pos]))
+				}
+				if closing {
+					return parts
+				}
+				start = next
+			}
+		}
+		pos = next
+	}
+	// Unterminated multipart: keep the last part
+	if start >= 0 && start <= len(body) {
+		parts = append(parts, body[start:])
+	}
+	return parts
+}
+
+// trimPartEnd drops the line break that belongs to the following delimiter
+func trimPartEnd(part []byte) []byte {
+	if bytes.HasSuffix(part, []byte("\r\n")) {
+		return part[:len(part)-2]
+	}
+	return bytes.TrimSuffix(part, []byte("\n"))
+}
+
+// isMultipart reports whether the entity has parts
+func (e *entity) isMultipart() bool {
+	return e.typ == "multipart"
+}
+
+// isMessage reports whether the entity encapsulates a message/rfc822
+func (e *entity) isMessage() bool {
+	return e.message != nil
+}
+
+// part resolves a section part path such as 1.2.3 (RFC 3501 section 6.4.5)
+func (e *entity) part(path []int) *entity {
+	cur := e
+	for i, n := range path {
+		if i > 0 && cur.isMessage() {
+			cur = cur.message
+		}
+		if cur.isMultipart() {
+			if n < 1 || n > len(cur.children) {
+				return nil
+			}
+			cur = cur.children[n-1]
+			continue
+		}
+		// The only part of a non-multipart entity is its body
+		if n != 1 {
+			return nil
+		}
+	}
+	return cur
+}
+
+// headerLines returns the raw header fields, folded lines joined with their field
+func headerLines(header []byte) []string {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(header))
+	scanner.Buffer(make([]byte, 0, 4096), len(header)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimRight(line, "\r") == "" {
+			break
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += "\n" + line
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// filterHeader returns the header fields named (or not named) in names, followed by a blank line
+func filterHeader(header []byte, names []string, exclude bool) []byte {
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[strings.ToLower(name)] = true
+	}
+
+	var b bytes.Buffer
+	for _, line := range headerLines(header) {
+		name, _, _ := strings.Cut(line, ":")
+		if wanted[strings.ToLower(strings.TrimSpace(name))] == exclude {
+			continue
+		}
+		for _, physical := range strings.Split(line, "\n") {
+			b.WriteString(strings.TrimRight(physical, "\r"))
+			b.WriteString("\r\n")
+		}
+	}
+	b.WriteString("\r\n")
+	return b.Bytes()
+}
+
+// decodedBody returns the body with its Content-Transfer-Encoding removed
+func (e *entity) decodedBody() []byte {
+	var reader io.Reader
+	switch strings.ToLower(strings.TrimSpace(e.fields.Get("Content-Transfer-Encoding"))) {
+	case "base64":
+		reader = base64.NewDecoder(base64.StdEncoding, &newlineStripper{r: bytes.NewReader(e.body)})
+	case "quoted-printable":
+		reader = quotedprintable.NewReader(bytes.NewReader(e.body))
+	default:
+		return e.body
+	}
+	decoded, err := io.ReadAll(reader)
+	if err != nil && len(decoded) == 0 {
+		return e.body
+	}
+	return decoded
+}
+
+// newlineStripper removes line breaks so base64 bodies decode in one pass
+type newlineStripper struct {
+	r io.Reader
+}
+
+func (n *newlineStripper) Read(p []byte) (int, error) {
+	count, err := n.r.Read(p)
+	out := p[:0]
+	for _, c := range p[:count] {
+		if c != '\r' && c != '\n' && c != ' ' && c != '\t' {
+			out = append(out, c)
+		}
+	}
+	return len(out), err
+}
+
+// text returns the decoded text of all text/* leaf parts, used by SEARCH BODY and TEXT
+func (e *entity) text() string {
+	var b strings.Builder
+	var walk func(*entity)
+	walk = func(cur *entity) {
+		switch {
+		case cur.isMultipart():
+			for _, child := range cur.children {
+				walk(child)
+			}
+		case cur.isMessage():
+			b.WriteString(decodeHeaderValue(cur.message.fields.Get("Subject")))
+			b.WriteByte('\n')
+			walk(cur.message)
+		case cur.typ == "text":
+			b.Write(cur.decodedBody())
+			b.WriteByte('\n')
+		}
+	}
+	walk(e)
+	return b.String()
+}
+
+// decodeHeaderValue decodes RFC 2047 encoded-words, returning the input when decoding fails
+func decodeHeaderValue(value string) string {
+	decoded, err := new(mime.WordDecoder).DecodeHeader(value)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
+
+// envelope renders the ENVELOPE structure of a message entity
+func (e *entity) envelope() string {
+	from := addressList(e.fields.Get("From"))
+	sender := addressList(e.fields.Get("Sender"))
+	if sender == "NIL" {
+		sender = from
+	}
+	replyTo := addressList(e.fields.Get("Reply-To"))
+	if replyTo == "NIL" {
+		replyTo = from
+	}
+
+	return fmt.Sprintf("(%s %s %s %s %s %s %s %s %s %s)",
+		nstring(e.fields.Get("Date")),
+		nstring(e.fields.Get("Subject")),
+		from,
+		sender,
+		replyTo,
+		addressList(e.fields.Get("To")),
+		addressList(e.fields.Get("Cc")),
+		addressList(e.fields.Get("Bcc")),
+		nstring(e.fields.Get("In-Reply-To")),
+		nstring(e.fields.Get("Message-Id")),
+	)
+}
+
+// addressList renders an address header as an IMAP address list, or NIL
+func addressList(value string) string {
+	if strings.TrimSpace(value) == "" {
+		return "NIL"
+	}
+	addresses, err := (&mail.AddressParser{WordDecoder: new(mime.WordDecoder)}).ParseList(value)
+	if err != nil || len(addresses) == 0 {
+		return "NIL"
+	}
+
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, address := range addresses {
+		mailbox, host, _ := strings.Cut(address.Address, "@")
+		fmt.Fprintf(&b, "(%s NIL %s %s)", nstring(address.Name), nstring(mailbox), nstring(host))
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// bodyStructure renders BODYSTRUCTURE (extended) or BODY (non-extended) for the entity
+func (e *entity) bodyStructure(extended bool) string {
+	var b strings.Builder
+	e.writeBodyStructure(&b, extended)
+	return b.String()
+}
+
+func (e *entity) writeBodyStructure(b *strings.Builder, extended bool) {
+	b.WriteByte('(')
+	defer b.WriteByte(')')
+
+	if e.isMultipart() {
+		if len(e.children) == 0 {
+			// A multipart without parts is described as an empty text part
+			b.WriteString(`"text" "plain" NIL NIL NIL "7bit" 0 0`)
+			return
+		}
+		for _, child := range e.children {
+			child.writeBodyStructure(b, extended)
+		}
+		b.WriteByte(' ')
+		b.WriteString(quoteString(strings.ToUpper(e.subtype)))
+		if extended {
+			fmt.Fprintf(b, " %s %s NIL NIL", paramList(e.params), e.disposition())
+		}
+		return
+	}
+
+	encoding := strings.TrimSpace(e.fields.Get("Content-Transfer-Encoding"))
+	if encoding == "" {
+		encoding = "7BIT"
+	}
+	fmt.Fprintf(b, "%s %s %s %s %s %s %d",
+		quoteString(strings.ToUpper(e.typ)),
+		quoteString(strings.ToUpper(e.subtype)),
+		paramList(e.params),
+		nstring(e.fields.Get("Content-Id")),
+		nstring(e.fields.Get("Content-Description")),
+		quoteString(strings.ToUpper(encoding)),
+		len(e.body),
+	)
+
+	switch {
+	case e.isMessage():
+		b.WriteByte(' ')
+		b.WriteString(e.message.envelope())
+		b.WriteByte(' ')
+		e.message.writeBodyStructure(b, extended)
+		fmt.Fprintf(b, " %d", countLines(e.body))
+	case e.typ == "text":
+		fmt.Fprintf(b, " %d", countLines(e.body))
+	}
+
+	if extended {
+		fmt.Fprintf(b, " %s %s NIL NIL", nstring(e.fields.Get("Content-Md5")), e.disposition())
+	}
+}
+
+// disposition renders the Content-Disposition of the entity, including the attachment filename
+func (e *entity) disposition() string {
+	value := e.fields.Get("Content-Disposition")
+	if value == "" {
+		return "NIL"
+	}
+	disposition, params, err := mime.ParseMediaType(value)
+	if err != nil {
+		return "NIL"
+	}
+	return fmt.Sprintf("(%s %s)", quoteString(strings.ToUpper(disposition)), paramList(params))
+}
+
+// paramList renders body parameters as a sorted attribute/value list, or NIL
+func paramList(params map[string]string) string {
+	if len(params) == 0 {
+		return "NIL"
+	}
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(params)*2)
+	for _, key := range keys {
+		parts = append(parts, quoteString(strings.ToUpper(key)), quoteString(params[key]))
+	}
+	return "(" + strings.Join(parts, " ") + ")"
+}
+
+// countLines counts the lines of a body for the text and message/rfc822 size fields
+func countLines(body []byte) int {
+	lines := bytes.Count(body, []byte("\n"))
+	if len(body) > 0 && body[len(body)-1] != '\n' {
+		lines++
+	}
+	return lines
+}
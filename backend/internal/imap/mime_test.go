@@ -0,0 +1,89 @@
+package imap
+
+import (
+	"strings"
+	"testing"
+)
+
+const testMultipartMessage = "From: Alice <alice@example.com>\r\n" +
+	"To: me@webrana.id\r\n" +
+	"Subject: Invoice\r\n" +
+	"Date: Mon, 02 Jan 2006 15:04:05 +0000\r\n" +
+	"Message-ID: <invoice-1@example.com>\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=\"outer\"\r\n" +
+	"\r\n" +
+	"preamble\r\n" +
+	"--outer\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"Your code is 42=\r\n" +
+	"17\r\n" +
+	"--outer\r\n" +
+	"Content-Type: application/pdf; name=\"invoice.pdf\"\r\n" +
+	"Content-Disposition: attachment; filename=\"invoice.pdf\"\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"JVBERi0xLjQK\r\n" +
+	"--outer--\r\n"
+
+// TestEntity_Sections verifies part numbering and section extraction
+func TestEntity_Sections(t *testing.T) {
+	raw := []byte(testMultipartMessage)
+	root := parseEntity(raw, 0)
+
+	if len(root.children) != 2 {
+		t.Fatalf("Expected 2 parts, got %d", len(root.children))
+	}
+
+	tests := []struct {
+		section string
+		want    string
+	}{
+		{"1", "Your code is 42=\r\n17"},
+		{"2", "JVBERi0xLjQK"},
+		{"2.MIME", "Content-Type: application/pdf; name=\"invoice.pdf\"\r\nContent-Disposition: attachment; filename=\"invoice.pdf\"\r\nContent-Transfer-Encoding: base64\r\n\r\n"},
+		{"HEADER.FIELDS (SUBJECT FROM)", "From: Alice <alice@example.com>\r\nSubject: Invoice\r\n\r\n"},
+		{"3", ""},
+	}
+	for _, tt := range tests {
+		spec, err := parseSection(tt.section)
+		if err != nil {
+			t.Fatalf("Failed to parse section %q: %v", tt.section, err)
+		}
+		if got := string(extractSection(root, raw, spec)); got != tt.want {
+			t.Errorf("BODY[%s] = %q, want %q", tt.section, got, tt.want)
+		}
+	}
+
+	if text := root.text(); !strings.Contains(text, "Your code is 4217") {
+		t.Errorf("Expected decoded text part, got %q", text)
+	}
+}
+
+// TestEntity_BodyStructure verifies BODYSTRUCTURE includes the attachment disposition
+func TestEntity_BodyStructure(t *testing.T) {
+	root := parseEntity([]byte(testMultipartMessage), 0)
+
+	structure := root.bodyStructure(true)
+	for _, want := range []string{
+		`("TEXT" "PLAIN" ("CHARSET" "utf-8") NIL NIL "QUOTED-PRINTABLE" 20 2 NIL NIL NIL NIL)`,
+		`("APPLICATION" "PDF" ("NAME" "invoice.pdf") NIL NIL "BASE64" 12 NIL ("ATTACHMENT" ("FILENAME" "invoice.pdf")) NIL NIL)`,
+		`"MIXED" ("BOUNDARY" "outer") NIL NIL NIL)`,
+	} {
+		if !strings.Contains(structure, want) {
+			t.Errorf("BODYSTRUCTURE missing %s:\n%s", want, structure)
+		}
+	}
+
+	if body := root.bodyStructure(false); strings.Contains(body, "ATTACHMENT") {
+		t.Errorf("Non-extensible BODY must not include extension data:\n%s", body)
+	}
+
+	envelope := root.envelope()
+	want := `("Mon, 02 Jan 2006 15:04:05 +0000" "Invoice" (("Alice" NIL "alice" "example.com"))`
+	if !strings.HasPrefix(envelope, want) || !strings.HasSuffix(envelope, `NIL "<invoice-1@example.com>")`) {
+		t.Errorf("Unexpected ENVELOPE:\n%s", envelope)
+	}
+}
@@ -0,0 +1,295 @@
+package imap
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Parser limits
+const (
+	MaxLineLength    = 64 * 1024
+	MaxLiteralLength = 64 * 1024 // Clients only send small literals (credentials, search strings)
+)
+
+// Parser errors
+var (
+	errLineTooLong    = errors.New("command line too long")
+	errLiteralTooLong = errors.New("literal too long")
+	errSyntax         = errors.New("syntax error")
+)
+
+// argKind identifies the kind of a parsed command argument
+type argKind int
+
+const (
+	argAtom argKind = iota
+	argString
+	argList
+)
+
+// arg is a parsed command argument: an atom, a string (quoted or literal) or a parenthesized list
+type arg struct {
+	kind  argKind
+	value string
+	list  []arg
+}
+
+// command is a tagged client command
+type command struct {
+	tag  string
+	name string
+	args []arg
+}
+
+// readCommand reads one command, requesting literal data with a continuation when needed
+func readCommand(r *bufio.Reader, continuation func()) (string, error) {
+	var buf strings.Builder
+	for {
+		line, err := readLine(r)
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString(line)
+
+		size, nonSync, ok := literalSuffix(line)
+		if !ok {
+			return buf.String(), nil
+		}
+		if size > MaxLiteralLength {
+			return "", errLiteralTooLong
+		}
+		if !nonSync {
+			continuation()
+		}
+
+		literal := make([]byte, size)
+		if _, err := io.ReadFull(r, literal); err != nil {
+			return "", err
+		}
+		buf.WriteString("\r\n")
+		buf.Write(literal)
+	}
+}
+
+// readLine reads a CRLF terminated line without the line ending
+func readLine(r *bufio.Reader) (string, error) {
+	var line []byte
+	for {
+		chunk, isPrefix, err := r.ReadLine()
+		if err != nil {
+			return "", err
+		}
+		line = append(line, chunk...)
+		if len(line) > MaxLineLength {
+			return "", errLineTooLong
+		}
+		if !isPrefix {
+			return string(line), nil
+		}
+	}
+}
+
+// literalSuffix reports whether a line ends with a literal marker {n} or {n+}
+func literalSuffix(line string) (int, bool, bool) {
+	if !strings.HasSuffix(line, "}") {
+		return 0, false, false
+	}
+	open := strings.LastIndexByte(line, '{')
+	if open < 0 {
+		return 0, false, false
+	}
+	inner := line[open+1 : len(line)-1]
+	nonSync := strings.HasSuffix(inner, "+")
+	inner = strings.TrimSuffix(inner, "+")
+	size, err := strconv.Atoi(inner)
+	if err != nil || size < 0 {
+		return 0, false, false
+	}
+	return size, nonSync, true
+}
+
+// parseCommand splits a command into tag, name and arguments
+func parseCommand(line string) (*command, error) {
+	tag, rest, ok := strings.Cut(line, " ")
+	if !ok || tag == "" || strings.ContainsAny(tag, "(){*%\"\\+") {
+		return &command{tag: tag}, errSyntax
+	}
+
+	p := &argParser{input: rest}
+	name, err := p.atom()
+	if err != nil || name == "" {
+		return &command{tag: tag}, errSyntax
+	}
+
+	args, err := p.args(false)
+	if err != nil {
+		return &command{tag: tag, name: strings.ToUpper(name)}, err
+	}
+
+	return &command{tag: tag, name: strings.ToUpper(name), args: args}, nil
+}
+
+// argParser tokenizes command arguments
+type argParser struct {
+	input string
+	pos   int
+}
+
+// args parses space separated arguments until the end of input or a closing parenthesis
+func (p *argParser) args(inList bool) ([]arg, error) {
+	var args []arg
+	for {
+		for p.pos < len(p.input) && p.input[p.pos] == ' ' {
+			p.pos++
+		}
+		if p.pos >= len(p.input) {
+			if inList {
+				return nil, errSyntax
+			}
+			return args, nil
+		}
+
+		switch c := p.input[p.pos]; c {
+		case ')':
+			if !inList {
+				return nil, errSyntax
+			}
+			p.pos++
+			return args, nil
+		case '(':
+			p.pos++
+			list, err := p.args(true)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg{kind: argList, list: list})
+		case '"':
+			s, err := p.quoted()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg{kind: argString, value: s})
+		case '{':
+			s, err := p.literal()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg{kind: argString, value: s})
+		default:
+			a, err := p.atom()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg{kind: argAtom, value: a})
+		}
+	}
+}
+
+// atom reads an atom; brackets are kept intact so BODY[HEADER.FIELDS (A B)]<0.10> is one atom
+func (p *argParser) atom() (string, error) {
+	start := p.pos
+	depth := 0
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		if depth == 0 && (c == ' ' || c == '(' || c == ')') {
+			break
+		}
+		switch c {
+		case '[':
+			depth++
+		case ']':
+			if depth == 0 {
+				return "", errSyntax
+			}
+			depth--
+		case '"', '{', '\r', '\n':
+			if depth == 0 {
+				return "", errSyntax
+			}
+		}
+		p.pos++
+	}
+	if depth != 0 || p.pos == start {
+		return "", errSyntax
+	}
+	return p.input[start:p.pos], nil
+}
+
+// quoted reads a quoted string, handling \" and \\ escapes
+func (p *argParser) quoted() (string, error) {
+	p.pos++ // opening quote
+	var b strings.Builder
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		p.pos++
+		switch c {
+		case '"':
+			return b.String(), nil
+		case '\\':
+			if p.pos >= len(p.input) {
+				return "", errSyntax
+			}
+			b.WriteByte(p.input[p.pos])
+			p.pos++
+		case '\r', '\n':
+			return "", errSyntax
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", errSyntax
+}
+
+// literal reads {n}CRLF followed by n octets
+func (p *argParser) literal() (string, error) {
+	end := strings.IndexByte(p.input[p.pos:], '}')
+	if end < 0 {
+		return "", errSyntax
+	}
+	spec := strings.TrimSuffix(p.input[p.pos+1:p.pos+end], "+")
+	size, err := strconv.Atoi(spec)
+	if err != nil || size < 0 {
+		return "", errSyntax
+	}
+	p.pos += end + 1
+	if !strings.HasPrefix(p.input[p.pos:], "\r\n") || p.pos+2+size > len(p.input) {
+		return "", errSyntax
+	}
+	p.pos += 2
+	s := p.input[p.pos : p.pos+size]
+	p.pos += size
+	return s, nil
+}
+
+// argValue returns the string value of an atom or string argument
+func argValue(a arg) (string, error) {
+	if a.kind == argList {
+		return "", errSyntax
+	}
+	return a.value, nil
+}
+
+// quoteString renders s as an IMAP quoted string, or as a literal when it cannot be quoted
+func quoteString(s string) string {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\r' || c == '\n' || c == 0 || c >= 0x80 {
+			return fmt.Sprintf("{%d}\r\n%s", len(s), s)
+		}
+	}
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// nstring renders an optional string, using NIL when empty
+func nstring(s string) string {
+	if s == "" {
+		return "NIL"
+	}
+	return quoteString(s)
+}
@@ -0,0 +1,99 @@
+package imap
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+	"testing"
+
+	"pgregory.net/rapid"
+)
+
+// TestQuoteString_RoundTrip verifies any string survives quoting and command parsing
+func TestQuoteString_RoundTrip(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		value := rapid.String().Draw(t, "value")
+
+		cmd, err := parseCommand("a1 LOGIN " + quoteString(value) + " secret")
+		if err != nil {
+			t.Fatalf("Failed to parse quoted %q: %v", value, err)
+		}
+		if len(cmd.args) != 2 || cmd.args[0].value != value || cmd.args[1].value != "secret" {
+			t.Fatalf("Round trip mismatch for %q: %+v", value, cmd.args)
+		}
+	})
+}
+
+// TestSeqSet_Contains verifies ranges, reversed ranges and "*"
+func TestSeqSet_Contains(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		max := rapid.Uint32Range(1, 1000).Draw(t, "max")
+		a := rapid.Uint32Range(1, 1000).Draw(t, "a")
+		b := rapid.Uint32Range(1, 1000).Draw(t, "b")
+		n := rapid.Uint32Range(1, 1000).Draw(t, "n")
+
+		set, err := parseSeqSet(strings.Join([]string{uintString(a), uintString(b)}, ":"))
+		if err != nil {
+			t.Fatalf("Failed to parse range: %v", err)
+		}
+		low, high := min(a, b), a+b-min(a, b)
+		if got := set.contains(n, max); got != (n >= low && n <= high) {
+			t.Fatalf("%d:%d contains %d = %v", a, b, n, got)
+		}
+
+		star, _ := parseSeqSet(uintString(a) + ":*")
+		low, high = min(a, max), a+max-min(a, max)
+		if got := star.contains(n, max); got != (n >= low && n <= high) {
+			t.Fatalf("%d:* (max %d) contains %d = %v", a, max, n, got)
+		}
+	})
+
+	for _, invalid := range []string{"", "0", "1:0", "a", "1,,2", "-1"} {
+		if _, err := parseSeqSet(invalid); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func uintString(n uint32) string {
+	return strconv.FormatUint(uint64(n), 10)
+}
+
+// TestParseCommand verifies nested lists, bracketed sections and literals
+func TestParseCommand(t *testing.T) {
+	cmd, err := parseCommand(`A7 UID FETCH 1:* (FLAGS BODY.PEEK[HEADER.FIELDS (FROM SUBJECT)]<0.512>)`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cmd.tag != "A7" || cmd.name != "UID" || len(cmd.args) != 3 {
+		t.Fatalf("Unexpected command: %+v", cmd)
+	}
+	items, err := parseFetchItems(cmd.args[2])
+	if err != nil || len(items) != 2 {
+		t.Fatalf("Unexpected items: %+v, %v", items, err)
+	}
+	body := items[1]
+	if !body.peek || body.section.specifier != "HEADER.FIELDS" || len(body.section.fields) != 2 || body.partial[1] != 512 {
+		t.Fatalf("Unexpected body item: %+v", body)
+	}
+	if body.section.String() != "HEADER.FIELDS (FROM SUBJECT)" {
+		t.Fatalf("Unexpected section name %q", body.section.String())
+	}
+
+	// A synchronizing literal is requested with a continuation
+	continued := false
+	line, err := readCommand(bufio.NewReader(strings.NewReader("a1 LOGIN {5}\r\nuser@ pass\r\n")), func() { continued = true })
+	if err != nil || !continued {
+		t.Fatalf("Expected literal with continuation, got %v, %v", line, continued)
+	}
+	cmd, err = parseCommand(line)
+	if err != nil || cmd.args[0].value != "user@" || cmd.args[1].value != "pass" {
+		t.Fatalf("Unexpected literal parse: %+v, %v", cmd, err)
+	}
+
+	for _, invalid := range []string{"", "a1", `a1 LOGIN "unterminated`, "a1 FETCH 1 (FLAGS", "a1 FETCH 1 BODY[TEXT"} {
+		if _, err := parseCommand(invalid); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
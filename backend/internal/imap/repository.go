@@ -0,0 +1,146 @@
+package imap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/email"
+)
+
+// EmailDeleter moves an email to the trash and publishes email_deleted
+// Implemented by email.Service so EXPUNGE behaves exactly like deleting in the web UI: the email
+// can be restored until the trash retention passes, then the trash purger removes its attachments.
+type EmailDeleter interface {
+	Delete(ctx context.Context, userID uuid.UUID, emailID string) (*email.DeleteEmailResponse, error)
+}
+
+// PgxStore implements Store using pgxpool
+type PgxStore struct {
+	pool    *pgxpool.Pool
+	deleter EmailDeleter
+}
+
+// NewPgxStore creates a new PgxStore
+func NewPgxStore(pool *pgxpool.Pool, deleter EmailDeleter) *PgxStore {
+	return &PgxStore{pool: pool, deleter: deleter}
+}
+
+// ListMailboxes returns one mailbox per alias owned by the user, including inactive aliases
+func (s *PgxStore) ListMailboxes(ctx context.Context, userID string) ([]MailboxInfo, error) {
+	query := `
+		SELECT id, full_address
+		FROM aliases
+		WHERE user_id = $1
+		ORDER BY full_address
+	`
+
+	rows, err := s.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list aliases: %w", err)
+	}
+	defer rows.Close()
+
+	var mailboxes []MailboxInfo
+	for rows.Next() {
+		var mailbox MailboxInfo
+		if err := rows.Scan(&mailbox.AliasID, &mailbox.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan alias: %w", err)
+		}
+		mailboxes = append(mailboxes, mailbox)
+	}
+
+	return mailboxes, rows.Err()
+}
+
+// ListMessages returns the messages of one alias, or of all the user's aliases for INBOX
+func (s *PgxStore) ListMessages(ctx context.Context, userID, aliasID string) ([]MessageInfo, error) {
+	query := `
+		SELECT e.id, e.imap_uid, e.alias_id, COALESCE(octet_length(e.raw_email), 0), e.is_read, e.received_at
+		FROM emails e
+		JOIN aliases a ON a.id = e.alias_id
+		WHERE a.user_id = $1
+		  AND ($2 = '' OR e.alias_id::text = $2)
+		  AND e.deleted_at IS NULL
+		ORDER BY e.imap_uid
+	`
+
+	rows, err := s.pool.Query(ctx, query, userID, aliasID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list emails: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []MessageInfo
+	for rows.Next() {
+		var msg MessageInfo
+		var uid int64
+		if err := rows.Scan(&msg.ID, &uid, &msg.AliasID, &msg.Size, &msg.Seen, &msg.InternalDate); err != nil {
+			return nil, fmt.Errorf("failed to scan email: %w", err)
+		}
+		msg.UID = uint32(uid)
+		messages = append(messages, msg)
+	}
+
+	return messages, rows.Err()
+}
+
+// GetRawMessage returns the stored raw_email of a message owned by the user
+// Emails stored before raw_email was captured are returned empty
+func (s *PgxStore) GetRawMessage(ctx context.Context, userID, emailID string) ([]byte, error) {
+	query := `
+		SELECT COALESCE(e.raw_email, ''::bytea)
+		FROM emails e
+		JOIN aliases a ON a.id = e.alias_id
+		WHERE e.id = $1 AND a.user_id = $2
+	`
+
+	var raw []byte
+	err := s.pool.QueryRow(ctx, query, emailID, userID).Scan(&raw)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMessageNotFound
+		}
+		return nil, fmt.Errorf("failed to get raw email: %w", err)
+	}
+
+	return raw, nil
+}
+
+// SetSeen maps \Seen to is_read for messages owned by the user
+func (s *PgxStore) SetSeen(ctx context.Context, userID string, emailIDs []string, seen bool) error {
+	query := `
+		UPDATE emails e
+		SET is_read = $3
+		FROM aliases a
+		WHERE a.id = e.alias_id
+		  AND a.user_id = $1
+		  AND e.id::text = ANY($2)
+	`
+
+	if _, err := s.pool.Exec(ctx, query, userID, emailIDs, seen); err != nil {
+		return fmt.Errorf("failed to update read status: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteMessage moves a message to the trash through the email service
+func (s *PgxStore) DeleteMessage(ctx context.Context, userID, emailID string) error {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return fmt.Errorf("invalid user ID: %w", err)
+	}
+
+	if _, err := s.deleter.Delete(ctx, uid, emailID); err != nil {
+		if errors.Is(err, email.ErrEmailNotFound) || errors.Is(err, email.ErrAccessDenied) {
+			return ErrMessageNotFound
+		}
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,264 @@
+package imap
+
+import (
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// searchDateLayout is the RFC 3501 date format used by SEARCH
+const searchDateLayout = "2-Jan-2006"
+
+// searchKey is a node of a parsed SEARCH program; a key with children is an AND of them
+type searchKey struct {
+	name     string      // Uppercase key name, "SEQ" for a sequence set, "AND" for a group
+	value    string      // String, header name or number argument
+	value2   string      // HEADER value
+	date     time.Time   // Date argument
+	set      seqSet      // SEQ and UID argument
+	children []searchKey // AND, NOT (1) and OR (2) operands
+}
+
+// parseSearch parses the SEARCH arguments, including an optional leading CHARSET
+// Only US-ASCII and UTF-8 are supported; anything else is reported as unsupported
+func parseSearch(args []arg) (searchKey, bool, error) {
+	if len(args) >= 2 && args[0].kind == argAtom && strings.EqualFold(args[0].value, "CHARSET") {
+		charset := strings.ToUpper(args[1].value)
+		if charset != "US-ASCII" && charset != "UTF-8" {
+			return searchKey{}, false, nil
+		}
+		args = args[2:]
+	}
+	if len(args) == 0 {
+		return searchKey{}, true, errSyntax
+	}
+
+	p := &searchParser{args: args}
+	root := searchKey{name: "AND"}
+	for p.pos < len(p.args) {
+		key, err := p.key()
+		if err != nil {
+			return searchKey{}, true, err
+		}
+		root.children = append(root.children, key)
+	}
+	return root, true, nil
+}
+
+// searchParser walks the argument list of a SEARCH command
+type searchParser struct {
+	args []arg
+	pos  int
+}
+
+// next returns the next argument as a string
+func (p *searchParser) next() (string, error) {
+	if p.pos >= len(p.args) {
+		return "", errSyntax
+	}
+	p.pos++
+	return argValue(p.args[p.pos-1])
+}
+
+// key parses one search key
+func (p *searchParser) key() (searchKey, error) {
+	if p.pos >= len(p.args) {
+		return searchKey{}, errSyntax
+	}
+	a := p.args[p.pos]
+	p.pos++
+
+	if a.kind == argList {
+		group := searchKey{name: "AND"}
+		sub := &searchParser{args: a.list}
+		for sub.pos < len(sub.args) {
+			key, err := sub.key()
+			if err != nil {
+				return searchKey{}, err
+			}
+			group.children = append(group.children, key)
+		}
+		if len(group.children) == 0 {
+			return searchKey{}, errSyntax
+		}
+		return group, nil
+	}
+	if a.kind != argAtom {
+		return searchKey{}, errSyntax
+	}
+
+	name := strings.ToUpper(a.value)
+	if c := name[0]; (c >= '0' && c <= '9') || c == '*' {
+		set, err := parseSeqSet(a.value)
+		return searchKey{name: "SEQ", set: set}, err
+	}
+
+	key := searchKey{name: name}
+	var err error
+	switch name {
+	case "ALL", "ANSWERED", "DELETED", "DRAFT", "FLAGGED", "NEW", "OLD", "RECENT", "SEEN",
+		"UNANSWERED", "UNDELETED", "UNDRAFT", "UNFLAGGED", "UNSEEN":
+	case "BCC", "BODY", "CC", "FROM", "KEYWORD", "SUBJECT", "TEXT", "TO", "UNKEYWORD":
+		key.value, err = p.next()
+	case "HEADER":
+		if key.value, err = p.next(); err == nil {
+			key.value2, err = p.next()
+		}
+	case "LARGER", "SMALLER":
+		if key.value, err = p.next(); err == nil {
+			_, err = strconv.ParseUint(key.value, 10, 32)
+		}
+	case "BEFORE", "ON", "SINCE", "SENTBEFORE", "SENTON", "SENTSINCE":
+		var value string
+		if value, err = p.next(); err == nil {
+			key.date, err = time.Parse(searchDateLayout, value)
+		}
+	case "UID":
+		var value string
+		if value, err = p.next(); err == nil {
+			key.set, err = parseSeqSet(value)
+		}
+	case "NOT":
+		var operand searchKey
+		operand, err = p.key()
+		key.children = []searchKey{operand}
+	case "OR":
+		var left, right searchKey
+		if left, err = p.key(); err == nil {
+			right, err = p.key()
+		}
+		key.children = []searchKey{left, right}
+	default:
+		return searchKey{}, errSyntax
+	}
+	if err != nil {
+		return searchKey{}, errSyntax
+	}
+	return key, nil
+}
+
+// needsBody reports whether evaluating the key requires the stored raw_email
+func (k searchKey) needsBody() bool {
+	switch k.name {
+	case "BCC", "BODY", "CC", "FROM", "HEADER", "SUBJECT", "TEXT", "TO", "SENTBEFORE", "SENTON", "SENTSINCE":
+		return true
+	}
+	for _, child := range k.children {
+		if child.needsBody() {
+			return true
+		}
+	}
+	return false
+}
+
+// searchContext holds the message being matched; root is only parsed when a key needs the raw_email
+type searchContext struct {
+	msg    *sessionMessage
+	seqNum uint32
+	maxSeq uint32
+	maxUID uint32
+	root   *entity
+}
+
+// matches evaluates the key against the message
+func (k searchKey) matches(ctx *searchContext) bool {
+	msg := ctx.msg
+	switch k.name {
+	case "AND":
+		for _, child := range k.children {
+			if !child.matches(ctx) {
+				return false
+			}
+		}
+		return true
+	case "NOT":
+		return !k.children[0].matches(ctx)
+	case "OR":
+		return k.children[0].matches(ctx) || k.children[1].matches(ctx)
+	case "SEQ":
+		return k.set.contains(ctx.seqNum, ctx.maxSeq)
+	case "UID":
+		return k.set.contains(msg.UID, ctx.maxUID)
+	case "ALL", "OLD", "UNANSWERED", "UNDRAFT", "UNFLAGGED", "UNKEYWORD":
+		return true
+	case "ANSWERED", "DRAFT", "FLAGGED", "KEYWORD", "NEW", "RECENT":
+		// These flags are never set; no message is \Recent
+		return false
+	case "SEEN":
+		return msg.Seen
+	case "UNSEEN":
+		return !msg.Seen
+	case "DELETED":
+		return msg.deleted
+	case "UNDELETED":
+		return !msg.deleted
+	case "LARGER":
+		n, _ := strconv.ParseInt(k.value, 10, 64)
+		return msg.Size > n
+	case "SMALLER":
+		n, _ := strconv.ParseInt(k.value, 10, 64)
+		return msg.Size < n
+	case "BEFORE":
+		return dateOnly(msg.InternalDate).Before(k.date)
+	case "ON":
+		return dateOnly(msg.InternalDate).Equal(k.date)
+	case "SINCE":
+		return !dateOnly(msg.InternalDate).Before(k.date)
+	}
+
+	root := ctx.root
+	if root == nil {
+		return false
+	}
+	switch k.name {
+	case "BCC", "CC", "FROM", "SUBJECT", "TO":
+		return containsFold(headerText(root, k.name), k.value)
+	case "HEADER":
+		values := root.fields.Values(k.value)
+		if len(values) == 0 {
+			return false
+		}
+		for _, value := range values {
+			if containsFold(decodeHeaderValue(value), k.value2) {
+				return true
+			}
+		}
+		return false
+	case "BODY":
+		return containsFold(root.text(), k.value)
+	case "TEXT":
+		return containsFold(decodeHeaderValue(string(root.header)), k.value) || containsFold(root.text(), k.value)
+	case "SENTBEFORE", "SENTON", "SENTSINCE":
+		sent, err := mail.ParseDate(root.fields.Get("Date"))
+		if err != nil {
+			return false
+		}
+		day := dateOnly(sent)
+		switch k.name {
+		case "SENTBEFORE":
+			return day.Before(k.date)
+		case "SENTON":
+			return day.Equal(k.date)
+		default:
+			return !day.Before(k.date)
+		}
+	}
+	return false
+}
+
+// headerText returns the decoded value of an address or subject header
+func headerText(root *entity, name string) string {
+	field := map[string]string{"BCC": "Bcc", "CC": "Cc", "FROM": "From", "SUBJECT": "Subject", "TO": "To"}[name]
+	return decodeHeaderValue(strings.Join(root.fields.Values(field), ", "))
+}
+
+// containsFold reports whether needle occurs in haystack, ignoring case
+func containsFold(haystack, needle string) bool {
+	return strings.Contains(strings.ToLower(haystack), strings.ToLower(needle))
+}
+
+// dateOnly truncates t to its calendar date in its own zone, as a UTC midnight
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
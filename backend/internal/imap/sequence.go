@@ -0,0 +1,70 @@
+package imap
+
+import (
+	"strconv"
+	"strings"
+)
+
+// seqRange is an inclusive range of sequence numbers or UIDs; 0 stands for "*"
+type seqRange struct {
+	start uint32
+	stop  uint32
+}
+
+// seqSet is a parsed sequence set such as 1:3,5,7:*
+type seqSet []seqRange
+
+// parseSeqSet parses an RFC 3501 sequence-set
+func parseSeqSet(s string) (seqSet, error) {
+	if s == "" {
+		return nil, errSyntax
+	}
+	var set seqSet
+	for _, part := range strings.Split(s, ",") {
+		first, last, isRange := strings.Cut(part, ":")
+		start, err := parseSeqNumber(first)
+		if err != nil {
+			return nil, err
+		}
+		stop := start
+		if isRange {
+			if stop, err = parseSeqNumber(last); err != nil {
+				return nil, err
+			}
+		}
+		set = append(set, seqRange{start: start, stop: stop})
+	}
+	return set, nil
+}
+
+// parseSeqNumber parses a non-zero number or "*"
+func parseSeqNumber(s string) (uint32, error) {
+	if s == "*" {
+		return 0, nil
+	}
+	n, err := strconv.ParseUint(s, 10, 32)
+	if err != nil || n == 0 {
+		return 0, errSyntax
+	}
+	return uint32(n), nil
+}
+
+// contains reports whether n is in the set; max is the value "*" stands for
+func (set seqSet) contains(n, max uint32) bool {
+	for _, r := range set {
+		start, stop := r.start, r.stop
+		if start == 0 {
+			start = max
+		}
+		if stop == 0 {
+			stop = max
+		}
+		if start > stop {
+			start, stop = stop, start
+		}
+		if n >= start && n <= stop {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,281 @@
+package imap
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Server defaults
+const (
+	DefaultPort                = 143
+	DefaultIdleTimeout         = 30 * time.Minute // RFC 3501 autologout timer minimum
+	DefaultIdlePollInterval    = 30 * time.Second
+	DefaultMaxConnections      = 100
+	DefaultMaxConnectionsPerIP = 10
+	DefaultShutdownTimeout     = 10 * time.Second
+)
+
+// forceCloseGracePeriod bounds the wait for sessions after their connections were closed
+const forceCloseGracePeriod = 5 * time.Second
+
+// Config contains IMAP server configuration
+type Config struct {
+	Hostname            string
+	Port                int
+	IdleTimeout         time.Duration // Inactivity timeout, also bounds a single IDLE
+	MaxConnections      int
+	MaxConnectionsPerIP int
+
+	// IdlePollInterval is how often IDLE rescans the mailbox for changes that never reach the
+	// events bus, such as changes made through the API server in another process
+	IdlePollInterval time.Duration
+}
+
+// DefaultConfig returns the default IMAP configuration
+func DefaultConfig() *Config {
+	return &Config{
+		Hostname:            "localhost",
+		Port:                DefaultPort,
+		IdleTimeout:         DefaultIdleTimeout,
+		MaxConnections:      DefaultMaxConnections,
+		MaxConnectionsPerIP: DefaultMaxConnectionsPerIP,
+		IdlePollInterval:    DefaultIdlePollInterval,
+	}
+}
+
+// Server is an IMAP4rev1 server exposing each alias as a mailbox
+type Server struct {
+	config        *Config
+	tlsConfig     *tls.Config
+	store         Store
+	authenticator Authenticator
+	subscriber    EventSubscriber
+	listener      net.Listener
+
+	// Connection management
+	activeConns   int64
+	ipConnections map[string]int
+	ipConnMu      sync.Mutex
+
+	// Server state
+	running      atomic.Bool
+	shuttingDown atomic.Bool
+	shutdownCh   chan struct{}
+	wg           sync.WaitGroup
+	sessions     map[*session]struct{}
+	sessionsMu   sync.Mutex
+}
+
+// NewServer creates a new IMAP server
+// tlsConfig is required: LOGIN and AUTHENTICATE are only accepted after STARTTLS
+func NewServer(config *Config, tlsConfig *tls.Config, store Store, authenticator Authenticator, subscriber EventSubscriber) *Server {
+	if config.IdleTimeout <= 0 {
+		config.IdleTimeout = DefaultIdleTimeout
+	}
+	if config.IdlePollInterval <= 0 {
+		config.IdlePollInterval = DefaultIdlePollInterval
+	}
+	if config.MaxConnections <= 0 {
+		config.MaxConnections = DefaultMaxConnections
+	}
+	if config.MaxConnectionsPerIP <= 0 {
+		config.MaxConnectionsPerIP = DefaultMaxConnectionsPerIP
+	}
+
+	return &Server{
+		config:        config,
+		tlsConfig:     tlsConfig,
+		store:         store,
+		authenticator: authenticator,
+		subscriber:    subscriber,
+		ipConnections: make(map[string]int),
+		shutdownCh:    make(chan struct{}),
+		sessions:      make(map[*session]struct{}),
+	}
+}
+
+// Start starts listening for IMAP connections
+func (s *Server) Start() error {
+	addr := fmt.Sprintf(":%d", s.config.Port)
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to start IMAP server on %s: %w", addr, err)
+	}
+
+	s.listener = listener
+	s.running.Store(true)
+
+	log.Printf("IMAP server started on port %d", s.config.Port)
+
+	go s.acceptLoop()
+
+	return nil
+}
+
+// Stop shuts the server down, waiting up to DefaultShutdownTimeout for sessions to say BYE
+func (s *Server) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultShutdownTimeout)
+	defer cancel()
+
+	if err := s.Shutdown(ctx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown stops accepting connections and ends every session with an untagged BYE
+// Sessions finish the command they are executing; idle and IDLE-ing sessions are woken
+// immediately. Connections still open when ctx is done are closed.
+func (s *Server) Shutdown(ctx context.Context) error {
+	if !s.running.Load() || !s.shuttingDown.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	s.running.Store(false)
+	close(s.shutdownCh)
+	if s.listener != nil {
+		s.listener.Close()
+	}
+
+	sessions := s.snapshotSessions()
+	log.Printf("IMAP server shutting down (%d sessions)", len(sessions))
+	for _, session := range sessions {
+		session.wake()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		log.Println("IMAP server stopped")
+		return nil
+	case <-ctx.Done():
+		for _, session := range s.snapshotSessions() {
+			session.rawConn.Close()
+		}
+		select {
+		case <-done:
+		case <-time.After(forceCloseGracePeriod):
+			log.Println("IMAP server shutdown timed out")
+		}
+		return ctx.Err()
+	}
+}
+
+// acceptLoop accepts incoming connections
+func (s *Server) acceptLoop() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if !s.running.Load() {
+				return
+			}
+			log.Printf("Error accepting IMAP connection: %v", err)
+			continue
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handleConnection(conn)
+		}()
+	}
+}
+
+// handleConnection enforces connection limits and runs a session
+func (s *Server) handleConnection(conn net.Conn) {
+	defer conn.Close()
+
+	if s.shuttingDown.Load() {
+		fmt.Fprintf(conn, "* BYE Server shutting down\r\n")
+		return
+	}
+
+	remoteAddr := conn.RemoteAddr().String()
+	remoteIP, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		remoteIP = remoteAddr
+	}
+
+	if !s.acquireConnection(remoteIP) {
+		log.Printf("IMAP connection from %s rejected: too many connections", remoteIP)
+		fmt.Fprintf(conn, "* BYE Too many connections\r\n")
+		return
+	}
+	defer s.releaseConnection(remoteIP)
+
+	session := newSession(s, conn, remoteIP)
+	s.trackSession(session)
+	defer s.untrackSession(session)
+	session.Run()
+}
+
+// acquireConnection reserves a global and a per-IP connection slot
+func (s *Server) acquireConnection(remoteIP string) bool {
+	s.ipConnMu.Lock()
+	defer s.ipConnMu.Unlock()
+
+	if atomic.LoadInt64(&s.activeConns) >= int64(s.config.MaxConnections) {
+		return false
+	}
+	if s.ipConnections[remoteIP] >= s.config.MaxConnectionsPerIP {
+		return false
+	}
+
+	atomic.AddInt64(&s.activeConns, 1)
+	s.ipConnections[remoteIP]++
+	return true
+}
+
+// releaseConnection frees the slots reserved by acquireConnection
+func (s *Server) releaseConnection(remoteIP string) {
+	s.ipConnMu.Lock()
+	defer s.ipConnMu.Unlock()
+
+	atomic.AddInt64(&s.activeConns, -1)
+	s.ipConnections[remoteIP]--
+	if s.ipConnections[remoteIP] <= 0 {
+		delete(s.ipConnections, remoteIP)
+	}
+}
+
+// GetActiveConnections returns the number of open IMAP connections
+func (s *Server) GetActiveConnections() int64 {
+	return atomic.LoadInt64(&s.activeConns)
+}
+
+// trackSession registers a running session so shutdown can reach it
+func (s *Server) trackSession(session *session) {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	s.sessions[session] = struct{}{}
+}
+
+// untrackSession removes a finished session
+func (s *Server) untrackSession(session *session) {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	delete(s.sessions, session)
+}
+
+// snapshotSessions returns the currently running sessions
+func (s *Server) snapshotSessions() []*session {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	sessions := make([]*session, 0, len(s.sessions))
+	for session := range s.sessions {
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
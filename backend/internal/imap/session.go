@@ -0,0 +1,1076 @@
+package imap
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/events"
+)
+
+// MaxAuthFailures is the number of failed LOGIN/AUTHENTICATE attempts before the session is closed
+const MaxAuthFailures = 3
+
+// Session timeouts
+const (
+	writeTimeout     = 30 * time.Second
+	handshakeTimeout = 30 * time.Second
+	storeTimeout     = 30 * time.Second
+)
+
+// idleEventBuffer is the number of pending bus events kept per IDLE; extra events are
+// dropped because one rescan covers any number of them
+const idleEventBuffer = 16
+
+// sessionMessage is a message in the selected mailbox; \Deleted lives only in the session
+type sessionMessage struct {
+	MessageInfo
+	deleted bool
+}
+
+// selectedMailbox is the state of the selected mailbox
+type selectedMailbox struct {
+	info     MailboxInfo
+	readOnly bool
+	messages []*sessionMessage
+}
+
+// maxUID returns the highest UID in the mailbox, or 0 when it is empty
+func (m *selectedMailbox) maxUID() uint32 {
+	if len(m.messages) == 0 {
+		return 0
+	}
+	return m.messages[len(m.messages)-1].UID
+}
+
+// session is a single IMAP connection
+type session struct {
+	server   *Server
+	rawConn  net.Conn
+	conn     net.Conn // rawConn, or its TLS wrapper after STARTTLS
+	reader   *bufio.Reader
+	writer   *bufio.Writer
+	remoteIP string
+
+	tlsEnabled   bool
+	userID       string
+	authFailures int
+	mailbox      *selectedMailbox
+}
+
+// newSession creates a session for an accepted connection
+func newSession(server *Server, conn net.Conn, remoteIP string) *session {
+	return &session{
+		server:   server,
+		rawConn:  conn,
+		conn:     conn,
+		reader:   bufio.NewReader(conn),
+		writer:   bufio.NewWriter(conn),
+		remoteIP: remoteIP,
+	}
+}
+
+// wake interrupts a blocking read so the session notices shutdown
+func (s *session) wake() {
+	s.rawConn.SetReadDeadline(time.Now())
+}
+
+// Run greets the client and processes commands until LOGOUT, error or shutdown
+func (s *session) Run() {
+	s.writeLine(fmt.Sprintf("* OK [CAPABILITY %s] %s IMAP4rev1 service ready", s.capabilities(), s.server.config.Hostname))
+	if !s.flush() {
+		return
+	}
+
+	for {
+		s.rawConn.SetReadDeadline(time.Now().Add(s.server.config.IdleTimeout))
+		if s.server.shuttingDown.Load() {
+			s.bye("Server shutting down")
+			return
+		}
+
+		line, err := readCommand(s.reader, func() {
+			s.writeLine("+ Ready for literal data")
+			s.flush()
+		})
+		if err != nil {
+			s.handleReadError(err)
+			return
+		}
+
+		cmd, err := parseCommand(line)
+		if err != nil {
+			tag := cmd.tag
+			if tag == "" {
+				tag = "*"
+			}
+			s.writeLine(tag + " BAD Syntax error")
+			if !s.flush() {
+				return
+			}
+			continue
+		}
+
+		quit := s.dispatch(cmd)
+		if !s.flush() || quit {
+			return
+		}
+	}
+}
+
+// handleReadError says goodbye when the read failed for a reason the client should hear about
+func (s *session) handleReadError(err error) {
+	var netErr net.Error
+	switch {
+	case s.server.shuttingDown.Load():
+		s.bye("Server shutting down")
+	case errors.As(err, &netErr) && netErr.Timeout():
+		s.bye("Autologout; idle for too long")
+	case errors.Is(err, errLineTooLong), errors.Is(err, errLiteralTooLong):
+		s.bye("Command too long")
+	}
+}
+
+// bye sends an untagged BYE; the connection is closed by the caller
+func (s *session) bye(text string) {
+	s.writeLine("* BYE " + text)
+	s.flush()
+}
+
+// writeLine buffers a response line
+func (s *session) writeLine(line string) {
+	s.writer.WriteString(line)
+	s.writer.WriteString("\r\n")
+}
+
+// flush sends buffered responses, returning false when the connection is gone
+func (s *session) flush() bool {
+	s.rawConn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return s.writer.Flush() == nil
+}
+
+// ok, no and bad send tagged completion responses
+func (s *session) ok(tag, text string)  { s.writeLine(tag + " OK " + text) }
+func (s *session) no(tag, text string)  { s.writeLine(tag + " NO " + text) }
+func (s *session) bad(tag, text string) { s.writeLine(tag + " BAD " + text) }
+
+// capabilities returns the capability list for the current state
+func (s *session) capabilities() string {
+	caps := []string{"IMAP4rev1", "LITERAL+", "IDLE", "UNSELECT"}
+	switch {
+	case !s.tlsEnabled:
+		caps = append(caps, "STARTTLS", "LOGINDISABLED")
+	case s.userID == "":
+		caps = append(caps, "AUTH=PLAIN")
+	}
+	return strings.Join(caps, " ")
+}
+
+// storeContext returns a context for store calls
+func (s *session) storeContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), storeTimeout)
+}
+
+// dispatch runs a command; it returns true when the session must end
+func (s *session) dispatch(cmd *command) bool {
+	switch cmd.name {
+	case "CAPABILITY":
+		s.writeLine("* CAPABILITY " + s.capabilities())
+		s.ok(cmd.tag, "CAPABILITY completed")
+		return false
+	case "NOOP", "CHECK":
+		if s.mailbox != nil {
+			s.refresh()
+		}
+		s.ok(cmd.tag, cmd.name+" completed")
+		return false
+	case "LOGOUT":
+		s.writeLine("* BYE Logging out")
+		s.ok(cmd.tag, "LOGOUT completed")
+		return true
+	}
+
+	// Not authenticated state
+	switch cmd.name {
+	case "STARTTLS", "LOGIN", "AUTHENTICATE":
+		if s.userID != "" {
+			s.bad(cmd.tag, "Already authenticated")
+			return false
+		}
+		switch cmd.name {
+		case "STARTTLS":
+			return s.handleSTARTTLS(cmd)
+		case "LOGIN":
+			return s.handleLOGIN(cmd)
+		default:
+			return s.handleAUTHENTICATE(cmd)
+		}
+	}
+
+	if s.userID == "" {
+		s.bad(cmd.tag, "Command not valid in this state")
+		return false
+	}
+
+	// Authenticated state
+	switch cmd.name {
+	case "SELECT", "EXAMINE":
+		s.handleSELECT(cmd, cmd.name == "EXAMINE")
+		return false
+	case "LIST", "LSUB":
+		s.handleLIST(cmd)
+		return false
+	case "STATUS":
+		s.handleSTATUS(cmd)
+		return false
+	case "SUBSCRIBE", "UNSUBSCRIBE":
+		// Every alias mailbox is always subscribed
+		s.ok(cmd.tag, cmd.name+" completed")
+		return false
+	case "CREATE", "DELETE", "RENAME":
+		s.no(cmd.tag, "[CANNOT] Mailboxes are managed through aliases")
+		return false
+	case "APPEND":
+		s.no(cmd.tag, "[CANNOT] Mailboxes only receive mail through SMTP")
+		return false
+	case "IDLE":
+		return s.handleIDLE(cmd)
+	}
+
+	if s.mailbox == nil {
+		s.bad(cmd.tag, "No mailbox selected")
+		return false
+	}
+
+	// Selected state
+	switch cmd.name {
+	case "CLOSE":
+		if !s.mailbox.readOnly {
+			s.expunge(false)
+		}
+		s.mailbox = nil
+		s.ok(cmd.tag, "CLOSE completed")
+	case "UNSELECT":
+		s.mailbox = nil
+		s.ok(cmd.tag, "UNSELECT completed")
+	case "EXPUNGE":
+		s.handleEXPUNGE(cmd)
+	case "FETCH":
+		s.handleFETCH(cmd.tag, cmd.args, false)
+	case "STORE":
+		s.handleSTORE(cmd.tag, cmd.args, false)
+	case "SEARCH":
+		s.handleSEARCH(cmd.tag, cmd.args, false)
+	case "COPY", "MOVE":
+		s.no(cmd.tag, "[CANNOT] Messages cannot be copied between aliases")
+	case "UID":
+		s.handleUID(cmd)
+	default:
+		s.bad(cmd.tag, "Unknown command")
+	}
+	return false
+}
+
+// handleSTARTTLS upgrades the connection to TLS
+func (s *session) handleSTARTTLS(cmd *command) bool {
+	if s.tlsEnabled {
+		s.bad(cmd.tag, "TLS already active")
+		return false
+	}
+	if s.server.tlsConfig == nil {
+		s.no(cmd.tag, "TLS not available")
+		return false
+	}
+	// Commands pipelined after STARTTLS would be processed as if they were protected
+	if s.reader.Buffered() > 0 {
+		s.bad(cmd.tag, "Unexpected data after STARTTLS")
+		return true
+	}
+
+	s.ok(cmd.tag, "Begin TLS negotiation now")
+	if !s.flush() {
+		return true
+	}
+
+	tlsConn := tls.Server(s.rawConn, s.server.tlsConfig)
+	s.rawConn.SetDeadline(time.Now().Add(handshakeTimeout))
+	if err := tlsConn.Handshake(); err != nil {
+		log.Printf("IMAP TLS handshake with %s failed: %v", s.remoteIP, err)
+		return true
+	}
+	s.rawConn.SetDeadline(time.Time{})
+
+	s.conn = tlsConn
+	s.reader = bufio.NewReader(tlsConn)
+	s.writer = bufio.NewWriter(tlsConn)
+	s.tlsEnabled = true
+	return false
+}
+
+// handleLOGIN authenticates with a username and an app password or access token
+func (s *session) handleLOGIN(cmd *command) bool {
+	if len(cmd.args) != 2 {
+		s.bad(cmd.tag, "LOGIN expects username and password")
+		return false
+	}
+	if !s.tlsEnabled {
+		s.no(cmd.tag, "[PRIVACYREQUIRED] Run STARTTLS first")
+		return false
+	}
+	username, err1 := argValue(cmd.args[0])
+	password, err2 := argValue(cmd.args[1])
+	if err1 != nil || err2 != nil {
+		s.bad(cmd.tag, "LOGIN expects username and password")
+		return false
+	}
+	return s.authenticate(cmd.tag, username, password)
+}
+
+// handleAUTHENTICATE implements AUTHENTICATE PLAIN, with or without an initial response
+func (s *session) handleAUTHENTICATE(cmd *command) bool {
+	if len(cmd.args) < 1 || len(cmd.args) > 2 {
+		s.bad(cmd.tag, "AUTHENTICATE expects a mechanism")
+		return false
+	}
+	if !s.tlsEnabled {
+		s.no(cmd.tag, "[PRIVACYREQUIRED] Run STARTTLS first")
+		return false
+	}
+	if !strings.EqualFold(cmd.args[0].value, "PLAIN") {
+		s.no(cmd.tag, "Unsupported authentication mechanism")
+		return false
+	}
+
+	var response string
+	if len(cmd.args) == 2 {
+		response = cmd.args[1].value
+	} else {
+		s.writeLine("+ ")
+		if !s.flush() {
+			return true
+		}
+		line, err := readLine(s.reader)
+		if err != nil {
+			return true
+		}
+		response = strings.TrimSpace(line)
+	}
+
+	if response == "*" {
+		s.bad(cmd.tag, "Authentication cancelled")
+		return false
+	}
+	if response == "=" {
+		response = ""
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(response)
+	if err != nil {
+		s.bad(cmd.tag, "Cannot decode response")
+		return false
+	}
+
+	// authzid NUL authcid NUL passwd; a differing authorization identity is not supported
+	parts := strings.Split(string(decoded), "\x00")
+	if len(parts) != 3 || (parts[0] != "" && !strings.EqualFold(parts[0], parts[1])) {
+		s.bad(cmd.tag, "Invalid PLAIN credentials")
+		return false
+	}
+	return s.authenticate(cmd.tag, parts[1], parts[2])
+}
+
+// authenticate verifies credentials; repeated failures end the session
+func (s *session) authenticate(tag, username, password string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, err := s.server.authenticator.Authenticate(ctx, username, password, s.remoteIP)
+	if err != nil {
+		s.authFailures++
+		log.Printf("IMAP login failed for %q from %s: %v", username, s.remoteIP, err)
+		if s.authFailures >= MaxAuthFailures {
+			s.writeLine("* BYE Too many authentication failures")
+			return true
+		}
+		s.no(tag, "[AUTHENTICATIONFAILED] Authentication failed")
+		return false
+	}
+
+	s.userID = userID
+	s.ok(tag, fmt.Sprintf("[CAPABILITY %s] Logged in", s.capabilities()))
+	return false
+}
+
+// resolveMailbox finds a mailbox by name; INBOX is case-insensitive and so are alias addresses
+func (s *session) resolveMailbox(name string) (MailboxInfo, error) {
+	if strings.EqualFold(name, InboxName) {
+		return MailboxInfo{Name: InboxName}, nil
+	}
+
+	ctx, cancel := s.storeContext()
+	defer cancel()
+
+	mailboxes, err := s.server.store.ListMailboxes(ctx, s.userID)
+	if err != nil {
+		return MailboxInfo{}, err
+	}
+	for _, mailbox := range mailboxes {
+		if strings.EqualFold(mailbox.Name, name) {
+			return mailbox, nil
+		}
+	}
+	return MailboxInfo{}, ErrMailboxNotFound
+}
+
+// loadMessages lists the messages of a mailbox
+func (s *session) loadMessages(info MailboxInfo) ([]*sessionMessage, error) {
+	ctx, cancel := s.storeContext()
+	defer cancel()
+
+	infos, err := s.server.store.ListMessages(ctx, s.userID, info.AliasID)
+	if err != nil {
+		return nil, err
+	}
+	messages := make([]*sessionMessage, len(infos))
+	for i := range infos {
+		messages[i] = &sessionMessage{MessageInfo: infos[i]}
+	}
+	return messages, nil
+}
+
+// handleSELECT selects a mailbox read-write (SELECT) or read-only (EXAMINE)
+func (s *session) handleSELECT(cmd *command, readOnly bool) {
+	// A failed SELECT still leaves no mailbox selected
+	s.mailbox = nil
+
+	if len(cmd.args) != 1 {
+		s.bad(cmd.tag, cmd.name+" expects a mailbox name")
+		return
+	}
+	name, _ := argValue(cmd.args[0])
+
+	info, err := s.resolveMailbox(name)
+	if err != nil {
+		s.mailboxError(cmd.tag, err)
+		return
+	}
+	messages, err := s.loadMessages(info)
+	if err != nil {
+		s.mailboxError(cmd.tag, err)
+		return
+	}
+
+	mailbox := &selectedMailbox{info: info, readOnly: readOnly, messages: messages}
+	s.writeLine(`* FLAGS (\Seen \Deleted)`)
+	if readOnly {
+		s.writeLine("* OK [PERMANENTFLAGS ()] Read-only mailbox")
+	} else {
+		s.writeLine(`* OK [PERMANENTFLAGS (\Seen \Deleted)] Limited`)
+	}
+	s.writeLine(fmt.Sprintf("* %d EXISTS", len(messages)))
+	s.writeLine("* 0 RECENT")
+	for i, msg := range messages {
+		if !msg.Seen {
+			s.writeLine(fmt.Sprintf("* OK [UNSEEN %d] First unseen", i+1))
+			break
+		}
+	}
+	s.writeLine(fmt.Sprintf("* OK [UIDVALIDITY %d] UIDs valid", UIDValidity))
+	s.writeLine(fmt.Sprintf("* OK [UIDNEXT %d] Predicted next UID", mailbox.maxUID()+1))
+
+	s.mailbox = mailbox
+	if readOnly {
+		s.ok(cmd.tag, "[READ-ONLY] EXAMINE completed")
+	} else {
+		s.ok(cmd.tag, "[READ-WRITE] SELECT completed")
+	}
+}
+
+// mailboxError reports a mailbox lookup failure
+func (s *session) mailboxError(tag string, err error) {
+	if errors.Is(err, ErrMailboxNotFound) {
+		s.no(tag, "[NONEXISTENT] Mailbox does not exist")
+		return
+	}
+	log.Printf("IMAP mailbox lookup failed for user %s: %v", s.userID, err)
+	s.no(tag, "[SERVERBUG] Mailbox temporarily unavailable")
+}
+
+// handleLIST lists INBOX and the alias mailboxes matching the pattern
+func (s *session) handleLIST(cmd *command) {
+	if len(cmd.args) != 2 {
+		s.bad(cmd.tag, cmd.name+" expects a reference and a mailbox pattern")
+		return
+	}
+	reference, err1 := argValue(cmd.args[0])
+	pattern, err2 := argValue(cmd.args[1])
+	if err1 != nil || err2 != nil {
+		s.bad(cmd.tag, cmd.name+" expects a reference and a mailbox pattern")
+		return
+	}
+
+	if pattern == "" {
+		// Hierarchy delimiter discovery
+		s.writeLine(fmt.Sprintf(`* %s (\Noselect) "%s" ""`, cmd.name, HierarchyDelimiter))
+		s.ok(cmd.tag, cmd.name+" completed")
+		return
+	}
+
+	ctx, cancel := s.storeContext()
+	defer cancel()
+	mailboxes, err := s.server.store.ListMailboxes(ctx, s.userID)
+	if err != nil {
+		s.mailboxError(cmd.tag, err)
+		return
+	}
+
+	names := []string{InboxName}
+	for _, mailbox := range mailboxes {
+		names = append(names, mailbox.Name)
+	}
+	for _, name := range names {
+		if matchMailbox(reference+pattern, name) {
+			s.writeLine(fmt.Sprintf(`* %s (\HasNoChildren) "%s" %s`, cmd.name, HierarchyDelimiter, quoteString(name)))
+		}
+	}
+	s.ok(cmd.tag, cmd.name+" completed")
+}
+
+// matchMailbox matches a LIST pattern where * and % match any run of characters
+// (% stops at the hierarchy delimiter, which alias names never contain)
+func matchMailbox(pattern, name string) bool {
+	pattern, name = strings.ToLower(pattern), strings.ToLower(name)
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*', '%':
+			rest := pattern[1:]
+			for i := 0; i <= len(name); i++ {
+				if pattern[0] == '%' && i > 0 && name[i-1] == HierarchyDelimiter[0] {
+					return false
+				}
+				if matchMailbox(rest, name[i:]) {
+					return true
+				}
+			}
+			return false
+		default:
+			if len(name) == 0 || name[0] != pattern[0] {
+				return false
+			}
+			pattern, name = pattern[1:], name[1:]
+		}
+	}
+	return len(name) == 0
+}
+
+// handleSTATUS reports mailbox counters without selecting it
+func (s *session) handleSTATUS(cmd *command) {
+	if len(cmd.args) != 2 || cmd.args[1].kind != argList {
+		s.bad(cmd.tag, "STATUS expects a mailbox name and a list of items")
+		return
+	}
+	name, _ := argValue(cmd.args[0])
+
+	info, err := s.resolveMailbox(name)
+	if err != nil {
+		s.mailboxError(cmd.tag, err)
+		return
+	}
+	messages, err := s.loadMessages(info)
+	if err != nil {
+		s.mailboxError(cmd.tag, err)
+		return
+	}
+
+	var unseen int
+	var maxUID uint32
+	for _, msg := range messages {
+		if !msg.Seen {
+			unseen++
+		}
+		maxUID = msg.UID
+	}
+
+	var items []string
+	for _, item := range cmd.args[1].list {
+		switch strings.ToUpper(item.value) {
+		case "MESSAGES":
+			items = append(items, fmt.Sprintf("MESSAGES %d", len(messages)))
+		case "RECENT":
+			items = append(items, "RECENT 0")
+		case "UIDNEXT":
+			items = append(items, fmt.Sprintf("UIDNEXT %d", maxUID+1))
+		case "UIDVALIDITY":
+			items = append(items, fmt.Sprintf("UIDVALIDITY %d", UIDValidity))
+		case "UNSEEN":
+			items = append(items, fmt.Sprintf("UNSEEN %d", unseen))
+		default:
+			s.bad(cmd.tag, "Unknown STATUS item")
+			return
+		}
+	}
+
+	s.writeLine(fmt.Sprintf("* STATUS %s (%s)", quoteString(info.Name), strings.Join(items, " ")))
+	s.ok(cmd.tag, "STATUS completed")
+}
+
+// targets returns the messages addressed by a sequence set, with their sequence numbers
+func (s *session) targets(set seqSet, uid bool) ([]*sessionMessage, []uint32) {
+	messages := s.mailbox.messages
+	maxSeq := uint32(len(messages))
+	maxUID := s.mailbox.maxUID()
+
+	var targets []*sessionMessage
+	var seqNums []uint32
+	for i, msg := range messages {
+		seqNum := uint32(i + 1)
+		if (uid && set.contains(msg.UID, maxUID)) || (!uid && set.contains(seqNum, maxSeq)) {
+			targets = append(targets, msg)
+			seqNums = append(seqNums, seqNum)
+		}
+	}
+	return targets, seqNums
+}
+
+// rawLoader returns a loader for a message's raw_email
+func (s *session) rawLoader(msg *sessionMessage) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		ctx, cancel := s.storeContext()
+		defer cancel()
+		return s.server.store.GetRawMessage(ctx, s.userID, msg.ID)
+	}
+}
+
+// handleFETCH implements FETCH and UID FETCH
+func (s *session) handleFETCH(tag string, args []arg, uid bool) {
+	if len(args) != 2 || args[0].kind != argAtom {
+		s.bad(tag, "FETCH expects a sequence set and data items")
+		return
+	}
+	set, err := parseSeqSet(args[0].value)
+	if err != nil {
+		s.bad(tag, "Invalid sequence set")
+		return
+	}
+	items, err := parseFetchItems(args[1])
+	if err != nil {
+		s.bad(tag, "Invalid FETCH data items")
+		return
+	}
+
+	targets, seqNums := s.targets(set, uid)
+
+	// Fetching a body section marks the message \Seen, like opening it in the web UI
+	markSeen := false
+	hasFlags := false
+	for _, item := range items {
+		markSeen = markSeen || item.setsSeen()
+		hasFlags = hasFlags || item.name == "FLAGS"
+	}
+	newlySeen := map[*sessionMessage]bool{}
+	if markSeen && !s.mailbox.readOnly {
+		var ids []string
+		for _, msg := range targets {
+			if !msg.Seen {
+				ids = append(ids, msg.ID)
+				newlySeen[msg] = true
+			}
+		}
+		if len(ids) > 0 {
+			ctx, cancel := s.storeContext()
+			err := s.server.store.SetSeen(ctx, s.userID, ids, true)
+			cancel()
+			if err != nil {
+				log.Printf("IMAP failed to set \\Seen for user %s: %v", s.userID, err)
+				s.no(tag, "[SERVERBUG] Failed to update flags")
+				return
+			}
+			for msg := range newlySeen {
+				msg.Seen = true
+			}
+		}
+	}
+
+	missing := false
+	for i, msg := range targets {
+		msgItems := items
+		if newlySeen[msg] && !hasFlags {
+			msgItems = append(append([]fetchItem(nil), items...), fetchItem{name: "FLAGS"})
+		}
+
+		response, err := fetchResponse(msg, msgItems, uid, s.rawLoader(msg))
+		if errors.Is(err, ErrMessageNotFound) {
+			// Deleted through the API; the EXPUNGE is reported on the next NOOP or IDLE
+			missing = true
+			continue
+		}
+		if err != nil {
+			log.Printf("IMAP FETCH failed for message %s: %v", msg.ID, err)
+			s.no(tag, "[SERVERBUG] FETCH failed")
+			return
+		}
+		s.writeLine(fmt.Sprintf("* %d FETCH (%s)", seqNums[i], response))
+	}
+
+	if missing {
+		s.no(tag, "Some messages were deleted and could not be fetched")
+		return
+	}
+	s.ok(tag, "FETCH completed")
+}
+
+// handleSTORE implements STORE and UID STORE for \Seen and \Deleted
+// \Seen is persisted as is_read; \Deleted is kept in the session until EXPUNGE or CLOSE
+func (s *session) handleSTORE(tag string, args []arg, uid bool) {
+	if len(args) < 3 || args[0].kind != argAtom || args[1].kind != argAtom {
+		s.bad(tag, "STORE expects a sequence set, an action and flags")
+		return
+	}
+	if s.mailbox.readOnly {
+		s.no(tag, "[READ-ONLY] Mailbox is read-only")
+		return
+	}
+	set, err := parseSeqSet(args[0].value)
+	if err != nil {
+		s.bad(tag, "Invalid sequence set")
+		return
+	}
+
+	action := strings.ToUpper(args[1].value)
+	silent := strings.HasSuffix(action, ".SILENT")
+	action = strings.TrimSuffix(action, ".SILENT")
+	if action != "FLAGS" && action != "+FLAGS" && action != "-FLAGS" {
+		s.bad(tag, "Invalid STORE action")
+		return
+	}
+
+	flagArgs := args[2:]
+	if len(flagArgs) == 1 && flagArgs[0].kind == argList {
+		flagArgs = flagArgs[0].list
+	}
+	var seenFlag, deletedFlag bool
+	for _, flag := range flagArgs {
+		// Other flags and keywords are not permanent and are ignored
+		switch strings.ToLower(flag.value) {
+		case `\seen`:
+			seenFlag = true
+		case `\deleted`:
+			deletedFlag = true
+		}
+	}
+
+	targets, seqNums := s.targets(set, uid)
+
+	var markRead, markUnread []string
+	seen := make([]bool, len(targets))
+	deleted := make([]bool, len(targets))
+	for i, msg := range targets {
+		switch action {
+		case "FLAGS":
+			seen[i], deleted[i] = seenFlag, deletedFlag
+		case "+FLAGS":
+			seen[i], deleted[i] = msg.Seen || seenFlag, msg.deleted || deletedFlag
+		case "-FLAGS":
+			seen[i], deleted[i] = msg.Seen && !seenFlag, msg.deleted && !deletedFlag
+		}
+		if seen[i] != msg.Seen {
+			if seen[i] {
+				markRead = append(markRead, msg.ID)
+			} else {
+				markUnread = append(markUnread, msg.ID)
+			}
+		}
+	}
+
+	ctx, cancel := s.storeContext()
+	defer cancel()
+	for _, change := range []struct {
+		ids  []string
+		seen bool
+	}{{markRead, true}, {markUnread, false}} {
+		if len(change.ids) == 0 {
+			continue
+		}
+		if err := s.server.store.SetSeen(ctx, s.userID, change.ids, change.seen); err != nil {
+			log.Printf("IMAP failed to store flags for user %s: %v", s.userID, err)
+			s.no(tag, "[SERVERBUG] Failed to update flags")
+			return
+		}
+	}
+
+	for i, msg := range targets {
+		msg.Seen, msg.deleted = seen[i], deleted[i]
+		if silent {
+			continue
+		}
+		if uid {
+			s.writeLine(fmt.Sprintf("* %d FETCH (UID %d FLAGS %s)", seqNums[i], msg.UID, formatFlags(msg)))
+		} else {
+			s.writeLine(fmt.Sprintf("* %d FETCH (FLAGS %s)", seqNums[i], formatFlags(msg)))
+		}
+	}
+	s.ok(tag, "STORE completed")
+}
+
+// handleSEARCH implements SEARCH and UID SEARCH
+func (s *session) handleSEARCH(tag string, args []arg, uid bool) {
+	key, charsetOK, err := parseSearch(args)
+	if !charsetOK {
+		s.no(tag, "[BADCHARSET (US-ASCII UTF-8)] Unsupported charset")
+		return
+	}
+	if err != nil {
+		s.bad(tag, "Invalid search criteria")
+		return
+	}
+
+	messages := s.mailbox.messages
+	needsBody := key.needsBody()
+	var results []string
+	for i, msg := range messages {
+		ctx := &searchContext{
+			msg:    msg,
+			seqNum: uint32(i + 1),
+			maxSeq: uint32(len(messages)),
+			maxUID: s.mailbox.maxUID(),
+		}
+		if needsBody {
+			raw, err := s.rawLoader(msg)()
+			if errors.Is(err, ErrMessageNotFound) {
+				continue
+			}
+			if err != nil {
+				log.Printf("IMAP SEARCH failed for message %s: %v", msg.ID, err)
+				s.no(tag, "[SERVERBUG] SEARCH failed")
+				return
+			}
+			ctx.root = parseEntity(raw, 0)
+		}
+		if key.matches(ctx) {
+			if uid {
+				results = append(results, strconv.FormatUint(uint64(msg.UID), 10))
+			} else {
+				results = append(results, strconv.Itoa(i+1))
+			}
+		}
+	}
+
+	if len(results) == 0 {
+		s.writeLine("* SEARCH")
+	} else {
+		s.writeLine("* SEARCH " + strings.Join(results, " "))
+	}
+	s.ok(tag, "SEARCH completed")
+}
+
+// handleUID dispatches UID FETCH, UID STORE and UID SEARCH
+func (s *session) handleUID(cmd *command) {
+	if len(cmd.args) == 0 || cmd.args[0].kind != argAtom {
+		s.bad(cmd.tag, "UID expects a command")
+		return
+	}
+	args := cmd.args[1:]
+	switch strings.ToUpper(cmd.args[0].value) {
+	case "FETCH":
+		s.handleFETCH(cmd.tag, args, true)
+	case "STORE":
+		s.handleSTORE(cmd.tag, args, true)
+	case "SEARCH":
+		s.handleSEARCH(cmd.tag, args, true)
+	case "COPY", "MOVE":
+		s.no(cmd.tag, "[CANNOT] Messages cannot be copied between aliases")
+	default:
+		s.bad(cmd.tag, "Unknown UID command")
+	}
+}
+
+// handleEXPUNGE moves messages flagged \Deleted to the trash
+func (s *session) handleEXPUNGE(cmd *command) {
+	if s.mailbox.readOnly {
+		s.no(cmd.tag, "[READ-ONLY] Mailbox is read-only")
+		return
+	}
+	if !s.expunge(true) {
+		s.no(cmd.tag, "[SERVERBUG] Some messages could not be deleted")
+		return
+	}
+	s.ok(cmd.tag, "EXPUNGE completed")
+}
+
+// expunge deletes \Deleted messages through the store, highest sequence number first so
+// each reported EXPUNGE stays valid; it returns false when a deletion failed
+func (s *session) expunge(report bool) bool {
+	ctx, cancel := s.storeContext()
+	defer cancel()
+
+	success := true
+	messages := s.mailbox.messages
+	for i := len(messages) - 1; i >= 0; i-- {
+		msg := messages[i]
+		if !msg.deleted {
+			continue
+		}
+		err := s.server.store.DeleteMessage(ctx, s.userID, msg.ID)
+		if err != nil && !errors.Is(err, ErrMessageNotFound) {
+			log.Printf("IMAP failed to delete message %s: %v", msg.ID, err)
+			success = false
+			continue
+		}
+		messages = append(messages[:i], messages[i+1:]...)
+		if report {
+			s.writeLine(fmt.Sprintf("* %d EXPUNGE", i+1))
+		}
+	}
+	s.mailbox.messages = messages
+	return success
+}
+
+// refresh rescans the selected mailbox and reports expunged, changed and new messages
+func (s *session) refresh() {
+	current, err := s.loadMessages(s.mailbox.info)
+	if err != nil {
+		log.Printf("IMAP mailbox refresh failed for user %s: %v", s.userID, err)
+		return
+	}
+
+	byUID := make(map[uint32]*sessionMessage, len(current))
+	for _, msg := range current {
+		byUID[msg.UID] = msg
+	}
+
+	// Messages deleted elsewhere (web UI, API, another client)
+	messages := s.mailbox.messages
+	for i := len(messages) - 1; i >= 0; i-- {
+		if _, ok := byUID[messages[i].UID]; !ok {
+			messages = append(messages[:i], messages[i+1:]...)
+			s.writeLine(fmt.Sprintf("* %d EXPUNGE", i+1))
+		}
+	}
+
+	// \Seen changed elsewhere
+	for i, msg := range messages {
+		if latest := byUID[msg.UID]; latest.Seen != msg.Seen {
+			msg.Seen = latest.Seen
+			s.writeLine(fmt.Sprintf("* %d FETCH (FLAGS %s)", i+1, formatFlags(msg)))
+		}
+	}
+
+	// New mail; UIDs must stay ascending, so a message committed out of UID order
+	// only shows up after the mailbox is selected again
+	maxUID := s.mailbox.maxUID()
+	added := false
+	for _, msg := range current {
+		if msg.UID > maxUID {
+			messages = append(messages, msg)
+			added = true
+		}
+	}
+	s.mailbox.messages = messages
+	if added {
+		s.writeLine(fmt.Sprintf("* %d EXISTS", len(messages)))
+	}
+}
+
+// idleEventData is the part of email event payloads used to filter alias mailboxes
+type idleEventData struct {
+	AliasID string `json:"alias_id"`
+}
+
+// relevant reports whether an event may change the selected mailbox
+func (s *session) relevant(event events.Event) bool {
+	if s.mailbox == nil {
+		return false
+	}
+	if event.Type != events.EventTypeNewEmail && event.Type != events.EventTypeEmailDeleted {
+		return false
+	}
+	if s.mailbox.info.AliasID == "" {
+		return true
+	}
+	var data idleEventData
+	if err := json.Unmarshal(event.Data, &data); err != nil || data.AliasID == "" {
+		return true
+	}
+	return data.AliasID == s.mailbox.info.AliasID
+}
+
+// idleLine is a line read from the client while idling
+type idleLine struct {
+	line string
+	err  error
+}
+
+// handleIDLE implements IDLE (RFC 2177): mailbox changes are pushed until the client sends DONE
+// The events bus is in-memory, so it only carries mail received and deleted in this process; changes
+// made through the API server are picked up by rescanning the mailbox every IdlePollInterval.
+func (s *session) handleIDLE(cmd *command) bool {
+	eventCh := make(chan events.Event, idleEventBuffer)
+	if s.server.subscriber != nil {
+		unsubscribe := s.server.subscriber.Subscribe(s.userID, func(event events.Event) {
+			// Never block the bus; a single pending event is enough to trigger a rescan
+			select {
+			case eventCh <- event:
+			default:
+			}
+		})
+		defer unsubscribe()
+	}
+
+	s.writeLine("+ idling")
+	if !s.flush() {
+		return true
+	}
+
+	lineCh := make(chan idleLine, 1)
+	go func() {
+		line, err := readLine(s.reader)
+		lineCh <- idleLine{line: line, err: err}
+	}()
+
+	poll := time.NewTicker(s.server.config.IdlePollInterval)
+	defer poll.Stop()
+
+	for {
+		select {
+		case event := <-eventCh:
+			if !s.relevant(event) {
+				continue
+			}
+			s.refresh()
+			if !s.flush() {
+				return true
+			}
+		case <-poll.C:
+			if s.mailbox == nil {
+				continue
+			}
+			s.refresh()
+			if !s.flush() {
+				return true
+			}
+		case <-s.server.shutdownCh:
+			s.bye("Server shutting down")
+			return true
+		case result := <-lineCh:
+			if result.err != nil {
+				s.handleReadError(result.err)
+				return true
+			}
+			if !strings.EqualFold(strings.TrimSpace(result.line), "DONE") {
+				s.bad(cmd.tag, "Expected DONE")
+				return false
+			}
+			s.ok(cmd.tag, "IDLE terminated")
+			return false
+		}
+	}
+}
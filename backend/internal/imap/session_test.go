@@ -0,0 +1,429 @@
+package imap
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/events"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/smtp"
+)
+
+// mockStore is an in-memory Store for user-1 with two aliases
+type mockStore struct {
+	mu        sync.Mutex
+	mailboxes []MailboxInfo
+	messages  []MessageInfo
+	raw       map[string][]byte
+	deleted   []string
+}
+
+func newMockStore() *mockStore {
+	store := &mockStore{
+		mailboxes: []MailboxInfo{
+			{Name: "me@webrana.id", AliasID: "alias-1"},
+			{Name: "shop@webrana.id", AliasID: "alias-2"},
+		},
+		raw: make(map[string][]byte),
+	}
+	store.add("email-1", 10, "alias-1", "From: Alice <alice@example.com>\r\nSubject: Hello\r\n\r\nfirst body\r\n")
+	store.add("email-2", 11, "alias-2", "From: Shop <orders@shop.example>\r\nSubject: Your order\r\n\r\norder 1234 shipped\r\n")
+	store.add("email-3", 12, "alias-1", testMultipartMessage)
+	return store
+}
+
+func (m *mockStore) add(id string, uid uint32, aliasID, raw string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, MessageInfo{
+		ID:           id,
+		UID:          uid,
+		AliasID:      aliasID,
+		Size:         int64(len(raw)),
+		InternalDate: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+	})
+	m.raw[id] = []byte(raw)
+}
+
+func (m *mockStore) ListMailboxes(ctx context.Context, userID string) ([]MailboxInfo, error) {
+	return m.mailboxes, nil
+}
+
+func (m *mockStore) ListMessages(ctx context.Context, userID, aliasID string) ([]MessageInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []MessageInfo
+	for _, msg := range m.messages {
+		if aliasID == "" || msg.AliasID == aliasID {
+			result = append(result, msg)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockStore) GetRawMessage(ctx context.Context, userID, emailID string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	raw, ok := m.raw[emailID]
+	if !ok {
+		return nil, ErrMessageNotFound
+	}
+	return raw, nil
+}
+
+func (m *mockStore) SetSeen(ctx context.Context, userID string, emailIDs []string, seen bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, id := range emailIDs {
+		for i := range m.messages {
+			if m.messages[i].ID == id {
+				m.messages[i].Seen = seen
+			}
+		}
+	}
+	return nil
+}
+
+func (m *mockStore) DeleteMessage(ctx context.Context, userID, emailID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.messages {
+		if m.messages[i].ID == emailID {
+			m.messages = append(m.messages[:i], m.messages[i+1:]...)
+			delete(m.raw, emailID)
+			m.deleted = append(m.deleted, emailID)
+			return nil
+		}
+	}
+	return ErrMessageNotFound
+}
+
+func (m *mockStore) isSeen(emailID string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, msg := range m.messages {
+		if msg.ID == emailID {
+			return msg.Seen
+		}
+	}
+	return false
+}
+
+// mockAuthenticator accepts a single username/password pair
+type mockAuthenticator struct{}
+
+func (mockAuthenticator) Authenticate(ctx context.Context, username, password, remoteIP string) (string, error) {
+	if strings.EqualFold(username, "owner@example.com") && password == "abcd-efgh-ijkl-mnop" {
+		return "user-1", nil
+	}
+	return "", errors.New("invalid credentials")
+}
+
+func startTestServer(t *testing.T, configure ...func(*Config)) (*Server, string, *mockStore, *events.InMemoryEventBus) {
+	t.Helper()
+	certPath, keyPath, err := smtp.GenerateSelfSignedCert("mail.test.local", t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to generate certificate: %v", err)
+	}
+	tlsConfig, err := smtp.LoadTLSConfig(certPath, keyPath)
+	if err != nil {
+		t.Fatalf("Failed to load TLS config: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find available port: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	config := DefaultConfig()
+	config.Port = port
+	config.Hostname = "mail.test.local"
+	for _, fn := range configure {
+		fn(config)
+	}
+
+	store := newMockStore()
+	bus := events.NewEventBus(events.NewEventStore(10))
+	server := NewServer(config, tlsConfig, store, mockAuthenticator{}, bus)
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	t.Cleanup(func() { server.Stop() })
+
+	return server, fmt.Sprintf("127.0.0.1:%d", port), store, bus
+}
+
+// testClient is a minimal IMAP client
+type testClient struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+	tag    int
+}
+
+func dialTestClient(t *testing.T, addr string) *testClient {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	t.Cleanup(func() { conn.Close() })
+	c := &testClient{t: t, conn: conn, reader: bufio.NewReader(conn)}
+	if greeting := c.readLine(); !strings.HasPrefix(greeting, "* OK [CAPABILITY") {
+		t.Fatalf("Unexpected greeting %q", greeting)
+	}
+	return c
+}
+
+func (c *testClient) readLine() string {
+	c.t.Helper()
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		c.t.Fatalf("Failed to read response: %v", err)
+	}
+	return strings.TrimRight(line, "\r\n")
+}
+
+// cmd sends a tagged command and returns all untagged output and the tagged completion
+func (c *testClient) cmd(command, expectStatus string) (string, string) {
+	c.t.Helper()
+	c.tag++
+	tag := fmt.Sprintf("a%d", c.tag)
+	fmt.Fprintf(c.conn, "%s %s\r\n", tag, command)
+
+	var output []string
+	for {
+		line := c.readLine()
+		if strings.HasPrefix(line, tag+" ") {
+			status := strings.TrimPrefix(line, tag+" ")
+			if !strings.HasPrefix(status, expectStatus) {
+				c.t.Fatalf("%s: expected %s, got %q\n%s", command, expectStatus, status, strings.Join(output, "\n"))
+			}
+			return strings.Join(output, "\n"), status
+		}
+		output = append(output, line)
+	}
+}
+
+// login upgrades to TLS and logs in
+func (c *testClient) login() {
+	c.t.Helper()
+	c.cmd("STARTTLS", "OK")
+	tlsConn := tls.Client(c.conn, &tls.Config{InsecureSkipVerify: true, MinVersion: tls.VersionTLS12})
+	if err := tlsConn.Handshake(); err != nil {
+		c.t.Fatalf("TLS handshake failed: %v", err)
+	}
+	c.conn = tlsConn
+	c.reader = bufio.NewReader(tlsConn)
+	c.cmd(`LOGIN owner@example.com "abcd-efgh-ijkl-mnop"`, "OK")
+}
+
+// TestIMAP_LoginRequiresTLS verifies credentials are never accepted in plaintext
+func TestIMAP_LoginRequiresTLS(t *testing.T) {
+	_, addr, _, _ := startTestServer(t)
+	c := dialTestClient(t, addr)
+
+	capabilities, _ := c.cmd("CAPABILITY", "OK")
+	if !strings.Contains(capabilities, "STARTTLS") || !strings.Contains(capabilities, "LOGINDISABLED") {
+		t.Fatalf("Expected STARTTLS and LOGINDISABLED before TLS: %s", capabilities)
+	}
+	c.cmd(`LOGIN owner@example.com "abcd-efgh-ijkl-mnop"`, "NO [PRIVACYREQUIRED]")
+	c.cmd("SELECT INBOX", "BAD")
+}
+
+// TestIMAP_TooManyAuthFailures verifies the session is closed after repeated failures
+func TestIMAP_TooManyAuthFailures(t *testing.T) {
+	_, addr, _, _ := startTestServer(t)
+	c := dialTestClient(t, addr)
+	c.cmd("STARTTLS", "OK")
+	tlsConn := tls.Client(c.conn, &tls.Config{InsecureSkipVerify: true, MinVersion: tls.VersionTLS12})
+	if err := tlsConn.Handshake(); err != nil {
+		t.Fatalf("TLS handshake failed: %v", err)
+	}
+	c.conn, c.reader = tlsConn, bufio.NewReader(tlsConn)
+
+	if capabilities, _ := c.cmd("CAPABILITY", "OK"); !strings.Contains(capabilities, "AUTH=PLAIN") || strings.Contains(capabilities, "LOGINDISABLED") {
+		t.Fatalf("Unexpected capabilities after STARTTLS: %s", capabilities)
+	}
+	for i := 1; i < MaxAuthFailures; i++ {
+		c.cmd("LOGIN owner@example.com wrong", "NO [AUTHENTICATIONFAILED]")
+	}
+	fmt.Fprintf(c.conn, "a9 LOGIN owner@example.com wrong\r\n")
+	if line := c.readLine(); !strings.HasPrefix(line, "* BYE") {
+		t.Fatalf("Expected BYE after too many failures, got %q", line)
+	}
+}
+
+// TestIMAP_MailboxFlow verifies LIST, SELECT, FETCH, SEARCH, STORE and EXPUNGE against the store
+func TestIMAP_MailboxFlow(t *testing.T) {
+	_, addr, store, _ := startTestServer(t)
+	c := dialTestClient(t, addr)
+	c.login()
+
+	list, _ := c.cmd(`LIST "" "*"`, "OK")
+	for _, want := range []string{`"/" "INBOX"`, `"/" "me@webrana.id"`, `"/" "shop@webrana.id"`} {
+		if !strings.Contains(list, want) {
+			t.Errorf("LIST missing %s:\n%s", want, list)
+		}
+	}
+	if status, _ := c.cmd(`STATUS "shop@webrana.id" (MESSAGES UNSEEN UIDNEXT)`, "OK"); !strings.Contains(status, "(MESSAGES 1 UNSEEN 1 UIDNEXT 12)") {
+		t.Errorf("Unexpected STATUS: %s", status)
+	}
+	c.cmd(`SELECT "nobody@webrana.id"`, "NO [NONEXISTENT]")
+
+	// One mailbox per alias
+	selected, _ := c.cmd(`SELECT "ME@webrana.id"`, "OK [READ-WRITE]")
+	for _, want := range []string{"* 2 EXISTS", "[UIDVALIDITY 1]", "[UIDNEXT 13]", "[UNSEEN 1]"} {
+		if !strings.Contains(selected, want) {
+			t.Errorf("SELECT missing %s:\n%s", want, selected)
+		}
+	}
+
+	// BODY.PEEK leaves the message unread
+	peek, _ := c.cmd("FETCH 1 (UID FLAGS BODY.PEEK[HEADER.FIELDS (SUBJECT)])", "OK")
+	if !strings.Contains(peek, "* 1 FETCH (UID 10 FLAGS () BODY[HEADER.FIELDS (SUBJECT)] {18}") {
+		t.Errorf("Unexpected FETCH response:\n%s", peek)
+	}
+	if store.isSeen("email-1") {
+		t.Fatal("BODY.PEEK must not set \\Seen")
+	}
+
+	// FETCH BODY[] returns raw_email and sets \Seen (is_read)
+	full, _ := c.cmd("UID FETCH 12 (BODY[])", "OK")
+	if !strings.Contains(full, fmt.Sprintf("* 2 FETCH (UID 12 BODY[] {%d}", len(testMultipartMessage))) || !strings.Contains(full, `FLAGS (\Seen))`) {
+		t.Errorf("Unexpected UID FETCH response:\n%s", full)
+	}
+	if !store.isSeen("email-3") {
+		t.Fatal("Expected BODY[] to set is_read")
+	}
+
+	if results, _ := c.cmd("SEARCH UNSEEN", "OK"); results != "* SEARCH 1" {
+		t.Errorf("Unexpected SEARCH UNSEEN: %q", results)
+	}
+	if results, _ := c.cmd(`UID SEARCH BODY "code is 4217" FROM alice`, "OK"); results != "* SEARCH 12" {
+		t.Errorf("Unexpected UID SEARCH: %q", results)
+	}
+	if results, _ := c.cmd(`SEARCH OR SUBJECT hello SUBJECT invoice NOT SEEN`, "OK"); results != "* SEARCH 1" {
+		t.Errorf("Unexpected SEARCH OR/NOT: %q", results)
+	}
+	c.cmd("SEARCH CHARSET KOI8-R ALL", "NO [BADCHARSET")
+
+	// \Seen maps to is_read; \Deleted + EXPUNGE deletes the email
+	if stored, _ := c.cmd(`STORE 1 +FLAGS (\Seen \Deleted)`, "OK"); stored != `* 1 FETCH (FLAGS (\Seen \Deleted))` {
+		t.Errorf("Unexpected STORE response: %q", stored)
+	}
+	if !store.isSeen("email-1") {
+		t.Fatal("Expected \\Seen to set is_read")
+	}
+	c.cmd(`STORE 2 -FLAGS.SILENT (\Seen)`, "OK")
+	if store.isSeen("email-3") {
+		t.Fatal("Expected -FLAGS \\Seen to clear is_read")
+	}
+
+	if expunged, _ := c.cmd("EXPUNGE", "OK"); expunged != "* 1 EXPUNGE" {
+		t.Errorf("Unexpected EXPUNGE response: %q", expunged)
+	}
+	if len(store.deleted) != 1 || store.deleted[0] != "email-1" {
+		t.Fatalf("Expected email-1 to be deleted, got %v", store.deleted)
+	}
+
+	// EXAMINE is read-only
+	c.cmd("EXAMINE INBOX", "OK [READ-ONLY]")
+	c.cmd(`STORE 1 +FLAGS (\Deleted)`, "NO")
+	c.cmd("LOGOUT", "OK")
+}
+
+// TestIMAP_IdleNewMail verifies IDLE pushes new mail published on the events bus
+func TestIMAP_IdleNewMail(t *testing.T) {
+	server, addr, store, bus := startTestServer(t)
+	c := dialTestClient(t, addr)
+	c.login()
+	c.cmd(`SELECT "shop@webrana.id"`, "OK")
+
+	fmt.Fprintf(c.conn, "a10 IDLE\r\n")
+	if line := c.readLine(); !strings.HasPrefix(line, "+ ") {
+		t.Fatalf("Expected IDLE continuation, got %q", line)
+	}
+	waitForSubscriber(t, bus, "user-1")
+
+	publish := func(aliasID string) {
+		data, _ := json.Marshal(map[string]string{"alias_id": aliasID})
+		bus.Publish(events.Event{ID: aliasID, Type: events.EventTypeNewEmail, UserID: "user-1", Data: data, Timestamp: time.Now()})
+	}
+
+	// Mail for another alias does not wake this mailbox; mail for it does
+	store.add("email-4", 13, "alias-1", "Subject: other\r\n\r\nx\r\n")
+	publish("alias-1")
+	store.add("email-5", 14, "alias-2", "Subject: new order\r\n\r\ny\r\n")
+	publish("alias-2")
+
+	if line := c.readLine(); line != "* 2 EXISTS" {
+		t.Fatalf("Expected EXISTS for new mail, got %q", line)
+	}
+
+	fmt.Fprintf(c.conn, "DONE\r\n")
+	if line := c.readLine(); !strings.HasPrefix(line, "a10 OK") {
+		t.Fatalf("Expected IDLE to complete, got %q", line)
+	}
+	if fetched, _ := c.cmd("UID FETCH 14 (RFC822.SIZE)", "OK"); !strings.Contains(fetched, "* 2 FETCH (UID 14 RFC822.SIZE 25)") {
+		t.Fatalf("Unexpected FETCH of new mail: %s", fetched)
+	}
+
+	// Shutdown ends an IDLE with BYE
+	fmt.Fprintf(c.conn, "a11 IDLE\r\n")
+	c.readLine()
+	waitForSubscriber(t, bus, "user-1")
+	go server.Stop()
+	if line := c.readLine(); !strings.HasPrefix(line, "* BYE") {
+		t.Fatalf("Expected BYE on shutdown, got %q", line)
+	}
+}
+
+// TestIMAP_IdlePollsForChangesOffTheBus verifies IDLE reports mail that was never published on the
+// events bus, as for changes made through the API server in another process
+func TestIMAP_IdlePollsForChangesOffTheBus(t *testing.T) {
+	_, addr, store, _ := startTestServer(t, func(config *Config) {
+		config.IdlePollInterval = 20 * time.Millisecond
+	})
+	c := dialTestClient(t, addr)
+	c.login()
+	c.cmd(`SELECT "shop@webrana.id"`, "OK")
+
+	fmt.Fprintf(c.conn, "a10 IDLE\r\n")
+	if line := c.readLine(); !strings.HasPrefix(line, "+ ") {
+		t.Fatalf("Expected IDLE continuation, got %q", line)
+	}
+
+	store.add("email-5", 14, "alias-2", "Subject: new order\r\n\r\ny\r\n")
+	if line := c.readLine(); line != "* 2 EXISTS" {
+		t.Fatalf("Expected EXISTS from the periodic rescan, got %q", line)
+	}
+
+	fmt.Fprintf(c.conn, "DONE\r\n")
+	if line := c.readLine(); !strings.HasPrefix(line, "a10 OK") {
+		t.Fatalf("Expected IDLE to complete, got %q", line)
+	}
+}
+
+// waitForSubscriber waits until the IDLE subscription is registered
+func waitForSubscriber(t *testing.T, bus *events.InMemoryEventBus, userID string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for bus.SubscriberCount(userID) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("IDLE did not subscribe to the events bus")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
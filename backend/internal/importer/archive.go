@@ -0,0 +1,258 @@
+package importer
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"path"
+	"strings"
+	"time"
+)
+
+// zipMagic starts every zip archive
+var zipMagic = []byte("PK\x03\x04")
+
+// sourceMessage is one message read from an uploaded archive
+type sourceMessage struct {
+	Raw          []byte    // Message with CRLF line endings, empty when Oversized
+	Oversized    bool      // The message is larger than the import limit and was not kept
+	EnvelopeDate time.Time // Date of the mbox "From " line, zero when unknown
+	Name         string    // Entry name for zip archives
+}
+
+// messageSource reads the messages of an archive in a stable order
+type messageSource interface {
+	// Next returns the next message, or io.EOF after the last one
+	Next() (*sourceMessage, error)
+	// Total returns the number of messages, or -1 when it is only known at the end
+	Total() int
+}
+
+// detectFormat identifies an archive from its first bytes
+func detectFormat(head []byte) (Format, error) {
+	switch {
+	case bytes.HasPrefix(head, zipMagic):
+		return FormatZip, nil
+	case bytes.HasPrefix(bytes.TrimLeft(head, "\r\n"), []byte("From ")):
+		return FormatMbox, nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+}
+
+// mboxReader splits an mbox file into messages
+// A message starts at a "From " line at the start of the file or after a blank line. Lines quoted
+// as ">From ", ">>From " and so on lose one ">" (mboxrd), and the blank line before each separator
+// is dropped.
+type mboxReader struct {
+	r       *bufio.Reader
+	maxSize int64
+	next    []byte // Separator line of the next message, nil at the end
+	err     error
+}
+
+func newMboxReader(r io.Reader, maxSize int64) (*mboxReader, error) {
+	m := &mboxReader{r: bufio.NewReaderSize(r, 64*1024), maxSize: maxSize}
+	for {
+		line, err := m.readLine()
+		if len(bytes.TrimSpace(line)) > 0 {
+			if !bytes.HasPrefix(line, []byte("From ")) {
+				return nil, ErrUnsupportedFormat
+			}
+			m.next = line
+			return m, nil
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				// An empty mbox has no messages
+				return m, nil
+			}
+			return nil, err
+		}
+	}
+}
+
+// readLine returns the next line without its line ending
+func (m *mboxReader) readLine() ([]byte, error) {
+	line, err := m.r.ReadBytes('\n')
+	line = bytes.TrimSuffix(bytes.TrimSuffix(line, []byte("\n")), []byte("\r"))
+	if err != nil && len(line) > 0 && errors.Is(err, io.EOF) {
+		// Last line without a line ending
+		return line, nil
+	}
+	return line, err
+}
+
+func (m *mboxReader) Total() int {
+	return -1
+}
+
+func (m *mboxReader) Next() (*sourceMessage, error) {
+	if m.next == nil {
+		if m.err != nil {
+			return nil, m.err
+		}
+		return nil, io.EOF
+	}
+
+	msg := &sourceMessage{EnvelopeDate: envelopeDate(m.next)}
+	m.next = nil
+
+	var buf bytes.Buffer
+	blankLines := 0 // Blank lines not written yet: they end the message if a separator follows
+	for {
+		line, err := m.readLine()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				m.err = err
+				return nil, err
+			}
+			break
+		}
+
+		if len(line) == 0 {
+			blankLines++
+			continue
+		}
+		if blankLines > 0 && bytes.HasPrefix(line, []byte("From ")) {
+			m.next = line
+			blankLines--
+		}
+		for ; blankLines > 0; blankLines-- {
+			msg.write(&buf, nil, m.maxSize)
+		}
+		if m.next != nil {
+			break
+		}
+
+		if bytes.HasPrefix(bytes.TrimLeft(line, ">"), []byte("From ")) && line[0] == '>' {
+			line = line[1:]
+		}
+		msg.write(&buf, line, m.maxSize)
+	}
+
+	if !msg.Oversized {
+		msg.Raw = buf.Bytes()
+	}
+	return msg, nil
+}
+
+// write appends a line with a CRLF ending, dropping the message once it exceeds maxSize
+func (msg *sourceMessage) write(buf *bytes.Buffer, line []byte, maxSize int64) {
+	if msg.Oversized {
+		return
+	}
+	if int64(buf.Len()+len(line)+2) > maxSize {
+		msg.Oversized = true
+		buf.Reset()
+		return
+	}
+	buf.Write(line)
+	buf.WriteString("\r\n")
+}
+
+// envelopeDate parses the date of an mbox "From sender date" line, returning zero when it has none
+func envelopeDate(separator []byte) time.Time {
+	fields := strings.Fields(string(separator))
+	if len(fields) < 7 {
+		return time.Time{}
+	}
+	date, err := time.Parse(time.ANSIC, strings.Join(fields[2:7], " "))
+	if err != nil {
+		return time.Time{}
+	}
+	return date.UTC()
+}
+
+// zipSource reads the .eml files of a zip archive in archive order
+// Other files, directories and macOS metadata are ignored.
+type zipSource struct {
+	files   []*zip.File
+	pos     int
+	maxSize int64
+}
+
+func newZipSource(r io.ReaderAt, size int64, maxSize int64) (*zipSource, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptArchive, err)
+	}
+
+	s := &zipSource{maxSize: maxSize}
+	for _, f := range zr.File {
+		name := f.Name
+		if f.FileInfo().IsDir() || !strings.EqualFold(path.Ext(name), ".eml") ||
+			strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(path.Base(name), "._") {
+			continue
+		}
+		s.files = append(s.files, f)
+	}
+	return s, nil
+}
+
+func (s *zipSource) Total() int {
+	return len(s.files)
+}
+
+func (s *zipSource) Next() (*sourceMessage, error) {
+	if s.pos >= len(s.files) {
+		return nil, io.EOF
+	}
+	f := s.files[s.pos]
+	s.pos++
+
+	msg := &sourceMessage{Name: f.Name}
+	if f.UncompressedSize64 > uint64(s.maxSize) {
+		msg.Oversized = true
+		return msg, nil
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrCorruptArchive, f.Name, err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, s.maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrCorruptArchive, f.Name, err)
+	}
+	if int64(len(data)) > s.maxSize {
+		msg.Oversized = true
+		return msg, nil
+	}
+	msg.Raw = toCRLF(data)
+	return msg, nil
+}
+
+// toCRLF normalizes the line endings of a message to CRLF, as received mail is stored
+func toCRLF(data []byte) []byte {
+	if !bytes.Contains(data, []byte("\n")) {
+		return data
+	}
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	return bytes.ReplaceAll(data, []byte("\n"), []byte("\r\n"))
+}
+
+// messageState returns when an archived message was received and whether it was read
+// The mbox envelope date is preferred as it records delivery, then the Date header, then now;
+// an mbox Status header containing R marks the message read.
+func messageState(msg *sourceMessage, now time.Time) (time.Time, bool) {
+	receivedAt := msg.EnvelopeDate
+	isRead := false
+
+	if parsed, err := mail.ReadMessage(bytes.NewReader(msg.Raw)); err == nil {
+		isRead = strings.Contains(parsed.Header.Get("Status"), "R")
+		if date, err := parsed.Header.Date(); err == nil && receivedAt.IsZero() {
+			receivedAt = date.UTC()
+		}
+	}
+	if receivedAt.IsZero() {
+		receivedAt = now
+	}
+	return receivedAt, isRead
+}
@@ -0,0 +1,183 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readAll(t *testing.T, source messageSource) []*sourceMessage {
+	t.Helper()
+	var messages []*sourceMessage
+	for {
+		msg, err := source.Next()
+		if errors.Is(err, io.EOF) {
+			return messages
+		}
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		messages = append(messages, msg)
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		head    string
+		want    Format
+		wantErr bool
+	}{
+		{"PK\x03\x04", FormatZip, false},
+		{"From alice@example.org", FormatMbox, false},
+		{"\r\nFrom alice", FormatMbox, false},
+		{"Subject: Hi", "", true},
+		{"%PDF", "", true},
+	}
+	for _, tt := range tests {
+		got, err := detectFormat([]byte(tt.head))
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("detectFormat(%q) = %q, %v; want %q, error %v", tt.head, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestMboxReader_SplitsAndUnquotes(t *testing.T) {
+	mbox := "From alice@example.org Tue Mar  5 09:07:01 2024\n" +
+		"Subject: One\n" +
+		"\n" +
+		">From here on\n" +
+		">>From quoted\n" +
+		"From inside a paragraph\n" +
+		"\n" +
+		"\n" +
+		"From bob@example.org Wed Mar  6 10:00:00 2024\n" +
+		"Subject: Two\n" +
+		"\n" +
+		"Body\n"
+
+	r, err := newMboxReader(strings.NewReader(mbox), 1<<20)
+	if err != nil {
+		t.Fatalf("newMboxReader() error = %v", err)
+	}
+	messages := readAll(t, r)
+	if len(messages) != 2 {
+		t.Fatalf("messages = %d, want 2", len(messages))
+	}
+
+	wantFirst := "Subject: One\r\n\r\nFrom here on\r\n>From quoted\r\nFrom inside a paragraph\r\n\r\n"
+	if got := string(messages[0].Raw); got != wantFirst {
+		t.Errorf("first message = %q, want %q", got, wantFirst)
+	}
+	if got := string(messages[1].Raw); got != "Subject: Two\r\n\r\nBody\r\n" {
+		t.Errorf("second message = %q", got)
+	}
+	if want := time.Date(2024, 3, 5, 9, 7, 1, 0, time.UTC); !messages[0].EnvelopeDate.Equal(want) {
+		t.Errorf("envelope date = %v, want %v", messages[0].EnvelopeDate, want)
+	}
+}
+
+func TestMboxReader_Oversized(t *testing.T) {
+	mbox := "From a Tue Mar  5 09:07:01 2024\nSubject: Big\n\n" + strings.Repeat("x", 200) + "\n\n" +
+		"From b Tue Mar  5 09:07:01 2024\nSubject: Small\n\nok\n"
+
+	r, err := newMboxReader(strings.NewReader(mbox), 100)
+	if err != nil {
+		t.Fatalf("newMboxReader() error = %v", err)
+	}
+	messages := readAll(t, r)
+	if len(messages) != 2 {
+		t.Fatalf("messages = %d, want 2", len(messages))
+	}
+	if !messages[0].Oversized || messages[0].Raw != nil {
+		t.Errorf("first message should be oversized without content")
+	}
+	if messages[1].Oversized {
+		t.Errorf("second message should not be oversized")
+	}
+}
+
+func TestMboxReader_RejectsNonMbox(t *testing.T) {
+	if _, err := newMboxReader(strings.NewReader("Subject: Hi\n\nBody\n"), 1<<20); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("newMboxReader() error = %v, want ErrUnsupportedFormat", err)
+	}
+}
+
+func TestZipSource_ReadsEmlFiles(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"inbox/one.eml":          "Subject: One\n\nBody\n",
+		"inbox/TWO.EML":          "Subject: Two\r\n\r\nBody\r\n",
+		"inbox/notes.txt":        "not mail",
+		"__MACOSX/inbox/one.eml": "metadata",
+		"inbox/._one.eml":        "metadata",
+	} {
+		f, _ := zw.Create(name)
+		f.Write([]byte(content))
+	}
+	zw.Close()
+
+	source, err := newZipSource(bytes.NewReader(buf.Bytes()), int64(buf.Len()), 1<<20)
+	if err != nil {
+		t.Fatalf("newZipSource() error = %v", err)
+	}
+	if source.Total() != 2 {
+		t.Fatalf("Total() = %d, want 2", source.Total())
+	}
+	for _, msg := range readAll(t, source) {
+		if !strings.HasSuffix(strings.ToLower(msg.Name), ".eml") || strings.Contains(msg.Name, "_") {
+			t.Errorf("unexpected entry %s", msg.Name)
+		}
+		if !bytes.HasSuffix(msg.Raw, []byte("Body\r\n")) || bytes.Contains(bytes.ReplaceAll(msg.Raw, []byte("\r\n"), nil), []byte("\n")) {
+			t.Errorf("%s = %q, want CRLF line endings", msg.Name, msg.Raw)
+		}
+	}
+}
+
+func TestZipSource_Corrupt(t *testing.T) {
+	data := []byte("PK\x03\x04 truncated")
+	if _, err := newZipSource(bytes.NewReader(data), int64(len(data)), 1<<20); !errors.Is(err, ErrCorruptArchive) {
+		t.Errorf("newZipSource() error = %v, want ErrCorruptArchive", err)
+	}
+}
+
+func TestMessageState(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	envelope := time.Date(2024, 3, 5, 9, 7, 1, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		msg      *sourceMessage
+		wantTime time.Time
+		wantRead bool
+	}{
+		{
+			name:     "envelope date and read status",
+			msg:      &sourceMessage{Raw: []byte("Date: Mon, 4 Mar 2024 08:00:00 +0000\r\nStatus: RO\r\n\r\nBody\r\n"), EnvelopeDate: envelope},
+			wantTime: envelope,
+			wantRead: true,
+		},
+		{
+			name:     "date header",
+			msg:      &sourceMessage{Raw: []byte("Date: Mon, 4 Mar 2024 10:00:00 +0200\r\nStatus: O\r\n\r\nBody\r\n")},
+			wantTime: time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "no date",
+			msg:      &sourceMessage{Raw: []byte("Subject: Hi\r\n\r\nBody\r\n")},
+			wantTime: now,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotTime, gotRead := messageState(tt.msg, now)
+			if !gotTime.Equal(tt.wantTime) || gotRead != tt.wantRead {
+				t.Errorf("messageState() = %v, %v; want %v, %v", gotTime, gotRead, tt.wantTime, tt.wantRead)
+			}
+		})
+	}
+}
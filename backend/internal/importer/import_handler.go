@@ -0,0 +1,259 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	appctx "github.com/welldanyogia/persistent-temp-mail/backend/internal/context"
+)
+
+// uploadTimeout is how long an upload request may take, instead of the server's read and write timeouts
+const uploadTimeout = 30 * time.Minute
+
+// APIResponse represents the standard API response format
+type APIResponse struct {
+	Success   bool        `json:"success"`
+	Data      interface{} `json:"data,omitempty"`
+	Error     *APIError   `json:"error,omitempty"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// APIError represents the error detail in API response
+type APIError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Handler handles HTTP requests for import endpoints
+type Handler struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+// NewHandler creates a new Handler instance
+func NewHandler(service *Service, logger *slog.Logger) *Handler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Create handles POST /api/v1/imports
+// The body is multipart/form-data with an alias_id field followed by a file field holding an mbox
+// file or a zip of .eml files; alias_id may instead be given as a query parameter. The file is
+// streamed to storage and imported in the background, with progress sent as import_progress SSE events.
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	// Large archives take longer than the server timeouts allow
+	rc := http.NewResponseController(w)
+	deadline := time.Now().Add(uploadTimeout)
+	if err := rc.SetReadDeadline(deadline); err != nil {
+		h.logger.Warn("Failed to extend upload read deadline", "error", err)
+	}
+	if err := rc.SetWriteDeadline(deadline); err != nil {
+		h.logger.Warn("Failed to extend upload write deadline", "error", err)
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "Request body must be multipart/form-data")
+		return
+	}
+
+	aliasID := r.URL.Query().Get("alias_id")
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				h.writeError(w, http.StatusBadRequest, CodeValidationError, "file is required")
+			} else {
+				h.writeError(w, http.StatusBadRequest, CodeValidationError, "Invalid multipart body")
+			}
+			return
+		}
+
+		switch part.FormName() {
+		case "alias_id":
+			value, err := io.ReadAll(io.LimitReader(part, 64))
+			if err != nil {
+				h.writeError(w, http.StatusBadRequest, CodeValidationError, "Invalid multipart body")
+				return
+			}
+			aliasID = strings.TrimSpace(string(value))
+		case "file":
+			if aliasID == "" {
+				h.writeError(w, http.StatusBadRequest, CodeValidationError, "alias_id is required before the file")
+				return
+			}
+			response, err := h.service.Create(r.Context(), userID, CreateImportRequest{
+				AliasID:  aliasID,
+				Filename: part.FileName(),
+				Body:     part,
+			})
+			if err != nil {
+				h.handleError(w, err)
+				return
+			}
+			h.writeSuccess(w, http.StatusAccepted, response)
+			return
+		}
+		part.Close()
+	}
+}
+
+// List handles GET /api/v1/imports
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.service.List(r.Context(), userID)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, response)
+}
+
+// Get handles GET /api/v1/imports/:id
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, jobID, ok := h.parseIDs(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.service.Get(r.Context(), userID, jobID)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, response)
+}
+
+// ListErrors handles GET /api/v1/imports/:id/errors
+func (h *Handler) ListErrors(w http.ResponseWriter, r *http.Request) {
+	userID, jobID, ok := h.parseIDs(w, r)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	response, err := h.service.ListErrors(r.Context(), userID, jobID, page, limit)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, response)
+}
+
+// parseUserID extracts the authenticated user ID
+func (h *Handler) parseUserID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	userIDStr, ok := appctx.ExtractUserID(r.Context())
+	if !ok {
+		h.writeError(w, http.StatusUnauthorized, CodeAuthTokenInvalid, "Invalid or expired token")
+		return uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		h.writeError(w, http.StatusUnauthorized, CodeAuthTokenInvalid, "Invalid user ID")
+		return uuid.Nil, false
+	}
+
+	return userID, true
+}
+
+// parseIDs extracts the authenticated user ID and the import ID path parameter
+func (h *Handler) parseIDs(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	userID, ok := h.parseUserID(w, r)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	jobID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "Invalid import ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, jobID, true
+}
+
+// handleError maps service errors to HTTP responses
+func (h *Handler) handleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrImportNotFound):
+		h.writeError(w, http.StatusNotFound, CodeImportNotFound, "Import not found")
+	case errors.Is(err, ErrImportInProgress):
+		h.writeError(w, http.StatusConflict, CodeImportInProgress, "An import is already in progress")
+	case errors.Is(err, ErrUnsupportedFormat):
+		h.writeError(w, http.StatusUnsupportedMediaType, CodeUnsupportedFormat, "File must be an mbox file or a zip of .eml files")
+	case errors.Is(err, ErrEmptyUpload):
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "File is empty")
+	case errors.Is(err, ErrUploadTooLarge):
+		h.writeError(w, http.StatusRequestEntityTooLarge, CodeUploadTooLarge,
+			"File exceeds the maximum size of "+strconv.FormatInt(h.service.MaxUploadSize(), 10)+" bytes")
+	case errors.Is(err, ErrAliasNotFound):
+		h.writeError(w, http.StatusNotFound, CodeAliasNotFound, "Alias not found")
+	case errors.Is(err, ErrAccessDenied):
+		h.writeError(w, http.StatusForbidden, CodeAccessDenied, "You don't have access to this alias")
+	case errors.Is(err, ErrStorageUnavailable):
+		h.writeError(w, http.StatusServiceUnavailable, CodeStorageUnavailable, "Imports are not available")
+	default:
+		h.logger.Error("Unexpected import error", "error", err)
+		h.writeError(w, http.StatusInternalServerError, CodeInternalError, "An unexpected error occurred")
+	}
+}
+
+// writeSuccess writes a successful JSON response
+func (h *Handler) writeSuccess(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := APIResponse{
+		Success:   true,
+		Data:      data,
+		Timestamp: time.Now().UTC(),
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// writeError writes an error JSON response
+func (h *Handler) writeError(w http.ResponseWriter, statusCode int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := APIResponse{
+		Success: false,
+		Error: &APIError{
+			Code:    code,
+			Message: message,
+		},
+		Timestamp: time.Now().UTC(),
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
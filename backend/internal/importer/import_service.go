@@ -0,0 +1,519 @@
+// Package importer provides background imports of mbox files and zips of .eml files into an alias
+// Feature: mailbox-import
+// Requirements: Messages run through the SMTP EmailProcessor pipeline with Message-ID dedup;
+// jobs resume after restarts and report per-message errors
+package importer
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/events"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/jobqueue"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/repository"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/smtp"
+)
+
+const (
+	// DefaultWorkers is the default number of imports processed at the same time
+	DefaultWorkers = 1
+	// DefaultMaxUploadSize is the default size limit of an uploaded archive
+	DefaultMaxUploadSize = 2 << 30 // 2 GB
+	// DefaultMaxMessageSize is the default size limit of one imported message, as for received mail
+	DefaultMaxMessageSize = 25 << 20 // 25 MB
+	// DefaultPollInterval is how often workers look for queued and stale imports
+	DefaultPollInterval = 30 * time.Second
+	// MaxListedJobs is the number of most recent imports listed
+	MaxListedJobs = 50
+	// DefaultErrorPageLimit is the default number of message errors per page
+	DefaultErrorPageLimit = 50
+	// MaxErrorPageLimit is the maximum number of message errors per page
+	MaxErrorPageLimit = 200
+	// sniffSize is how much of an upload is read to detect its format
+	sniffSize = 512
+	// maxFilenameLength limits stored upload filenames
+	maxFilenameLength = 255
+	// staleAfter is how long a running import may go without progress before it is requeued
+	staleAfter = 10 * time.Minute
+	// progressInterval is the minimum time between progress events of a running import
+	progressInterval = time.Second
+)
+
+// Format is an import archive format
+type Format string
+
+// Import formats
+const (
+	FormatMbox Format = "mbox" // mbox (mboxrd or mboxo)
+	FormatZip  Format = "zip"  // Zip of .eml files
+)
+
+// Status is the state of an import job
+type Status string
+
+// Import job statuses
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+)
+
+// Service errors
+var (
+	ErrImportNotFound     = errors.New("import not found")
+	ErrImportInProgress   = errors.New("an import is already in progress")
+	ErrUnsupportedFormat  = errors.New("file is not an mbox or a zip of .eml files")
+	ErrCorruptArchive     = errors.New("archive is corrupt")
+	ErrUploadTooLarge     = errors.New("upload is too large")
+	ErrEmptyUpload        = errors.New("upload is empty")
+	ErrAliasNotFound      = errors.New("alias not found")
+	ErrAccessDenied       = errors.New("access denied")
+	ErrStorageUnavailable = errors.New("storage is not configured")
+)
+
+// Error codes for API responses
+const (
+	CodeValidationError    = "VALIDATION_ERROR"
+	CodeImportNotFound     = "IMPORT_NOT_FOUND"
+	CodeImportInProgress   = "IMPORT_IN_PROGRESS"
+	CodeUnsupportedFormat  = "UNSUPPORTED_FORMAT"
+	CodeUploadTooLarge     = "UPLOAD_TOO_LARGE"
+	CodeAliasNotFound      = "ALIAS_NOT_FOUND"
+	CodeAccessDenied       = "RESOURCE_ACCESS_DENIED"
+	CodeStorageUnavailable = "STORAGE_UNAVAILABLE"
+	CodeInternalError      = "INTERNAL_ERROR"
+	CodeAuthTokenInvalid   = "AUTH_TOKEN_INVALID"
+)
+
+// Job is a stored import job
+type Job struct {
+	ID                uuid.UUID
+	UserID            uuid.UUID
+	AliasID           uuid.UUID
+	Format            Format
+	Status            Status
+	Filename          string
+	StorageKey        string
+	SizeBytes         int64
+	TotalMessages     *int
+	ProcessedMessages int
+	ImportedMessages  int
+	DuplicateMessages int
+	FailedMessages    int
+	Error             string
+	CreatedAt         time.Time
+	StartedAt         *time.Time
+	CompletedAt       *time.Time
+}
+
+// MessageError records a message of an import that could not be stored
+type MessageError struct {
+	Index     int       `json:"index"`                // Position of the message in the archive, from 0
+	Name      string    `json:"name,omitempty"`       // Entry name in zip archives
+	MessageID string    `json:"message_id,omitempty"` // Message-ID header, when it could be read
+	Error     string    `json:"error"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateImportRequest describes an uploaded archive to import
+type CreateImportRequest struct {
+	AliasID  string
+	Filename string
+	Body     io.Reader
+}
+
+// JobResponse represents an import job in API responses
+type JobResponse struct {
+	ID                string     `json:"id"`
+	AliasID           string     `json:"alias_id"`
+	Format            Format     `json:"format"`
+	Status            Status     `json:"status"`
+	Filename          string     `json:"filename"`
+	SizeBytes         int64      `json:"size_bytes"`
+	TotalMessages     *int       `json:"total_messages,omitempty"` // Omitted until known
+	ProcessedMessages int        `json:"processed_messages"`
+	ImportedMessages  int        `json:"imported_messages"`
+	DuplicateMessages int        `json:"duplicate_messages"`
+	FailedMessages    int        `json:"failed_messages"`
+	Error             string     `json:"error,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	StartedAt         *time.Time `json:"started_at,omitempty"`
+	CompletedAt       *time.Time `json:"completed_at,omitempty"`
+}
+
+// JobListResponse represents the most recent import jobs of a user
+type JobListResponse struct {
+	Imports []JobResponse `json:"imports"`
+}
+
+// ErrorListResponse represents a page of message errors of an import
+type ErrorListResponse struct {
+	Errors     []MessageError `json:"errors"`
+	Pagination Pagination     `json:"pagination"`
+}
+
+// Pagination represents pagination metadata
+type Pagination struct {
+	CurrentPage int `json:"current_page"`
+	PerPage     int `json:"per_page"`
+	TotalPages  int `json:"total_pages"`
+	TotalCount  int `json:"total_count"`
+}
+
+// Progress holds the message counters of a running import
+type Progress struct {
+	Processed  int
+	Imported   int
+	Duplicates int
+	Failed     int
+}
+
+// Repository defines import job data access
+type Repository interface {
+	// HasActive reports whether the user has an import pending or running
+	HasActive(ctx context.Context, userID uuid.UUID) (bool, error)
+	// Create stores a pending job, or returns ErrImportInProgress when the user has one pending or running
+	Create(ctx context.Context, job *Job) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Job, error)
+	ListByUser(ctx context.Context, userID uuid.UUID, limit int) ([]Job, error)
+	ListErrors(ctx context.Context, jobID uuid.UUID, page, limit int) ([]MessageError, int, error)
+	// ClaimNext marks the oldest pending job running and returns it, or nil when there is none
+	ClaimNext(ctx context.Context) (*Job, error)
+	SetTotal(ctx context.Context, id uuid.UUID, total int) error
+	// RecordMessage saves the counters after a message, with its error if it failed, in one transaction
+	RecordMessage(ctx context.Context, id uuid.UUID, progress Progress, msgErr *MessageError) error
+	Complete(ctx context.Context, id uuid.UUID, total int) error
+	Fail(ctx context.Context, id uuid.UUID, message string) error
+	// RequeueStale returns running jobs without progress since before to pending; they resume where they stopped
+	RequeueStale(ctx context.Context, before time.Time) (int, error)
+}
+
+// Processor stores one imported message; it is implemented by smtp.EmailProcessor
+type Processor interface {
+	ImportEmail(ctx context.Context, data *smtp.DataResult, aliasID uuid.UUID, isRead bool) (string, error)
+}
+
+// AliasRepository defines the alias lookup used for ownership checks
+type AliasRepository interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*repository.AliasWithStats, error)
+}
+
+// ObjectStore defines the object storage operations used for uploaded archives
+type ObjectStore interface {
+	UploadStream(ctx context.Context, key, contentType string, r io.Reader) (int64, error)
+	GetObject(ctx context.Context, key string) (io.ReadCloser, error)
+	DeleteObject(ctx context.Context, key string) error
+}
+
+// Service handles import jobs and runs the workers that process them
+type Service struct {
+	repo           Repository
+	processor      Processor
+	aliasRepo      AliasRepository
+	storage        ObjectStore
+	eventBus       events.EventBus
+	workers        int
+	maxUploadSize  int64
+	maxMessageSize int64
+	tempDir        string
+	logger         *slog.Logger
+
+	runner *jobqueue.Runner[Job]
+}
+
+// ServiceConfig holds configuration for the import service
+type ServiceConfig struct {
+	Repository     Repository
+	Processor      Processor
+	AliasRepo      AliasRepository
+	Storage        ObjectStore // nil disables imports
+	EventBus       events.EventBus
+	Workers        int
+	MaxUploadSize  int64
+	MaxMessageSize int64
+	PollInterval   time.Duration
+	TempDir        string // Where zip archives are downloaded for reading; os.TempDir() when empty
+	Logger         *slog.Logger
+}
+
+// NewService creates a new import service
+func NewService(cfg ServiceConfig) *Service {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = DefaultWorkers
+	}
+	if cfg.MaxUploadSize <= 0 {
+		cfg.MaxUploadSize = DefaultMaxUploadSize
+	}
+	if cfg.MaxMessageSize <= 0 {
+		cfg.MaxMessageSize = DefaultMaxMessageSize
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = DefaultPollInterval
+	}
+	s := &Service{
+		repo:           cfg.Repository,
+		processor:      cfg.Processor,
+		aliasRepo:      cfg.AliasRepo,
+		storage:        cfg.Storage,
+		eventBus:       cfg.EventBus,
+		workers:        cfg.Workers,
+		maxUploadSize:  cfg.MaxUploadSize,
+		maxMessageSize: cfg.MaxMessageSize,
+		tempDir:        cfg.TempDir,
+		logger:         logger,
+	}
+	s.runner = jobqueue.NewRunner(jobqueue.Config[Job]{
+		Name:         "import",
+		Queue:        cfg.Repository,
+		Run:          s.runJob,
+		Workers:      cfg.Workers,
+		PollInterval: cfg.PollInterval,
+		StaleAfter:   staleAfter,
+		Logger:       logger,
+	})
+	return s
+}
+
+// MaxUploadSize returns the size limit of an uploaded archive
+func (s *Service) MaxUploadSize() int64 {
+	return s.maxUploadSize
+}
+
+// Create stores an uploaded archive and queues its import into an alias of the user
+// The archive is streamed to object storage; its format is detected from its first bytes.
+func (s *Service) Create(ctx context.Context, userID uuid.UUID, req CreateImportRequest) (*JobResponse, error) {
+	if s.storage == nil {
+		return nil, ErrStorageUnavailable
+	}
+
+	aliasID, err := s.checkAlias(ctx, userID, req.AliasID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Refuse early rather than after a long upload; Create checks again atomically
+	active, err := s.repo.HasActive(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check active imports: %w", err)
+	}
+	if active {
+		return nil, ErrImportInProgress
+	}
+
+	body := bufio.NewReader(&limitedReader{r: req.Body, remaining: s.maxUploadSize})
+	head, err := body.Peek(sniffSize)
+	if len(head) == 0 {
+		if errors.Is(err, ErrUploadTooLarge) {
+			return nil, err
+		}
+		return nil, ErrEmptyUpload
+	}
+	format, err := detectFormat(head)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &Job{
+		ID:       uuid.New(),
+		UserID:   userID,
+		AliasID:  aliasID,
+		Format:   format,
+		Status:   StatusPending,
+		Filename: cleanFilename(req.Filename, format),
+	}
+	job.StorageKey = fmt.Sprintf("imports/%s/%s.%s", userID, job.ID, format)
+
+	size, err := s.storage.UploadStream(ctx, job.StorageKey, contentType(format), body)
+	if err != nil {
+		if errors.Is(err, ErrUploadTooLarge) {
+			return nil, ErrUploadTooLarge
+		}
+		return nil, fmt.Errorf("failed to store upload: %w", err)
+	}
+	job.SizeBytes = size
+
+	if err := s.repo.Create(ctx, job); err != nil {
+		s.deleteUpload(job)
+		if errors.Is(err, ErrImportInProgress) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to create import: %w", err)
+	}
+
+	s.runner.Wake()
+
+	response := toJobResponse(job)
+	return &response, nil
+}
+
+// checkAlias parses an alias ID and verifies the user owns the alias
+func (s *Service) checkAlias(ctx context.Context, userID uuid.UUID, aliasIDStr string) (uuid.UUID, error) {
+	aliasID, err := uuid.Parse(aliasIDStr)
+	if err != nil {
+		return uuid.Nil, ErrAliasNotFound
+	}
+	alias, err := s.aliasRepo.GetByID(ctx, aliasID)
+	if err != nil {
+		if errors.Is(err, repository.ErrAliasNotFound) {
+			return uuid.Nil, ErrAliasNotFound
+		}
+		return uuid.Nil, fmt.Errorf("failed to get alias: %w", err)
+	}
+	if alias.UserID != userID {
+		return uuid.Nil, ErrAccessDenied
+	}
+	return aliasID, nil
+}
+
+// Get returns an import job of the user
+func (s *Service) Get(ctx context.Context, userID, jobID uuid.UUID) (*JobResponse, error) {
+	job, err := s.getOwnedJob(ctx, userID, jobID)
+	if err != nil {
+		return nil, err
+	}
+	response := toJobResponse(job)
+	return &response, nil
+}
+
+// List returns the user's most recent import jobs, newest first
+func (s *Service) List(ctx context.Context, userID uuid.UUID) (*JobListResponse, error) {
+	jobs, err := s.repo.ListByUser(ctx, userID, MaxListedJobs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list imports: %w", err)
+	}
+
+	response := &JobListResponse{Imports: make([]JobResponse, len(jobs))}
+	for i := range jobs {
+		response.Imports[i] = toJobResponse(&jobs[i])
+	}
+	return response, nil
+}
+
+// ListErrors returns a page of the messages of an import that could not be stored, in archive order
+func (s *Service) ListErrors(ctx context.Context, userID, jobID uuid.UUID, page, limit int) (*ErrorListResponse, error) {
+	if _, err := s.getOwnedJob(ctx, userID, jobID); err != nil {
+		return nil, err
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = DefaultErrorPageLimit
+	}
+	if limit > MaxErrorPageLimit {
+		limit = MaxErrorPageLimit
+	}
+
+	msgErrors, total, err := s.repo.ListErrors(ctx, jobID, page, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list import errors: %w", err)
+	}
+
+	return &ErrorListResponse{
+		Errors: msgErrors,
+		Pagination: Pagination{
+			CurrentPage: page,
+			PerPage:     limit,
+			TotalPages:  (total + limit - 1) / limit,
+			TotalCount:  total,
+		},
+	}, nil
+}
+
+// getOwnedJob returns a job, hiding jobs of other users as not found
+func (s *Service) getOwnedJob(ctx context.Context, userID, jobID uuid.UUID) (*Job, error) {
+	job, err := s.repo.GetByID(ctx, jobID)
+	if err != nil {
+		if errors.Is(err, ErrImportNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get import: %w", err)
+	}
+	if job.UserID != userID {
+		return nil, ErrImportNotFound
+	}
+	return job, nil
+}
+
+// deleteUpload removes the uploaded archive of a job
+func (s *Service) deleteUpload(job *Job) {
+	if err := s.storage.DeleteObject(context.Background(), job.StorageKey); err != nil {
+		s.logger.Warn("Failed to delete import upload", "import_id", job.ID, "error", err)
+	}
+}
+
+// limitedReader fails with ErrUploadTooLarge once more than remaining bytes are read
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, ErrUploadTooLarge
+	}
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n, ErrUploadTooLarge
+	}
+	return n, err
+}
+
+// cleanFilename returns the base name of an uploaded file, or a default name for its format
+func cleanFilename(name string, format Format) string {
+	name = strings.TrimSpace(path.Base(strings.ReplaceAll(name, `\`, "/")))
+	if name == "" || name == "." || name == "/" {
+		name = "upload." + string(format)
+	}
+	if len(name) > maxFilenameLength {
+		name = name[:maxFilenameLength]
+	}
+	return strings.ToValidUTF8(name, "_")
+}
+
+// contentType returns the content type an archive is stored with
+func contentType(format Format) string {
+	if format == FormatZip {
+		return "application/zip"
+	}
+	return "application/mbox"
+}
+
+// toJobResponse converts a job to its API representation
+func toJobResponse(job *Job) JobResponse {
+	return JobResponse{
+		ID:                job.ID.String(),
+		AliasID:           job.AliasID.String(),
+		Format:            job.Format,
+		Status:            job.Status,
+		Filename:          job.Filename,
+		SizeBytes:         job.SizeBytes,
+		TotalMessages:     job.TotalMessages,
+		ProcessedMessages: job.ProcessedMessages,
+		ImportedMessages:  job.ImportedMessages,
+		DuplicateMessages: job.DuplicateMessages,
+		FailedMessages:    job.FailedMessages,
+		Error:             job.Error,
+		CreatedAt:         job.CreatedAt,
+		StartedAt:         job.StartedAt,
+		CompletedAt:       job.CompletedAt,
+	}
+}
@@ -0,0 +1,406 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/events"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/repository"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/smtp"
+)
+
+// mockRepository keeps jobs in memory; it implements only the methods the tests reach
+type mockRepository struct {
+	Repository
+	jobs   map[uuid.UUID]*Job
+	errors map[uuid.UUID][]MessageError
+}
+
+func newMockRepository() *mockRepository {
+	return &mockRepository{jobs: make(map[uuid.UUID]*Job), errors: make(map[uuid.UUID][]MessageError)}
+}
+
+func (m *mockRepository) HasActive(ctx context.Context, userID uuid.UUID) (bool, error) {
+	for _, j := range m.jobs {
+		if j.UserID == userID && (j.Status == StatusPending || j.Status == StatusRunning) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockRepository) Create(ctx context.Context, job *Job) error {
+	if active, _ := m.HasActive(ctx, job.UserID); active {
+		return ErrImportInProgress
+	}
+	job.CreatedAt = time.Now().UTC()
+	copied := *job
+	m.jobs[job.ID] = &copied
+	return nil
+}
+
+func (m *mockRepository) GetByID(ctx context.Context, id uuid.UUID) (*Job, error) {
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, ErrImportNotFound
+	}
+	copied := *job
+	return &copied, nil
+}
+
+func (m *mockRepository) ListErrors(ctx context.Context, jobID uuid.UUID, page, limit int) ([]MessageError, int, error) {
+	all := m.errors[jobID]
+	start := min((page-1)*limit, len(all))
+	end := min(start+limit, len(all))
+	return all[start:end], len(all), nil
+}
+
+func (m *mockRepository) ClaimNext(ctx context.Context) (*Job, error) {
+	for _, j := range m.jobs {
+		if j.Status == StatusPending {
+			j.Status = StatusRunning
+			copied := *j
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockRepository) SetTotal(ctx context.Context, id uuid.UUID, total int) error {
+	m.jobs[id].TotalMessages = &total
+	return nil
+}
+
+func (m *mockRepository) RecordMessage(ctx context.Context, id uuid.UUID, progress Progress, msgErr *MessageError) error {
+	job := m.jobs[id]
+	job.ProcessedMessages = progress.Processed
+	job.ImportedMessages = progress.Imported
+	job.DuplicateMessages = progress.Duplicates
+	job.FailedMessages = progress.Failed
+	if msgErr != nil {
+		m.errors[id] = append(m.errors[id], *msgErr)
+	}
+	return nil
+}
+
+func (m *mockRepository) Complete(ctx context.Context, id uuid.UUID, total int) error {
+	m.jobs[id].Status = StatusCompleted
+	m.jobs[id].TotalMessages = &total
+	return nil
+}
+
+func (m *mockRepository) Fail(ctx context.Context, id uuid.UUID, message string) error {
+	m.jobs[id].Status = StatusFailed
+	m.jobs[id].Error = message
+	return nil
+}
+
+// mockProcessor stores messages by Message-ID, rejecting repeats and messages containing "FAIL"
+type mockProcessor struct {
+	seen     map[string]bool
+	imported []*smtp.DataResult
+}
+
+func (m *mockProcessor) ImportEmail(ctx context.Context, data *smtp.DataResult, aliasID uuid.UUID, isRead bool) (string, error) {
+	if bytes.Contains(data.Data, []byte("FAIL")) {
+		return "", errors.New("database unavailable")
+	}
+	id := messageID(data.Data)
+	if id != "" && m.seen[id] {
+		return "", smtp.ErrDuplicateMessage
+	}
+	m.seen[id] = true
+	m.imported = append(m.imported, data)
+	return uuid.New().String(), nil
+}
+
+// mockAliasRepository implements AliasRepository for testing
+type mockAliasRepository struct {
+	aliases map[uuid.UUID]uuid.UUID // alias ID to owner ID
+}
+
+func (m *mockAliasRepository) GetByID(ctx context.Context, id uuid.UUID) (*repository.AliasWithStats, error) {
+	owner, ok := m.aliases[id]
+	if !ok {
+		return nil, repository.ErrAliasNotFound
+	}
+	return &repository.AliasWithStats{Alias: repository.Alias{ID: id, UserID: owner}}, nil
+}
+
+// mockStore keeps uploaded objects in memory
+type mockStore struct {
+	objects map[string][]byte
+}
+
+func (m *mockStore) UploadStream(ctx context.Context, key, contentType string, r io.Reader) (int64, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+	m.objects[key] = data
+	return int64(len(data)), nil
+}
+
+func (m *mockStore) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	data, ok := m.objects[key]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *mockStore) DeleteObject(ctx context.Context, key string) error {
+	delete(m.objects, key)
+	return nil
+}
+
+// mockEventBus records published events
+type mockEventBus struct {
+	events.EventBus
+	events []events.Event
+}
+
+func (m *mockEventBus) Publish(event events.Event) error {
+	m.events = append(m.events, event)
+	return nil
+}
+
+type testEnv struct {
+	service   *Service
+	repo      *mockRepository
+	processor *mockProcessor
+	store     *mockStore
+	bus       *mockEventBus
+	userID    uuid.UUID
+	aliasID   uuid.UUID
+}
+
+func newTestEnv() *testEnv {
+	env := &testEnv{
+		repo:      newMockRepository(),
+		processor: &mockProcessor{seen: make(map[string]bool)},
+		store:     &mockStore{objects: make(map[string][]byte)},
+		bus:       &mockEventBus{},
+		userID:    uuid.New(),
+		aliasID:   uuid.New(),
+	}
+	env.service = NewService(ServiceConfig{
+		Repository:     env.repo,
+		Processor:      env.processor,
+		AliasRepo:      &mockAliasRepository{aliases: map[uuid.UUID]uuid.UUID{env.aliasID: env.userID}},
+		Storage:        env.store,
+		EventBus:       env.bus,
+		MaxUploadSize:  1 << 20,
+		MaxMessageSize: 1024,
+		TempDir:        "",
+	})
+	return env
+}
+
+func (env *testEnv) create(t *testing.T, filename string, body []byte) *JobResponse {
+	t.Helper()
+	job, err := env.service.Create(context.Background(), env.userID, CreateImportRequest{
+		AliasID:  env.aliasID.String(),
+		Filename: filename,
+		Body:     bytes.NewReader(body),
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	return job
+}
+
+// runNext claims and imports the next queued job like a worker would
+func (env *testEnv) runNext(t *testing.T) {
+	t.Helper()
+	job, err := env.repo.ClaimNext(context.Background())
+	if err != nil || job == nil {
+		t.Fatalf("ClaimNext() = %v, %v", job, err)
+	}
+	env.service.runJob(context.Background(), job)
+}
+
+func mboxMessage(id, body string) string {
+	return fmt.Sprintf("From sender@example.org Tue Mar  5 09:07:01 2024\nMessage-ID: <%s>\nSubject: %s\n\n%s\n\n", id, id, body)
+}
+
+func TestCreate_Validation(t *testing.T) {
+	env := newTestEnv()
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		userID  uuid.UUID
+		aliasID string
+		body    string
+		wantErr error
+	}{
+		{"invalid alias", env.userID, "nope", "From a", ErrAliasNotFound},
+		{"unknown alias", env.userID, uuid.New().String(), "From a", ErrAliasNotFound},
+		{"alias of another user", uuid.New(), env.aliasID.String(), "From a", ErrAccessDenied},
+		{"empty upload", env.userID, env.aliasID.String(), "", ErrEmptyUpload},
+		{"unsupported format", env.userID, env.aliasID.String(), "%PDF-1.7", ErrUnsupportedFormat},
+		{"too large", env.userID, env.aliasID.String(), "From " + strings.Repeat("x", 1<<20), ErrUploadTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := env.service.Create(ctx, tt.userID, CreateImportRequest{AliasID: tt.aliasID, Body: strings.NewReader(tt.body)})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Create() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+	if len(env.repo.jobs) != 0 {
+		t.Errorf("jobs = %d, want none created", len(env.repo.jobs))
+	}
+}
+
+func TestCreate_OneActiveImportPerUser(t *testing.T) {
+	env := newTestEnv()
+
+	job := env.create(t, `C:\Users\me\Inbox.mbox`, []byte(mboxMessage("a@x", "Body")))
+	if job.Format != FormatMbox || job.Status != StatusPending || job.Filename != "Inbox.mbox" {
+		t.Errorf("Create() = %+v, want pending mbox named Inbox.mbox", job)
+	}
+
+	_, err := env.service.Create(context.Background(), env.userID, CreateImportRequest{
+		AliasID: env.aliasID.String(),
+		Body:    strings.NewReader(mboxMessage("b@x", "Body")),
+	})
+	if !errors.Is(err, ErrImportInProgress) {
+		t.Errorf("second Create() error = %v, want ErrImportInProgress", err)
+	}
+}
+
+func TestCreate_WithoutStorage(t *testing.T) {
+	service := NewService(ServiceConfig{Repository: newMockRepository()})
+
+	if _, err := service.Create(context.Background(), uuid.New(), CreateImportRequest{}); !errors.Is(err, ErrStorageUnavailable) {
+		t.Errorf("Create() error = %v, want ErrStorageUnavailable", err)
+	}
+}
+
+func TestRunJob_Mbox(t *testing.T) {
+	env := newTestEnv()
+	ctx := context.Background()
+
+	mbox := mboxMessage("one@x", "First") +
+		mboxMessage("one@x", "Repeated") +
+		mboxMessage("two@x", "FAIL") +
+		mboxMessage("big@x", strings.Repeat("x", 2048)) +
+		mboxMessage("three@x", "Third")
+	created := env.create(t, "inbox.mbox", []byte(mbox))
+	env.runNext(t)
+
+	jobID := uuid.MustParse(created.ID)
+	job, err := env.service.Get(ctx, env.userID, jobID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if job.Status != StatusCompleted || *job.TotalMessages != 5 || job.ProcessedMessages != 5 ||
+		job.ImportedMessages != 2 || job.DuplicateMessages != 1 || job.FailedMessages != 2 {
+		t.Fatalf("job = %+v, want completed with 2 imported, 1 duplicate, 2 failed", job)
+	}
+	if len(env.store.objects) != 0 {
+		t.Errorf("uploaded archive was not deleted")
+	}
+
+	msgErrors, err := env.service.ListErrors(ctx, env.userID, jobID, 1, 10)
+	if err != nil {
+		t.Fatalf("ListErrors() error = %v", err)
+	}
+	if msgErrors.Pagination.TotalCount != 2 || msgErrors.Errors[0].Index != 2 || msgErrors.Errors[0].MessageID != "two@x" ||
+		msgErrors.Errors[0].Error != "message could not be stored" || msgErrors.Errors[1].Index != 3 {
+		t.Errorf("ListErrors() = %+v", msgErrors)
+	}
+
+	first := env.processor.imported[0]
+	if !first.ReceivedAt.Equal(time.Date(2024, 3, 5, 9, 7, 1, 0, time.UTC)) || !strings.HasPrefix(first.QueueID, "import-") {
+		t.Errorf("first import = %s at %v", first.QueueID, first.ReceivedAt)
+	}
+
+	var last events.ImportProgressEvent
+	json.Unmarshal(env.bus.events[len(env.bus.events)-1].Data, &last)
+	if last.Status != string(StatusCompleted) || last.ImportedMessages != 2 {
+		t.Errorf("last progress event = %+v", last)
+	}
+}
+
+func TestRunJob_Zip(t *testing.T) {
+	env := newTestEnv()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for i := 0; i < 3; i++ {
+		f, _ := zw.Create(fmt.Sprintf("mail/%d.eml", i))
+		fmt.Fprintf(f, "Message-ID: <%d@x>\nSubject: %d\n\nBody\n", i, i)
+	}
+	zw.Close()
+
+	created := env.create(t, "mail.zip", buf.Bytes())
+	if created.Format != FormatZip {
+		t.Fatalf("Format = %s, want zip", created.Format)
+	}
+	env.runNext(t)
+
+	job := env.repo.jobs[uuid.MustParse(created.ID)]
+	if job.Status != StatusCompleted || job.ImportedMessages != 3 {
+		t.Errorf("job = %+v, want 3 imported", job)
+	}
+}
+
+func TestRunJob_CorruptZip(t *testing.T) {
+	env := newTestEnv()
+
+	created := env.create(t, "mail.zip", []byte("PK\x03\x04 not really a zip"))
+	env.runNext(t)
+
+	job := env.repo.jobs[uuid.MustParse(created.ID)]
+	if job.Status != StatusFailed || !strings.Contains(job.Error, "corrupt") {
+		t.Errorf("job = %s %q, want failed as corrupt", job.Status, job.Error)
+	}
+	if len(env.store.objects) != 0 {
+		t.Errorf("uploaded archive was not deleted")
+	}
+}
+
+func TestRunJob_ResumesAfterProcessedMessages(t *testing.T) {
+	env := newTestEnv()
+
+	created := env.create(t, "inbox.mbox", []byte(mboxMessage("one@x", "1")+mboxMessage("two@x", "2")+mboxMessage("three@x", "3")))
+
+	// A previous run processed the first two messages before stopping
+	jobID := uuid.MustParse(created.ID)
+	env.repo.jobs[jobID].ProcessedMessages = 2
+	env.repo.jobs[jobID].ImportedMessages = 2
+	env.runNext(t)
+
+	job := env.repo.jobs[jobID]
+	if job.Status != StatusCompleted || job.ProcessedMessages != 3 || job.ImportedMessages != 3 {
+		t.Errorf("job = %+v, want completed with 3 processed", job)
+	}
+	if len(env.processor.imported) != 1 || messageID(env.processor.imported[0].Data) != "three@x" {
+		t.Errorf("imported %d messages on resume, want only three@x", len(env.processor.imported))
+	}
+}
+
+func TestLimitedReader(t *testing.T) {
+	data := strings.Repeat("x", 10)
+
+	got, err := io.ReadAll(&limitedReader{r: strings.NewReader(data), remaining: 10})
+	if err != nil || string(got) != data {
+		t.Errorf("ReadAll() at limit = %d bytes, %v", len(got), err)
+	}
+	if _, err := io.ReadAll(&limitedReader{r: strings.NewReader(data), remaining: 9}); !errors.Is(err, ErrUploadTooLarge) {
+		t.Errorf("ReadAll() over limit error = %v, want ErrUploadTooLarge", err)
+	}
+}
@@ -0,0 +1,247 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/jobqueue"
+)
+
+// jobColumns are the import_jobs columns scanned by scanJob, in order
+const jobColumns = `
+	id, user_id, alias_id, format, status, filename, storage_key, size_bytes, total_messages,
+	processed_messages, imported_messages, duplicate_messages, failed_messages, COALESCE(error, ''),
+	created_at, started_at, completed_at
+`
+
+// PostgresRepository stores import jobs in PostgreSQL
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresRepository creates a new PostgresRepository
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{pool: pool}
+}
+
+// HasActive reports whether the user has an import pending or running
+func (r *PostgresRepository) HasActive(ctx context.Context, userID uuid.UUID) (bool, error) {
+	var active bool
+	err := r.pool.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM import_jobs WHERE user_id = $1 AND status IN ('pending', 'running'))
+	`, userID).Scan(&active)
+	return active, err
+}
+
+// Create stores a pending job unless the user already has one pending or running
+// Serializing on the user row keeps two concurrent uploads from both passing the check.
+func (r *PostgresRepository) Create(ctx context.Context, job *Job) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM users WHERE id = $1 FOR UPDATE`, job.UserID); err != nil {
+		return fmt.Errorf("failed to lock user: %w", err)
+	}
+
+	var active bool
+	err = tx.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM import_jobs WHERE user_id = $1 AND status IN ('pending', 'running'))
+	`, job.UserID).Scan(&active)
+	if err != nil {
+		return fmt.Errorf("failed to check active imports: %w", err)
+	}
+	if active {
+		return ErrImportInProgress
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO import_jobs (id, user_id, alias_id, format, status, filename, storage_key, size_bytes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING created_at
+	`, job.ID, job.UserID, job.AliasID, string(job.Format), string(job.Status), job.Filename, job.StorageKey, job.SizeBytes).Scan(&job.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert import job: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetByID returns a job
+func (r *PostgresRepository) GetByID(ctx context.Context, id uuid.UUID) (*Job, error) {
+	job, err := scanJob(r.pool.QueryRow(ctx, `SELECT `+jobColumns+` FROM import_jobs WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrImportNotFound
+		}
+		return nil, err
+	}
+	return job, nil
+}
+
+// ListByUser returns a user's most recent jobs, newest first
+func (r *PostgresRepository) ListByUser(ctx context.Context, userID uuid.UUID, limit int) ([]Job, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+jobColumns+` FROM import_jobs
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list import jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := make([]Job, 0)
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, rows.Err()
+}
+
+// ListErrors returns a page of a job's message errors in archive order, with the total count
+func (r *PostgresRepository) ListErrors(ctx context.Context, jobID uuid.UUID, page, limit int) ([]MessageError, int, error) {
+	var total int
+	if err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM import_job_errors WHERE job_id = $1`, jobID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count import errors: %w", err)
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT message_index, COALESCE(entry_name, ''), COALESCE(message_id, ''), error, created_at
+		FROM import_job_errors
+		WHERE job_id = $1
+		ORDER BY message_index
+		LIMIT $2 OFFSET $3
+	`, jobID, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list import errors: %w", err)
+	}
+	defer rows.Close()
+
+	msgErrors := make([]MessageError, 0)
+	for rows.Next() {
+		var e MessageError
+		if err := rows.Scan(&e.Index, &e.Name, &e.MessageID, &e.Error, &e.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		msgErrors = append(msgErrors, e)
+	}
+	return msgErrors, total, rows.Err()
+}
+
+// ClaimNext marks the oldest pending job running and returns it, or nil when there is none
+func (r *PostgresRepository) ClaimNext(ctx context.Context) (*Job, error) {
+	job, err := scanJob(r.pool.QueryRow(ctx, jobqueue.ClaimQuery("import_jobs",
+		"started_at = COALESCE(started_at, (NOW() AT TIME ZONE 'utc'))", jobColumns)))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim import job: %w", err)
+	}
+	return job, nil
+}
+
+// SetTotal records the number of messages in a job's archive
+func (r *PostgresRepository) SetTotal(ctx context.Context, id uuid.UUID, total int) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE import_jobs SET total_messages = $2, updated_at = (NOW() AT TIME ZONE 'utc')
+		WHERE id = $1
+	`, id, total)
+	return err
+}
+
+// RecordMessage saves a job's counters after a message, with the message's error if it failed
+// A message processed again after a resume keeps its first error.
+func (r *PostgresRepository) RecordMessage(ctx context.Context, id uuid.UUID, progress Progress, msgErr *MessageError) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if msgErr != nil {
+		_, err = tx.Exec(ctx, `
+			INSERT INTO import_job_errors (job_id, message_index, entry_name, message_id, error)
+			VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5)
+			ON CONFLICT (job_id, message_index) DO NOTHING
+		`, id, msgErr.Index, msgErr.Name, msgErr.MessageID, msgErr.Error)
+		if err != nil {
+			return fmt.Errorf("failed to insert import error: %w", err)
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE import_jobs
+		SET processed_messages = $2, imported_messages = $3, duplicate_messages = $4, failed_messages = $5,
+		    updated_at = (NOW() AT TIME ZONE 'utc')
+		WHERE id = $1
+	`, id, progress.Processed, progress.Imported, progress.Duplicates, progress.Failed)
+	if err != nil {
+		return fmt.Errorf("failed to update import progress: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Complete records a job whose archive was fully processed
+func (r *PostgresRepository) Complete(ctx context.Context, id uuid.UUID, total int) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE import_jobs
+		SET status = 'completed', total_messages = $2, error = NULL,
+		    completed_at = (NOW() AT TIME ZONE 'utc'),
+		    updated_at = (NOW() AT TIME ZONE 'utc')
+		WHERE id = $1
+	`, id, total)
+	return err
+}
+
+// Fail records a job whose archive could not be processed
+func (r *PostgresRepository) Fail(ctx context.Context, id uuid.UUID, message string) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE import_jobs
+		SET status = 'failed', error = $2,
+		    completed_at = (NOW() AT TIME ZONE 'utc'),
+		    updated_at = (NOW() AT TIME ZONE 'utc')
+		WHERE id = $1
+	`, id, message)
+	return err
+}
+
+// RequeueStale returns running jobs without progress since before to pending
+// Their counters are kept so the next run continues after the messages already processed.
+func (r *PostgresRepository) RequeueStale(ctx context.Context, before time.Time) (int, error) {
+	result, err := r.pool.Exec(ctx, jobqueue.RequeueStaleQuery("import_jobs", ""), before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return int(result.RowsAffected()), nil
+}
+
+// scanJob scans a row selected with jobColumns
+func scanJob(row pgx.Row) (*Job, error) {
+	var job Job
+	var format, status string
+	err := row.Scan(
+		&job.ID, &job.UserID, &job.AliasID, &format, &status, &job.Filename, &job.StorageKey, &job.SizeBytes,
+		&job.TotalMessages, &job.ProcessedMessages, &job.ImportedMessages, &job.DuplicateMessages,
+		&job.FailedMessages, &job.Error, &job.CreatedAt, &job.StartedAt, &job.CompletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	job.Format = Format(format)
+	job.Status = Status(status)
+	return &job, nil
+}
@@ -0,0 +1,28 @@
+package importer
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes registers mailbox import routes with the Chi router
+// All routes require authentication via auth middleware. They must be mounted outside the request
+// timeout middleware, as uploads of large archives take longer.
+func RegisterRoutes(r chi.Router, handler *Handler, authMiddleware func(next http.Handler) http.Handler) {
+	r.Route("/imports", func(r chi.Router) {
+		r.Use(authMiddleware)
+
+		// POST /api/v1/imports - Upload an mbox file or zip of .eml files into an alias (multipart: alias_id, file)
+		r.Post("/", handler.Create)
+
+		// GET /api/v1/imports - List recent imports
+		r.Get("/", handler.List)
+
+		// GET /api/v1/imports/:id - Get import status and progress
+		r.Get("/{id}", handler.Get)
+
+		// GET /api/v1/imports/:id/errors - List messages that could not be imported (page, limit)
+		r.Get("/{id}/errors", handler.ListErrors)
+	})
+}
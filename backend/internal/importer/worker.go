@@ -0,0 +1,249 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/events"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/smtp"
+)
+
+// Start launches the import workers and the stale job sweep
+// Jobs left running by a previous process are resumed once they go stale.
+func (s *Service) Start() error {
+	if s.storage == nil {
+		s.logger.Info("Import workers disabled: storage is not configured")
+		return nil
+	}
+	if err := s.runner.Start(); err != nil {
+		return err
+	}
+
+	s.logger.Info("Import workers started", "workers", s.workers)
+	return nil
+}
+
+// Stop cancels running imports and waits for the workers to exit
+// Cancelled jobs stay running in the database and resume once stale.
+func (s *Service) Stop() {
+	if s.runner.Stop() {
+		s.logger.Info("Import workers stopped")
+	}
+}
+
+// runJob imports one claimed job and records the outcome
+// The uploaded archive is deleted once the job completes or fails.
+func (s *Service) runJob(ctx context.Context, job *Job) {
+	s.logger.Info("Import started", "import_id", job.ID, "user_id", job.UserID, "format", job.Format,
+		"resume_from", job.ProcessedMessages)
+	s.publishProgress(job)
+
+	err := s.importArchive(ctx, job)
+	if ctx.Err() != nil {
+		// Stopped: leave the job running so it resumes
+		return
+	}
+	if err != nil {
+		s.logger.Error("Import failed", "import_id", job.ID, "error", err)
+		job.Status = StatusFailed
+		job.Error = "Import failed"
+		if errors.Is(err, ErrCorruptArchive) || errors.Is(err, ErrUnsupportedFormat) {
+			job.Error = err.Error()
+		}
+		if failErr := s.repo.Fail(ctx, job.ID, job.Error); failErr != nil {
+			s.logger.Error("Failed to record import failure", "import_id", job.ID, "error", failErr)
+			return
+		}
+		s.deleteUpload(job)
+		s.publishProgress(job)
+		return
+	}
+
+	total := job.ProcessedMessages
+	job.Status = StatusCompleted
+	job.TotalMessages = &total
+	if err := s.repo.Complete(ctx, job.ID, total); err != nil {
+		s.logger.Error("Failed to record import completion", "import_id", job.ID, "error", err)
+		return
+	}
+	s.deleteUpload(job)
+	s.publishProgress(job)
+	s.logger.Info("Import completed", "import_id", job.ID, "imported", job.ImportedMessages,
+		"duplicates", job.DuplicateMessages, "failed", job.FailedMessages)
+}
+
+// importArchive reads a job's archive and imports every message after those already processed
+func (s *Service) importArchive(ctx context.Context, job *Job) error {
+	body, err := s.storage.GetObject(ctx, job.StorageKey)
+	if err != nil {
+		return fmt.Errorf("failed to get upload: %w", err)
+	}
+	defer body.Close()
+
+	var source messageSource
+	if job.Format == FormatZip {
+		// Zip needs random access: download to a temporary file
+		tmp, err := os.CreateTemp(s.tempDir, "import-*.zip")
+		if err != nil {
+			return fmt.Errorf("failed to create temporary file: %w", err)
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+
+		size, err := io.Copy(tmp, body)
+		if err != nil {
+			return fmt.Errorf("failed to download upload: %w", err)
+		}
+		if source, err = newZipSource(tmp, size, s.maxMessageSize); err != nil {
+			return err
+		}
+	} else {
+		if source, err = newMboxReader(body, s.maxMessageSize); err != nil {
+			return err
+		}
+	}
+
+	if total := source.Total(); total >= 0 && job.TotalMessages == nil {
+		job.TotalMessages = &total
+		if err := s.repo.SetTotal(ctx, job.ID, total); err != nil {
+			s.logger.Warn("Failed to record import total", "import_id", job.ID, "error", err)
+		}
+		s.publishProgress(job)
+	}
+
+	return s.importMessages(ctx, job, source)
+}
+
+// importMessages imports the messages of a source, skipping those a previous run already processed
+// Counters are saved after every message. A message imported just before a crash is processed again
+// on resume and then counted as a duplicate, as the alias already has its content hash (import key).
+func (s *Service) importMessages(ctx context.Context, job *Job, source messageSource) error {
+	lastProgress := time.Now()
+	for index := 0; ; index++ {
+		msg, err := source.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if index < job.ProcessedMessages {
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		msgErr := s.importMessage(ctx, job, index, msg)
+		if ctx.Err() != nil {
+			// Not counted: the message is processed again on resume
+			return ctx.Err()
+		}
+		job.ProcessedMessages++
+		if msgErr != nil {
+			job.FailedMessages++
+		}
+
+		progress := Progress{
+			Processed:  job.ProcessedMessages,
+			Imported:   job.ImportedMessages,
+			Duplicates: job.DuplicateMessages,
+			Failed:     job.FailedMessages,
+		}
+		if err := s.repo.RecordMessage(ctx, job.ID, progress, msgErr); err != nil {
+			return fmt.Errorf("failed to record import progress: %w", err)
+		}
+
+		if time.Since(lastProgress) >= progressInterval {
+			lastProgress = time.Now()
+			s.publishProgress(job)
+		}
+	}
+}
+
+// importMessage imports one message, returning the error to report for it if it was not stored
+func (s *Service) importMessage(ctx context.Context, job *Job, index int, msg *sourceMessage) *MessageError {
+	msgErr := &MessageError{Index: index, Name: msg.Name, CreatedAt: time.Now().UTC()}
+	if msg.Oversized {
+		msgErr.Error = fmt.Sprintf("message exceeds the size limit of %d bytes", s.maxMessageSize)
+		return msgErr
+	}
+	msgErr.MessageID = messageID(msg.Raw)
+
+	receivedAt, isRead := messageState(msg, time.Now().UTC())
+	data := &smtp.DataResult{
+		Data:       msg.Raw,
+		QueueID:    fmt.Sprintf("import-%s-%d", job.ID.String()[:8], index),
+		ReceivedAt: receivedAt,
+		SizeBytes:  int64(len(msg.Raw)),
+	}
+
+	_, err := s.processor.ImportEmail(ctx, data, job.AliasID, isRead)
+	switch {
+	case err == nil:
+		job.ImportedMessages++
+		return nil
+	case errors.Is(err, smtp.ErrDuplicateMessage):
+		job.DuplicateMessages++
+		return nil
+	case errors.Is(err, smtp.ErrUnparseableMessage):
+		msgErr.Error = err.Error()
+	default:
+		s.logger.Warn("Failed to import message", "import_id", job.ID, "index", index, "error", err)
+		msgErr.Error = "message could not be stored"
+	}
+	return msgErr
+}
+
+// messageID returns the Message-ID header of a raw message without angle brackets, or empty
+func messageID(raw []byte) string {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return ""
+	}
+	id := strings.TrimSpace(msg.Header.Get("Message-Id"))
+	return strings.TrimSuffix(strings.TrimPrefix(id, "<"), ">")
+}
+
+// publishProgress sends the state of a job to the user's SSE connections
+func (s *Service) publishProgress(job *Job) {
+	if s.eventBus == nil {
+		return
+	}
+
+	data, err := json.Marshal(events.ImportProgressEvent{
+		ID:                job.ID.String(),
+		AliasID:           job.AliasID.String(),
+		Status:            string(job.Status),
+		TotalMessages:     job.TotalMessages,
+		ProcessedMessages: job.ProcessedMessages,
+		ImportedMessages:  job.ImportedMessages,
+		DuplicateMessages: job.DuplicateMessages,
+		FailedMessages:    job.FailedMessages,
+		Error:             job.Error,
+	})
+	if err != nil {
+		s.logger.Warn("Failed to marshal import_progress event", "error", err)
+		return
+	}
+
+	event := events.Event{
+		ID:        uuid.New().String(),
+		Type:      events.EventTypeImportProgress,
+		UserID:    job.UserID.String(),
+		Data:      data,
+		Timestamp: time.Now().UTC(),
+	}
+	if err := s.eventBus.Publish(event); err != nil {
+		s.logger.Warn("Failed to publish import_progress event", "import_id", job.ID, "error", err)
+	}
+}
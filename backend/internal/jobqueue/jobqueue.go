@@ -0,0 +1,172 @@
+// Package jobqueue runs background workers over a table of queued jobs in PostgreSQL
+// Workers claim pending jobs one at a time, a sweeper returns jobs that stopped making progress
+// to pending, and Wake lets new jobs start without waiting for the next poll.
+package jobqueue
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Queue is the job table a Runner works through
+type Queue[J any] interface {
+	// ClaimNext marks the oldest pending job running and returns it, or nil when there is none
+	ClaimNext(ctx context.Context) (*J, error)
+	// RequeueStale returns running jobs without progress since before to pending
+	RequeueStale(ctx context.Context, before time.Time) (int, error)
+}
+
+// Config holds configuration for a Runner
+type Config[J any] struct {
+	Name         string // Job kind used in log messages, e.g. "export"
+	Queue        Queue[J]
+	Run          func(ctx context.Context, job *J) // Runs one claimed job; ctx is cancelled by Stop
+	Sweep        func(ctx context.Context)         // Optional extra work run on every sweep
+	Workers      int
+	PollInterval time.Duration
+	StaleAfter   time.Duration
+	Logger       *slog.Logger
+}
+
+// Runner runs the workers and the stale job sweeper of one queue
+type Runner[J any] struct {
+	cfg    Config[J]
+	logger *slog.Logger
+
+	wake     chan struct{}
+	stopChan chan struct{}
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	running  bool
+}
+
+// NewRunner creates a runner; it does nothing until Start is called
+func NewRunner[J any](cfg Config[J]) *Runner[J] {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Runner[J]{
+		cfg:    cfg,
+		logger: logger,
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// Start launches the workers and the sweeper
+func (r *Runner[J]) Start() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.running {
+		return fmt.Errorf("%s workers are already running", r.cfg.Name)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.running = true
+	r.cancel = cancel
+	r.stopChan = make(chan struct{})
+
+	for i := 0; i < r.cfg.Workers; i++ {
+		r.wg.Add(1)
+		go r.runWorker(ctx)
+	}
+	r.wg.Add(1)
+	go r.runSweeper(ctx)
+	return nil
+}
+
+// Stop cancels running jobs and waits for the workers to exit, reporting whether they were running
+// Cancelled jobs stay running in the database and are requeued once stale.
+func (r *Runner[J]) Stop() bool {
+	r.mu.Lock()
+	if !r.running {
+		r.mu.Unlock()
+		return false
+	}
+	r.running = false
+	close(r.stopChan)
+	r.cancel()
+	r.mu.Unlock()
+
+	r.wg.Wait()
+	return true
+}
+
+// Wake makes an idle worker look for pending jobs now
+// A full channel means one is already about to look.
+func (r *Runner[J]) Wake() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Sweep runs one pass of stale job recovery followed by the configured Sweep function
+func (r *Runner[J]) Sweep(ctx context.Context) {
+	requeued, err := r.cfg.Queue.RequeueStale(ctx, time.Now().UTC().Add(-r.cfg.StaleAfter))
+	if err != nil {
+		r.logger.Error("Failed to requeue stale jobs", "queue", r.cfg.Name, "error", err)
+	} else if requeued > 0 {
+		r.logger.Warn("Requeued stale jobs", "queue", r.cfg.Name, "count", requeued)
+		r.Wake()
+	}
+
+	if r.cfg.Sweep != nil {
+		r.cfg.Sweep(ctx)
+	}
+}
+
+// runWorker runs claimed jobs one at a time until stopped
+func (r *Runner[J]) runWorker(ctx context.Context) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain the queue before waiting again
+		for {
+			job, err := r.cfg.Queue.ClaimNext(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					r.logger.Error("Failed to claim job", "queue", r.cfg.Name, "error", err)
+				}
+				break
+			}
+			if job == nil {
+				break
+			}
+			r.cfg.Run(ctx, job)
+		}
+
+		select {
+		case <-r.stopChan:
+			return
+		case <-r.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// runSweeper sweeps the queue until stopped
+func (r *Runner[J]) runSweeper(ctx context.Context) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		r.Sweep(ctx)
+
+		select {
+		case <-r.stopChan:
+			return
+		case <-ticker.C:
+		}
+	}
+}
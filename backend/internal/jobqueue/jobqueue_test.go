@@ -0,0 +1,169 @@
+package jobqueue
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testJob struct {
+	id int
+}
+
+// memoryQueue is a Queue of pending and running jobs held in memory
+type memoryQueue struct {
+	mu      sync.Mutex
+	pending []*testJob
+	running []*testJob
+}
+
+func (q *memoryQueue) add(job *testJob) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending = append(q.pending, job)
+}
+
+func (q *memoryQueue) ClaimNext(ctx context.Context) (*testJob, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.pending) == 0 {
+		return nil, nil
+	}
+	job := q.pending[0]
+	q.pending = q.pending[1:]
+	q.running = append(q.running, job)
+	return job, nil
+}
+
+func (q *memoryQueue) RequeueStale(ctx context.Context, before time.Time) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	n := len(q.running)
+	q.pending = append(q.pending, q.running...)
+	q.running = nil
+	return n, nil
+}
+
+func TestRunner_WakeRunsQueuedJobs(t *testing.T) {
+	queue := &memoryQueue{}
+	ran := make(chan int, 10)
+	runner := NewRunner(Config[testJob]{
+		Name:         "test",
+		Queue:        queue,
+		Run:          func(ctx context.Context, job *testJob) { ran <- job.id },
+		Workers:      2,
+		PollInterval: time.Hour,
+		StaleAfter:   time.Hour,
+	})
+	if err := runner.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer runner.Stop()
+
+	if err := runner.Start(); err == nil || !strings.Contains(err.Error(), "test workers") {
+		t.Errorf("second Start() error = %v, want already running", err)
+	}
+
+	for i := 1; i <= 3; i++ {
+		queue.add(&testJob{id: i})
+	}
+	runner.Wake()
+
+	seen := map[int]bool{}
+	for len(seen) < 3 {
+		select {
+		case id := <-ran:
+			seen[id] = true
+		case <-time.After(5 * time.Second):
+			t.Fatalf("ran jobs %v, want 1, 2 and 3", seen)
+		}
+	}
+}
+
+func TestRunner_StopCancelsRunningJobs(t *testing.T) {
+	queue := &memoryQueue{}
+	queue.add(&testJob{id: 1})
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	runner := NewRunner(Config[testJob]{
+		Name:  "test",
+		Queue: queue,
+		Run: func(ctx context.Context, job *testJob) {
+			close(started)
+			<-ctx.Done()
+			close(cancelled)
+		},
+		Workers:      1,
+		PollInterval: time.Hour,
+		StaleAfter:   time.Hour,
+	})
+	if err := runner.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	<-started
+
+	if !runner.Stop() {
+		t.Error("Stop() = false, want true while running")
+	}
+	select {
+	case <-cancelled:
+	default:
+		t.Error("Stop() returned before the running job was cancelled")
+	}
+	if runner.Stop() {
+		t.Error("second Stop() = true, want false")
+	}
+}
+
+func TestRunner_SweepRequeuesAndRunsHook(t *testing.T) {
+	queue := &memoryQueue{}
+	queue.running = []*testJob{{id: 1}}
+	swept := 0
+	runner := NewRunner(Config[testJob]{
+		Name:       "test",
+		Queue:      queue,
+		Run:        func(ctx context.Context, job *testJob) {},
+		Sweep:      func(ctx context.Context) { swept++ },
+		StaleAfter: time.Minute,
+	})
+
+	runner.Sweep(context.Background())
+
+	if len(queue.pending) != 1 || len(queue.running) != 0 {
+		t.Errorf("pending = %d, running = %d, want the stale job requeued", len(queue.pending), len(queue.running))
+	}
+	if swept != 1 {
+		t.Errorf("Sweep hook ran %d times, want 1", swept)
+	}
+	select {
+	case <-runner.wake:
+	default:
+		t.Error("requeued jobs did not wake a worker")
+	}
+}
+
+func TestClaimQuery(t *testing.T) {
+	query := ClaimQuery("export_jobs", "started_at = NOW()", "id, status")
+	for _, want := range []string{
+		"UPDATE export_jobs",
+		"started_at = NOW(),",
+		"SELECT id FROM export_jobs",
+		"FOR UPDATE SKIP LOCKED",
+		"RETURNING id, status",
+	} {
+		if !strings.Contains(query, want) {
+			t.Errorf("ClaimQuery() = %q, want it to contain %q", query, want)
+		}
+	}
+}
+
+func TestRequeueStaleQuery(t *testing.T) {
+	if query := RequeueStaleQuery("import_jobs", ""); !strings.Contains(query, "SET status = 'pending', updated_at") {
+		t.Errorf("RequeueStaleQuery() without set = %q", query)
+	}
+	if query := RequeueStaleQuery("export_jobs", "processed_emails = 0"); !strings.Contains(query, "'pending', processed_emails = 0, updated_at") {
+		t.Errorf("RequeueStaleQuery() with set = %q", query)
+	}
+}
@@ -0,0 +1,33 @@
+package jobqueue
+
+// ClaimQuery returns the statement that marks the oldest pending job of table running and returns columns
+// set lists further assignments made on claim, such as started_at; updated_at is always refreshed.
+// SKIP LOCKED lets several workers, in one or more processes, claim jobs without blocking each other.
+func ClaimQuery(table, set, columns string) string {
+	return `
+		UPDATE ` + table + `
+		SET status = 'running',
+		    ` + set + `,
+		    updated_at = (NOW() AT TIME ZONE 'utc')
+		WHERE id = (
+			SELECT id FROM ` + table + `
+			WHERE status = 'pending'
+			ORDER BY created_at, id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + columns
+}
+
+// RequeueStaleQuery returns the statement that moves running jobs of table last updated before $1 back to
+// pending. set lists further assignments, such as progress counters to reset, and may be empty.
+func RequeueStaleQuery(table, set string) string {
+	if set != "" {
+		set += ", "
+	}
+	return `
+		UPDATE ` + table + `
+		SET status = 'pending', ` + set + `updated_at = (NOW() AT TIME ZONE 'utc')
+		WHERE status = 'running' AND updated_at < $1
+	`
+}
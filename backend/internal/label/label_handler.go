@@ -0,0 +1,202 @@
+package label
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	appctx "github.com/welldanyogia/persistent-temp-mail/backend/internal/context"
+)
+
+// APIResponse represents the standard API response format
+type APIResponse struct {
+	Success   bool        `json:"success"`
+	Data      interface{} `json:"data,omitempty"`
+	Error     *APIError   `json:"error,omitempty"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// APIError represents the error detail in API response
+type APIError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Handler handles HTTP requests for label endpoints
+type Handler struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+// NewHandler creates a new Handler instance
+func NewHandler(service *Service, logger *slog.Logger) *Handler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Create handles POST /api/v1/labels
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req CreateLabelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "Invalid request body")
+		return
+	}
+
+	response, err := h.service.Create(r.Context(), userID, req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusCreated, response)
+}
+
+// List handles GET /api/v1/labels
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.service.List(r.Context(), userID)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, response)
+}
+
+// Get handles GET /api/v1/labels/:id
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.service.Get(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, response)
+}
+
+// Update handles PATCH /api/v1/labels/:id
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateLabelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "Invalid request body")
+		return
+	}
+
+	response, err := h.service.Update(r.Context(), userID, chi.URLParam(r, "id"), req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, response)
+}
+
+// Delete handles DELETE /api/v1/labels/:id
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.service.Delete(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, response)
+}
+
+// parseUserID extracts the authenticated user ID
+func (h *Handler) parseUserID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	userIDStr, ok := appctx.ExtractUserID(r.Context())
+	if !ok {
+		h.writeError(w, http.StatusUnauthorized, CodeAuthTokenInvalid, "Invalid or expired token")
+		return uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		h.writeError(w, http.StatusUnauthorized, CodeAuthTokenInvalid, "Invalid user ID")
+		return uuid.Nil, false
+	}
+
+	return userID, true
+}
+
+// handleError maps service errors to HTTP responses
+func (h *Handler) handleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrLabelNotFound):
+		h.writeError(w, http.StatusNotFound, CodeLabelNotFound, "Label not found")
+	case errors.Is(err, ErrLabelExists):
+		h.writeError(w, http.StatusConflict, CodeLabelExists, "A label with this name already exists")
+	case errors.Is(err, ErrLabelLimitReached):
+		h.writeError(w, http.StatusForbidden, CodeLabelLimitReached, "Maximum number of labels reached")
+	case errors.Is(err, ErrInvalidName), errors.Is(err, ErrInvalidColor):
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, err.Error())
+	default:
+		h.logger.Error("Unexpected label error", "error", err)
+		h.writeError(w, http.StatusInternalServerError, CodeInternalError, "An unexpected error occurred")
+	}
+}
+
+// writeSuccess writes a successful JSON response
+func (h *Handler) writeSuccess(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := APIResponse{
+		Success:   true,
+		Data:      data,
+		Timestamp: time.Now().UTC(),
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// writeError writes an error JSON response
+func (h *Handler) writeError(w http.ResponseWriter, statusCode int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := APIResponse{
+		Success: false,
+		Error: &APIError{
+			Code:    code,
+			Message: message,
+		},
+		Timestamp: time.Now().UTC(),
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
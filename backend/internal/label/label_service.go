@@ -0,0 +1,365 @@
+// Package label provides user-defined labels for organizing emails across aliases
+// Feature: email-labels
+// Requirements: Labels have a name and color, are unique per user by name, and apply to many emails
+package label
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/events"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/repository"
+)
+
+const (
+	// DefaultLabelLimit is the default max labels per user
+	DefaultLabelLimit = 100
+	// MaxNameLength is the maximum label name length in characters
+	MaxNameLength = 50
+	// DefaultColor is the color of labels created without one
+	DefaultColor = "#6b7280"
+)
+
+// Service errors
+var (
+	ErrLabelNotFound     = errors.New("label not found")
+	ErrLabelExists       = errors.New("label already exists")
+	ErrLabelLimitReached = errors.New("label limit reached")
+	ErrInvalidName       = errors.New("label name must be 1 to 50 characters without control characters")
+	ErrInvalidColor      = errors.New("color must be a hex color such as #1a2b3c")
+)
+
+// Error codes for API responses
+const (
+	CodeValidationError   = "VALIDATION_ERROR"
+	CodeLabelNotFound     = "LABEL_NOT_FOUND"
+	CodeLabelExists       = "LABEL_EXISTS"
+	CodeLabelLimitReached = "LABEL_LIMIT_REACHED"
+	CodeInternalError     = "INTERNAL_ERROR"
+	CodeAuthTokenInvalid  = "AUTH_TOKEN_INVALID"
+)
+
+// colorPattern matches #rgb and #rrggbb hex colors
+var colorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// CreateLabelRequest represents the request to create a label
+type CreateLabelRequest struct {
+	Name  string `json:"name"`
+	Color string `json:"color,omitempty"` // DefaultColor when empty
+}
+
+// UpdateLabelRequest represents the request to rename or recolor a label
+type UpdateLabelRequest struct {
+	Name  *string `json:"name,omitempty"`
+	Color *string `json:"color,omitempty"`
+}
+
+// LabelResponse represents a label in API responses
+type LabelResponse struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Color       string    `json:"color"`
+	EmailCount  int       `json:"email_count"`
+	UnreadCount int       `json:"unread_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// LabelListResponse represents all labels of a user, ordered by name
+type LabelListResponse struct {
+	Labels []LabelResponse `json:"labels"`
+}
+
+// DeleteLabelResponse represents the response after deleting a label
+type DeleteLabelResponse struct {
+	Message         string `json:"message"`
+	LabelID         string `json:"label_id"`
+	EmailsUnlabeled int    `json:"emails_unlabeled"`
+}
+
+// Repository defines label data access
+type Repository interface {
+	CountByUser(ctx context.Context, userID uuid.UUID) (int, error)
+	// Create stores a label, or returns ErrLabelExists when the user has one with the same name
+	Create(ctx context.Context, label *repository.Label) error
+	GetByID(ctx context.Context, id uuid.UUID) (*repository.LabelWithCounts, error)
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]repository.LabelWithCounts, error)
+	// Update saves the name and color, or returns ErrLabelExists when the new name is taken
+	Update(ctx context.Context, label *repository.Label) error
+	// Delete removes a label from every email and deletes it
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// Service handles label business logic
+type Service struct {
+	repo       Repository
+	eventBus   events.EventBus
+	labelLimit int
+	logger     *slog.Logger
+}
+
+// ServiceConfig contains configuration for the label Service
+type ServiceConfig struct {
+	Repository Repository
+	EventBus   events.EventBus
+	LabelLimit int // Max labels per user (default: 100)
+	Logger     *slog.Logger
+}
+
+// NewService creates a new label Service instance
+func NewService(cfg ServiceConfig) *Service {
+	if cfg.LabelLimit <= 0 {
+		cfg.LabelLimit = DefaultLabelLimit
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+
+	return &Service{
+		repo:       cfg.Repository,
+		eventBus:   cfg.EventBus,
+		labelLimit: cfg.LabelLimit,
+		logger:     cfg.Logger,
+	}
+}
+
+// Create creates a label for the user
+func (s *Service) Create(ctx context.Context, userID uuid.UUID, req CreateLabelRequest) (*LabelResponse, error) {
+	name, err := normalizeName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	color := DefaultColor
+	if req.Color != "" {
+		if color, err = normalizeColor(req.Color); err != nil {
+			return nil, err
+		}
+	}
+
+	count, err := s.repo.CountByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count labels: %w", err)
+	}
+	if count >= s.labelLimit {
+		return nil, ErrLabelLimitReached
+	}
+
+	label := &repository.Label{
+		ID:     uuid.New(),
+		UserID: userID,
+		Name:   name,
+		Color:  color,
+	}
+	if err := s.repo.Create(ctx, label); err != nil {
+		if errors.Is(err, ErrLabelExists) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to create label: %w", err)
+	}
+
+	s.logger.Info("Label created", "label_id", label.ID, "user_id", userID)
+	s.publishLabelEvent(events.EventTypeLabelCreated, label)
+
+	response := toLabelResponse(&repository.LabelWithCounts{Label: *label})
+	return &response, nil
+}
+
+// List returns all labels of the user with their email counts, ordered by name
+func (s *Service) List(ctx context.Context, userID uuid.UUID) (*LabelListResponse, error) {
+	labels, err := s.repo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list labels: %w", err)
+	}
+
+	response := &LabelListResponse{Labels: make([]LabelResponse, len(labels))}
+	for i := range labels {
+		response.Labels[i] = toLabelResponse(&labels[i])
+	}
+	return response, nil
+}
+
+// Get returns a label of the user
+func (s *Service) Get(ctx context.Context, userID uuid.UUID, labelID string) (*LabelResponse, error) {
+	label, err := s.getOwnedLabel(ctx, userID, labelID)
+	if err != nil {
+		return nil, err
+	}
+	response := toLabelResponse(label)
+	return &response, nil
+}
+
+// Update renames or recolors a label of the user
+func (s *Service) Update(ctx context.Context, userID uuid.UUID, labelID string, req UpdateLabelRequest) (*LabelResponse, error) {
+	label, err := s.getOwnedLabel(ctx, userID, labelID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		if label.Name, err = normalizeName(*req.Name); err != nil {
+			return nil, err
+		}
+	}
+	if req.Color != nil {
+		if label.Color, err = normalizeColor(*req.Color); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.Update(ctx, &label.Label); err != nil {
+		if errors.Is(err, ErrLabelExists) || errors.Is(err, ErrLabelNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to update label: %w", err)
+	}
+
+	s.logger.Info("Label updated", "label_id", label.ID, "user_id", userID)
+	s.publishLabelEvent(events.EventTypeLabelUpdated, &label.Label)
+
+	response := toLabelResponse(label)
+	return &response, nil
+}
+
+// Delete deletes a label of the user, removing it from every email
+// The emails themselves are kept.
+func (s *Service) Delete(ctx context.Context, userID uuid.UUID, labelID string) (*DeleteLabelResponse, error) {
+	label, err := s.getOwnedLabel(ctx, userID, labelID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Delete(ctx, label.ID); err != nil {
+		if errors.Is(err, ErrLabelNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to delete label: %w", err)
+	}
+
+	s.logger.Info("Label deleted", "label_id", label.ID, "user_id", userID, "emails_unlabeled", label.EmailCount)
+	s.publishLabelDeletedEvent(userID, label.ID)
+
+	return &DeleteLabelResponse{
+		Message:         "Label deleted successfully",
+		LabelID:         label.ID.String(),
+		EmailsUnlabeled: label.EmailCount,
+	}, nil
+}
+
+// getOwnedLabel returns a label, hiding labels of other users as not found
+func (s *Service) getOwnedLabel(ctx context.Context, userID uuid.UUID, labelID string) (*repository.LabelWithCounts, error) {
+	id, err := uuid.Parse(labelID)
+	if err != nil {
+		return nil, ErrLabelNotFound
+	}
+
+	label, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrLabelNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get label: %w", err)
+	}
+	if label.UserID != userID {
+		return nil, ErrLabelNotFound
+	}
+	return label, nil
+}
+
+// normalizeName trims a label name and checks its length and characters
+func normalizeName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > MaxNameLength || !utf8.ValidString(name) {
+		return "", ErrInvalidName
+	}
+	if strings.IndexFunc(name, unicode.IsControl) >= 0 {
+		return "", ErrInvalidName
+	}
+	return name, nil
+}
+
+// normalizeColor returns a hex color as lowercase #rrggbb
+func normalizeColor(color string) (string, error) {
+	color = strings.TrimSpace(color)
+	if !colorPattern.MatchString(color) {
+		return "", ErrInvalidColor
+	}
+	color = strings.ToLower(color)
+	if len(color) == 4 {
+		color = string([]byte{'#', color[1], color[1], color[2], color[2], color[3], color[3]})
+	}
+	return color, nil
+}
+
+// toLabelResponse converts a label to its API representation
+func toLabelResponse(label *repository.LabelWithCounts) LabelResponse {
+	return LabelResponse{
+		ID:          label.ID.String(),
+		Name:        label.Name,
+		Color:       label.Color,
+		EmailCount:  label.EmailCount,
+		UnreadCount: label.UnreadCount,
+		CreatedAt:   label.CreatedAt,
+		UpdatedAt:   label.UpdatedAt,
+	}
+}
+
+// publishLabelEvent publishes a label_created or label_updated event to the event bus
+func (s *Service) publishLabelEvent(eventType string, label *repository.Label) {
+	if s.eventBus == nil {
+		return
+	}
+
+	data, err := json.Marshal(events.LabelEvent{
+		ID:        label.ID.String(),
+		Name:      label.Name,
+		Color:     label.Color,
+		UpdatedAt: label.UpdatedAt,
+	})
+	if err != nil {
+		s.logger.Warn("Failed to marshal label event", "type", eventType, "error", err)
+		return
+	}
+
+	s.publish(eventType, label.UserID, data)
+}
+
+// publishLabelDeletedEvent publishes a label_deleted event to the event bus
+func (s *Service) publishLabelDeletedEvent(userID, labelID uuid.UUID) {
+	if s.eventBus == nil {
+		return
+	}
+
+	data, err := json.Marshal(events.LabelDeletedEvent{
+		ID:        labelID.String(),
+		DeletedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		s.logger.Warn("Failed to marshal label_deleted event", "error", err)
+		return
+	}
+
+	s.publish(events.EventTypeLabelDeleted, userID, data)
+}
+
+// publish sends an event to the user's SSE connections
+func (s *Service) publish(eventType string, userID uuid.UUID, data json.RawMessage) {
+	event := events.Event{
+		ID:        uuid.New().String(),
+		Type:      eventType,
+		UserID:    userID.String(),
+		Data:      data,
+		Timestamp: time.Now().UTC(),
+	}
+	if err := s.eventBus.Publish(event); err != nil {
+		s.logger.Warn("Failed to publish label event", "type", eventType, "error", err)
+	}
+}
@@ -0,0 +1,261 @@
+package label
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/events"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/repository"
+	"pgregory.net/rapid"
+)
+
+// mockRepository implements Repository for testing
+type mockRepository struct {
+	labels map[uuid.UUID]*repository.LabelWithCounts
+}
+
+func newMockRepository() *mockRepository {
+	return &mockRepository{labels: make(map[uuid.UUID]*repository.LabelWithCounts)}
+}
+
+func (m *mockRepository) nameTaken(label *repository.Label) bool {
+	for _, l := range m.labels {
+		if l.ID != label.ID && l.UserID == label.UserID && strings.EqualFold(l.Name, label.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *mockRepository) CountByUser(ctx context.Context, userID uuid.UUID) (int, error) {
+	count := 0
+	for _, l := range m.labels {
+		if l.UserID == userID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *mockRepository) Create(ctx context.Context, label *repository.Label) error {
+	if m.nameTaken(label) {
+		return ErrLabelExists
+	}
+	label.CreatedAt = time.Now().UTC()
+	label.UpdatedAt = label.CreatedAt
+	m.labels[label.ID] = &repository.LabelWithCounts{Label: *label}
+	return nil
+}
+
+func (m *mockRepository) GetByID(ctx context.Context, id uuid.UUID) (*repository.LabelWithCounts, error) {
+	l, ok := m.labels[id]
+	if !ok {
+		return nil, ErrLabelNotFound
+	}
+	copied := *l
+	return &copied, nil
+}
+
+func (m *mockRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]repository.LabelWithCounts, error) {
+	var result []repository.LabelWithCounts
+	for _, l := range m.labels {
+		if l.UserID == userID {
+			result = append(result, *l)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockRepository) Update(ctx context.Context, label *repository.Label) error {
+	l, ok := m.labels[label.ID]
+	if !ok {
+		return ErrLabelNotFound
+	}
+	if m.nameTaken(label) {
+		return ErrLabelExists
+	}
+	label.UpdatedAt = time.Now().UTC()
+	l.Label = *label
+	return nil
+}
+
+func (m *mockRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if _, ok := m.labels[id]; !ok {
+		return ErrLabelNotFound
+	}
+	delete(m.labels, id)
+	return nil
+}
+
+// mockEventBus records published events
+type mockEventBus struct {
+	published []events.Event
+}
+
+func (m *mockEventBus) Publish(event events.Event) error {
+	m.published = append(m.published, event)
+	return nil
+}
+
+func (m *mockEventBus) Subscribe(userID string, handler events.EventHandler) func() {
+	return func() {}
+}
+
+func (m *mockEventBus) GetEventsSince(userID string, lastEventID string) ([]events.Event, error) {
+	return nil, nil
+}
+
+func newTestService(limit int) (*Service, *mockRepository, *mockEventBus) {
+	repo := newMockRepository()
+	bus := &mockEventBus{}
+	return NewService(ServiceConfig{Repository: repo, EventBus: bus, LabelLimit: limit}), repo, bus
+}
+
+// TestNormalizeColor verifies hex colors are accepted in any case and stored as lowercase #rrggbb
+func TestNormalizeColor(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		hex := rapid.StringMatching(`[0-9a-fA-F]{6}`).Draw(t, "hex")
+		color, err := normalizeColor("#" + hex)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if color != "#"+strings.ToLower(hex) {
+			t.Fatalf("Expected lowercase color, got %q", color)
+		}
+	})
+
+	if color, _ := normalizeColor("#AbC"); color != "#aabbcc" {
+		t.Errorf("Expected short color to expand, got %q", color)
+	}
+	for _, invalid := range []string{"", "red", "#12345", "123456", "#ggg000", "#1234567"} {
+		if _, err := normalizeColor(invalid); !errors.Is(err, ErrInvalidColor) {
+			t.Errorf("Expected ErrInvalidColor for %q, got %v", invalid, err)
+		}
+	}
+}
+
+// TestNormalizeName verifies names are trimmed and limited to 50 printable characters
+func TestNormalizeName(t *testing.T) {
+	if name, err := normalizeName("  Work  "); err != nil || name != "Work" {
+		t.Errorf("Expected trimmed name, got %q (%v)", name, err)
+	}
+	if _, err := normalizeName(strings.Repeat("é", MaxNameLength)); err != nil {
+		t.Errorf("Expected %d multi-byte characters to be accepted, got %v", MaxNameLength, err)
+	}
+	for _, invalid := range []string{"", "   ", strings.Repeat("a", MaxNameLength+1), "tab\tname", "new\nline"} {
+		if _, err := normalizeName(invalid); !errors.Is(err, ErrInvalidName) {
+			t.Errorf("Expected ErrInvalidName for %q, got %v", invalid, err)
+		}
+	}
+}
+
+// TestCreateLabel verifies defaults, duplicate names and the per-user limit
+func TestCreateLabel(t *testing.T) {
+	service, _, bus := newTestService(2)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	label, err := service.Create(ctx, userID, CreateLabelRequest{Name: "Receipts"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if label.Color != DefaultColor {
+		t.Errorf("Expected default color, got %q", label.Color)
+	}
+	if len(bus.published) != 1 || bus.published[0].Type != events.EventTypeLabelCreated || bus.published[0].UserID != userID.String() {
+		t.Fatalf("Expected label_created event for user, got %+v", bus.published)
+	}
+
+	if _, err := service.Create(ctx, userID, CreateLabelRequest{Name: "receipts"}); !errors.Is(err, ErrLabelExists) {
+		t.Fatalf("Expected ErrLabelExists, got %v", err)
+	}
+	if _, err := service.Create(ctx, uuid.New(), CreateLabelRequest{Name: "Receipts"}); err != nil {
+		t.Fatalf("Other users should be able to use the same name: %v", err)
+	}
+
+	if _, err := service.Create(ctx, userID, CreateLabelRequest{Name: "Travel", Color: "#FF0000"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := service.Create(ctx, userID, CreateLabelRequest{Name: "Work"}); !errors.Is(err, ErrLabelLimitReached) {
+		t.Fatalf("Expected ErrLabelLimitReached, got %v", err)
+	}
+}
+
+// TestLabelOwnership verifies labels of other users are reported as not found and left unchanged
+func TestLabelOwnership(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		service, repo, bus := newTestService(0)
+		ctx := context.Background()
+		ownerID := uuid.New()
+		otherID := uuid.New()
+
+		label, err := service.Create(ctx, ownerID, CreateLabelRequest{Name: rapid.StringMatching(`[A-Za-z]{1,20}`).Draw(t, "name")})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		published := len(bus.published)
+
+		newName := "Renamed"
+		if _, err := service.Get(ctx, otherID, label.ID); !errors.Is(err, ErrLabelNotFound) {
+			t.Fatalf("Expected ErrLabelNotFound for get, got %v", err)
+		}
+		if _, err := service.Update(ctx, otherID, label.ID, UpdateLabelRequest{Name: &newName}); !errors.Is(err, ErrLabelNotFound) {
+			t.Fatalf("Expected ErrLabelNotFound for update, got %v", err)
+		}
+		if _, err := service.Delete(ctx, otherID, label.ID); !errors.Is(err, ErrLabelNotFound) {
+			t.Fatalf("Expected ErrLabelNotFound for delete, got %v", err)
+		}
+		if _, err := service.Get(ctx, ownerID, "not-a-uuid"); !errors.Is(err, ErrLabelNotFound) {
+			t.Fatalf("Expected ErrLabelNotFound for invalid ID, got %v", err)
+		}
+		if len(repo.labels) != 1 || len(bus.published) != published {
+			t.Fatal("Labels must not change for non-owner")
+		}
+	})
+}
+
+// TestUpdateAndDeleteLabel verifies partial updates and the events they publish
+func TestUpdateAndDeleteLabel(t *testing.T) {
+	service, repo, bus := newTestService(0)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	label, _ := service.Create(ctx, userID, CreateLabelRequest{Name: "Work", Color: "#112233"})
+	other, _ := service.Create(ctx, userID, CreateLabelRequest{Name: "Home"})
+
+	color := "#ABCDEF"
+	updated, err := service.Update(ctx, userID, label.ID, UpdateLabelRequest{Color: &color})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if updated.Name != "Work" || updated.Color != "#abcdef" {
+		t.Fatalf("Expected only color to change, got %+v", updated)
+	}
+	if last := bus.published[len(bus.published)-1]; last.Type != events.EventTypeLabelUpdated {
+		t.Fatalf("Expected label_updated event, got %s", last.Type)
+	}
+
+	taken := "HOME"
+	if _, err := service.Update(ctx, userID, label.ID, UpdateLabelRequest{Name: &taken}); !errors.Is(err, ErrLabelExists) {
+		t.Fatalf("Expected ErrLabelExists, got %v", err)
+	}
+
+	repo.labels[uuid.MustParse(other.ID)].EmailCount = 3
+	deleted, err := service.Delete(ctx, userID, other.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if deleted.EmailsUnlabeled != 3 {
+		t.Errorf("Expected 3 emails unlabeled, got %d", deleted.EmailsUnlabeled)
+	}
+	if last := bus.published[len(bus.published)-1]; last.Type != events.EventTypeLabelDeleted {
+		t.Fatalf("Expected label_deleted event, got %s", last.Type)
+	}
+	if _, ok := repo.labels[uuid.MustParse(other.ID)]; ok {
+		t.Fatal("Expected label to be deleted")
+	}
+}
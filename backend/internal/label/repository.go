@@ -0,0 +1,146 @@
+package label
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/repository"
+)
+
+// labelColumns are the columns scanned by scanLabel, in order
+// The query must alias labels as l and LEFT JOIN email_labels as el and emails as e.
+const labelColumns = `
+	l.id, l.user_id, l.name, l.color, l.created_at, l.updated_at,
+	COUNT(e.id), COUNT(e.id) FILTER (WHERE NOT e.is_read)
+`
+
+// labelFrom joins a label with the emails it is applied to
+const labelFrom = `
+	FROM labels l
+	LEFT JOIN email_labels el ON el.label_id = l.id
+	LEFT JOIN emails e ON e.id = el.email_id AND e.deleted_at IS NULL
+`
+
+// PostgresRepository stores labels in PostgreSQL
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresRepository creates a new PostgresRepository
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{pool: pool}
+}
+
+// CountByUser returns the number of labels a user has
+func (r *PostgresRepository) CountByUser(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM labels WHERE user_id = $1`, userID).Scan(&count)
+	return count, err
+}
+
+// Create stores a label
+func (r *PostgresRepository) Create(ctx context.Context, label *repository.Label) error {
+	err := r.pool.QueryRow(ctx, `
+		INSERT INTO labels (id, user_id, name, color)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at, updated_at
+	`, label.ID, label.UserID, label.Name, label.Color).Scan(&label.CreatedAt, &label.UpdatedAt)
+	if err != nil {
+		// Check for unique constraint violation (name already used by the user)
+		if strings.Contains(err.Error(), "idx_labels_user_name") {
+			return ErrLabelExists
+		}
+		return fmt.Errorf("failed to insert label: %w", err)
+	}
+	return nil
+}
+
+// GetByID returns a label with its email counts
+func (r *PostgresRepository) GetByID(ctx context.Context, id uuid.UUID) (*repository.LabelWithCounts, error) {
+	label, err := scanLabel(r.pool.QueryRow(ctx, `
+		SELECT `+labelColumns+labelFrom+`
+		WHERE l.id = $1
+		GROUP BY l.id
+	`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrLabelNotFound
+		}
+		return nil, err
+	}
+	return label, nil
+}
+
+// ListByUser returns a user's labels with their email counts, ordered by name
+func (r *PostgresRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]repository.LabelWithCounts, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+labelColumns+labelFrom+`
+		WHERE l.user_id = $1
+		GROUP BY l.id
+		ORDER BY LOWER(l.name), l.id
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list labels: %w", err)
+	}
+	defer rows.Close()
+
+	labels := make([]repository.LabelWithCounts, 0)
+	for rows.Next() {
+		label, err := scanLabel(rows)
+		if err != nil {
+			return nil, err
+		}
+		labels = append(labels, *label)
+	}
+	return labels, rows.Err()
+}
+
+// Update saves a label's name and color
+func (r *PostgresRepository) Update(ctx context.Context, label *repository.Label) error {
+	err := r.pool.QueryRow(ctx, `
+		UPDATE labels
+		SET name = $2, color = $3, updated_at = (NOW() AT TIME ZONE 'utc')
+		WHERE id = $1
+		RETURNING updated_at
+	`, label.ID, label.Name, label.Color).Scan(&label.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrLabelNotFound
+		}
+		if strings.Contains(err.Error(), "idx_labels_user_name") {
+			return ErrLabelExists
+		}
+		return fmt.Errorf("failed to update label: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes a label; email_labels rows are removed by ON DELETE CASCADE
+func (r *PostgresRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.pool.Exec(ctx, `DELETE FROM labels WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete label: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrLabelNotFound
+	}
+	return nil
+}
+
+// scanLabel scans a row selected with labelColumns
+func scanLabel(row pgx.Row) (*repository.LabelWithCounts, error) {
+	var label repository.LabelWithCounts
+	err := row.Scan(
+		&label.ID, &label.UserID, &label.Name, &label.Color, &label.CreatedAt, &label.UpdatedAt,
+		&label.EmailCount, &label.UnreadCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &label, nil
+}
@@ -0,0 +1,30 @@
+package label
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes registers label routes with the Chi router
+// All routes require authentication via auth middleware
+func RegisterRoutes(r chi.Router, handler *Handler, authMiddleware func(next http.Handler) http.Handler) {
+	r.Route("/labels", func(r chi.Router) {
+		r.Use(authMiddleware)
+
+		// POST /api/v1/labels - Create a label (name, color)
+		r.Post("/", handler.Create)
+
+		// GET /api/v1/labels - List labels with email counts
+		r.Get("/", handler.List)
+
+		// GET /api/v1/labels/:id - Get a label
+		r.Get("/{id}", handler.Get)
+
+		// PATCH /api/v1/labels/:id - Rename or recolor a label
+		r.Patch("/{id}", handler.Update)
+
+		// DELETE /api/v1/labels/:id - Delete a label, keeping its emails
+		r.Delete("/{id}", handler.Delete)
+	})
+}
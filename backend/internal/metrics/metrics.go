@@ -181,6 +181,50 @@ var (
 	)
 )
 
+var (
+	// RetentionEmailsDeleted counts emails deleted by retention policies
+	RetentionEmailsDeleted = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "tempmail",
+			Subsystem: "retention",
+			Name:      "emails_deleted_total",
+			Help:      "Total number of emails deleted by retention policies by policy mode",
+		},
+		[]string{"mode"},
+	)
+
+	// RetentionBytesFreed counts email bytes freed by retention policies
+	RetentionBytesFreed = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "tempmail",
+			Subsystem: "retention",
+			Name:      "bytes_freed_total",
+			Help:      "Total size in bytes of emails deleted by retention policies",
+		},
+	)
+
+	// RetentionRunDuration measures how long a retention run takes
+	RetentionRunDuration = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "tempmail",
+			Subsystem: "retention",
+			Name:      "run_duration_seconds",
+			Help:      "Retention run duration in seconds",
+			Buckets:   []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300},
+		},
+	)
+
+	// RetentionRunErrors counts retention runs that failed
+	RetentionRunErrors = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "tempmail",
+			Subsystem: "retention",
+			Name:      "run_errors_total",
+			Help:      "Total number of retention runs that failed",
+		},
+	)
+)
+
 // responseWriter wraps http.ResponseWriter to capture status code and size
 type responseWriter struct {
 	http.ResponseWriter
@@ -216,6 +260,11 @@ func (rw *responseWriter) Flush() {
 	}
 }
 
+// Unwrap returns the wrapped writer, for http.ResponseController
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // Middleware returns a chi middleware that records HTTP metrics
 func Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,645 @@
+package parser
+
+import (
+	"errors"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	// Invitations name IANA zones; embed the database so lookups work in minimal containers
+	_ "time/tzdata"
+)
+
+// ContentTypeCalendar is the media type of iCalendar invitations
+const ContentTypeCalendar = "text/calendar"
+
+// MaxCalendarEvents limits how many events are kept from one calendar part
+const MaxCalendarEvents = 50
+
+// ErrNoCalendarEvents is returned by ParseCalendar when the data contains no VEVENT
+var ErrNoCalendarEvents = errors.New("no calendar events")
+
+// Calendar is the iCalendar (RFC 5545) content of a text/calendar part
+type Calendar struct {
+	Method string          `json:"method,omitempty"` // iTIP method: REQUEST, CANCEL, REPLY, PUBLISH
+	Events []CalendarEvent `json:"events"`
+}
+
+// CalendarEvent is a VEVENT
+type CalendarEvent struct {
+	UID          string              `json:"uid,omitempty"`
+	Sequence     int                 `json:"sequence"`
+	Status       string              `json:"status,omitempty"` // TENTATIVE, CONFIRMED or CANCELLED
+	Cancelled    bool                `json:"cancelled"`        // METHOD:CANCEL or STATUS:CANCELLED
+	Summary      string              `json:"summary,omitempty"`
+	Description  string              `json:"description,omitempty"`
+	Location     string              `json:"location,omitempty"`
+	URL          string              `json:"url,omitempty"`
+	Organizer    *CalendarAttendee   `json:"organizer,omitempty"`
+	Attendees    []CalendarAttendee  `json:"attendees,omitempty"`
+	Start        *CalendarTime       `json:"start,omitempty"`
+	End          *CalendarTime       `json:"end,omitempty"`
+	AllDay       bool                `json:"all_day"`
+	Recurrence   *CalendarRecurrence `json:"recurrence,omitempty"`
+	RecurrenceID *CalendarTime       `json:"recurrence_id,omitempty"` // Set when the event changes one occurrence
+}
+
+// CalendarAttendee is an ORGANIZER or ATTENDEE
+type CalendarAttendee struct {
+	Email  string `json:"email"`
+	Name   string `json:"name,omitempty"`
+	Role   string `json:"role,omitempty"`   // REQ-PARTICIPANT, OPT-PARTICIPANT, CHAIR...
+	Status string `json:"status,omitempty"` // PARTSTAT: NEEDS-ACTION, ACCEPTED, DECLINED...
+	RSVP   bool   `json:"rsvp,omitempty"`
+}
+
+// CalendarTime is a DTSTART, DTEND or RECURRENCE-ID value
+type CalendarTime struct {
+	UTC      time.Time `json:"utc"`                 // The instant; floating times are read as UTC
+	Local    string    `json:"local"`               // Wall time as written: 2006-01-02T15:04:05, or 2006-01-02 for dates
+	TimeZone string    `json:"time_zone,omitempty"` // TZID as written, "UTC", or empty for floating times
+}
+
+// CalendarRecurrence holds the recurrence properties of an event
+type CalendarRecurrence struct {
+	Rule       string      `json:"rule,omitempty"`       // RRULE value, e.g. FREQ=WEEKLY;BYDAY=MO
+	Dates      []time.Time `json:"dates,omitempty"`      // RDATE instants
+	Exceptions []time.Time `json:"exceptions,omitempty"` // EXDATE instants
+}
+
+// icsProperty is one unfolded content line
+type icsProperty struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// icsComponent is a BEGIN/END block with its properties and nested components
+type icsComponent struct {
+	name       string
+	props      []icsProperty
+	components []*icsComponent
+}
+
+// get returns the first property with the given name
+func (c *icsComponent) get(name string) (icsProperty, bool) {
+	for _, p := range c.props {
+		if p.name == name {
+			return p, true
+		}
+	}
+	return icsProperty{}, false
+}
+
+// IsCalendar reports whether a MIME part is an iCalendar invitation, by media type or .ics name
+func IsCalendar(mediaType, filename string) bool {
+	switch strings.ToLower(mediaType) {
+	case ContentTypeCalendar, "application/ics":
+		return true
+	}
+	return strings.EqualFold(filepath.Ext(filename), ".ics")
+}
+
+// ParseCalendar parses iCalendar data into its events
+// Parsing is lenient: unknown properties and malformed lines are skipped
+func ParseCalendar(data []byte) (*Calendar, error) {
+	root := parseICS(string(data))
+
+	cal := &Calendar{}
+	for _, vcalendar := range root.components {
+		if vcalendar.name != "VCALENDAR" {
+			continue
+		}
+		if method, ok := vcalendar.get("METHOD"); ok && cal.Method == "" {
+			cal.Method = strings.ToUpper(strings.TrimSpace(method.value))
+		}
+
+		zones := make(map[string]*icsComponent)
+		for _, c := range vcalendar.components {
+			if c.name == "VTIMEZONE" {
+				if tzid, ok := c.get("TZID"); ok {
+					zones[unquote(tzid.value)] = c
+				}
+			}
+		}
+
+		for _, c := range vcalendar.components {
+			if c.name != "VEVENT" || len(cal.Events) >= MaxCalendarEvents {
+				continue
+			}
+			cal.Events = append(cal.Events, parseEvent(c, zones, cal.Method))
+		}
+	}
+
+	if len(cal.Events) == 0 {
+		return nil, ErrNoCalendarEvents
+	}
+	return cal, nil
+}
+
+// parseICS unfolds content lines and builds the component tree
+func parseICS(data string) *icsComponent {
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	// Folded lines continue with a single space or tab (RFC 5545 3.1)
+	data = strings.ReplaceAll(data, "\n ", "")
+	data = strings.ReplaceAll(data, "\n\t", "")
+
+	root := &icsComponent{}
+	stack := []*icsComponent{root}
+	for _, line := range strings.Split(data, "\n") {
+		prop, ok := parseICSLine(strings.TrimRight(line, "\r"))
+		if !ok {
+			continue
+		}
+		current := stack[len(stack)-1]
+		switch prop.name {
+		case "BEGIN":
+			c := &icsComponent{name: strings.ToUpper(strings.TrimSpace(prop.value))}
+			current.components = append(current.components, c)
+			stack = append(stack, c)
+		case "END":
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		default:
+			current.props = append(current.props, prop)
+		}
+	}
+	return root
+}
+
+// parseICSLine splits a content line into name, parameters and value
+// Parameter values may be quoted and contain ':' or ';'
+func parseICSLine(line string) (icsProperty, bool) {
+	prop := icsProperty{params: make(map[string]string)}
+
+	i := strings.IndexAny(line, ";:")
+	if i <= 0 {
+		return prop, false
+	}
+	prop.name = strings.ToUpper(strings.TrimSpace(line[:i]))
+
+	for line[i] == ';' {
+		rest := line[i+1:]
+		eq := strings.IndexByte(rest, '=')
+		if eq < 0 {
+			return prop, false
+		}
+		name := strings.ToUpper(strings.TrimSpace(rest[:eq]))
+		rest = rest[eq+1:]
+
+		var value string
+		end := 0
+		if strings.HasPrefix(rest, `"`) {
+			closing := strings.IndexByte(rest[1:], '"')
+			if closing < 0 {
+				return prop, false
+			}
+			value = rest[1 : closing+1]
+			end = closing + 2
+		}
+		next := strings.IndexAny(rest[end:], ";:")
+		if next < 0 {
+			return prop, false
+		}
+		if end == 0 {
+			value = rest[:next]
+		}
+		prop.params[name] = value
+		i = len(line) - len(rest) + end + next
+	}
+
+	prop.value = line[i+1:]
+	return prop, true
+}
+
+// parseEvent converts a VEVENT component
+func parseEvent(c *icsComponent, zones map[string]*icsComponent, method string) CalendarEvent {
+	event := CalendarEvent{}
+	var duration time.Duration
+	var recurrence CalendarRecurrence
+
+	for _, p := range c.props {
+		switch p.name {
+		case "UID":
+			event.UID = strings.TrimSpace(p.value)
+		case "SEQUENCE":
+			event.Sequence, _ = strconv.Atoi(strings.TrimSpace(p.value))
+		case "STATUS":
+			event.Status = strings.ToUpper(strings.TrimSpace(p.value))
+		case "SUMMARY":
+			event.Summary = unescapeText(p.value)
+		case "DESCRIPTION":
+			event.Description = unescapeText(p.value)
+		case "LOCATION":
+			event.Location = unescapeText(p.value)
+		case "URL":
+			event.URL = strings.TrimSpace(p.value)
+		case "ORGANIZER":
+			organizer := parseAttendee(p)
+			event.Organizer = &organizer
+		case "ATTENDEE":
+			event.Attendees = append(event.Attendees, parseAttendee(p))
+		case "DTSTART":
+			event.Start = parseCalendarTime(p, zones)
+			event.AllDay = strings.EqualFold(p.params["VALUE"], "DATE") || len(strings.TrimSpace(p.value)) == 8
+		case "DTEND":
+			event.End = parseCalendarTime(p, zones)
+		case "DURATION":
+			duration = parseDuration(p.value)
+		case "RECURRENCE-ID":
+			event.RecurrenceID = parseCalendarTime(p, zones)
+		case "RRULE":
+			recurrence.Rule = strings.TrimSpace(p.value)
+		case "RDATE", "EXDATE":
+			for _, value := range strings.Split(p.value, ",") {
+				prop := icsProperty{name: p.name, params: p.params, value: value}
+				t := parseCalendarTime(prop, zones)
+				if t == nil {
+					continue
+				}
+				if p.name == "RDATE" {
+					recurrence.Dates = append(recurrence.Dates, t.UTC)
+				} else {
+					recurrence.Exceptions = append(recurrence.Exceptions, t.UTC)
+				}
+			}
+		}
+	}
+
+	// DTEND may be replaced by DURATION; all-day events without either last one day
+	if event.End == nil && event.Start != nil {
+		switch {
+		case duration > 0:
+			event.End = shiftCalendarTime(event.Start, duration)
+		case event.AllDay:
+			event.End = shiftCalendarTime(event.Start, 24*time.Hour)
+		}
+	}
+
+	if recurrence.Rule != "" || len(recurrence.Dates) > 0 || len(recurrence.Exceptions) > 0 {
+		event.Recurrence = &recurrence
+	}
+	event.Cancelled = method == "CANCEL" || event.Status == "CANCELLED"
+	return event
+}
+
+// parseAttendee reads a CAL-ADDRESS with its CN, ROLE, PARTSTAT and RSVP parameters
+func parseAttendee(p icsProperty) CalendarAttendee {
+	address := strings.TrimSpace(p.value)
+	if len(address) >= 7 && strings.EqualFold(address[:7], "mailto:") {
+		address = address[7:]
+	}
+	return CalendarAttendee{
+		Email:  strings.ToLower(address),
+		Name:   decodeParamText(p.params["CN"], ""),
+		Role:   strings.ToUpper(p.params["ROLE"]),
+		Status: strings.ToUpper(p.params["PARTSTAT"]),
+		RSVP:   strings.EqualFold(p.params["RSVP"], "TRUE"),
+	}
+}
+
+// calendarLayouts are the DATE-TIME and DATE forms of RFC 5545 3.3.4 and 3.3.5
+const (
+	icsDateTimeLayout = "20060102T150405"
+	icsDateLayout     = "20060102"
+	localTimeLayout   = "2006-01-02T15:04:05"
+	localDateLayout   = "2006-01-02"
+)
+
+// parseCalendarTime resolves a date or date-time value using its TZID
+func parseCalendarTime(p icsProperty, zones map[string]*icsComponent) *CalendarTime {
+	value := strings.TrimSpace(p.value)
+
+	if len(value) == 8 {
+		date, err := time.Parse(icsDateLayout, value)
+		if err != nil {
+			return nil
+		}
+		return &CalendarTime{UTC: date, Local: date.Format(localDateLayout)}
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(icsDateTimeLayout, strings.TrimSuffix(value, "Z"))
+		if err != nil {
+			return nil
+		}
+		return &CalendarTime{UTC: t, Local: t.Format(localTimeLayout), TimeZone: "UTC"}
+	}
+
+	wall, err := time.Parse(icsDateTimeLayout, value)
+	if err != nil {
+		return nil
+	}
+	ct := &CalendarTime{UTC: wall, Local: wall.Format(localTimeLayout)}
+
+	if tzid := unquote(p.params["TZID"]); tzid != "" {
+		ct.TimeZone = tzid
+		ct.UTC = resolveWallTime(wall, tzid, zones[tzid])
+	}
+	return ct
+}
+
+// resolveWallTime converts a wall time in tzid to UTC
+// IANA names are used directly; Windows names used by Outlook are mapped; otherwise the
+// VTIMEZONE definition in the calendar decides the offset. Unknown zones are read as UTC.
+func resolveWallTime(wall time.Time, tzid string, vtimezone *icsComponent) time.Time {
+	name := tzid
+	if iana, ok := windowsTimeZones[tzid]; ok {
+		name = iana
+	}
+	if loc, err := time.LoadLocation(name); err == nil && name != "" && name != "Local" {
+		return time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), 0, loc).UTC()
+	}
+
+	if vtimezone != nil {
+		if offset, ok := vtimezoneOffset(vtimezone, wall); ok {
+			return wall.Add(-offset)
+		}
+	}
+	return wall
+}
+
+// vtimezoneOffset returns the UTC offset in effect at a wall time according to a VTIMEZONE
+// The STANDARD or DAYLIGHT observance with the latest onset not after the wall time applies.
+func vtimezoneOffset(vtimezone *icsComponent, wall time.Time) (time.Duration, bool) {
+	var latest time.Time
+	var offset time.Duration
+	found := false
+
+	for _, observance := range vtimezone.components {
+		if observance.name != "STANDARD" && observance.name != "DAYLIGHT" {
+			continue
+		}
+		start, ok := observance.get("DTSTART")
+		if !ok {
+			continue
+		}
+		dtstart, err := time.Parse(icsDateTimeLayout, strings.TrimSpace(start.value))
+		if err != nil {
+			continue
+		}
+		to, ok := observance.get("TZOFFSETTO")
+		if !ok {
+			continue
+		}
+		toOffset, ok := parseUTCOffset(to.value)
+		if !ok {
+			continue
+		}
+
+		onset, ok := dtstart, !dtstart.After(wall)
+		if rule, hasRule := observance.get("RRULE"); hasRule {
+			// Check this year's and last year's transition
+			for _, year := range []int{wall.Year(), wall.Year() - 1} {
+				if t, valid := yearlyOnset(rule.value, dtstart, year); valid && !t.After(wall) && !t.Before(dtstart) {
+					onset, ok = t, true
+					break
+				}
+			}
+		}
+
+		if ok && (!found || onset.After(latest)) {
+			latest, offset, found = onset, toOffset, true
+		}
+	}
+	return offset, found
+}
+
+// byDayPattern matches RRULE BYDAY values such as -1SU or 2MO
+var byDayPattern = regexp.MustCompile(`^([+-]?\d{1,2})?(SU|MO|TU|WE|TH|FR|SA)$`)
+
+var icsWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// yearlyOnset computes a time zone transition in a year from a FREQ=YEARLY rule
+// Only the BYMONTH, BYDAY and BYMONTHDAY forms used in VTIMEZONE definitions are supported.
+func yearlyOnset(rule string, dtstart time.Time, year int) (time.Time, bool) {
+	parts := make(map[string]string)
+	for _, part := range strings.Split(strings.ToUpper(rule), ";") {
+		if k, v, ok := strings.Cut(part, "="); ok {
+			parts[k] = v
+		}
+	}
+	if parts["FREQ"] != "YEARLY" {
+		return time.Time{}, false
+	}
+
+	month := dtstart.Month()
+	if m, err := strconv.Atoi(parts["BYMONTH"]); err == nil && m >= 1 && m <= 12 {
+		month = time.Month(m)
+	}
+	clock := func(day int) time.Time {
+		return time.Date(year, month, day, dtstart.Hour(), dtstart.Minute(), dtstart.Second(), 0, time.UTC)
+	}
+
+	var onset time.Time
+	switch {
+	case parts["BYDAY"] != "":
+		m := byDayPattern.FindStringSubmatch(parts["BYDAY"])
+		if m == nil {
+			return time.Time{}, false
+		}
+		n := 1
+		if m[1] != "" {
+			n, _ = strconv.Atoi(m[1])
+		}
+		weekday := icsWeekdays[m[2]]
+		if n > 0 {
+			first := clock(1)
+			day := 1 + (int(weekday)-int(first.Weekday())+7)%7 + (n-1)*7
+			onset = clock(day)
+		} else {
+			last := clock(1).AddDate(0, 1, -1)
+			day := last.Day() - (int(last.Weekday())-int(weekday)+7)%7 + (n+1)*7
+			onset = clock(day)
+		}
+		if onset.Month() != month {
+			return time.Time{}, false
+		}
+	case parts["BYMONTHDAY"] != "":
+		day, err := strconv.Atoi(parts["BYMONTHDAY"])
+		if err != nil {
+			return time.Time{}, false
+		}
+		onset = clock(day)
+	default:
+		onset = clock(dtstart.Day())
+	}
+
+	if until := parts["UNTIL"]; until != "" {
+		if u, err := time.Parse(icsDateTimeLayout, strings.TrimSuffix(until, "Z")); err == nil && onset.After(u) {
+			return time.Time{}, false
+		}
+	}
+	return onset, true
+}
+
+// parseUTCOffset parses a TZOFFSETTO value such as +0530 or -0800
+func parseUTCOffset(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if len(value) != 5 && len(value) != 7 {
+		return 0, false
+	}
+	sign := time.Duration(1)
+	switch value[0] {
+	case '-':
+		sign = -1
+	case '+':
+	default:
+		return 0, false
+	}
+	hours, err1 := strconv.Atoi(value[1:3])
+	minutes, err2 := strconv.Atoi(value[3:5])
+	seconds := 0
+	var err3 error
+	if len(value) == 7 {
+		seconds, err3 = strconv.Atoi(value[5:7])
+	}
+	if err1 != nil || err2 != nil || err3 != nil {
+		return 0, false
+	}
+	return sign * (time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second), true
+}
+
+// durationPattern matches RFC 5545 durations such as PT1H30M, P1D or -P2W
+var durationPattern = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// parseDuration parses a DURATION value; invalid values return 0
+func parseDuration(value string) time.Duration {
+	m := durationPattern.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(value)))
+	if m == nil {
+		return 0
+	}
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, unit := range units {
+		if n, err := strconv.Atoi(m[i+2]); err == nil {
+			d += time.Duration(n) * unit
+		}
+	}
+	if m[1] == "-" {
+		d = -d
+	}
+	return d
+}
+
+// shiftCalendarTime returns t moved by d, keeping the wall time and time zone consistent
+func shiftCalendarTime(t *CalendarTime, d time.Duration) *CalendarTime {
+	shifted := &CalendarTime{UTC: t.UTC.Add(d), TimeZone: t.TimeZone}
+	layout := localTimeLayout
+	if len(t.Local) == len(localDateLayout) {
+		layout = localDateLayout
+	}
+	if local, err := time.Parse(layout, t.Local); err == nil {
+		shifted.Local = local.Add(d).Format(layout)
+	}
+	return shifted
+}
+
+// unescapeText decodes an RFC 5545 TEXT value
+func unescapeText(value string) string {
+	if !strings.Contains(value, `\`) {
+		return strings.TrimSpace(value)
+	}
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i+1 == len(value) {
+			b.WriteByte(value[i])
+			continue
+		}
+		i++
+		switch value[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(value[i])
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// unquote strips the quotes some clients put around TZID values
+func unquote(value string) string {
+	return strings.Trim(strings.TrimSpace(value), `"`)
+}
+
+// windowsTimeZones maps the Windows zone names Outlook and Exchange write as TZID to IANA names
+var windowsTimeZones = map[string]string{
+	"Dateline Standard Time":         "Etc/GMT+12",
+	"Hawaiian Standard Time":         "Pacific/Honolulu",
+	"Alaskan Standard Time":          "America/Anchorage",
+	"Pacific Standard Time":          "America/Los_Angeles",
+	"Mountain Standard Time":         "America/Denver",
+	"US Mountain Standard Time":      "America/Phoenix",
+	"Central Standard Time":          "America/Chicago",
+	"Eastern Standard Time":          "America/New_York",
+	"Atlantic Standard Time":         "America/Halifax",
+	"E. South America Standard Time": "America/Sao_Paulo",
+	"UTC":                            "UTC",
+	"GMT Standard Time":              "Europe/London",
+	"Greenwich Standard Time":        "Atlantic/Reykjavik",
+	"W. Europe Standard Time":        "Europe/Berlin",
+	"Romance Standard Time":          "Europe/Paris",
+	"Central Europe Standard Time":   "Europe/Budapest",
+	"Central European Standard Time": "Europe/Warsaw",
+	"GTB Standard Time":              "Europe/Bucharest",
+	"FLE Standard Time":              "Europe/Kiev",
+	"Russian Standard Time":          "Europe/Moscow",
+	"Arabian Standard Time":          "Asia/Dubai",
+	"India Standard Time":            "Asia/Kolkata",
+	"SE Asia Standard Time":          "Asia/Jakarta",
+	"China Standard Time":            "Asia/Shanghai",
+	"Singapore Standard Time":        "Asia/Singapore",
+	"Tokyo Standard Time":            "Asia/Tokyo",
+	"Korea Standard Time":            "Asia/Seoul",
+	"AUS Eastern Standard Time":      "Australia/Sydney",
+	"New Zealand Standard Time":      "Pacific/Auckland",
+	"W. Australia Standard Time":     "Australia/Perth",
+	"Cen. Australia Standard Time":   "Australia/Adelaide",
+	"South Africa Standard Time":     "Africa/Johannesburg",
+	"Egypt Standard Time":            "Africa/Cairo",
+	"Turkey Standard Time":           "Europe/Istanbul",
+	"Israel Standard Time":           "Asia/Jerusalem",
+	"Pakistan Standard Time":         "Asia/Karachi",
+	"Bangladesh Standard Time":       "Asia/Dhaka",
+	"Taipei Standard Time":           "Asia/Taipei",
+	"SA Pacific Standard Time":       "America/Bogota",
+	"Argentina Standard Time":        "America/Argentina/Buenos_Aires",
+	"Central Standard Time (Mexico)": "America/Mexico_City",
+	"Canada Central Standard Time":   "America/Regina",
+	"Newfoundland Standard Time":     "America/St_Johns",
+	"Azores Standard Time":           "Atlantic/Azores",
+	"E. Europe Standard Time":        "Europe/Chisinau",
+	"West Pacific Standard Time":     "Pacific/Port_Moresby",
+	"N. Central Asia Standard Time":  "Asia/Novosibirsk",
+	"North Asia East Standard Time":  "Asia/Irkutsk",
+	"Myanmar Standard Time":          "Asia/Yangon",
+	"Nepal Standard Time":            "Asia/Kathmandu",
+}
+
+// extractCalendar parses the first iCalendar part of a raw message
+// Returns nil when the message has no invitation with events
+func extractCalendar(raw []byte) *Calendar {
+	// Cheap check so ordinary mail is not read twice
+	if !containsFold(raw, "text/calendar") && !containsFold(raw, "application/ics") && !containsFold(raw, ".ics") {
+		return nil
+	}
+
+	header, data, ok := findPart(raw, IsCalendar)
+	if !ok {
+		return nil
+	}
+	_, params, _ := ParseMediaType(header.Get(HeaderContentType))
+	decoded, _ := DecodeCharset(data, params["charset"])
+
+	cal, err := ParseCalendar(decoded)
+	if err != nil {
+		return nil
+	}
+	return cal
+}
@@ -0,0 +1,236 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+)
+
+// outlookInvite is a meeting request as sent by Outlook with a Windows time zone name
+const outlookInvite = "BEGIN:VCALENDAR\r\n" +
+	"METHOD:REQUEST\r\n" +
+	"PRODID:Microsoft Exchange Server 2010\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VTIMEZONE\r\n" +
+	"TZID:W. Europe Standard Time\r\n" +
+	"BEGIN:STANDARD\r\n" +
+	"DTSTART:16010101T030000\r\n" +
+	"TZOFFSETFROM:+0200\r\n" +
+	"TZOFFSETTO:+0100\r\n" +
+	"RRULE:FREQ=YEARLY;INTERVAL=1;BYDAY=-1SU;BYMONTH=10\r\n" +
+	"END:STANDARD\r\n" +
+	"BEGIN:DAYLIGHT\r\n" +
+	"DTSTART:16010101T020000\r\n" +
+	"TZOFFSETFROM:+0100\r\n" +
+	"TZOFFSETTO:+0200\r\n" +
+	"RRULE:FREQ=YEARLY;INTERVAL=1;BYDAY=-1SU;BYMONTH=3\r\n" +
+	"END:DAYLIGHT\r\n" +
+	"END:VTIMEZONE\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"ORGANIZER;CN=\"Müller, Anna\":mailto:Anna.Mueller@example.de\r\n" +
+	"ATTENDEE;ROLE=REQ-PARTICIPANT;PARTSTAT=NEEDS-ACTION;RSVP=TRUE;CN=me@webrana.id:mailto:me@webrana.id\r\n" +
+	"ATTENDEE;ROLE=OPT-PARTICIPANT;PARTSTAT=ACCEPTED;CN=\"Team: Ops\":mailto:ops@example.de\r\n" +
+	"DESCRIPTION;LANGUAGE=de-DE:Agenda:\\n1. Zahlen\\, Budget\\; Plan\\n2. Sonstiges\r\n" +
+	"RRULE:FREQ=WEEKLY;UNTIL=20240430T080000Z;INTERVAL=1;BYDAY=TU\r\n" +
+	"EXDATE;TZID=W. Europe Standard Time:20240409T100000\r\n" +
+	"UID:040000008200E00074C5B7101A82E00800000000\r\n" +
+	"SUMMARY;LANGUAGE=de-DE:Quartalsplanung Q2\r\n" +
+	"DTSTART;TZID=W. Europe Standard Time:20240402T100000\r\n" +
+	"DTEND;TZID=W. Europe Standard Time:20240402T113000\r\n" +
+	"LOCATION;LANGUAGE=de-DE:Raum 4.12 / Teams\r\n" +
+	"SEQUENCE:2\r\n" +
+	"STATUS:CONFIRMED\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"DESCRIPTION:REMINDER\r\n" +
+	"TRIGGER;RELATED=START:-PT15M\r\n" +
+	"ACTION:DISPLAY\r\n" +
+	"END:VALARM\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+// TestParseCalendar_Outlook verifies attendees, escaping, recurrence and Windows time zones
+func TestParseCalendar_Outlook(t *testing.T) {
+	cal, err := ParseCalendar([]byte(outlookInvite))
+	if err != nil {
+		t.Fatalf("ParseCalendar failed: %v", err)
+	}
+	if cal.Method != "REQUEST" || len(cal.Events) != 1 {
+		t.Fatalf("Unexpected calendar %+v", cal)
+	}
+	event := cal.Events[0]
+
+	if event.Summary != "Quartalsplanung Q2" || event.Location != "Raum 4.12 / Teams" || event.Sequence != 2 || event.Cancelled {
+		t.Errorf("Unexpected event fields %+v", event)
+	}
+	if event.Description != "Agenda:\n1. Zahlen, Budget; Plan\n2. Sonstiges" {
+		t.Errorf("Description = %q", event.Description)
+	}
+
+	if event.Organizer == nil || event.Organizer.Email != "anna.mueller@example.de" || event.Organizer.Name != "Müller, Anna" {
+		t.Errorf("Organizer = %+v", event.Organizer)
+	}
+	if len(event.Attendees) != 2 {
+		t.Fatalf("Expected 2 attendees, got %+v", event.Attendees)
+	}
+	if a := event.Attendees[0]; a.Email != "me@webrana.id" || !a.RSVP || a.Status != "NEEDS-ACTION" || a.Role != "REQ-PARTICIPANT" {
+		t.Errorf("First attendee = %+v", a)
+	}
+	if a := event.Attendees[1]; a.Name != "Team: Ops" || a.Status != "ACCEPTED" || a.RSVP {
+		t.Errorf("Second attendee = %+v", a)
+	}
+
+	// 10:00 in Berlin summer time is 08:00 UTC
+	wantStart := time.Date(2024, 4, 2, 8, 0, 0, 0, time.UTC)
+	if event.Start == nil || !event.Start.UTC.Equal(wantStart) || event.Start.Local != "2024-04-02T10:00:00" || event.Start.TimeZone != "W. Europe Standard Time" {
+		t.Errorf("Start = %+v", event.Start)
+	}
+	if event.End == nil || !event.End.UTC.Equal(wantStart.Add(90*time.Minute)) {
+		t.Errorf("End = %+v", event.End)
+	}
+	if event.AllDay {
+		t.Error("Timed event reported as all day")
+	}
+
+	if event.Recurrence == nil || event.Recurrence.Rule != "FREQ=WEEKLY;UNTIL=20240430T080000Z;INTERVAL=1;BYDAY=TU" {
+		t.Fatalf("Recurrence = %+v", event.Recurrence)
+	}
+	if len(event.Recurrence.Exceptions) != 1 || !event.Recurrence.Exceptions[0].Equal(time.Date(2024, 4, 9, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("Exceptions = %v", event.Recurrence.Exceptions)
+	}
+}
+
+// TestParseCalendar_Variants verifies cancellations, all-day events, durations and time zone forms
+func TestParseCalendar_Variants(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\n" +
+		"METHOD:CANCEL\n" +
+		"BEGIN:VTIMEZONE\n" +
+		"TZID:Custom/Jakarta\n" +
+		"BEGIN:STANDARD\n" +
+		"DTSTART:19700101T000000\n" +
+		"TZOFFSETFROM:+0700\n" +
+		"TZOFFSETTO:+0700\n" +
+		"END:STANDARD\n" +
+		"END:VTIMEZONE\n" +
+		"BEGIN:VEVENT\n" +
+		"UID:all-day@example.com\n" +
+		"SUMMARY:Company holiday\n" +
+		"DTSTART;VALUE=DATE:20241225\n" +
+		"END:VEVENT\n" +
+		"BEGIN:VEVENT\n" +
+		"UID:duration@example.com\n" +
+		"SUMMARY:Standup with a very long title that Google folds across\n" +
+		"  two lines\n" +
+		"DTSTART;TZID=\"America/New_York\":20240115T093000\n" +
+		"DURATION:PT15M\n" +
+		"STATUS:CANCELLED\n" +
+		"END:VEVENT\n" +
+		"BEGIN:VEVENT\n" +
+		"UID:custom@example.com\n" +
+		"DTSTART;TZID=Custom/Jakarta:20240301T090000\n" +
+		"DTEND:20240301T030000Z\n" +
+		"RECURRENCE-ID;TZID=Custom/Jakarta:20240228T090000\n" +
+		"END:VEVENT\n" +
+		"END:VCALENDAR\n"
+
+	cal, err := ParseCalendar([]byte(ics))
+	if err != nil {
+		t.Fatalf("ParseCalendar failed: %v", err)
+	}
+	if cal.Method != "CANCEL" || len(cal.Events) != 3 {
+		t.Fatalf("Unexpected calendar %+v", cal)
+	}
+
+	holiday := cal.Events[0]
+	if !holiday.AllDay || holiday.Start.Local != "2024-12-25" || holiday.End == nil || holiday.End.Local != "2024-12-26" || !holiday.Cancelled {
+		t.Errorf("All-day event = %+v start=%+v end=%+v", holiday, holiday.Start, holiday.End)
+	}
+
+	standup := cal.Events[1]
+	if standup.Summary != "Standup with a very long title that Google folds across two lines" {
+		t.Errorf("Summary = %q", standup.Summary)
+	}
+	if !standup.Start.UTC.Equal(time.Date(2024, 1, 15, 14, 30, 0, 0, time.UTC)) || !standup.End.UTC.Equal(time.Date(2024, 1, 15, 14, 45, 0, 0, time.UTC)) || standup.End.Local != "2024-01-15T09:45:00" {
+		t.Errorf("Standup times = %+v %+v", standup.Start, standup.End)
+	}
+
+	custom := cal.Events[2]
+	if !custom.Start.UTC.Equal(time.Date(2024, 3, 1, 2, 0, 0, 0, time.UTC)) || custom.End.TimeZone != "UTC" {
+		t.Errorf("VTIMEZONE resolution = %+v %+v", custom.Start, custom.End)
+	}
+	if custom.RecurrenceID == nil || custom.RecurrenceID.Local != "2024-02-28T09:00:00" {
+		t.Errorf("RecurrenceID = %+v", custom.RecurrenceID)
+	}
+
+	if _, err := ParseCalendar([]byte("BEGIN:VCALENDAR\nBEGIN:VTODO\nEND:VTODO\nEND:VCALENDAR\n")); err != ErrNoCalendarEvents {
+		t.Errorf("Expected ErrNoCalendarEvents, got %v", err)
+	}
+}
+
+// TestVTIMEZONEOffset_MatchesTZDatabase verifies VTIMEZONE rules agree with the IANA database
+func TestVTIMEZONEOffset_MatchesTZDatabase(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("LoadLocation failed: %v", err)
+	}
+	zone := parseICS(outlookInvite).components[0].components[0]
+
+	rapid.Check(t, func(t *rapid.T) {
+		instant := time.Unix(rapid.Int64Range(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC).Unix(), time.Date(2035, 1, 1, 0, 0, 0, 0, time.UTC).Unix()).Draw(t, "instant"), 0)
+		local := instant.In(berlin)
+		wall := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), 0, time.UTC)
+
+		// Wall times within two hours of a transition are ambiguous or skipped
+		_, offset := local.Zone()
+		for _, shift := range []time.Duration{-2 * time.Hour, 2 * time.Hour} {
+			if _, other := instant.Add(shift).In(berlin).Zone(); other != offset {
+				t.Skip("near transition")
+			}
+		}
+
+		got := resolveWallTime(wall, "Test/Berlin", zone)
+		if !got.Equal(instant) {
+			t.Fatalf("VTIMEZONE resolved %s to %s, want %s", wall, got, instant.UTC())
+		}
+	})
+}
+
+// TestParseICSLine_NeverPanics verifies malformed content lines are rejected safely
+func TestParseICSLine_NeverPanics(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		line := rapid.StringMatching(`[A-Z;:="\-a-z0-9 ]{0,40}`).Draw(t, "line")
+		parseICSLine(line)
+		ParseCalendar([]byte("BEGIN:VCALENDAR\nBEGIN:VEVENT\n" + line + "\nEND:VEVENT\nEND:VCALENDAR\n"))
+	})
+}
+
+// TestParse_Calendar verifies invitations are found in multipart/alternative mail
+func TestParse_Calendar(t *testing.T) {
+	email := "From: anna.mueller@example.de\r\n" +
+		"To: me@webrana.id\r\n" +
+		"Subject: Quartalsplanung Q2\r\n" +
+		"Content-Type: multipart/alternative; boundary=\"invite\"\r\n" +
+		"\r\n" +
+		"--invite\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" +
+		"You have been invited.\r\n" +
+		"--invite\r\n" +
+		"Content-Type: text/calendar; charset=\"utf-8\"; method=REQUEST\r\n" +
+		"Content-Transfer-Encoding: base64\r\n" +
+		"\r\n" +
+		string(EncodeBase64([]byte(outlookInvite))) + "\r\n" +
+		"--invite--\r\n"
+
+	parsed, err := NewEmailParser().Parse([]byte(email))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if parsed.Calendar == nil || len(parsed.Calendar.Events) != 1 || parsed.Calendar.Events[0].Organizer.Name != "Müller, Anna" {
+		t.Fatalf("Calendar = %+v", parsed.Calendar)
+	}
+	if !strings.Contains(parsed.BodyText, "You have been invited.") {
+		t.Errorf("BodyText = %q", parsed.BodyText)
+	}
+}
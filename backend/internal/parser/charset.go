@@ -0,0 +1,200 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/ianaindex"
+	"golang.org/x/text/transform"
+)
+
+// ErrUnknownCharset is returned by ConvertCharset for labels missing from the registry
+// The returned data is still usable: it is decoded with FallbackCharset when it is not UTF-8
+var ErrUnknownCharset = errors.New("unknown charset")
+
+// FallbackCharset decodes undeclared or unknown 8-bit content; every byte sequence is valid in it
+const FallbackCharset = "windows-1252"
+
+// charsetAliases maps labels seen in real mail that the WHATWG registry does not list
+var charsetAliases = map[string]string{
+	"cp932":         "shift_jis",
+	"cp936":         "gbk",
+	"ms936":         "gbk",
+	"euc-cn":        "gbk",
+	"cp949":         "euc-kr",
+	"cp950":         "big5",
+	"ms950":         "big5",
+	"iso-2022-jp-1": "iso-2022-jp",
+	"iso-2022-jp-2": "iso-2022-jp",
+	"cp20866":       "koi8-r",
+	"cp21866":       "koi8-u",
+	"cp28591":       "iso-8859-1",
+	"cp65001":       "utf-8",
+}
+
+// CharsetInfo describes how a MIME body or RFC 2047 encoded word was decoded
+type CharsetInfo struct {
+	Label    string // Charset as declared, normalized to lower case; empty when undeclared
+	Charset  string // Canonical name of the charset actually used
+	Known    bool   // Whether the declared label was found in the registry
+	Replaced bool   // Whether invalid sequences were replaced with U+FFFD
+}
+
+// LookupCharset resolves a MIME charset label to an encoding and its canonical name
+// The WHATWG registry is consulted first, so latin1 and us-ascii decode as windows-1252 the way
+// browsers do; IANA names cover the remaining legacy code pages. UTF-8 returns a nil encoding.
+func LookupCharset(label string) (encoding.Encoding, string, bool) {
+	label = normalizeCharsetLabel(label)
+	if alias, ok := charsetAliases[label]; ok {
+		label = alias
+	}
+
+	switch label {
+	case "utf-8", "utf8":
+		return nil, "utf-8", true
+	case "us-ascii", "ascii":
+		// ASCII is a subset of UTF-8; 8-bit bytes in "ASCII" mail are almost always windows-1252
+		return nil, "us-ascii", true
+	}
+
+	if enc, err := htmlindex.Get(label); err == nil {
+		name, err := htmlindex.Name(enc)
+		if err != nil {
+			name = label
+		}
+		if name == "utf-8" {
+			return nil, name, true
+		}
+		return enc, name, true
+	}
+
+	if enc, err := ianaindex.MIME.Encoding(label); err == nil && enc != nil {
+		name, err := ianaindex.MIME.Name(enc)
+		if err != nil {
+			name = label
+		}
+		return enc, strings.ToLower(name), true
+	}
+
+	return nil, "", false
+}
+
+// normalizeCharsetLabel lower-cases a label and strips quotes and surrounding whitespace
+func normalizeCharsetLabel(label string) string {
+	return strings.ToLower(strings.Trim(strings.TrimSpace(label), `"'`))
+}
+
+// DecodeCharset converts data in the labelled charset to valid UTF-8
+// Decoding never fails: invalid sequences become U+FFFD, and undeclared or unknown charsets
+// keep valid UTF-8 as it is and decode anything else with FallbackCharset.
+func DecodeCharset(data []byte, label string) ([]byte, CharsetInfo) {
+	info := CharsetInfo{Label: normalizeCharsetLabel(label)}
+
+	enc, name, known := LookupCharset(info.Label)
+	info.Known = known || info.Label == ""
+	info.Charset = name
+
+	// Undeclared, unknown or 8-bit "ASCII" content: keep UTF-8, otherwise use the fallback
+	if !known || info.Label == "" || name == "us-ascii" {
+		if utf8.Valid(data) {
+			if name == "" {
+				info.Charset = "utf-8"
+			}
+			return data, info
+		}
+		enc, info.Charset, _ = LookupCharset(FallbackCharset)
+	}
+
+	// Declared UTF-8
+	if enc == nil {
+		if utf8.Valid(data) {
+			return data, info
+		}
+		info.Replaced = true
+		return bytes.ToValidUTF8(data, []byte(string(utf8.RuneError))), info
+	}
+
+	decoded, replaced := decodeLossy(enc.NewDecoder(), data)
+	info.Replaced = replaced
+	return decoded, info
+}
+
+// decodeLossy runs a decoder over data, replacing each byte it rejects with U+FFFD
+func decodeLossy(decoder transform.Transformer, data []byte) ([]byte, bool) {
+	var out bytes.Buffer
+	out.Grow(len(data))
+	dst := make([]byte, 4096)
+	replaced := false
+
+	src := data
+	for len(src) > 0 {
+		nDst, nSrc, err := decoder.Transform(dst, src, true)
+		out.Write(dst[:nDst])
+		src = src[nSrc:]
+
+		switch {
+		case err == nil:
+		case errors.Is(err, transform.ErrShortDst):
+			if nDst == 0 && nSrc == 0 {
+				dst = make([]byte, 2*len(dst))
+			}
+		default:
+			out.WriteRune(utf8.RuneError)
+			if len(src) > 0 {
+				src = src[1:]
+			}
+			decoder.Reset()
+			replaced = true
+		}
+	}
+
+	// Decoders map undefined code points to U+FFFD themselves
+	if !replaced && bytes.ContainsRune(out.Bytes(), utf8.RuneError) && !bytes.ContainsRune(data, utf8.RuneError) {
+		replaced = true
+	}
+	if !utf8.Valid(out.Bytes()) {
+		return bytes.ToValidUTF8(out.Bytes(), []byte(string(utf8.RuneError))), true
+	}
+	return out.Bytes(), replaced
+}
+
+// charsetLog collects the charsets detected while parsing a single message
+// A nil *charsetLog discards everything, so helpers can be used outside Parse
+type charsetLog struct {
+	charset  string
+	warnings []string
+}
+
+// record notes a decoded body or header; the first body charset becomes the message charset
+func (l *charsetLog) record(where string, info CharsetInfo) {
+	if l == nil {
+		return
+	}
+	if where == "body" && l.charset == "" {
+		l.charset = info.Charset
+	}
+	if !info.Known {
+		l.warnings = append(l.warnings, fmt.Sprintf("%s: unknown charset %q decoded as %s", where, info.Label, info.Charset))
+	}
+	if info.Replaced {
+		l.warnings = append(l.warnings, fmt.Sprintf("%s: invalid %s sequences replaced", where, info.Charset))
+	}
+}
+
+// charsetReader returns a mime.WordDecoder CharsetReader backed by the registry
+// Unknown charsets degrade to the fallback instead of leaving the encoded word undecoded
+func (l *charsetLog) charsetReader(label string, input io.Reader) (io.Reader, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+	decoded, info := DecodeCharset(data, label)
+	l.record("header", info)
+	return bytes.NewReader(decoded), nil
+}
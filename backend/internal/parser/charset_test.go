@@ -0,0 +1,165 @@
+package parser
+
+import (
+	"encoding/base64"
+	"errors"
+	"mime"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"pgregory.net/rapid"
+)
+
+// charsetSamples pairs a label with text that exists in its repertoire
+var charsetSamples = []struct {
+	label string
+	text  string
+	want  string // Canonical name recorded for diagnostics
+}{
+	{"ISO-2022-JP", "こんにちは、世界", "iso-2022-jp"},
+	{"Shift_JIS", "認証コード", "shift_jis"},
+	{"cp932", "認証コード", "shift_jis"},
+	{"EUC-JP", "東京都", "euc-jp"},
+	{"GBK", "验证码", "gbk"},
+	{"gb2312", "验证码", "gbk"},
+	{"GB18030", "验证码 €", "gb18030"},
+	{"Big5", "驗證碼", "big5"},
+	{"EUC-KR", "인증 코드", "euc-kr"},
+	{"KOI8-R", "Привет, мир", "koi8-r"},
+	{"windows-1251", "Код подтверждения", "windows-1251"},
+	{"ISO-8859-2", "Zażółć gęślą jaźń", "iso-8859-2"},
+	{"iso-8859-5", "Привет", "iso-8859-5"},
+	{"ISO-8859-7", "Καλημέρα", "iso-8859-7"},
+	{"ISO-8859-15", "Prix: 10 €", "iso-8859-15"},
+	{"latin1", "Café crème", "windows-1252"},
+	{"Windows-1252", "“quoted” – dash", "windows-1252"},
+	{"\"utf-8\"", "héllo wörld", "utf-8"},
+	{"IBM437", "╔═╗ box", "ibm437"},
+}
+
+// TestDecodeCharset_Registry verifies every sample survives encoding and decoding
+func TestDecodeCharset_Registry(t *testing.T) {
+	for _, tt := range charsetSamples {
+		t.Run(tt.label, func(t *testing.T) {
+			encoded := []byte(tt.text)
+			if enc, _, ok := LookupCharset(tt.label); !ok {
+				t.Fatalf("Charset %q not in registry", tt.label)
+			} else if enc != nil {
+				var err error
+				encoded, err = enc.NewEncoder().Bytes([]byte(tt.text))
+				if err != nil {
+					t.Fatalf("Failed to encode sample: %v", err)
+				}
+			}
+
+			decoded, info := DecodeCharset(encoded, tt.label)
+			if string(decoded) != tt.text {
+				t.Errorf("DecodeCharset() = %q, want %q", decoded, tt.text)
+			}
+			if info.Charset != tt.want || !info.Known || info.Replaced {
+				t.Errorf("Unexpected info %+v, want charset %q", info, tt.want)
+			}
+		})
+	}
+}
+
+// TestDecodeCharset_AlwaysValidUTF8 verifies arbitrary bytes never produce invalid UTF-8
+func TestDecodeCharset_AlwaysValidUTF8(t *testing.T) {
+	labels := []string{"", "utf-8", "us-ascii", "x-unknown", "iso-2022-jp", "shift_jis", "euc-jp", "gb18030", "big5", "euc-kr", "koi8-r", "utf-16"}
+
+	rapid.Check(t, func(t *rapid.T) {
+		data := rapid.SliceOf(rapid.Byte()).Draw(t, "data")
+		label := rapid.SampledFrom(labels).Draw(t, "label")
+
+		decoded, info := DecodeCharset(data, label)
+		if !utf8.Valid(decoded) {
+			t.Fatalf("DecodeCharset(%q) produced invalid UTF-8 %q", label, decoded)
+		}
+		if info.Charset == "" {
+			t.Fatalf("No charset recorded for %q", label)
+		}
+		if utf8.Valid(data) && (label == "" || label == "utf-8" || label == "x-unknown") && string(decoded) != string(data) {
+			t.Fatalf("Valid UTF-8 must pass through unchanged for %q", label)
+		}
+	})
+}
+
+// TestConvertCharset_Degrades verifies invalid sequences and unknown charsets
+func TestConvertCharset_Degrades(t *testing.T) {
+	// Truncated Shift_JIS double-byte character
+	result, err := ConvertCharset([]byte{0x82, 0xa0, 0x82}, "shift_jis")
+	if err != nil || string(result) != "あ�" {
+		t.Errorf("ConvertCharset() = %q, %v", result, err)
+	}
+
+	// Invalid UTF-8 is replaced rather than stored
+	if result, _ := ConvertCharset([]byte("ok \xff"), "utf-8"); string(result) != "ok �" {
+		t.Errorf("Expected replacement for invalid UTF-8, got %q", result)
+	}
+
+	// Unknown charsets fall back to windows-1252 and report the label
+	result, err = ConvertCharset([]byte("caf\xe9"), "x-mac-unknown")
+	if !errors.Is(err, ErrUnknownCharset) || string(result) != "café" {
+		t.Errorf("Expected fallback conversion with ErrUnknownCharset, got %q, %v", result, err)
+	}
+}
+
+// TestParse_Charsets verifies bodies and RFC 2047 encoded words in legacy charsets
+func TestParse_Charsets(t *testing.T) {
+	parser := NewEmailParser()
+
+	sjis, _, _ := LookupCharset("shift_jis")
+	body, _ := sjis.NewEncoder().Bytes([]byte("認証コードは 4217 です"))
+	koi8, _, _ := LookupCharset("koi8-r")
+	name, _ := koi8.NewEncoder().String("Иван")
+	jis, _, _ := LookupCharset("iso-2022-jp")
+	subject, _ := jis.NewEncoder().String("ご注文の確認")
+
+	email := "From: " + mime.QEncoding.Encode("koi8-r", name) + " <ivan@example.ru>\r\n" +
+		"To: me@webrana.id\r\n" +
+		"Subject: " + mime.BEncoding.Encode("ISO-2022-JP", subject) + "\r\n" +
+		"X-Mailer: =?x-unknown?Q?Mailer_caf=E9?=\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/alternative; boundary=b\r\n" +
+		"\r\n" +
+		"--b\r\n" +
+		"Content-Type: text/plain; charset=Shift_JIS\r\n" +
+		"Content-Transfer-Encoding: base64\r\n" +
+		"\r\n" +
+		base64.StdEncoding.EncodeToString(body) + "\r\n" +
+		"--b\r\n" +
+		"Content-Type: text/html; charset=\"windows-1251\"\r\n" +
+		"Content-Transfer-Encoding: quoted-printable\r\n" +
+		"\r\n" +
+		"<p>=CF=F0=E8=E2=E5=F2</p>\r\n" +
+		"--b--\r\n"
+
+	parsed, err := parser.Parse([]byte(email))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if parsed.Subject != "ご注文の確認" {
+		t.Errorf("Subject = %q", parsed.Subject)
+	}
+	if parsed.FromName != "Иван" {
+		t.Errorf("FromName = %q", parsed.FromName)
+	}
+	if parsed.Headers["X-Mailer"] != "Mailer café" {
+		t.Errorf("X-Mailer = %q", parsed.Headers["X-Mailer"])
+	}
+	if strings.TrimSpace(parsed.BodyText) != "認証コードは 4217 です" {
+		t.Errorf("BodyText = %q", parsed.BodyText)
+	}
+	if strings.TrimSpace(parsed.BodyHTML) != "<p>Привет</p>" {
+		t.Errorf("BodyHTML = %q", parsed.BodyHTML)
+	}
+
+	if parsed.Charset != "shift_jis" {
+		t.Errorf("Charset = %q, want shift_jis", parsed.Charset)
+	}
+	if len(parsed.CharsetWarnings) == 0 || !strings.Contains(strings.Join(parsed.CharsetWarnings, "\n"), `unknown charset "x-unknown"`) {
+		t.Errorf("Expected a warning for the unknown header charset, got %v", parsed.CharsetWarnings)
+	}
+}
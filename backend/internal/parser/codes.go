@@ -0,0 +1,406 @@
+package parser
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// LinkKind classifies an account link found in an email
+type LinkKind string
+
+// Link kinds
+const (
+	LinkVerification  LinkKind = "verification"   // Confirms an email address or account
+	LinkMagic         LinkKind = "magic_link"     // Signs the user in without a password
+	LinkPasswordReset LinkKind = "password_reset" // Sets a new password
+)
+
+// Code sources, in the order they are searched
+const (
+	CodeSourceSender  = "sender"  // A pattern registered for the sender's domain
+	CodeSourceSubject = "subject" // The subject line
+	CodeSourceBody    = "body"    // The text or HTML body
+)
+
+// Limits on what is kept from one email
+const (
+	MaxCodes = 5
+	MaxLinks = 10
+)
+
+// Codes are the one-time codes and account links found in a signup or login email
+type Codes struct {
+	OTPs  []OTPCode  `json:"otps,omitempty"`
+	Links []CodeLink `json:"links,omitempty"`
+}
+
+// OTPCode is a one-time code, with separators removed
+type OTPCode struct {
+	Code   string `json:"code"`
+	Source string `json:"source"`
+}
+
+// CodeLink is a verification, magic sign-in or password reset link
+type CodeLink struct {
+	Kind LinkKind `json:"kind"`
+	URL  string   `json:"url"`
+	Text string   `json:"text,omitempty"` // Anchor text of HTML links
+}
+
+// SenderCodePattern is a rule for the codes and links of one sender
+// Senders with fixed formats, such as Google's "G-123456", are matched exactly instead of by heuristics.
+type SenderCodePattern struct {
+	Domain   string         // Sender domain; subdomains match as well
+	Code     *regexp.Regexp // Matches a code in the subject or body; the first group, if any, is the code
+	Link     *regexp.Regexp // Matches the sender's account link URLs
+	LinkKind LinkKind       // Kind of the links matched by Link
+}
+
+var (
+	senderPatternsMu sync.RWMutex
+	senderPatterns   = map[string][]SenderCodePattern{}
+)
+
+func init() {
+	for _, pattern := range []SenderCodePattern{
+		{Domain: "google.com", Code: regexp.MustCompile(`\bG-(\d{6})\b`)},
+		{Domain: "facebookmail.com", Code: regexp.MustCompile(`\bFB-(\d{5,8})\b`)},
+		{Domain: "slack.com", Code: regexp.MustCompile(`\b([A-Z0-9]{3}-[A-Z0-9]{3})\b`)},
+		{Domain: "github.com", Link: regexp.MustCompile(`^https://github\.com/users/[^/]+/emails/\d+/confirm_verification/`), LinkKind: LinkVerification},
+		{Domain: "github.com", Link: regexp.MustCompile(`^https://github\.com/password_reset/`), LinkKind: LinkPasswordReset},
+	} {
+		RegisterSenderCodePattern(pattern)
+	}
+}
+
+// RegisterSenderCodePattern adds a rule for the codes and links of a sender domain
+// Patterns are tried before the generic heuristics, in the order they were registered.
+func RegisterSenderCodePattern(pattern SenderCodePattern) {
+	domain := strings.ToLower(strings.TrimSpace(pattern.Domain))
+	senderPatternsMu.Lock()
+	defer senderPatternsMu.Unlock()
+	senderPatterns[domain] = append(senderPatterns[domain], pattern)
+}
+
+// senderCodePatterns returns the patterns for a sender address, most specific domain first
+func senderCodePatterns(from string) []SenderCodePattern {
+	at := strings.LastIndex(from, "@")
+	if at < 0 {
+		return nil
+	}
+	domain := strings.ToLower(from[at+1:])
+
+	senderPatternsMu.RLock()
+	defer senderPatternsMu.RUnlock()
+	var patterns []SenderCodePattern
+	for domain != "" {
+		patterns = append(patterns, senderPatterns[domain]...)
+		dot := strings.Index(domain, ".")
+		if dot < 0 {
+			break
+		}
+		domain = domain[dot+1:]
+	}
+	return patterns
+}
+
+var (
+	// otpKeywordPattern matches words that introduce a one-time code
+	otpKeywordPattern = regexp.MustCompile(`(?i)\b(?:codes?|otp|passcode|pin|one[- ]time|verification|verify|security|confirmation|2fa|two[- ]factor|kode)\b`)
+
+	// otpCandidatePattern matches numeric codes, optionally grouped as "123 456" or "123-456",
+	// and upper-case alphanumeric codes
+	otpCandidatePattern = regexp.MustCompile(`\b(?:\d{3}[ -]\d{3,4}|\d{4,8}|[A-Z0-9]{6,8})\b`)
+
+	// textURLPattern matches URLs in plain text
+	textURLPattern = regexp.MustCompile(`https?://[^\s<>"'()\[\]]+`)
+
+	// anchorPattern matches HTML links and their content
+	anchorPattern = regexp.MustCompile(`(?is)<a\b[^>]*?\bhref\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))[^>]*>(.*?)</a>`)
+
+	htmlHiddenPattern = regexp.MustCompile(`(?is)<!--.*?-->|<script\b.*?</script>|<style\b.*?</style>|<head\b.*?</head>`)
+	htmlBreakPattern  = regexp.MustCompile(`(?i)<(?:br|/p|/div|/td|/th|/tr|/li|/h[1-6]|/table)\b[^>]*>`)
+	htmlTagPattern    = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+// Heuristic distances, in bytes, between a code and the keyword naming it
+const (
+	otpKeywordBefore      = 80 // "Your verification code is: 123456"
+	otpKeywordAfter       = 40 // "123456 is your code"
+	otpAlphanumericBefore = 30 // "Your code is AB7K2Q"
+)
+
+// ExtractCodes finds one-time codes and verification, magic sign-in and password reset links
+// Sender patterns are tried first, then codes named by a keyword near them in the subject or body.
+// It returns nil if the email has neither.
+func ExtractCodes(from, subject, bodyText, bodyHTML string) *Codes {
+	htmlBody := htmlText(bodyHTML)
+	patterns := senderCodePatterns(from)
+	codes := &Codes{}
+
+	addCode := func(code, source string) {
+		if len(codes.OTPs) >= MaxCodes {
+			return
+		}
+		for _, existing := range codes.OTPs {
+			if existing.Code == code {
+				return
+			}
+		}
+		codes.OTPs = append(codes.OTPs, OTPCode{Code: code, Source: source})
+	}
+
+	for _, pattern := range patterns {
+		if pattern.Code == nil {
+			continue
+		}
+		for _, text := range []string{subject, bodyText, htmlBody} {
+			for _, match := range pattern.Code.FindAllStringSubmatch(text, -1) {
+				code := match[0]
+				if len(match) > 1 {
+					code = match[1]
+				}
+				// Sender formats keep their separators, as in Slack's "ABC-123"
+				addCode(code, CodeSourceSender)
+			}
+		}
+	}
+	for _, code := range findOTPs(subject) {
+		addCode(code, CodeSourceSubject)
+	}
+	for _, text := range []string{bodyText, htmlBody} {
+		for _, code := range findOTPs(text) {
+			addCode(code, CodeSourceBody)
+		}
+	}
+
+	// description is the anchor text of an HTML link, or the line before a bare URL
+	addLink := func(rawURL, text, description string) {
+		rawURL = strings.TrimRight(html.UnescapeString(strings.TrimSpace(rawURL)), ".,;:!?")
+		if len(codes.Links) >= MaxLinks {
+			return
+		}
+		for _, existing := range codes.Links {
+			if existing.URL == rawURL {
+				return
+			}
+		}
+		if kind, ok := classifyLink(rawURL, description, patterns); ok {
+			codes.Links = append(codes.Links, CodeLink{Kind: kind, URL: rawURL, Text: text})
+		}
+	}
+
+	for _, match := range anchorPattern.FindAllStringSubmatch(bodyHTML, -1) {
+		text := collapseSpaces(htmlText(match[4]))
+		addLink(match[1]+match[2]+match[3], text, text)
+	}
+	for _, text := range []string{bodyText, htmlBody} {
+		for _, loc := range textURLPattern.FindAllStringIndex(text, -1) {
+			// "Confirm your email address:\nhttps://..."
+			addLink(text[loc[0]:loc[1]], "", lastLine(text[:loc[0]]))
+		}
+	}
+
+	if len(codes.OTPs) == 0 && len(codes.Links) == 0 {
+		return nil
+	}
+	return codes
+}
+
+// lastLine returns the last non-empty line of s
+func lastLine(s string) string {
+	s = strings.TrimRight(s, " \t\r\n")
+	return s[strings.LastIndexByte(s, '\n')+1:]
+}
+
+// findOTPs returns the codes in text that have a keyword close to them
+func findOTPs(text string) []string {
+	// Numbers inside URLs are never codes
+	text = textURLPattern.ReplaceAllString(text, " ")
+
+	keywords := otpKeywordPattern.FindAllStringIndex(text, -1)
+	if len(keywords) == 0 {
+		return nil
+	}
+
+	var codes []string
+	used := make([]bool, len(keywords))
+	for _, loc := range otpCandidatePattern.FindAllStringIndex(text, -1) {
+		candidate := text[loc[0]:loc[1]]
+		if !isOTPCandidate(text, loc[0], loc[1]) {
+			continue
+		}
+
+		// Letter codes must follow the keyword closely, unless they stand on a line of their own
+		before, after := otpKeywordBefore, otpKeywordAfter
+		if strings.IndexFunc(candidate, func(r rune) bool { return r >= 'A' && r <= 'Z' }) >= 0 {
+			after = 0
+			if !aloneOnLine(text, loc[0], loc[1]) {
+				before = otpAlphanumericBefore
+			}
+		}
+
+		// A keyword names one code; later numbers such as an address in the footer are not codes
+		named := false
+		for i, keyword := range keywords {
+			if used[i] {
+				continue
+			}
+			if keyword[1] <= loc[0] && loc[0]-keyword[1] <= before {
+				used[i] = true
+				named = true
+			} else if keyword[0] >= loc[1] && keyword[0]-loc[1] <= after {
+				used[i] = true
+				named = true
+				break
+			}
+		}
+		if named {
+			codes = append(codes, strings.NewReplacer(" ", "", "-", "").Replace(candidate))
+		}
+	}
+	return codes
+}
+
+// aloneOnLine reports whether text[start:end] is the only content of its line
+func aloneOnLine(text string, start, end int) bool {
+	lineStart := strings.LastIndexByte(text[:start], '\n') + 1
+	lineEnd := strings.IndexByte(text[end:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(text) - end
+	}
+	return strings.TrimSpace(text[lineStart:start]) == "" && strings.TrimSpace(text[end:end+lineEnd]) == ""
+}
+
+// isOTPCandidate rejects matches that are amounts, dates, times, phone numbers or words
+func isOTPCandidate(text string, start, end int) bool {
+	candidate := text[start:end]
+
+	digits := 0
+	letters := 0
+	for _, c := range candidate {
+		switch {
+		case c >= '0' && c <= '9':
+			digits++
+		case c >= 'A' && c <= 'Z':
+			letters++
+		}
+	}
+	if digits == 0 || (letters > 0 && digits < 2) {
+		return false
+	}
+	// Years read as codes far more often than they are codes
+	if len(candidate) == 4 && (strings.HasPrefix(candidate, "19") || strings.HasPrefix(candidate, "20")) {
+		return false
+	}
+
+	for _, symbol := range []string{"$", "€", "£", "¥", "#", "+", "/", ".", ","} {
+		if strings.HasSuffix(text[:start], symbol) {
+			return false
+		}
+	}
+	if start > 1 && (text[start-1] == '-' || text[start-1] == ' ') && isDigit(text[start-2]) {
+		return false
+	}
+	if end < len(text) {
+		switch next := text[end]; {
+		case next == '%' || next == '/':
+			return false
+		case strings.IndexByte(".,-", next) >= 0 && end+1 < len(text) && isDigit(text[end+1]):
+			return false
+		case next == ' ' && end+1 < len(text) && isDigit(text[end+1]):
+			return false
+		}
+	}
+	return true
+}
+
+// isDigit reports whether c is an ASCII digit
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// classifyLink returns the kind of an account link from its URL and the text describing it
+func classifyLink(rawURL, text string, patterns []SenderCodePattern) (LinkKind, bool) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", false
+	}
+
+	for _, pattern := range patterns {
+		if pattern.Link != nil && pattern.Link.MatchString(rawURL) {
+			return pattern.LinkKind, true
+		}
+	}
+
+	target := strings.ToLower(parsed.Path + "?" + parsed.RawQuery)
+	described := strings.ToLower(text)
+	both := target + " " + described
+	if containsAny(both, "unsubscribe", "privacy", "preferences") {
+		return "", false
+	}
+
+	switch {
+	case containsAny(both, "password", "passwd") && containsAny(both, "reset", "forgot", "recover", "change"),
+		containsAny(target, "reset") && hasToken(parsed):
+		return LinkPasswordReset, true
+	case containsAny(both, "verify", "verification", "confirm", "activate", "activation", "validate", "validation"):
+		return LinkVerification, true
+	case containsAny(both, "magic", "login", "log-in", "log in", "signin", "sign-in", "sign_in", "sign in") && hasToken(parsed):
+		return LinkMagic, true
+	}
+	return "", false
+}
+
+// hasToken reports whether a URL carries a one-time token in its query or path
+func hasToken(u *url.URL) bool {
+	query := u.Query()
+	for _, name := range []string{"token", "code", "key", "otp", "t", "hash", "signature"} {
+		if query.Get(name) != "" {
+			return true
+		}
+	}
+	for _, segment := range strings.Split(u.Path, "/") {
+		if len(segment) >= 20 {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAny reports whether s contains any of the substrings
+func containsAny(s string, substrs ...string) bool {
+	for _, substr := range substrs {
+		if strings.Contains(s, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// htmlText converts HTML to plain text, with a line break after each block element
+func htmlText(body string) string {
+	if body == "" {
+		return ""
+	}
+	body = htmlHiddenPattern.ReplaceAllString(body, " ")
+	body = htmlBreakPattern.ReplaceAllString(body, "\n")
+	body = htmlTagPattern.ReplaceAllString(body, " ")
+	body = strings.ReplaceAll(html.UnescapeString(body), "\u00a0", " ")
+
+	var lines []string
+	for _, line := range strings.Split(body, "\n") {
+		if line = collapseSpaces(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// collapseSpaces trims s and replaces runs of whitespace with single spaces
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,199 @@
+package parser
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"pgregory.net/rapid"
+)
+
+// TestExtractCodes_Corpus verifies codes and links are found in typical signup and login emails
+func TestExtractCodes_Corpus(t *testing.T) {
+	tests := []struct {
+		name                              string
+		from, subject, bodyText, bodyHTML string
+		codes                             []OTPCode
+		links                             []CodeLink
+	}{
+		{
+			name:     "code in subject and body",
+			from:     "no-reply@example.com",
+			subject:  "Your verification code is 482913",
+			bodyText: "Hi,\r\n\r\nYour verification code is 482913. It expires in 10 minutes.\r\n",
+			codes:    []OTPCode{{Code: "482913", Source: CodeSourceSubject}},
+		},
+		{
+			name:     "code before keyword",
+			from:     "auth@example.com",
+			subject:  "Sign in",
+			bodyText: "731 402 is your one-time passcode for Example.",
+			codes:    []OTPCode{{Code: "731402", Source: CodeSourceBody}},
+		},
+		{
+			name:    "code alone in an HTML cell",
+			from:    "hello@app.example.com",
+			subject: "Confirm your account",
+			bodyHTML: `<html><head><style>td{font-size:32px}</style></head><body>
+				<table><tr><td>Enter this code to finish signing up:</td></tr>
+				<tr><td><strong>5&nbsp;0&nbsp;9</strong>&nbsp;</td></tr>
+				<tr><td style="font-size:28px">AB7K2Q</td></tr></table>
+				<p>&copy; 2024 Example Inc, 1600 Main Street</p></body></html>`,
+			codes: []OTPCode{{Code: "AB7K2Q", Source: CodeSourceBody}},
+		},
+		{
+			name:     "Indonesian code",
+			from:     "otp@bank.example.id",
+			subject:  "Kode OTP",
+			bodyText: "JANGAN BERIKAN kode ini kepada siapapun. Kode OTP Anda: 802311",
+			codes:    []OTPCode{{Code: "802311", Source: CodeSourceBody}},
+		},
+		{
+			name:     "Google sender pattern",
+			from:     "noreply@accounts.google.com",
+			subject:  "G-538201 is your Google verification code",
+			bodyText: "G-538201 is your Google verification code.",
+			codes:    []OTPCode{{Code: "538201", Source: CodeSourceSender}},
+		},
+		{
+			name:     "Slack sender pattern",
+			from:     "feedback@slack.com",
+			subject:  "Slack confirmation code: QX4-9TZ",
+			bodyText: "Your confirmation code is below.\n\nQX4-9TZ\n",
+			codes:    []OTPCode{{Code: "QX4-9TZ", Source: CodeSourceSender}},
+		},
+		{
+			name:    "verification link",
+			from:    "welcome@service.example",
+			subject: "Please confirm your email",
+			bodyHTML: `<p>Thanks for signing up!</p>
+				<a class="btn" href="https://service.example/account/confirm?token=abc123&amp;uid=7">Confirm&nbsp;email
+				</a> <a href="https://service.example/unsubscribe?u=7">Unsubscribe</a>
+				<a href="https://service.example/privacy">Privacy</a>`,
+			links: []CodeLink{{Kind: LinkVerification, URL: "https://service.example/account/confirm?token=abc123&uid=7", Text: "Confirm email"}},
+		},
+		{
+			name:     "tracked reset link in text",
+			from:     "support@shop.example",
+			subject:  "Reset your password",
+			bodyText: "Someone asked to reset your password. Use this link:\r\nhttps://click.mailer.example/ls/click?upn=Zm9vYmFyYmF6.\r\n\r\nVisit https://shop.example/help for help.",
+			links:    []CodeLink{{Kind: LinkPasswordReset, URL: "https://click.mailer.example/ls/click?upn=Zm9vYmFyYmF6"}},
+		},
+		{
+			name:     "magic link",
+			from:     "login@notes.example",
+			subject:  "Your sign-in link",
+			bodyHTML: `<a href='https://notes.example/auth/magic?token=9f8e7d'>Sign in to Notes</a> <a href="https://notes.example/login">Log in with password</a>`,
+			links:    []CodeLink{{Kind: LinkMagic, URL: "https://notes.example/auth/magic?token=9f8e7d", Text: "Sign in to Notes"}},
+		},
+		{
+			name:     "GitHub sender link",
+			from:     "noreply@github.com",
+			subject:  "[GitHub] Please verify your email address.",
+			bodyText: "Almost done! To complete your sign up, please follow this link:\n\nhttps://github.com/users/octo/emails/12345/confirm_verification/deadbeef",
+			links:    []CodeLink{{Kind: LinkVerification, URL: "https://github.com/users/octo/emails/12345/confirm_verification/deadbeef"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codes := ExtractCodes(tt.from, tt.subject, tt.bodyText, tt.bodyHTML)
+			if codes == nil {
+				t.Fatal("Expected codes, got nil")
+			}
+			if !reflect.DeepEqual(codes.OTPs, tt.codes) {
+				t.Errorf("OTPs = %+v, want %+v", codes.OTPs, tt.codes)
+			}
+			if !reflect.DeepEqual(codes.Links, tt.links) {
+				t.Errorf("Links = %+v, want %+v", codes.Links, tt.links)
+			}
+		})
+	}
+}
+
+// TestExtractCodes_Negatives verifies numbers that are not codes are ignored
+func TestExtractCodes_Negatives(t *testing.T) {
+	tests := []struct {
+		name, subject, bodyText string
+	}{
+		{"order", "Order 123456 shipped", "Your order #123456 has shipped. Total: $1250.00"},
+		{"no keyword", "Hello", "We have 250000 users and counting."},
+		{"phone and date", "Security alert", "Call our security team at +1 555 123 4567 or 555-123-4567 before 2024-06-01."},
+		{"year and amount", "Your code of conduct", "Updated code of conduct, effective 2025. Discount 1500%."},
+		{"url only", "Code review", "See https://example.com/pull/482913 for the code."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if codes := ExtractCodes("news@example.com", tt.subject, tt.bodyText, ""); codes != nil {
+				t.Errorf("Expected no codes, got %+v", codes)
+			}
+		})
+	}
+}
+
+// TestRegisterSenderCodePattern verifies registered patterns apply to the domain and its subdomains only
+func TestRegisterSenderCodePattern(t *testing.T) {
+	RegisterSenderCodePattern(SenderCodePattern{
+		Domain:   "Patterns.Example",
+		Code:     regexp.MustCompile(`\bPX(\d{4})\b`),
+		Link:     regexp.MustCompile(`^https://go\.patterns\.example/`),
+		LinkKind: LinkMagic,
+	})
+
+	body := "Use PX4821 or https://go.patterns.example/abc"
+	codes := ExtractCodes("bot@mail.patterns.example", "", body, "")
+	want := &Codes{
+		OTPs:  []OTPCode{{Code: "4821", Source: CodeSourceSender}},
+		Links: []CodeLink{{Kind: LinkMagic, URL: "https://go.patterns.example/abc"}},
+	}
+	if !reflect.DeepEqual(codes, want) {
+		t.Errorf("Codes = %+v, want %+v", codes, want)
+	}
+
+	if codes := ExtractCodes("bot@otherpatterns.example", "", body, ""); codes != nil {
+		t.Errorf("Pattern should not apply to another domain, got %+v", codes)
+	}
+}
+
+// TestExtractCodes_KeywordProximity verifies any code stated next to a keyword is found
+func TestExtractCodes_KeywordProximity(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		code := rapid.StringMatching(`[1-9][0-9]{5}`).Draw(t, "code")
+		filler := rapid.StringMatching(`[a-z ]{0,40}`).Draw(t, "filler")
+		template := rapid.SampledFrom([]string{
+			"%s Your verification code is %s. %s",
+			"%s Enter %s as your one-time code. %s",
+			"%s\nCode: %s\n%s",
+		}).Draw(t, "template")
+
+		body := fmt.Sprintf(template, filler, code, filler)
+		codes := ExtractCodes("no-reply@example.com", "Welcome", body, "")
+		if codes == nil || len(codes.OTPs) == 0 || codes.OTPs[0].Code != code {
+			t.Fatalf("Expected code %s in %q, got %+v", code, body, codes)
+		}
+	})
+}
+
+// TestParse_Codes verifies codes are extracted while parsing
+func TestParse_Codes(t *testing.T) {
+	raw := "From: Example <no-reply@example.com>\r\n" +
+		"Subject: Welcome\r\n" +
+		"Content-Type: text/html; charset=utf-8\r\n" +
+		"Content-Transfer-Encoding: quoted-printable\r\n" +
+		"\r\n" +
+		"<p>Your code is <b>612=\r\n044</b></p><a href=3D\"https://example.com/verify?t=3Dx\">Verify</a>\r\n"
+
+	parsed, err := NewEmailParser().Parse([]byte(raw))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	want := &Codes{
+		OTPs:  []OTPCode{{Code: "612044", Source: CodeSourceBody}},
+		Links: []CodeLink{{Kind: LinkVerification, URL: "https://example.com/verify?t=x", Text: "Verify"}},
+	}
+	if !reflect.DeepEqual(parsed.Codes, want) {
+		t.Errorf("Codes = %+v, want %+v", parsed.Codes, want)
+	}
+}
@@ -0,0 +1,187 @@
+package parser
+
+import (
+	"bytes"
+	"io"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Media types of an email forwarded as an attachment
+const (
+	ContentTypeMessage       = "message/rfc822"
+	ContentTypeGlobalMessage = "message/global" // RFC 6532 message with UTF-8 headers
+)
+
+// Embedded message limits
+const (
+	MaxEmbeddedDepth    = 5  // Levels of forwarded messages parsed below the top-level email
+	MaxEmbeddedMessages = 20 // Forwarded messages parsed per email
+)
+
+// EmbeddedMessage is a message/rfc822 part parsed as an email of its own
+type EmbeddedMessage struct {
+	Path     string       `json:"path"`               // 1-based position, dot-separated per level (e.g. "2" or "1.3")
+	Filename string       `json:"filename,omitempty"` // Filename of the part, usually ending in .eml
+	Email    *ParsedEmail `json:"email"`
+}
+
+// EmbeddedSummary describes an embedded message without its content, for listing and storage at ingest
+type EmbeddedSummary struct {
+	Path       string    `json:"path"`
+	Filename   string    `json:"filename,omitempty"`
+	From       string    `json:"from"`
+	FromName   string    `json:"from_name,omitempty"`
+	Subject    string    `json:"subject,omitempty"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// SummarizeEmbedded summarizes one level of embedded messages, nil when there are none
+func SummarizeEmbedded(embedded []*EmbeddedMessage) []EmbeddedSummary {
+	if len(embedded) == 0 {
+		return nil
+	}
+	summaries := make([]EmbeddedSummary, len(embedded))
+	for i, e := range embedded {
+		summaries[i] = EmbeddedSummary{
+			Path:       e.Path,
+			Filename:   e.Filename,
+			From:       e.Email.From,
+			FromName:   e.Email.FromName,
+			Subject:    e.Email.Subject,
+			ReceivedAt: e.Email.ReceivedAt,
+		}
+	}
+	return summaries
+}
+
+// IsEmbeddedMessage reports whether a MIME part with this media type encapsulates an email
+func IsEmbeddedMessage(mediaType string) bool {
+	return mediaType == ContentTypeMessage || mediaType == ContentTypeGlobalMessage
+}
+
+// HasEmbeddedMessage reports whether raw may contain a forwarded message, without parsing it
+func HasEmbeddedMessage(raw []byte) bool {
+	return containsFold(raw, ContentTypeMessage) || containsFold(raw, ContentTypeGlobalMessage)
+}
+
+// FindEmbedded returns the embedded message at path, searching nested levels, or nil
+func (e *ParsedEmail) FindEmbedded(path string) *EmbeddedMessage {
+	if e == nil {
+		return nil
+	}
+	for _, embedded := range e.Embedded {
+		if embedded.Path == path {
+			return embedded
+		}
+		if strings.HasPrefix(path, embedded.Path+".") {
+			return embedded.Email.FindEmbedded(path)
+		}
+	}
+	return nil
+}
+
+// extractEmbedded parses the message/rfc822 parts of raw, the message at path
+// Parts that cannot be parsed are skipped; they remain available as attachments
+func (p *EmailParser) extractEmbedded(raw []byte, path string, depth int) []*EmbeddedMessage {
+	var embedded []*EmbeddedMessage
+	embeddedParts(raw, func(n int, filename string, data []byte) bool {
+		childPath := strconv.Itoa(n)
+		if path != "" {
+			childPath = path + "." + childPath
+		}
+
+		email, err := p.parse(data, childPath, depth+1)
+		if err != nil {
+			return true
+		}
+
+		embedded = append(embedded, &EmbeddedMessage{
+			Path:     childPath,
+			Filename: filename,
+			Email:    email,
+		})
+		return true
+	})
+
+	return embedded
+}
+
+// ParseEmbedded parses the content of the embedded message at path, as returned by EmbeddedMessageData
+// The result matches the message FindEmbedded returns after parsing the whole email, nested paths included.
+func (p *EmailParser) ParseEmbedded(raw []byte, path string) (*ParsedEmail, error) {
+	return p.parse(raw, path, strings.Count(path, ".")+1)
+}
+
+// EmbeddedMessageData returns the content and filename of the embedded message at path, without parsing
+// any of the messages on the way. ok is false when raw has no embedded message at path.
+func EmbeddedMessageData(raw []byte, path string) (data []byte, filename string, ok bool) {
+	segments := strings.Split(path, ".")
+	if len(segments) > MaxEmbeddedDepth {
+		return nil, "", false
+	}
+
+	data = raw
+	for _, segment := range segments {
+		want, err := strconv.Atoi(segment)
+		if err != nil || want < 1 || want > MaxEmbeddedMessages {
+			return nil, "", false
+		}
+
+		var found []byte
+		embeddedParts(data, func(n int, name string, part []byte) bool {
+			if n == want {
+				found, filename = part, name
+				return false
+			}
+			return true
+		})
+		if found == nil {
+			return nil, "", false
+		}
+		data = found
+	}
+	return data, filename, true
+}
+
+// embeddedParts calls visit with the position, filename and transfer-decoded content of each
+// message/rfc822 part of raw that reads as a message, until visit returns false or
+// MaxEmbeddedMessages parts were visited. Positions count from 1.
+func embeddedParts(raw []byte, visit func(n int, filename string, data []byte) bool) {
+	if !HasEmbeddedMessage(raw) {
+		return
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return
+	}
+
+	n := 0
+	walkParts(textproto.MIMEHeader(msg.Header), msg.Body, func(header textproto.MIMEHeader, mediaType string, body io.Reader) bool {
+		if !IsEmbeddedMessage(mediaType) {
+			return true
+		}
+
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return true
+		}
+		// message/rfc822 should be 7bit or 8bit, but some clients base64-encode it anyway
+		if decoded, err := DecodeContent(data, header.Get(HeaderEncoding)); err == nil {
+			data = decoded
+		}
+		if len(data) == 0 {
+			return true
+		}
+		if _, err := mail.ReadMessage(bytes.NewReader(data)); err != nil {
+			return true
+		}
+
+		n++
+		return visit(n, PartFilename(header.Get(HeaderDisposition), header.Get(HeaderContentType)), data) && n < MaxEmbeddedMessages
+	})
+}
@@ -0,0 +1,172 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+)
+
+// forwardEmail wraps inner as a message/rfc822 part of a new message
+func forwardEmail(from, subject, inner, headers string) string {
+	return "From: " + from + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"Content-Type: multipart/mixed; boundary=\"fwd-" + subject + "\"\r\n" +
+		"\r\n" +
+		"--fwd-" + subject + "\r\n" +
+		"Content-Type: text/plain\r\n" +
+		"\r\n" +
+		"Forwarding " + subject + "\r\n" +
+		"--fwd-" + subject + "\r\n" +
+		headers +
+		"\r\n" +
+		inner + "\r\n" +
+		"--fwd-" + subject + "--\r\n"
+}
+
+// TestParse_EmbeddedMessages verifies nested forwards are parsed without leaking into the outer body
+func TestParse_EmbeddedMessages(t *testing.T) {
+	original := "From: =?UTF-8?Q?Ren=C3=A9e?= <renee@example.fr>\r\n" +
+		"Date: Tue, 02 Apr 2024 10:00:00 +0200\r\n" +
+		"Subject: Original\r\n" +
+		"Content-Type: text/html; charset=utf-8\r\n" +
+		"\r\n" +
+		"<p>Bonjour</p>"
+	middle := forwardEmail("bob@example.com", "Middle", original,
+		"Content-Type: message/rfc822; name=\"Original.eml\"\r\n")
+	outer := forwardEmail("carol@example.com", "Outer", string(EncodeBase64([]byte(middle))),
+		"Content-Type: message/rfc822\r\nContent-Transfer-Encoding: base64\r\nContent-Disposition: attachment; filename=\"Middle.eml\"\r\n")
+
+	parsed, err := NewEmailParser().Parse([]byte(outer))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if parsed.BodyText != "Forwarding Outer" || parsed.BodyHTML != "" {
+		t.Errorf("Outer body = %q / %q", parsed.BodyText, parsed.BodyHTML)
+	}
+	if len(parsed.Embedded) != 1 {
+		t.Fatalf("Expected 1 embedded message, got %d", len(parsed.Embedded))
+	}
+
+	first := parsed.Embedded[0]
+	if first.Path != "1" || first.Filename != "Middle.eml" || first.Email.Subject != "Middle" || first.Email.From != "bob@example.com" {
+		t.Errorf("First embedded = %s %s %+v", first.Path, first.Filename, first.Email)
+	}
+
+	second := parsed.FindEmbedded("1.1")
+	if second == nil {
+		t.Fatal("Expected nested message at 1.1")
+	}
+	if second.Filename != "Original.eml" || second.Email.FromName != "Renée" || second.Email.BodyHTML != "<p>Bonjour</p>" {
+		t.Errorf("Nested embedded = %s %+v", second.Filename, second.Email)
+	}
+	if !second.Email.ReceivedAt.Equal(time.Date(2024, 4, 2, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("Embedded ReceivedAt should come from its Date header, got %s", second.Email.ReceivedAt)
+	}
+	if string(second.Email.RawEmail) != original {
+		t.Errorf("Embedded raw = %q", second.Email.RawEmail)
+	}
+
+	for _, path := range []string{"", "0", "2", "1.2", "1.1.1", "1."} {
+		if parsed.FindEmbedded(path) != nil {
+			t.Errorf("FindEmbedded(%q) should be nil", path)
+		}
+	}
+}
+
+// TestParse_EmbeddedMessageTopLevel verifies a message whose whole body is a message/rfc822
+func TestParse_EmbeddedMessageTopLevel(t *testing.T) {
+	email := "From: bounce@example.com\r\n" +
+		"Subject: Returned mail\r\n" +
+		"Content-Type: message/global\r\n" +
+		"\r\n" +
+		"From: me@example.com\r\n" +
+		"Subject: Héllo\r\n" +
+		"\r\n" +
+		"Body\r\n"
+
+	parsed, err := NewEmailParser().Parse([]byte(email))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	embedded := parsed.FindEmbedded("1")
+	if embedded == nil || embedded.Email.Subject != "Héllo" || strings.TrimSpace(embedded.Email.BodyText) != "Body" {
+		t.Fatalf("Embedded = %+v", embedded)
+	}
+}
+
+// TestParse_EmbeddedDepthLimit verifies recursion stops at MaxEmbeddedDepth
+func TestParse_EmbeddedDepthLimit(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		levels := rapid.IntRange(1, MaxEmbeddedDepth+3).Draw(t, "levels")
+
+		email := "From: origin@example.com\r\nSubject: L0\r\n\r\nOrigin\r\n"
+		for i := 1; i <= levels; i++ {
+			email = forwardEmail("relay@example.com", "L"+strings.Repeat("x", i), email, "Content-Type: message/rfc822\r\n")
+		}
+
+		parsed, err := NewEmailParser().Parse([]byte(email))
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+
+		depth := 0
+		path := ""
+		for current := parsed; len(current.Embedded) > 0; current = current.Embedded[0].Email {
+			depth++
+			path = current.Embedded[0].Path
+		}
+		if want := min(levels, MaxEmbeddedDepth); depth != want {
+			t.Fatalf("Parsed %d levels of %d, want %d", depth, levels, want)
+		}
+		if depth > 0 && parsed.FindEmbedded(path) == nil {
+			t.Fatalf("FindEmbedded(%q) failed", path)
+		}
+
+		// Finding the part and parsing only it gives the same message as parsing everything
+		if depth > 0 {
+			data, _, ok := EmbeddedMessageData([]byte(email), path)
+			if !ok {
+				t.Fatalf("EmbeddedMessageData(%q) failed", path)
+			}
+			alone, err := NewEmailParser().ParseEmbedded(data, path)
+			if err != nil {
+				t.Fatalf("ParseEmbedded failed: %v", err)
+			}
+			want := parsed.FindEmbedded(path).Email
+			if alone.Subject != want.Subject || len(alone.Embedded) != len(want.Embedded) {
+				t.Fatalf("ParseEmbedded(%q) = %q with %d embedded, want %q with %d", path, alone.Subject, len(alone.Embedded), want.Subject, len(want.Embedded))
+			}
+		}
+		if _, _, ok := EmbeddedMessageData([]byte(email), path+".1"); ok {
+			t.Fatalf("EmbeddedMessageData found a message below the last parsed level %q", path)
+		}
+	})
+}
+
+// TestSummarizeEmbedded verifies summaries keep what is listed for forwarded messages
+func TestSummarizeEmbedded(t *testing.T) {
+	inner := "From: Billing <billing@example.com>\r\nDate: Mon, 01 Apr 2024 09:00:00 +0000\r\nSubject: Invoice\r\n\r\nTotal\r\n"
+	email := forwardEmail("bob@example.com", "Fwd", inner, "Content-Type: message/rfc822\r\nContent-Disposition: attachment; filename=\"Invoice.eml\"\r\n")
+
+	parsed, err := NewEmailParser().Parse([]byte(email))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	summaries := SummarizeEmbedded(parsed.Embedded)
+	want := EmbeddedSummary{
+		Path:       "1",
+		Filename:   "Invoice.eml",
+		From:       "billing@example.com",
+		FromName:   "Billing",
+		Subject:    "Invoice",
+		ReceivedAt: time.Date(2024, 4, 1, 9, 0, 0, 0, time.UTC),
+	}
+	if len(summaries) != 1 || summaries[0] != want {
+		t.Errorf("SummarizeEmbedded() = %+v, want %+v", summaries, want)
+	}
+	if SummarizeEmbedded(nil) != nil {
+		t.Error("Expected no summaries without embedded messages")
+	}
+}
@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+)
+
+// Header is one header field in the order it appears in the message
+type Header struct {
+	Name  string `json:"name"`  // Field name as written, e.g. "DKIM-Signature"
+	Value string `json:"value"` // Unfolded value, not MIME-decoded
+}
+
+// ParseHeaderList returns the header fields of a raw message in their original order
+// Repeated fields such as Received and DKIM-Signature are kept as separate entries.
+// Folded lines are unfolded (RFC 5322 section 2.2.3) and values are truncated to MaxHeaderLength.
+// Lines that are not header fields, such as an mbox "From " line, are skipped.
+func ParseHeaderList(raw []byte) []Header {
+	headers := []Header{}
+	folding := false
+
+	for len(raw) > 0 {
+		var line []byte
+		line, raw, _ = bytes.Cut(raw, []byte("\n"))
+		line = bytes.TrimSuffix(line, []byte("\r"))
+		if len(line) == 0 {
+			break
+		}
+
+		// A line starting with whitespace continues the previous field
+		if line[0] == ' ' || line[0] == '\t' {
+			if folding {
+				headers[len(headers)-1].Value += string(line)
+			}
+			continue
+		}
+
+		name, value, ok := bytes.Cut(line, []byte(":"))
+		name = bytes.TrimRight(name, " \t")
+		folding = ok && isHeaderName(name)
+		if !folding {
+			continue
+		}
+		headers = append(headers, Header{Name: string(name), Value: string(value)})
+	}
+
+	for i := range headers {
+		headers[i].Value = truncateHeaderValue(strings.TrimSpace(headers[i].Value))
+	}
+
+	return headers
+}
+
+// HeaderBlock returns the header section of a raw message as written, up to and including the blank line ending it
+// A message without a blank line is all headers.
+func HeaderBlock(raw []byte) []byte {
+	for i := 0; i < len(raw); {
+		end := bytes.IndexByte(raw[i:], '\n')
+		if end < 0 {
+			break
+		}
+		line := bytes.TrimSuffix(raw[i:i+end], []byte("\r"))
+		i += end + 1
+		if len(line) == 0 {
+			return raw[:i]
+		}
+	}
+	return raw
+}
+
+// isHeaderName reports whether name is a valid field name: printable ASCII without colons
+func isHeaderName(name []byte) bool {
+	if len(name) == 0 {
+		return false
+	}
+	for _, c := range name {
+		if c < '!' || c > '~' || c == ':' {
+			return false
+		}
+	}
+	return true
+}
+
+// truncateHeaderValue limits a value to MaxHeaderLength bytes without splitting a character
+func truncateHeaderValue(value string) string {
+	if len(value) <= MaxHeaderLength {
+		return value
+	}
+	cut := MaxHeaderLength
+	for cut > 0 && !utf8.RuneStart(value[cut]) {
+		cut--
+	}
+	return value[:cut]
+}
@@ -0,0 +1,129 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"pgregory.net/rapid"
+)
+
+// TestParseHeaderList verifies repeated fields keep their order and folded values are unfolded
+func TestParseHeaderList(t *testing.T) {
+	raw := "From sender@example.com Mon Jan  1 12:00:00 2024\n" +
+		"Received: from mx2.example.net\r\n" +
+		"\tby mx1.example.com; Mon, 1 Jan 2024 12:00:02 +0000\r\n" +
+		"Received: from client.example.org\r\n" +
+		" by mx2.example.net; Mon, 1 Jan 2024 12:00:01 +0000\r\n" +
+		"DKIM-Signature: v=1; d=example.org;\r\n" +
+		"\tb=abc\r\n" +
+		"DKIM-Signature: v=1; d=mailer.example;\r\n" +
+		"Subject : =?UTF-8?B?SGVsbG8=?=\r\n" +
+		"X-Empty:\r\n" +
+		"\r\n" +
+		"Body: not a header\r\n"
+
+	want := []Header{
+		{Name: "Received", Value: "from mx2.example.net\tby mx1.example.com; Mon, 1 Jan 2024 12:00:02 +0000"},
+		{Name: "Received", Value: "from client.example.org by mx2.example.net; Mon, 1 Jan 2024 12:00:01 +0000"},
+		{Name: "DKIM-Signature", Value: "v=1; d=example.org;\tb=abc"},
+		{Name: "DKIM-Signature", Value: "v=1; d=mailer.example;"},
+		{Name: "Subject", Value: "=?UTF-8?B?SGVsbG8=?="},
+		{Name: "X-Empty", Value: ""},
+	}
+
+	if got := ParseHeaderList([]byte(raw)); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseHeaderList() = %+v, want %+v", got, want)
+	}
+}
+
+// TestHeaderBlock verifies the header section ends at the first blank line, with either line ending
+func TestHeaderBlock(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"Subject: Hi\r\nTo: a@example.com\r\n\r\nBody\r\n\r\nMore\r\n", "Subject: Hi\r\nTo: a@example.com\r\n\r\n"},
+		{"Subject: Hi\n\nBody\n", "Subject: Hi\n\n"},
+		{"Subject: Hi\r\n", "Subject: Hi\r\n"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := string(HeaderBlock([]byte(tt.raw))); got != tt.want {
+			t.Errorf("HeaderBlock(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+// TestParseHeaderList_Truncation verifies long values are cut without splitting a character
+func TestParseHeaderList_Truncation(t *testing.T) {
+	raw := "X-Long: a" + strings.Repeat("é", MaxHeaderLength) + "\r\n\r\n"
+
+	headers := ParseHeaderList([]byte(raw))
+	if len(headers) != 1 {
+		t.Fatalf("Expected 1 header, got %d", len(headers))
+	}
+	value := headers[0].Value
+	if len(value) > MaxHeaderLength || !utf8.ValidString(value) {
+		t.Errorf("Value has %d bytes, valid UTF-8 %v", len(value), utf8.ValidString(value))
+	}
+}
+
+// TestParseHeaderList_RoundTrip verifies any field list survives serialization with folding
+func TestParseHeaderList_RoundTrip(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		names := rapid.SampledFrom([]string{"Received", "DKIM-Signature", "Authentication-Results", "X-Custom", "Subject"})
+		n := rapid.IntRange(0, 12).Draw(t, "count")
+
+		var want []Header
+		var raw strings.Builder
+		for i := 0; i < n; i++ {
+			name := names.Draw(t, "name")
+			words := rapid.SliceOfN(rapid.StringMatching(`[a-z0-9=;.@]{1,12}`), 1, 6).Draw(t, "words")
+			want = append(want, Header{Name: name, Value: strings.Join(words, " ")})
+
+			raw.WriteString(name + ": " + words[0])
+			for _, word := range words[1:] {
+				if rapid.Bool().Draw(t, "fold") {
+					raw.WriteString("\r\n")
+				}
+				raw.WriteString(" " + word)
+			}
+			raw.WriteString("\r\n")
+		}
+		raw.WriteString("\r\nbody\r\n")
+
+		got := ParseHeaderList([]byte(raw.String()))
+		if len(want) == 0 {
+			want = []Header{}
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("ParseHeaderList() = %+v, want %+v", got, want)
+		}
+	})
+}
+
+// TestParse_HeaderList verifies the ordered list is extracted while parsing alongside the headers map
+func TestParse_HeaderList(t *testing.T) {
+	raw := "Received: from b\r\n" +
+		"Received: from a\r\n" +
+		"From: sender@example.com\r\n" +
+		"Subject: Hello\r\n" +
+		"\r\n" +
+		"Hi\r\n"
+
+	parsed, err := NewEmailParser().Parse([]byte(raw))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if got := len(parsed.HeaderList); got != 4 {
+		t.Fatalf("Expected 4 header fields, got %d", got)
+	}
+	if parsed.HeaderList[0].Value != "from b" || parsed.HeaderList[1].Value != "from a" {
+		t.Errorf("Received order = %+v", parsed.HeaderList[:2])
+	}
+	if parsed.Headers["Received"] != "from b" {
+		t.Errorf("Headers map keeps the first value, got %q", parsed.Headers["Received"])
+	}
+}
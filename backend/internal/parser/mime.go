@@ -0,0 +1,201 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// ErrMIMEPartNotFound is returned when a part ID does not name a leaf part of the message
+var ErrMIMEPartNotFound = errors.New("MIME part not found")
+
+// rootPartID is the part ID of a message body that is not multipart (as in IMAP BODY[1])
+const rootPartID = "1"
+
+// Body kinds reported for the parts the displayed bodies were taken from
+const (
+	BodyKindHTML = "html"
+	BodyKindText = "text"
+)
+
+// bodySource records the part IDs the HTML and text bodies were taken from
+type bodySource struct {
+	html string
+	text string
+}
+
+// childPartID numbers the nth part of a multipart the way IMAP does: "1", "2", then "2.1", "2.2"
+func childPartID(parent string, n int) string {
+	if parent == "" {
+		return strconv.Itoa(n)
+	}
+	return parent + "." + strconv.Itoa(n)
+}
+
+// MIMEStructure is the MIME tree of a message with the parts its bodies were taken from
+type MIMEStructure struct {
+	Root     *MIMEPart `json:"root"`
+	HTMLPart string    `json:"html_part,omitempty"` // Part ID of the HTML body
+	TextPart string    `json:"text_part,omitempty"` // Part ID of the plain text body
+}
+
+// MIMEPart describes one entity of a message's MIME tree
+type MIMEPart struct {
+	ID               string      `json:"id,omitempty"` // Empty for a multipart message root
+	ContentType      string      `json:"content_type"`
+	Charset          string      `json:"charset,omitempty"`
+	TransferEncoding string      `json:"transfer_encoding,omitempty"`
+	Disposition      string      `json:"disposition,omitempty"`
+	Filename         string      `json:"filename,omitempty"`
+	ContentID        string      `json:"content_id,omitempty"`
+	Size             int64       `json:"size"`                   // Encoded body size in bytes
+	DecodedSize      int64       `json:"decoded_size,omitempty"` // Body size after transfer decoding, leaves only
+	Body             string      `json:"body,omitempty"`         // "html" or "text" when displayed as the email body
+	Parts            []*MIMEPart `json:"parts,omitempty"`
+}
+
+// IsLeaf reports whether the part has content rather than child parts
+func (p *MIMEPart) IsLeaf() bool {
+	return p.ID != "" && len(p.Parts) == 0 && !strings.HasPrefix(p.ContentType, "multipart/")
+}
+
+// InspectMIME returns the MIME tree of a raw message and marks the parts chosen as its HTML and text bodies
+// The bodies are chosen by the same code paths Parse uses
+func InspectMIME(raw []byte) (*MIMEStructure, error) {
+	root, err := inspectMessage(raw, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	structure := &MIMEStructure{Root: root}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return structure, nil
+	}
+	var src bodySource
+	if _, _, err := NewEmailParser().extractBody(msg, nil, &src); err == nil {
+		structure.HTMLPart, structure.TextPart = src.html, src.text
+	}
+
+	markBodyParts(root, structure.HTMLPart, structure.TextPart)
+	return structure, nil
+}
+
+// ExtractMIMEPart returns a leaf part of a raw message by part ID
+// The content is transfer-decoded when decode is true and returned as stored otherwise
+func ExtractMIMEPart(raw []byte, id string, decode bool) (*MIMEPart, []byte, error) {
+	var found *MIMEPart
+	var content []byte
+	_, err := inspectMessage(raw, func(part *MIMEPart, body []byte) {
+		if found == nil && part.ID == id {
+			found, content = part, body
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if found == nil || !found.IsLeaf() {
+		return nil, nil, ErrMIMEPartNotFound
+	}
+
+	if decode {
+		if decoded, err := DecodeContent(content, found.TransferEncoding); err == nil {
+			content = decoded
+		}
+	}
+	return found, content, nil
+}
+
+// inspectMessage parses the header of raw and describes its body, calling leaf for each leaf part
+func inspectMessage(raw []byte, leaf func(part *MIMEPart, body []byte)) (*MIMEPart, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, &ParseError{
+			Stage:   "parse",
+			Message: fmt.Sprintf("failed to parse email: %v", err),
+			Raw:     raw,
+		}
+	}
+	body, err := io.ReadAll(msg.Body)
+	if err != nil {
+		return nil, &ParseError{
+			Stage:   "body",
+			Message: fmt.Sprintf("failed to read body: %v", err),
+			Raw:     raw,
+		}
+	}
+
+	header := textproto.MIMEHeader(msg.Header)
+	id := rootPartID
+	if mediaType, params, _ := ParseMediaType(header.Get(HeaderContentType)); strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != "" {
+		id = ""
+	}
+	return inspectEntity(header, body, id, 0, leaf), nil
+}
+
+// inspectEntity describes one entity, descending into multiparts up to maxPartDepth
+// Parts are read raw so their transfer encoding is reported as sent
+func inspectEntity(header textproto.MIMEHeader, body []byte, id string, depth int, leaf func(part *MIMEPart, body []byte)) *MIMEPart {
+	mediaType, params, _ := ParseMediaType(header.Get(HeaderContentType))
+	if mediaType == "" {
+		mediaType = ContentTypePlain
+	}
+	disposition, _, _ := ParseMediaType(header.Get(HeaderDisposition))
+
+	part := &MIMEPart{
+		ID:               id,
+		ContentType:      mediaType,
+		Charset:          params["charset"],
+		TransferEncoding: strings.ToLower(strings.TrimSpace(header.Get(HeaderEncoding))),
+		Disposition:      disposition,
+		Filename:         PartFilename(header.Get(HeaderDisposition), header.Get(HeaderContentType)),
+		ContentID:        strings.Trim(strings.TrimSpace(header.Get("Content-Id")), "<>"),
+		Size:             int64(len(body)),
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != "" && depth < maxPartDepth {
+		reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+		for n := 1; ; n++ {
+			child, err := reader.NextRawPart()
+			if err != nil {
+				break
+			}
+			data, err := io.ReadAll(child)
+			if err != nil {
+				break
+			}
+			part.Parts = append(part.Parts, inspectEntity(child.Header, data, childPartID(id, n), depth+1, leaf))
+		}
+		return part
+	}
+
+	part.DecodedSize = part.Size
+	if decoded, err := DecodeContent(body, part.TransferEncoding); err == nil {
+		part.DecodedSize = int64(len(decoded))
+	}
+	if leaf != nil {
+		leaf(part, body)
+	}
+	return part
+}
+
+// markBodyParts sets Body on the parts with the given HTML and text part IDs
+func markBodyParts(part *MIMEPart, htmlID, textID string) {
+	switch {
+	case part.ID == "":
+	case part.ID == htmlID:
+		part.Body = BodyKindHTML
+	case part.ID == textID:
+		part.Body = BodyKindText
+	}
+	for _, child := range part.Parts {
+		markBodyParts(child, htmlID, textID)
+	}
+}
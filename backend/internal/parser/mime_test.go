@@ -0,0 +1,109 @@
+package parser
+
+import (
+	"errors"
+	"testing"
+)
+
+const mimeTestMessage = "From: sender@example.com\r\n" +
+	"Subject: Report\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=outer\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/alternative; boundary=inner\r\n" +
+	"\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"Caf=C3=A9\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"\r\n" +
+	"<p>Caf\xc3\xa9</p>\r\n" +
+	"--inner--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: application/pdf; name=\"report.pdf\"\r\n" +
+	"Content-Disposition: attachment; filename=\"report.pdf\"\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"JVBERi0xLjQ=\r\n" +
+	"--outer--\r\n"
+
+// TestInspectMIME verifies part IDs follow IMAP numbering and the chosen bodies are marked
+func TestInspectMIME(t *testing.T) {
+	structure, err := InspectMIME([]byte(mimeTestMessage))
+	if err != nil {
+		t.Fatalf("InspectMIME failed: %v", err)
+	}
+
+	root := structure.Root
+	if root.ID != "" || root.ContentType != "multipart/mixed" || len(root.Parts) != 2 {
+		t.Fatalf("Root = %+v", root)
+	}
+	alternative := root.Parts[0]
+	if alternative.ID != "1" || alternative.ContentType != "multipart/alternative" || len(alternative.Parts) != 2 {
+		t.Fatalf("Part 1 = %+v", alternative)
+	}
+
+	text, html, pdf := alternative.Parts[0], alternative.Parts[1], root.Parts[1]
+	if text.ID != "1.1" || text.Charset != "utf-8" || text.TransferEncoding != "quoted-printable" || text.Body != BodyKindText {
+		t.Errorf("Part 1.1 = %+v", text)
+	}
+	if html.ID != "1.2" || html.ContentType != ContentTypeHTML || html.Body != BodyKindHTML {
+		t.Errorf("Part 1.2 = %+v", html)
+	}
+	if pdf.ID != "2" || pdf.Disposition != "attachment" || pdf.Filename != "report.pdf" || pdf.Body != "" {
+		t.Errorf("Part 2 = %+v", pdf)
+	}
+	if pdf.DecodedSize != 8 {
+		t.Errorf("Part 2 decoded size = %d, want 8", pdf.DecodedSize)
+	}
+	if structure.TextPart != "1.1" || structure.HTMLPart != "1.2" {
+		t.Errorf("Body parts = text %q html %q", structure.TextPart, structure.HTMLPart)
+	}
+}
+
+// TestInspectMIME_SinglePart verifies a message that is not multipart is part 1
+func TestInspectMIME_SinglePart(t *testing.T) {
+	raw := "From: sender@example.com\r\n\r\nHello\r\n"
+
+	structure, err := InspectMIME([]byte(raw))
+	if err != nil {
+		t.Fatalf("InspectMIME failed: %v", err)
+	}
+	if structure.Root.ID != rootPartID || structure.Root.ContentType != ContentTypePlain || structure.TextPart != rootPartID {
+		t.Errorf("Structure = %+v, root %+v", structure, structure.Root)
+	}
+}
+
+// TestExtractMIMEPart verifies leaf parts are returned decoded or as sent
+func TestExtractMIMEPart(t *testing.T) {
+	part, data, err := ExtractMIMEPart([]byte(mimeTestMessage), "1.1", true)
+	if err != nil {
+		t.Fatalf("ExtractMIMEPart failed: %v", err)
+	}
+	if part.ContentType != ContentTypePlain || string(data) != "Café" {
+		t.Errorf("Decoded part 1.1 = %q (%s)", data, part.ContentType)
+	}
+
+	_, data, err = ExtractMIMEPart([]byte(mimeTestMessage), "1.1", false)
+	if err != nil {
+		t.Fatalf("ExtractMIMEPart failed: %v", err)
+	}
+	if string(data) != "Caf=C3=A9" {
+		t.Errorf("Raw part 1.1 = %q", data)
+	}
+
+	_, data, err = ExtractMIMEPart([]byte(mimeTestMessage), "2", true)
+	if err != nil || string(data) != "%PDF-1.4" {
+		t.Errorf("Decoded part 2 = %q, %v", data, err)
+	}
+
+	for _, id := range []string{"", "1", "3", "1.3", "x"} {
+		if _, _, err := ExtractMIMEPart([]byte(mimeTestMessage), id, true); !errors.Is(err, ErrMIMEPartNotFound) {
+			t.Errorf("ExtractMIMEPart(%q) error = %v, want ErrMIMEPartNotFound", id, err)
+		}
+	}
+}
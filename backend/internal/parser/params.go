@@ -0,0 +1,300 @@
+package parser
+
+import (
+	"errors"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrMissingMediaType is returned by ParseMediaType when a header has no media or disposition type
+var ErrMissingMediaType = errors.New("missing media type")
+
+// paramSection is one RFC 2231 continuation of a parameter (name*N or name*N*)
+type paramSection struct {
+	value   string
+	encoded bool
+}
+
+// ParseMediaType parses a Content-Type or Content-Disposition value leniently
+// Unlike mime.ParseMediaType, malformed parameters never discard the others. Parameter values
+// are fully decoded: RFC 2231 continuations with charset, language and percent-encoding, RFC 2047
+// encoded words inside values, and raw 8-bit bytes are all converted to UTF-8.
+// The media type and parameter names are lower-cased. The error is only set when the type is missing.
+func ParseMediaType(value string) (string, map[string]string, error) {
+	mediaType, rest, _ := strings.Cut(value, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+
+	simple := make(map[string]string)
+	extended := make(map[string]string)
+	sections := make(map[string]map[int]paramSection)
+
+	for rest != "" {
+		var name, val string
+		var ok bool
+		name, val, rest, ok = nextParam(rest)
+		if !ok {
+			continue
+		}
+
+		base, star, isStar := strings.Cut(name, "*")
+		switch {
+		case !isStar:
+			if _, exists := simple[base]; !exists {
+				simple[base] = val
+			}
+		case star == "":
+			if _, exists := extended[base]; !exists {
+				extended[base] = val
+			}
+		default:
+			encoded := strings.HasSuffix(star, "*")
+			n, err := strconv.Atoi(strings.TrimSuffix(star, "*"))
+			if err != nil || n < 0 {
+				continue
+			}
+			if sections[base] == nil {
+				sections[base] = make(map[int]paramSection)
+			}
+			if _, exists := sections[base][n]; !exists {
+				sections[base][n] = paramSection{value: val, encoded: encoded}
+			}
+		}
+	}
+
+	params := make(map[string]string, len(simple))
+	for name, val := range simple {
+		params[name] = decodeParamText(val, "")
+	}
+	// RFC 2231 forms take precedence over the plain parameter sent for older clients
+	for name, val := range extended {
+		if decoded := decodeExtendedValue(val); decoded != "" || params[name] == "" {
+			params[name] = decoded
+		}
+	}
+	for name, parts := range sections {
+		if decoded := joinSections(parts); decoded != "" || params[name] == "" {
+			params[name] = decoded
+		}
+	}
+
+	if mediaType == "" {
+		return "", params, ErrMissingMediaType
+	}
+	return mediaType, params, nil
+}
+
+// nextParam consumes one "name=value" pair and returns the remaining input
+// Values may be quoted strings with backslash escapes, or tokens running to the next ";"
+// so that unquoted values containing spaces or tspecials are still accepted
+func nextParam(s string) (name, value, rest string, ok bool) {
+	s = strings.TrimLeft(s, " \t\r\n;")
+	if s == "" {
+		return "", "", "", false
+	}
+
+	eq := strings.IndexAny(s, "=;")
+	if eq < 0 || s[eq] == ';' {
+		// Parameter without a value; skip it
+		if eq < 0 {
+			return "", "", "", false
+		}
+		return "", "", s[eq+1:], false
+	}
+
+	name = strings.ToLower(strings.TrimSpace(s[:eq]))
+	s = strings.TrimLeft(s[eq+1:], " \t\r\n")
+
+	if strings.HasPrefix(s, `"`) {
+		var b strings.Builder
+		i := 1
+		for ; i < len(s); i++ {
+			c := s[i]
+			if c == '\\' && i+1 < len(s) {
+				i++
+				b.WriteByte(s[i])
+				continue
+			}
+			if c == '"' {
+				break
+			}
+			b.WriteByte(c)
+		}
+		value = b.String()
+		s = s[min(i+1, len(s)):]
+		// Anything between the closing quote and the next ";" is ignored
+		if semi := strings.IndexByte(s, ';'); semi >= 0 {
+			s = s[semi+1:]
+		} else {
+			s = ""
+		}
+	} else {
+		semi := strings.IndexByte(s, ';')
+		if semi < 0 {
+			value, s = s, ""
+		} else {
+			value, s = s[:semi], s[semi+1:]
+		}
+		value = strings.TrimSpace(value)
+	}
+
+	if name == "" {
+		return "", "", s, false
+	}
+	return name, value, s, true
+}
+
+// decodeExtendedValue decodes a single RFC 2231 value of the form charset'language'percent-encoded
+func decodeExtendedValue(value string) string {
+	charset, encoded := splitExtendedValue(value)
+	return decodeParamText(percentDecode(encoded), charset)
+}
+
+// splitExtendedValue separates the charset and drops the language of an RFC 2231 value
+// Values without the two apostrophes are treated as percent-encoded text in an unknown charset
+func splitExtendedValue(value string) (charset, encoded string) {
+	first := strings.IndexByte(value, '\'')
+	if first < 0 {
+		return "", value
+	}
+	second := strings.IndexByte(value[first+1:], '\'')
+	if second < 0 {
+		return "", value
+	}
+	return value[:first], value[first+1+second+1:]
+}
+
+// joinSections reassembles RFC 2231 continuations in order
+// Encoded sections are percent-decoded to bytes before the whole value is converted from the
+// charset of section 0, because clients split multi-byte characters across sections
+func joinSections(parts map[int]paramSection) string {
+	numbers := make([]int, 0, len(parts))
+	for n := range parts {
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+
+	var raw strings.Builder
+	charset := ""
+	for i, n := range numbers {
+		part := parts[n]
+		if !part.encoded {
+			raw.WriteString(part.value)
+			continue
+		}
+		value := part.value
+		if i == 0 {
+			charset, value = splitExtendedValue(value)
+		}
+		raw.WriteString(percentDecode(value))
+	}
+
+	return decodeParamText(raw.String(), charset)
+}
+
+// percentDecode decodes %XX escapes, keeping malformed escapes literally
+func percentDecode(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) {
+			if v, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// decodeParamText converts a parameter value in charset to UTF-8 and decodes RFC 2047 encoded
+// words, which many clients put inside quoted filenames instead of using RFC 2231
+func decodeParamText(value, charset string) string {
+	if charset != "" || !utf8.ValidString(value) {
+		converted, _ := DecodeCharset([]byte(value), charset)
+		value = string(converted)
+	}
+
+	if strings.Contains(value, "=?") {
+		decoder := &mime.WordDecoder{CharsetReader: (*charsetLog)(nil).charsetReader}
+		if decoded, err := decoder.DecodeHeader(value); err == nil {
+			value = decoded
+		}
+	}
+
+	return value
+}
+
+// PartFilename returns the filename of a MIME part
+// The Content-Disposition filename parameter is preferred over the Content-Type name parameter
+func PartFilename(disposition, contentType string) string {
+	if disposition != "" {
+		if _, params, _ := ParseMediaType(disposition); params["filename"] != "" {
+			return params["filename"]
+		}
+	}
+	if contentType != "" {
+		if _, params, _ := ParseMediaType(contentType); params["name"] != "" {
+			return params["name"]
+		}
+	}
+	return ""
+}
+
+// FormatContentDisposition renders a Content-Disposition header value for a download
+// An ASCII filename is always sent for old user agents; non-ASCII names are added as an
+// RFC 5987 filename* parameter in UTF-8
+func FormatContentDisposition(dispositionType, filename string) string {
+	var fallback strings.Builder
+	ascii := true
+	for _, r := range filename {
+		switch {
+		case r >= 0x80:
+			ascii = false
+			fallback.WriteByte('_')
+		case r < 0x20 || r == 0x7f || r == '"' || r == '\\':
+			fallback.WriteByte('_')
+		default:
+			fallback.WriteRune(r)
+		}
+	}
+
+	value := dispositionType + `; filename="` + fallback.String() + `"`
+	if !ascii || fallback.String() != filename {
+		value += "; filename*=UTF-8''" + encodeRFC5987(filename)
+	}
+	return value
+}
+
+// encodeRFC5987 percent-encodes everything outside the RFC 5987 attr-char set
+func encodeRFC5987(s string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if isAttrChar(c) {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hex[c>>4])
+		b.WriteByte(hex[c&0x0f])
+	}
+	return b.String()
+}
+
+// isAttrChar reports whether c may appear unencoded in an RFC 5987 value
+func isAttrChar(c byte) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$&+-.^_`|~", c) >= 0
+}
@@ -0,0 +1,152 @@
+package parser
+
+import (
+	"mime"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"pgregory.net/rapid"
+)
+
+// filenameCase is an entry of testdata/filename_corpus.txt
+type filenameCase struct {
+	description string
+	header      string // Header name
+	value       string // Unfolded header value
+	want        string
+}
+
+// loadFilenameCorpus reads the client quirks corpus
+func loadFilenameCorpus(t *testing.T) []filenameCase {
+	t.Helper()
+	data, err := os.ReadFile("testdata/filename_corpus.txt")
+	if err != nil {
+		t.Fatalf("Failed to read corpus: %v", err)
+	}
+
+	var cases []filenameCase
+	var current filenameCase
+	for _, line := range strings.Split(string(data), "\n") {
+		switch {
+		case strings.HasPrefix(line, "#"):
+			current.description = strings.TrimSpace(strings.TrimPrefix(line, "#"))
+		case strings.HasPrefix(line, "=> "):
+			current.want = strings.TrimPrefix(line, "=> ")
+			cases = append(cases, current)
+			current = filenameCase{}
+		case strings.HasPrefix(line, " "), strings.HasPrefix(line, "\t"):
+			current.value += line
+		case strings.TrimSpace(line) != "":
+			name, value, _ := strings.Cut(line, ":")
+			current.header, current.value = name, strings.TrimSpace(value)
+		}
+	}
+	if len(cases) == 0 {
+		t.Fatal("Corpus is empty")
+	}
+	return cases
+}
+
+// TestPartFilename_Corpus verifies real-world filename quirks decode and survive a round trip
+func TestPartFilename_Corpus(t *testing.T) {
+	for _, tc := range loadFilenameCorpus(t) {
+		t.Run(tc.description, func(t *testing.T) {
+			var got string
+			if strings.EqualFold(tc.header, HeaderContentType) {
+				got = PartFilename("", tc.value)
+			} else {
+				got = PartFilename(tc.value, "")
+			}
+			if got != tc.want {
+				t.Fatalf("PartFilename(%q) = %q, want %q", tc.value, got, tc.want)
+			}
+
+			// Re-encoding for a download decodes to the same name
+			header := FormatContentDisposition("attachment", got)
+			for _, r := range header {
+				if r >= 0x80 || r < 0x20 {
+					t.Fatalf("Content-Disposition must be printable ASCII: %q", header)
+				}
+			}
+			if roundTrip := PartFilename(header, ""); roundTrip != tc.want {
+				t.Fatalf("Round trip through %q = %q, want %q", header, roundTrip, tc.want)
+			}
+		})
+	}
+}
+
+// encodeContinuations encodes a filename as RFC 2231 continuations cut at the given widths
+// Cuts never split a %XX triplet, but may split a multi-byte character
+func encodeContinuations(name string, widths []int) string {
+	encoded := encodeRFC5987(name)
+	var sections []string
+	for len(encoded) > 0 {
+		width := 1
+		if len(widths) > 0 {
+			width, widths = widths[0], widths[1:]
+		}
+		cut := 0
+		for cut < len(encoded) && cut < width {
+			if encoded[cut] == '%' {
+				cut += 3
+			} else {
+				cut++
+			}
+		}
+		sections = append(sections, encoded[:cut])
+		encoded = encoded[cut:]
+	}
+
+	var b strings.Builder
+	b.WriteString("attachment")
+	for i, section := range sections {
+		if i == 0 {
+			section = "UTF-8''" + section
+		}
+		b.WriteString(";\r\n filename*" + strconv.Itoa(i) + "*=" + section)
+	}
+	return b.String()
+}
+
+// TestParseMediaType_RoundTrip verifies any filename survives RFC 2231 and RFC 5987 encoding
+func TestParseMediaType_RoundTrip(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		name := rapid.StringMatching(`[^\x00-\x1f\x7f]{1,40}`).Draw(t, "name")
+		widths := rapid.SliceOfN(rapid.IntRange(1, 12), 0, 10).Draw(t, "widths")
+
+		if got := PartFilename(FormatContentDisposition("attachment", name), ""); got != name {
+			t.Fatalf("FormatContentDisposition round trip = %q, want %q", got, name)
+		}
+
+		continued := strings.ReplaceAll(encodeContinuations(name, widths), "\r\n", "")
+		if got := PartFilename(continued, ""); got != name {
+			t.Fatalf("Continuation round trip of %q = %q, want %q", continued, got, name)
+		}
+
+		// Values produced by the standard library encoder are read back
+		if formatted := mime.FormatMediaType("attachment", map[string]string{"filename": name}); formatted != "" {
+			if got := PartFilename(formatted, ""); got != name {
+				t.Fatalf("mime.FormatMediaType round trip of %q = %q, want %q", formatted, got, name)
+			}
+		}
+	})
+}
+
+// TestParseMediaType_Lenient verifies malformed parameters do not discard the rest
+func TestParseMediaType_Lenient(t *testing.T) {
+	mediaType, params, err := ParseMediaType(`Multipart/Mixed; ; junk; boundary="b1"; charset*=us-ascii'en'utf%2D8`)
+	if err != nil || mediaType != "multipart/mixed" || params["boundary"] != "b1" || params["charset"] != "utf-8" {
+		t.Fatalf("Unexpected result %q %v %v", mediaType, params, err)
+	}
+
+	if _, _, err := ParseMediaType(`; filename="x"`); err != ErrMissingMediaType {
+		t.Fatalf("Expected ErrMissingMediaType, got %v", err)
+	}
+
+	// Raw 8-bit ISO-8859-1 in a quoted filename is decoded with the fallback charset
+	if got := PartFilename("attachment; filename=\"caf\xe9.txt\"", ""); got != "café.txt" {
+		t.Fatalf("Expected fallback decoding, got %q", got)
+	}
+}
@@ -7,9 +7,11 @@ import (
 	"mime"
 	"mime/multipart"
 	"net/mail"
+	"net/textproto"
 	"regexp"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 // EmailParser implements email parsing functionality
@@ -23,7 +25,13 @@ func NewEmailParser() *EmailParser {
 
 // Parse parses a raw email into a ParsedEmail structure
 // Requirements: 4.1-4.12
+// Forwarded messages (message/rfc822 parts) are parsed recursively into Embedded, up to MaxEmbeddedDepth
 func (p *EmailParser) Parse(raw []byte) (*ParsedEmail, error) {
+	return p.parse(raw, "", 0)
+}
+
+// parse implements Parse for the message at path, depth levels below the top-level email
+func (p *EmailParser) parse(raw []byte, path string, depth int) (*ParsedEmail, error) {
 	if len(raw) == 0 {
 		return nil, &ParseError{
 			Stage:   "parse",
@@ -42,8 +50,11 @@ func (p *EmailParser) Parse(raw []byte) (*ParsedEmail, error) {
 		}
 	}
 
+	// Charsets detected in headers and bodies are recorded for diagnostics
+	charsets := &charsetLog{}
+
 	// Extract headers
-	headers, err := p.ExtractHeaders(msg)
+	headers, err := p.extractHeaders(msg, charsets)
 	if err != nil {
 		return nil, &ParseError{
 			Stage:   "headers",
@@ -52,23 +63,61 @@ func (p *EmailParser) Parse(raw []byte) (*ParsedEmail, error) {
 		}
 	}
 
+	// Keep every field in order, including repeated Received and DKIM-Signature fields
+	headerList := ParseHeaderList(raw)
+
 	// Extract From address and display name (Requirements 4.1, 4.2)
-	fromAddress, fromName := p.extractFromHeader(msg.Header.Get(HeaderFrom))
+	fromAddress, fromName := p.extractFromHeader(msg.Header.Get(HeaderFrom), charsets)
 
 	// Extract Subject (Requirement 4.3)
-	subject := p.decodeHeader(msg.Header.Get(HeaderSubject))
+	subject := p.decodeHeader(msg.Header.Get(HeaderSubject), charsets)
 
 	// Extract To address
-	toAddress := p.extractToAddress(msg.Header.Get(HeaderTo))
+	toAddress := p.extractToAddress(msg.Header.Get(HeaderTo), charsets)
 
 	// Extract body content (Requirements 4.5-4.8)
-	bodyHTML, bodyText, err := p.ExtractBody(msg)
+	bodyHTML, bodyText, err := p.extractBody(msg, charsets, &bodySource{})
 	if err != nil {
 		// Log error but continue - store raw email
 		bodyHTML = ""
 		bodyText = ""
 	}
 
+	// Outlook's winmail.dat carries the formatted body and the message properties
+	var tnefProperties map[string]string
+	if tnef := extractTNEF(raw); tnef != nil {
+		if bodyHTML == "" {
+			bodyHTML = tnef.BodyHTML
+		}
+		if strings.TrimSpace(bodyText) == "" {
+			bodyText = tnef.BodyText
+		}
+		tnefProperties = tnef.Properties
+	}
+
+	// Meeting invitations are kept as structured data for event cards
+	calendar := extractCalendar(raw)
+
+	// Signup and login emails are searched for one-time codes and account links
+	codes := ExtractCodes(fromAddress, subject, bodyText, bodyHTML)
+
+	// Threading headers place replies in the conversation they belong to
+	thread := ExtractThreadInfo(msg.Header, subject)
+
+	// Forwarded-as-attachment messages are parsed as emails of their own
+	var embedded []*EmbeddedMessage
+	if depth < MaxEmbeddedDepth {
+		embedded = p.extractEmbedded(raw, path, depth)
+	}
+
+	// An embedded message was received when it was sent, not when it was parsed
+	receivedAt := time.Now().UTC()
+	if depth > 0 {
+		if date, err := msg.Header.Date(); err == nil {
+			receivedAt = date.UTC()
+		}
+	}
+
 	parsed := &ParsedEmail{
 		From:       fromAddress,
 		FromName:   fromName,
@@ -77,9 +126,19 @@ func (p *EmailParser) Parse(raw []byte) (*ParsedEmail, error) {
 		BodyHTML:   bodyHTML,
 		BodyText:   bodyText,
 		Headers:    headers,
+		HeaderList: headerList,
 		SizeBytes:  int64(len(raw)),
-		ReceivedAt: time.Now().UTC(),
+		ReceivedAt: receivedAt,
 		RawEmail:   raw,
+
+		Charset:         charsets.charset,
+		CharsetWarnings: charsets.warnings,
+
+		TNEFProperties: tnefProperties,
+		Calendar:       calendar,
+		Codes:          codes,
+		Thread:         thread,
+		Embedded:       embedded,
 	}
 
 	return parsed, nil
@@ -90,6 +149,11 @@ func (p *EmailParser) Parse(raw []byte) (*ParsedEmail, error) {
 // Property 6: Header Extraction - correctly extracts all headers into JSONB format
 // Property 13: Header Injection Prevention - validates CRLF injection and header length
 func (p *EmailParser) ExtractHeaders(msg *mail.Message) (map[string]string, error) {
+	return p.extractHeaders(msg, nil)
+}
+
+// extractHeaders implements ExtractHeaders, recording the charsets of encoded words
+func (p *EmailParser) extractHeaders(msg *mail.Message, charsets *charsetLog) (map[string]string, error) {
 	headers := make(map[string]string)
 
 	for key, values := range msg.Header {
@@ -110,7 +174,7 @@ func (p *EmailParser) ExtractHeaders(msg *mail.Message) (map[string]string, erro
 			}
 
 			// Decode MIME encoded words
-			decodedValue := p.decodeHeader(value)
+			decodedValue := p.decodeHeader(value, charsets)
 
 			// Store header (use first value if multiple)
 			if _, exists := headers[key]; !exists {
@@ -124,13 +188,13 @@ func (p *EmailParser) ExtractHeaders(msg *mail.Message) (map[string]string, erro
 
 // extractFromHeader extracts email address and display name from From header
 // Requirements: 4.1, 4.2
-func (p *EmailParser) extractFromHeader(from string) (address, name string) {
+func (p *EmailParser) extractFromHeader(from string, charsets *charsetLog) (address, name string) {
 	if from == "" {
 		return "", ""
 	}
 
 	// Decode MIME encoded words first
-	from = p.decodeHeader(from)
+	from = p.decodeHeader(from, charsets)
 
 	// Parse the address
 	addr, err := mail.ParseAddress(from)
@@ -144,13 +208,13 @@ func (p *EmailParser) extractFromHeader(from string) (address, name string) {
 }
 
 // extractToAddress extracts the primary To address
-func (p *EmailParser) extractToAddress(to string) string {
+func (p *EmailParser) extractToAddress(to string, charsets *charsetLog) string {
 	if to == "" {
 		return ""
 	}
 
 	// Decode MIME encoded words first
-	to = p.decodeHeader(to)
+	to = p.decodeHeader(to, charsets)
 
 	// Parse the address list
 	addrs, err := mail.ParseAddressList(to)
@@ -163,16 +227,23 @@ func (p *EmailParser) extractToAddress(to string) string {
 }
 
 // decodeHeader decodes MIME encoded words in a header value
-func (p *EmailParser) decodeHeader(value string) string {
+// Encoded words in any registered charset are converted to UTF-8; raw 8-bit bytes outside
+// encoded words are decoded with FallbackCharset so the result is always valid UTF-8
+func (p *EmailParser) decodeHeader(value string, charsets *charsetLog) string {
 	if value == "" {
 		return ""
 	}
 
-	decoder := new(mime.WordDecoder)
+	decoder := &mime.WordDecoder{CharsetReader: charsets.charsetReader}
 	decoded, err := decoder.DecodeHeader(value)
 	if err != nil {
 		// Return original value if decoding fails
-		return value
+		decoded = value
+	}
+
+	if !utf8.ValidString(decoded) {
+		converted, _ := DecodeCharset([]byte(decoded), "")
+		decoded = string(converted)
 	}
 
 	return decoded
@@ -230,71 +301,186 @@ func TruncateHeader(value string) string {
 // Requirements: 4.5-4.8
 // Property 7: Content Type Handling - correctly extracts body content for various content types
 func (p *EmailParser) ExtractBody(msg *mail.Message) (html, text string, err error) {
+	return p.extractBody(msg, nil, &bodySource{})
+}
+
+// extractBody implements ExtractBody, recording the charsets of text parts
+// Part IDs of the parts the bodies were taken from are recorded in src
+func (p *EmailParser) extractBody(msg *mail.Message, charsets *charsetLog, src *bodySource) (html, text string, err error) {
+	encoding := msg.Header.Get(HeaderEncoding)
 	contentType := msg.Header.Get(HeaderContentType)
 	if contentType == "" {
 		// Default to text/plain if no content type specified
 		contentType = ContentTypePlain
 	}
 
-	mediaType, params, err := mime.ParseMediaType(contentType)
+	mediaType, params, err := ParseMediaType(contentType)
 	if err != nil {
 		// Try to read as plain text
-		body, readErr := io.ReadAll(msg.Body)
+		body, readErr := readTextPart(msg.Body, encoding, nil, charsets)
 		if readErr != nil {
 			return "", "", readErr
 		}
-		return "", string(body), nil
+		src.text = rootPartID
+		return "", body, nil
 	}
 
 	switch {
 	case mediaType == ContentTypePlain:
 		// Requirement 4.5: Handle text/plain content type
-		body, err := io.ReadAll(msg.Body)
+		body, err := readTextPart(msg.Body, encoding, params, charsets)
 		if err != nil {
 			return "", "", err
 		}
-		return "", string(body), nil
+		src.text = rootPartID
+		return "", body, nil
 
 	case mediaType == ContentTypeHTML:
 		// Requirement 4.6: Handle text/html content type
-		body, err := io.ReadAll(msg.Body)
+		body, err := readTextPart(msg.Body, encoding, params, charsets)
 		if err != nil {
 			return "", "", err
 		}
-		return string(body), "", nil
+		src.html = rootPartID
+		return body, "", nil
 
 	case mediaType == ContentTypeMultiAlt:
 		// Requirement 4.7: Handle multipart/alternative (prefer HTML over plain text)
-		return p.extractMultipartAlternative(msg.Body, params["boundary"])
+		return p.extractMultipartAlternative(msg.Body, params["boundary"], charsets, "", src)
 
 	case mediaType == ContentTypeMultiMixed:
 		// Requirement 4.8: Handle multipart/mixed (email with attachments)
-		return p.extractMultipartMixed(msg.Body, params["boundary"])
+		return p.extractMultipartMixed(msg.Body, params["boundary"], charsets, "", src)
 
 	case strings.HasPrefix(mediaType, "multipart/"):
 		// Handle other multipart types
-		return p.extractMultipartGeneric(msg.Body, params["boundary"])
+		return p.extractMultipartGeneric(msg.Body, params["boundary"], charsets, "", src)
 
 	default:
 		// Unknown content type, try to read as text
-		body, err := io.ReadAll(msg.Body)
+		body, err := readTextPart(msg.Body, encoding, params, charsets)
 		if err != nil {
 			return "", "", err
 		}
-		return "", string(body), nil
+		src.text = rootPartID
+		return "", body, nil
+	}
+}
+
+// readTextPart reads a text body, undoing its transfer encoding and converting it to UTF-8
+// multipart.Reader already decodes quoted-printable parts and drops their encoding header
+// Requirement 4.11: Handle various character encodings
+func readTextPart(body io.Reader, encoding string, params map[string]string, charsets *charsetLog) (string, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+
+	if decoded, err := DecodeContent(data, encoding); err == nil {
+		data = decoded
+	}
+
+	converted, info := DecodeCharset(data, params["charset"])
+	charsets.record("body", info)
+	return string(converted), nil
+}
+
+// maxPartDepth limits how deeply walkParts descends into nested multiparts
+const maxPartDepth = 8
+
+// findPart returns the header and transfer-decoded content of the first MIME part that matches,
+// searching nested multiparts depth-first. The match receives the media type and filename.
+func findPart(raw []byte, match func(mediaType, filename string) bool) (textproto.MIMEHeader, []byte, bool) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, nil, false
+	}
+
+	var found textproto.MIMEHeader
+	var content []byte
+	walkParts(textproto.MIMEHeader(msg.Header), msg.Body, func(header textproto.MIMEHeader, mediaType string, body io.Reader) bool {
+		if !match(mediaType, PartFilename(header.Get(HeaderDisposition), header.Get(HeaderContentType))) {
+			return true
+		}
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return true
+		}
+		if decoded, err := DecodeContent(data, header.Get(HeaderEncoding)); err == nil {
+			data = decoded
+		}
+		found, content = header, data
+		return false
+	})
+	return found, content, found != nil
+}
+
+// walkParts calls visit with each leaf MIME part of an entity, depth-first, until visit returns false
+// Multiparts nested deeper than maxPartDepth are skipped; message/rfc822 parts are leaves
+func walkParts(header textproto.MIMEHeader, body io.Reader, visit func(header textproto.MIMEHeader, mediaType string, body io.Reader) bool) {
+	walkPartsIn(header, body, visit, 0)
+}
+
+func walkPartsIn(header textproto.MIMEHeader, body io.Reader, visit func(header textproto.MIMEHeader, mediaType string, body io.Reader) bool, depth int) bool {
+	mediaType, params, _ := ParseMediaType(header.Get(HeaderContentType))
+
+	if strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != "" {
+		if depth >= maxPartDepth {
+			return true
+		}
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err != nil {
+				return true
+			}
+			if !walkPartsIn(part.Header, part, visit, depth+1) {
+				return false
+			}
+		}
+	}
+
+	return visit(header, mediaType, body)
+}
+
+// containsFold reports whether substr occurs in data ignoring ASCII case, without copying data
+// Candidates are found with bytes.IndexByte on either case of the first byte, so large bodies
+// are scanned at memchr speed.
+func containsFold(data []byte, substr string) bool {
+	if substr == "" {
+		return true
+	}
+	needle := []byte(substr)
+	firsts := []byte{needle[0]}
+	if lower := needle[0] | 0x20; 'a' <= lower && lower <= 'z' {
+		firsts = []byte{lower, lower &^ 0x20}
+	}
+
+	for _, first := range firsts {
+		for rest := data; len(rest) >= len(needle); {
+			i := bytes.IndexByte(rest, first)
+			if i < 0 || len(rest)-i < len(needle) {
+				break
+			}
+			if bytes.EqualFold(rest[i:i+len(needle)], needle) {
+				return true
+			}
+			rest = rest[i+1:]
+		}
 	}
+	return false
 }
 
 // extractMultipartAlternative extracts body from multipart/alternative
 // Requirement 4.7: Prefer HTML over plain text
-func (p *EmailParser) extractMultipartAlternative(body io.Reader, boundary string) (html, text string, err error) {
+func (p *EmailParser) extractMultipartAlternative(body io.Reader, boundary string, charsets *charsetLog, id string, src *bodySource) (html, text string, err error) {
 	if boundary == "" {
 		return "", "", fmt.Errorf("missing boundary for multipart/alternative")
 	}
 
 	reader := multipart.NewReader(body, boundary)
 
-	for {
+	for n := 1; ; n++ {
 		part, err := reader.NextPart()
 		if err == io.EOF {
 			break
@@ -302,20 +488,24 @@ func (p *EmailParser) extractMultipartAlternative(body io.Reader, boundary strin
 		if err != nil {
 			return html, text, err
 		}
+		partID := childPartID(id, n)
 
 		contentType := part.Header.Get(HeaderContentType)
-		mediaType, _, _ := mime.ParseMediaType(contentType)
+		mediaType, params, _ := ParseMediaType(contentType)
+		if mediaType != ContentTypePlain && mediaType != ContentTypeHTML {
+			continue
+		}
 
-		partBody, err := io.ReadAll(part)
+		partBody, err := readTextPart(part, part.Header.Get(HeaderEncoding), params, charsets)
 		if err != nil {
 			continue
 		}
 
 		switch mediaType {
 		case ContentTypePlain:
-			text = string(partBody)
+			text, src.text = partBody, partID
 		case ContentTypeHTML:
-			html = string(partBody)
+			html, src.html = partBody, partID
 		}
 	}
 
@@ -324,14 +514,14 @@ func (p *EmailParser) extractMultipartAlternative(body io.Reader, boundary strin
 
 // extractMultipartMixed extracts body from multipart/mixed
 // Requirement 4.8: Handle multipart/mixed (email with attachments)
-func (p *EmailParser) extractMultipartMixed(body io.Reader, boundary string) (html, text string, err error) {
+func (p *EmailParser) extractMultipartMixed(body io.Reader, boundary string, charsets *charsetLog, id string, src *bodySource) (html, text string, err error) {
 	if boundary == "" {
 		return "", "", fmt.Errorf("missing boundary for multipart/mixed")
 	}
 
 	reader := multipart.NewReader(body, boundary)
 
-	for {
+	for n := 1; ; n++ {
 		part, err := reader.NextPart()
 		if err == io.EOF {
 			break
@@ -339,40 +529,42 @@ func (p *EmailParser) extractMultipartMixed(body io.Reader, boundary string) (ht
 		if err != nil {
 			return html, text, err
 		}
+		partID := childPartID(id, n)
 
 		// Check if this is an attachment
-		disposition := part.Header.Get(HeaderDisposition)
-		if strings.HasPrefix(disposition, "attachment") {
+		dispositionType, _, _ := ParseMediaType(part.Header.Get(HeaderDisposition))
+		if dispositionType == "attachment" {
 			// Skip attachments for body extraction
 			continue
 		}
 
 		contentType := part.Header.Get(HeaderContentType)
-		mediaType, params, _ := mime.ParseMediaType(contentType)
+		mediaType, params, _ := ParseMediaType(contentType)
 
 		switch {
 		case mediaType == ContentTypePlain:
-			partBody, err := io.ReadAll(part)
+			partBody, err := readTextPart(part, part.Header.Get(HeaderEncoding), params, charsets)
 			if err != nil {
 				continue
 			}
-			text = string(partBody)
+			text, src.text = partBody, partID
 
 		case mediaType == ContentTypeHTML:
-			partBody, err := io.ReadAll(part)
+			partBody, err := readTextPart(part, part.Header.Get(HeaderEncoding), params, charsets)
 			if err != nil {
 				continue
 			}
-			html = string(partBody)
+			html, src.html = partBody, partID
 
 		case mediaType == ContentTypeMultiAlt:
 			// Nested multipart/alternative
-			nestedHTML, nestedText, _ := p.extractMultipartAlternative(part, params["boundary"])
+			var nested bodySource
+			nestedHTML, nestedText, _ := p.extractMultipartAlternative(part, params["boundary"], charsets, partID, &nested)
 			if nestedHTML != "" {
-				html = nestedHTML
+				html, src.html = nestedHTML, nested.html
 			}
 			if nestedText != "" {
-				text = nestedText
+				text, src.text = nestedText, nested.text
 			}
 		}
 	}
@@ -381,14 +573,14 @@ func (p *EmailParser) extractMultipartMixed(body io.Reader, boundary string) (ht
 }
 
 // extractMultipartGeneric extracts body from generic multipart types
-func (p *EmailParser) extractMultipartGeneric(body io.Reader, boundary string) (html, text string, err error) {
+func (p *EmailParser) extractMultipartGeneric(body io.Reader, boundary string, charsets *charsetLog, id string, src *bodySource) (html, text string, err error) {
 	if boundary == "" {
 		return "", "", fmt.Errorf("missing boundary for multipart")
 	}
 
 	reader := multipart.NewReader(body, boundary)
 
-	for {
+	for n := 1; ; n++ {
 		part, err := reader.NextPart()
 		if err == io.EOF {
 			break
@@ -396,45 +588,44 @@ func (p *EmailParser) extractMultipartGeneric(body io.Reader, boundary string) (
 		if err != nil {
 			return html, text, err
 		}
+		partID := childPartID(id, n)
 
 		contentType := part.Header.Get(HeaderContentType)
-		mediaType, params, _ := mime.ParseMediaType(contentType)
+		mediaType, params, _ := ParseMediaType(contentType)
 
 		switch {
-		case mediaType == ContentTypePlain:
-			partBody, err := io.ReadAll(part)
+		case mediaType == ContentTypePlain && text == "":
+			partBody, err := readTextPart(part, part.Header.Get(HeaderEncoding), params, charsets)
 			if err != nil {
 				continue
 			}
-			if text == "" {
-				text = string(partBody)
-			}
+			text, src.text = partBody, partID
 
-		case mediaType == ContentTypeHTML:
-			partBody, err := io.ReadAll(part)
+		case mediaType == ContentTypeHTML && html == "":
+			partBody, err := readTextPart(part, part.Header.Get(HeaderEncoding), params, charsets)
 			if err != nil {
 				continue
 			}
-			if html == "" {
-				html = string(partBody)
-			}
+			html, src.html = partBody, partID
 
 		case mediaType == ContentTypeMultiAlt:
-			nestedHTML, nestedText, _ := p.extractMultipartAlternative(part, params["boundary"])
+			var nested bodySource
+			nestedHTML, nestedText, _ := p.extractMultipartAlternative(part, params["boundary"], charsets, partID, &nested)
 			if nestedHTML != "" && html == "" {
-				html = nestedHTML
+				html, src.html = nestedHTML, nested.html
 			}
 			if nestedText != "" && text == "" {
-				text = nestedText
+				text, src.text = nestedText, nested.text
 			}
 
 		case strings.HasPrefix(mediaType, "multipart/"):
-			nestedHTML, nestedText, _ := p.extractMultipartGeneric(part, params["boundary"])
+			var nested bodySource
+			nestedHTML, nestedText, _ := p.extractMultipartGeneric(part, params["boundary"], charsets, partID, &nested)
 			if nestedHTML != "" && html == "" {
-				html = nestedHTML
+				html, src.html = nestedHTML, nested.html
 			}
 			if nestedText != "" && text == "" {
-				text = nestedText
+				text, src.text = nestedText, nested.text
 			}
 		}
 	}
@@ -703,80 +894,16 @@ func EncodeBase64(data []byte) []byte {
 
 // ConvertCharset converts content from a source charset to UTF-8
 // Requirement 4.11: Handle various character encodings (UTF-8, ISO-8859-1, etc.)
+// Every charset in the registry is supported; invalid sequences are replaced with U+FFFD.
+// Unknown charsets return ErrUnknownCharset together with a best-effort conversion.
 func ConvertCharset(data []byte, charset string) ([]byte, error) {
-	charset = strings.ToLower(strings.TrimSpace(charset))
-
-	switch charset {
-	case "utf-8", "utf8", "":
-		// Already UTF-8 or no charset specified
-		return data, nil
-
-	case "iso-8859-1", "latin1", "latin-1":
-		// Convert ISO-8859-1 to UTF-8
-		return convertISO88591ToUTF8(data), nil
-
-	case "iso-8859-15", "latin9", "latin-9":
-		// Convert ISO-8859-15 to UTF-8 (similar to ISO-8859-1 with some differences)
-		return convertISO885915ToUTF8(data), nil
-
-	case "windows-1252", "cp1252":
-		// Convert Windows-1252 to UTF-8
-		return convertWindows1252ToUTF8(data), nil
-
-	case "us-ascii", "ascii":
-		// ASCII is a subset of UTF-8
-		return data, nil
-
-	default:
-		// Unknown charset, return as-is
-		return data, nil
-	}
-}
-
-// convertISO88591ToUTF8 converts ISO-8859-1 encoded bytes to UTF-8
-func convertISO88591ToUTF8(data []byte) []byte {
-	var result bytes.Buffer
-	for _, b := range data {
-		if b < 128 {
-			result.WriteByte(b)
-		} else {
-			// ISO-8859-1 bytes 128-255 map directly to Unicode code points 128-255
-			result.WriteByte(0xC0 | (b >> 6))
-			result.WriteByte(0x80 | (b & 0x3F))
-		}
+	converted, info := DecodeCharset(data, charset)
+	if !info.Known {
+		return converted, fmt.Errorf("%w: %s", ErrUnknownCharset, info.Label)
 	}
-	return result.Bytes()
-}
-
-// convertISO885915ToUTF8 converts ISO-8859-15 encoded bytes to UTF-8
-func convertISO885915ToUTF8(data []byte) []byte {
-	// ISO-8859-15 is mostly the same as ISO-8859-1, with a few differences
-	// For simplicity, we treat it the same as ISO-8859-1
-	return convertISO88591ToUTF8(data)
+	return converted, nil
 }
 
-// convertWindows1252ToUTF8 converts Windows-1252 encoded bytes to UTF-8
-func convertWindows1252ToUTF8(data []byte) []byte {
-	// Windows-1252 is similar to ISO-8859-1 but has different mappings for 128-159
-	var result bytes.Buffer
-	for _, b := range data {
-		if b < 128 {
-			result.WriteByte(b)
-		} else if b >= 160 {
-			// Same as ISO-8859-1 for 160-255
-			result.WriteByte(0xC0 | (b >> 6))
-			result.WriteByte(0x80 | (b & 0x3F))
-		} else {
-			// Windows-1252 specific mappings for 128-159
-			// For simplicity, we'll use the ISO-8859-1 mapping
-			result.WriteByte(0xC0 | (b >> 6))
-			result.WriteByte(0x80 | (b & 0x3F))
-		}
-	}
-	return result.Bytes()
-}
-
-
 // ParseWithErrorRecovery parses an email with error recovery
 // Requirement 4.12: Store raw email on parse failure and log error
 // Property 9: Parse Error Handling - malformed emails are stored raw without crashing
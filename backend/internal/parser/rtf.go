@@ -0,0 +1,319 @@
+package parser
+
+import (
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidCompressedRTF is returned by DecompressRTF for streams it cannot read
+var ErrInvalidCompressedRTF = errors.New("invalid compressed RTF")
+
+// Compressed RTF stream types (MS-OXRTFCP 2.1.3.1.1)
+const (
+	rtfCompressed   = 0x75465A4C // "LZFu"
+	rtfUncompressed = 0x414C454D // "MELA"
+)
+
+// rtfPrebuffer initializes the compressed RTF dictionary (MS-OXRTFCP 3.1.1.1.2)
+const rtfPrebuffer = "{\\rtf1\\ansi\\mac\\deff0\\deftab720{\\fonttbl;}{\\f0\\fnil \\froman \\fswiss \\fmodern \\fscript \\fdecor MS Sans SerifSymbolArialTimes New RomanCourier{\\colortbl\\red0\\green0\\blue0\r\n\\par \\pard\\plain\\f0\\fs20\\b\\i\\u\\tab\\tx"
+
+// DecompressRTF expands a PR_RTF_COMPRESSED property as written by Outlook
+// Truncated streams return what was decoded so far, matching how Outlook renders them
+func DecompressRTF(data []byte) ([]byte, error) {
+	if len(data) < 16 {
+		return nil, ErrInvalidCompressedRTF
+	}
+
+	compSize := int(binary.LittleEndian.Uint32(data[0:4]))
+	rawSize := int(binary.LittleEndian.Uint32(data[4:8]))
+	compType := binary.LittleEndian.Uint32(data[8:12])
+
+	// compSize counts the header fields after itself
+	end := len(data)
+	if compSize+4 < end && compSize >= 12 {
+		end = compSize + 4
+	}
+	input := data[16:end]
+
+	switch compType {
+	case rtfUncompressed:
+		return input[:min(rawSize, len(input))], nil
+	case rtfCompressed:
+	default:
+		return nil, ErrInvalidCompressedRTF
+	}
+
+	var dict [4096]byte
+	copy(dict[:], rtfPrebuffer)
+	write := len(rtfPrebuffer)
+
+	out := make([]byte, 0, min(rawSize, 16*len(input)))
+	emit := func(b byte) {
+		out = append(out, b)
+		dict[write] = b
+		write = (write + 1) % len(dict)
+	}
+
+	for i := 0; i < len(input); {
+		control := input[i]
+		i++
+		for bit := 0; bit < 8 && i < len(input); bit++ {
+			if control&(1<<bit) == 0 {
+				emit(input[i])
+				i++
+				continue
+			}
+
+			if i+1 >= len(input) {
+				return out, nil
+			}
+			ref := int(input[i])<<8 | int(input[i+1])
+			i += 2
+			offset, length := ref>>4, ref&0x0f+2
+			// A reference to the current write position marks the end of the stream
+			if offset == write {
+				return out, nil
+			}
+			for k := 0; k < length; k++ {
+				emit(dict[(offset+k)%len(dict)])
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// rtfSkippedDestinations are groups whose content is never part of the body
+var rtfSkippedDestinations = map[string]bool{
+	"fonttbl": true, "colortbl": true, "stylesheet": true, "info": true, "pict": true,
+	"object": true, "header": true, "footer": true, "headerl": true, "headerr": true,
+	"footerl": true, "footerr": true, "listtable": true, "listoverridetable": true,
+	"rsidtbl": true, "generator": true, "xmlnstbl": true, "themedata": true,
+	"colorschememapping": true, "latentstyles": true, "datastore": true, "filetbl": true,
+}
+
+// rtfSymbols maps control words for typographic characters to their text
+var rtfSymbols = map[string]string{
+	"par": "\n", "line": "\n", "sect": "\n", "row": "\n", "tab": "\t", "cell": "\t",
+	"emdash": "—", "endash": "–", "bullet": "•", "lquote": "‘", "rquote": "’",
+	"ldblquote": "“", "rdblquote": "”", "emspace": "\u2003", "enspace": "\u2002",
+}
+
+// rtfGroup is the formatting state saved and restored at group boundaries
+type rtfGroup struct {
+	skip    bool // Ignored destination
+	htmltag bool // Inside {\*\htmltag ...}: original HTML markup
+	htmlrtf bool // Inside \htmlrtf ... \htmlrtf0: RTF-only content
+	uc      int  // Fallback characters following \uN
+}
+
+// rtfWriter collects body text, decoding \'hh bytes in the document code page
+type rtfWriter struct {
+	out      strings.Builder
+	pending  []byte
+	codepage string
+}
+
+// writeByte queues a byte in the document code page
+func (w *rtfWriter) writeByte(b byte) {
+	w.pending = append(w.pending, b)
+}
+
+// writeString flushes queued bytes and appends UTF-8 text
+func (w *rtfWriter) writeString(s string) {
+	w.flush()
+	w.out.WriteString(s)
+}
+
+// flush converts queued code page bytes to UTF-8
+func (w *rtfWriter) flush() {
+	if len(w.pending) == 0 {
+		return
+	}
+	decoded, _ := DecodeCharset(w.pending, w.codepage)
+	w.out.Write(decoded)
+	w.pending = w.pending[:0]
+}
+
+// RTFBody extracts the message body from an RTF document
+// Outlook stores HTML mail as RTF with the original markup encapsulated (\fromhtml1, MS-OXRTFEX);
+// that markup is returned as html. Any other document is returned as plain text.
+func RTFBody(rtf []byte) (html, text string) {
+	fromHTML := strings.Contains(string(rtf[:min(len(rtf), 1024)]), `\fromhtml`)
+
+	w := &rtfWriter{codepage: FallbackCharset}
+	state := rtfGroup{uc: 1}
+	var stack []rtfGroup
+	groupStart := false
+	skipChars := 0
+
+	// visible reports whether text at the current position belongs to the body
+	visible := func() bool {
+		if state.skip {
+			return false
+		}
+		if fromHTML {
+			return state.htmltag || !state.htmlrtf
+		}
+		return true
+	}
+
+	for i := 0; i < len(rtf); i++ {
+		c := rtf[i]
+		switch c {
+		case '{':
+			stack = append(stack, state)
+			groupStart = true
+			continue
+		case '}':
+			if len(stack) > 0 {
+				state = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+			groupStart = false
+			continue
+		case '\r', '\n':
+			continue
+		case '\\':
+		default:
+			groupStart = false
+			if skipChars > 0 {
+				skipChars--
+			} else if visible() {
+				w.writeByte(c)
+			}
+			continue
+		}
+
+		// Control symbol or control word
+		if i+1 >= len(rtf) {
+			break
+		}
+		next := rtf[i+1]
+		if !isASCIILetter(next) {
+			i++
+			switch next {
+			case '*':
+				// Ignorable destination; htmltag is the only one carrying body content
+				if groupStart {
+					state.skip = true
+				}
+				continue
+			case '\'':
+				if i+2 < len(rtf) {
+					if v, err := strconv.ParseUint(string(rtf[i+1:i+3]), 16, 8); err == nil {
+						if skipChars > 0 {
+							skipChars--
+						} else if visible() {
+							w.writeByte(byte(v))
+						}
+					}
+					i += 2
+				}
+			case '~':
+				if visible() {
+					w.writeString("\u00a0")
+				}
+			case '_':
+				if visible() {
+					w.writeString("-")
+				}
+			case '\\', '{', '}':
+				if skipChars > 0 {
+					skipChars--
+				} else if visible() {
+					w.writeByte(next)
+				}
+			case '\r', '\n':
+				if visible() {
+					w.writeString("\n")
+				}
+			}
+			groupStart = false
+			continue
+		}
+
+		// Control word: letters, an optional signed number and one optional space delimiter
+		j := i + 1
+		for j < len(rtf) && isASCIILetter(rtf[j]) {
+			j++
+		}
+		word := string(rtf[i+1 : j])
+		k := j
+		if k < len(rtf) && rtf[k] == '-' {
+			k++
+		}
+		for k < len(rtf) && rtf[k] >= '0' && rtf[k] <= '9' {
+			k++
+		}
+		param, hasParam := 0, k > j
+		if hasParam {
+			param, _ = strconv.Atoi(string(rtf[j:k]))
+		}
+		if k < len(rtf) && rtf[k] == ' ' {
+			k++
+		}
+		i = k - 1
+
+		first := groupStart
+		groupStart = false
+
+		switch {
+		case word == "htmltag" && len(stack) > 0 && !stack[len(stack)-1].skip:
+			// {\*\htmltag ...}: the \* marked the group as skipped, but it holds the original markup
+			state.skip, state.htmltag = false, true
+		case word == "htmlrtf":
+			state.htmlrtf = !hasParam || param != 0
+		case word == "ansicpg" && hasParam:
+			w.flush()
+			w.codepage = CodepageCharset(param)
+		case word == "uc" && hasParam:
+			state.uc = param
+		case word == "u" && hasParam:
+			if param < 0 {
+				param += 65536
+			}
+			if visible() && skipChars == 0 {
+				w.writeString(string(rune(param)))
+			}
+			skipChars = state.uc
+		case first && rtfSkippedDestinations[word]:
+			state.skip = true
+		case visible() && skipChars == 0:
+			if symbol, ok := rtfSymbols[word]; ok {
+				if fromHTML && !state.htmltag && (word == "par" || word == "line") {
+					symbol = "\r\n"
+				}
+				w.writeString(symbol)
+			}
+		}
+	}
+	w.flush()
+
+	if fromHTML {
+		return w.out.String(), ""
+	}
+	return "", strings.TrimSpace(w.out.String())
+}
+
+// isASCIILetter reports whether c starts or continues an RTF control word
+func isASCIILetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// CodepageCharset maps a Windows code page number to a charset label for DecodeCharset
+func CodepageCharset(codepage int) string {
+	switch {
+	case codepage == 65001:
+		return "utf-8"
+	case codepage == 1200:
+		return "utf-16le"
+	case codepage == 874, codepage >= 1250 && codepage <= 1258:
+		return "windows-" + strconv.Itoa(codepage)
+	case codepage <= 0:
+		return FallbackCharset
+	}
+	return "cp" + strconv.Itoa(codepage)
+}
@@ -0,0 +1,138 @@
+package parser
+
+import (
+	"io"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+)
+
+// StreamPart is a leaf MIME part of a message read from a stream
+type StreamPart struct {
+	Header    textproto.MIMEHeader
+	MediaType string
+	Params    map[string]string
+	Nested    bool      // Part of a multipart body rather than the whole message body
+	Body      io.Reader // Transfer-decoded content, valid until the visit function returns
+}
+
+// Filename returns the decoded filename of the part, or "" if it has none
+func (p *StreamPart) Filename() string {
+	return PartFilename(p.Header.Get(HeaderDisposition), p.Header.Get(HeaderContentType))
+}
+
+// StreamParts reads a message from r and calls visit with each leaf part, depth-first
+// Content is decoded while it is read, so memory use does not grow with the size of the message
+// or its parts. Whatever visit leaves unread is skipped. An error from visit stops the walk and is returned.
+func StreamParts(r io.Reader, visit func(part *StreamPart) error) error {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return err
+	}
+
+	header := textproto.MIMEHeader(msg.Header)
+	topType, topParams, _ := ParseMediaType(header.Get(HeaderContentType))
+	nested := strings.HasPrefix(topType, "multipart/") && topParams["boundary"] != ""
+
+	var visitErr error
+	walkParts(header, msg.Body, func(header textproto.MIMEHeader, mediaType string, body io.Reader) bool {
+		_, params, _ := ParseMediaType(header.Get(HeaderContentType))
+		visitErr = visit(&StreamPart{
+			Header:    header,
+			MediaType: mediaType,
+			Params:    params,
+			Nested:    nested,
+			Body:      NewDecodingReader(body, header.Get(HeaderEncoding)),
+		})
+		return visitErr == nil
+	})
+	return visitErr
+}
+
+// NewDecodingReader returns a reader that undoes a Content-Transfer-Encoding as r is read
+// Base64 is decoded as leniently as DecodeBase64; unknown encodings are passed through.
+func NewDecodingReader(r io.Reader, encoding string) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case EncodingBase64:
+		return &base64Reader{src: r}
+	case EncodingQuotedPrintable:
+		return quotedprintable.NewReader(r)
+	}
+	return r
+}
+
+// base64DecodeTable maps base64 alphabet bytes to their values; other bytes map to 0xFF
+var base64DecodeTable = func() [256]byte {
+	var table [256]byte
+	for i := range table {
+		table[i] = 0xFF
+	}
+	for i, c := range "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/" {
+		table[c] = byte(i)
+	}
+	return table
+}()
+
+// base64Reader decodes base64 incrementally, skipping line breaks and stray characters
+// Padding ends the current quantum, so concatenated encodings decode as in DecodeBase64.
+type base64Reader struct {
+	src     io.Reader
+	in      [4096]byte
+	decoded [3 * 1025]byte // A full input buffer plus a quantum carried over from the previous read
+	out     []byte
+	quantum [4]byte
+	count   int
+	err     error
+}
+
+// Read implements io.Reader
+func (r *base64Reader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+
+		r.out = r.decoded[:0]
+		n, err := r.src.Read(r.in[:])
+		for _, c := range r.in[:n] {
+			if c == '=' {
+				r.flush()
+				continue
+			}
+			v := base64DecodeTable[c]
+			if v == 0xFF {
+				continue
+			}
+			r.quantum[r.count] = v
+			r.count++
+			if r.count == 4 {
+				r.flush()
+			}
+		}
+
+		if err != nil {
+			r.flush()
+			r.err = err
+		}
+	}
+
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+// flush emits the bytes of a complete or padded quantum
+func (r *base64Reader) flush() {
+	q := r.quantum
+	if r.count >= 2 {
+		r.out = append(r.out, q[0]<<2|q[1]>>4)
+	}
+	if r.count >= 3 {
+		r.out = append(r.out, q[1]<<4|q[2]>>2)
+	}
+	if r.count == 4 {
+		r.out = append(r.out, q[2]<<6|q[3])
+	}
+	r.count = 0
+}
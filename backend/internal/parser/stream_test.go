@@ -0,0 +1,167 @@
+package parser
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"pgregory.net/rapid"
+)
+
+// wrapLines breaks encoded content into lines of the given width
+func wrapLines(encoded []byte, width int, eol string) []byte {
+	var out bytes.Buffer
+	for len(encoded) > width {
+		out.Write(encoded[:width])
+		out.WriteString(eol)
+		encoded = encoded[width:]
+	}
+	out.Write(encoded)
+	return out.Bytes()
+}
+
+// TestBase64Reader_MatchesDecodeBase64 verifies streaming and in-memory decoding agree
+func TestBase64Reader_MatchesDecodeBase64(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		data := rapid.SliceOfN(rapid.Byte(), 0, 10000).Draw(t, "data")
+		width := rapid.IntRange(1, 100).Draw(t, "width")
+		eol := rapid.SampledFrom([]string{"\r\n", "\n", " \r\n", "\t\n"}).Draw(t, "eol")
+		encoded := wrapLines(EncodeBase64(data), width, eol)
+
+		var src io.Reader = bytes.NewReader(encoded)
+		if rapid.Bool().Draw(t, "oneByte") {
+			src = iotest.OneByteReader(src)
+		}
+		streamed, err := io.ReadAll(NewDecodingReader(src, "Base64"))
+		if err != nil {
+			t.Fatalf("Streaming decode failed: %v", err)
+		}
+		if !bytes.Equal(streamed, data) {
+			t.Fatalf("Streaming decode mismatch: got %d bytes, want %d", len(streamed), len(data))
+		}
+
+		decoded, _ := DecodeBase64(encoded)
+		if !bytes.Equal(streamed, decoded) {
+			t.Fatal("Streaming decode differs from DecodeBase64")
+		}
+	})
+}
+
+// TestBase64Reader_Concatenated verifies padded encodings joined together decode like DecodeBase64
+func TestBase64Reader_Concatenated(t *testing.T) {
+	encoded := []byte("QQ==\r\nQkM=\r\nREVG\r\n")
+	streamed, _ := io.ReadAll(NewDecodingReader(bytes.NewReader(encoded), EncodingBase64))
+	decoded, _ := DecodeBase64(encoded)
+	if string(streamed) != "ABCDEF" || !bytes.Equal(streamed, decoded) {
+		t.Errorf("Streamed %q, DecodeBase64 %q", streamed, decoded)
+	}
+}
+
+// TestStreamParts verifies leaf parts are visited in order with decoded bodies
+func TestStreamParts(t *testing.T) {
+	attachment := bytes.Repeat([]byte{0x00, 0xFF, 'x'}, 5000)
+	email := "From: sender@example.com\r\n" +
+		"Content-Type: multipart/mixed; boundary=\"outer\"\r\n" +
+		"\r\n" +
+		"--outer\r\n" +
+		"Content-Type: multipart/alternative; boundary=\"inner\"\r\n" +
+		"\r\n" +
+		"--inner\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"Content-Transfer-Encoding: quoted-printable\r\n" +
+		"\r\n" +
+		"Caf=C3=A9 =\r\nau lait\r\n" +
+		"--inner\r\n" +
+		"Content-Type: text/html\r\n" +
+		"\r\n" +
+		"<p>Hi</p>\r\n" +
+		"--inner--\r\n" +
+		"--outer\r\n" +
+		"Content-Type: application/octet-stream\r\n" +
+		"Content-Disposition: attachment; filename*=UTF-8''r%C3%A9sum%C3%A9.bin\r\n" +
+		"Content-Transfer-Encoding: base64\r\n" +
+		"\r\n" +
+		string(wrapLines(EncodeBase64(attachment), 76, "\r\n")) + "\r\n" +
+		"--outer--\r\n"
+
+	type visited struct {
+		mediaType, filename, body string
+		nested                    bool
+	}
+	var parts []visited
+	err := StreamParts(iotest.OneByteReader(strings.NewReader(email)), func(part *StreamPart) error {
+		body, err := io.ReadAll(part.Body)
+		if err != nil {
+			return err
+		}
+		parts = append(parts, visited{part.MediaType, part.Filename(), string(body), part.Nested})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamParts failed: %v", err)
+	}
+
+	want := []visited{
+		{ContentTypePlain, "", "Café au lait", true},
+		{ContentTypeHTML, "", "<p>Hi</p>", true},
+		{ContentTypeOctetStream, "résumé.bin", string(attachment), true},
+	}
+	if len(parts) != len(want) {
+		t.Fatalf("Visited %d parts, want %d", len(parts), len(want))
+	}
+	for i := range want {
+		if parts[i] != want[i] {
+			t.Errorf("Part %d = %+.60v, want %+.60v", i, parts[i], want[i])
+		}
+	}
+}
+
+// TestStreamParts_StopsOnError verifies an error from visit ends the walk
+func TestStreamParts_StopsOnError(t *testing.T) {
+	email := "Content-Type: multipart/mixed; boundary=b\r\n\r\n" +
+		"--b\r\nContent-Type: text/plain\r\n\r\none\r\n" +
+		"--b\r\nContent-Type: text/plain\r\n\r\ntwo\r\n" +
+		"--b--\r\n"
+
+	visits := 0
+	err := StreamParts(strings.NewReader(email), func(part *StreamPart) error {
+		visits++
+		return io.ErrShortWrite
+	})
+	if err != io.ErrShortWrite || visits != 1 {
+		t.Errorf("err = %v after %d visits", err, visits)
+	}
+
+	// A message that is not multipart is a single part of its own
+	err = StreamParts(strings.NewReader("Content-Type: text/plain\r\n\r\nbody"), func(part *StreamPart) error {
+		if part.Nested || part.MediaType != ContentTypePlain {
+			t.Errorf("Unexpected top-level part %+v", part)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("StreamParts failed: %v", err)
+	}
+}
+
+// TestContainsFold verifies the case-insensitive search used to skip parsing of ordinary mail
+func TestContainsFold(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		data := []byte(rapid.StringMatching(`[a-zA-Z./ -]{0,40}`).Draw(t, "data"))
+		substr := rapid.StringMatching(`[a-zA-Z./-]{1,6}`).Draw(t, "substr")
+
+		want := bytes.Contains(bytes.ToLower(data), bytes.ToLower([]byte(substr)))
+		if got := containsFold(data, substr); got != want {
+			t.Fatalf("containsFold(%q, %q) = %v, want %v", data, substr, got, want)
+		}
+	})
+
+	if !containsFold([]byte("Content-Type: APPLICATION/MS-TNEF"), "ms-tnef") {
+		t.Error("Expected upper-case media type to match")
+	}
+	if containsFold([]byte("ms-tne"), "ms-tnef") {
+		t.Error("Expected a truncated match to fail")
+	}
+}
@@ -0,0 +1,148 @@
+package parser
+
+import (
+	"net/mail"
+	"strings"
+	"unicode/utf8"
+)
+
+// Thread header names
+const (
+	HeaderMessageID  = "Message-Id"
+	HeaderInReplyTo  = "In-Reply-To"
+	HeaderReferences = "References"
+)
+
+// MaxThreadReferences limits how many ancestors are kept per message (the most recent are kept)
+const MaxThreadReferences = 50
+
+// replyPrefixes are subject prefixes added by replies and forwards in common mail clients
+// (English, German, Nordic, Dutch, French, Italian and Indonesian)
+var replyPrefixes = []string{"re", "fw", "fwd", "aw", "wg", "sv", "vs", "antw", "tr", "rif", "r", "bls", "balas", "trs"}
+
+// ThreadInfo identifies a message's place in a conversation
+type ThreadInfo struct {
+	MessageID  string   `json:"message_id,omitempty"` // Message-ID without angle brackets
+	References []string `json:"references,omitempty"` // Ancestor Message-IDs, oldest first; the parent is last
+	Subject    string   `json:"subject,omitempty"`    // Normalized subject without reply and forward prefixes
+	IsReply    bool     `json:"is_reply"`             // Subject had a reply prefix or the message names a parent
+}
+
+// ExtractThreadInfo reads the threading headers of a message the way JWZ threading does:
+// References gives the ancestors, and an In-Reply-To parent missing from it is appended
+func ExtractThreadInfo(header mail.Header, subject string) ThreadInfo {
+	info := ThreadInfo{}
+
+	if ids := ParseMessageIDs(header.Get(HeaderMessageID)); len(ids) > 0 {
+		info.MessageID = ids[0]
+	}
+
+	seen := make(map[string]bool)
+	for _, id := range ParseMessageIDs(header.Get(HeaderReferences)) {
+		if id != info.MessageID && !seen[id] {
+			seen[id] = true
+			info.References = append(info.References, id)
+		}
+	}
+
+	// Only the first In-Reply-To ID is the parent; some clients list other addresses after it
+	if ids := ParseMessageIDs(header.Get(HeaderInReplyTo)); len(ids) > 0 {
+		parent := ids[0]
+		if parent != info.MessageID && !seen[parent] {
+			info.References = append(info.References, parent)
+		}
+	}
+
+	if len(info.References) > MaxThreadReferences {
+		info.References = info.References[len(info.References)-MaxThreadReferences:]
+	}
+
+	base, prefixed := BaseSubject(subject)
+	info.Subject = base
+	info.IsReply = prefixed || len(info.References) > 0
+
+	return info
+}
+
+// ParseMessageIDs returns the message IDs in a Message-ID, In-Reply-To or References value
+// Bracketed IDs are preferred; a value without brackets is treated as a single bare ID
+func ParseMessageIDs(value string) []string {
+	var ids []string
+	rest := value
+	for {
+		start := strings.IndexByte(rest, '<')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(rest[start:], '>')
+		if end < 0 {
+			break
+		}
+		if id := strings.TrimSpace(rest[start+1 : start+end]); id != "" && !strings.ContainsAny(id, " \t\r\n") {
+			ids = append(ids, id)
+		}
+		rest = rest[start+end+1:]
+	}
+
+	if len(ids) == 0 {
+		if bare := strings.TrimSpace(value); bare != "" && !strings.ContainsAny(bare, " \t\r\n<>") && strings.Contains(bare, "@") {
+			ids = append(ids, bare)
+		}
+	}
+
+	return ids
+}
+
+// BaseSubject strips reply and forward prefixes such as "Re:", "Fwd:" and "AW[2]:" from a subject
+// and normalizes case and spacing so replies compare equal to the original subject
+// The second result reports whether any prefix was removed
+func BaseSubject(subject string) (string, bool) {
+	s := collapseSpaces(strings.ToLower(strings.TrimSpace(subject)))
+	prefixed := false
+
+	for {
+		rest, ok := stripReplyPrefix(s)
+		if !ok {
+			break
+		}
+		s = rest
+		prefixed = true
+	}
+
+	// A trailing "(fwd)" is the old-style forward marker
+	for strings.HasSuffix(s, "(fwd)") {
+		s = strings.TrimSpace(strings.TrimSuffix(s, "(fwd)"))
+		prefixed = true
+	}
+
+	return s, prefixed
+}
+
+// stripReplyPrefix removes one leading prefix with an optional counter ("re[2]:", "re(3):")
+func stripReplyPrefix(s string) (string, bool) {
+	for _, prefix := range replyPrefixes {
+		if !strings.HasPrefix(s, prefix) {
+			continue
+		}
+		rest := s[len(prefix):]
+
+		if len(rest) > 0 && (rest[0] == '[' || rest[0] == '(') {
+			closing := byte(']')
+			if rest[0] == '(' {
+				closing = ')'
+			}
+			end := strings.IndexByte(rest, closing)
+			if end < 0 || strings.Trim(rest[1:end], "0123456789") != "" {
+				continue
+			}
+			rest = rest[end+1:]
+		}
+
+		rest = strings.TrimLeft(rest, " ")
+		if strings.HasPrefix(rest, ":") || strings.HasPrefix(rest, "：") {
+			_, size := utf8.DecodeRuneInString(rest)
+			return strings.TrimSpace(rest[size:]), true
+		}
+	}
+	return s, false
+}
@@ -0,0 +1,153 @@
+package parser
+
+import (
+	"net/mail"
+	"reflect"
+	"strings"
+	"testing"
+
+	"pgregory.net/rapid"
+)
+
+// TestExtractThreadInfo verifies Message-ID and ancestors are read from the threading headers
+func TestExtractThreadInfo(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  mail.Header
+		subject string
+		want    ThreadInfo
+	}{
+		{
+			name:    "new message",
+			header:  mail.Header{"Message-Id": {"<a1@example.com>"}},
+			subject: "Hello",
+			want:    ThreadInfo{MessageID: "a1@example.com", Subject: "hello"},
+		},
+		{
+			name: "reply with references",
+			header: mail.Header{
+				"Message-Id":  {"<c3@example.com>"},
+				"References":  {"<a1@example.com>\r\n <b2@example.com>"},
+				"In-Reply-To": {"<b2@example.com>"},
+			},
+			subject: "Re: Re: Hello",
+			want:    ThreadInfo{MessageID: "c3@example.com", References: []string{"a1@example.com", "b2@example.com"}, Subject: "hello", IsReply: true},
+		},
+		{
+			name: "In-Reply-To missing from references becomes the parent",
+			header: mail.Header{
+				"Message-Id":  {"<c3@example.com>"},
+				"References":  {"<a1@example.com>"},
+				"In-Reply-To": {"<b2@example.com> (Alice's message of Monday)"},
+			},
+			subject: "Hello",
+			want:    ThreadInfo{MessageID: "c3@example.com", References: []string{"a1@example.com", "b2@example.com"}, Subject: "hello", IsReply: true},
+		},
+		{
+			name: "duplicate and self references are dropped",
+			header: mail.Header{
+				"Message-Id": {"<c3@example.com>"},
+				"References": {"<a1@example.com> <a1@example.com> <c3@example.com>"},
+			},
+			subject: "",
+			want:    ThreadInfo{MessageID: "c3@example.com", References: []string{"a1@example.com"}, IsReply: true},
+		},
+		{
+			name:    "bare Message-ID",
+			header:  mail.Header{"Message-Id": {" a1@example.com "}},
+			subject: "Fwd: Report",
+			want:    ThreadInfo{MessageID: "a1@example.com", Subject: "report", IsReply: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExtractThreadInfo(tt.header, tt.subject)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractThreadInfo() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestExtractThreadInfo_ReferenceLimit verifies only the most recent ancestors are kept
+func TestExtractThreadInfo_ReferenceLimit(t *testing.T) {
+	var refs []string
+	for i := 0; i < MaxThreadReferences+10; i++ {
+		refs = append(refs, "<"+strings.Repeat("x", i+1)+"@example.com>")
+	}
+	header := mail.Header{"References": {strings.Join(refs, " ")}, "In-Reply-To": {"<parent@example.com>"}}
+
+	info := ExtractThreadInfo(header, "")
+	if len(info.References) != MaxThreadReferences {
+		t.Fatalf("Expected %d references, got %d", MaxThreadReferences, len(info.References))
+	}
+	if last := info.References[len(info.References)-1]; last != "parent@example.com" {
+		t.Errorf("Expected parent to be kept last, got %s", last)
+	}
+}
+
+// TestBaseSubject verifies reply and forward prefixes in common languages are removed
+func TestBaseSubject(t *testing.T) {
+	tests := []struct {
+		subject  string
+		want     string
+		prefixed bool
+	}{
+		{"Weekly report", "weekly report", false},
+		{"RE: Weekly  report", "weekly report", true},
+		{"Re[2]: Fwd: Weekly report", "weekly report", true},
+		{"AW: WG: Angebot", "angebot", true},
+		{"SV: Møte", "møte", true},
+		{"Balas: Undangan", "undangan", true},
+		{"Re： 会議", "会議", true},
+		{"Weekly report (fwd)", "weekly report", true},
+		{"Reply needed", "reply needed", false},
+		{"Re (draft): notes", "re (draft): notes", false},
+	}
+
+	for _, tt := range tests {
+		got, prefixed := BaseSubject(tt.subject)
+		if got != tt.want || prefixed != tt.prefixed {
+			t.Errorf("BaseSubject(%q) = (%q, %v), want (%q, %v)", tt.subject, got, prefixed, tt.want, tt.prefixed)
+		}
+	}
+}
+
+// TestBaseSubject_Properties verifies replies share the original's base subject and normalizing is idempotent
+func TestBaseSubject_Properties(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		subject := rapid.StringMatching(`[A-Za-z0-9 ():\[\]]{0,40}`).Draw(t, "subject")
+		prefixes := rapid.SliceOfN(rapid.SampledFrom([]string{"Re: ", "RE: ", "Fwd: ", "FW:", "Re[3]: ", "AW: "}), 0, 4).Draw(t, "prefixes")
+
+		base, _ := BaseSubject(subject)
+		again, _ := BaseSubject(base)
+		if again != base {
+			t.Fatalf("BaseSubject not idempotent: %q -> %q -> %q", subject, base, again)
+		}
+
+		reply, _ := BaseSubject(strings.Join(prefixes, "") + subject)
+		if reply != base {
+			t.Fatalf("Reply base %q differs from original base %q", reply, base)
+		}
+	})
+}
+
+// TestParse_Thread verifies thread info is extracted while parsing
+func TestParse_Thread(t *testing.T) {
+	raw := "From: bob@example.com\r\n" +
+		"Subject: Re: Lunch?\r\n" +
+		"Message-ID: <b2@example.com>\r\n" +
+		"In-Reply-To: <a1@example.com>\r\n" +
+		"\r\n" +
+		"Sure.\r\n"
+
+	parsed, err := NewEmailParser().Parse([]byte(raw))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	want := ThreadInfo{MessageID: "b2@example.com", References: []string{"a1@example.com"}, Subject: "lunch?", IsReply: true}
+	if !reflect.DeepEqual(parsed.Thread, want) {
+		t.Errorf("Thread = %+v, want %+v", parsed.Thread, want)
+	}
+}
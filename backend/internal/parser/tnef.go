@@ -0,0 +1,577 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// TNEF media types used by Outlook for winmail.dat
+const (
+	ContentTypeTNEF       = "application/ms-tnef"
+	ContentTypeTNEFVendor = "application/vnd.ms-tnef"
+)
+
+// TNEF decoding errors
+var (
+	ErrNotTNEF       = errors.New("not a TNEF stream")
+	ErrTruncatedTNEF = errors.New("truncated TNEF stream")
+)
+
+// tnefSignature starts every TNEF stream (MS-OXTNEF 2.1.3.1)
+const tnefSignature = 0x223E9F78
+
+// TNEF attribute levels
+const (
+	tnefLevelMessage    = 0x01
+	tnefLevelAttachment = 0x02
+)
+
+// TNEF attribute tags; the high word of an attribute ID is its type and is ignored
+const (
+	attSubject       = 0x8004
+	attDateSent      = 0x8005
+	attMessageClass  = 0x8008
+	attMessageID     = 0x8009
+	attBody          = 0x800C
+	attAttachData    = 0x800F
+	attAttachTitle   = 0x8010
+	attAttachRendata = 0x9002
+	attMsgProps      = 0x9003
+	attAttachment    = 0x9005
+	attOemCodepage   = 0x9007
+)
+
+// MAPI property types (MS-OXCDATA 2.11.1)
+const (
+	ptShort    = 0x0002
+	ptLong     = 0x0003
+	ptFloat    = 0x0004
+	ptDouble   = 0x0005
+	ptCurrency = 0x0006
+	ptAppTime  = 0x0007
+	ptError    = 0x000A
+	ptBoolean  = 0x000B
+	ptObject   = 0x000D
+	ptI8       = 0x0014
+	ptString8  = 0x001E
+	ptUnicode  = 0x001F
+	ptSysTime  = 0x0040
+	ptCLSID    = 0x0048
+	ptBinary   = 0x0102
+	ptMultiple = 0x1000
+)
+
+// MAPI property IDs read from TNEF streams
+const (
+	prMessageClass        = 0x001A
+	prSubject             = 0x0037
+	prClientSubmitTime    = 0x0039
+	prSentRepName         = 0x0042
+	prSentRepEmail        = 0x0065
+	prConversationTopic   = 0x0070
+	prImportance          = 0x0017
+	prSenderName          = 0x0C1A
+	prSenderEmail         = 0x0C1F
+	prDeliveryTime        = 0x0E06
+	prBody                = 0x1000
+	prRTFCompressed       = 0x1009
+	prBodyHTML            = 0x1013
+	prInternetMessageID   = 0x1035
+	prInReplyTo           = 0x1042
+	prDisplayName         = 0x3001
+	prAttachDataObj       = 0x3701
+	prAttachFilename      = 0x3704
+	prAttachMethod        = 0x3705
+	prAttachLongFilename  = 0x3707
+	prAttachMIMETag       = 0x370E
+	prInternetCodepage    = 0x3FDE
+	tnefNamedPropertyBase = 0x8000 // IDs from here on are named properties
+)
+
+// attachMethodEmbedded is the PR_ATTACH_METHOD of an attached Outlook message
+const attachMethodEmbedded = 5
+
+// tnefMaxEmbeddedDepth limits how deeply attached messages are unpacked
+const tnefMaxEmbeddedDepth = 4
+
+// tnefPropertyNames lists the message properties exposed in TNEFMessage.Properties
+var tnefPropertyNames = map[uint16]string{
+	prMessageClass:      "message_class",
+	prSubject:           "subject",
+	prClientSubmitTime:  "sent_at",
+	prSentRepName:       "sent_representing_name",
+	prSentRepEmail:      "sent_representing_email",
+	prConversationTopic: "conversation_topic",
+	prImportance:        "importance",
+	prSenderName:        "sender_name",
+	prSenderEmail:       "sender_email",
+	prDeliveryTime:      "delivered_at",
+	prInternetMessageID: "message_id",
+	prInReplyTo:         "in_reply_to",
+}
+
+// TNEFMessage is the content of a decoded winmail.dat
+type TNEFMessage struct {
+	BodyText    string            // Plain text body (PR_BODY)
+	BodyHTML    string            // HTML body, from PR_BODY_HTML or de-encapsulated from RTF
+	BodyRTF     []byte            // Decompressed RTF body (PR_RTF_COMPRESSED)
+	Properties  map[string]string // Readable message properties such as subject and sender
+	Attachments []*Attachment     // Embedded files
+}
+
+// tnefProperty is one decoded MAPI property value
+type tnefProperty struct {
+	id     uint16
+	typ    uint16
+	values [][]byte
+}
+
+// tnefAttachment accumulates the attributes of one embedded file
+type tnefAttachment struct {
+	title    string
+	data     []byte
+	props    []tnefProperty
+	codepage string
+}
+
+// tnefReader reads little-endian values, recording the first out-of-bounds read
+type tnefReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *tnefReader) next(n int) []byte {
+	if r.err != nil || n < 0 || n > len(r.data)-r.pos {
+		r.err = ErrTruncatedTNEF
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *tnefReader) uint8() byte {
+	if b := r.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *tnefReader) uint16() uint16 {
+	if b := r.next(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *tnefReader) uint32() uint32 {
+	if b := r.next(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *tnefReader) remaining() int {
+	return len(r.data) - r.pos
+}
+
+// IsTNEF reports whether a MIME part is a TNEF stream, by media type or the winmail.dat name
+func IsTNEF(mediaType, filename string) bool {
+	switch strings.ToLower(mediaType) {
+	case ContentTypeTNEF, ContentTypeTNEFVendor:
+		return true
+	}
+	return strings.EqualFold(filepath.Base(filename), "winmail.dat")
+}
+
+// DecodeTNEF decodes an Outlook TNEF stream (winmail.dat)
+// Decoding is lenient: a truncated stream returns everything read before the damage with
+// ErrTruncatedTNEF, so callers can still use the embedded files that were recovered.
+func DecodeTNEF(data []byte) (*TNEFMessage, error) {
+	return decodeTNEF(data, 0)
+}
+
+func decodeTNEF(data []byte, depth int) (*TNEFMessage, error) {
+	r := &tnefReader{data: data}
+	if r.uint32() != tnefSignature {
+		return nil, ErrNotTNEF
+	}
+	r.uint16() // Legacy key
+
+	msg := &TNEFMessage{Properties: make(map[string]string)}
+	codepage := FallbackCharset
+	var messageProps []tnefProperty
+	var attachments []*tnefAttachment
+	var current *tnefAttachment
+
+	for r.remaining() > 0 && r.err == nil {
+		level := r.uint8()
+		id := r.uint32()
+		length := r.uint32()
+		value := r.next(int(length))
+		r.uint16() // Checksum; Outlook does not verify it either
+		if r.err != nil {
+			break
+		}
+
+		switch tag := id & 0xFFFF; {
+		case tag == attOemCodepage && len(value) >= 4:
+			codepage = CodepageCharset(int(binary.LittleEndian.Uint32(value)))
+		case tag == attAttachRendata:
+			// Each embedded file starts with its rendering attribute
+			current = &tnefAttachment{codepage: codepage}
+			attachments = append(attachments, current)
+		case level == tnefLevelAttachment && current != nil:
+			switch tag {
+			case attAttachTitle:
+				current.title = decodeTNEFString(value, codepage)
+			case attAttachData:
+				current.data = value
+			case attAttachment:
+				props, _ := decodeMAPIProperties(value)
+				current.props = append(current.props, props...)
+			}
+		case level == tnefLevelMessage:
+			switch tag {
+			case attSubject:
+				msg.Properties["subject"] = decodeTNEFString(value, codepage)
+			case attMessageClass:
+				msg.Properties["message_class"] = decodeTNEFString(value, codepage)
+			case attMessageID:
+				msg.Properties["message_id"] = decodeTNEFString(value, codepage)
+			case attDateSent:
+				if t, ok := decodeTNEFDate(value); ok {
+					msg.Properties["sent_at"] = t.Format(time.RFC3339)
+				}
+			case attBody:
+				msg.BodyText = decodeTNEFString(value, codepage)
+			case attMsgProps:
+				props, _ := decodeMAPIProperties(value)
+				messageProps = append(messageProps, props...)
+			}
+		}
+	}
+
+	msg.applyProperties(messageProps, codepage)
+
+	for _, att := range attachments {
+		msg.Attachments = append(msg.Attachments, att.files(depth)...)
+	}
+
+	if r.err != nil {
+		return msg, r.err
+	}
+	return msg, nil
+}
+
+// applyProperties fills the body and readable properties from the message MAPI properties
+func (m *TNEFMessage) applyProperties(props []tnefProperty, codepage string) {
+	// PR_INTERNET_CPID is the charset of the HTML body
+	htmlCodepage := codepage
+	for _, p := range props {
+		if p.id == prInternetCodepage && p.typ == ptLong && len(p.values) > 0 && len(p.values[0]) >= 4 {
+			htmlCodepage = CodepageCharset(int(binary.LittleEndian.Uint32(p.values[0])))
+		}
+	}
+
+	for _, p := range props {
+		if len(p.values) == 0 {
+			continue
+		}
+		switch p.id {
+		case prBody:
+			if text := p.text(codepage); text != "" {
+				m.BodyText = text
+			}
+		case prBodyHTML:
+			if p.typ == ptBinary {
+				decoded, _ := DecodeCharset(p.values[0], htmlCodepage)
+				m.BodyHTML = string(decoded)
+			} else {
+				m.BodyHTML = p.text(codepage)
+			}
+		case prRTFCompressed:
+			if rtf, err := DecompressRTF(p.values[0]); err == nil {
+				m.BodyRTF = rtf
+			}
+		default:
+			if name, ok := tnefPropertyNames[p.id]; ok {
+				if value := p.text(codepage); value != "" {
+					m.Properties[name] = value
+				}
+			}
+		}
+	}
+
+	// Outlook often sends HTML mail only as RTF with the markup encapsulated
+	if len(m.BodyRTF) > 0 {
+		html, text := RTFBody(m.BodyRTF)
+		if m.BodyHTML == "" {
+			m.BodyHTML = html
+		}
+		if m.BodyText == "" {
+			m.BodyText = text
+		}
+	}
+}
+
+// files returns the embedded files of an attachment; embedded messages contribute their own files
+func (a *tnefAttachment) files(depth int) []*Attachment {
+	var longName, shortName, displayName, mimeType string
+	data := a.data
+	method := 0
+
+	for _, p := range a.props {
+		if len(p.values) == 0 {
+			continue
+		}
+		switch p.id {
+		case prAttachLongFilename:
+			longName = p.text(a.codepage)
+		case prAttachFilename:
+			shortName = p.text(a.codepage)
+		case prDisplayName:
+			displayName = p.text(a.codepage)
+		case prAttachMIMETag:
+			mimeType = strings.ToLower(p.text(a.codepage))
+		case prAttachMethod:
+			if len(p.values[0]) >= 4 {
+				method = int(binary.LittleEndian.Uint32(p.values[0]))
+			}
+		case prAttachDataObj:
+			if p.typ == ptObject && len(p.values[0]) >= 16 {
+				// Object values start with the interface identifier
+				data = p.values[0][16:]
+			} else if data == nil {
+				data = p.values[0]
+			}
+		}
+	}
+
+	if method == attachMethodEmbedded && depth < tnefMaxEmbeddedDepth {
+		if nested, err := decodeTNEF(data, depth+1); nested != nil && (err == nil || errors.Is(err, ErrTruncatedTNEF)) {
+			return nested.Attachments
+		}
+	}
+
+	filename := firstNonEmpty(longName, shortName, a.title, displayName)
+	if filename == "" {
+		filename = "attachment"
+	}
+	if mimeType == "" {
+		mimeType = ContentTypeOctetStream
+	}
+
+	return []*Attachment{{
+		Filename:    filename,
+		ContentType: mimeType,
+		Data:        data,
+		SizeBytes:   int64(len(data)),
+	}}
+}
+
+// decodeMAPIProperties decodes an attMsgProps or attAttachment value (MS-OXTNEF 2.1.3.5)
+func decodeMAPIProperties(data []byte) ([]tnefProperty, error) {
+	r := &tnefReader{data: data}
+	count := r.uint32()
+
+	var props []tnefProperty
+	for i := uint32(0); i < count && r.err == nil; i++ {
+		typ := r.uint16()
+		id := r.uint16()
+
+		// Named properties carry a GUID and a numeric ID or a name
+		if id >= tnefNamedPropertyBase {
+			r.next(16)
+			if kind := r.uint32(); kind == 0 {
+				r.uint32()
+			} else {
+				r.next(padTo4(int(r.uint32())))
+			}
+		}
+
+		prop := tnefProperty{id: id, typ: typ &^ ptMultiple}
+		switch base := typ &^ ptMultiple; {
+		case base == ptString8, base == ptUnicode, base == ptBinary, base == ptObject:
+			// Variable-length values are counted even when single-valued
+			n := r.uint32()
+			for j := uint32(0); j < n && r.err == nil; j++ {
+				size := int(r.uint32())
+				value := r.next(padTo4(size))
+				if value != nil {
+					prop.values = append(prop.values, value[:size])
+				}
+			}
+		default:
+			size := mapiFixedSize(base)
+			if size == 0 {
+				return props, ErrTruncatedTNEF
+			}
+			n := uint32(1)
+			if typ&ptMultiple != 0 {
+				n = r.uint32()
+			}
+			for j := uint32(0); j < n && r.err == nil; j++ {
+				prop.values = append(prop.values, r.next(padTo4(size)))
+			}
+		}
+
+		if r.err == nil {
+			props = append(props, prop)
+		}
+	}
+
+	return props, r.err
+}
+
+// mapiFixedSize returns the size of a fixed-length MAPI type, or 0 for unsupported types
+func mapiFixedSize(typ uint16) int {
+	switch typ {
+	case ptShort, ptBoolean:
+		return 2
+	case ptLong, ptFloat, ptError:
+		return 4
+	case ptDouble, ptCurrency, ptAppTime, ptI8, ptSysTime:
+		return 8
+	case ptCLSID:
+		return 16
+	}
+	return 0
+}
+
+// text renders the first value of a property as a string
+func (p tnefProperty) text(codepage string) string {
+	value := p.values[0]
+	switch p.typ {
+	case ptUnicode:
+		return decodeUTF16(value)
+	case ptString8:
+		return decodeTNEFString(value, codepage)
+	case ptLong:
+		if len(value) >= 4 {
+			return strconv.FormatUint(uint64(binary.LittleEndian.Uint32(value)), 10)
+		}
+	case ptSysTime:
+		if len(value) >= 8 {
+			if t := fileTime(binary.LittleEndian.Uint64(value)); !t.IsZero() {
+				return t.Format(time.RFC3339)
+			}
+		}
+	}
+	return ""
+}
+
+// decodeTNEFString decodes a NUL-terminated 8-bit string in the stream code page
+func decodeTNEFString(value []byte, codepage string) string {
+	if i := strings.IndexByte(string(value), 0); i >= 0 {
+		value = value[:i]
+	}
+	decoded, _ := DecodeCharset(value, codepage)
+	return strings.TrimSpace(string(decoded))
+}
+
+// decodeUTF16 decodes a NUL-terminated UTF-16LE string
+func decodeUTF16(value []byte) string {
+	units := make([]uint16, 0, len(value)/2)
+	for i := 0; i+1 < len(value); i += 2 {
+		u := binary.LittleEndian.Uint16(value[i:])
+		if u == 0 {
+			break
+		}
+		units = append(units, u)
+	}
+	return string(utf16.Decode(units))
+}
+
+// decodeTNEFDate decodes an atpDate attribute: year, month, day, hour, minute, second, weekday
+func decodeTNEFDate(value []byte) (time.Time, bool) {
+	if len(value) < 12 {
+		return time.Time{}, false
+	}
+	field := func(i int) int { return int(binary.LittleEndian.Uint16(value[2*i:])) }
+	return time.Date(field(0), time.Month(field(1)), field(2), field(3), field(4), field(5), 0, time.UTC), true
+}
+
+// fileTime converts a Windows FILETIME (100ns intervals since 1601) to UTC
+func fileTime(ft uint64) time.Time {
+	const unixEpochOffset = 116444736000000000
+	if ft < unixEpochOffset {
+		return time.Time{}
+	}
+	ns := (ft - unixEpochOffset) * 100
+	return time.Unix(0, int64(ns)).UTC()
+}
+
+// padTo4 rounds a MAPI value size up to the 4-byte boundary used in TNEF
+func padTo4(n int) int {
+	return (n + 3) &^ 3
+}
+
+// firstNonEmpty returns the first non-empty string
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// extractTNEF finds and decodes the first TNEF part of a raw message, searching nested multiparts
+// Returns nil when the message has no readable TNEF part
+func extractTNEF(raw []byte) *TNEFMessage {
+	// Cheap check so ordinary mail is not read twice, without a lower-cased copy of the message
+	if !containsFold(raw, "ms-tnef") && !containsFold(raw, "winmail.dat") {
+		return nil
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil
+	}
+	return findTNEF(textproto.MIMEHeader(msg.Header), msg.Body, 0)
+}
+
+// findTNEF walks a MIME entity looking for a TNEF part
+func findTNEF(header textproto.MIMEHeader, body io.Reader, depth int) *TNEFMessage {
+	mediaType, params, _ := ParseMediaType(header.Get(HeaderContentType))
+
+	if strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != "" && depth < tnefMaxEmbeddedDepth {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err != nil {
+				return nil
+			}
+			if tnef := findTNEF(part.Header, part, depth+1); tnef != nil {
+				return tnef
+			}
+		}
+	}
+
+	if !IsTNEF(mediaType, PartFilename(header.Get(HeaderDisposition), header.Get(HeaderContentType))) {
+		return nil
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil
+	}
+	if decoded, err := DecodeContent(data, header.Get(HeaderEncoding)); err == nil {
+		data = decoded
+	}
+
+	tnef, _ := DecodeTNEF(data)
+	return tnef
+}
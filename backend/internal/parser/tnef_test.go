@@ -0,0 +1,305 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"pgregory.net/rapid"
+)
+
+// tnefBuilder writes TNEF streams the way Outlook lays them out
+type tnefBuilder struct {
+	buf bytes.Buffer
+}
+
+func newTNEFBuilder() *tnefBuilder {
+	b := &tnefBuilder{}
+	binary.Write(&b.buf, binary.LittleEndian, uint32(tnefSignature))
+	binary.Write(&b.buf, binary.LittleEndian, uint16(0x0001))
+	return b
+}
+
+// attr appends an attribute with its checksum
+func (b *tnefBuilder) attr(level byte, id uint32, value []byte) *tnefBuilder {
+	b.buf.WriteByte(level)
+	binary.Write(&b.buf, binary.LittleEndian, id)
+	binary.Write(&b.buf, binary.LittleEndian, uint32(len(value)))
+	b.buf.Write(value)
+	var sum uint16
+	for _, c := range value {
+		sum += uint16(c)
+	}
+	binary.Write(&b.buf, binary.LittleEndian, sum)
+	return b
+}
+
+func (b *tnefBuilder) bytes() []byte {
+	return b.buf.Bytes()
+}
+
+// mapiProp is a single-valued MAPI property for the builder
+type mapiProp struct {
+	typ   uint16
+	id    uint16
+	value []byte
+}
+
+// encodeMAPIProps renders an attMsgProps or attAttachment value
+func encodeMAPIProps(props ...mapiProp) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, uint32(len(props)))
+	for _, p := range props {
+		binary.Write(&buf, binary.LittleEndian, p.typ)
+		binary.Write(&buf, binary.LittleEndian, p.id)
+		switch p.typ {
+		case ptString8, ptUnicode, ptBinary, ptObject:
+			binary.Write(&buf, binary.LittleEndian, uint32(1))
+			binary.Write(&buf, binary.LittleEndian, uint32(len(p.value)))
+		}
+		buf.Write(p.value)
+		buf.Write(make([]byte, padTo4(len(p.value))-len(p.value)))
+	}
+	return buf.Bytes()
+}
+
+func unicodeValue(s string) []byte {
+	var buf bytes.Buffer
+	for _, u := range utf16.Encode([]rune(s + "\x00")) {
+		binary.Write(&buf, binary.LittleEndian, u)
+	}
+	return buf.Bytes()
+}
+
+func uint32Value(v uint32) []byte {
+	return binary.LittleEndian.AppendUint32(nil, v)
+}
+
+// compressRTF writes an LZFu stream made only of literals, which every reader must accept
+func compressRTF(rtf []byte) []byte {
+	var payload bytes.Buffer
+	for i := 0; ; i += 8 {
+		chunk := rtf[min(i, len(rtf)):min(i+8, len(rtf))]
+		if len(chunk) == 8 {
+			payload.WriteByte(0)
+			payload.Write(chunk)
+			continue
+		}
+		// The last run ends with a reference to the write position
+		write := (len(rtfPrebuffer) + len(rtf)) % 4096
+		payload.WriteByte(1 << len(chunk))
+		payload.Write(chunk)
+		payload.WriteByte(byte(write >> 4))
+		payload.WriteByte(byte(write << 4))
+		break
+	}
+
+	header := binary.LittleEndian.AppendUint32(nil, uint32(payload.Len()+12))
+	header = binary.LittleEndian.AppendUint32(header, uint32(len(rtf)))
+	header = binary.LittleEndian.AppendUint32(header, rtfCompressed)
+	header = binary.LittleEndian.AppendUint32(header, 0)
+	return append(header, payload.Bytes()...)
+}
+
+// outlookHTMLRTF is HTML mail as Outlook encapsulates it in RTF
+const outlookHTMLRTF = `{\rtf1\ansi\ansicpg1252\fromhtml1 \deff0{\fonttbl{\f0\fswiss Arial;}}` +
+	`{\*\htmltag19 <html>}{\*\htmltag34 <body>}{\*\htmltag64 <p>}\htmlrtf {\htmlrtf0 Caf\'e9 \u8364?5 \{ok\}` +
+	`\htmlrtf }\htmlrtf0 {\*\htmltag72 </p>}\htmlrtf \par\htmlrtf0 {\*\htmltag58 </body>}{\*\htmltag27 </html>}}`
+
+// sampleTNEF builds a winmail.dat with a message, an RTF body and two embedded files
+func sampleTNEF() []byte {
+	sent := uint64(time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC).UnixNano()/100) + 116444736000000000
+
+	return newTNEFBuilder().
+		attr(tnefLevelMessage, 0x00089006, uint32Value(0x00010000)).
+		attr(tnefLevelMessage, 0x00069007, append(uint32Value(1252), uint32Value(0)...)).
+		attr(tnefLevelMessage, 0x00078008, []byte("IPM.Microsoft Mail.Note\x00")).
+		attr(tnefLevelMessage, 0x00018004, []byte("Rapport trimestriel \xe9t\xe9\x00")).
+		attr(tnefLevelMessage, 0x00069003, encodeMAPIProps(
+			mapiProp{ptUnicode, prSenderName, unicodeValue("Zoë Martin")},
+			mapiProp{ptString8, prSenderEmail, []byte("zoe@example.fr\x00")},
+			mapiProp{ptSysTime, prClientSubmitTime, binary.LittleEndian.AppendUint64(nil, sent)},
+			mapiProp{ptLong, prImportance, uint32Value(2)},
+			mapiProp{ptBinary, prRTFCompressed, compressRTF([]byte(outlookHTMLRTF))},
+		)).
+		attr(tnefLevelAttachment, 0x00069002, make([]byte, 14)).
+		attr(tnefLevelAttachment, 0x00018010, []byte("RAPPOR~1.PDF\x00")).
+		attr(tnefLevelAttachment, 0x0006800F, []byte("%PDF-1.4 report")).
+		attr(tnefLevelAttachment, 0x00069005, encodeMAPIProps(
+			mapiProp{ptUnicode, prAttachLongFilename, unicodeValue("Rapport trimestriel.pdf")},
+			mapiProp{ptString8, prAttachMIMETag, []byte("application/pdf\x00")},
+			mapiProp{ptLong, prAttachMethod, uint32Value(1)},
+		)).
+		attr(tnefLevelAttachment, 0x00069002, make([]byte, 14)).
+		attr(tnefLevelAttachment, 0x00018010, []byte("caf\xe9.txt\x00")).
+		attr(tnefLevelAttachment, 0x0006800F, []byte("menu du jour")).
+		bytes()
+}
+
+// TestDecompressRTF verifies the MS-OXRTFCP example and the literal-only streams used in tests
+func TestDecompressRTF(t *testing.T) {
+	if len(rtfPrebuffer) != 207 {
+		t.Fatalf("Prebuffer must be 207 bytes, got %d", len(rtfPrebuffer))
+	}
+
+	// Example from MS-OXRTFCP 3.1.1
+	example := []byte{
+		0x2d, 0x00, 0x00, 0x00, 0x2b, 0x00, 0x00, 0x00, 0x4c, 0x5a, 0x46, 0x75, 0xf1, 0xc5, 0xc7, 0xa7,
+		0x03, 0x00, 0x0a, 0x00, 0x72, 0x63, 0x70, 0x67, 0x31, 0x32, 0x35, 0x42, 0x32, 0x0a, 0xf3, 0x20,
+		0x68, 0x65, 0x6c, 0x09, 0x00, 0x20, 0x62, 0x77, 0x05, 0xb0, 0x6c, 0x64, 0x7d, 0x0a, 0x80, 0x0f,
+		0xa0,
+	}
+	got, err := DecompressRTF(example)
+	if err != nil || string(got) != "{\\rtf1\\ansi\\ansicpg1252\\pard hello world}\r\n" {
+		t.Fatalf("DecompressRTF() = %q, %v", got, err)
+	}
+
+	rapid.Check(t, func(t *rapid.T) {
+		rtf := rapid.SliceOfN(rapid.Byte(), 0, 300).Draw(t, "rtf")
+		got, err := DecompressRTF(compressRTF(rtf))
+		if err != nil || !bytes.Equal(got, rtf) {
+			t.Fatalf("Round trip = %q, %v, want %q", got, err, rtf)
+		}
+	})
+
+	if _, err := DecompressRTF([]byte("short")); !errors.Is(err, ErrInvalidCompressedRTF) {
+		t.Errorf("Expected ErrInvalidCompressedRTF, got %v", err)
+	}
+}
+
+// TestRTFBody verifies encapsulated HTML and plain RTF bodies
+func TestRTFBody(t *testing.T) {
+	html, text := RTFBody([]byte(outlookHTMLRTF))
+	if html != "<html><body><p>Café €5 {ok}</p></body></html>" || text != "" {
+		t.Errorf("RTFBody(fromhtml) = %q, %q", html, text)
+	}
+
+	plain := `{\rtf1\ansi\ansicpg1251{\fonttbl{\f0 Arial;}}{\*\generator Riched20;}\f0 \'cf\'f0\'e8\'e2\'e5\'f2,\par world \u8364? \endash\~ok}`
+	html, text = RTFBody([]byte(plain))
+	if html != "" || text != "Привет,\nworld € –\u00a0ok" {
+		t.Errorf("RTFBody(plain) = %q, %q", html, text)
+	}
+}
+
+// TestDecodeTNEF verifies the body, properties and embedded files of a winmail.dat
+func TestDecodeTNEF(t *testing.T) {
+	tnef, err := DecodeTNEF(sampleTNEF())
+	if err != nil {
+		t.Fatalf("DecodeTNEF failed: %v", err)
+	}
+
+	if tnef.BodyHTML != "<html><body><p>Café €5 {ok}</p></body></html>" {
+		t.Errorf("BodyHTML = %q", tnef.BodyHTML)
+	}
+	if !strings.Contains(string(tnef.BodyRTF), `\fromhtml1`) {
+		t.Errorf("BodyRTF not decompressed: %q", tnef.BodyRTF)
+	}
+
+	wantProps := map[string]string{
+		"subject":       "Rapport trimestriel été",
+		"message_class": "IPM.Microsoft Mail.Note",
+		"sender_name":   "Zoë Martin",
+		"sender_email":  "zoe@example.fr",
+		"sent_at":       "2024-03-01T09:30:00Z",
+		"importance":    "2",
+	}
+	for name, want := range wantProps {
+		if got := tnef.Properties[name]; got != want {
+			t.Errorf("Properties[%s] = %q, want %q", name, got, want)
+		}
+	}
+
+	if len(tnef.Attachments) != 2 {
+		t.Fatalf("Expected 2 attachments, got %d", len(tnef.Attachments))
+	}
+	pdf, txt := tnef.Attachments[0], tnef.Attachments[1]
+	if pdf.Filename != "Rapport trimestriel.pdf" || pdf.ContentType != "application/pdf" || string(pdf.Data) != "%PDF-1.4 report" || pdf.SizeBytes != 15 {
+		t.Errorf("Unexpected first attachment %+v", pdf)
+	}
+	if txt.Filename != "café.txt" || txt.ContentType != ContentTypeOctetStream || string(txt.Data) != "menu du jour" {
+		t.Errorf("Unexpected second attachment %+v", txt)
+	}
+}
+
+// TestDecodeTNEF_Damaged verifies truncated and foreign data
+func TestDecodeTNEF_Damaged(t *testing.T) {
+	if _, err := DecodeTNEF([]byte("PK\x03\x04 not tnef")); !errors.Is(err, ErrNotTNEF) {
+		t.Errorf("Expected ErrNotTNEF, got %v", err)
+	}
+
+	// Cut inside the second attachment: the first one is still recovered
+	stream := sampleTNEF()
+	truncated := stream[:len(stream)-10]
+	tnef, err := DecodeTNEF(truncated)
+	if !errors.Is(err, ErrTruncatedTNEF) || tnef == nil {
+		t.Fatalf("Expected partial result with ErrTruncatedTNEF, got %v", err)
+	}
+	if len(tnef.Attachments) == 0 || tnef.Attachments[0].Filename != "Rapport trimestriel.pdf" {
+		t.Errorf("First attachment not recovered: %+v", tnef.Attachments)
+	}
+
+	// Arbitrary corruption never panics
+	rapid.Check(t, func(t *rapid.T) {
+		data := append([]byte(nil), stream...)
+		flips := rapid.SliceOfN(rapid.IntRange(6, len(data)-1), 1, 8).Draw(t, "flips")
+		for _, i := range flips {
+			data[i] ^= rapid.Byte().Draw(t, "mask")
+		}
+		cut := rapid.IntRange(6, len(data)).Draw(t, "cut")
+		DecodeTNEF(data[:cut])
+	})
+}
+
+// TestDecodeTNEF_Fixture verifies the winmail.dat shared with the attachment handler tests
+func TestDecodeTNEF_Fixture(t *testing.T) {
+	data, err := os.ReadFile("testdata/winmail.dat")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	if !bytes.Equal(data, sampleTNEF()) {
+		t.Fatal("testdata/winmail.dat is out of date with sampleTNEF")
+	}
+}
+
+// TestParse_TNEF verifies the body and properties of Outlook mail come from winmail.dat
+func TestParse_TNEF(t *testing.T) {
+	email := "From: zoe@example.fr\r\n" +
+		"To: me@webrana.id\r\n" +
+		"Subject: Rapport\r\n" +
+		"Content-Type: multipart/mixed; boundary=\"tnef\"\r\n" +
+		"\r\n" +
+		"--tnef\r\n" +
+		"Content-Type: text/plain; charset=us-ascii\r\n" +
+		"\r\n" +
+		"\r\n" +
+		"--tnef\r\n" +
+		"Content-Type: application/ms-tnef; name=\"winmail.dat\"\r\n" +
+		"Content-Transfer-Encoding: base64\r\n" +
+		"Content-Disposition: attachment; filename=\"winmail.dat\"\r\n" +
+		"\r\n" +
+		base64.StdEncoding.EncodeToString(sampleTNEF()) + "\r\n" +
+		"--tnef--\r\n"
+
+	parsed, err := NewEmailParser().Parse([]byte(email))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if parsed.BodyHTML != "<html><body><p>Café €5 {ok}</p></body></html>" {
+		t.Errorf("BodyHTML = %q", parsed.BodyHTML)
+	}
+	if parsed.TNEFProperties["sender_name"] != "Zoë Martin" {
+		t.Errorf("TNEFProperties = %v", parsed.TNEFProperties)
+	}
+
+	// Ordinary mail is unaffected
+	plain, _ := NewEmailParser().Parse([]byte("From: a@example.com\r\nContent-Type: text/plain\r\n\r\nhello"))
+	if plain.TNEFProperties != nil || plain.BodyText != "hello" {
+		t.Errorf("Unexpected TNEF result for plain mail: %+v", plain)
+	}
+}
@@ -12,11 +12,31 @@ type ParsedEmail struct {
 	Subject     string            `json:"subject"`
 	BodyHTML    string            `json:"body_html"`
 	BodyText    string            `json:"body_text"`
-	Headers     map[string]string `json:"headers"`
+	Headers     map[string]string `json:"headers"`     // First value of each field, MIME-decoded
+	HeaderList  []Header          `json:"header_list"` // Every field in message order
 	Attachments []*Attachment     `json:"attachments"`
 	SizeBytes   int64             `json:"size_bytes"`
 	ReceivedAt  time.Time         `json:"received_at"`
 	RawEmail    []byte            `json:"-"` // Store raw email for error recovery
+
+	// Charset diagnostics
+	Charset         string   `json:"charset"`                    // Charset the body was decoded from (first text part)
+	CharsetWarnings []string `json:"charset_warnings,omitempty"` // Unknown charsets and replaced invalid sequences
+
+	// Message properties from an Outlook winmail.dat (TNEF) part, such as sender and sent time
+	TNEFProperties map[string]string `json:"tnef_properties,omitempty"`
+
+	// Events from a text/calendar part (meeting invitations and cancellations)
+	Calendar *Calendar `json:"calendar,omitempty"`
+
+	// One-time codes and verification, magic sign-in and password reset links
+	Codes *Codes `json:"codes,omitempty"`
+
+	// Message-ID, ancestors and base subject used to group the message into a conversation
+	Thread ThreadInfo `json:"thread"`
+
+	// Forwarded messages attached as message/rfc822 parts, parsed recursively
+	Embedded []*EmbeddedMessage `json:"embedded,omitempty"`
 }
 
 // Attachment represents an email attachment before processing
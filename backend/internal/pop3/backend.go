@@ -0,0 +1,58 @@
+// Package pop3 provides a POP3 (RFC 1939) server for legacy clients and automated systems
+// Feature: pop3-access
+// Requirements: STLS before USER/PASS with app passwords, a maildrop of all the user's mail
+// or of a single alias, RETR of raw_email, DELE through the email service
+package pop3
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// MaildropSeparator separates the account from an alias address in USER
+// "owner@example.com" opens all of the user's mail; "owner@example.com/shop@webrana.id" opens one alias
+const MaildropSeparator = "/"
+
+// Store errors
+var (
+	ErrMaildropNotFound = errors.New("maildrop not found")
+	ErrMessageNotFound  = errors.New("message not found")
+)
+
+// MessageInfo is the per-message metadata of a maildrop listing
+type MessageInfo struct {
+	ID   string // Email ID, also used as the UIDL unique-id
+	Size int64  // Size of raw_email in octets
+}
+
+// Store provides maildrop data for POP3 sessions
+type Store interface {
+	// FindAlias returns the ID of an alias owned by the user, matched case-insensitively
+	FindAlias(ctx context.Context, userID, address string) (string, error)
+	// ListMessages returns the maildrop ordered by arrival; an empty aliasID means all the user's mail
+	ListMessages(ctx context.Context, userID, aliasID string) ([]MessageInfo, error)
+	// GetRawMessage returns the stored raw_email of a message owned by the user
+	GetRawMessage(ctx context.Context, userID, emailID string) ([]byte, error)
+	// DeleteMessage moves a message to the trash; the trash purger deletes it and its attachments later
+	DeleteMessage(ctx context.Context, userID, emailID string) error
+}
+
+// Authenticator verifies USER/PASS credentials against app passwords and returns the user ID
+type Authenticator interface {
+	AuthenticateAppPassword(ctx context.Context, username, password, remoteIP string) (string, error)
+}
+
+// splitMaildrop splits a USER argument into the account and the optional alias address
+// The separator is searched after the account's "@" because a local part may contain "/"
+func splitMaildrop(user string) (account, alias string) {
+	at := strings.Index(user, "@")
+	if at < 0 {
+		return user, ""
+	}
+	sep := strings.Index(user[at:], MaildropSeparator)
+	if sep < 0 {
+		return user, ""
+	}
+	return user[:at+sep], user[at+sep+len(MaildropSeparator):]
+}
@@ -0,0 +1,119 @@
+package pop3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/email"
+)
+
+// EmailDeleter moves an email to the trash and publishes email_deleted
+// Implemented by email.Service so DELE behaves exactly like deleting in the web UI: the email
+// can be restored until the trash retention passes, then the trash purger removes its attachments.
+type EmailDeleter interface {
+	Delete(ctx context.Context, userID uuid.UUID, emailID string) (*email.DeleteEmailResponse, error)
+}
+
+// PgxStore implements Store using pgxpool
+type PgxStore struct {
+	pool    *pgxpool.Pool
+	deleter EmailDeleter
+}
+
+// NewPgxStore creates a new PgxStore
+func NewPgxStore(pool *pgxpool.Pool, deleter EmailDeleter) *PgxStore {
+	return &PgxStore{pool: pool, deleter: deleter}
+}
+
+// FindAlias returns the ID of an alias owned by the user, including inactive aliases
+func (s *PgxStore) FindAlias(ctx context.Context, userID, address string) (string, error) {
+	query := `
+		SELECT id
+		FROM aliases
+		WHERE user_id = $1 AND LOWER(full_address) = LOWER($2)
+	`
+
+	var aliasID string
+	err := s.pool.QueryRow(ctx, query, userID, address).Scan(&aliasID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrMaildropNotFound
+		}
+		return "", fmt.Errorf("failed to find alias: %w", err)
+	}
+
+	return aliasID, nil
+}
+
+// ListMessages returns the messages of one alias, or of all the user's aliases, oldest first
+func (s *PgxStore) ListMessages(ctx context.Context, userID, aliasID string) ([]MessageInfo, error) {
+	query := `
+		SELECT e.id, COALESCE(octet_length(e.raw_email), 0)
+		FROM emails e
+		JOIN aliases a ON a.id = e.alias_id
+		WHERE a.user_id = $1
+		  AND ($2 = '' OR e.alias_id::text = $2)
+		  AND e.deleted_at IS NULL
+		ORDER BY e.imap_uid
+	`
+
+	rows, err := s.pool.Query(ctx, query, userID, aliasID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list emails: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []MessageInfo
+	for rows.Next() {
+		var msg MessageInfo
+		if err := rows.Scan(&msg.ID, &msg.Size); err != nil {
+			return nil, fmt.Errorf("failed to scan email: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages, rows.Err()
+}
+
+// GetRawMessage returns the stored raw_email of a message owned by the user
+// Emails stored before raw_email was captured are returned empty
+func (s *PgxStore) GetRawMessage(ctx context.Context, userID, emailID string) ([]byte, error) {
+	query := `
+		SELECT COALESCE(e.raw_email, ''::bytea)
+		FROM emails e
+		JOIN aliases a ON a.id = e.alias_id
+		WHERE e.id = $1 AND a.user_id = $2
+	`
+
+	var raw []byte
+	err := s.pool.QueryRow(ctx, query, emailID, userID).Scan(&raw)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMessageNotFound
+		}
+		return nil, fmt.Errorf("failed to get raw email: %w", err)
+	}
+
+	return raw, nil
+}
+
+// DeleteMessage moves a message to the trash through the email service
+func (s *PgxStore) DeleteMessage(ctx context.Context, userID, emailID string) error {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return fmt.Errorf("invalid user ID: %w", err)
+	}
+
+	if _, err := s.deleter.Delete(ctx, uid, emailID); err != nil {
+		if errors.Is(err, email.ErrEmailNotFound) || errors.Is(err, email.ErrAccessDenied) {
+			return ErrMessageNotFound
+		}
+		return err
+	}
+
+	return nil
+}
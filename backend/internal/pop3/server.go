@@ -0,0 +1,294 @@
+package pop3
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Server defaults
+const (
+	DefaultPort                = 110
+	DefaultIdleTimeout         = 10 * time.Minute // RFC 1939 autologout timer minimum
+	DefaultMaxConnections      = 100
+	DefaultMaxConnectionsPerIP = 10
+	DefaultShutdownTimeout     = 10 * time.Second
+)
+
+// forceCloseGracePeriod bounds the wait for sessions after their connections were closed
+const forceCloseGracePeriod = 5 * time.Second
+
+// Config contains POP3 server configuration
+type Config struct {
+	Hostname            string
+	Port                int
+	IdleTimeout         time.Duration // Inactivity timeout
+	MaxConnections      int
+	MaxConnectionsPerIP int
+}
+
+// DefaultConfig returns the default POP3 configuration
+func DefaultConfig() *Config {
+	return &Config{
+		Hostname:            "localhost",
+		Port:                DefaultPort,
+		IdleTimeout:         DefaultIdleTimeout,
+		MaxConnections:      DefaultMaxConnections,
+		MaxConnectionsPerIP: DefaultMaxConnectionsPerIP,
+	}
+}
+
+// Server is a POP3 server exposing a user's mail, or one alias, as a maildrop
+type Server struct {
+	config        *Config
+	tlsConfig     *tls.Config
+	store         Store
+	authenticator Authenticator
+	listener      net.Listener
+
+	// Exclusive-access locks on maildrops, keyed by user ID and alias ID
+	locks   map[string]struct{}
+	locksMu sync.Mutex
+
+	// Connection management
+	activeConns   int64
+	ipConnections map[string]int
+	ipConnMu      sync.Mutex
+
+	// Server state
+	running      atomic.Bool
+	shuttingDown atomic.Bool
+	shutdownCh   chan struct{}
+	wg           sync.WaitGroup
+	sessions     map[*session]struct{}
+	sessionsMu   sync.Mutex
+}
+
+// NewServer creates a new POP3 server
+// tlsConfig is required: USER and PASS are only accepted after STLS
+func NewServer(config *Config, tlsConfig *tls.Config, store Store, authenticator Authenticator) *Server {
+	if config.IdleTimeout <= 0 {
+		config.IdleTimeout = DefaultIdleTimeout
+	}
+	if config.MaxConnections <= 0 {
+		config.MaxConnections = DefaultMaxConnections
+	}
+	if config.MaxConnectionsPerIP <= 0 {
+		config.MaxConnectionsPerIP = DefaultMaxConnectionsPerIP
+	}
+
+	return &Server{
+		config:        config,
+		tlsConfig:     tlsConfig,
+		store:         store,
+		authenticator: authenticator,
+		locks:         make(map[string]struct{}),
+		ipConnections: make(map[string]int),
+		shutdownCh:    make(chan struct{}),
+		sessions:      make(map[*session]struct{}),
+	}
+}
+
+// Start starts listening for POP3 connections
+func (s *Server) Start() error {
+	addr := fmt.Sprintf(":%d", s.config.Port)
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to start POP3 server on %s: %w", addr, err)
+	}
+
+	s.listener = listener
+	s.running.Store(true)
+
+	log.Printf("POP3 server started on port %d", s.config.Port)
+
+	go s.acceptLoop()
+
+	return nil
+}
+
+// Stop shuts the server down, waiting up to DefaultShutdownTimeout for sessions to end
+func (s *Server) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultShutdownTimeout)
+	defer cancel()
+
+	if err := s.Shutdown(ctx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown stops accepting connections and ends every session with -ERR
+// Sessions finish the command they are executing; idle sessions are woken immediately and
+// close without entering the UPDATE state, so messages marked with DELE are kept.
+// Connections still open when ctx is done are closed.
+func (s *Server) Shutdown(ctx context.Context) error {
+	if !s.running.Load() || !s.shuttingDown.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	s.running.Store(false)
+	close(s.shutdownCh)
+	if s.listener != nil {
+		s.listener.Close()
+	}
+
+	sessions := s.snapshotSessions()
+	log.Printf("POP3 server shutting down (%d sessions)", len(sessions))
+	for _, session := range sessions {
+		session.wake()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		log.Println("POP3 server stopped")
+		return nil
+	case <-ctx.Done():
+		for _, session := range s.snapshotSessions() {
+			session.rawConn.Close()
+		}
+		select {
+		case <-done:
+		case <-time.After(forceCloseGracePeriod):
+			log.Println("POP3 server shutdown timed out")
+		}
+		return ctx.Err()
+	}
+}
+
+// acceptLoop accepts incoming connections
+func (s *Server) acceptLoop() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if !s.running.Load() {
+				return
+			}
+			log.Printf("Error accepting POP3 connection: %v", err)
+			continue
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handleConnection(conn)
+		}()
+	}
+}
+
+// handleConnection enforces connection limits and runs a session
+func (s *Server) handleConnection(conn net.Conn) {
+	defer conn.Close()
+
+	if s.shuttingDown.Load() {
+		fmt.Fprintf(conn, "-ERR Server shutting down\r\n")
+		return
+	}
+
+	remoteAddr := conn.RemoteAddr().String()
+	remoteIP, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		remoteIP = remoteAddr
+	}
+
+	if !s.acquireConnection(remoteIP) {
+		log.Printf("POP3 connection from %s rejected: too many connections", remoteIP)
+		fmt.Fprintf(conn, "-ERR [SYS/TEMP] Too many connections\r\n")
+		return
+	}
+	defer s.releaseConnection(remoteIP)
+
+	session := newSession(s, conn, remoteIP)
+	s.trackSession(session)
+	defer s.untrackSession(session)
+	session.Run()
+}
+
+// acquireConnection reserves a global and a per-IP connection slot
+func (s *Server) acquireConnection(remoteIP string) bool {
+	s.ipConnMu.Lock()
+	defer s.ipConnMu.Unlock()
+
+	if atomic.LoadInt64(&s.activeConns) >= int64(s.config.MaxConnections) {
+		return false
+	}
+	if s.ipConnections[remoteIP] >= s.config.MaxConnectionsPerIP {
+		return false
+	}
+
+	atomic.AddInt64(&s.activeConns, 1)
+	s.ipConnections[remoteIP]++
+	return true
+}
+
+// releaseConnection frees the slots reserved by acquireConnection
+func (s *Server) releaseConnection(remoteIP string) {
+	s.ipConnMu.Lock()
+	defer s.ipConnMu.Unlock()
+
+	atomic.AddInt64(&s.activeConns, -1)
+	s.ipConnections[remoteIP]--
+	if s.ipConnections[remoteIP] <= 0 {
+		delete(s.ipConnections, remoteIP)
+	}
+}
+
+// GetActiveConnections returns the number of open POP3 connections
+func (s *Server) GetActiveConnections() int64 {
+	return atomic.LoadInt64(&s.activeConns)
+}
+
+// trackSession registers a running session so shutdown can reach it
+func (s *Server) trackSession(session *session) {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	s.sessions[session] = struct{}{}
+}
+
+// untrackSession removes a finished session
+func (s *Server) untrackSession(session *session) {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	delete(s.sessions, session)
+}
+
+// snapshotSessions returns the currently running sessions
+func (s *Server) snapshotSessions() []*session {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	sessions := make([]*session, 0, len(s.sessions))
+	for session := range s.sessions {
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
+
+// lockMaildrop takes the exclusive-access lock required by RFC 1939 for a maildrop
+func (s *Server) lockMaildrop(key string) bool {
+	s.locksMu.Lock()
+	defer s.locksMu.Unlock()
+	if _, locked := s.locks[key]; locked {
+		return false
+	}
+	s.locks[key] = struct{}{}
+	return true
+}
+
+// unlockMaildrop releases a lock taken by lockMaildrop
+func (s *Server) unlockMaildrop(key string) {
+	s.locksMu.Lock()
+	defer s.locksMu.Unlock()
+	delete(s.locks, key)
+}
@@ -0,0 +1,546 @@
+package pop3
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxAuthFailures is the number of failed PASS attempts before the session is closed
+const MaxAuthFailures = 3
+
+// MaxLineLength bounds a command line; RFC 2449 limits commands to 255 octets
+const MaxLineLength = 512
+
+// Session timeouts
+const (
+	writeTimeout     = 30 * time.Second
+	handshakeTimeout = 30 * time.Second
+	storeTimeout     = 30 * time.Second
+)
+
+// deadlineRefreshBytes is how much of a message is written before the write deadline is extended
+const deadlineRefreshBytes = 64 * 1024
+
+var errLineTooLong = errors.New("line too long")
+
+// sessionMessage is a message in the maildrop; DELE marks live only in the session until QUIT
+type sessionMessage struct {
+	MessageInfo
+	deleted bool
+}
+
+// session is a single POP3 connection
+type session struct {
+	server   *Server
+	rawConn  net.Conn
+	conn     net.Conn // rawConn, or its TLS wrapper after STLS
+	reader   *bufio.Reader
+	writer   *bufio.Writer
+	remoteIP string
+
+	tlsEnabled   bool
+	username     string // Argument of the last USER command
+	authFailures int
+	userID       string
+	lockKey      string
+	messages     []*sessionMessage
+}
+
+// newSession creates a session for an accepted connection
+func newSession(server *Server, conn net.Conn, remoteIP string) *session {
+	return &session{
+		server:   server,
+		rawConn:  conn,
+		conn:     conn,
+		reader:   bufio.NewReader(conn),
+		writer:   bufio.NewWriter(conn),
+		remoteIP: remoteIP,
+	}
+}
+
+// wake interrupts a blocking read so the session notices shutdown
+func (s *session) wake() {
+	s.rawConn.SetReadDeadline(time.Now())
+}
+
+// Run greets the client and processes commands until QUIT, error or shutdown
+// A session that ends without QUIT never enters the UPDATE state, so DELE marks are discarded
+func (s *session) Run() {
+	defer func() {
+		if s.lockKey != "" {
+			s.server.unlockMaildrop(s.lockKey)
+		}
+	}()
+
+	s.writeLine(fmt.Sprintf("+OK %s POP3 service ready", s.server.config.Hostname))
+	if !s.flush() {
+		return
+	}
+
+	for {
+		s.rawConn.SetReadDeadline(time.Now().Add(s.server.config.IdleTimeout))
+		if s.server.shuttingDown.Load() {
+			s.errClose("Server shutting down")
+			return
+		}
+
+		line, err := readLine(s.reader)
+		if err != nil {
+			s.handleReadError(err)
+			return
+		}
+
+		name, arg, _ := strings.Cut(line, " ")
+		quit := s.dispatch(strings.ToUpper(name), arg)
+		if !s.flush() || quit {
+			return
+		}
+	}
+}
+
+// readLine reads a CRLF (or LF) terminated command line without the line ending
+func readLine(r *bufio.Reader) (string, error) {
+	var line []byte
+	for {
+		chunk, isPrefix, err := r.ReadLine()
+		if err != nil {
+			return "", err
+		}
+		line = append(line, chunk...)
+		if len(line) > MaxLineLength {
+			return "", errLineTooLong
+		}
+		if !isPrefix {
+			return string(line), nil
+		}
+	}
+}
+
+// handleReadError tells the client why the session ends when it can still hear it
+func (s *session) handleReadError(err error) {
+	var netErr net.Error
+	switch {
+	case s.server.shuttingDown.Load():
+		s.errClose("Server shutting down")
+	case errors.As(err, &netErr) && netErr.Timeout():
+		s.errClose("Autologout; idle for too long")
+	case errors.Is(err, errLineTooLong):
+		s.errClose("Command too long")
+	}
+}
+
+// errClose sends a final -ERR; the connection is closed by the caller
+func (s *session) errClose(text string) {
+	s.writeLine("-ERR " + text)
+	s.flush()
+}
+
+// writeLine buffers a response line
+func (s *session) writeLine(line string) {
+	s.writer.WriteString(line)
+	s.writer.WriteString("\r\n")
+}
+
+// flush sends buffered responses, returning false when the connection is gone
+func (s *session) flush() bool {
+	s.rawConn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return s.writer.Flush() == nil
+}
+
+// ok and err send single-line responses
+func (s *session) ok(text string)  { s.writeLine("+OK " + text) }
+func (s *session) err(text string) { s.writeLine("-ERR " + text) }
+
+// storeContext returns a context for store calls
+func (s *session) storeContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), storeTimeout)
+}
+
+// capabilities returns the CAPA list for the current state
+func (s *session) capabilities() []string {
+	caps := []string{"TOP", "UIDL", "RESP-CODES", "AUTH-RESP-CODE"}
+	switch {
+	case !s.tlsEnabled:
+		caps = append(caps, "STLS")
+	case s.userID == "":
+		caps = append(caps, "USER")
+	}
+	return append(caps, "IMPLEMENTATION persistent-temp-mail")
+}
+
+// dispatch runs a command; it returns true when the session must end
+func (s *session) dispatch(name, arg string) bool {
+	switch name {
+	case "CAPA":
+		s.ok("Capability list follows")
+		for _, capability := range s.capabilities() {
+			s.writeLine(capability)
+		}
+		s.writeLine(".")
+		return false
+	case "QUIT":
+		return s.handleQUIT()
+	case "NOOP":
+		if s.userID == "" {
+			s.err("Command not valid in this state")
+		} else {
+			s.ok("")
+		}
+		return false
+	}
+
+	// AUTHORIZATION state
+	if s.userID == "" {
+		switch name {
+		case "STLS":
+			return s.handleSTLS()
+		case "USER":
+			s.handleUSER(arg)
+			return false
+		case "PASS":
+			return s.handlePASS(arg)
+		default:
+			s.err("Command not valid in this state")
+			return false
+		}
+	}
+
+	// TRANSACTION state
+	switch name {
+	case "STAT":
+		count, size := s.stat()
+		s.ok(fmt.Sprintf("%d %d", count, size))
+	case "LIST", "UIDL":
+		s.handleListing(name, arg)
+	case "RETR":
+		s.handleRETR(arg)
+	case "TOP":
+		s.handleTOP(arg)
+	case "DELE":
+		s.handleDELE(arg)
+	case "RSET":
+		for _, msg := range s.messages {
+			msg.deleted = false
+		}
+		count, size := s.stat()
+		s.ok(fmt.Sprintf("Maildrop has %d messages (%d octets)", count, size))
+	default:
+		s.err("Unknown command")
+	}
+	return false
+}
+
+// handleSTLS upgrades the connection to TLS (RFC 2595)
+func (s *session) handleSTLS() bool {
+	if s.tlsEnabled {
+		s.err("TLS already active")
+		return false
+	}
+	if s.server.tlsConfig == nil {
+		s.err("TLS not available")
+		return false
+	}
+	// Commands pipelined after STLS would be processed as if they were protected
+	if s.reader.Buffered() > 0 {
+		s.err("Unexpected data after STLS")
+		return true
+	}
+
+	s.ok("Begin TLS negotiation now")
+	if !s.flush() {
+		return true
+	}
+
+	tlsConn := tls.Server(s.rawConn, s.server.tlsConfig)
+	s.rawConn.SetDeadline(time.Now().Add(handshakeTimeout))
+	if err := tlsConn.Handshake(); err != nil {
+		log.Printf("POP3 TLS handshake with %s failed: %v", s.remoteIP, err)
+		return true
+	}
+	s.rawConn.SetDeadline(time.Time{})
+
+	s.conn = tlsConn
+	s.reader = bufio.NewReader(tlsConn)
+	s.writer = bufio.NewWriter(tlsConn)
+	s.tlsEnabled = true
+	s.username = ""
+	return false
+}
+
+// handleUSER records the account and optional alias for the following PASS
+func (s *session) handleUSER(arg string) {
+	if !s.tlsEnabled {
+		s.err("[AUTH] Run STLS first")
+		return
+	}
+	arg = strings.TrimSpace(arg)
+	if arg == "" {
+		s.err("USER expects a name")
+		return
+	}
+	s.username = arg
+	s.ok("Send your app password")
+}
+
+// handlePASS authenticates the USER with an app password and opens the maildrop
+// The whole rest of the line is the password, so it may contain spaces
+func (s *session) handlePASS(arg string) bool {
+	if !s.tlsEnabled {
+		s.err("[AUTH] Run STLS first")
+		return false
+	}
+	if s.username == "" {
+		s.err("Send USER first")
+		return false
+	}
+	username := s.username
+	s.username = ""
+	account, alias := splitMaildrop(username)
+
+	ctx, cancel := s.storeContext()
+	defer cancel()
+
+	userID, err := s.server.authenticator.AuthenticateAppPassword(ctx, account, arg, s.remoteIP)
+	if err != nil {
+		log.Printf("POP3 login failed for %q from %s: %v", account, s.remoteIP, err)
+		return s.authFailed()
+	}
+
+	aliasID := ""
+	if alias != "" {
+		aliasID, err = s.server.store.FindAlias(ctx, userID, alias)
+		if err != nil {
+			log.Printf("POP3 maildrop %q not available for user %s: %v", alias, userID, err)
+			return s.authFailed()
+		}
+	}
+
+	lockKey := userID + MaildropSeparator + aliasID
+	if !s.server.lockMaildrop(lockKey) {
+		s.err("[IN-USE] Maildrop already locked")
+		return false
+	}
+
+	infos, err := s.server.store.ListMessages(ctx, userID, aliasID)
+	if err != nil {
+		s.server.unlockMaildrop(lockKey)
+		log.Printf("POP3 failed to open maildrop for user %s: %v", userID, err)
+		s.err("[SYS/TEMP] Unable to open maildrop")
+		return false
+	}
+
+	s.userID = userID
+	s.lockKey = lockKey
+	s.messages = make([]*sessionMessage, len(infos))
+	for i := range infos {
+		s.messages[i] = &sessionMessage{MessageInfo: infos[i]}
+	}
+
+	count, size := s.stat()
+	s.ok(fmt.Sprintf("Maildrop has %d messages (%d octets)", count, size))
+	return false
+}
+
+// authFailed reports a failed PASS; repeated failures end the session
+func (s *session) authFailed() bool {
+	s.authFailures++
+	if s.authFailures >= MaxAuthFailures {
+		s.err("[AUTH] Too many authentication failures")
+		return true
+	}
+	s.err("[AUTH] Authentication failed")
+	return false
+}
+
+// stat returns the number and total size of messages not marked as deleted
+func (s *session) stat() (int, int64) {
+	var count int
+	var size int64
+	for _, msg := range s.messages {
+		if !msg.deleted {
+			count++
+			size += msg.Size
+		}
+	}
+	return count, size
+}
+
+// message resolves a message-number argument, rejecting messages marked as deleted
+func (s *session) message(arg string) (int, *sessionMessage, bool) {
+	n, err := strconv.Atoi(strings.TrimSpace(arg))
+	if err != nil || n < 1 || n > len(s.messages) {
+		s.err("No such message")
+		return 0, nil, false
+	}
+	msg := s.messages[n-1]
+	if msg.deleted {
+		s.err(fmt.Sprintf("Message %d already deleted", n))
+		return 0, nil, false
+	}
+	return n, msg, true
+}
+
+// handleListing implements LIST and UIDL, for one message or as a multi-line scan listing
+func (s *session) handleListing(name, arg string) {
+	value := func(msg *sessionMessage) string {
+		if name == "UIDL" {
+			return msg.ID
+		}
+		return strconv.FormatInt(msg.Size, 10)
+	}
+
+	if strings.TrimSpace(arg) != "" {
+		n, msg, ok := s.message(arg)
+		if ok {
+			s.ok(fmt.Sprintf("%d %s", n, value(msg)))
+		}
+		return
+	}
+
+	count, size := s.stat()
+	s.ok(fmt.Sprintf("%d messages (%d octets)", count, size))
+	for i, msg := range s.messages {
+		if !msg.deleted {
+			s.writeLine(fmt.Sprintf("%d %s", i+1, value(msg)))
+		}
+	}
+	s.writeLine(".")
+}
+
+// handleRETR sends the stored raw_email of a message
+func (s *session) handleRETR(arg string) {
+	_, msg, ok := s.message(arg)
+	if !ok {
+		return
+	}
+	raw, ok := s.loadRaw(msg)
+	if !ok {
+		return
+	}
+	s.ok(fmt.Sprintf("%d octets", msg.Size))
+	s.writeMessage(raw, -1)
+}
+
+// handleTOP sends the header and the first n body lines of a message
+func (s *session) handleTOP(arg string) {
+	msgArg, linesArg, found := strings.Cut(strings.TrimSpace(arg), " ")
+	lines, err := strconv.Atoi(strings.TrimSpace(linesArg))
+	if !found || err != nil || lines < 0 {
+		s.err("TOP expects a message number and a line count")
+		return
+	}
+	_, msg, ok := s.message(msgArg)
+	if !ok {
+		return
+	}
+	raw, ok := s.loadRaw(msg)
+	if !ok {
+		return
+	}
+	s.ok("Top of message follows")
+	s.writeMessage(raw, lines)
+}
+
+// loadRaw fetches raw_email, reporting failures to the client
+func (s *session) loadRaw(msg *sessionMessage) ([]byte, bool) {
+	ctx, cancel := s.storeContext()
+	defer cancel()
+
+	raw, err := s.server.store.GetRawMessage(ctx, s.userID, msg.ID)
+	if err != nil {
+		if errors.Is(err, ErrMessageNotFound) {
+			s.err("Message no longer exists")
+		} else {
+			log.Printf("POP3 failed to load email %s: %v", msg.ID, err)
+			s.err("[SYS/TEMP] Unable to read message")
+		}
+		return nil, false
+	}
+	return raw, true
+}
+
+// writeMessage sends a message as a dot-stuffed multi-line response with CRLF line endings
+// bodyLines limits the lines sent after the header (TOP); a negative value sends everything
+func (s *session) writeMessage(raw []byte, bodyLines int) {
+	raw = bytes.TrimSuffix(raw, []byte("\n"))
+	inBody := false
+	written := 0
+	for _, line := range bytes.Split(raw, []byte("\n")) {
+		if len(raw) == 0 {
+			break
+		}
+		if inBody && bodyLines >= 0 {
+			if bodyLines == 0 {
+				break
+			}
+			bodyLines--
+		}
+		line = bytes.TrimSuffix(line, []byte("\r"))
+		if !inBody && len(line) == 0 {
+			inBody = true
+		}
+
+		if bytes.HasPrefix(line, []byte(".")) {
+			s.writer.WriteByte('.')
+		}
+		s.writer.Write(line)
+		s.writer.WriteString("\r\n")
+
+		written += len(line) + 2
+		if written >= deadlineRefreshBytes {
+			s.rawConn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			written = 0
+		}
+	}
+	s.writeLine(".")
+}
+
+// handleDELE marks a message for deletion at QUIT
+func (s *session) handleDELE(arg string) {
+	n, msg, ok := s.message(arg)
+	if !ok {
+		return
+	}
+	msg.deleted = true
+	s.ok(fmt.Sprintf("Message %d deleted", n))
+}
+
+// handleQUIT ends the session, entering the UPDATE state when a maildrop is open
+// Marked messages are deleted through the store; messages already gone count as deleted
+func (s *session) handleQUIT() bool {
+	if s.userID == "" {
+		s.ok(fmt.Sprintf("%s POP3 server signing off", s.server.config.Hostname))
+		return true
+	}
+
+	ctx, cancel := s.storeContext()
+	defer cancel()
+
+	failed := 0
+	for _, msg := range s.messages {
+		if !msg.deleted {
+			continue
+		}
+		if err := s.server.store.DeleteMessage(ctx, s.userID, msg.ID); err != nil && !errors.Is(err, ErrMessageNotFound) {
+			log.Printf("POP3 failed to delete email %s: %v", msg.ID, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		s.err(fmt.Sprintf("[SYS/TEMP] %d deleted messages not removed", failed))
+		return true
+	}
+	count, _ := s.stat()
+	s.ok(fmt.Sprintf("%s POP3 server signing off (%d messages left)", s.server.config.Hostname, count))
+	return true
+}
@@ -0,0 +1,326 @@
+package pop3
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/smtp"
+	"pgregory.net/rapid"
+)
+
+// mockStore is an in-memory Store for user-1 with two aliases
+type mockStore struct {
+	mu       sync.Mutex
+	aliases  map[string]string // address -> alias ID
+	messages []MessageInfo
+	aliasOf  map[string]string // email ID -> alias ID
+	raw      map[string][]byte
+	deleted  []string
+}
+
+func newMockStore() *mockStore {
+	store := &mockStore{
+		aliases: map[string]string{"me@webrana.id": "alias-1", "shop@webrana.id": "alias-2"},
+		aliasOf: make(map[string]string),
+		raw:     make(map[string][]byte),
+	}
+	store.add("email-1", "alias-1", "From: Alice <alice@example.com>\r\nSubject: Hello\r\n\r\nline one\r\n.hidden dot\r\nline three\r\n")
+	store.add("email-2", "alias-2", "Subject: Your order\n\norder 1234 shipped\n")
+	store.add("email-3", "alias-1", "Subject: Third\r\n\r\nbody\r\n")
+	return store
+}
+
+func (m *mockStore) add(id, aliasID, raw string) {
+	m.messages = append(m.messages, MessageInfo{ID: id, Size: int64(len(raw))})
+	m.aliasOf[id] = aliasID
+	m.raw[id] = []byte(raw)
+}
+
+func (m *mockStore) FindAlias(ctx context.Context, userID, address string) (string, error) {
+	if aliasID, ok := m.aliases[strings.ToLower(address)]; ok {
+		return aliasID, nil
+	}
+	return "", ErrMaildropNotFound
+}
+
+func (m *mockStore) ListMessages(ctx context.Context, userID, aliasID string) ([]MessageInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []MessageInfo
+	for _, msg := range m.messages {
+		if aliasID == "" || m.aliasOf[msg.ID] == aliasID {
+			result = append(result, msg)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockStore) GetRawMessage(ctx context.Context, userID, emailID string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	raw, ok := m.raw[emailID]
+	if !ok {
+		return nil, ErrMessageNotFound
+	}
+	return raw, nil
+}
+
+func (m *mockStore) DeleteMessage(ctx context.Context, userID, emailID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.messages {
+		if m.messages[i].ID == emailID {
+			m.messages = append(m.messages[:i], m.messages[i+1:]...)
+			delete(m.raw, emailID)
+			m.deleted = append(m.deleted, emailID)
+			return nil
+		}
+	}
+	return ErrMessageNotFound
+}
+
+func (m *mockStore) deletedIDs() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.deleted...)
+}
+
+// mockAuthenticator accepts a single app password
+type mockAuthenticator struct{}
+
+func (mockAuthenticator) AuthenticateAppPassword(ctx context.Context, username, password, remoteIP string) (string, error) {
+	if strings.EqualFold(username, "owner@example.com") && password == "abcd efgh ijkl mnop" {
+		return "user-1", nil
+	}
+	return "", errors.New("invalid credentials")
+}
+
+func startTestServer(t *testing.T) (*Server, string, *mockStore) {
+	t.Helper()
+	certPath, keyPath, err := smtp.GenerateSelfSignedCert("mail.test.local", t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to generate certificate: %v", err)
+	}
+	tlsConfig, err := smtp.LoadTLSConfig(certPath, keyPath)
+	if err != nil {
+		t.Fatalf("Failed to load TLS config: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find available port: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	config := DefaultConfig()
+	config.Port = port
+	config.Hostname = "mail.test.local"
+
+	store := newMockStore()
+	server := NewServer(config, tlsConfig, store, mockAuthenticator{})
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	t.Cleanup(func() { server.Stop() })
+
+	return server, fmt.Sprintf("127.0.0.1:%d", port), store
+}
+
+// testClient is a minimal POP3 client
+type testClient struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func dialTestClient(t *testing.T, addr string) *testClient {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	t.Cleanup(func() { conn.Close() })
+	c := &testClient{t: t, conn: conn, reader: bufio.NewReader(conn)}
+	if greeting := c.readLine(); !strings.HasPrefix(greeting, "+OK") {
+		t.Fatalf("Unexpected greeting %q", greeting)
+	}
+	return c
+}
+
+func (c *testClient) readLine() string {
+	c.t.Helper()
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		c.t.Fatalf("Failed to read response: %v", err)
+	}
+	return strings.TrimRight(line, "\r\n")
+}
+
+// cmd sends a command and checks the status line prefix
+func (c *testClient) cmd(command, expect string) string {
+	c.t.Helper()
+	fmt.Fprintf(c.conn, "%s\r\n", command)
+	line := c.readLine()
+	if !strings.HasPrefix(line, expect) {
+		c.t.Fatalf("%s: expected %q, got %q", command, expect, line)
+	}
+	return line
+}
+
+// multiline sends a command expecting +OK and returns the raw lines up to the terminating "."
+func (c *testClient) multiline(command string) []string {
+	c.t.Helper()
+	c.cmd(command, "+OK")
+	var lines []string
+	for {
+		line := c.readLine()
+		if line == "." {
+			return lines
+		}
+		lines = append(lines, line)
+	}
+}
+
+// startTLS upgrades the connection
+func (c *testClient) startTLS() {
+	c.t.Helper()
+	c.cmd("STLS", "+OK")
+	tlsConn := tls.Client(c.conn, &tls.Config{InsecureSkipVerify: true, MinVersion: tls.VersionTLS12})
+	if err := tlsConn.Handshake(); err != nil {
+		c.t.Fatalf("TLS handshake failed: %v", err)
+	}
+	c.conn = tlsConn
+	c.reader = bufio.NewReader(tlsConn)
+}
+
+// TestPOP3_RequiresTLS verifies credentials are never accepted in plaintext
+func TestPOP3_RequiresTLS(t *testing.T) {
+	_, addr, _ := startTestServer(t)
+	c := dialTestClient(t, addr)
+
+	if caps := c.multiline("CAPA"); !strings.Contains(strings.Join(caps, "\n"), "STLS") || strings.Contains(strings.Join(caps, "\n"), "USER") {
+		t.Fatalf("Expected STLS and no USER before TLS: %v", caps)
+	}
+	c.cmd("USER owner@example.com", "-ERR [AUTH]")
+	c.cmd("STAT", "-ERR")
+
+	c.startTLS()
+	if caps := c.multiline("CAPA"); !strings.Contains(strings.Join(caps, "\n"), "USER") {
+		t.Fatalf("Expected USER after STLS: %v", caps)
+	}
+	c.cmd("PASS abcd efgh ijkl mnop", "-ERR")
+	for i := 1; i < MaxAuthFailures; i++ {
+		c.cmd("USER owner@example.com", "+OK")
+		c.cmd("PASS wrong", "-ERR [AUTH] Authentication failed")
+	}
+	c.cmd("USER owner@example.com", "+OK")
+	c.cmd("PASS wrong", "-ERR [AUTH] Too many")
+}
+
+// TestPOP3_Maildrop verifies listing, RETR, TOP, DELE/RSET and deletion at QUIT
+func TestPOP3_Maildrop(t *testing.T) {
+	_, addr, store := startTestServer(t)
+	c := dialTestClient(t, addr)
+	c.startTLS()
+	c.cmd("USER owner@example.com", "+OK")
+	c.cmd("PASS abcd efgh ijkl mnop", "+OK Maildrop has 3 messages")
+
+	// A second session cannot open the same maildrop
+	other := dialTestClient(t, addr)
+	other.startTLS()
+	other.cmd("USER owner@example.com", "+OK")
+	other.cmd("PASS abcd efgh ijkl mnop", "-ERR [IN-USE]")
+
+	if uidl := c.multiline("UIDL"); strings.Join(uidl, ",") != "1 email-1,2 email-2,3 email-3" {
+		t.Errorf("Unexpected UIDL listing: %v", uidl)
+	}
+	c.cmd("LIST 2", "+OK 2 40")
+
+	// RETR returns raw_email dot-stuffed with CRLF line endings
+	retr := c.multiline("RETR 1")
+	if strings.Join(retr, "\n") != "From: Alice <alice@example.com>\nSubject: Hello\n\nline one\n..hidden dot\nline three" {
+		t.Errorf("Unexpected RETR: %q", retr)
+	}
+	if top := c.multiline("TOP 1 1"); strings.Join(top, "\n") != "From: Alice <alice@example.com>\nSubject: Hello\n\nline one" {
+		t.Errorf("Unexpected TOP: %q", top)
+	}
+	if retr := c.multiline("RETR 2"); strings.Join(retr, "\n") != "Subject: Your order\n\norder 1234 shipped" {
+		t.Errorf("Unexpected RETR of LF-only message: %q", retr)
+	}
+
+	// DELE is undone by RSET and applied at QUIT
+	c.cmd("DELE 3", "+OK")
+	c.cmd("RSET", "+OK Maildrop has 3 messages")
+	c.cmd("DELE 1", "+OK")
+	c.cmd("DELE 1", "-ERR Message 1 already deleted")
+	c.cmd("RETR 1", "-ERR")
+	c.cmd("STAT", "+OK 2 ")
+	c.cmd("RETR 9", "-ERR No such message")
+	if deleted := store.deletedIDs(); len(deleted) != 0 {
+		t.Fatalf("DELE must not delete before QUIT, got %v", deleted)
+	}
+
+	c.cmd("QUIT", "+OK")
+	if deleted := store.deletedIDs(); len(deleted) != 1 || deleted[0] != "email-1" {
+		t.Fatalf("Expected email-1 to be deleted at QUIT, got %v", deleted)
+	}
+
+	// The lock is released and the alias maildrop only contains the alias's mail
+	d := dialTestClient(t, addr)
+	d.startTLS()
+	d.cmd("USER owner@example.com/SHOP@webrana.id", "+OK")
+	d.cmd("PASS abcd efgh ijkl mnop", "+OK Maildrop has 1 messages")
+	if list := d.multiline("UIDL"); strings.Join(list, ",") != "1 email-2" {
+		t.Errorf("Unexpected alias maildrop: %v", list)
+	}
+
+	e := dialTestClient(t, addr)
+	e.startTLS()
+	e.cmd("USER owner@example.com/nobody@webrana.id", "+OK")
+	e.cmd("PASS abcd efgh ijkl mnop", "-ERR [AUTH]")
+}
+
+// TestPOP3_ShutdownKeepsMarkedMessages verifies a session ended by shutdown does not enter UPDATE
+func TestPOP3_ShutdownKeepsMarkedMessages(t *testing.T) {
+	server, addr, store := startTestServer(t)
+	c := dialTestClient(t, addr)
+	c.startTLS()
+	c.cmd("USER owner@example.com", "+OK")
+	c.cmd("PASS abcd efgh ijkl mnop", "+OK")
+	c.cmd("DELE 1", "+OK")
+
+	go server.Stop()
+	if line := c.readLine(); line != "-ERR Server shutting down" {
+		t.Fatalf("Expected shutdown notice, got %q", line)
+	}
+	if deleted := store.deletedIDs(); len(deleted) != 0 {
+		t.Fatalf("Expected no deletions, got %v", deleted)
+	}
+}
+
+// TestSplitMaildrop verifies the alias is split off after the account's domain
+func TestSplitMaildrop(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		local := rapid.StringMatching(`[a-z0-9/._-]{1,16}`).Draw(t, "local")
+		alias := rapid.StringMatching(`([a-z0-9/._-]{1,16}@[a-z]{1,8}\.id)?`).Draw(t, "alias")
+
+		user := local + "@example.com"
+		if alias != "" {
+			user += MaildropSeparator + alias
+		}
+		gotAccount, gotAlias := splitMaildrop(user)
+		if gotAccount != local+"@example.com" || gotAlias != alias {
+			t.Fatalf("splitMaildrop(%q) = %q, %q", user, gotAccount, gotAlias)
+		}
+	})
+}
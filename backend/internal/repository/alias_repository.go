@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -78,7 +80,7 @@ func (r *AliasRepository) GetByID(ctx context.Context, id uuid.UUID) (*AliasWith
 			COALESCE(SUM(e.size_bytes), 0) as total_size_bytes
 		FROM aliases a
 		JOIN domains d ON d.id = a.domain_id
-		LEFT JOIN emails e ON e.alias_id = a.id
+		LEFT JOIN emails e ON e.alias_id = a.id AND e.deleted_at IS NULL
 		WHERE a.id = $1
 		GROUP BY a.id, d.domain_name
 	`
@@ -124,7 +126,7 @@ func (r *AliasRepository) GetByFullAddress(ctx context.Context, fullAddress stri
 			COALESCE(SUM(e.size_bytes), 0) as total_size_bytes
 		FROM aliases a
 		JOIN domains d ON d.id = a.domain_id
-		LEFT JOIN emails e ON e.alias_id = a.id
+		LEFT JOIN emails e ON e.alias_id = a.id AND e.deleted_at IS NULL
 		WHERE LOWER(a.full_address) = LOWER($1)
 		GROUP BY a.id, d.domain_name
 	`
@@ -158,7 +160,8 @@ func (r *AliasRepository) GetByFullAddress(ctx context.Context, fullAddress stri
 
 // List retrieves aliases for a user with pagination, filtering, search, and sorting
 // Requirements: 2.1-2.6 (List aliases)
-func (r *AliasRepository) List(ctx context.Context, userID uuid.UUID, params ListAliasParams) ([]AliasWithStats, int, error) {
+// Pages are read by offset, or by keyset from params.Cursor; the cursors of the neighboring pages are returned either way.
+func (r *AliasRepository) List(ctx context.Context, userID uuid.UUID, params ListAliasParams) ([]AliasWithStats, int, PageCursors, error) {
 	// Apply defaults
 	if params.Page < 1 {
 		params.Page = 1
@@ -174,7 +177,7 @@ func (r *AliasRepository) List(ctx context.Context, userID uuid.UUID, params Lis
 	baseQuery := `
 		FROM aliases a
 		JOIN domains d ON d.id = a.domain_id
-		LEFT JOIN emails e ON e.alias_id = a.id
+		LEFT JOIN emails e ON e.alias_id = a.id AND e.deleted_at IS NULL
 		WHERE a.user_id = $1
 	`
 	args := []interface{}{userID}
@@ -199,7 +202,7 @@ func (r *AliasRepository) List(ctx context.Context, userID uuid.UUID, params Lis
 	var totalCount int
 	err := r.pool.QueryRow(ctx, countQuery, args...).Scan(&totalCount)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count aliases: %w", err)
+		return nil, 0, PageCursors{}, fmt.Errorf("failed to count aliases: %w", err)
 	}
 
 	// Build select query with grouping
@@ -216,24 +219,38 @@ func (r *AliasRepository) List(ctx context.Context, userID uuid.UUID, params Lis
 	`
 
 	// Add sorting
-	sortField := "a.created_at"
-	if params.Sort == "email_count" {
-		sortField = "email_count"
-	}
-	sortOrder := "DESC"
-	if params.Order == "asc" {
-		sortOrder = "ASC"
+	sort := aliasSort(params)
+	desc := !strings.HasSuffix(sort, ":asc")
+	columns, casts := []string{"a.created_at"}, []string{"timestamp"}
+	if strings.HasPrefix(sort, "email_count:") {
+		columns, casts = []string{"COALESCE(COUNT(e.id), 0)"}, []string{"bigint"}
 	}
-	selectQuery += fmt.Sprintf(" ORDER BY %s %s", sortField, sortOrder)
 
-	// Add pagination
+	// Add pagination: keyset after (or before) the cursor, or offset by page
+	backward := params.Cursor != nil && params.Cursor.Backward
 	offset := (params.Page - 1) * params.Limit
-	selectQuery += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argIdx, argIdx+1)
-	args = append(args, params.Limit, offset)
+	if params.Cursor != nil {
+		if params.Cursor.Sort != sort {
+			return nil, 0, PageCursors{}, ErrInvalidCursor
+		}
+		var condition string
+		condition, args, argIdx, err = keysetCondition(params.Cursor, columns, casts, "a.id", desc, args, argIdx)
+		if err != nil {
+			return nil, 0, PageCursors{}, err
+		}
+		selectQuery += " HAVING " + condition
+		selectQuery += orderBy(columns, "a.id", desc, backward)
+		selectQuery += fmt.Sprintf(" LIMIT $%d", argIdx)
+		args = append(args, params.Limit+1)
+	} else {
+		selectQuery += orderBy(columns, "a.id", desc, false)
+		selectQuery += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argIdx, argIdx+1)
+		args = append(args, params.Limit, offset)
+	}
 
 	rows, err := r.pool.Query(ctx, selectQuery, args...)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to query aliases: %w", err)
+		return nil, 0, PageCursors{}, fmt.Errorf("failed to query aliases: %w", err)
 	}
 	defer rows.Close()
 
@@ -256,16 +273,56 @@ func (r *AliasRepository) List(ctx context.Context, userID uuid.UUID, params Lis
 			&alias.TotalSizeBytes,
 		)
 		if err != nil {
-			return nil, 0, fmt.Errorf("failed to scan alias: %w", err)
+			return nil, 0, PageCursors{}, fmt.Errorf("failed to scan alias: %w", err)
 		}
 		aliases = append(aliases, alias)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, 0, fmt.Errorf("error iterating aliases: %w", err)
+		return nil, 0, PageCursors{}, fmt.Errorf("error iterating aliases: %w", err)
+	}
+
+	// Cursor pages fetch one extra alias to tell whether more follow
+	hasNext := offset+len(aliases) < totalCount
+	hasPrev := offset > 0
+	if params.Cursor != nil {
+		more := len(aliases) > params.Limit
+		if more {
+			aliases = aliases[:params.Limit]
+		}
+		if backward {
+			slices.Reverse(aliases)
+		}
+		hasNext, hasPrev = more || backward, more || !backward
 	}
 
-	return aliases, totalCount, nil
+	keys := make([][]string, len(aliases))
+	ids := make([]uuid.UUID, len(aliases))
+	for i, alias := range aliases {
+		keys[i] = aliasSortKey(sort, alias)
+		ids[i] = alias.ID
+	}
+	return aliases, totalCount, pageCursors(sort, keys, ids, params.Cursor, hasNext, hasPrev), nil
+}
+
+// aliasSort returns the sort of an alias listing as "column:order"
+func aliasSort(params ListAliasParams) string {
+	column := "created_at"
+	if params.Sort == "email_count" {
+		column = "email_count"
+	}
+	if params.Order == "asc" {
+		return column + ":asc"
+	}
+	return column + ":desc"
+}
+
+// aliasSortKey returns the cursor values of an alias for a sort
+func aliasSortKey(sort string, alias AliasWithStats) []string {
+	if strings.HasPrefix(sort, "email_count:") {
+		return []string{strconv.Itoa(alias.EmailCount)}
+	}
+	return []string{alias.CreatedAt.Format("2006-01-02T15:04:05.999999")}
 }
 
 
@@ -356,7 +413,7 @@ func (r *AliasRepository) GetDetailStats(ctx context.Context, aliasID uuid.UUID)
 			COALESCE(SUM(CASE WHEN received_at >= (NOW() AT TIME ZONE 'utc') - INTERVAL '7 days' THEN 1 ELSE 0 END), 0) as emails_this_week,
 			COALESCE(SUM(CASE WHEN received_at >= (NOW() AT TIME ZONE 'utc') - INTERVAL '30 days' THEN 1 ELSE 0 END), 0) as emails_this_month
 		FROM emails
-		WHERE alias_id = $1
+		WHERE alias_id = $1 AND deleted_at IS NULL
 	`
 
 	stats := &AliasStats{}
@@ -382,7 +439,7 @@ func (r *AliasRepository) GetDetailStats(ctx context.Context, aliasID uuid.UUID)
 	topSendersQuery := `
 		SELECT sender_address, COUNT(*) as count
 		FROM emails
-		WHERE alias_id = $1
+		WHERE alias_id = $1 AND deleted_at IS NULL
 		GROUP BY sender_address
 		ORDER BY count DESC
 		LIMIT 5
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// App password repository errors
+var (
+	ErrAppPasswordNotFound = errors.New("app password not found")
+)
+
+// AppPasswordRepository defines the interface for app password data access
+type AppPasswordRepository interface {
+	Create(ctx context.Context, appPassword *AppPassword) error
+	ListByUserID(ctx context.Context, userID uuid.UUID) ([]AppPassword, error)
+	CountByUserID(ctx context.Context, userID uuid.UUID) (int, error)
+	Delete(ctx context.Context, id, userID uuid.UUID) error
+	UpdateLastUsed(ctx context.Context, id uuid.UUID) error
+}
+
+// appPasswordRepository implements AppPasswordRepository using PostgreSQL
+type appPasswordRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewAppPasswordRepository creates a new AppPasswordRepository instance
+func NewAppPasswordRepository(pool *pgxpool.Pool) AppPasswordRepository {
+	return &appPasswordRepository{pool: pool}
+}
+
+// Create inserts a new app password into the database
+func (r *appPasswordRepository) Create(ctx context.Context, appPassword *AppPassword) error {
+	query := `
+		INSERT INTO app_passwords (user_id, name, password_hash)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`
+
+	return r.pool.QueryRow(ctx, query,
+		appPassword.UserID,
+		appPassword.Name,
+		appPassword.PasswordHash,
+	).Scan(&appPassword.ID, &appPassword.CreatedAt)
+}
+
+// ListByUserID retrieves all app passwords for a user, newest first
+func (r *appPasswordRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]AppPassword, error) {
+	query := `
+		SELECT id, user_id, name, password_hash, last_used_at, created_at
+		FROM app_passwords
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var appPasswords []AppPassword
+	for rows.Next() {
+		var p AppPassword
+		if err := rows.Scan(&p.ID, &p.UserID, &p.Name, &p.PasswordHash, &p.LastUsedAt, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		appPasswords = append(appPasswords, p)
+	}
+
+	return appPasswords, rows.Err()
+}
+
+// CountByUserID counts the app passwords owned by a user
+func (r *appPasswordRepository) CountByUserID(ctx context.Context, userID uuid.UUID) (int, error) {
+	query := `SELECT COUNT(*) FROM app_passwords WHERE user_id = $1`
+
+	var count int
+	if err := r.pool.QueryRow(ctx, query, userID).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Delete revokes an app password owned by the given user
+func (r *appPasswordRepository) Delete(ctx context.Context, id, userID uuid.UUID) error {
+	query := `DELETE FROM app_passwords WHERE id = $1 AND user_id = $2`
+
+	result, err := r.pool.Exec(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrAppPasswordNotFound
+	}
+
+	return nil
+}
+
+// UpdateLastUsed records a successful authentication with an app password
+func (r *appPasswordRepository) UpdateLastUsed(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE app_passwords SET last_used_at = (NOW() AT TIME ZONE 'utc') WHERE id = $1`
+
+	_, err := r.pool.Exec(ctx, query, id)
+	return err
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor is malformed or was issued for another sort
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is a keyset pagination position: the sort key and ID of the item a page continues from
+// Cursors are opaque to clients; Encode and DecodeCursor convert them to and from URL-safe strings.
+type Cursor struct {
+	Sort     string    `json:"s"`           // Sort the cursor was issued for, e.g. "received_at:desc"
+	Values   []string  `json:"v"`           // Sort key of the item, one value per sort column
+	ID       uuid.UUID `json:"i"`           // Tiebreaker for items with equal sort keys
+	Backward bool      `json:"b,omitempty"` // The page ends before the item instead of starting after it
+}
+
+// PageCursors holds the cursors of the pages after and before a listed page, empty at either end
+type PageCursors struct {
+	Next string
+	Prev string
+}
+
+// Encode returns the cursor as an opaque URL-safe string
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor returned by Encode
+func DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Sort == "" || len(c.Values) == 0 || c.ID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// keysetCondition returns the row comparison selecting items after (or, for backward cursors, before) the cursor
+// columns and idColumn are compared together, so columns must share one sort direction; casts type each value argument.
+func keysetCondition(cursor *Cursor, columns, casts []string, idColumn string, desc bool, args []interface{}, argIdx int) (string, []interface{}, int, error) {
+	if len(cursor.Values) != len(columns) {
+		return "", args, argIdx, ErrInvalidCursor
+	}
+
+	placeholders := make([]string, 0, len(columns)+1)
+	for i, value := range cursor.Values {
+		placeholders = append(placeholders, fmt.Sprintf("$%d::%s", argIdx, casts[i]))
+		args = append(args, value)
+		argIdx++
+	}
+	placeholders = append(placeholders, fmt.Sprintf("$%d", argIdx))
+	args = append(args, cursor.ID)
+	argIdx++
+
+	op := ">"
+	if desc != cursor.Backward {
+		op = "<"
+	}
+	condition := fmt.Sprintf("(%s, %s) %s (%s)", strings.Join(columns, ", "), idColumn, op, strings.Join(placeholders, ", "))
+	return condition, args, argIdx, nil
+}
+
+// orderBy returns an ORDER BY clause over columns and idColumn, reversed when reading a page backward
+func orderBy(columns []string, idColumn string, desc, backward bool) string {
+	dir := "ASC"
+	if desc != backward {
+		dir = "DESC"
+	}
+	terms := make([]string, 0, len(columns)+1)
+	for _, column := range columns {
+		terms = append(terms, column+" "+dir)
+	}
+	terms = append(terms, idColumn+" "+dir)
+	return " ORDER BY " + strings.Join(terms, ", ")
+}
+
+// pageCursors returns the cursors around a page given the sort key and ID of each item in display order
+// An empty page read from a cursor can still be read back from the cursor position.
+func pageCursors(sort string, keys [][]string, ids []uuid.UUID, cursor *Cursor, hasNext, hasPrev bool) PageCursors {
+	var cursors PageCursors
+	if len(ids) == 0 {
+		if cursor != nil {
+			back := *cursor
+			back.Backward = !cursor.Backward
+			if back.Backward {
+				cursors.Prev = back.Encode()
+			} else {
+				cursors.Next = back.Encode()
+			}
+		}
+		return cursors
+	}
+
+	if hasNext {
+		last := len(ids) - 1
+		cursors.Next = Cursor{Sort: sort, Values: keys[last], ID: ids[last]}.Encode()
+	}
+	if hasPrev {
+		cursors.Prev = Cursor{Sort: sort, Values: keys[0], ID: ids[0], Backward: true}.Encode()
+	}
+	return cursors
+}
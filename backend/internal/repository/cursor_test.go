@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// TestCursorRoundTrip verifies cursors decode to what was encoded and malformed cursors are rejected
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := Cursor{Sort: "rank", Values: []string{"0.1", "2024-03-01T10:00:00.5"}, ID: uuid.New(), Backward: true}
+	decoded, err := DecodeCursor(cursor.Encode())
+	if err != nil || !reflect.DeepEqual(*decoded, cursor) {
+		t.Fatalf("DecodeCursor() = %+v, %v, want %+v", decoded, err, cursor)
+	}
+
+	for _, s := range []string{"", "not base64!", Cursor{Sort: "size:asc", ID: uuid.New()}.Encode()} {
+		if _, err := DecodeCursor(s); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("DecodeCursor(%q) error = %v, want ErrInvalidCursor", s, err)
+		}
+	}
+}
+
+// TestKeysetCondition verifies the comparison follows the sort direction and flips for backward cursors
+func TestKeysetCondition(t *testing.T) {
+	id := uuid.New()
+	columns, casts := []string{"e.received_at"}, []string{"timestamp"}
+
+	condition, args, argIdx, err := keysetCondition(&Cursor{Values: []string{"2024-03-01T10:00:00"}, ID: id}, columns, casts, "e.id", true, []interface{}{"user"}, 2)
+	if err != nil || condition != "(e.received_at, e.id) < ($2::timestamp, $3)" || argIdx != 4 {
+		t.Errorf("Forward condition = %q, %d, %v", condition, argIdx, err)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"user", "2024-03-01T10:00:00", id}) {
+		t.Errorf("Args = %v", args)
+	}
+
+	condition, _, _, _ = keysetCondition(&Cursor{Values: []string{"1"}, ID: id, Backward: true}, columns, casts, "e.id", true, nil, 1)
+	if condition != "(e.received_at, e.id) > ($1::timestamp, $2)" {
+		t.Errorf("Backward condition = %q", condition)
+	}
+	if got := orderBy(columns, "e.id", true, true); got != " ORDER BY e.received_at ASC, e.id ASC" {
+		t.Errorf("Backward order = %q", got)
+	}
+
+	if _, _, _, err := keysetCondition(&Cursor{Values: []string{"1", "2"}, ID: id}, columns, casts, "e.id", true, nil, 1); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Cursor for another sort error = %v", err)
+	}
+}
+
+// TestPageCursors verifies cursors point past each end of a page only when more items exist there
+func TestPageCursors(t *testing.T) {
+	ids := []uuid.UUID{uuid.New(), uuid.New()}
+	keys := [][]string{{"2"}, {"1"}}
+
+	cursors := pageCursors("size:desc", keys, ids, nil, true, false)
+	if cursors.Prev != "" {
+		t.Errorf("First page Prev = %q", cursors.Prev)
+	}
+	next, err := DecodeCursor(cursors.Next)
+	if err != nil || next.ID != ids[1] || next.Values[0] != "1" || next.Backward {
+		t.Errorf("Next = %+v, %v", next, err)
+	}
+
+	cursors = pageCursors("size:desc", keys, ids, next, false, true)
+	prev, err := DecodeCursor(cursors.Prev)
+	if cursors.Next != "" || err != nil || prev.ID != ids[0] || !prev.Backward {
+		t.Errorf("Last page cursors = %+v, Prev = %+v", cursors, prev)
+	}
+
+	cursors = pageCursors("size:desc", nil, nil, next, false, true)
+	if back, err := DecodeCursor(cursors.Prev); cursors.Next != "" || err != nil || back.ID != next.ID || !back.Backward {
+		t.Errorf("Empty page cursors = %+v", cursors)
+	}
+}
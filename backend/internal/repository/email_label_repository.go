@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// GetLabelIDsOwnedByUser filters label IDs to only those owned by the user
+func (r *EmailRepo) GetLabelIDsOwnedByUser(ctx context.Context, labelIDs []uuid.UUID, userID uuid.UUID) ([]uuid.UUID, error) {
+	if len(labelIDs) == 0 {
+		return []uuid.UUID{}, nil
+	}
+
+	// Build query with placeholders
+	placeholders := make([]string, len(labelIDs))
+	args := make([]interface{}, len(labelIDs)+1)
+	args[0] = userID
+	for i, id := range labelIDs {
+		placeholders[i] = fmt.Sprintf("$%d", i+2)
+		args[i+1] = id
+	}
+
+	query := fmt.Sprintf(`SELECT id FROM labels WHERE user_id = $1 AND id IN (%s)`, strings.Join(placeholders, ", "))
+
+	var ownedIDs []uuid.UUID
+	if err := r.db.SelectContext(ctx, &ownedIDs, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to filter owned labels: %w", err)
+	}
+	return ownedIDs, nil
+}
+
+// AddLabels applies every label to every email, skipping pairs already labeled
+// Returns the number of labels newly applied
+func (r *EmailRepo) AddLabels(ctx context.Context, emailIDs, labelIDs []uuid.UUID) (int, error) {
+	if len(emailIDs) == 0 || len(labelIDs) == 0 {
+		return 0, nil
+	}
+
+	// Build one (email_id, label_id) row per pair
+	values := make([]string, 0, len(emailIDs)*len(labelIDs))
+	args := make([]interface{}, 0, 2*len(emailIDs)*len(labelIDs))
+	for _, emailID := range emailIDs {
+		for _, labelID := range labelIDs {
+			values = append(values, fmt.Sprintf("($%d, $%d)", len(args)+1, len(args)+2))
+			args = append(args, emailID, labelID)
+		}
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO email_labels (email_id, label_id)
+		VALUES %s
+		ON CONFLICT (email_id, label_id) DO NOTHING
+	`, strings.Join(values, ", "))
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add labels: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// RemoveLabels removes every label from every email
+// Returns the number of labels removed
+func (r *EmailRepo) RemoveLabels(ctx context.Context, emailIDs, labelIDs []uuid.UUID) (int, error) {
+	if len(emailIDs) == 0 || len(labelIDs) == 0 {
+		return 0, nil
+	}
+
+	// Build query with placeholders
+	emailPlaceholders := make([]string, len(emailIDs))
+	labelPlaceholders := make([]string, len(labelIDs))
+	args := make([]interface{}, 0, len(emailIDs)+len(labelIDs))
+	for i, id := range emailIDs {
+		emailPlaceholders[i] = fmt.Sprintf("$%d", len(args)+1)
+		args = append(args, id)
+	}
+	for i, id := range labelIDs {
+		labelPlaceholders[i] = fmt.Sprintf("$%d", len(args)+1)
+		args = append(args, id)
+	}
+
+	query := fmt.Sprintf(
+		"DELETE FROM email_labels WHERE email_id IN (%s) AND label_id IN (%s)",
+		strings.Join(emailPlaceholders, ", "),
+		strings.Join(labelPlaceholders, ", "),
+	)
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove labels: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// GetLabelsByEmailIDs returns the labels of each email, ordered by name
+// Emails without labels are absent from the map
+func (r *EmailRepo) GetLabelsByEmailIDs(ctx context.Context, emailIDs []uuid.UUID) (map[uuid.UUID][]Label, error) {
+	labels := make(map[uuid.UUID][]Label)
+	if len(emailIDs) == 0 {
+		return labels, nil
+	}
+
+	// Build query with placeholders
+	placeholders := make([]string, len(emailIDs))
+	args := make([]interface{}, len(emailIDs))
+	for i, id := range emailIDs {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = id
+	}
+
+	query := fmt.Sprintf(`
+		SELECT el.email_id, l.id, l.user_id, l.name, l.color, l.created_at, l.updated_at
+		FROM email_labels el
+		JOIN labels l ON l.id = el.label_id
+		WHERE el.email_id IN (%s)
+		ORDER BY LOWER(l.name), l.id
+	`, strings.Join(placeholders, ", "))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email labels: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var emailID uuid.UUID
+		var label Label
+		if err := rows.Scan(&emailID, &label.ID, &label.UserID, &label.Name, &label.Color, &label.CreatedAt, &label.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan email label: %w", err)
+		}
+		labels[emailID] = append(labels[emailID], label)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating email labels: %w", err)
+	}
+
+	return labels, nil
+}
@@ -6,6 +6,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 	"unicode"
 
@@ -21,7 +23,7 @@ var (
 // EmailRepositoryInterface defines the interface for email repository operations
 // Requirements: 1.1-1.9, 2.1, 4.1, 5.1-5.2, 6.1-6.5
 type EmailRepositoryInterface interface {
-	List(ctx context.Context, userID uuid.UUID, params ListEmailParams) ([]EmailWithPreview, int, error)
+	List(ctx context.Context, userID uuid.UUID, params ListEmailParams) ([]EmailWithPreview, int, PageCursors, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*Email, error)
 	Delete(ctx context.Context, id uuid.UUID) error
 	DeleteBatch(ctx context.Context, ids []uuid.UUID) (int, error)
@@ -76,8 +78,9 @@ func GeneratePreviewText(bodyText string, maxLength int) string {
 
 
 // List retrieves emails for a user with pagination, filtering, search, and sorting
+// Pages are read by offset, or by keyset from params.Cursor; the cursors of the neighboring pages are returned either way.
 // Requirements: 1.1-1.9 (List emails with filters)
-func (r *EmailRepo) List(ctx context.Context, userID uuid.UUID, params ListEmailParams) ([]EmailWithPreview, int, error) {
+func (r *EmailRepo) List(ctx context.Context, userID uuid.UUID, params ListEmailParams) ([]EmailWithPreview, int, PageCursors, error) {
 	// Apply defaults
 	if params.Page < 1 {
 		params.Page = 1
@@ -89,73 +92,22 @@ func (r *EmailRepo) List(ctx context.Context, userID uuid.UUID, params ListEmail
 		params.Limit = 100
 	}
 
-	// Build base query - join with aliases to filter by user ownership
-	baseQuery := `
-		FROM emails e
-		JOIN aliases a ON e.alias_id = a.id
-		WHERE a.user_id = $1
-	`
-	args := []interface{}{userID}
-	argIdx := 2
-
-	// Add alias filter
-	if params.AliasID != nil {
-		baseQuery += fmt.Sprintf(" AND e.alias_id = $%d", argIdx)
-		args = append(args, *params.AliasID)
-		argIdx++
-	}
-
-	// Add search filter (search in subject, sender_address, body_text)
-	if params.Search != "" {
-		baseQuery += fmt.Sprintf(` AND (
-			LOWER(e.subject) LIKE LOWER($%d) OR
-			LOWER(e.sender_address) LIKE LOWER($%d) OR
-			LOWER(e.body_text) LIKE LOWER($%d)
-		)`, argIdx, argIdx, argIdx)
-		args = append(args, "%"+params.Search+"%")
-		argIdx++
-	}
-
-	// Add date range filter
-	if params.FromDate != nil {
-		baseQuery += fmt.Sprintf(" AND e.received_at >= $%d", argIdx)
-		args = append(args, *params.FromDate)
-		argIdx++
-	}
-	if params.ToDate != nil {
-		baseQuery += fmt.Sprintf(" AND e.received_at <= $%d", argIdx)
-		args = append(args, *params.ToDate)
-		argIdx++
-	}
-
-	// Add has_attachments filter
-	if params.HasAttachments != nil {
-		if *params.HasAttachments {
-			baseQuery += " AND EXISTS (SELECT 1 FROM attachments att WHERE att.email_id = e.id)"
-		} else {
-			baseQuery += " AND NOT EXISTS (SELECT 1 FROM attachments att WHERE att.email_id = e.id)"
-		}
-	}
-
-	// Add is_read filter
-	if params.IsRead != nil {
-		baseQuery += fmt.Sprintf(" AND e.is_read = $%d", argIdx)
-		args = append(args, *params.IsRead)
-		argIdx++
-	}
+	baseQuery, args, argIdx := emailListFilter(userID, params)
+	hasText := params.Search.HasText()
 
 	// Count total records
 	countQuery := "SELECT COUNT(*) " + baseQuery
 	var totalCount int
 	err := r.db.GetContext(ctx, &totalCount, countQuery, args...)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count emails: %w", err)
+		return nil, 0, PageCursors{}, fmt.Errorf("failed to count emails: %w", err)
 	}
 
 	// Build select query
 	selectQuery := `
 		SELECT 
 			e.id,
+			e.thread_id,
 			e.alias_id,
 			a.full_address as alias_email,
 			e.sender_address as from_address,
@@ -165,37 +117,57 @@ func (r *EmailRepo) List(ctx context.Context, userID uuid.UUID, params ListEmail
 			e.received_at,
 			e.size_bytes,
 			e.is_read,
-			(SELECT COUNT(*) FROM attachments att WHERE att.email_id = e.id) as attachment_count
+			e.is_starred,
+			e.is_archived,
+			e.snoozed_until,
+			e.deleted_at,
+			(SELECT COUNT(*) FROM attachments att WHERE att.email_id = e.id) as attachment_count,
+			` + snippetColumn(hasText) + ` as snippet,
+			` + rankColumn(hasText) + ` as rank
 	` + baseQuery
 
-	// Add sorting
-	sortField := "e.received_at"
-	if params.Sort == "size" {
-		sortField = "e.size_bytes"
-	}
-	sortOrder := "DESC"
-	if params.Order == "asc" {
-		sortOrder = "ASC"
-	}
-	selectQuery += fmt.Sprintf(" ORDER BY %s %s", sortField, sortOrder)
+	// Add sorting (text searches rank best matches first unless a sort is given)
+	sort := emailSort(params, hasText)
+	desc := sort != "received_at:asc" && sort != "size:asc"
+	columns, casts := emailSortColumns(sort)
 
-	// Add pagination
+	// Add pagination: keyset after (or before) the cursor, or offset by page
+	backward := params.Cursor != nil && params.Cursor.Backward
 	offset := (params.Page - 1) * params.Limit
-	selectQuery += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argIdx, argIdx+1)
-	args = append(args, params.Limit, offset)
+	if params.Cursor != nil {
+		if params.Cursor.Sort != sort {
+			return nil, 0, PageCursors{}, ErrInvalidCursor
+		}
+		var condition string
+		condition, args, argIdx, err = keysetCondition(params.Cursor, columns, casts, "e.id", desc, args, argIdx)
+		if err != nil {
+			return nil, 0, PageCursors{}, err
+		}
+		selectQuery += " AND " + condition
+		selectQuery += orderBy(columns, "e.id", desc, backward)
+		selectQuery += fmt.Sprintf(" LIMIT $%d", argIdx)
+		args = append(args, params.Limit+1)
+	} else {
+		selectQuery += orderBy(columns, "e.id", desc, false)
+		selectQuery += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argIdx, argIdx+1)
+		args = append(args, params.Limit, offset)
+	}
 
 	rows, err := r.db.QueryContext(ctx, selectQuery, args...)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to query emails: %w", err)
+		return nil, 0, PageCursors{}, fmt.Errorf("failed to query emails: %w", err)
 	}
 	defer rows.Close()
 
 	var emails []EmailWithPreview
+	var keys [][]string
 	for rows.Next() {
 		var email EmailWithPreview
 		var bodyText string
+		var rank float32
 		err := rows.Scan(
 			&email.ID,
+			&email.ThreadID,
 			&email.AliasID,
 			&email.AliasEmail,
 			&email.FromAddress,
@@ -205,24 +177,224 @@ func (r *EmailRepo) List(ctx context.Context, userID uuid.UUID, params ListEmail
 			&email.ReceivedAt,
 			&email.SizeBytes,
 			&email.IsRead,
+			&email.IsStarred,
+			&email.IsArchived,
+			&email.SnoozedUntil,
+			&email.DeletedAt,
 			&email.AttachmentCount,
+			&email.Snippet,
+			&rank,
 		)
 		if err != nil {
-			return nil, 0, fmt.Errorf("failed to scan email: %w", err)
+			return nil, 0, PageCursors{}, fmt.Errorf("failed to scan email: %w", err)
 		}
 
 		// Generate preview text
 		email.PreviewText = GeneratePreviewText(bodyText, 200)
+		if email.Snippet != nil {
+			snippet := escapeSnippet(*email.Snippet)
+			email.Snippet = &snippet
+		}
 		email.HasAttachments = email.AttachmentCount > 0
 
 		emails = append(emails, email)
+		keys = append(keys, emailSortKey(sort, email, rank))
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, 0, fmt.Errorf("error iterating emails: %w", err)
+		return nil, 0, PageCursors{}, fmt.Errorf("error iterating emails: %w", err)
+	}
+
+	// Cursor pages fetch one extra email to tell whether more follow
+	hasNext := offset+len(emails) < totalCount
+	hasPrev := offset > 0
+	if params.Cursor != nil {
+		more := len(emails) > params.Limit
+		if more {
+			emails, keys = emails[:params.Limit], keys[:params.Limit]
+		}
+		if backward {
+			slices.Reverse(emails)
+			slices.Reverse(keys)
+		}
+		hasNext, hasPrev = more || backward, more || !backward
+	}
+
+	ids := make([]uuid.UUID, len(emails))
+	for i, email := range emails {
+		ids[i] = email.ID
+	}
+	return emails, totalCount, pageCursors(sort, keys, ids, params.Cursor, hasNext, hasPrev), nil
+}
+
+// CountEmails counts the emails an email listing with params would return, and how many of them are unread
+// Pagination, sort and cursor params are ignored.
+func (r *EmailRepo) CountEmails(ctx context.Context, userID uuid.UUID, params ListEmailParams) (int, int, error) {
+	baseQuery, args, _ := emailListFilter(userID, params)
+
+	var total, unread int
+	query := "SELECT COUNT(*), COUNT(*) FILTER (WHERE NOT e.is_read) " + baseQuery
+	if err := r.db.QueryRowContext(ctx, query, args...).Scan(&total, &unread); err != nil {
+		return 0, 0, fmt.Errorf("failed to count emails: %w", err)
+	}
+	return total, unread, nil
+}
+
+// emailListFilter builds the FROM and WHERE clauses of an email listing, with their arguments
+// The user ID is $1; free-text searches join the query as q. It returns the index of the next argument.
+func emailListFilter(userID uuid.UUID, params ListEmailParams) (string, []interface{}, int) {
+	// Build base query - join with aliases to filter by user ownership
+	baseQuery := `
+		FROM emails e
+		JOIN aliases a ON e.alias_id = a.id
+	`
+	args := []interface{}{userID}
+	argIdx := 2
+
+	// Free-text terms become one ranked query q over the search index
+	hasText := params.Search.HasText()
+	if hasText {
+		baseQuery += " CROSS JOIN websearch_to_tsquery('english', $2) AS q"
+		args = append(args, searchTextQuery(params.Search))
+		argIdx++
+	}
+	baseQuery += " WHERE a.user_id = $1"
+
+	// Trashed emails are listed only in the trash
+	if params.InTrash {
+		baseQuery += " AND e.deleted_at IS NOT NULL"
+	} else {
+		baseQuery += " AND e.deleted_at IS NULL"
+	}
+
+	// Add alias filter
+	if params.AliasID != nil {
+		baseQuery += fmt.Sprintf(" AND e.alias_id = $%d", argIdx)
+		args = append(args, *params.AliasID)
+		argIdx++
 	}
 
-	return emails, totalCount, nil
+	// Restrict to one email, to test whether it matches the other filters
+	if params.EmailID != nil {
+		baseQuery += fmt.Sprintf(" AND e.id = $%d", argIdx)
+		args = append(args, *params.EmailID)
+		argIdx++
+	}
+
+	// Add search filter (an empty text query, e.g. only stop words, matches everything)
+	if hasText {
+		baseQuery += " AND (numnode(q) = 0 OR e.search_vector @@ q)"
+	}
+	if params.Search != nil {
+		for _, term := range params.Search.Terms {
+			var condition string
+			condition, args, argIdx = searchTermCondition(term, args, argIdx)
+			if condition == "" {
+				continue
+			}
+			if term.Negated {
+				condition = "NOT " + condition
+			}
+			baseQuery += " AND " + condition
+		}
+	}
+
+	// Add date range filter
+	if params.FromDate != nil {
+		baseQuery += fmt.Sprintf(" AND e.received_at >= $%d", argIdx)
+		args = append(args, *params.FromDate)
+		argIdx++
+	}
+	if params.ToDate != nil {
+		baseQuery += fmt.Sprintf(" AND e.received_at <= $%d", argIdx)
+		args = append(args, *params.ToDate)
+		argIdx++
+	}
+
+	// Add has_attachments filter
+	if params.HasAttachments != nil {
+		if *params.HasAttachments {
+			baseQuery += " AND EXISTS (SELECT 1 FROM attachments att WHERE att.email_id = e.id)"
+		} else {
+			baseQuery += " AND NOT EXISTS (SELECT 1 FROM attachments att WHERE att.email_id = e.id)"
+		}
+	}
+
+	// Add is_read filter
+	if params.IsRead != nil {
+		baseQuery += fmt.Sprintf(" AND e.is_read = $%d", argIdx)
+		args = append(args, *params.IsRead)
+		argIdx++
+	}
+
+	// Add star, archive and snooze filters
+	if params.IsStarred != nil {
+		baseQuery += fmt.Sprintf(" AND e.is_starred = $%d", argIdx)
+		args = append(args, *params.IsStarred)
+		argIdx++
+	}
+	if params.IsArchived != nil {
+		baseQuery += fmt.Sprintf(" AND e.is_archived = $%d", argIdx)
+		args = append(args, *params.IsArchived)
+		argIdx++
+	}
+	if params.IsSnoozed != nil {
+		if *params.IsSnoozed {
+			baseQuery += " AND e.snoozed_until IS NOT NULL"
+		} else {
+			baseQuery += " AND e.snoozed_until IS NULL"
+		}
+	}
+
+	// Add label filter
+	if params.LabelID != nil {
+		baseQuery += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM email_labels el WHERE el.email_id = e.id AND el.label_id = $%d)", argIdx)
+		args = append(args, *params.LabelID)
+		argIdx++
+	}
+
+	return baseQuery, args, argIdx
+}
+
+// emailSort returns the sort of an email listing as "column:order", or "rank" for ranked text searches
+func emailSort(params ListEmailParams, hasText bool) string {
+	order := "desc"
+	if params.Order == "asc" {
+		order = "asc"
+	}
+	switch {
+	case params.Sort == "size":
+		return "size:" + order
+	case params.Sort == "" && hasText:
+		return "rank"
+	default:
+		return "received_at:" + order
+	}
+}
+
+// emailSortColumns returns the columns of an email sort and the SQL types of their cursor values
+func emailSortColumns(sort string) ([]string, []string) {
+	switch sort {
+	case "size:asc", "size:desc":
+		return []string{"e.size_bytes"}, []string{"bigint"}
+	case "rank":
+		return []string{"ts_rank_cd(e.search_vector, q)", "e.received_at"}, []string{"real", "timestamp"}
+	default:
+		return []string{"e.received_at"}, []string{"timestamp"}
+	}
+}
+
+// emailSortKey returns the cursor values of an email for a sort
+func emailSortKey(sort string, email EmailWithPreview, rank float32) []string {
+	receivedAt := email.ReceivedAt.Format("2006-01-02T15:04:05.999999")
+	switch sort {
+	case "size:asc", "size:desc":
+		return []string{strconv.FormatInt(email.SizeBytes, 10)}
+	case "rank":
+		return []string{strconv.FormatFloat(float64(rank), 'g', -1, 32), receivedAt}
+	default:
+		return []string{receivedAt}
+	}
 }
 
 
@@ -231,13 +403,14 @@ func (r *EmailRepo) List(ctx context.Context, userID uuid.UUID, params ListEmail
 func (r *EmailRepo) GetByID(ctx context.Context, id uuid.UUID) (*Email, error) {
 	query := `
 		SELECT id, alias_id, sender_address, sender_name, subject, body_html, body_text, 
-		       headers, size_bytes, is_read, raw_email, received_at, created_at
+		       headers, header_list, calendar, codes, body_charset, charset_warnings, tnef_properties, embedded, message_id, thread_id, size_bytes, is_read,
+		       is_starred, is_archived, snoozed_until, deleted_at, raw_email, received_at, created_at
 		FROM emails
 		WHERE id = $1
 	`
 
 	var email Email
-	var headersJSON []byte
+	var headersJSON, headerListJSON, calendarJSON, codesJSON, charsetWarningsJSON, tnefJSON, embeddedJSON []byte
 
 	row := r.db.QueryRowContext(ctx, query, id)
 	err := row.Scan(
@@ -249,8 +422,21 @@ func (r *EmailRepo) GetByID(ctx context.Context, id uuid.UUID) (*Email, error) {
 		&email.BodyHTML,
 		&email.BodyText,
 		&headersJSON,
+		&headerListJSON,
+		&calendarJSON,
+		&codesJSON,
+		&email.BodyCharset,
+		&charsetWarningsJSON,
+		&tnefJSON,
+		&embeddedJSON,
+		&email.MessageID,
+		&email.ThreadID,
 		&email.SizeBytes,
 		&email.IsRead,
+		&email.IsStarred,
+		&email.IsArchived,
+		&email.SnoozedUntil,
+		&email.DeletedAt,
 		&email.RawEmail,
 		&email.ReceivedAt,
 		&email.CreatedAt,
@@ -270,6 +456,24 @@ func (r *EmailRepo) GetByID(ctx context.Context, id uuid.UUID) (*Email, error) {
 	} else {
 		email.Headers = make(map[string]string)
 	}
+	if len(headerListJSON) > 0 {
+		email.HeaderList = json.RawMessage(headerListJSON)
+	}
+	if len(calendarJSON) > 0 {
+		email.Calendar = json.RawMessage(calendarJSON)
+	}
+	if len(codesJSON) > 0 {
+		email.Codes = json.RawMessage(codesJSON)
+	}
+	if len(charsetWarningsJSON) > 0 {
+		email.CharsetWarnings = json.RawMessage(charsetWarningsJSON)
+	}
+	if len(tnefJSON) > 0 {
+		email.TNEFProperties = json.RawMessage(tnefJSON)
+	}
+	if len(embeddedJSON) > 0 {
+		email.Embedded = json.RawMessage(embeddedJSON)
+	}
 
 	return &email, nil
 }
@@ -384,11 +588,13 @@ func (r *EmailRepo) MarkAsReadBatch(ctx context.Context, ids []uuid.UUID) (int,
 func (r *EmailRepo) GetStats(ctx context.Context, userID uuid.UUID) (*InboxStats, error) {
 	stats := &InboxStats{}
 
-	// Get total emails, unread count, and total size
+	// Get total emails, unread count, trash count, and total size
+	// Trashed emails count only towards the trash and the size they still take up
 	summaryQuery := `
 		SELECT 
-			COUNT(*) as total_emails,
-			COALESCE(SUM(CASE WHEN e.is_read = false THEN 1 ELSE 0 END), 0) as unread_emails,
+			COUNT(*) FILTER (WHERE e.deleted_at IS NULL) as total_emails,
+			COUNT(*) FILTER (WHERE e.deleted_at IS NULL AND e.is_read = false) as unread_emails,
+			COUNT(*) FILTER (WHERE e.deleted_at IS NOT NULL) as trashed_emails,
 			COALESCE(SUM(e.size_bytes), 0) as total_size_bytes
 		FROM emails e
 		JOIN aliases a ON e.alias_id = a.id
@@ -397,6 +603,7 @@ func (r *EmailRepo) GetStats(ctx context.Context, userID uuid.UUID) (*InboxStats
 	err := r.db.QueryRowContext(ctx, summaryQuery, userID).Scan(
 		&stats.TotalEmails,
 		&stats.UnreadEmails,
+		&stats.TrashedEmails,
 		&stats.TotalSizeBytes,
 	)
 	if err != nil {
@@ -411,7 +618,7 @@ func (r *EmailRepo) GetStats(ctx context.Context, userID uuid.UUID) (*InboxStats
 			COALESCE(SUM(CASE WHEN e.received_at >= (NOW() AT TIME ZONE 'utc') - INTERVAL '30 days' THEN 1 ELSE 0 END), 0) as emails_this_month
 		FROM emails e
 		JOIN aliases a ON e.alias_id = a.id
-		WHERE a.user_id = $1
+		WHERE a.user_id = $1 AND e.deleted_at IS NULL
 	`
 	err = r.db.QueryRowContext(ctx, timeQuery, userID).Scan(
 		&stats.EmailsToday,
@@ -429,7 +636,7 @@ func (r *EmailRepo) GetStats(ctx context.Context, userID uuid.UUID) (*InboxStats
 			a.full_address as alias_email,
 			COUNT(e.id) as count
 		FROM aliases a
-		LEFT JOIN emails e ON e.alias_id = a.id
+		LEFT JOIN emails e ON e.alias_id = a.id AND e.deleted_at IS NULL
 		WHERE a.user_id = $1
 		GROUP BY a.id, a.full_address
 		ORDER BY count DESC
@@ -453,6 +660,26 @@ func (r *EmailRepo) GetStats(ctx context.Context, userID uuid.UUID) (*InboxStats
 		return nil, fmt.Errorf("error iterating alias counts: %w", err)
 	}
 
+	// Get emails per label
+	labelQuery := `
+		SELECT
+			l.id as label_id,
+			l.name,
+			l.color,
+			COUNT(e.id) as count,
+			COUNT(e.id) FILTER (WHERE e.is_read = false) as unread_count
+		FROM labels l
+		LEFT JOIN email_labels el ON el.label_id = l.id
+		LEFT JOIN emails e ON e.id = el.email_id AND e.deleted_at IS NULL
+		WHERE l.user_id = $1
+		GROUP BY l.id, l.name, l.color
+		ORDER BY LOWER(l.name)
+	`
+	stats.EmailsPerLabel = []LabelEmailCount{}
+	if err := r.db.SelectContext(ctx, &stats.EmailsPerLabel, labelQuery, userID); err != nil {
+		return nil, fmt.Errorf("failed to get emails per label: %w", err)
+	}
+
 	return stats, nil
 }
 
@@ -485,10 +712,42 @@ func (r *EmailRepo) Create(ctx context.Context, email *Email) error {
 
 	query := `
 		INSERT INTO emails (id, alias_id, sender_address, sender_name, subject, body_html, body_text, 
-		                    headers, size_bytes, is_read, raw_email, received_at, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		                    headers, calendar, codes, size_bytes, is_read, raw_email, received_at, created_at,
+		                    message_id, thread_id, header_list, body_charset, charset_warnings, tnef_properties, embedded)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
 	`
 
+	// An email without a thread starts its own
+	if email.ThreadID == uuid.Nil {
+		email.ThreadID = email.ID
+	}
+
+	// A nil RawMessage is stored as NULL rather than the JSON literal null
+	var calendarJSON []byte
+	if len(email.Calendar) > 0 {
+		calendarJSON = email.Calendar
+	}
+	var codesJSON []byte
+	if len(email.Codes) > 0 {
+		codesJSON = email.Codes
+	}
+	var headerListJSON []byte
+	if len(email.HeaderList) > 0 {
+		headerListJSON = email.HeaderList
+	}
+	var charsetWarningsJSON []byte
+	if len(email.CharsetWarnings) > 0 {
+		charsetWarningsJSON = email.CharsetWarnings
+	}
+	var tnefJSON []byte
+	if len(email.TNEFProperties) > 0 {
+		tnefJSON = email.TNEFProperties
+	}
+	var embeddedJSON []byte
+	if len(email.Embedded) > 0 {
+		embeddedJSON = email.Embedded
+	}
+
 	_, err = r.db.ExecContext(ctx, query,
 		email.ID,
 		email.AliasID,
@@ -498,11 +757,20 @@ func (r *EmailRepo) Create(ctx context.Context, email *Email) error {
 		email.BodyHTML,
 		email.BodyText,
 		headersJSON,
+		calendarJSON,
+		codesJSON,
 		email.SizeBytes,
 		email.IsRead,
 		email.RawEmail,
 		email.ReceivedAt,
 		email.CreatedAt,
+		email.MessageID,
+		email.ThreadID,
+		headerListJSON,
+		email.BodyCharset,
+		charsetWarningsJSON,
+		tnefJSON,
+		embeddedJSON,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create email: %w", err)
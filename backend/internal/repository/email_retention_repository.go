@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/retention"
+)
+
+// ExpiredEmail is an email due for deletion under its alias's retention policy
+type ExpiredEmail struct {
+	ID      uuid.UUID
+	AliasID uuid.UUID
+	UserID  uuid.UUID
+	Mode    string // Mode of the policy that expired the email
+}
+
+// GetExpiredEmails retrieves up to limit emails expired under the effective retention of their alias, oldest first
+// Emails expire once older than an hours or days policy, or when a latest policy's newer emails outnumber its value.
+// Trashed emails do not count towards the latest emails kept.
+// Age policies seek each alias's emails up to its cutoff; latest policies seek the alias's Nth newest live email
+// and take the live emails older than it, so no emails are ranked and aliases within their limit return nothing.
+func (r *EmailRepo) GetExpiredEmails(ctx context.Context, now time.Time, limit int) ([]ExpiredEmail, error) {
+	query := `
+		WITH policies AS (
+			SELECT
+				a.id as alias_id,
+				a.user_id,
+				COALESCE(a.retention_mode, d.retention_mode) as mode,
+				CASE WHEN a.retention_mode IS NOT NULL THEN a.retention_value ELSE d.retention_value END as value
+			FROM aliases a
+			JOIN domains d ON d.id = a.domain_id
+			WHERE COALESCE(a.retention_mode, d.retention_mode) IN ('hours', 'days', 'latest')
+		),
+		aged AS (
+			SELECT
+				alias_id,
+				user_id,
+				mode,
+				CASE mode
+					WHEN 'hours' THEN $1 - make_interval(hours => value)
+					ELSE $1 - make_interval(days => value)
+				END as cutoff
+			FROM policies
+			WHERE mode IN ('hours', 'days')
+		),
+		latest AS (
+			SELECT p.alias_id, p.user_id, kept.received_at, kept.id
+			FROM policies p
+			CROSS JOIN LATERAL (
+				SELECT e.received_at, e.id
+				FROM emails e
+				WHERE e.alias_id = p.alias_id AND e.deleted_at IS NULL
+				ORDER BY e.received_at DESC, e.id DESC
+				OFFSET p.value - 1
+				LIMIT 1
+			) kept
+			WHERE p.mode = 'latest'
+		)
+		SELECT id, alias_id, user_id, mode
+		FROM (
+			SELECT e.id, e.alias_id, a.user_id, a.mode, e.received_at
+			FROM aged a
+			JOIN emails e ON e.alias_id = a.alias_id AND e.received_at <= a.cutoff
+			UNION ALL
+			SELECT e.id, e.alias_id, l.user_id, 'latest', e.received_at
+			FROM latest l
+			JOIN emails e ON e.alias_id = l.alias_id AND e.deleted_at IS NULL
+				AND (e.received_at, e.id) < (l.received_at, l.id)
+		) expired
+		ORDER BY received_at, id
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, now.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query expired emails: %w", err)
+	}
+	defer rows.Close()
+
+	var expired []ExpiredEmail
+	for rows.Next() {
+		var email ExpiredEmail
+		if err := rows.Scan(&email.ID, &email.AliasID, &email.UserID, &email.Mode); err != nil {
+			return nil, fmt.Errorf("failed to scan expired email: %w", err)
+		}
+		expired = append(expired, email)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating expired emails: %w", err)
+	}
+
+	return expired, nil
+}
+
+// GetRetentionPolicies retrieves the effective retention policy of multiple aliases
+func (r *EmailRepo) GetRetentionPolicies(ctx context.Context, aliasIDs []uuid.UUID) (map[uuid.UUID]retention.Policy, error) {
+	policies := make(map[uuid.UUID]retention.Policy)
+	if len(aliasIDs) == 0 {
+		return policies, nil
+	}
+
+	// Build query with placeholders
+	placeholders := make([]string, len(aliasIDs))
+	args := make([]interface{}, len(aliasIDs))
+	for i, id := range aliasIDs {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = id
+	}
+
+	query := fmt.Sprintf(`
+		SELECT a.id, a.retention_mode, a.retention_value, d.retention_mode, d.retention_value
+		FROM aliases a
+		JOIN domains d ON d.id = a.domain_id
+		WHERE a.id IN (%s)
+	`, strings.Join(placeholders, ", "))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query retention policies: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var aliasID uuid.UUID
+		var aliasMode, domainMode *string
+		var aliasValue, domainValue *int
+		if err := rows.Scan(&aliasID, &aliasMode, &aliasValue, &domainMode, &domainValue); err != nil {
+			return nil, fmt.Errorf("failed to scan retention policy: %w", err)
+		}
+		policies[aliasID] = retention.Effective(
+			retention.FromColumns(aliasMode, aliasValue),
+			retention.FromColumns(domainMode, domainValue),
+		)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating retention policies: %w", err)
+	}
+
+	return policies, nil
+}
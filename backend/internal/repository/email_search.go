@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// snippetMaxBodyLength caps how much of a body ts_headline scans for a snippet
+const snippetMaxBodyLength = 65536
+
+// snippetOptions are the ts_headline options for search snippets
+var snippetOptions = fmt.Sprintf(`StartSel="%s", StopSel="%s", MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=" ... "`,
+	SnippetStartSel, SnippetStopSel)
+
+// searchTextQuery joins the free-text terms of a search into websearch_to_tsquery syntax
+// Every term is quoted so words such as "or" are searched rather than read as operators
+func searchTextQuery(search *EmailSearch) string {
+	var parts []string
+	for _, term := range search.Terms {
+		if term.Op != SearchText {
+			continue
+		}
+		part := `"` + strings.ReplaceAll(term.Value, `"`, " ") + `"`
+		if term.Negated {
+			part = "-" + part
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " ")
+}
+
+// searchTermCondition returns the WHERE condition for a field or state term, appending its arguments
+// Free-text terms return "" as they are matched together through the q query
+func searchTermCondition(term SearchTerm, args []interface{}, argIdx int) (string, []interface{}, int) {
+	switch term.Op {
+	case SearchFrom:
+		args = append(args, likePattern(term.Value))
+		return fmt.Sprintf("(e.sender_address ILIKE $%d OR COALESCE(e.sender_name, '') ILIKE $%d)", argIdx, argIdx), args, argIdx + 1
+	case SearchTo:
+		args = append(args, likePattern(term.Value))
+		return fmt.Sprintf(`(a.full_address ILIKE $%d OR COALESCE(e.headers->>'To', '') ILIKE $%d OR COALESCE(e.headers->>'Cc', '') ILIKE $%d)`,
+			argIdx, argIdx, argIdx), args, argIdx + 1
+	case SearchSubject:
+		// Matches idx_emails_subject_gin
+		args = append(args, term.Value)
+		return fmt.Sprintf("(to_tsvector('english', COALESCE(e.subject, '')) @@ phraseto_tsquery('english', $%d))", argIdx), args, argIdx + 1
+	case SearchHasAttachment:
+		return "EXISTS (SELECT 1 FROM attachments att WHERE att.email_id = e.id)", args, argIdx
+	case SearchUnread:
+		return "(e.is_read = false)", args, argIdx
+	case SearchBefore:
+		args = append(args, term.Time)
+		return fmt.Sprintf("(e.received_at < $%d)", argIdx), args, argIdx + 1
+	case SearchAfter:
+		args = append(args, term.Time)
+		return fmt.Sprintf("(e.received_at >= $%d)", argIdx), args, argIdx + 1
+	case SearchLarger:
+		args = append(args, term.Size)
+		return fmt.Sprintf("(e.size_bytes > $%d)", argIdx), args, argIdx + 1
+	default:
+		return "", args, argIdx
+	}
+}
+
+// snippetColumn returns the select expression for EmailWithPreview.Snippet
+// Snippets highlight the text query in the body, or the subject when the body is empty
+func snippetColumn(hasText bool) string {
+	if !hasText {
+		return "NULL::text"
+	}
+	return fmt.Sprintf("NULLIF(ts_headline('english', left(COALESCE(NULLIF(e.body_text, ''), e.subject, ''), %d), q, '%s'), '')",
+		snippetMaxBodyLength, snippetOptions)
+}
+
+// rankColumn returns the select expression for the text search rank, which orders ranked searches
+func rankColumn(hasText bool) string {
+	if !hasText {
+		return "0::real"
+	}
+	return "ts_rank_cd(e.search_vector, q)"
+}
+
+// likePattern returns an ILIKE pattern matching value anywhere, with wildcards in value escaped
+func likePattern(value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+	return "%" + value + "%"
+}
+
+// escapeSnippet HTML-escapes a ts_headline snippet, keeping only the highlight markers as markup
+func escapeSnippet(snippet string) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(snippet, SnippetStartSel)
+		if start < 0 {
+			break
+		}
+		matchStart := start + len(SnippetStartSel)
+		end := strings.Index(snippet[matchStart:], SnippetStopSel)
+		if end < 0 {
+			break
+		}
+		end += matchStart
+
+		b.WriteString(html.EscapeString(snippet[:start]))
+		b.WriteString(SnippetStartSel)
+		b.WriteString(html.EscapeString(snippet[matchStart:end]))
+		b.WriteString(SnippetStopSel)
+		snippet = snippet[end+len(SnippetStopSel):]
+	}
+	b.WriteString(html.EscapeString(snippet))
+	return b.String()
+}
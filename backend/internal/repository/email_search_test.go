@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"testing"
+)
+
+// TestSearchTextQuery verifies free-text terms are quoted for websearch_to_tsquery and other terms skipped
+func TestSearchTextQuery(t *testing.T) {
+	search := &EmailSearch{Terms: []SearchTerm{
+		{Op: SearchText, Value: "or"},
+		{Op: SearchText, Value: `say "hi"`, Negated: true},
+		{Op: SearchFrom, Value: "alice"},
+	}}
+	if got, want := searchTextQuery(search), `"or" -"say  hi "`; got != want {
+		t.Errorf("searchTextQuery() = %q, want %q", got, want)
+	}
+}
+
+// TestEscapeSnippet verifies snippet text is HTML-escaped while highlight markers are kept
+func TestEscapeSnippet(t *testing.T) {
+	got := escapeSnippet(`<b>Your</b> <mark>code</mark> is & <mark>123</mark> <mark>`)
+	want := `&lt;b&gt;Your&lt;/b&gt; <mark>code</mark> is &amp; <mark>123</mark> &lt;mark&gt;`
+	if got != want {
+		t.Errorf("escapeSnippet() = %q, want %q", got, want)
+	}
+
+	if got := likePattern(`50%_off\`); got != `%50\%\_off\\%` {
+		t.Errorf("likePattern() = %q", got)
+	}
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WokenEmail is a snoozed email returned to the inbox
+type WokenEmail struct {
+	ID            uuid.UUID
+	AliasID       uuid.UUID
+	UserID        uuid.UUID
+	ThreadID      uuid.UUID
+	SenderAddress string
+	Subject       *string
+	SnoozedUntil  time.Time
+}
+
+// SetStarredBatch stars or unstars multiple emails
+// Returns the number of emails updated
+func (r *EmailRepo) SetStarredBatch(ctx context.Context, ids []uuid.UUID, starred bool) (int, error) {
+	return r.updateEmailBatch(ctx, ids, "is_starred = $1", starred)
+}
+
+// SetArchivedBatch archives or unarchives multiple emails
+// Returns the number of emails updated
+func (r *EmailRepo) SetArchivedBatch(ctx context.Context, ids []uuid.UUID, archived bool) (int, error) {
+	return r.updateEmailBatch(ctx, ids, "is_archived = $1", archived)
+}
+
+// SnoozeBatch hides multiple emails until a time, or returns them to the inbox when until is nil
+// Returns the number of emails updated
+func (r *EmailRepo) SnoozeBatch(ctx context.Context, ids []uuid.UUID, until *time.Time) (int, error) {
+	if until != nil {
+		utc := until.UTC()
+		until = &utc
+	}
+	return r.updateEmailBatch(ctx, ids, "snoozed_until = $1", until)
+}
+
+// updateEmailBatch sets a column of multiple emails from a value bound to $1
+func (r *EmailRepo) updateEmailBatch(ctx context.Context, ids []uuid.UUID, set string, value interface{}) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	// Build query with placeholders
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids)+1)
+	args[0] = value
+	for i, id := range ids {
+		placeholders[i] = fmt.Sprintf("$%d", i+2)
+		args[i+1] = id
+	}
+
+	query := fmt.Sprintf("UPDATE emails SET %s WHERE id IN (%s)", set, strings.Join(placeholders, ", "))
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update emails: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// WakeSnoozed returns up to limit emails snoozed until now or earlier to the inbox as unread
+// Woken emails are unarchived too. SKIP LOCKED lets several processes wake emails without waking one twice.
+func (r *EmailRepo) WakeSnoozed(ctx context.Context, now time.Time, limit int) ([]WokenEmail, error) {
+	query := `
+		WITH due AS (
+			SELECT id, snoozed_until
+			FROM emails
+			WHERE snoozed_until IS NOT NULL AND snoozed_until <= $1 AND deleted_at IS NULL
+			ORDER BY snoozed_until, id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE emails e
+		SET snoozed_until = NULL, is_read = false, is_archived = false
+		FROM due, aliases a
+		WHERE e.id = due.id AND a.id = e.alias_id
+		RETURNING e.id, e.alias_id, a.user_id, e.thread_id, e.sender_address, e.subject, due.snoozed_until
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, now.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wake snoozed emails: %w", err)
+	}
+	defer rows.Close()
+
+	var woken []WokenEmail
+	for rows.Next() {
+		var email WokenEmail
+		err := rows.Scan(
+			&email.ID,
+			&email.AliasID,
+			&email.UserID,
+			&email.ThreadID,
+			&email.SenderAddress,
+			&email.Subject,
+			&email.SnoozedUntil,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan woken email: %w", err)
+		}
+		woken = append(woken, email)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating woken emails: %w", err)
+	}
+
+	return woken, nil
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TrashedEmail is an email in the trash due to be permanently deleted
+type TrashedEmail struct {
+	ID        uuid.UUID
+	AliasID   uuid.UUID
+	UserID    uuid.UUID
+	DeletedAt time.Time
+}
+
+// TrashBatch moves multiple emails to the trash
+// Emails already in the trash keep their original deletion time. Returns the number of emails updated
+func (r *EmailRepo) TrashBatch(ctx context.Context, ids []uuid.UUID, deletedAt time.Time) (int, error) {
+	return r.updateEmailBatch(ctx, ids, "deleted_at = COALESCE(deleted_at, $1)", deletedAt.UTC())
+}
+
+// RestoreBatch moves multiple emails out of the trash
+// Returns the number of emails updated
+func (r *EmailRepo) RestoreBatch(ctx context.Context, ids []uuid.UUID) (int, error) {
+	return r.updateEmailBatch(ctx, ids, "deleted_at = $1", nil)
+}
+
+// GetTrashedEmails retrieves up to limit emails trashed at or before a time, oldest first
+// userID restricts the emails to one user's trash; uuid.Nil returns the trash of every user
+func (r *EmailRepo) GetTrashedEmails(ctx context.Context, userID uuid.UUID, before time.Time, limit int) ([]TrashedEmail, error) {
+	query := `
+		SELECT e.id, e.alias_id, a.user_id, e.deleted_at
+		FROM emails e
+		JOIN aliases a ON e.alias_id = a.id
+		WHERE e.deleted_at IS NOT NULL AND e.deleted_at <= $1
+		  AND ($2 = '00000000-0000-0000-0000-000000000000'::uuid OR a.user_id = $2)
+		ORDER BY e.deleted_at, e.id
+		LIMIT $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, before.UTC(), userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trashed emails: %w", err)
+	}
+	defer rows.Close()
+
+	var trashed []TrashedEmail
+	for rows.Next() {
+		var email TrashedEmail
+		if err := rows.Scan(&email.ID, &email.AliasID, &email.UserID, &email.DeletedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan trashed email: %w", err)
+		}
+		trashed = append(trashed, email)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating trashed emails: %w", err)
+	}
+
+	return trashed, nil
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -36,6 +37,16 @@ type FailedLoginAttempt struct {
 	AttemptedAt time.Time `db:"attempted_at"`
 }
 
+// AppPassword represents a per-user app password used by mail clients
+type AppPassword struct {
+	ID           uuid.UUID  `db:"id"`
+	UserID       uuid.UUID  `db:"user_id"`
+	Name         string     `db:"name"`
+	PasswordHash string     `db:"password_hash"`
+	LastUsedAt   *time.Time `db:"last_used_at"`
+	CreatedAt    time.Time  `db:"created_at"`
+}
+
 // Alias represents an email alias in the database
 type Alias struct {
 	ID          uuid.UUID  `db:"id"`
@@ -80,6 +91,7 @@ type ListAliasParams struct {
 	Search   string
 	Sort     string
 	Order    string
+	Cursor   *Cursor // Keyset position; Page is ignored when set
 }
 
 
@@ -93,11 +105,25 @@ type Email struct {
 	BodyHTML      *string           `db:"body_html"`
 	BodyText      *string           `db:"body_text"`
 	Headers       map[string]string `db:"headers"`
+	HeaderList    json.RawMessage   `db:"header_list"` // Ordered [{"name", "value"}] fields
+	Calendar      json.RawMessage   `db:"calendar"`
+	Codes         json.RawMessage   `db:"codes"`
+	BodyCharset   *string           `db:"body_charset"` // Charset the body was decoded from
+	MessageID     *string           `db:"message_id"`
+	ThreadID      uuid.UUID         `db:"thread_id"`
 	SizeBytes     int64             `db:"size_bytes"`
 	IsRead        bool              `db:"is_read"`
+	IsStarred     bool              `db:"is_starred"`
+	IsArchived    bool              `db:"is_archived"`
+	SnoozedUntil  *time.Time        `db:"snoozed_until"` // Hidden until then; nil when not snoozed
+	DeletedAt     *time.Time        `db:"deleted_at"`    // Moved to the trash then; nil when not trashed
 	RawEmail      []byte            `db:"raw_email"`
 	ReceivedAt    time.Time         `db:"received_at"`
 	CreatedAt     time.Time         `db:"created_at"`
+
+	CharsetWarnings json.RawMessage `db:"charset_warnings"` // Unknown charsets and replaced invalid sequences
+	TNEFProperties  json.RawMessage `db:"tnef_properties"`  // Message properties of a winmail.dat part
+	Embedded        json.RawMessage `db:"embedded"`         // Summaries of top-level forwarded messages
 }
 
 // Attachment represents an email attachment metadata in the database
@@ -116,23 +142,97 @@ type Attachment struct {
 	CreatedAt    time.Time `db:"created_at"`
 }
 
+// Label represents a user-defined email label in the database
+type Label struct {
+	ID        uuid.UUID `db:"id"`
+	UserID    uuid.UUID `db:"user_id"`
+	Name      string    `db:"name"`
+	Color     string    `db:"color"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// LabelWithCounts represents a label with the number of emails it is applied to
+type LabelWithCounts struct {
+	Label
+	EmailCount  int `db:"email_count"`
+	UnreadCount int `db:"unread_count"`
+}
+
 // ListEmailParams holds parameters for listing emails
 type ListEmailParams struct {
 	Page           int
 	Limit          int
 	AliasID        *uuid.UUID
-	Search         string
+	EmailID        *uuid.UUID // Restricts the listing to one email, to test whether it matches
+	Search         *EmailSearch
 	FromDate       *time.Time
 	ToDate         *time.Time
 	HasAttachments *bool
 	IsRead         *bool
+	IsStarred      *bool
+	IsArchived     *bool
+	IsSnoozed      *bool // true lists only snoozed emails, false only emails not snoozed
+	InTrash        bool  // true lists only trashed emails, false only emails not trashed
+	LabelID        *uuid.UUID
 	Sort           string
 	Order          string
+	Cursor         *Cursor // Keyset position; Page is ignored when set
+}
+
+// SearchOp identifies what a search term matches
+type SearchOp string
+
+// Search operators
+const (
+	SearchText          SearchOp = "text"           // Subject, sender and bodies, ranked
+	SearchFrom          SearchOp = "from"           // Sender address or name
+	SearchTo            SearchOp = "to"             // Alias address, To or Cc header
+	SearchSubject       SearchOp = "subject"        // Subject words
+	SearchHasAttachment SearchOp = "has:attachment" // At least one attachment
+	SearchUnread        SearchOp = "is:unread"      // Not read yet
+	SearchBefore        SearchOp = "before"         // Received before Time
+	SearchAfter         SearchOp = "after"          // Received at or after Time
+	SearchLarger        SearchOp = "larger"         // Larger than Size bytes
+)
+
+// Markers around matched words in EmailWithPreview.Snippet
+const (
+	SnippetStartSel = "<mark>"
+	SnippetStopSel  = "</mark>"
+)
+
+// SearchTerm is one condition of an email search
+type SearchTerm struct {
+	Op      SearchOp
+	Value   string    // Word or phrase for text, from, to and subject terms
+	Time    time.Time // Day boundary for before and after terms
+	Size    int64     // Bytes for larger terms
+	Negated bool
+}
+
+// EmailSearch is a parsed search query; an email must match every term
+type EmailSearch struct {
+	Terms []SearchTerm
+}
+
+// HasText reports whether the search has free-text terms, which rank results and produce snippets
+func (s *EmailSearch) HasText() bool {
+	if s == nil {
+		return false
+	}
+	for _, term := range s.Terms {
+		if term.Op == SearchText {
+			return true
+		}
+	}
+	return false
 }
 
 // EmailWithPreview represents an email with preview text for list responses
 type EmailWithPreview struct {
 	ID              uuid.UUID  `db:"id" json:"id"`
+	ThreadID        uuid.UUID  `db:"thread_id" json:"thread_id"`
 	AliasID         uuid.UUID  `db:"alias_id" json:"alias_id"`
 	AliasEmail      string     `db:"alias_email" json:"alias_email"`
 	FromAddress     string     `db:"from_address" json:"from_address"`
@@ -144,17 +244,48 @@ type EmailWithPreview struct {
 	AttachmentCount int        `db:"attachment_count" json:"attachment_count"`
 	SizeBytes       int64      `db:"size_bytes" json:"size_bytes"`
 	IsRead          bool       `db:"is_read" json:"is_read"`
+	IsStarred       bool       `db:"is_starred" json:"is_starred"`
+	IsArchived      bool       `db:"is_archived" json:"is_archived"`
+	SnoozedUntil    *time.Time `db:"snoozed_until" json:"snoozed_until,omitempty"`
+	DeletedAt       *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
+	Snippet         *string    `db:"snippet" json:"snippet,omitempty"` // HTML-escaped body excerpt with matches between SnippetStartSel and SnippetStopSel
+}
+
+// ListThreadParams holds parameters for listing threads
+type ListThreadParams struct {
+	Page    int
+	Limit   int
+	AliasID *uuid.UUID
+	IsRead  *bool // false lists threads with unread emails, true lists fully read threads
+}
+
+// ThreadSummary represents a conversation with a preview of its latest email for list responses
+type ThreadSummary struct {
+	ID              uuid.UUID `db:"thread_id" json:"id"`
+	AliasID         uuid.UUID `db:"alias_id" json:"alias_id"`
+	AliasEmail      string    `db:"alias_email" json:"alias_email"`
+	Subject         *string   `db:"subject" json:"subject,omitempty"` // Subject of the first email
+	MessageCount    int       `db:"message_count" json:"message_count"`
+	UnreadCount     int       `db:"unread_count" json:"unread_count"`
+	HasAttachments  bool      `db:"has_attachments" json:"has_attachments"`
+	LastEmailID     uuid.UUID `db:"last_email_id" json:"last_email_id"`
+	LastFromAddress string    `db:"last_from_address" json:"last_from_address"`
+	LastFromName    *string   `db:"last_from_name" json:"last_from_name,omitempty"`
+	LastReceivedAt  time.Time `db:"last_received_at" json:"last_received_at"`
+	PreviewText     string    `db:"preview_text" json:"preview_text"`
 }
 
 // InboxStats represents inbox statistics for a user
 type InboxStats struct {
 	TotalEmails     int               `json:"total_emails"`
 	UnreadEmails    int               `json:"unread_emails"`
-	TotalSizeBytes  int64             `json:"total_size_bytes"`
+	TrashedEmails   int               `json:"trashed_emails"`
+	TotalSizeBytes  int64             `json:"total_size_bytes"` // Includes trashed emails until they are purged
 	EmailsToday     int               `json:"emails_today"`
 	EmailsThisWeek  int               `json:"emails_this_week"`
 	EmailsThisMonth int               `json:"emails_this_month"`
 	EmailsPerAlias  []AliasEmailCount `json:"emails_per_alias"`
+	EmailsPerLabel  []LabelEmailCount `json:"emails_per_label"`
 }
 
 // AliasEmailCount represents email count per alias
@@ -163,3 +294,12 @@ type AliasEmailCount struct {
 	AliasEmail string    `db:"alias_email" json:"alias_email"`
 	Count      int       `db:"count" json:"count"`
 }
+
+// LabelEmailCount represents email counts per label
+type LabelEmailCount struct {
+	LabelID     uuid.UUID `db:"label_id" json:"label_id"`
+	Name        string    `db:"name" json:"name"`
+	Color       string    `db:"color" json:"color"`
+	Count       int       `db:"count" json:"count"`
+	UnreadCount int       `db:"unread_count" json:"unread_count"`
+}
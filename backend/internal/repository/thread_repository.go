@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Thread repository errors
+var (
+	ErrThreadNotFound = errors.New("thread not found")
+)
+
+// ListThreads retrieves a user's conversations, most recently active first
+// Each thread carries message and unread counts and a preview of its latest email
+func (r *EmailRepo) ListThreads(ctx context.Context, userID uuid.UUID, params ListThreadParams) ([]ThreadSummary, int, error) {
+	// Apply defaults
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	if params.Limit < 1 {
+		params.Limit = 20
+	}
+	if params.Limit > 100 {
+		params.Limit = 100
+	}
+
+	// Build the per-thread aggregate - join with aliases to filter by user ownership
+	threadsQuery := `
+		SELECT
+			e.thread_id,
+			COUNT(*) as message_count,
+			COUNT(*) FILTER (WHERE e.is_read = false) as unread_count,
+			MAX(e.received_at) as last_received_at,
+			(array_agg(e.subject ORDER BY e.received_at))[1] as subject
+		FROM emails e
+		JOIN aliases a ON e.alias_id = a.id
+		WHERE a.user_id = $1 AND e.deleted_at IS NULL
+	`
+	args := []interface{}{userID}
+	argIdx := 2
+
+	// Add alias filter
+	if params.AliasID != nil {
+		threadsQuery += fmt.Sprintf(" AND e.alias_id = $%d", argIdx)
+		args = append(args, *params.AliasID)
+		argIdx++
+	}
+
+	threadsQuery += " GROUP BY e.thread_id"
+
+	// Add is_read filter - a thread is unread while any of its emails is
+	if params.IsRead != nil {
+		if *params.IsRead {
+			threadsQuery += " HAVING COUNT(*) FILTER (WHERE e.is_read = false) = 0"
+		} else {
+			threadsQuery += " HAVING COUNT(*) FILTER (WHERE e.is_read = false) > 0"
+		}
+	}
+
+	// Count total threads
+	countQuery := "WITH threads AS (" + threadsQuery + ") SELECT COUNT(*) FROM threads"
+	var totalCount int
+	if err := r.db.GetContext(ctx, &totalCount, countQuery, args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to count threads: %w", err)
+	}
+
+	// Build select query with the latest email of each thread
+	selectQuery := "WITH threads AS (" + threadsQuery + `)
+		SELECT
+			t.thread_id,
+			l.alias_id,
+			a.full_address as alias_email,
+			t.subject,
+			t.message_count,
+			t.unread_count,
+			EXISTS (
+				SELECT 1 FROM attachments att
+				JOIN emails te ON att.email_id = te.id
+				WHERE te.thread_id = t.thread_id AND te.deleted_at IS NULL
+			) as has_attachments,
+			l.id as last_email_id,
+			l.sender_address as last_from_address,
+			l.sender_name as last_from_name,
+			t.last_received_at,
+			COALESCE(l.body_text, '') as body_text
+		FROM threads t
+		JOIN LATERAL (
+			SELECT id, alias_id, sender_address, sender_name, body_text
+			FROM emails
+			WHERE thread_id = t.thread_id AND deleted_at IS NULL
+			ORDER BY received_at DESC
+			LIMIT 1
+		) l ON true
+		JOIN aliases a ON a.id = l.alias_id
+		ORDER BY t.last_received_at DESC, t.thread_id
+	`
+
+	// Add pagination
+	offset := (params.Page - 1) * params.Limit
+	selectQuery += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argIdx, argIdx+1)
+	args = append(args, params.Limit, offset)
+
+	rows, err := r.db.QueryContext(ctx, selectQuery, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query threads: %w", err)
+	}
+	defer rows.Close()
+
+	var threads []ThreadSummary
+	for rows.Next() {
+		var thread ThreadSummary
+		var bodyText string
+		err := rows.Scan(
+			&thread.ID,
+			&thread.AliasID,
+			&thread.AliasEmail,
+			&thread.Subject,
+			&thread.MessageCount,
+			&thread.UnreadCount,
+			&thread.HasAttachments,
+			&thread.LastEmailID,
+			&thread.LastFromAddress,
+			&thread.LastFromName,
+			&thread.LastReceivedAt,
+			&bodyText,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan thread: %w", err)
+		}
+
+		// Generate preview text from the latest email
+		thread.PreviewText = GeneratePreviewText(bodyText, 200)
+
+		threads = append(threads, thread)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating threads: %w", err)
+	}
+
+	return threads, totalCount, nil
+}
+
+// GetThreadOwner returns the user whose alias received the thread
+func (r *EmailRepo) GetThreadOwner(ctx context.Context, threadID uuid.UUID) (uuid.UUID, error) {
+	query := `
+		SELECT a.user_id FROM emails e
+		JOIN aliases a ON e.alias_id = a.id
+		WHERE e.thread_id = $1
+		LIMIT 1
+	`
+
+	var userID uuid.UUID
+	err := r.db.GetContext(ctx, &userID, query, threadID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return uuid.Nil, ErrThreadNotFound
+		}
+		return uuid.Nil, fmt.Errorf("failed to get thread owner: %w", err)
+	}
+
+	return userID, nil
+}
+
+// GetThreadEmails retrieves the emails of a thread in the order they were received
+func (r *EmailRepo) GetThreadEmails(ctx context.Context, threadID uuid.UUID) ([]EmailWithPreview, error) {
+	query := `
+		SELECT
+			e.id,
+			e.thread_id,
+			e.alias_id,
+			a.full_address as alias_email,
+			e.sender_address as from_address,
+			e.sender_name as from_name,
+			e.subject,
+			COALESCE(e.body_text, '') as body_text,
+			e.received_at,
+			e.size_bytes,
+			e.is_read,
+			e.is_starred,
+			e.is_archived,
+			e.snoozed_until,
+			(SELECT COUNT(*) FROM attachments att WHERE att.email_id = e.id) as attachment_count
+		FROM emails e
+		JOIN aliases a ON e.alias_id = a.id
+		WHERE e.thread_id = $1 AND e.deleted_at IS NULL
+		ORDER BY e.received_at ASC, e.id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, threadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query thread emails: %w", err)
+	}
+	defer rows.Close()
+
+	var emails []EmailWithPreview
+	for rows.Next() {
+		var email EmailWithPreview
+		var bodyText string
+		err := rows.Scan(
+			&email.ID,
+			&email.ThreadID,
+			&email.AliasID,
+			&email.AliasEmail,
+			&email.FromAddress,
+			&email.FromName,
+			&email.Subject,
+			&bodyText,
+			&email.ReceivedAt,
+			&email.SizeBytes,
+			&email.IsRead,
+			&email.IsStarred,
+			&email.IsArchived,
+			&email.SnoozedUntil,
+			&email.AttachmentCount,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan email: %w", err)
+		}
+
+		// Generate preview text
+		email.PreviewText = GeneratePreviewText(bodyText, 200)
+		email.HasAttachments = email.AttachmentCount > 0
+
+		emails = append(emails, email)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating thread emails: %w", err)
+	}
+
+	if len(emails) == 0 {
+		return nil, ErrThreadNotFound
+	}
+
+	return emails, nil
+}
+
+// MarkThreadAsRead marks every unread email of a thread as read
+func (r *EmailRepo) MarkThreadAsRead(ctx context.Context, threadID uuid.UUID) (int, error) {
+	query := `UPDATE emails SET is_read = true WHERE thread_id = $1 AND is_read = false AND deleted_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, threadID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark thread as read: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
@@ -0,0 +1,134 @@
+// Package retention provides email retention policies per alias, with domain-level defaults
+// Feature: email-retention
+// Requirements: Keep mail forever, for N hours or N days, or keep only the latest N messages
+package retention
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Policy modes
+const (
+	ModeInherit = "inherit" // Aliases only: use the domain default
+	ModeForever = "forever"
+	ModeHours   = "hours"  // Keep mail for Value hours after it is received
+	ModeDays    = "days"   // Keep mail for Value days after it is received
+	ModeLatest  = "latest" // Keep only the latest Value messages
+)
+
+// Policy limits
+const (
+	MaxHours  = 24 * 365
+	MaxDays   = 10 * 365
+	MaxLatest = 100000
+)
+
+// ErrInvalidPolicy is returned for a policy with an unknown mode or an out of range value
+var ErrInvalidPolicy = errors.New("invalid retention policy")
+
+// Policy is how long the mail of an alias is kept
+type Policy struct {
+	Mode  string `json:"mode"`
+	Value int    `json:"value,omitempty"` // Hours, days or number of messages; unset for forever and inherit
+}
+
+// Forever is the policy of domains without a default
+var Forever = Policy{Mode: ModeForever}
+
+// Validate checks the mode and the range of the value
+// inherit is only valid when allowInherit is set.
+func (p Policy) Validate(allowInherit bool) error {
+	switch p.Mode {
+	case ModeInherit:
+		if !allowInherit {
+			return fmt.Errorf("%w: mode must be forever, hours, days or latest", ErrInvalidPolicy)
+		}
+		fallthrough
+	case ModeForever:
+		if p.Value != 0 {
+			return fmt.Errorf("%w: %s takes no value", ErrInvalidPolicy, p.Mode)
+		}
+	case ModeHours:
+		return validateRange(p, MaxHours)
+	case ModeDays:
+		return validateRange(p, MaxDays)
+	case ModeLatest:
+		return validateRange(p, MaxLatest)
+	default:
+		if allowInherit {
+			return fmt.Errorf("%w: mode must be inherit, forever, hours, days or latest", ErrInvalidPolicy)
+		}
+		return fmt.Errorf("%w: mode must be forever, hours, days or latest", ErrInvalidPolicy)
+	}
+	return nil
+}
+
+// validateRange checks the value of a policy is between 1 and max
+func validateRange(p Policy, max int) error {
+	if p.Value < 1 || p.Value > max {
+		return fmt.Errorf("%w: %s must be between 1 and %d", ErrInvalidPolicy, p.Mode, max)
+	}
+	return nil
+}
+
+// MaxAge returns how long mail is kept, or 0 when the policy does not expire mail by age
+func (p Policy) MaxAge() time.Duration {
+	switch p.Mode {
+	case ModeHours:
+		return time.Duration(p.Value) * time.Hour
+	case ModeDays:
+		return time.Duration(p.Value) * 24 * time.Hour
+	}
+	return 0
+}
+
+// ExpiresAt returns when mail received at receivedAt expires
+// Returns nil when mail is kept forever or only the latest messages are kept, since those have no fixed expiry.
+func (p Policy) ExpiresAt(receivedAt time.Time) *time.Time {
+	maxAge := p.MaxAge()
+	if maxAge == 0 {
+		return nil
+	}
+	expiresAt := receivedAt.Add(maxAge)
+	return &expiresAt
+}
+
+// Effective returns the policy applied to an alias
+// The alias policy wins; without one the domain default applies, and without that mail is kept forever.
+func Effective(alias, domain *Policy) Policy {
+	if alias != nil {
+		return *alias
+	}
+	if domain != nil {
+		return *domain
+	}
+	return Forever
+}
+
+// FromColumns builds a policy from its stored mode and value, or nil when none is stored
+func FromColumns(mode *string, value *int) *Policy {
+	if mode == nil {
+		return nil
+	}
+	policy := &Policy{Mode: *mode}
+	if value != nil {
+		policy.Value = *value
+	}
+	return policy
+}
+
+// Columns returns the stored mode and value of a policy
+// nil and inherit policies are stored as NULL.
+func (p *Policy) Columns() (*string, *int) {
+	if p == nil || p.Mode == ModeInherit {
+		return nil, nil
+	}
+	mode := p.Mode
+	if p.Mode == ModeForever {
+		return &mode, nil
+	}
+	value := p.Value
+	return &mode, &value
+}
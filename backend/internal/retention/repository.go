@@ -0,0 +1,109 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresRepository stores retention policies on the aliases and domains tables
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresRepository creates a new PostgresRepository
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{pool: pool}
+}
+
+// GetAliasRetention returns the policy of an alias with its domain default
+func (r *PostgresRepository) GetAliasRetention(ctx context.Context, aliasID uuid.UUID) (*AliasRetention, error) {
+	var retention AliasRetention
+	var aliasMode, domainMode *string
+	var aliasValue, domainValue *int
+
+	err := r.pool.QueryRow(ctx, `
+		SELECT a.id, a.user_id, a.domain_id, a.retention_mode, a.retention_value, d.retention_mode, d.retention_value
+		FROM aliases a
+		JOIN domains d ON d.id = a.domain_id
+		WHERE a.id = $1
+	`, aliasID).Scan(
+		&retention.AliasID,
+		&retention.UserID,
+		&retention.DomainID,
+		&aliasMode,
+		&aliasValue,
+		&domainMode,
+		&domainValue,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAliasNotFound
+		}
+		return nil, fmt.Errorf("failed to get alias retention: %w", err)
+	}
+
+	retention.Policy = FromColumns(aliasMode, aliasValue)
+	retention.DomainPolicy = FromColumns(domainMode, domainValue)
+	return &retention, nil
+}
+
+// SetAliasRetention stores the policy of an alias
+func (r *PostgresRepository) SetAliasRetention(ctx context.Context, aliasID uuid.UUID, policy *Policy) error {
+	mode, value := policy.Columns()
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE aliases
+		SET retention_mode = $2, retention_value = $3, updated_at = (NOW() AT TIME ZONE 'utc')
+		WHERE id = $1
+	`, aliasID, mode, value)
+	if err != nil {
+		return fmt.Errorf("failed to update alias retention: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrAliasNotFound
+	}
+	return nil
+}
+
+// GetDomainRetention returns the default policy of a domain
+func (r *PostgresRepository) GetDomainRetention(ctx context.Context, domainID uuid.UUID) (*DomainRetention, error) {
+	var retention DomainRetention
+	var mode *string
+	var value *int
+
+	err := r.pool.QueryRow(ctx, `
+		SELECT id, user_id, retention_mode, retention_value
+		FROM domains
+		WHERE id = $1
+	`, domainID).Scan(&retention.DomainID, &retention.UserID, &mode, &value)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrDomainNotFound
+		}
+		return nil, fmt.Errorf("failed to get domain retention: %w", err)
+	}
+
+	retention.Policy = FromColumns(mode, value)
+	return &retention, nil
+}
+
+// SetDomainRetention stores the default policy of a domain
+func (r *PostgresRepository) SetDomainRetention(ctx context.Context, domainID uuid.UUID, policy *Policy) error {
+	mode, value := policy.Columns()
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE domains
+		SET retention_mode = $2, retention_value = $3, updated_at = (NOW() AT TIME ZONE 'utc')
+		WHERE id = $1
+	`, domainID, mode, value)
+	if err != nil {
+		return fmt.Errorf("failed to update domain retention: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrDomainNotFound
+	}
+	return nil
+}
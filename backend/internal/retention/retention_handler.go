@@ -0,0 +1,184 @@
+package retention
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	appctx "github.com/welldanyogia/persistent-temp-mail/backend/internal/context"
+)
+
+// APIResponse represents the standard API response format
+type APIResponse struct {
+	Success   bool        `json:"success"`
+	Data      interface{} `json:"data,omitempty"`
+	Error     *APIError   `json:"error,omitempty"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// APIError represents the error detail in API response
+type APIError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Handler handles HTTP requests for retention policy endpoints
+type Handler struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+// NewHandler creates a new Handler instance
+func NewHandler(service *Service, logger *slog.Logger) *Handler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// GetAlias handles GET /api/v1/aliases/:id/retention
+func (h *Handler) GetAlias(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.service.GetAlias(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, response)
+}
+
+// UpdateAlias handles PUT /api/v1/aliases/:id/retention
+func (h *Handler) UpdateAlias(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req Policy
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "Invalid request body")
+		return
+	}
+
+	response, err := h.service.UpdateAlias(r.Context(), userID, chi.URLParam(r, "id"), req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, response)
+}
+
+// GetDomain handles GET /api/v1/domains/:id/retention
+func (h *Handler) GetDomain(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.service.GetDomain(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, response)
+}
+
+// UpdateDomain handles PUT /api/v1/domains/:id/retention
+func (h *Handler) UpdateDomain(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req Policy
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "Invalid request body")
+		return
+	}
+
+	response, err := h.service.UpdateDomain(r.Context(), userID, chi.URLParam(r, "id"), req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, response)
+}
+
+// parseUserID extracts the authenticated user ID
+func (h *Handler) parseUserID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	userIDStr, ok := appctx.ExtractUserID(r.Context())
+	if !ok {
+		h.writeError(w, http.StatusUnauthorized, CodeAuthTokenInvalid, "Invalid or expired token")
+		return uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		h.writeError(w, http.StatusUnauthorized, CodeAuthTokenInvalid, "Invalid user ID")
+		return uuid.Nil, false
+	}
+
+	return userID, true
+}
+
+// handleError maps service errors to HTTP responses
+func (h *Handler) handleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrAliasNotFound):
+		h.writeError(w, http.StatusNotFound, CodeAliasNotFound, "Alias not found")
+	case errors.Is(err, ErrDomainNotFound):
+		h.writeError(w, http.StatusNotFound, CodeDomainNotFound, "Domain not found")
+	case errors.Is(err, ErrInvalidPolicy):
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, err.Error())
+	default:
+		h.logger.Error("Unexpected retention error", "error", err)
+		h.writeError(w, http.StatusInternalServerError, CodeInternalError, "An unexpected error occurred")
+	}
+}
+
+// writeSuccess writes a successful JSON response
+func (h *Handler) writeSuccess(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := APIResponse{
+		Success:   true,
+		Data:      data,
+		Timestamp: time.Now().UTC(),
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// writeError writes an error JSON response
+func (h *Handler) writeError(w http.ResponseWriter, statusCode int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := APIResponse{
+		Success: false,
+		Error: &APIError{
+			Code:    code,
+			Message: message,
+		},
+		Timestamp: time.Now().UTC(),
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,229 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+)
+
+// Service errors
+var (
+	ErrAliasNotFound  = errors.New("alias not found")
+	ErrDomainNotFound = errors.New("domain not found")
+)
+
+// Error codes for API responses
+const (
+	CodeValidationError  = "VALIDATION_ERROR"
+	CodeAliasNotFound    = "ALIAS_NOT_FOUND"
+	CodeDomainNotFound   = "DOMAIN_NOT_FOUND"
+	CodeInternalError    = "INTERNAL_ERROR"
+	CodeAuthTokenInvalid = "AUTH_TOKEN_INVALID"
+)
+
+// AliasRetention is the stored retention of an alias and of its domain
+type AliasRetention struct {
+	AliasID      uuid.UUID
+	UserID       uuid.UUID
+	DomainID     uuid.UUID
+	Policy       *Policy // nil inherits the domain default
+	DomainPolicy *Policy // nil keeps mail forever
+}
+
+// DomainRetention is the stored default retention of a domain
+type DomainRetention struct {
+	DomainID uuid.UUID
+	UserID   uuid.UUID
+	Policy   *Policy // nil keeps mail forever
+}
+
+// AliasRetentionResponse represents the retention of an alias in API responses
+type AliasRetentionResponse struct {
+	AliasID       string `json:"alias_id"`
+	Policy        Policy `json:"policy"`         // inherit when the alias has no policy of its own
+	DomainDefault Policy `json:"domain_default"` // Policy of the alias's domain
+	Effective     Policy `json:"effective"`      // Policy applied to the alias's mail
+}
+
+// DomainRetentionResponse represents the default retention of a domain in API responses
+type DomainRetentionResponse struct {
+	DomainID string `json:"domain_id"`
+	Policy   Policy `json:"policy"`
+}
+
+// Repository defines retention policy data access
+type Repository interface {
+	// GetAliasRetention returns ErrAliasNotFound when the alias does not exist
+	GetAliasRetention(ctx context.Context, aliasID uuid.UUID) (*AliasRetention, error)
+	// SetAliasRetention stores the policy of an alias; nil inherits the domain default
+	SetAliasRetention(ctx context.Context, aliasID uuid.UUID, policy *Policy) error
+	// GetDomainRetention returns ErrDomainNotFound when the domain does not exist
+	GetDomainRetention(ctx context.Context, domainID uuid.UUID) (*DomainRetention, error)
+	// SetDomainRetention stores the default policy of a domain
+	SetDomainRetention(ctx context.Context, domainID uuid.UUID, policy *Policy) error
+}
+
+// Service handles retention policy business logic
+// The expiry of mail itself is run by the email package's retention scheduler.
+type Service struct {
+	repo   Repository
+	logger *slog.Logger
+}
+
+// ServiceConfig contains configuration for the retention Service
+type ServiceConfig struct {
+	Repository Repository
+	Logger     *slog.Logger
+}
+
+// NewService creates a new retention Service instance
+func NewService(cfg ServiceConfig) *Service {
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+
+	return &Service{
+		repo:   cfg.Repository,
+		logger: cfg.Logger,
+	}
+}
+
+// GetAlias returns the retention of one of the user's aliases
+func (s *Service) GetAlias(ctx context.Context, userID uuid.UUID, aliasID string) (*AliasRetentionResponse, error) {
+	retention, err := s.getOwnedAlias(ctx, userID, aliasID)
+	if err != nil {
+		return nil, err
+	}
+	return toAliasResponse(retention), nil
+}
+
+// UpdateAlias sets the retention of one of the user's aliases
+// The inherit mode removes the alias's policy so the domain default applies.
+func (s *Service) UpdateAlias(ctx context.Context, userID uuid.UUID, aliasID string, policy Policy) (*AliasRetentionResponse, error) {
+	if err := policy.Validate(true); err != nil {
+		return nil, err
+	}
+
+	retention, err := s.getOwnedAlias(ctx, userID, aliasID)
+	if err != nil {
+		return nil, err
+	}
+
+	retention.Policy = &policy
+	if policy.Mode == ModeInherit {
+		retention.Policy = nil
+	}
+	if err := s.repo.SetAliasRetention(ctx, retention.AliasID, retention.Policy); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Alias retention updated",
+		"alias_id", retention.AliasID,
+		"user_id", userID,
+		"mode", policy.Mode,
+		"value", policy.Value,
+	)
+
+	return toAliasResponse(retention), nil
+}
+
+// GetDomain returns the default retention of one of the user's domains
+func (s *Service) GetDomain(ctx context.Context, userID uuid.UUID, domainID string) (*DomainRetentionResponse, error) {
+	retention, err := s.getOwnedDomain(ctx, userID, domainID)
+	if err != nil {
+		return nil, err
+	}
+	return toDomainResponse(retention), nil
+}
+
+// UpdateDomain sets the default retention of one of the user's domains
+// It applies to every alias of the domain without a policy of its own.
+func (s *Service) UpdateDomain(ctx context.Context, userID uuid.UUID, domainID string, policy Policy) (*DomainRetentionResponse, error) {
+	if err := policy.Validate(false); err != nil {
+		return nil, err
+	}
+
+	retention, err := s.getOwnedDomain(ctx, userID, domainID)
+	if err != nil {
+		return nil, err
+	}
+
+	retention.Policy = &policy
+	if err := s.repo.SetDomainRetention(ctx, retention.DomainID, retention.Policy); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Domain retention updated",
+		"domain_id", retention.DomainID,
+		"user_id", userID,
+		"mode", policy.Mode,
+		"value", policy.Value,
+	)
+
+	return toDomainResponse(retention), nil
+}
+
+// getOwnedAlias loads the retention of an alias, hiding aliases of other users as not found
+func (s *Service) getOwnedAlias(ctx context.Context, userID uuid.UUID, aliasID string) (*AliasRetention, error) {
+	id, err := uuid.Parse(aliasID)
+	if err != nil {
+		return nil, ErrAliasNotFound
+	}
+
+	retention, err := s.repo.GetAliasRetention(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrAliasNotFound) {
+			return nil, ErrAliasNotFound
+		}
+		return nil, fmt.Errorf("failed to get alias retention: %w", err)
+	}
+	if retention.UserID != userID {
+		return nil, ErrAliasNotFound
+	}
+	return retention, nil
+}
+
+// getOwnedDomain loads the retention of a domain, hiding domains of other users as not found
+func (s *Service) getOwnedDomain(ctx context.Context, userID uuid.UUID, domainID string) (*DomainRetention, error) {
+	id, err := uuid.Parse(domainID)
+	if err != nil {
+		return nil, ErrDomainNotFound
+	}
+
+	retention, err := s.repo.GetDomainRetention(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrDomainNotFound) {
+			return nil, ErrDomainNotFound
+		}
+		return nil, fmt.Errorf("failed to get domain retention: %w", err)
+	}
+	if retention.UserID != userID {
+		return nil, ErrDomainNotFound
+	}
+	return retention, nil
+}
+
+// toAliasResponse converts the retention of an alias to its response format
+func toAliasResponse(retention *AliasRetention) *AliasRetentionResponse {
+	policy := Policy{Mode: ModeInherit}
+	if retention.Policy != nil {
+		policy = *retention.Policy
+	}
+	return &AliasRetentionResponse{
+		AliasID:       retention.AliasID.String(),
+		Policy:        policy,
+		DomainDefault: Effective(nil, retention.DomainPolicy),
+		Effective:     Effective(retention.Policy, retention.DomainPolicy),
+	}
+}
+
+// toDomainResponse converts the retention of a domain to its response format
+func toDomainResponse(retention *DomainRetention) *DomainRetentionResponse {
+	return &DomainRetentionResponse{
+		DomainID: retention.DomainID.String(),
+		Policy:   Effective(nil, retention.Policy),
+	}
+}
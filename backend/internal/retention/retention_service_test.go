@@ -0,0 +1,241 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"pgregory.net/rapid"
+)
+
+// mockRepository implements Repository for testing
+type mockRepository struct {
+	aliases map[uuid.UUID]*AliasRetention
+	domains map[uuid.UUID]*DomainRetention
+}
+
+func newMockRepository() *mockRepository {
+	return &mockRepository{
+		aliases: make(map[uuid.UUID]*AliasRetention),
+		domains: make(map[uuid.UUID]*DomainRetention),
+	}
+}
+
+func (m *mockRepository) GetAliasRetention(ctx context.Context, aliasID uuid.UUID) (*AliasRetention, error) {
+	a, ok := m.aliases[aliasID]
+	if !ok {
+		return nil, ErrAliasNotFound
+	}
+	copied := *a
+	if d, ok := m.domains[a.DomainID]; ok {
+		copied.DomainPolicy = d.Policy
+	}
+	return &copied, nil
+}
+
+func (m *mockRepository) SetAliasRetention(ctx context.Context, aliasID uuid.UUID, policy *Policy) error {
+	a, ok := m.aliases[aliasID]
+	if !ok {
+		return ErrAliasNotFound
+	}
+	a.Policy = policy
+	return nil
+}
+
+func (m *mockRepository) GetDomainRetention(ctx context.Context, domainID uuid.UUID) (*DomainRetention, error) {
+	d, ok := m.domains[domainID]
+	if !ok {
+		return nil, ErrDomainNotFound
+	}
+	copied := *d
+	return &copied, nil
+}
+
+func (m *mockRepository) SetDomainRetention(ctx context.Context, domainID uuid.UUID, policy *Policy) error {
+	d, ok := m.domains[domainID]
+	if !ok {
+		return ErrDomainNotFound
+	}
+	d.Policy = policy
+	return nil
+}
+
+// addAlias stores an alias of a new domain owned by userID
+func (m *mockRepository) addAlias(userID uuid.UUID) (aliasID, domainID uuid.UUID) {
+	aliasID, domainID = uuid.New(), uuid.New()
+	m.domains[domainID] = &DomainRetention{DomainID: domainID, UserID: userID}
+	m.aliases[aliasID] = &AliasRetention{AliasID: aliasID, UserID: userID, DomainID: domainID}
+	return aliasID, domainID
+}
+
+// TestPolicy_Validate verifies values are accepted only within the range of their mode
+func TestPolicy_Validate(t *testing.T) {
+	limits := map[string]int{ModeHours: MaxHours, ModeDays: MaxDays, ModeLatest: MaxLatest}
+
+	rapid.Check(t, func(t *rapid.T) {
+		mode := rapid.SampledFrom([]string{ModeHours, ModeDays, ModeLatest}).Draw(t, "mode")
+		value := rapid.IntRange(-10, limits[mode]+10).Draw(t, "value")
+
+		err := Policy{Mode: mode, Value: value}.Validate(false)
+		valid := value >= 1 && value <= limits[mode]
+		if valid && err != nil {
+			t.Fatalf("Expected %s %d to be valid, got %v", mode, value, err)
+		}
+		if !valid && !errors.Is(err, ErrInvalidPolicy) {
+			t.Fatalf("Expected ErrInvalidPolicy for %s %d, got %v", mode, value, err)
+		}
+	})
+
+	tests := []struct {
+		name         string
+		policy       Policy
+		allowInherit bool
+		wantErr      bool
+	}{
+		{"forever", Policy{Mode: ModeForever}, false, false},
+		{"forever with value", Policy{Mode: ModeForever, Value: 3}, false, true},
+		{"inherit on alias", Policy{Mode: ModeInherit}, true, false},
+		{"inherit on domain", Policy{Mode: ModeInherit}, false, true},
+		{"inherit with value", Policy{Mode: ModeInherit, Value: 3}, true, true},
+		{"unknown mode", Policy{Mode: "weeks", Value: 3}, true, true},
+		{"empty mode", Policy{}, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate(tt.allowInherit)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestPolicy_ExpiresAt verifies only hours and days policies give mail a fixed expiry
+func TestPolicy_ExpiresAt(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		value := rapid.IntRange(1, MaxHours).Draw(t, "value")
+		receivedAt := time.Unix(rapid.Int64Range(0, 10*365*24*3600).Draw(t, "received"), 0).UTC()
+
+		hours := Policy{Mode: ModeHours, Value: value}.ExpiresAt(receivedAt)
+		if hours == nil || !hours.Equal(receivedAt.Add(time.Duration(value)*time.Hour)) {
+			t.Fatalf("Expected expiry %d hours after %v, got %v", value, receivedAt, hours)
+		}
+
+		days := Policy{Mode: ModeDays, Value: value}.ExpiresAt(receivedAt)
+		if days == nil || !days.Equal(receivedAt.AddDate(0, 0, value)) {
+			t.Fatalf("Expected expiry %d days after %v, got %v", value, receivedAt, days)
+		}
+
+		for _, p := range []Policy{Forever, {Mode: ModeLatest, Value: value}, {}} {
+			if expiresAt := p.ExpiresAt(receivedAt); expiresAt != nil {
+				t.Fatalf("Expected no expiry for %+v, got %v", p, expiresAt)
+			}
+		}
+	})
+}
+
+// TestPolicy_Columns verifies policies survive a round trip through their stored columns
+func TestPolicy_Columns(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		mode := rapid.SampledFrom([]string{ModeForever, ModeHours, ModeDays, ModeLatest}).Draw(t, "mode")
+		policy := Policy{Mode: mode}
+		if mode != ModeForever {
+			policy.Value = rapid.IntRange(1, MaxHours).Draw(t, "value")
+		}
+
+		restored := FromColumns(policy.Columns())
+		if restored == nil || *restored != policy {
+			t.Fatalf("Expected %+v after round trip, got %+v", policy, restored)
+		}
+	})
+
+	if mode, value := (&Policy{Mode: ModeInherit}).Columns(); mode != nil || value != nil {
+		t.Error("Expected inherit to be stored as NULL")
+	}
+	if FromColumns(nil, nil) != nil {
+		t.Error("Expected no policy from NULL columns")
+	}
+}
+
+// TestEffective verifies the alias policy wins over the domain default, which wins over forever
+func TestEffective(t *testing.T) {
+	alias := &Policy{Mode: ModeLatest, Value: 10}
+	domain := &Policy{Mode: ModeDays, Value: 7}
+
+	if got := Effective(alias, domain); got != *alias {
+		t.Errorf("Expected alias policy, got %+v", got)
+	}
+	if got := Effective(nil, domain); got != *domain {
+		t.Errorf("Expected domain default, got %+v", got)
+	}
+	if got := Effective(nil, nil); got != Forever {
+		t.Errorf("Expected forever, got %+v", got)
+	}
+}
+
+// TestUpdateAlias verifies alias policies are stored, and inherit falls back to the domain default
+func TestUpdateAlias(t *testing.T) {
+	ctx := context.Background()
+	repo := newMockRepository()
+	service := NewService(ServiceConfig{Repository: repo})
+	userID := uuid.New()
+	aliasID, domainID := repo.addAlias(userID)
+
+	if _, err := service.UpdateDomain(ctx, userID, domainID.String(), Policy{Mode: ModeDays, Value: 7}); err != nil {
+		t.Fatalf("UpdateDomain failed: %v", err)
+	}
+
+	response, err := service.UpdateAlias(ctx, userID, aliasID.String(), Policy{Mode: ModeHours, Value: 12})
+	if err != nil {
+		t.Fatalf("UpdateAlias failed: %v", err)
+	}
+	if response.Effective != (Policy{Mode: ModeHours, Value: 12}) {
+		t.Errorf("Expected alias policy to apply, got %+v", response.Effective)
+	}
+
+	response, err = service.UpdateAlias(ctx, userID, aliasID.String(), Policy{Mode: ModeInherit})
+	if err != nil {
+		t.Fatalf("UpdateAlias failed: %v", err)
+	}
+	if repo.aliases[aliasID].Policy != nil {
+		t.Error("Expected inherit to remove the alias policy")
+	}
+	if response.Policy.Mode != ModeInherit || response.Effective != (Policy{Mode: ModeDays, Value: 7}) {
+		t.Errorf("Expected inherited domain default, got %+v", response)
+	}
+
+	if _, err := service.UpdateAlias(ctx, userID, aliasID.String(), Policy{Mode: ModeDays}); !errors.Is(err, ErrInvalidPolicy) {
+		t.Errorf("Expected ErrInvalidPolicy, got %v", err)
+	}
+}
+
+// TestRetentionOwnership verifies users cannot see or change the retention of other users' aliases and domains
+func TestRetentionOwnership(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		ctx := context.Background()
+		repo := newMockRepository()
+		service := NewService(ServiceConfig{Repository: repo})
+		aliasID, domainID := repo.addAlias(uuid.New())
+		otherID := uuid.New()
+		policy := Policy{Mode: ModeLatest, Value: rapid.IntRange(1, MaxLatest).Draw(t, "value")}
+
+		if _, err := service.GetAlias(ctx, otherID, aliasID.String()); !errors.Is(err, ErrAliasNotFound) {
+			t.Fatalf("Expected ErrAliasNotFound, got %v", err)
+		}
+		if _, err := service.UpdateAlias(ctx, otherID, aliasID.String(), policy); !errors.Is(err, ErrAliasNotFound) {
+			t.Fatalf("Expected ErrAliasNotFound, got %v", err)
+		}
+		if _, err := service.GetDomain(ctx, otherID, domainID.String()); !errors.Is(err, ErrDomainNotFound) {
+			t.Fatalf("Expected ErrDomainNotFound, got %v", err)
+		}
+		if _, err := service.UpdateDomain(ctx, otherID, domainID.String(), policy); !errors.Is(err, ErrDomainNotFound) {
+			t.Fatalf("Expected ErrDomainNotFound, got %v", err)
+		}
+		if repo.aliases[aliasID].Policy != nil || repo.domains[domainID].Policy != nil {
+			t.Fatal("Expected policies of other users to be unchanged")
+		}
+	})
+}
@@ -0,0 +1,31 @@
+package retention
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes registers retention policy routes with the Chi router
+// All routes require authentication via auth middleware
+func RegisterRoutes(r chi.Router, handler *Handler, authMiddleware func(next http.Handler) http.Handler) {
+	r.Route("/aliases/{id}/retention", func(r chi.Router) {
+		r.Use(authMiddleware)
+
+		// GET /api/v1/aliases/:id/retention - Get the alias policy, its domain default and the effective policy
+		r.Get("/", handler.GetAlias)
+
+		// PUT /api/v1/aliases/:id/retention - Set the alias policy (mode inherit uses the domain default)
+		r.Put("/", handler.UpdateAlias)
+	})
+
+	r.Route("/domains/{id}/retention", func(r chi.Router) {
+		r.Use(authMiddleware)
+
+		// GET /api/v1/domains/:id/retention - Get the default policy of the domain's aliases
+		r.Get("/", handler.GetDomain)
+
+		// PUT /api/v1/domains/:id/retention - Set the default policy of the domain's aliases
+		r.Put("/", handler.UpdateDomain)
+	})
+}
@@ -0,0 +1,149 @@
+package savedsearch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// savedSearchColumns are the columns scanned by scanSavedSearch, in order
+const savedSearchColumns = `id, user_id, name, query, params, is_pinned, created_at, updated_at`
+
+// PostgresRepository stores saved searches in PostgreSQL
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresRepository creates a new PostgresRepository
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{pool: pool}
+}
+
+// CountByUser returns the number of saved searches a user has
+func (r *PostgresRepository) CountByUser(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM saved_searches WHERE user_id = $1`, userID).Scan(&count)
+	return count, err
+}
+
+// Create stores a saved search
+func (r *PostgresRepository) Create(ctx context.Context, search *SavedSearch) error {
+	paramsJSON, err := json.Marshal(search.Params)
+	if err != nil {
+		return fmt.Errorf("failed to encode saved search params: %w", err)
+	}
+
+	err = r.pool.QueryRow(ctx, `
+		INSERT INTO saved_searches (id, user_id, name, query, params, is_pinned)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING created_at, updated_at
+	`, search.ID, search.UserID, search.Name, search.Query, paramsJSON, search.IsPinned).Scan(&search.CreatedAt, &search.UpdatedAt)
+	if err != nil {
+		// Check for unique constraint violation (name already used by the user)
+		if strings.Contains(err.Error(), "idx_saved_searches_user_name") {
+			return ErrSavedSearchExists
+		}
+		return fmt.Errorf("failed to insert saved search: %w", err)
+	}
+	return nil
+}
+
+// GetByID returns a saved search
+func (r *PostgresRepository) GetByID(ctx context.Context, id uuid.UUID) (*SavedSearch, error) {
+	search, err := scanSavedSearch(r.pool.QueryRow(ctx, `
+		SELECT `+savedSearchColumns+`
+		FROM saved_searches
+		WHERE id = $1
+	`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrSavedSearchNotFound
+		}
+		return nil, err
+	}
+	return search, nil
+}
+
+// ListByUser returns a user's saved searches, pinned first, then by name
+func (r *PostgresRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]SavedSearch, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+savedSearchColumns+`
+		FROM saved_searches
+		WHERE user_id = $1
+		ORDER BY is_pinned DESC, LOWER(name), id
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list saved searches: %w", err)
+	}
+	defer rows.Close()
+
+	searches := make([]SavedSearch, 0)
+	for rows.Next() {
+		search, err := scanSavedSearch(rows)
+		if err != nil {
+			return nil, err
+		}
+		searches = append(searches, *search)
+	}
+	return searches, rows.Err()
+}
+
+// Update saves a saved search's name, query, params and pin
+func (r *PostgresRepository) Update(ctx context.Context, search *SavedSearch) error {
+	paramsJSON, err := json.Marshal(search.Params)
+	if err != nil {
+		return fmt.Errorf("failed to encode saved search params: %w", err)
+	}
+
+	err = r.pool.QueryRow(ctx, `
+		UPDATE saved_searches
+		SET name = $2, query = $3, params = $4, is_pinned = $5, updated_at = (NOW() AT TIME ZONE 'utc')
+		WHERE id = $1
+		RETURNING updated_at
+	`, search.ID, search.Name, search.Query, paramsJSON, search.IsPinned).Scan(&search.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrSavedSearchNotFound
+		}
+		if strings.Contains(err.Error(), "idx_saved_searches_user_name") {
+			return ErrSavedSearchExists
+		}
+		return fmt.Errorf("failed to update saved search: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes a saved search
+func (r *PostgresRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.pool.Exec(ctx, `DELETE FROM saved_searches WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete saved search: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrSavedSearchNotFound
+	}
+	return nil
+}
+
+// scanSavedSearch scans a row selected with savedSearchColumns
+func scanSavedSearch(row pgx.Row) (*SavedSearch, error) {
+	var search SavedSearch
+	var paramsJSON []byte
+	err := row.Scan(
+		&search.ID, &search.UserID, &search.Name, &search.Query, &paramsJSON, &search.IsPinned,
+		&search.CreatedAt, &search.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(paramsJSON, &search.Params); err != nil {
+		return nil, fmt.Errorf("failed to decode saved search params: %w", err)
+	}
+	return &search, nil
+}
@@ -0,0 +1,39 @@
+package savedsearch
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes registers saved search routes with the Chi router
+// All routes require authentication via auth middleware
+func RegisterRoutes(r chi.Router, handler *Handler, authMiddleware func(next http.Handler) http.Handler) {
+	r.Route("/saved-searches", func(r chi.Router) {
+		r.Use(authMiddleware)
+
+		// POST /api/v1/saved-searches - Create a saved search (name, query, params, pinned)
+		r.Post("/", handler.Create)
+
+		// GET /api/v1/saved-searches - List saved searches, pinned first with unread counts
+		r.Get("/", handler.List)
+
+		// GET /api/v1/saved-searches/counts - Total and unread counts of every saved search
+		r.Get("/counts", handler.Counts)
+
+		// GET /api/v1/saved-searches/:id - Get a saved search
+		r.Get("/{id}", handler.Get)
+
+		// PATCH /api/v1/saved-searches/:id - Rename, pin or change the filters of a saved search
+		r.Patch("/{id}", handler.Update)
+
+		// DELETE /api/v1/saved-searches/:id - Delete a saved search, keeping its emails
+		r.Delete("/{id}", handler.Delete)
+
+		// GET /api/v1/saved-searches/:id/emails - List the emails matching a saved search (page, limit, cursor)
+		r.Get("/{id}/emails", handler.Execute)
+
+		// GET /api/v1/saved-searches/:id/count - Total and unread counts of a saved search
+		r.Get("/{id}/count", handler.Count)
+	})
+}
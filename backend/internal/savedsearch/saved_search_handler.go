@@ -0,0 +1,268 @@
+package savedsearch
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	appctx "github.com/welldanyogia/persistent-temp-mail/backend/internal/context"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/email"
+)
+
+// APIResponse represents the standard API response format
+type APIResponse struct {
+	Success   bool        `json:"success"`
+	Data      interface{} `json:"data,omitempty"`
+	Error     *APIError   `json:"error,omitempty"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// APIError represents the error detail in API response
+type APIError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Handler handles HTTP requests for saved search endpoints
+type Handler struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+// NewHandler creates a new Handler instance
+func NewHandler(service *Service, logger *slog.Logger) *Handler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Create handles POST /api/v1/saved-searches
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req CreateSavedSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "Invalid request body")
+		return
+	}
+
+	response, err := h.service.Create(r.Context(), userID, req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusCreated, response)
+}
+
+// List handles GET /api/v1/saved-searches
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.service.List(r.Context(), userID)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, response)
+}
+
+// Counts handles GET /api/v1/saved-searches/counts
+func (h *Handler) Counts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.service.Counts(r.Context(), userID)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, response)
+}
+
+// Get handles GET /api/v1/saved-searches/:id
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.service.Get(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, response)
+}
+
+// Update handles PATCH /api/v1/saved-searches/:id
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateSavedSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "Invalid request body")
+		return
+	}
+
+	response, err := h.service.Update(r.Context(), userID, chi.URLParam(r, "id"), req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, response)
+}
+
+// Delete handles DELETE /api/v1/saved-searches/:id
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.service.Delete(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, response)
+}
+
+// Execute handles GET /api/v1/saved-searches/:id/emails
+func (h *Handler) Execute(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	// Parse pagination; the email service applies the defaults and the max limit
+	var page ExecuteParams
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		if n, err := strconv.Atoi(pageStr); err == nil && n > 0 {
+			page.Page = n
+		}
+	}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if n, err := strconv.Atoi(limitStr); err == nil && n > 0 {
+			page.Limit = n
+		}
+	}
+	page.Cursor = r.URL.Query().Get("cursor")
+
+	response, err := h.service.Execute(r.Context(), userID, chi.URLParam(r, "id"), page)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, response)
+}
+
+// Count handles GET /api/v1/saved-searches/:id/count
+func (h *Handler) Count(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.service.Count(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, response)
+}
+
+// parseUserID extracts the authenticated user ID
+func (h *Handler) parseUserID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	userIDStr, ok := appctx.ExtractUserID(r.Context())
+	if !ok {
+		h.writeError(w, http.StatusUnauthorized, CodeAuthTokenInvalid, "Invalid or expired token")
+		return uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		h.writeError(w, http.StatusUnauthorized, CodeAuthTokenInvalid, "Invalid user ID")
+		return uuid.Nil, false
+	}
+
+	return userID, true
+}
+
+// handleError maps service errors to HTTP responses
+func (h *Handler) handleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrSavedSearchNotFound):
+		h.writeError(w, http.StatusNotFound, CodeSavedSearchNotFound, "Saved search not found")
+	case errors.Is(err, ErrSavedSearchExists):
+		h.writeError(w, http.StatusConflict, CodeSavedSearchExists, "A saved search with this name already exists")
+	case errors.Is(err, ErrSavedSearchLimitReached):
+		h.writeError(w, http.StatusForbidden, CodeSavedSearchLimitReached, "Maximum number of saved searches reached")
+	case errors.Is(err, ErrInvalidName), errors.Is(err, ErrInvalidSavedSearch):
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, err.Error())
+	case errors.Is(err, email.ErrInvalidCursor):
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "Invalid or expired cursor for this sort")
+	default:
+		h.logger.Error("Unexpected saved search error", "error", err)
+		h.writeError(w, http.StatusInternalServerError, CodeInternalError, "An unexpected error occurred")
+	}
+}
+
+// writeSuccess writes a successful JSON response
+func (h *Handler) writeSuccess(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := APIResponse{
+		Success:   true,
+		Data:      data,
+		Timestamp: time.Now().UTC(),
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// writeError writes an error JSON response
+func (h *Handler) writeError(w http.ResponseWriter, statusCode int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := APIResponse{
+		Success: false,
+		Error: &APIError{
+			Code:    code,
+			Message: message,
+		},
+		Timestamp: time.Now().UTC(),
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
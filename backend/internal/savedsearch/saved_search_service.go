@@ -0,0 +1,452 @@
+// Package savedsearch provides named email filters that users run on demand and pin with unread counts
+// Feature: saved-searches
+// Requirements: A saved search has a name plus email list filters or a search query, and is unique per user by name
+package savedsearch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/email"
+)
+
+const (
+	// DefaultSavedSearchLimit is the default max saved searches per user
+	DefaultSavedSearchLimit = 50
+	// MaxNameLength is the maximum saved search name length in characters
+	MaxNameLength = 50
+)
+
+// Service errors
+var (
+	ErrSavedSearchNotFound     = errors.New("saved search not found")
+	ErrSavedSearchExists       = errors.New("saved search already exists")
+	ErrSavedSearchLimitReached = errors.New("saved search limit reached")
+	ErrInvalidName             = errors.New("saved search name must be 1 to 50 characters without control characters")
+	ErrInvalidSavedSearch      = errors.New("invalid saved search")
+)
+
+// Error codes for API responses
+const (
+	CodeValidationError         = "VALIDATION_ERROR"
+	CodeSavedSearchNotFound     = "SAVED_SEARCH_NOT_FOUND"
+	CodeSavedSearchExists       = "SAVED_SEARCH_EXISTS"
+	CodeSavedSearchLimitReached = "SAVED_SEARCH_LIMIT_REACHED"
+	CodeInternalError           = "INTERNAL_ERROR"
+	CodeAuthTokenInvalid        = "AUTH_TOKEN_INVALID"
+)
+
+// SavedSearch is a stored saved search
+type SavedSearch struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Name      string
+	Query     string                // Search query, combined with Params.Search
+	Params    email.ListEmailParams // Filters; page, limit and cursor are never stored
+	IsPinned  bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// CreateSavedSearchRequest represents the request to create a saved search
+type CreateSavedSearchRequest struct {
+	Name   string                 `json:"name"`
+	Query  string                 `json:"query,omitempty"`
+	Params *email.ListEmailParams `json:"params,omitempty"`
+	Pinned bool                   `json:"pinned"`
+}
+
+// UpdateSavedSearchRequest represents the request to change a saved search
+type UpdateSavedSearchRequest struct {
+	Name   *string                `json:"name,omitempty"`
+	Query  *string                `json:"query,omitempty"`
+	Params *email.ListEmailParams `json:"params,omitempty"` // Replaces all stored filters
+	Pinned *bool                  `json:"pinned,omitempty"`
+}
+
+// ExecuteParams selects the page of a saved search's emails
+type ExecuteParams struct {
+	Page   int
+	Limit  int
+	Cursor string // next_cursor or prev_cursor of a previous page; Page is ignored when set
+}
+
+// SavedSearchResponse represents a saved search in API responses
+type SavedSearchResponse struct {
+	ID          string                `json:"id"`
+	Name        string                `json:"name"`
+	Query       string                `json:"query"`
+	Params      email.ListEmailParams `json:"params"`
+	Pinned      bool                  `json:"pinned"`
+	UnreadCount *int                  `json:"unread_count,omitempty"` // Set for pinned saved searches in lists
+	CreatedAt   time.Time             `json:"created_at"`
+	UpdatedAt   time.Time             `json:"updated_at"`
+}
+
+// SavedSearchListResponse represents all saved searches of a user, pinned first, then by name
+type SavedSearchListResponse struct {
+	SavedSearches []SavedSearchResponse `json:"saved_searches"`
+}
+
+// SavedSearchCountResponse represents how many emails a saved search matches
+type SavedSearchCountResponse struct {
+	ID          string `json:"id"`
+	TotalCount  int    `json:"total_count"`
+	UnreadCount int    `json:"unread_count"`
+}
+
+// SavedSearchCountsResponse represents the email counts of all saved searches of a user
+type SavedSearchCountsResponse struct {
+	Counts []SavedSearchCountResponse `json:"counts"`
+}
+
+// DeleteSavedSearchResponse represents the response after deleting a saved search
+type DeleteSavedSearchResponse struct {
+	Message       string `json:"message"`
+	SavedSearchID string `json:"saved_search_id"`
+}
+
+// Repository defines saved search data access
+type Repository interface {
+	CountByUser(ctx context.Context, userID uuid.UUID) (int, error)
+	// Create stores a saved search, or returns ErrSavedSearchExists when the user has one with the same name
+	Create(ctx context.Context, search *SavedSearch) error
+	GetByID(ctx context.Context, id uuid.UUID) (*SavedSearch, error)
+	// ListByUser returns a user's saved searches, pinned first, then by name
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]SavedSearch, error)
+	// Update saves the name, query, params and pin, or returns ErrSavedSearchExists when the new name is taken
+	Update(ctx context.Context, search *SavedSearch) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// EmailSearcher runs email listings; implemented by the email Service
+type EmailSearcher interface {
+	List(ctx context.Context, userID uuid.UUID, params email.ListEmailParams) (*email.EmailListResponse, error)
+	Count(ctx context.Context, userID uuid.UUID, params email.ListEmailParams) (*email.EmailCountResponse, error)
+	Matches(ctx context.Context, userID, emailID uuid.UUID, params email.ListEmailParams) (bool, error)
+}
+
+// Service handles saved search business logic
+type Service struct {
+	repo        Repository
+	emails      EmailSearcher
+	searchLimit int
+	logger      *slog.Logger
+}
+
+// ServiceConfig contains configuration for the saved search Service
+type ServiceConfig struct {
+	Repository  Repository
+	Emails      EmailSearcher
+	SearchLimit int // Max saved searches per user (default: 50)
+	Logger      *slog.Logger
+}
+
+// NewService creates a new saved search Service instance
+func NewService(cfg ServiceConfig) *Service {
+	if cfg.SearchLimit <= 0 {
+		cfg.SearchLimit = DefaultSavedSearchLimit
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+
+	return &Service{
+		repo:        cfg.Repository,
+		emails:      cfg.Emails,
+		searchLimit: cfg.SearchLimit,
+		logger:      cfg.Logger,
+	}
+}
+
+// Create creates a saved search for the user
+func (s *Service) Create(ctx context.Context, userID uuid.UUID, req CreateSavedSearchRequest) (*SavedSearchResponse, error) {
+	search := &SavedSearch{
+		ID:       uuid.New(),
+		UserID:   userID,
+		Name:     req.Name,
+		Query:    req.Query,
+		IsPinned: req.Pinned,
+	}
+	if req.Params != nil {
+		search.Params = *req.Params
+	}
+	if err := normalize(search); err != nil {
+		return nil, err
+	}
+
+	count, err := s.repo.CountByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count saved searches: %w", err)
+	}
+	if count >= s.searchLimit {
+		return nil, ErrSavedSearchLimitReached
+	}
+
+	if err := s.repo.Create(ctx, search); err != nil {
+		if errors.Is(err, ErrSavedSearchExists) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to create saved search: %w", err)
+	}
+
+	s.logger.Info("Saved search created", "saved_search_id", search.ID, "user_id", userID)
+
+	response := toSavedSearchResponse(search)
+	return &response, nil
+}
+
+// List returns all saved searches of the user, pinned first, then by name
+// Pinned saved searches include their unread count.
+func (s *Service) List(ctx context.Context, userID uuid.UUID) (*SavedSearchListResponse, error) {
+	searches, err := s.repo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list saved searches: %w", err)
+	}
+
+	response := &SavedSearchListResponse{SavedSearches: make([]SavedSearchResponse, len(searches))}
+	for i := range searches {
+		response.SavedSearches[i] = toSavedSearchResponse(&searches[i])
+		if !searches[i].IsPinned {
+			continue
+		}
+		counts, err := s.emails.Count(ctx, userID, searchParams(&searches[i]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to count saved search emails: %w", err)
+		}
+		response.SavedSearches[i].UnreadCount = &counts.UnreadCount
+	}
+	return response, nil
+}
+
+// Get returns a saved search of the user
+func (s *Service) Get(ctx context.Context, userID uuid.UUID, searchID string) (*SavedSearchResponse, error) {
+	search, err := s.getOwnedSearch(ctx, userID, searchID)
+	if err != nil {
+		return nil, err
+	}
+	response := toSavedSearchResponse(search)
+	return &response, nil
+}
+
+// Update renames, pins or changes the filters of a saved search of the user
+func (s *Service) Update(ctx context.Context, userID uuid.UUID, searchID string, req UpdateSavedSearchRequest) (*SavedSearchResponse, error) {
+	search, err := s.getOwnedSearch(ctx, userID, searchID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		search.Name = *req.Name
+	}
+	if req.Query != nil {
+		search.Query = *req.Query
+	}
+	if req.Params != nil {
+		search.Params = *req.Params
+	}
+	if req.Pinned != nil {
+		search.IsPinned = *req.Pinned
+	}
+	if err := normalize(search); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(ctx, search); err != nil {
+		if errors.Is(err, ErrSavedSearchExists) || errors.Is(err, ErrSavedSearchNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to update saved search: %w", err)
+	}
+
+	s.logger.Info("Saved search updated", "saved_search_id", search.ID, "user_id", userID)
+
+	response := toSavedSearchResponse(search)
+	return &response, nil
+}
+
+// Delete deletes a saved search of the user; its emails are kept
+func (s *Service) Delete(ctx context.Context, userID uuid.UUID, searchID string) (*DeleteSavedSearchResponse, error) {
+	search, err := s.getOwnedSearch(ctx, userID, searchID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Delete(ctx, search.ID); err != nil {
+		if errors.Is(err, ErrSavedSearchNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to delete saved search: %w", err)
+	}
+
+	s.logger.Info("Saved search deleted", "saved_search_id", search.ID, "user_id", userID)
+
+	return &DeleteSavedSearchResponse{
+		Message:       "Saved search deleted successfully",
+		SavedSearchID: search.ID.String(),
+	}, nil
+}
+
+// Execute lists the emails matching a saved search of the user
+func (s *Service) Execute(ctx context.Context, userID uuid.UUID, searchID string, page ExecuteParams) (*email.EmailListResponse, error) {
+	search, err := s.getOwnedSearch(ctx, userID, searchID)
+	if err != nil {
+		return nil, err
+	}
+
+	params := searchParams(search)
+	params.Page = page.Page
+	params.Limit = page.Limit
+	params.Cursor = page.Cursor
+	return s.emails.List(ctx, userID, params)
+}
+
+// Count returns how many emails match a saved search of the user, and how many of them are unread
+func (s *Service) Count(ctx context.Context, userID uuid.UUID, searchID string) (*SavedSearchCountResponse, error) {
+	search, err := s.getOwnedSearch(ctx, userID, searchID)
+	if err != nil {
+		return nil, err
+	}
+	return s.count(ctx, search)
+}
+
+// Counts returns the email counts of every saved search of the user
+func (s *Service) Counts(ctx context.Context, userID uuid.UUID) (*SavedSearchCountsResponse, error) {
+	searches, err := s.repo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list saved searches: %w", err)
+	}
+
+	response := &SavedSearchCountsResponse{Counts: make([]SavedSearchCountResponse, len(searches))}
+	for i := range searches {
+		counts, err := s.count(ctx, &searches[i])
+		if err != nil {
+			return nil, err
+		}
+		response.Counts[i] = *counts
+	}
+	return response, nil
+}
+
+// Match returns the IDs of the user's saved searches that list an email
+// Saved searches that fail to run are skipped, so one bad search does not hide the others.
+func (s *Service) Match(ctx context.Context, userID, emailID uuid.UUID) ([]string, error) {
+	searches, err := s.repo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list saved searches: %w", err)
+	}
+
+	matched := make([]string, 0)
+	for i := range searches {
+		ok, err := s.emails.Matches(ctx, userID, emailID, searchParams(&searches[i]))
+		if err != nil {
+			s.logger.Warn("Failed to match saved search", "saved_search_id", searches[i].ID, "email_id", emailID, "error", err)
+			continue
+		}
+		if ok {
+			matched = append(matched, searches[i].ID.String())
+		}
+	}
+	return matched, nil
+}
+
+// count counts the emails of a saved search
+func (s *Service) count(ctx context.Context, search *SavedSearch) (*SavedSearchCountResponse, error) {
+	counts, err := s.emails.Count(ctx, search.UserID, searchParams(search))
+	if err != nil {
+		return nil, fmt.Errorf("failed to count saved search emails: %w", err)
+	}
+	return &SavedSearchCountResponse{
+		ID:          search.ID.String(),
+		TotalCount:  counts.TotalCount,
+		UnreadCount: counts.UnreadCount,
+	}, nil
+}
+
+// getOwnedSearch returns a saved search, hiding saved searches of other users as not found
+func (s *Service) getOwnedSearch(ctx context.Context, userID uuid.UUID, searchID string) (*SavedSearch, error) {
+	id, err := uuid.Parse(searchID)
+	if err != nil {
+		return nil, ErrSavedSearchNotFound
+	}
+
+	search, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrSavedSearchNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get saved search: %w", err)
+	}
+	if search.UserID != userID {
+		return nil, ErrSavedSearchNotFound
+	}
+	return search, nil
+}
+
+// searchParams returns the email list params of a saved search, with its query added to the params search
+func searchParams(search *SavedSearch) email.ListEmailParams {
+	params := search.Params
+	if search.Query != "" {
+		params.Search = strings.TrimSpace(params.Search + " " + search.Query)
+	}
+	return params
+}
+
+// normalize trims the name and query of a saved search, drops paging from its params and validates it
+func normalize(search *SavedSearch) error {
+	search.Name = strings.TrimSpace(search.Name)
+	if search.Name == "" || utf8.RuneCountInString(search.Name) > MaxNameLength || !utf8.ValidString(search.Name) {
+		return ErrInvalidName
+	}
+	if strings.IndexFunc(search.Name, unicode.IsControl) >= 0 {
+		return ErrInvalidName
+	}
+
+	search.Query = strings.TrimSpace(search.Query)
+	search.Params.Page = 0
+	search.Params.Limit = 0
+	search.Params.Cursor = ""
+
+	params := &search.Params
+	for _, query := range []string{search.Query, params.Search} {
+		if len(query) > email.MaxSearchLength {
+			return fmt.Errorf("%w: search query is too long", ErrInvalidSavedSearch)
+		}
+		if _, err := email.ParseSearch(query); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSavedSearch, err)
+		}
+	}
+	if _, err := uuid.Parse(params.AliasID); params.AliasID != "" && err != nil {
+		return fmt.Errorf("%w: alias_id must be a UUID", ErrInvalidSavedSearch)
+	}
+	if _, err := uuid.Parse(params.LabelID); params.LabelID != "" && err != nil {
+		return fmt.Errorf("%w: label_id must be a UUID", ErrInvalidSavedSearch)
+	}
+	if params.Sort != "" && params.Sort != "received_at" && params.Sort != "size" {
+		return fmt.Errorf("%w: sort must be received_at or size", ErrInvalidSavedSearch)
+	}
+	if params.Order != "" && params.Order != "asc" && params.Order != "desc" {
+		return fmt.Errorf("%w: order must be asc or desc", ErrInvalidSavedSearch)
+	}
+	return nil
+}
+
+// toSavedSearchResponse converts a saved search to its API representation
+func toSavedSearchResponse(search *SavedSearch) SavedSearchResponse {
+	return SavedSearchResponse{
+		ID:        search.ID.String(),
+		Name:      search.Name,
+		Query:     search.Query,
+		Params:    search.Params,
+		Pinned:    search.IsPinned,
+		CreatedAt: search.CreatedAt,
+		UpdatedAt: search.UpdatedAt,
+	}
+}
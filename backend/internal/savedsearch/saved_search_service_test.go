@@ -0,0 +1,286 @@
+package savedsearch
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/email"
+	"pgregory.net/rapid"
+)
+
+// mockRepository keeps searches in memory; it implements only the methods the tests reach
+type mockRepository struct {
+	Repository
+	searches map[uuid.UUID]*SavedSearch
+}
+
+func newMockRepository() *mockRepository {
+	return &mockRepository{searches: make(map[uuid.UUID]*SavedSearch)}
+}
+
+func (m *mockRepository) CountByUser(ctx context.Context, userID uuid.UUID) (int, error) {
+	searches, err := m.ListByUser(ctx, userID)
+	return len(searches), err
+}
+
+func (m *mockRepository) Create(ctx context.Context, search *SavedSearch) error {
+	for _, s := range m.searches {
+		if s.UserID == search.UserID && strings.EqualFold(s.Name, search.Name) {
+			return ErrSavedSearchExists
+		}
+	}
+	copied := *search
+	m.searches[search.ID] = &copied
+	return nil
+}
+
+func (m *mockRepository) GetByID(ctx context.Context, id uuid.UUID) (*SavedSearch, error) {
+	s, ok := m.searches[id]
+	if !ok {
+		return nil, ErrSavedSearchNotFound
+	}
+	copied := *s
+	return &copied, nil
+}
+
+func (m *mockRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]SavedSearch, error) {
+	var result []SavedSearch
+	for _, s := range m.searches {
+		if s.UserID == userID {
+			result = append(result, *s)
+		}
+	}
+	return result, nil
+}
+
+// mockEmailSearcher matches emails whose subject contains the search, and records the params it ran
+type mockEmailSearcher struct {
+	subjects map[uuid.UUID]string
+	unread   map[uuid.UUID]bool
+	listed   []email.ListEmailParams
+}
+
+func (m *mockEmailSearcher) matches(id uuid.UUID, params email.ListEmailParams) bool {
+	return strings.Contains(m.subjects[id], params.Search)
+}
+
+func (m *mockEmailSearcher) List(ctx context.Context, userID uuid.UUID, params email.ListEmailParams) (*email.EmailListResponse, error) {
+	m.listed = append(m.listed, params)
+	response := &email.EmailListResponse{Emails: []email.EmailWithPreview{}}
+	for id := range m.subjects {
+		if m.matches(id, params) {
+			response.Emails = append(response.Emails, email.EmailWithPreview{ID: id.String()})
+		}
+	}
+	response.Pagination.TotalCount = len(response.Emails)
+	return response, nil
+}
+
+func (m *mockEmailSearcher) Count(ctx context.Context, userID uuid.UUID, params email.ListEmailParams) (*email.EmailCountResponse, error) {
+	counts := &email.EmailCountResponse{}
+	for id := range m.subjects {
+		if m.matches(id, params) {
+			counts.TotalCount++
+			if m.unread[id] {
+				counts.UnreadCount++
+			}
+		}
+	}
+	return counts, nil
+}
+
+func (m *mockEmailSearcher) Matches(ctx context.Context, userID, emailID uuid.UUID, params email.ListEmailParams) (bool, error) {
+	return m.matches(emailID, params), nil
+}
+
+func newTestService(limit int) (*Service, *mockRepository, *mockEmailSearcher) {
+	repo := newMockRepository()
+	emails := &mockEmailSearcher{subjects: make(map[uuid.UUID]string), unread: make(map[uuid.UUID]bool)}
+	return NewService(ServiceConfig{Repository: repo, Emails: emails, SearchLimit: limit}), repo, emails
+}
+
+// TestCreateSavedSearch verifies validation, name uniqueness and the per-user limit
+func TestCreateSavedSearch(t *testing.T) {
+	ctx := context.Background()
+	service, repo, _ := newTestService(2)
+	userID := uuid.New()
+
+	created, err := service.Create(ctx, userID, CreateSavedSearchRequest{
+		Name:   "  Invoices ",
+		Query:  " from:billing ",
+		Params: &email.ListEmailParams{Page: 3, Limit: 50, Cursor: "abc", Sort: "size"},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if created.Name != "Invoices" || created.Query != "from:billing" {
+		t.Errorf("Expected trimmed name and query, got %q and %q", created.Name, created.Query)
+	}
+	stored := repo.searches[uuid.MustParse(created.ID)]
+	if stored.Params.Page != 0 || stored.Params.Limit != 0 || stored.Params.Cursor != "" || stored.Params.Sort != "size" {
+		t.Errorf("Expected paging dropped and sort kept, got %+v", stored.Params)
+	}
+
+	if _, err := service.Create(ctx, userID, CreateSavedSearchRequest{Name: "INVOICES"}); !errors.Is(err, ErrSavedSearchExists) {
+		t.Errorf("Expected ErrSavedSearchExists, got %v", err)
+	}
+
+	invalid := []CreateSavedSearchRequest{
+		{Name: "   "},
+		{Name: strings.Repeat("x", MaxNameLength+1)},
+		{Name: "Bad query", Query: "has:nothing"},
+		{Name: "Long query", Query: strings.Repeat("x", email.MaxSearchLength+1)},
+		{Name: "Bad alias", Params: &email.ListEmailParams{AliasID: "not-a-uuid"}},
+		{Name: "Bad label", Params: &email.ListEmailParams{LabelID: "not-a-uuid"}},
+		{Name: "Bad sort", Params: &email.ListEmailParams{Sort: "subject"}},
+		{Name: "Bad order", Params: &email.ListEmailParams{Order: "up"}},
+	}
+	for _, req := range invalid {
+		_, err := service.Create(ctx, userID, req)
+		if !errors.Is(err, ErrInvalidName) && !errors.Is(err, ErrInvalidSavedSearch) {
+			t.Errorf("Expected a validation error for %+v, got %v", req, err)
+		}
+	}
+
+	if _, err := service.Create(ctx, userID, CreateSavedSearchRequest{Name: "Receipts"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := service.Create(ctx, userID, CreateSavedSearchRequest{Name: "Third"}); !errors.Is(err, ErrSavedSearchLimitReached) {
+		t.Errorf("Expected ErrSavedSearchLimitReached, got %v", err)
+	}
+}
+
+// TestSavedSearchOwnership verifies users cannot see, run or change other users' saved searches
+func TestSavedSearchOwnership(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		ctx := context.Background()
+		service, _, _ := newTestService(0)
+		ownerID, otherID := uuid.New(), uuid.New()
+
+		created, err := service.Create(ctx, ownerID, CreateSavedSearchRequest{Name: rapid.StringMatching(`[A-Za-z]{1,20}`).Draw(t, "name")})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		name := "Renamed"
+		calls := []func() error{
+			func() error { _, err := service.Get(ctx, otherID, created.ID); return err },
+			func() error {
+				_, err := service.Update(ctx, otherID, created.ID, UpdateSavedSearchRequest{Name: &name})
+				return err
+			},
+			func() error { _, err := service.Execute(ctx, otherID, created.ID, ExecuteParams{}); return err },
+			func() error { _, err := service.Count(ctx, otherID, created.ID); return err },
+			func() error { _, err := service.Delete(ctx, otherID, created.ID); return err },
+		}
+		for i, call := range calls {
+			if err := call(); !errors.Is(err, ErrSavedSearchNotFound) {
+				t.Fatalf("Call %d: expected ErrSavedSearchNotFound, got %v", i, err)
+			}
+		}
+
+		if _, err := service.Get(ctx, ownerID, created.ID); err != nil {
+			t.Fatalf("Expected the owner to still get the saved search, got %v", err)
+		}
+	})
+}
+
+// TestExecuteSavedSearch verifies the query is added to the stored search and the page comes from the request
+func TestExecuteSavedSearch(t *testing.T) {
+	ctx := context.Background()
+	service, _, emails := newTestService(0)
+	userID := uuid.New()
+
+	created, err := service.Create(ctx, userID, CreateSavedSearchRequest{
+		Name:   "Invoices",
+		Query:  "invoice",
+		Params: &email.ListEmailParams{Search: "from:billing", AliasID: uuid.New().String()},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	if _, err := service.Execute(ctx, userID, created.ID, ExecuteParams{Page: 2, Limit: 10, Cursor: "next"}); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	params := emails.listed[0]
+	if params.Search != "from:billing invoice" || params.AliasID != created.Params.AliasID {
+		t.Errorf("Expected stored filters with the query added, got %+v", params)
+	}
+	if params.Page != 2 || params.Limit != 10 || params.Cursor != "next" {
+		t.Errorf("Expected the requested page, got %+v", params)
+	}
+}
+
+// TestSavedSearchCountsAndMatch verifies pinned searches list unread counts and new emails are matched
+func TestSavedSearchCountsAndMatch(t *testing.T) {
+	ctx := context.Background()
+	service, _, emails := newTestService(0)
+	userID := uuid.New()
+
+	invoice, unreadInvoice, newsletter := uuid.New(), uuid.New(), uuid.New()
+	emails.subjects[invoice] = "invoice 1"
+	emails.subjects[unreadInvoice] = "invoice 2"
+	emails.subjects[newsletter] = "newsletter"
+	emails.unread[unreadInvoice] = true
+	emails.unread[newsletter] = true
+
+	invoices, err := service.Create(ctx, userID, CreateSavedSearchRequest{Name: "Invoices", Query: "invoice", Pinned: true})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	news, err := service.Create(ctx, userID, CreateSavedSearchRequest{Name: "News", Query: "newsletter"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	list, err := service.List(ctx, userID)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	for _, s := range list.SavedSearches {
+		switch s.ID {
+		case invoices.ID:
+			if s.UnreadCount == nil || *s.UnreadCount != 1 {
+				t.Errorf("Expected 1 unread invoice, got %v", s.UnreadCount)
+			}
+		case news.ID:
+			if s.UnreadCount != nil {
+				t.Errorf("Expected no unread count for an unpinned search, got %d", *s.UnreadCount)
+			}
+		}
+	}
+
+	count, err := service.Count(ctx, userID, invoices.ID)
+	if err != nil {
+		t.Fatalf("Count failed: %v", err)
+	}
+	if count.TotalCount != 2 || count.UnreadCount != 1 {
+		t.Errorf("Expected 2 invoices, 1 unread, got %+v", count)
+	}
+
+	counts, err := service.Counts(ctx, userID)
+	if err != nil {
+		t.Fatalf("Counts failed: %v", err)
+	}
+	if len(counts.Counts) != 2 {
+		t.Errorf("Expected counts of 2 saved searches, got %d", len(counts.Counts))
+	}
+
+	matched, err := service.Match(ctx, userID, newsletter)
+	if err != nil {
+		t.Fatalf("Match failed: %v", err)
+	}
+	if len(matched) != 1 || matched[0] != news.ID {
+		t.Errorf("Expected the newsletter to match only %s, got %v", news.ID, matched)
+	}
+
+	matched, err = service.Match(ctx, uuid.New(), newsletter)
+	if err != nil || len(matched) != 0 {
+		t.Errorf("Expected no matches for a user without saved searches, got %v, %v", matched, err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -55,6 +56,62 @@ func (r *PgxAliasRepository) GetUserIDByAliasID(ctx context.Context, aliasID str
 	return userID, nil
 }
 
+// IsSenderOwnedByUser reports whether address is an active alias on a verified domain owned by the user
+// Used by the submission listener to restrict MAIL FROM
+func (r *PgxAliasRepository) IsSenderOwnedByUser(ctx context.Context, userID, address string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM aliases a
+			JOIN domains d ON d.id = a.domain_id
+			WHERE a.user_id = $1
+			  AND LOWER(a.full_address) = LOWER($2)
+			  AND a.is_active = true
+			  AND d.is_verified = true
+		)
+	`
+
+	var owned bool
+	if err := r.pool.QueryRow(ctx, query, userID, address).Scan(&owned); err != nil {
+		return false, fmt.Errorf("failed to check sender ownership: %w", err)
+	}
+
+	return owned, nil
+}
+
+// PgxOutboundQueue implements OutboundQueue using the outbound_messages table
+type PgxOutboundQueue struct {
+	pool *pgxpool.Pool
+}
+
+// NewPgxOutboundQueue creates a new PgxOutboundQueue
+func NewPgxOutboundQueue(pool *pgxpool.Pool) *PgxOutboundQueue {
+	return &PgxOutboundQueue{pool: pool}
+}
+
+// Enqueue stores a submitted message for the outbound delivery worker
+func (q *PgxOutboundQueue) Enqueue(ctx context.Context, message *OutboundMessage) error {
+	query := `
+		INSERT INTO outbound_messages (user_id, queue_id, mail_from, recipients, raw_message, size_bytes, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+	`
+
+	_, err := q.pool.Exec(ctx, query,
+		message.UserID,
+		message.QueueID,
+		message.MailFrom,
+		message.Recipients,
+		message.Data,
+		message.SizeBytes,
+		message.ReceivedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue outbound message: %w", err)
+	}
+
+	return nil
+}
+
 // PgxEmailRepository implements EmailRepository using pgxpool
 type PgxEmailRepository struct {
 	pool *pgxpool.Pool
@@ -72,13 +129,53 @@ func (r *PgxEmailRepository) Create(ctx context.Context, email *Email) error {
 	if err != nil {
 		headersJSON = []byte("{}")
 	}
+	headerListJSON, err := json.Marshal(email.HeaderList)
+	if err != nil || email.HeaderList == nil {
+		headerListJSON = []byte("[]")
+	}
+
+	// Meeting invitations are stored as structured JSON, NULL otherwise
+	var calendarJSON []byte
+	if email.Calendar != nil {
+		calendarJSON, _ = json.Marshal(email.Calendar)
+	}
+	var codesJSON []byte
+	if email.Codes != nil {
+		codesJSON, _ = json.Marshal(email.Codes)
+	}
+	var charsetWarningsJSON []byte
+	if len(email.CharsetWarnings) > 0 {
+		charsetWarningsJSON, _ = json.Marshal(email.CharsetWarnings)
+	}
+	var tnefJSON []byte
+	if len(email.TNEFProperties) > 0 {
+		tnefJSON, _ = json.Marshal(email.TNEFProperties)
+	}
+	var embeddedJSON []byte
+	if len(email.Embedded) > 0 {
+		embeddedJSON, _ = json.Marshal(email.Embedded)
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Replies join the conversation they belong to
+	if err := lockAliasThreads(ctx, tx, email.AliasID); err != nil {
+		return err
+	}
+	if err := r.resolveThread(ctx, tx, email); err != nil {
+		return err
+	}
 
 	query := `
-		INSERT INTO emails (id, alias_id, sender_address, sender_name, subject, body_html, body_text, headers, size_bytes, is_read, raw_email, received_at, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		INSERT INTO emails (id, alias_id, sender_address, sender_name, subject, body_html, body_text, headers, calendar, codes, size_bytes, is_read, raw_email, received_at, created_at, message_id, thread_id, thread_references, thread_subject, header_list, body_charset, charset_warnings, tnef_properties, embedded, import_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25)
 	`
 
-	_, err = r.pool.Exec(ctx, query,
+	_, err = tx.Exec(ctx, query,
 		email.ID,
 		email.AliasID,
 		email.SenderAddress,
@@ -87,16 +184,36 @@ func (r *PgxEmailRepository) Create(ctx context.Context, email *Email) error {
 		email.BodyHTML,
 		email.BodyText,
 		headersJSON,
+		calendarJSON,
+		codesJSON,
 		email.SizeBytes,
 		email.IsRead,
 		email.RawEmail,
 		email.ReceivedAt,
 		email.CreatedAt,
+		email.MessageID,
+		email.ThreadID,
+		nonNilReferences(email.ThreadReferences),
+		email.ThreadSubject,
+		headerListJSON,
+		email.BodyCharset,
+		charsetWarningsJSON,
+		tnefJSON,
+		embeddedJSON,
+		email.ImportKey,
 	)
 	if err != nil {
+		// Check for unique constraint violation (message already imported for the alias)
+		if strings.Contains(err.Error(), "idx_emails_alias_import_key") || strings.Contains(err.Error(), "idx_emails_alias_import_message_id") {
+			return ErrDuplicateMessage
+		}
 		return fmt.Errorf("failed to create email: %w", err)
 	}
 
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return nil
 }
 
@@ -197,11 +314,22 @@ func ConvertToRepositoryEmail(email *Email) map[string]interface{} {
 		"body_html":      email.BodyHTML,
 		"body_text":      email.BodyText,
 		"headers":        email.Headers,
+		"header_list":    email.HeaderList,
+		"calendar":       email.Calendar,
+		"codes":          email.Codes,
+		"body_charset":   email.BodyCharset,
+		"message_id":     email.MessageID,
+		"thread_id":      email.ThreadID,
 		"size_bytes":     email.SizeBytes,
 		"is_read":        email.IsRead,
 		"raw_email":      email.RawEmail,
 		"received_at":    email.ReceivedAt,
 		"created_at":     email.CreatedAt,
+
+		"charset_warnings": email.CharsetWarnings,
+		"tnef_properties":  email.TNEFProperties,
+		"embedded":         email.Embedded,
+		"import_key":       email.ImportKey,
 	}
 }
 
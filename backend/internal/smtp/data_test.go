@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -373,6 +374,37 @@ func TestDATA_QueueIDGeneration(t *testing.T) {
 	}
 }
 
+// TestGenerateQueueID_Concurrent verifies IDs generated at the same time are unique and fit the queue_id column
+func TestGenerateQueueID_Concurrent(t *testing.T) {
+	const workers, perWorker = 8, 1000
+
+	var mu sync.Mutex
+	ids := make(map[string]bool, workers*perWorker)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			generated := make([]string, perWorker)
+			for i := range generated {
+				generated[i] = GenerateQueueID()
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, id := range generated {
+				if ids[id] {
+					t.Errorf("Duplicate queue ID generated: %s", id)
+				}
+				if len(id) > 32 {
+					t.Errorf("Queue ID %s is longer than 32 characters", id)
+				}
+				ids[id] = true
+			}
+		}()
+	}
+	wg.Wait()
+}
+
 // TestDotStuffing tests dot-stuffing removal
 // **Validates: Requirements 3.2**
 func TestDotStuffing(t *testing.T) {
@@ -0,0 +1,199 @@
+package smtp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// drainTestClient is a minimal SMTP client for drain tests
+type drainTestClient struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func dialDrainTestClient(t *testing.T, addr string) *drainTestClient {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	return &drainTestClient{t: t, conn: conn, reader: bufio.NewReader(conn)}
+}
+
+// readReply reads a complete (possibly multi-line) reply and returns its last line
+func (c *drainTestClient) readReply() string {
+	c.t.Helper()
+	for {
+		line, err := c.reader.ReadString('\n')
+		if err != nil {
+			c.t.Fatalf("Failed to read reply: %v", err)
+		}
+		if len(line) < 4 || line[3] != '-' {
+			return strings.TrimSpace(line)
+		}
+	}
+}
+
+func (c *drainTestClient) cmd(line, expectPrefix string) string {
+	c.t.Helper()
+	fmt.Fprintf(c.conn, "%s\r\n", line)
+	reply := c.readReply()
+	if !strings.HasPrefix(reply, expectPrefix) {
+		c.t.Fatalf("%s: expected %s reply, got %q", line, expectPrefix, reply)
+	}
+	return reply
+}
+
+func startDrainTestServer(t *testing.T) (*SMTPServer, string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find available port: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	config := DefaultSMTPConfig()
+	config.Port = port
+	config.Hostname = "test.local"
+	config.RateLimitPerMinute = 1000
+
+	repo := NewMockAliasRepository()
+	repo.AddAlias("user@webrana.id", true)
+
+	server := NewSMTPServer(config, nil, repo)
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	return server, fmt.Sprintf("127.0.0.1:%d", port)
+}
+
+// waitForTransactions waits until the server reports n active transactions
+func waitForTransactions(t *testing.T, server *SMTPServer, n int64) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for server.GetActiveTransactions() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d active transactions, got %d", n, server.GetActiveTransactions())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// TestDrain_InFlightTransactionCompletes verifies idle and new sessions get 421 while
+// an in-flight transaction may finish before being closed with 421
+func TestDrain_InFlightTransactionCompletes(t *testing.T) {
+	server, addr := startDrainTestServer(t)
+
+	busy := dialDrainTestClient(t, addr)
+	defer busy.conn.Close()
+	busy.readReply()
+	busy.cmd("EHLO busy.client", "250")
+	busy.cmd("MAIL FROM:<sender@example.com>", "250")
+	busy.cmd("RCPT TO:<user@webrana.id>", "250")
+
+	idle := dialDrainTestClient(t, addr)
+	defer idle.conn.Close()
+	idle.readReply()
+	idle.cmd("EHLO idle.client", "250")
+
+	waitForTransactions(t, server, 1)
+
+	shutdownErr := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdownErr <- server.Shutdown(ctx)
+	}()
+
+	// Idle session is told to go away without sending anything
+	if reply := idle.readReply(); !strings.HasPrefix(reply, "421") {
+		t.Fatalf("Expected 421 for idle session, got %q", reply)
+	}
+
+	// New connections are refused with 421
+	fresh := dialDrainTestClient(t, addr)
+	defer fresh.conn.Close()
+	if reply := fresh.readReply(); !strings.HasPrefix(reply, "421") {
+		t.Fatalf("Expected 421 for new connection, got %q", reply)
+	}
+
+	health := server.HealthCheck()
+	if !health.Draining || health.Status != "draining" || health.ActiveTransactions != 1 {
+		t.Fatalf("Expected draining health with 1 transaction, got %+v", health)
+	}
+
+	// In-flight transaction finishes normally, then the session is closed with 421
+	busy.cmd("DATA", "354")
+	fmt.Fprintf(busy.conn, "Subject: drain\r\n\r\nhello\r\n.\r\n")
+	if reply := busy.readReply(); !strings.HasPrefix(reply, "250") {
+		t.Fatalf("Expected 250 for in-flight message, got %q", reply)
+	}
+	if reply := busy.readReply(); !strings.HasPrefix(reply, "421") {
+		t.Fatalf("Expected 421 after transaction, got %q", reply)
+	}
+
+	if err := <-shutdownErr; err != nil {
+		t.Fatalf("Expected clean drain, got %v", err)
+	}
+	if server.IsRunning() {
+		t.Fatal("Server should not be running after drain")
+	}
+	if server.GetActiveTransactions() != 0 {
+		t.Fatalf("Expected 0 active transactions, got %d", server.GetActiveTransactions())
+	}
+}
+
+// TestDrain_DeadlineForcesClose verifies sessions still open at the deadline get 421
+func TestDrain_DeadlineForcesClose(t *testing.T) {
+	server, addr := startDrainTestServer(t)
+
+	busy := dialDrainTestClient(t, addr)
+	defer busy.conn.Close()
+	busy.readReply()
+	busy.cmd("EHLO busy.client", "250")
+	busy.cmd("MAIL FROM:<sender@example.com>", "250")
+	busy.cmd("RCPT TO:<user@webrana.id>", "250")
+	busy.cmd("DATA", "354")
+	fmt.Fprintf(busy.conn, "Subject: slow\r\n\r\npartial body\r\n")
+
+	waitForTransactions(t, server, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	err := server.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected deadline exceeded, got %v", err)
+	}
+
+	if reply := busy.readReply(); !strings.HasPrefix(reply, "421") {
+		t.Fatalf("Expected 421 at drain deadline, got %q", reply)
+	}
+	if _, err := busy.reader.ReadString('\n'); err == nil {
+		t.Fatal("Expected connection to be closed after 421")
+	}
+}
+
+// TestDrain_StopWithoutSessions verifies Stop returns promptly when nothing is in flight
+func TestDrain_StopWithoutSessions(t *testing.T) {
+	server, _ := startDrainTestServer(t)
+
+	start := time.Now()
+	if err := server.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Fatal("Stop should not wait for the drain deadline when idle")
+	}
+	if err := server.Stop(); err != nil {
+		t.Fatalf("Second Stop should be a no-op, got %v", err)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"sync/atomic"
 	"time"
+
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/parser"
 )
 
 // EventType represents the type of event
@@ -41,6 +43,11 @@ type NewEmailEvent struct {
 	ReceivedAt     time.Time `json:"received_at"`     // When the email was received
 	HasAttachments bool      `json:"has_attachments"` // Whether email has attachments
 	SizeBytes      int64     `json:"size_bytes"`      // Size of the email in bytes
+	ThreadID       string    `json:"thread_id,omitempty"` // Conversation the email was added to
+	SavedSearchIDs []string  `json:"saved_search_ids,omitempty"` // Saved searches of the user that list the email
+
+	// One-time codes and account links, so clients can offer them without opening the email
+	Codes *parser.Codes `json:"codes,omitempty"`
 }
 
 // EventPublisher defines the interface for publishing events
@@ -1,10 +1,17 @@
 package smtp
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/email"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/parser"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/savedsearch"
 	"pgregory.net/rapid"
 )
 
@@ -322,3 +329,188 @@ func longText(length int) string {
 	}
 	return string(result)
 }
+
+// TestNewEmailEvent_Codes verifies extracted codes are included in the new_email payload
+func TestNewEmailEvent_Codes(t *testing.T) {
+	event := CreateNewEmailEvent("email-1", "alias-1", "", "no-reply@example.com", nil, nil, nil, nil, time.Now().UTC(), false, 100)
+
+	generic, err := event.ToEvent("user-1")
+	if err != nil {
+		t.Fatalf("ToEvent failed: %v", err)
+	}
+	if strings.Contains(string(generic.Data), `"codes"`) {
+		t.Errorf("Codes should be omitted when none were found: %s", generic.Data)
+	}
+
+	event.Codes = &parser.Codes{OTPs: []parser.OTPCode{{Code: "482913", Source: parser.CodeSourceBody}}}
+	generic, err = event.ToEvent("user-1")
+	if err != nil {
+		t.Fatalf("ToEvent failed: %v", err)
+	}
+	if !strings.Contains(string(generic.Data), `"codes":{"otps":[{"code":"482913","source":"body"}]}`) {
+		t.Errorf("Expected codes in payload: %s", generic.Data)
+	}
+}
+
+// staticAliasRepository resolves every address to one alias of one user
+type staticAliasRepository struct {
+	aliasID string
+	userID  string
+}
+
+func (r *staticAliasRepository) GetByFullAddress(ctx context.Context, fullAddress string) (*AliasInfo, error) {
+	return &AliasInfo{ID: r.aliasID, IsActive: true}, nil
+}
+
+func (r *staticAliasRepository) GetUserIDByAliasID(ctx context.Context, aliasID string) (string, error) {
+	return r.userID, nil
+}
+
+// capturingEventPublisher records published events
+type capturingEventPublisher struct {
+	events []Event
+}
+
+func (p *capturingEventPublisher) Publish(event Event) error {
+	p.events = append(p.events, event)
+	return nil
+}
+
+// staticSearchMatcher returns fixed saved search IDs and records what it was asked to match
+type staticSearchMatcher struct {
+	ids     []string
+	err     error
+	userID  uuid.UUID
+	emailID uuid.UUID
+}
+
+func (m *staticSearchMatcher) Match(ctx context.Context, userID, emailID uuid.UUID) ([]string, error) {
+	m.userID, m.emailID = userID, emailID
+	return m.ids, m.err
+}
+
+// TestProcessEmail_SavedSearchIDs verifies new_email events list the saved searches matching the stored email
+func TestProcessEmail_SavedSearchIDs(t *testing.T) {
+	userID := uuid.New()
+	raw := []byte("From: Alice <alice@example.org>\r\nTo: inbox@example.com\r\nSubject: Invoice\r\n\r\nHello\r\n")
+	data := &DataResult{Data: raw, SizeBytes: int64(len(raw)), ReceivedAt: time.Now().UTC(), Recipients: []string{"inbox@example.com"}}
+
+	tests := []struct {
+		name    string
+		matcher *staticSearchMatcher
+		want    []string
+	}{
+		{"matching saved searches", &staticSearchMatcher{ids: []string{"search-1", "search-2"}}, []string{"search-1", "search-2"}},
+		{"matching fails", &staticSearchMatcher{err: errors.New("database unavailable")}, nil},
+		{"no matcher", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publisher := &capturingEventPublisher{}
+			cfg := ProcessorConfig{
+				Parser:         parser.NewEmailParser(),
+				EmailRepo:      &dedupEmailRepository{},
+				AliasRepo:      &staticAliasRepository{aliasID: uuid.New().String(), userID: userID.String()},
+				EventPublisher: publisher,
+			}
+			if tt.matcher != nil {
+				cfg.SearchMatcher = tt.matcher
+			}
+			processor := NewEmailProcessor(cfg)
+
+			result, err := processor.ProcessEmail(context.Background(), data)
+			if err != nil || len(result.Errors) > 0 {
+				t.Fatalf("ProcessEmail() = %+v, %v", result, err)
+			}
+			if len(publisher.events) != 1 {
+				t.Fatalf("Expected 1 event, got %d", len(publisher.events))
+			}
+
+			var event NewEmailEvent
+			if err := json.Unmarshal(publisher.events[0].Data, &event); err != nil {
+				t.Fatalf("Failed to decode event: %v", err)
+			}
+			if strings.Join(event.SavedSearchIDs, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Expected saved searches %v, got %v", tt.want, event.SavedSearchIDs)
+			}
+			if tt.matcher != nil && (tt.matcher.userID != userID || tt.matcher.emailID.String() != result.EmailID) {
+				t.Errorf("Expected match of email %s for user %s, got %s for %s", result.EmailID, userID, tt.matcher.emailID, tt.matcher.userID)
+			}
+		})
+	}
+}
+
+// userSavedSearches is a saved search repository holding one user's searches; other methods are unused
+type userSavedSearches struct {
+	savedsearch.Repository
+	searches []savedsearch.SavedSearch
+}
+
+func (r *userSavedSearches) ListByUser(ctx context.Context, userID uuid.UUID) ([]savedsearch.SavedSearch, error) {
+	return r.searches, nil
+}
+
+// searchTermEmails matches a stored email against saved searches by their search term
+type searchTermEmails struct {
+	savedsearch.EmailSearcher
+	emailID uuid.UUID
+	terms   map[string]bool
+}
+
+func (e *searchTermEmails) Matches(ctx context.Context, userID, emailID uuid.UUID, params email.ListEmailParams) (bool, error) {
+	return emailID == e.emailID && e.terms[params.Search], nil
+}
+
+// TestProcessEmail_SavedSearchService verifies the saved search service wired into the SMTP processor
+// lists its matching searches in the new_email event
+func TestProcessEmail_SavedSearchService(t *testing.T) {
+	userID := uuid.New()
+	invoices := savedsearch.SavedSearch{ID: uuid.New(), UserID: userID, Name: "Invoices", Query: "invoice"}
+	receipts := savedsearch.SavedSearch{ID: uuid.New(), UserID: userID, Name: "Receipts", Query: "receipt"}
+	emails := &searchTermEmails{terms: map[string]bool{"invoice": true}}
+	repo := &dedupEmailRepository{}
+
+	publisher := &capturingEventPublisher{}
+	processor := NewEmailProcessor(ProcessorConfig{
+		Parser:         parser.NewEmailParser(),
+		EmailRepo:      &createHook{EmailRepository: repo, created: func(e *Email) { emails.emailID = e.ID }},
+		AliasRepo:      &staticAliasRepository{aliasID: uuid.New().String(), userID: userID.String()},
+		EventPublisher: publisher,
+		SearchMatcher: savedsearch.NewService(savedsearch.ServiceConfig{
+			Repository: &userSavedSearches{searches: []savedsearch.SavedSearch{invoices, receipts}},
+			Emails:     emails,
+		}),
+	})
+
+	raw := []byte("From: Alice <alice@example.org>\r\nTo: inbox@example.com\r\nSubject: Invoice\r\n\r\nHello\r\n")
+	data := &DataResult{Data: raw, SizeBytes: int64(len(raw)), ReceivedAt: time.Now().UTC(), Recipients: []string{"inbox@example.com"}}
+	if result, err := processor.ProcessEmail(context.Background(), data); err != nil || len(result.Errors) > 0 {
+		t.Fatalf("ProcessEmail() = %+v, %v", result, err)
+	}
+	if len(publisher.events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(publisher.events))
+	}
+
+	var event NewEmailEvent
+	if err := json.Unmarshal(publisher.events[0].Data, &event); err != nil {
+		t.Fatalf("Failed to decode event: %v", err)
+	}
+	if strings.Join(event.SavedSearchIDs, ",") != invoices.ID.String() {
+		t.Errorf("Expected saved searches [%s], got %v", invoices.ID, event.SavedSearchIDs)
+	}
+}
+
+// createHook is an EmailRepository that reports each email it creates
+type createHook struct {
+	EmailRepository
+	created func(*Email)
+}
+
+func (r *createHook) Create(ctx context.Context, email *Email) error {
+	if err := r.EmailRepository.Create(ctx, email); err != nil {
+		return err
+	}
+	r.created(email)
+	return nil
+}
@@ -0,0 +1,93 @@
+package smtp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// ImportEmail errors
+var (
+	// ErrDuplicateMessage is returned when the alias already has the message, by content or Message-ID
+	ErrDuplicateMessage = errors.New("duplicate message")
+	// ErrUnparseableMessage is returned when the message cannot be parsed at all
+	ErrUnparseableMessage = errors.New("message could not be parsed")
+)
+
+// DuplicateChecker is implemented by email repositories that can look up emails by Message-ID and import key
+// ImportEmail checks for duplicates before uploading attachments when the processor's EmailRepository
+// implements it. Duplicates stored concurrently are still rejected by Create with ErrDuplicateMessage.
+type DuplicateChecker interface {
+	ExistsByMessageID(ctx context.Context, aliasID uuid.UUID, messageID string) (bool, error)
+	ExistsByImportKey(ctx context.Context, aliasID uuid.UUID, importKey string) (bool, error)
+}
+
+// ImportKey returns the key an imported message is deduplicated by: the hex SHA-256 of its raw bytes
+func ImportKey(raw []byte) string {
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// ImportEmail stores a message from a mailbox archive for an alias, through the same parsing and
+// attachment pipeline as received mail
+// data.ReceivedAt should be when the message was originally received. Messages the alias already has,
+// by identical content or by Message-ID, are skipped with ErrDuplicateMessage, so an import can be
+// repeated safely and concurrent imports of the same message store it once.
+// No new_email event is published.
+func (p *EmailProcessor) ImportEmail(ctx context.Context, data *DataResult, aliasID uuid.UUID, isRead bool) (string, error) {
+	parsedEmail := p.parser.SafeParse(data.Data)
+	if parsedEmail == nil {
+		return "", ErrUnparseableMessage
+	}
+
+	importKey := ImportKey(data.Data)
+	if checker, ok := p.emailRepo.(DuplicateChecker); ok {
+		exists, err := checker.ExistsByImportKey(ctx, aliasID, importKey)
+		if err == nil && !exists && parsedEmail.Thread.MessageID != "" {
+			exists, err = checker.ExistsByMessageID(ctx, aliasID, parsedEmail.Thread.MessageID)
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to check for duplicates: %w", err)
+		}
+		if exists {
+			return "", ErrDuplicateMessage
+		}
+	}
+
+	for _, warning := range parsedEmail.CharsetWarnings {
+		p.logger.Printf("Charset warning for %s (body charset %q): %s", data.QueueID, parsedEmail.Charset, warning)
+	}
+
+	email, _, err := p.storeEmail(ctx, parsedEmail, data, aliasID, isRead, importKey)
+	if err != nil {
+		if errors.Is(err, ErrDuplicateMessage) {
+			return "", ErrDuplicateMessage
+		}
+		return "", err
+	}
+	return email.ID.String(), nil
+}
+
+// ExistsByMessageID reports whether an alias has an email with the given Message-ID
+func (r *PgxEmailRepository) ExistsByMessageID(ctx context.Context, aliasID uuid.UUID, messageID string) (bool, error) {
+	var exists bool
+	err := r.pool.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM emails WHERE alias_id = $1 AND message_id = $2)`,
+		aliasID, messageID,
+	).Scan(&exists)
+	return exists, err
+}
+
+// ExistsByImportKey reports whether an alias has an imported email with the given import key
+func (r *PgxEmailRepository) ExistsByImportKey(ctx context.Context, aliasID uuid.UUID, importKey string) (bool, error) {
+	var exists bool
+	err := r.pool.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM emails WHERE alias_id = $1 AND import_key = $2)`,
+		aliasID, importKey,
+	).Scan(&exists)
+	return exists, err
+}
@@ -0,0 +1,218 @@
+package smtp
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/parser"
+)
+
+// dedupEmailRepository stores emails in memory and implements DuplicateChecker
+// Like the unique indexes on emails, Create rejects an import the alias already has.
+type dedupEmailRepository struct {
+	emails []*Email
+}
+
+func (r *dedupEmailRepository) Create(ctx context.Context, email *Email) error {
+	if email.ImportKey != nil {
+		for _, e := range r.emails {
+			if e.AliasID != email.AliasID || e.ImportKey == nil {
+				continue
+			}
+			if *e.ImportKey == *email.ImportKey || (e.MessageID != nil && email.MessageID != nil && *e.MessageID == *email.MessageID) {
+				return ErrDuplicateMessage
+			}
+		}
+	}
+	r.emails = append(r.emails, email)
+	return nil
+}
+
+func (r *dedupEmailRepository) ExistsByImportKey(ctx context.Context, aliasID uuid.UUID, importKey string) (bool, error) {
+	for _, e := range r.emails {
+		if e.AliasID == aliasID && e.ImportKey != nil && *e.ImportKey == importKey {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *dedupEmailRepository) ExistsByMessageID(ctx context.Context, aliasID uuid.UUID, messageID string) (bool, error) {
+	for _, e := range r.emails {
+		if e.AliasID == aliasID && e.MessageID != nil && *e.MessageID == messageID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestImportEmail_DeduplicatesByMessageID(t *testing.T) {
+	repo := &dedupEmailRepository{}
+	processor := NewEmailProcessor(ProcessorConfig{
+		Parser:    parser.NewEmailParser(),
+		EmailRepo: repo,
+	})
+
+	raw := []byte("From: Alice <alice@example.org>\r\nTo: inbox@example.com\r\nSubject: Archived\r\nMessage-ID: <abc@example.org>\r\n\r\nHello\r\n")
+	receivedAt := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	data := &DataResult{Data: raw, SizeBytes: int64(len(raw)), ReceivedAt: receivedAt}
+	aliasID := uuid.New()
+
+	id, err := processor.ImportEmail(context.Background(), data, aliasID, true)
+	if err != nil || id == "" {
+		t.Fatalf("ImportEmail() = %q, %v", id, err)
+	}
+	stored := repo.emails[0]
+	if !stored.IsRead || !stored.ReceivedAt.Equal(receivedAt) || *stored.Subject != "Archived" {
+		t.Errorf("stored email = read %v, received %v, subject %q", stored.IsRead, stored.ReceivedAt, *stored.Subject)
+	}
+
+	if _, err := processor.ImportEmail(context.Background(), data, aliasID, true); !errors.Is(err, ErrDuplicateMessage) {
+		t.Errorf("second ImportEmail() error = %v, want ErrDuplicateMessage", err)
+	}
+	if _, err := processor.ImportEmail(context.Background(), data, uuid.New(), true); err != nil {
+		t.Errorf("ImportEmail() for another alias error = %v", err)
+	}
+	if len(repo.emails) != 2 {
+		t.Errorf("stored %d emails, want 2", len(repo.emails))
+	}
+}
+
+func TestImportEmail_DeduplicatesWithoutMessageID(t *testing.T) {
+	repo := &dedupEmailRepository{}
+	processor := NewEmailProcessor(ProcessorConfig{
+		Parser:    parser.NewEmailParser(),
+		EmailRepo: repo,
+	})
+	aliasID := uuid.New()
+
+	raw := []byte("From: alice@example.org\r\nSubject: No ID\r\n\r\nHello\r\n")
+	data := &DataResult{Data: raw, SizeBytes: int64(len(raw)), ReceivedAt: time.Now().UTC()}
+	if _, err := processor.ImportEmail(context.Background(), data, aliasID, false); err != nil {
+		t.Fatalf("ImportEmail() error = %v", err)
+	}
+	if key := repo.emails[0].ImportKey; key == nil || *key != ImportKey(raw) {
+		t.Errorf("stored import key = %v, want %s", key, ImportKey(raw))
+	}
+	if _, err := processor.ImportEmail(context.Background(), data, aliasID, false); !errors.Is(err, ErrDuplicateMessage) {
+		t.Errorf("second ImportEmail() error = %v, want ErrDuplicateMessage", err)
+	}
+
+	other := []byte("From: alice@example.org\r\nSubject: No ID\r\n\r\nHello again\r\n")
+	if _, err := processor.ImportEmail(context.Background(), &DataResult{Data: other, SizeBytes: int64(len(other))}, aliasID, false); err != nil {
+		t.Errorf("ImportEmail() of different content error = %v", err)
+	}
+}
+
+// racingEmailRepository finds no duplicates before storing, like a job racing another one that
+// stores the same message between the check and the insert
+type racingEmailRepository struct {
+	dedupEmailRepository
+}
+
+func (r *racingEmailRepository) ExistsByMessageID(ctx context.Context, aliasID uuid.UUID, messageID string) (bool, error) {
+	return false, nil
+}
+
+func (r *racingEmailRepository) ExistsByImportKey(ctx context.Context, aliasID uuid.UUID, importKey string) (bool, error) {
+	return false, nil
+}
+
+func TestImportEmail_ConcurrentDuplicate(t *testing.T) {
+	repo := &racingEmailRepository{}
+	processor := NewEmailProcessor(ProcessorConfig{
+		Parser:    parser.NewEmailParser(),
+		EmailRepo: repo,
+	})
+	aliasID := uuid.New()
+
+	first := []byte("From: alice@example.org\r\nMessage-ID: <race@example.org>\r\nSubject: Race\r\n\r\nHello\r\n")
+	if _, err := processor.ImportEmail(context.Background(), &DataResult{Data: first}, aliasID, false); err != nil {
+		t.Fatalf("ImportEmail() error = %v", err)
+	}
+
+	// Same Message-ID with different bytes, e.g. the copy of another mailbox export
+	second := []byte("From: alice@example.org\r\nMessage-ID: <race@example.org>\r\nSubject: Race\r\nX-Folder: Archive\r\n\r\nHello\r\n")
+	for _, raw := range [][]byte{first, second} {
+		if _, err := processor.ImportEmail(context.Background(), &DataResult{Data: raw}, aliasID, false); !errors.Is(err, ErrDuplicateMessage) {
+			t.Errorf("racing ImportEmail() error = %v, want ErrDuplicateMessage", err)
+		}
+	}
+	if len(repo.emails) != 1 {
+		t.Errorf("stored %d emails, want 1", len(repo.emails))
+	}
+}
+
+func TestImportEmail_StoresCharset(t *testing.T) {
+	repo := &dedupEmailRepository{}
+	processor := NewEmailProcessor(ProcessorConfig{
+		Parser:    parser.NewEmailParser(),
+		EmailRepo: repo,
+	})
+
+	raw := []byte("From: alice@example.org\r\nTo: inbox@example.com\r\nSubject: Legacy\r\nContent-Type: text/plain; charset=x-unknown\r\n\r\nCaf\xe9\r\n")
+	data := &DataResult{Data: raw, SizeBytes: int64(len(raw)), ReceivedAt: time.Now().UTC()}
+
+	if _, err := processor.ImportEmail(context.Background(), data, uuid.New(), false); err != nil {
+		t.Fatalf("ImportEmail() error = %v", err)
+	}
+	stored := repo.emails[0]
+	if stored.BodyCharset == nil || *stored.BodyCharset != parser.FallbackCharset {
+		t.Errorf("stored body charset = %v, want %s", stored.BodyCharset, parser.FallbackCharset)
+	}
+	if len(stored.CharsetWarnings) != 1 || !strings.Contains(stored.CharsetWarnings[0], `unknown charset "x-unknown"`) {
+		t.Errorf("stored charset warnings = %v", stored.CharsetWarnings)
+	}
+}
+
+func TestImportEmail_StoresTNEFProperties(t *testing.T) {
+	tnef, err := os.ReadFile("../parser/testdata/winmail.dat")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	repo := &dedupEmailRepository{}
+	processor := NewEmailProcessor(ProcessorConfig{
+		Parser:    parser.NewEmailParser(),
+		EmailRepo: repo,
+	})
+
+	raw := []byte("From: zoe@example.fr\r\nTo: inbox@example.com\r\nSubject: Rapport\r\n" +
+		"Content-Type: application/ms-tnef; name=\"winmail.dat\"\r\nContent-Transfer-Encoding: base64\r\n\r\n" +
+		base64.StdEncoding.EncodeToString(tnef) + "\r\n")
+	data := &DataResult{Data: raw, SizeBytes: int64(len(raw)), ReceivedAt: time.Now().UTC()}
+
+	if _, err := processor.ImportEmail(context.Background(), data, uuid.New(), false); err != nil {
+		t.Fatalf("ImportEmail() error = %v", err)
+	}
+	if got := repo.emails[0].TNEFProperties["sender_name"]; got != "Zoë Martin" {
+		t.Errorf("stored TNEF sender_name = %q, properties %v", got, repo.emails[0].TNEFProperties)
+	}
+}
+
+func TestImportEmail_StoresEmbeddedSummaries(t *testing.T) {
+	repo := &dedupEmailRepository{}
+	processor := NewEmailProcessor(ProcessorConfig{
+		Parser:    parser.NewEmailParser(),
+		EmailRepo: repo,
+	})
+
+	raw := []byte("From: bob@example.com\r\nSubject: Fwd: Invoice\r\nContent-Type: multipart/mixed; boundary=\"outer\"\r\n\r\n" +
+		"--outer\r\nContent-Type: text/plain\r\n\r\nSee below\r\n" +
+		"--outer\r\nContent-Type: message/rfc822\r\n\r\nFrom: billing@example.com\r\nSubject: Invoice\r\n\r\nTotal\r\n" +
+		"--outer--\r\n")
+	data := &DataResult{Data: raw, SizeBytes: int64(len(raw)), ReceivedAt: time.Now().UTC()}
+
+	if _, err := processor.ImportEmail(context.Background(), data, uuid.New(), false); err != nil {
+		t.Fatalf("ImportEmail() error = %v", err)
+	}
+	embedded := repo.emails[0].Embedded
+	if len(embedded) != 1 || embedded[0].Path != "1" || embedded[0].From != "billing@example.com" || embedded[0].Subject != "Invoice" {
+		t.Errorf("stored embedded summaries = %+v", embedded)
+	}
+}
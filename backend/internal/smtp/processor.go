@@ -4,6 +4,7 @@
 package smtp
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -26,6 +27,7 @@ type EmailProcessor struct {
 	attachmentRepo    AttachmentRepository
 	aliasRepo         AliasLookupRepository
 	eventPublisher    EventPublisher
+	searchMatcher     SavedSearchMatcher
 	logger            *log.Logger
 }
 
@@ -45,6 +47,11 @@ type AliasLookupRepository interface {
 	GetUserIDByAliasID(ctx context.Context, aliasID string) (string, error)
 }
 
+// SavedSearchMatcher finds the saved searches of a user that list an email
+type SavedSearchMatcher interface {
+	Match(ctx context.Context, userID, emailID uuid.UUID) ([]string, error)
+}
+
 // Email represents an email to be stored
 type Email struct {
 	ID            uuid.UUID         `db:"id"`
@@ -55,11 +62,28 @@ type Email struct {
 	BodyHTML      *string           `db:"body_html"`
 	BodyText      *string           `db:"body_text"`
 	Headers       map[string]string `db:"headers"`
+	HeaderList    []parser.Header   `db:"header_list"`
+	Calendar      *parser.Calendar  `db:"calendar"`
+	Codes         *parser.Codes     `db:"codes"`
+	BodyCharset   *string           `db:"body_charset"`
+	MessageID     *string           `db:"message_id"`
+	ThreadID      uuid.UUID         `db:"thread_id"` // Set by the repository when the email is stored
+
+	ThreadReferences []string `db:"thread_references"`
+	ThreadSubject    *string  `db:"thread_subject"`
+	IsReply          bool     `db:"-"` // Allows the subject fallback when no ancestor is known
 	SizeBytes     int64             `db:"size_bytes"`
 	IsRead        bool              `db:"is_read"`
 	RawEmail      []byte            `db:"raw_email"`
 	ReceivedAt    time.Time         `db:"received_at"`
 	CreatedAt     time.Time         `db:"created_at"`
+
+	CharsetWarnings []string          `db:"charset_warnings"` // Unknown charsets and replaced invalid sequences
+	TNEFProperties  map[string]string `db:"tnef_properties"`  // Message properties of a winmail.dat part
+
+	Embedded []parser.EmbeddedSummary `db:"embedded"` // Top-level forwarded messages
+
+	ImportKey *string `db:"import_key"` // Hex SHA-256 of an imported message, unique per alias; nil for received mail
 }
 
 // Attachment represents attachment metadata to be stored
@@ -83,6 +107,7 @@ type ProcessorConfig struct {
 	AttachmentRepo    AttachmentRepository
 	AliasRepo         AliasLookupRepository
 	EventPublisher    EventPublisher
+	SearchMatcher     SavedSearchMatcher // Optional; new_email events list the matching saved searches when set
 	Logger            *log.Logger
 }
 
@@ -105,6 +130,7 @@ func NewEmailProcessor(cfg ProcessorConfig) *EmailProcessor {
 		attachmentRepo:    cfg.AttachmentRepo,
 		aliasRepo:         cfg.AliasRepo,
 		eventPublisher:    eventPublisher,
+		searchMatcher:     cfg.SearchMatcher,
 		logger:            logger,
 	}
 }
@@ -120,6 +146,9 @@ type ProcessResult struct {
 
 // ProcessEmail processes a received email through the full pipeline
 // Requirements: All - connects SMTP → parser → attachment handler → repositories
+// The message is held in memory once, as data.Data, because it is stored as raw_email. Parsing reads it
+// in place and attachments are decoded and uploaded as a stream from it, so processing adds about one
+// attachment.StreamPartSize buffer on top of the message rather than copies of it (see BenchmarkProcessEmail).
 func (p *EmailProcessor) ProcessEmail(ctx context.Context, data *DataResult) (*ProcessResult, error) {
 	result := &ProcessResult{
 		QueueID:    data.QueueID,
@@ -134,6 +163,11 @@ func (p *EmailProcessor) ProcessEmail(ctx context.Context, data *DataResult) (*P
 		return nil, fmt.Errorf("failed to parse email")
 	}
 
+	// Record charset problems so mojibake in stored bodies can be traced back
+	for _, warning := range parsedEmail.CharsetWarnings {
+		p.logger.Printf("Charset warning for %s (body charset %q): %s", data.QueueID, parsedEmail.Charset, warning)
+	}
+
 	// Process for each recipient
 	for _, recipient := range data.Recipients {
 		emailID, attachmentCount, err := p.processForRecipient(ctx, parsedEmail, data, recipient)
@@ -162,13 +196,32 @@ func (p *EmailProcessor) processForRecipient(ctx context.Context, parsedEmail *p
 		return "", 0, fmt.Errorf("invalid alias ID: %w", err)
 	}
 
+	email, attachmentCount, err := p.storeEmail(ctx, parsedEmail, data, aliasID, false, "")
+	if err != nil {
+		return "", 0, err
+	}
+
+	// Publish new email event
+	// Requirements: 8.1, 8.2, 8.3 - Real-time notification
+	if err := p.publishNewEmailEvent(ctx, email, alias, attachmentCount > 0); err != nil {
+		p.logger.Printf("Failed to publish new email event: %v", err)
+		// Don't fail the whole email processing for event publishing errors
+	}
+
+	return email.ID.String(), attachmentCount, nil
+}
+
+// storeEmail extracts the attachments of a parsed email and stores it for an alias
+// It returns the stored email and the number of attachments kept. importKey is empty for received mail.
+// Attachments already uploaded are deleted again when the email cannot be stored.
+func (p *EmailProcessor) storeEmail(ctx context.Context, parsedEmail *parser.ParsedEmail, data *DataResult, aliasID uuid.UUID, isRead bool, importKey string) (*Email, int, error) {
 	// Generate email ID
 	emailID := uuid.New()
 
 	// Process attachments if handler is available
 	var processedAttachments []*attachment.ProcessedAttachment
 	if p.attachmentHandler != nil {
-		processed, validationErrors := p.attachmentHandler.ProcessAndValidate(ctx, emailID.String(), data.Data)
+		processed, validationErrors := p.attachmentHandler.ProcessStream(ctx, emailID.String(), bytes.NewReader(data.Data))
 		processedAttachments = processed
 		for _, validErr := range validationErrors {
 			p.logger.Printf("Attachment validation error: %s - %s", validErr.Filename, validErr.Reason)
@@ -186,16 +239,37 @@ func (p *EmailProcessor) processForRecipient(ctx context.Context, parsedEmail *p
 		BodyHTML:      stringPtr(parsedEmail.BodyHTML),
 		BodyText:      stringPtr(parsedEmail.BodyText),
 		Headers:       parsedEmail.Headers,
+		HeaderList:    parsedEmail.HeaderList,
+		Calendar:      parsedEmail.Calendar,
+		Codes:         parsedEmail.Codes,
+		BodyCharset:   stringPtr(parsedEmail.Charset),
+		MessageID:     stringPtr(parsedEmail.Thread.MessageID),
 		SizeBytes:     data.SizeBytes,
-		IsRead:        false,
+		IsRead:        isRead,
 		RawEmail:      data.Data,
 		ReceivedAt:    data.ReceivedAt,
 		CreatedAt:     time.Now().UTC(),
+
+		ThreadReferences: parsedEmail.Thread.References,
+		ThreadSubject:    stringPtr(parsedEmail.Thread.Subject),
+		IsReply:          parsedEmail.Thread.IsReply,
+
+		CharsetWarnings: parsedEmail.CharsetWarnings,
+		TNEFProperties:  parsedEmail.TNEFProperties,
+
+		Embedded: parser.SummarizeEmbedded(parsedEmail.Embedded),
+
+		ImportKey: stringPtr(importKey),
 	}
 
 	// Store email in database
 	if err := p.emailRepo.Create(ctx, email); err != nil {
-		return "", 0, fmt.Errorf("failed to store email: %w", err)
+		for _, att := range processedAttachments {
+			if delErr := p.attachmentHandler.DeleteAttachment(context.WithoutCancel(ctx), att.StorageKey); delErr != nil {
+				p.logger.Printf("Failed to delete attachment %s of unstored email: %v", att.StorageKey, delErr)
+			}
+		}
+		return nil, 0, fmt.Errorf("failed to store email: %w", err)
 	}
 
 	// Store attachment metadata in database
@@ -223,14 +297,7 @@ func (p *EmailProcessor) processForRecipient(ctx context.Context, parsedEmail *p
 		}
 	}
 
-	// Publish new email event
-	// Requirements: 8.1, 8.2, 8.3 - Real-time notification
-	if err := p.publishNewEmailEvent(ctx, email, alias, len(processedAttachments) > 0); err != nil {
-		p.logger.Printf("Failed to publish new email event: %v", err)
-		// Don't fail the whole email processing for event publishing errors
-	}
-
-	return emailID.String(), len(processedAttachments), nil
+	return email, len(processedAttachments), nil
 }
 
 // publishNewEmailEvent publishes a new email event to the event bus
@@ -256,6 +323,9 @@ func (p *EmailProcessor) publishNewEmailEvent(ctx context.Context, email *Email,
 		hasAttachments,
 		email.SizeBytes,
 	)
+	newEmailEvent.Codes = email.Codes
+	newEmailEvent.ThreadID = email.ThreadID.String()
+	newEmailEvent.SavedSearchIDs = p.matchSavedSearches(ctx, userID, email.ID)
 
 	// Convert to generic event
 	event, err := newEmailEvent.ToEvent(userID)
@@ -267,6 +337,27 @@ func (p *EmailProcessor) publishNewEmailEvent(ctx context.Context, email *Email,
 	return p.eventPublisher.Publish(*event)
 }
 
+// matchSavedSearches returns the IDs of the user's saved searches that list a new email
+// Matching errors are logged and leave the event without saved searches.
+func (p *EmailProcessor) matchSavedSearches(ctx context.Context, userID string, emailID uuid.UUID) []string {
+	if p.searchMatcher == nil {
+		return nil
+	}
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		p.logger.Printf("Invalid user ID for saved search matching: %s", userID)
+		return nil
+	}
+
+	ids, err := p.searchMatcher.Match(ctx, uid, emailID)
+	if err != nil {
+		p.logger.Printf("Failed to match saved searches for email %s: %v", emailID, err)
+		return nil
+	}
+	return ids
+}
+
 // stringPtr returns a pointer to a string, or nil if empty
 func stringPtr(s string) *string {
 	if s == "" {
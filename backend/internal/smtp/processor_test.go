@@ -0,0 +1,169 @@
+package smtp
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"runtime/metrics"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/attachment"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/parser"
+)
+
+// discardEmailRepository accepts emails without keeping them
+type discardEmailRepository struct{}
+
+func (r *discardEmailRepository) Create(ctx context.Context, email *Email) error {
+	return nil
+}
+
+// discardS3 accepts uploads, multipart uploads included, and drops their content
+type discardS3 struct {
+	mu     sync.Mutex
+	nextID int
+}
+
+// ServeHTTP implements http.Handler
+func (s *discardS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	io.Copy(io.Discard, r.Body)
+	key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+	query := r.URL.Query()
+
+	switch {
+	case r.Method == http.MethodPut:
+		w.Header().Set("ETag", `"etag"`)
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		s.mu.Lock()
+		s.nextID++
+		id := s.nextID
+		s.mu.Unlock()
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><Bucket>bucket</Bucket><Key>%s</Key><UploadId>upload-%d</UploadId></InitiateMultipartUploadResult>", key, id)
+	case r.Method == http.MethodPost:
+		fmt.Fprintf(w, "<CompleteMultipartUploadResult><Bucket>bucket</Bucket><Key>%s</Key><ETag>\"etag\"</ETag></CompleteMultipartUploadResult>", key)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// pdfMessage builds a message with a text body and base64 PDF attachments of the given sizes
+func pdfMessage(sizes ...int) []byte {
+	var msg bytes.Buffer
+	msg.WriteString("From: sender@example.org\r\nTo: inbox@example.com\r\nSubject: Reports\r\n")
+	msg.WriteString("Content-Type: multipart/mixed; boundary=\"reports\"\r\n\r\n")
+	msg.WriteString("--reports\r\nContent-Type: text/plain\r\n\r\nThe reports are attached.\r\n")
+
+	rng := rand.New(rand.NewSource(1))
+	for i, size := range sizes {
+		data := make([]byte, size)
+		rng.Read(data)
+		copy(data, "%PDF-1.4\n")
+
+		fmt.Fprintf(&msg, "--reports\r\nContent-Type: application/pdf\r\nContent-Transfer-Encoding: base64\r\n")
+		fmt.Fprintf(&msg, "Content-Disposition: attachment; filename=\"report-%d.pdf\"\r\n\r\n", i+1)
+		encoded := base64.StdEncoding.EncodeToString(data)
+		for len(encoded) > 76 {
+			msg.WriteString(encoded[:76] + "\r\n")
+			encoded = encoded[76:]
+		}
+		msg.WriteString(encoded + "\r\n")
+	}
+	msg.WriteString("--reports--\r\n")
+	return msg.Bytes()
+}
+
+// BenchmarkProcessEmail measures memory use of the whole pipeline for one received message:
+// parsing, attachment extraction and upload, storage and the new_email event.
+// The message is about 20 MB of base64 attachments. It is held in memory once, as DataResult.Data,
+// because it is stored as raw_email; peak-heap-B is the highest live heap above that while
+// processing, and peak-heap-per-msg-B is the same relative to the message size.
+func BenchmarkProcessEmail(b *testing.B) {
+	raw := pdfMessage(6*1024*1024, 6*1024*1024, 3*1024*1024)
+
+	srv := httptest.NewServer(&discardS3{})
+	b.Cleanup(srv.Close)
+	client := s3.New(s3.Options{
+		BaseEndpoint: aws.String(srv.URL),
+		UsePathStyle: true,
+		Region:       "us-east-1",
+		Credentials:  credentials.NewStaticCredentialsProvider("access", "secret", ""),
+	})
+
+	processor := NewEmailProcessor(ProcessorConfig{
+		Parser:            parser.NewEmailParser(),
+		AttachmentHandler: attachment.NewHandler(client, "bucket"),
+		EmailRepo:         &discardEmailRepository{},
+		AliasRepo:         &staticAliasRepository{aliasID: uuid.New().String(), userID: uuid.New().String()},
+		EventPublisher:    &capturingEventPublisher{},
+	})
+	process := func() {
+		data := &DataResult{
+			Data:       raw,
+			QueueID:    "bench",
+			ReceivedAt: time.Now().UTC(),
+			SizeBytes:  int64(len(raw)),
+			Recipients: []string{"inbox@example.com"},
+		}
+		result, err := processor.ProcessEmail(context.Background(), data)
+		if err != nil || result.AttachmentCount != 3 {
+			b.Fatalf("ProcessEmail() = %+v, %v", result, err)
+		}
+	}
+	process() // Fill the buffer pool and connection pool
+
+	b.SetBytes(int64(len(raw)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	var peak uint64
+	for i := 0; i < b.N; i++ {
+		peak = max(peak, peakHeap(process))
+	}
+	b.ReportMetric(float64(peak), "peak-heap-B")
+	b.ReportMetric(float64(peak)/float64(len(raw)), "peak-heap-per-msg-B")
+}
+
+// peakHeap runs fn and returns the highest live heap it reached above the heap before it started
+func peakHeap(fn func()) uint64 {
+	sample := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	read := func() uint64 {
+		metrics.Read(sample)
+		return sample[0].Value.Uint64()
+	}
+
+	runtime.GC()
+	base := read()
+	peak := base
+	done := make(chan struct{})
+	sampled := make(chan struct{})
+	go func() {
+		defer close(sampled)
+		ticker := time.NewTicker(200 * time.Microsecond)
+		defer ticker.Stop()
+		for {
+			peak = max(peak, read())
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	fn()
+	close(done)
+	<-sampled
+	return max(peak, read()) - base
+}
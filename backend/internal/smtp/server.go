@@ -3,6 +3,7 @@ package smtp
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"log"
 	"net"
@@ -24,6 +25,12 @@ type SMTPServer struct {
 	// Email processor callback
 	dataCallback    func(ctx context.Context, data *DataResult) error
 	
+	// Optional session transcript capture
+	transcriptStore TranscriptStore
+	
+	// Authenticated submission mode (nil for the inbound MX listener)
+	submission      *SubmissionOptions
+	
 	// Connection management
 	activeConns     int64
 	ipConnections   map[string]int
@@ -37,8 +44,24 @@ type SMTPServer struct {
 	running         atomic.Bool
 	wg              sync.WaitGroup
 	shutdownCh      chan struct{}
+	
+	// Graceful drain state
+	draining           atomic.Bool
+	drainCh            chan struct{} // closed when draining starts
+	activeTransactions int64
+	sessions           map[*SMTPSession]struct{}
+	sessionsMu         sync.Mutex
 }
 
+// DefaultDrainTimeout is how long in-flight transactions may run after shutdown starts
+const DefaultDrainTimeout = 30 * time.Second
+
+// forceCloseGracePeriod bounds the wait for sessions after they were force-closed
+const forceCloseGracePeriod = 5 * time.Second
+
+// shutdownMessage is sent with 421 to clients during drain
+const shutdownMessage = "Service shutting down, closing transmission channel"
+
 // rateLimitEntry tracks rate limiting per IP
 type rateLimitEntry struct {
 	count     int
@@ -66,6 +89,8 @@ func NewSMTPServer(config *SMTPConfig, tlsConfig *tls.Config, aliasRepo AliasRep
 		ipConnections: make(map[string]int),
 		ipRateLimit:   make(map[string]*rateLimitEntry),
 		shutdownCh:    make(chan struct{}),
+		drainCh:       make(chan struct{}),
+		sessions:      make(map[*SMTPSession]struct{}),
 	}
 }
 
@@ -80,6 +105,8 @@ func NewSMTPServerWithSSLService(config *SMTPConfig, sslService SSLServiceInterf
 		ipConnections: make(map[string]int),
 		ipRateLimit:   make(map[string]*rateLimitEntry),
 		shutdownCh:    make(chan struct{}),
+		drainCh:       make(chan struct{}),
+		sessions:      make(map[*SMTPSession]struct{}),
 	}
 
 	// Create TLS handler with SSL service
@@ -104,6 +131,8 @@ func NewSMTPServerWithSSLServiceAndFallback(config *SMTPConfig, sslService SSLSe
 		ipConnections: make(map[string]int),
 		ipRateLimit:   make(map[string]*rateLimitEntry),
 		shutdownCh:    make(chan struct{}),
+		drainCh:       make(chan struct{}),
+		sessions:      make(map[*SMTPSession]struct{}),
 	}
 
 	// Create TLS handler with SSL service and fallback
@@ -150,6 +179,17 @@ func (s *SMTPServer) SetDataCallback(callback func(ctx context.Context, data *Da
 	s.dataCallback = callback
 }
 
+// SetTranscriptStore enables session transcript capture
+// Transcripts are only persisted for sessions matching a domain or sender IP opt-in rule
+func (s *SMTPServer) SetTranscriptStore(store TranscriptStore) {
+	s.transcriptStore = store
+}
+
+// SetSubmission turns this server into an authenticated submission listener
+func (s *SMTPServer) SetSubmission(options *SubmissionOptions) {
+	s.submission = options
+}
+
 // Start starts the SMTP server
 // Requirements: 1.1 (Listen on port 25)
 func (s *SMTPServer) Start() error {
@@ -169,34 +209,105 @@ func (s *SMTPServer) Start() error {
 	return nil
 }
 
-// Stop gracefully stops the SMTP server
+// Stop gracefully stops the SMTP server, draining in-flight transactions for up to DrainTimeout
 func (s *SMTPServer) Stop() error {
-	if !s.running.Load() {
+	timeout := s.config.DrainTimeout
+	if timeout <= 0 {
+		timeout = DefaultDrainTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	
+	// Sessions cut off at the deadline were already told 421 and logged
+	if err := s.Shutdown(ctx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown drains the SMTP server
+// New connections are answered with 421 while draining. Idle sessions are closed
+// with 421 immediately; sessions inside a mail transaction may finish it before
+// receiving 421. Sessions still open when ctx is done get 421 before the socket closes.
+func (s *SMTPServer) Shutdown(ctx context.Context) error {
+	if !s.running.Load() || !s.draining.CompareAndSwap(false, true) {
 		return nil
 	}
 	
-	s.running.Store(false)
-	close(s.shutdownCh)
+	close(s.drainCh)
+	log.Printf("SMTP server draining (%d active transactions)", s.GetActiveTransactions())
 	
-	if s.listener != nil {
-		s.listener.Close()
+	// Idle sessions are blocked reading the next command; wake them so they can say goodbye
+	for _, session := range s.snapshotSessions() {
+		session.wakeIfIdle()
 	}
 	
-	// Wait for all connections to finish with timeout
 	done := make(chan struct{})
 	go func() {
 		s.wg.Wait()
 		close(done)
 	}()
 	
+	var err error
 	select {
 	case <-done:
-		log.Println("SMTP server stopped gracefully")
-	case <-time.After(30 * time.Second):
-		log.Println("SMTP server shutdown timed out")
+		log.Println("SMTP server drained gracefully")
+	case <-ctx.Done():
+		remaining := s.snapshotSessions()
+		log.Printf("SMTP drain deadline reached, closing %d sessions", len(remaining))
+		for _, session := range remaining {
+			session.forceClose()
+		}
+		select {
+		case <-done:
+		case <-time.After(forceCloseGracePeriod):
+			log.Println("SMTP server shutdown timed out")
+		}
+		err = ctx.Err()
 	}
 	
-	return nil
+	s.running.Store(false)
+	close(s.shutdownCh)
+	if s.listener != nil {
+		s.listener.Close()
+	}
+	
+	return err
+}
+
+// IsDraining returns whether the server is draining for shutdown
+func (s *SMTPServer) IsDraining() bool {
+	return s.draining.Load()
+}
+
+// GetActiveTransactions returns the number of sessions inside a mail transaction
+func (s *SMTPServer) GetActiveTransactions() int64 {
+	return atomic.LoadInt64(&s.activeTransactions)
+}
+
+// trackSession registers a running session so drain can reach it
+func (s *SMTPServer) trackSession(session *SMTPSession) {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	s.sessions[session] = struct{}{}
+}
+
+// untrackSession removes a finished session
+func (s *SMTPServer) untrackSession(session *SMTPSession) {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	delete(s.sessions, session)
+}
+
+// snapshotSessions returns the currently running sessions
+func (s *SMTPServer) snapshotSessions() []*SMTPSession {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	sessions := make([]*SMTPSession, 0, len(s.sessions))
+	for session := range s.sessions {
+		sessions = append(sessions, session)
+	}
+	return sessions
 }
 
 // acceptLoop accepts incoming connections
@@ -210,15 +321,24 @@ func (s *SMTPServer) acceptLoop() {
 			continue
 		}
 		
-		go s.handleConnection(conn)
+		// Add before spawning so a concurrent Shutdown always waits for this connection
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handleConnection(conn)
+		}()
 	}
 }
 
 // handleConnection handles a single SMTP connection
 // Requirements: 1.6, 1.7, 1.8, 6.3, 6.5, 4.1, 4.6, 4.7
 func (s *SMTPServer) handleConnection(conn net.Conn) {
-	s.wg.Add(1)
-	defer s.wg.Done()
+	// Refuse new sessions while draining
+	if s.draining.Load() {
+		s.sendResponse(conn, CodeServiceUnavailable, fmt.Sprintf("%s %s", s.config.Hostname, shutdownMessage))
+		conn.Close()
+		return
+	}
 	
 	remoteAddr := conn.RemoteAddr().String()
 	remoteIP, _, err := net.SplitHostPort(remoteAddr)
@@ -261,6 +381,15 @@ func (s *SMTPServer) handleConnection(conn net.Conn) {
 	
 	// Create and run session
 	session := NewSMTPSessionWithCallback(conn, s.config, tlsConfig, s.aliasRepo, remoteIP, s.dataCallback)
+	if s.transcriptStore != nil {
+		session.SetTranscriptRecorder(NewTranscriptRecorder(s.transcriptStore, remoteIP))
+	}
+	session.submission = s.submission
+	session.drainCh = s.drainCh
+	session.txCounter = &s.activeTransactions
+	
+	s.trackSession(session)
+	defer s.untrackSession(session)
 	session.Run()
 }
 
@@ -371,6 +500,7 @@ func DefaultSMTPConfig() *SMTPConfig {
 		MaxMessageSize:      25 * 1024 * 1024, // 25 MB (Requirement 1.9)
 		MaxRecipients:       100,  // Requirement 2.6
 		RateLimitPerMinute:  20,   // Requirement 6.3
+		DrainTimeout:        DefaultDrainTimeout,
 	}
 }
 
@@ -385,6 +515,8 @@ type HealthStatus struct {
 	TLSEnabled      bool   `json:"tls_enabled"`
 	Hostname        string `json:"hostname"`
 	Port            int    `json:"port"`
+	Draining        bool   `json:"draining"`
+	ActiveTransactions int64 `json:"active_transactions"`
 }
 
 // HealthCheck returns the current health status of the SMTP server
@@ -398,11 +530,16 @@ func (s *SMTPServer) HealthCheck() HealthStatus {
 		TLSEnabled:  s.tlsConfig != nil || s.tlsHandler != nil,
 		Hostname:    s.config.Hostname,
 		Port:        s.config.Port,
+		Draining:    s.draining.Load(),
+		ActiveTransactions: s.GetActiveTransactions(),
 	}
 }
 
-// getHealthStatus returns "healthy" if server is running, "unhealthy" otherwise
+// getHealthStatus returns "draining" during shutdown, "healthy" if server is running, "unhealthy" otherwise
 func (s *SMTPServer) getHealthStatus() string {
+	if s.draining.Load() {
+		return "draining"
+	}
 	if s.running.Load() {
 		return "healthy"
 	}
@@ -3,11 +3,14 @@ package smtp
 import (
 	"bufio"
 	"context"
+	"crypto/rand"
 	"crypto/tls"
+	"errors"
 	"fmt"
-	"io"
 	"net"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -23,6 +26,19 @@ type SMTPSession struct {
 	state          *SessionState
 	ehloReceived   bool
 	dataCallback   func(ctx context.Context, data *DataResult) error // Callback for processing email data
+	transcript     *TranscriptRecorder                               // Optional transcript capture (nil when disabled)
+	
+	// Authenticated submission support (nil for inbound MX sessions)
+	submission     *SubmissionOptions
+	authUserID     string
+	authFailures   int
+	
+	// Graceful drain support (nil when the session is not managed by a server)
+	drainCh        <-chan struct{} // closed when the server starts draining
+	txCounter      *int64          // server-wide count of sessions inside a mail transaction
+	inTransaction  atomic.Bool
+	closing        atomic.Bool
+	connMu         sync.Mutex      // serializes writes and conn swaps against a forced close
 }
 
 // NewSMTPSession creates a new SMTP session
@@ -55,19 +71,28 @@ func NewSMTPSessionWithCallback(conn net.Conn, config *SMTPConfig, tlsConfig *tl
 // Run starts the SMTP session
 // Requirement 1.4: Respond with 220 greeting
 func (s *SMTPSession) Run() {
+	// Persist the transcript after the connection is closed so the client never waits on it
+	defer s.finishTranscript()
 	defer s.conn.Close()
+	defer s.setInTransaction(false)
 	
 	// Send greeting (Requirement 1.4)
 	s.sendResponse(CodeServiceReady, fmt.Sprintf("%s %s", s.config.Hostname, SMTPResponses[CodeServiceReady]))
 	
 	for {
-		// Reset deadline on each command
-		s.conn.SetDeadline(time.Now().Add(s.config.ConnectionTimeout))
+		// Reset deadline on each command; stop between transactions once draining
+		if !s.prepareNextCommand() {
+			s.sendShutdownNotice()
+			return
+		}
 		
-		line, err := s.reader.ReadString('\n')
+		line, err := s.readCommandLine()
 		if err != nil {
-			if err != io.EOF {
-				// Log error but don't send response (connection may be closed)
+			// Connection closed or timed out; say goodbye if this is a drain wake-up
+			if s.closing.Load() {
+				s.transcript.RecordResponse(fmt.Sprintf("%d %s %s", CodeServiceUnavailable, s.config.Hostname, shutdownMessage))
+			} else if s.isDraining() {
+				s.sendShutdownNotice()
 			}
 			return
 		}
@@ -77,6 +102,8 @@ func (s *SMTPSession) Run() {
 			continue
 		}
 		
+		s.transcript.RecordCommand(line)
+		
 		// Parse command
 		cmd, args := s.parseCommand(line)
 		
@@ -106,6 +133,8 @@ func (s *SMTPSession) handleCommand(cmd, args string) bool {
 		s.handleEHLO(args)
 	case "STARTTLS":
 		s.handleSTARTTLS()
+	case "AUTH":
+		return s.handleAUTH(args)
 	case "MAIL":
 		s.handleMAILFROM(args)
 	case "RCPT":
@@ -135,6 +164,7 @@ func (s *SMTPSession) handleEHLO(domain string) {
 	
 	s.ehloReceived = true
 	s.resetTransaction()
+	s.transcript.SetHelo(domain)
 	
 	// Build capabilities list (Requirement 1.5)
 	capabilities := []string{
@@ -148,6 +178,11 @@ func (s *SMTPSession) handleEHLO(domain string) {
 		capabilities = append(capabilities, "STARTTLS")
 	}
 	
+	// Submission sessions only offer AUTH once the channel is encrypted
+	if s.submission != nil && s.state.TLSEnabled {
+		capabilities = append(capabilities, "AUTH PLAIN LOGIN")
+	}
+	
 	// Send multi-line response
 	for i, cap := range capabilities {
 		if i == len(capabilities)-1 {
@@ -188,11 +223,14 @@ func (s *SMTPSession) handleSTARTTLS() {
 	tlsConn.SetDeadline(time.Now().Add(s.config.ConnectionTimeout))
 	
 	// Update connection and readers/writers
+	s.connMu.Lock()
 	s.conn = tlsConn
 	s.reader = bufio.NewReader(tlsConn)
 	s.writer = bufio.NewWriter(tlsConn)
+	s.connMu.Unlock()
 	s.state.TLSEnabled = true
 	s.state.Conn = tlsConn
+	s.transcript.SetTLSEnabled()
 	
 	// Reset EHLO state after STARTTLS
 	s.ehloReceived = false
@@ -207,6 +245,11 @@ func (s *SMTPSession) handleMAILFROM(args string) {
 		return
 	}
 	
+	// Submission requires STARTTLS and AUTH before a transaction can start
+	if s.submission != nil && !s.requireAuthenticated() {
+		return
+	}
+	
 	// Parse MAIL FROM:<address>
 	if !strings.HasPrefix(strings.ToUpper(args), "FROM:") {
 		s.sendResponse(CodeSyntaxErrorParams, "Syntax error in parameters")
@@ -244,7 +287,14 @@ func (s *SMTPSession) handleMAILFROM(args string) {
 		return
 	}
 	
+	// Submission restricts the envelope sender to the user's own aliases
+	if s.submission != nil && !s.checkSenderOwnership(address) {
+		return
+	}
+	
 	s.state.MailFrom = address
+	s.setInTransaction(true)
+	s.transcript.AddMailFrom(address)
 	s.sendResponse(CodeOK, SMTPResponses[CodeOK])
 }
 
@@ -284,6 +334,15 @@ func (s *SMTPSession) handleRCPTTO(args string) {
 		return
 	}
 	
+	// Record the attempt before lookup so rejected recipients still match domain opt-in
+	s.transcript.AddRecipient(address)
+	
+	// Authenticated submission relays to any recipient
+	if s.submission != nil {
+		s.addRecipient(address)
+		return
+	}
+	
 	// Validate recipient exists in aliases table (Requirements 2.1-2.5, Property 3)
 	// Case-insensitive lookup is handled by the repository (Requirement 2.5)
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -302,6 +361,11 @@ func (s *SMTPSession) handleRCPTTO(args string) {
 		return
 	}
 	
+	s.addRecipient(address)
+}
+
+// addRecipient adds a validated recipient to the transaction and replies 250
+func (s *SMTPSession) addRecipient(address string) {
 	// Check for duplicate recipients
 	lowerAddress := strings.ToLower(address)
 	for _, rcpt := range s.state.Recipients {
@@ -338,6 +402,9 @@ func (s *SMTPSession) handleDATA() {
 		return
 	}
 	
+	// Message bodies are never captured in transcripts
+	s.transcript.RecordDataOmitted(len(data))
+	
 	// Record received_at timestamp in UTC (Requirement 3.5)
 	receivedAt := time.Now().UTC()
 	
@@ -355,9 +422,16 @@ func (s *SMTPSession) handleDATA() {
 		MailFrom:   s.state.MailFrom,
 	}
 	
-	// Call data callback if configured (for email processing)
-	// Requirements: All - Process email through parser → attachment handler → repositories
-	if s.dataCallback != nil {
+	// Authenticated submission queues the message for outbound delivery instead
+	if s.submission != nil {
+		if err := s.enqueueSubmission(s.state.DataResult); err != nil {
+			s.sendResponse(CodeTempFailure, SMTPResponses[CodeTempFailure])
+			s.resetTransaction()
+			return
+		}
+	} else if s.dataCallback != nil {
+		// Call data callback if configured (for email processing)
+		// Requirements: All - Process email through parser → attachment handler → repositories
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 		
@@ -372,6 +446,7 @@ func (s *SMTPSession) handleDATA() {
 	}
 	
 	// Requirement 3.3: Respond with 250 OK and queue ID
+	s.transcript.AddQueueID(queueID)
 	s.sendResponse(CodeOK, fmt.Sprintf("OK queued as %s", queueID))
 	
 	// Reset transaction state for next message
@@ -457,22 +532,145 @@ func (s *SMTPSession) resetTransaction() {
 	s.state.MailFrom = ""
 	s.state.Recipients = make([]string, 0)
 	s.state.MessageSize = 0
+	s.setInTransaction(false)
 }
 
 // sendResponse sends an SMTP response
 func (s *SMTPSession) sendResponse(code int, message string) {
 	response := fmt.Sprintf("%d %s\r\n", code, message)
-	s.writer.WriteString(response)
-	s.writer.Flush()
+	s.transcript.RecordResponse(response)
+	s.write(response)
 }
 
 // sendMultilineResponse sends a multi-line SMTP response
 func (s *SMTPSession) sendMultilineResponse(code int, message string) {
 	response := fmt.Sprintf("%d-%s\r\n", code, message)
+	s.transcript.RecordResponse(response)
+	s.write(response)
+}
+
+// write writes and flushes a response unless the session was force-closed
+func (s *SMTPSession) write(response string) {
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+	if s.closing.Load() {
+		return
+	}
 	s.writer.WriteString(response)
 	s.writer.Flush()
 }
 
+// isDraining reports whether the owning server has started draining
+func (s *SMTPSession) isDraining() bool {
+	if s.drainCh == nil {
+		return false
+	}
+	select {
+	case <-s.drainCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// setInTransaction tracks MAIL FROM..DATA/RSET boundaries for drain and health reporting
+func (s *SMTPSession) setInTransaction(active bool) {
+	if s.inTransaction.Swap(active) == active || s.txCounter == nil {
+		return
+	}
+	if active {
+		atomic.AddInt64(s.txCounter, 1)
+	} else {
+		atomic.AddInt64(s.txCounter, -1)
+	}
+}
+
+// prepareNextCommand resets the idle deadline before reading the next command
+// Returns false if the session should close because the server is draining and
+// no transaction is in progress. The deadline is set under connMu so a concurrent
+// wakeIfIdle is never overwritten.
+func (s *SMTPSession) prepareNextCommand() bool {
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+	s.conn.SetDeadline(time.Now().Add(s.config.ConnectionTimeout))
+	return !s.isDraining() || s.inTransaction.Load()
+}
+
+// readCommandLine reads one command line
+// A session inside a transaction that is woken by drain keeps reading; partial
+// input read before the wake-up is preserved.
+func (s *SMTPSession) readCommandLine() (string, error) {
+	var line string
+	for {
+		part, err := s.reader.ReadString('\n')
+		line += part
+		if err == nil {
+			return line, nil
+		}
+		
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() &&
+			s.isDraining() && s.inTransaction.Load() && !s.closing.Load() {
+			s.connMu.Lock()
+			s.conn.SetDeadline(time.Now().Add(s.config.ConnectionTimeout))
+			s.connMu.Unlock()
+			continue
+		}
+		return line, err
+	}
+}
+
+// wakeIfIdle interrupts a session waiting for its next command outside a transaction
+func (s *SMTPSession) wakeIfIdle() {
+	if s.inTransaction.Load() {
+		return
+	}
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+	s.conn.SetReadDeadline(time.Now())
+}
+
+// forceClose sends 421 and closes the connection when the drain deadline is reached
+func (s *SMTPSession) forceClose() {
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+	if s.closing.Swap(true) {
+		return
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	s.writer.WriteString(fmt.Sprintf("%d %s %s\r\n", CodeServiceUnavailable, s.config.Hostname, shutdownMessage))
+	s.writer.Flush()
+	s.conn.Close()
+}
+
+// sendShutdownNotice tells the client the server is going away
+func (s *SMTPSession) sendShutdownNotice() {
+	s.connMu.Lock()
+	s.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	s.connMu.Unlock()
+	s.sendResponse(CodeServiceUnavailable, fmt.Sprintf("%s %s", s.config.Hostname, shutdownMessage))
+}
+
+// SetSubmission enables authenticated submission mode for this session
+func (s *SMTPSession) SetSubmission(options *SubmissionOptions) {
+	s.submission = options
+}
+
+// SetTranscriptRecorder enables transcript capture for this session
+func (s *SMTPSession) SetTranscriptRecorder(recorder *TranscriptRecorder) {
+	s.transcript = recorder
+}
+
+// finishTranscript hands the recorded transcript to the store, if capture is enabled
+func (s *SMTPSession) finishTranscript() {
+	if s.transcript == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s.transcript.Finish(ctx)
+}
+
 // generateQueueID generates a unique queue ID for the message
 func generateQueueID() string {
 	return fmt.Sprintf("%d", time.Now().UnixNano())
@@ -480,9 +678,10 @@ func generateQueueID() string {
 
 // GenerateQueueID generates a unique queue ID for the message
 // Requirement 3.3: Generate queue ID
-// Uses timestamp + random component for uniqueness
+// Uses timestamp + random component for uniqueness, so IDs generated in the same clock tick differ
 func GenerateQueueID() string {
-	// Format: timestamp in hex + random suffix for uniqueness
-	timestamp := time.Now().UnixNano()
-	return fmt.Sprintf("%x", timestamp)
+	// Format: timestamp in hex + 8 random hex characters
+	var suffix [4]byte
+	rand.Read(suffix[:])
+	return fmt.Sprintf("%x%x", time.Now().UnixNano(), suffix)
 }
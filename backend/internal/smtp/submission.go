@@ -0,0 +1,256 @@
+package smtp
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// DefaultSubmissionPort is the standard message submission port (RFC 6409)
+const DefaultSubmissionPort = 587
+
+// MaxAuthFailures is the number of failed AUTH attempts before the session is closed
+const MaxAuthFailures = 3
+
+// errAuthRejected means the AUTH exchange was refused and a reply was already sent
+var errAuthRejected = errors.New("authentication exchange rejected")
+
+// SubmissionAuthenticator verifies AUTH credentials against per-user app passwords
+type SubmissionAuthenticator interface {
+	AuthenticateAppPassword(ctx context.Context, username, password, remoteIP string) (string, error)
+}
+
+// SenderOwnershipChecker verifies that an authenticated user may use an envelope sender
+type SenderOwnershipChecker interface {
+	IsSenderOwnedByUser(ctx context.Context, userID, address string) (bool, error)
+}
+
+// OutboundQueue accepts submitted messages for outbound delivery
+type OutboundQueue interface {
+	Enqueue(ctx context.Context, message *OutboundMessage) error
+}
+
+// OutboundMessage is a message accepted on the submission listener
+type OutboundMessage struct {
+	UserID     string
+	QueueID    string
+	MailFrom   string
+	Recipients []string
+	Data       []byte
+	SizeBytes  int64
+	ReceivedAt time.Time
+}
+
+// SubmissionOptions turns a server into an authenticated submission listener
+// Sessions require STARTTLS and AUTH before MAIL, MAIL FROM must be an alias owned by
+// the authenticated user, recipients are not limited to local aliases and accepted
+// messages go to the outbound queue instead of local delivery
+type SubmissionOptions struct {
+	Authenticator SubmissionAuthenticator
+	Senders       SenderOwnershipChecker
+	Queue         OutboundQueue
+}
+
+// handleAUTH handles the AUTH command (RFC 4954) with the PLAIN and LOGIN mechanisms
+// Returns true when the session must end
+func (s *SMTPSession) handleAUTH(args string) bool {
+	if s.submission == nil {
+		s.sendResponse(CodeSyntaxError, "Command not recognized")
+		return false
+	}
+
+	if !s.ehloReceived {
+		s.sendResponse(CodeBadSequence, "Send EHLO first")
+		return false
+	}
+
+	if !s.state.TLSEnabled {
+		s.sendResponse(CodeAuthRequired, "5.7.0 Must issue a STARTTLS command first")
+		return false
+	}
+
+	if s.authUserID != "" {
+		s.sendResponse(CodeBadSequence, "5.5.1 Already authenticated")
+		return false
+	}
+
+	if s.state.MailFrom != "" {
+		s.sendResponse(CodeBadSequence, "5.5.1 AUTH not permitted during a mail transaction")
+		return false
+	}
+
+	mechanism, initial, _ := strings.Cut(strings.TrimSpace(args), " ")
+	initial = strings.TrimSpace(initial)
+
+	var username, password string
+	var err error
+	switch strings.ToUpper(mechanism) {
+	case "PLAIN":
+		username, password, err = s.readAuthPlain(initial)
+	case "LOGIN":
+		username, password, err = s.readAuthLogin(initial)
+	default:
+		s.sendResponse(CodeParamNotImplemented, "5.5.4 Unrecognized authentication type")
+		return false
+	}
+	if err != nil {
+		// Anything other than a refused exchange means the connection is gone
+		return !errors.Is(err, errAuthRejected)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, err := s.submission.Authenticator.AuthenticateAppPassword(ctx, username, password, s.state.RemoteIP)
+	if err != nil {
+		s.authFailures++
+		log.Printf("SMTP submission AUTH failed for %q from %s: %v", username, s.state.RemoteIP, err)
+		if s.authFailures >= MaxAuthFailures {
+			s.sendResponse(CodeServiceUnavailable, "4.7.0 Too many authentication failures")
+			return true
+		}
+		s.sendResponse(CodeAuthFailed, "5.7.8 Authentication credentials invalid")
+		return false
+	}
+
+	s.authUserID = userID
+	s.sendResponse(CodeAuthSuccess, "2.7.0 Authentication successful")
+	return false
+}
+
+// readAuthPlain reads PLAIN credentials, prompting for them if no initial response was sent
+func (s *SMTPSession) readAuthPlain(initial string) (string, string, error) {
+	response := initial
+	if response == "" {
+		var err error
+		if response, err = s.readAuthResponse(""); err != nil {
+			return "", "", err
+		}
+	}
+
+	decoded, err := s.decodeAuthResponse(response)
+	if err != nil {
+		return "", "", err
+	}
+
+	// authzid NUL authcid NUL passwd; a differing authorization identity is not supported
+	parts := strings.Split(decoded, "\x00")
+	if len(parts) != 3 || (parts[0] != "" && !strings.EqualFold(parts[0], parts[1])) {
+		s.sendResponse(CodeSyntaxErrorParams, "5.5.2 Invalid PLAIN credentials")
+		return "", "", errAuthRejected
+	}
+
+	return parts[1], parts[2], nil
+}
+
+// readAuthLogin reads LOGIN credentials through the Username:/Password: challenges
+func (s *SMTPSession) readAuthLogin(initial string) (string, string, error) {
+	usernameResponse := initial
+	if usernameResponse == "" {
+		var err error
+		if usernameResponse, err = s.readAuthResponse("VXNlcm5hbWU6"); err != nil {
+			return "", "", err
+		}
+	}
+
+	username, err := s.decodeAuthResponse(usernameResponse)
+	if err != nil {
+		return "", "", err
+	}
+
+	passwordResponse, err := s.readAuthResponse("UGFzc3dvcmQ6")
+	if err != nil {
+		return "", "", err
+	}
+
+	password, err := s.decodeAuthResponse(passwordResponse)
+	if err != nil {
+		return "", "", err
+	}
+
+	return username, password, nil
+}
+
+// readAuthResponse sends a 334 challenge and reads the client's response line
+// Responses carry credentials and are never recorded in the transcript
+func (s *SMTPSession) readAuthResponse(challenge string) (string, error) {
+	s.sendResponse(CodeAuthContinue, challenge)
+
+	line, err := s.readCommandLine()
+	if err != nil {
+		return "", err
+	}
+
+	line = strings.TrimSpace(line)
+	if line == "*" {
+		s.sendResponse(CodeSyntaxErrorParams, "5.0.0 Authentication cancelled")
+		return "", errAuthRejected
+	}
+
+	return line, nil
+}
+
+// decodeAuthResponse decodes a base64 AUTH response
+func (s *SMTPSession) decodeAuthResponse(response string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(response)
+	if err != nil {
+		s.sendResponse(CodeSyntaxErrorParams, "5.5.2 Cannot decode response")
+		return "", errAuthRejected
+	}
+	return string(decoded), nil
+}
+
+// requireAuthenticated rejects MAIL until the submission session has TLS and AUTH
+func (s *SMTPSession) requireAuthenticated() bool {
+	if !s.state.TLSEnabled {
+		s.sendResponse(CodeAuthRequired, "5.7.0 Must issue a STARTTLS command first")
+		return false
+	}
+	if s.authUserID == "" {
+		s.sendResponse(CodeAuthRequired, "5.7.0 Authentication required")
+		return false
+	}
+	return true
+}
+
+// checkSenderOwnership verifies MAIL FROM is an active alias owned by the authenticated user
+func (s *SMTPSession) checkSenderOwnership(address string) bool {
+	if address == "" {
+		s.sendResponse(CodeMailboxNameNotAllowed, "5.7.1 Null sender not permitted for submission")
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	owned, err := s.submission.Senders.IsSenderOwnedByUser(ctx, s.authUserID, strings.ToLower(address))
+	if err != nil {
+		s.sendResponse(CodeTempFailure, SMTPResponses[CodeTempFailure])
+		return false
+	}
+	if !owned {
+		s.sendResponse(CodeMailboxNameNotAllowed, fmt.Sprintf("5.7.1 Sender address <%s> not owned by authenticated user", address))
+		return false
+	}
+	return true
+}
+
+// enqueueSubmission hands an accepted message to the outbound queue
+func (s *SMTPSession) enqueueSubmission(data *DataResult) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	return s.submission.Queue.Enqueue(ctx, &OutboundMessage{
+		UserID:     s.authUserID,
+		QueueID:    data.QueueID,
+		MailFrom:   data.MailFrom,
+		Recipients: data.Recipients,
+		Data:       data.Data,
+		SizeBytes:  data.SizeBytes,
+		ReceivedAt: data.ReceivedAt,
+	})
+}
@@ -0,0 +1,268 @@
+package smtp
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// mockSubmissionAuthenticator accepts a single username/password pair
+type mockSubmissionAuthenticator struct {
+	username string
+	password string
+	userID   string
+}
+
+func (m *mockSubmissionAuthenticator) AuthenticateAppPassword(ctx context.Context, username, password, remoteIP string) (string, error) {
+	if strings.EqualFold(username, m.username) && password == m.password {
+		return m.userID, nil
+	}
+	return "", errors.New("invalid credentials")
+}
+
+// mockSenderChecker maps user IDs to the sender addresses they own
+type mockSenderChecker struct {
+	owned map[string][]string
+}
+
+func (m *mockSenderChecker) IsSenderOwnedByUser(ctx context.Context, userID, address string) (bool, error) {
+	for _, a := range m.owned[userID] {
+		if strings.EqualFold(a, address) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// mockOutboundQueue records enqueued messages
+type mockOutboundQueue struct {
+	mu       sync.Mutex
+	messages []*OutboundMessage
+}
+
+func (m *mockOutboundQueue) Enqueue(ctx context.Context, message *OutboundMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, message)
+	return nil
+}
+
+func (m *mockOutboundQueue) snapshot() []*OutboundMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*OutboundMessage(nil), m.messages...)
+}
+
+func startSubmissionTestServer(t *testing.T) (string, *mockOutboundQueue) {
+	t.Helper()
+	certPath, keyPath, err := GenerateSelfSignedCert("mail.test.local", t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to generate certificate: %v", err)
+	}
+	tlsConfig, err := LoadTLSConfig(certPath, keyPath)
+	if err != nil {
+		t.Fatalf("Failed to load TLS config: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find available port: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	config := DefaultSMTPConfig()
+	config.Port = port
+	config.Hostname = "mail.test.local"
+	config.RateLimitPerMinute = 1000
+
+	queue := &mockOutboundQueue{}
+	server := NewSMTPServer(config, tlsConfig, NewMockAliasRepository())
+	server.SetSubmission(&SubmissionOptions{
+		Authenticator: &mockSubmissionAuthenticator{username: "owner@example.com", password: "abcdefghijklmnop", userID: "user-1"},
+		Senders:       &mockSenderChecker{owned: map[string][]string{"user-1": {"me@webrana.id"}}},
+		Queue:         queue,
+	})
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	t.Cleanup(func() { server.Stop() })
+
+	return fmt.Sprintf("127.0.0.1:%d", port), queue
+}
+
+// startTLS upgrades the test client connection after a 220 reply to STARTTLS
+func (c *drainTestClient) startTLS() {
+	c.t.Helper()
+	c.cmd("STARTTLS", "220")
+	tlsConn := tls.Client(c.conn, &tls.Config{InsecureSkipVerify: true, MinVersion: tls.VersionTLS12})
+	if err := tlsConn.Handshake(); err != nil {
+		c.t.Fatalf("TLS handshake failed: %v", err)
+	}
+	c.conn = tlsConn
+	c.reader = bufio.NewReader(tlsConn)
+}
+
+// ehlo sends EHLO and returns every line of the reply
+func (c *drainTestClient) ehlo() string {
+	c.t.Helper()
+	fmt.Fprintf(c.conn, "EHLO client.example\r\n")
+	var lines []string
+	for {
+		line, err := c.reader.ReadString('\n')
+		if err != nil {
+			c.t.Fatalf("Failed to read EHLO reply: %v", err)
+		}
+		lines = append(lines, strings.TrimSpace(line))
+		if len(line) < 4 || line[3] != '-' {
+			return strings.Join(lines, "\n")
+		}
+	}
+}
+
+func plainCredentials(username, password string) string {
+	return base64.StdEncoding.EncodeToString([]byte("\x00" + username + "\x00" + password))
+}
+
+// TestSubmission_RequiresTLSBeforeAuth verifies AUTH and MAIL are refused on a plaintext channel
+func TestSubmission_RequiresTLSBeforeAuth(t *testing.T) {
+	addr, _ := startSubmissionTestServer(t)
+
+	c := dialDrainTestClient(t, addr)
+	defer c.conn.Close()
+	c.readReply()
+
+	capabilities := c.ehlo()
+	if strings.Contains(capabilities, "AUTH") {
+		t.Fatalf("AUTH must not be advertised before STARTTLS:\n%s", capabilities)
+	}
+	if !strings.Contains(capabilities, "STARTTLS") {
+		t.Fatalf("Expected STARTTLS capability:\n%s", capabilities)
+	}
+
+	c.cmd("AUTH PLAIN "+plainCredentials("owner@example.com", "abcdefghijklmnop"), "530")
+	c.cmd("MAIL FROM:<me@webrana.id>", "530")
+}
+
+// TestSubmission_AuthPlainAndSenderOwnership verifies the full authenticated flow
+func TestSubmission_AuthPlainAndSenderOwnership(t *testing.T) {
+	addr, queue := startSubmissionTestServer(t)
+
+	c := dialDrainTestClient(t, addr)
+	defer c.conn.Close()
+	c.readReply()
+	c.ehlo()
+	c.startTLS()
+
+	if capabilities := c.ehlo(); !strings.Contains(capabilities, "AUTH PLAIN LOGIN") {
+		t.Fatalf("Expected AUTH capability after STARTTLS:\n%s", capabilities)
+	}
+
+	c.cmd("MAIL FROM:<me@webrana.id>", "530")
+	c.cmd("AUTH PLAIN "+plainCredentials("owner@example.com", "wrong-password"), "535")
+	c.cmd("AUTH PLAIN "+plainCredentials("owner@example.com", "abcdefghijklmnop"), "235")
+	c.cmd("AUTH PLAIN "+plainCredentials("owner@example.com", "abcdefghijklmnop"), "503")
+
+	c.cmd("MAIL FROM:<someone-else@webrana.id>", "553")
+	c.cmd("MAIL FROM:<>", "553")
+	c.cmd("MAIL FROM:<Me@Webrana.id>", "250")
+	c.cmd("RCPT TO:<friend@external.example>", "250")
+	c.cmd("DATA", "354")
+	fmt.Fprintf(c.conn, "Subject: hello\r\n\r\nbody\r\n.\r\n")
+	if reply := c.readReply(); !strings.HasPrefix(reply, "250") {
+		t.Fatalf("Expected 250 after DATA, got %q", reply)
+	}
+
+	messages := queue.snapshot()
+	if len(messages) != 1 {
+		t.Fatalf("Expected 1 queued message, got %d", len(messages))
+	}
+	msg := messages[0]
+	if msg.UserID != "user-1" || msg.MailFrom != "Me@Webrana.id" || len(msg.Recipients) != 1 || msg.Recipients[0] != "friend@external.example" {
+		t.Fatalf("Unexpected queued message: %+v", msg)
+	}
+	if !strings.Contains(string(msg.Data), "Subject: hello") || msg.QueueID == "" {
+		t.Fatalf("Expected message data and queue ID, got %+v", msg)
+	}
+}
+
+// TestSubmission_AuthLogin verifies the LOGIN mechanism challenges
+func TestSubmission_AuthLogin(t *testing.T) {
+	addr, _ := startSubmissionTestServer(t)
+
+	c := dialDrainTestClient(t, addr)
+	defer c.conn.Close()
+	c.readReply()
+	c.ehlo()
+	c.startTLS()
+	c.ehlo()
+
+	if reply := c.cmd("AUTH LOGIN", "334"); reply != "334 VXNlcm5hbWU6" {
+		t.Fatalf("Expected Username: challenge, got %q", reply)
+	}
+	if reply := c.cmd(base64.StdEncoding.EncodeToString([]byte("owner@example.com")), "334"); reply != "334 UGFzc3dvcmQ6" {
+		t.Fatalf("Expected Password: challenge, got %q", reply)
+	}
+	c.cmd(base64.StdEncoding.EncodeToString([]byte("abcdefghijklmnop")), "235")
+	c.cmd("MAIL FROM:<me@webrana.id>", "250")
+}
+
+// TestSubmission_TooManyAuthFailures verifies the session is closed after repeated failures
+func TestSubmission_TooManyAuthFailures(t *testing.T) {
+	addr, _ := startSubmissionTestServer(t)
+
+	c := dialDrainTestClient(t, addr)
+	defer c.conn.Close()
+	c.readReply()
+	c.ehlo()
+	c.startTLS()
+	c.ehlo()
+
+	// Cancelling an exchange is not a failed attempt
+	c.cmd("AUTH LOGIN", "334")
+	c.cmd("*", "501")
+	for i := 1; i < MaxAuthFailures; i++ {
+		c.cmd("AUTH PLAIN "+plainCredentials("owner@example.com", "nope"), "535")
+	}
+	c.cmd("AUTH PLAIN "+plainCredentials("owner@example.com", "nope"), "421")
+	if _, err := c.reader.ReadString('\n'); err == nil {
+		t.Fatal("Expected connection to be closed after too many failures")
+	}
+}
+
+// TestSubmission_InboundRejectsAuth verifies the MX listener does not offer AUTH
+func TestSubmission_InboundRejectsAuth(t *testing.T) {
+	session, conn := createDataTestSession("EHLO client.example\r\nAUTH PLAIN AGZvbwBiYXI=\r\nQUIT\r\n", 1024*1024)
+	session.Run()
+
+	output := conn.GetOutput()
+	if strings.Contains(output, "AUTH") {
+		t.Fatalf("Inbound session must not advertise AUTH:\n%s", output)
+	}
+	if !strings.Contains(output, "500 Command not recognized") {
+		t.Fatalf("Expected AUTH to be rejected on inbound session:\n%s", output)
+	}
+}
+
+// TestTranscript_AuthRedacted verifies AUTH initial responses never reach the transcript
+func TestTranscript_AuthRedacted(t *testing.T) {
+	recorder := NewTranscriptRecorder(newMockTranscriptStore(), "203.0.113.7")
+	secret := plainCredentials("owner@example.com", "abcdefghijklmnop")
+	recorder.RecordCommand("AUTH PLAIN " + secret)
+	recorder.RecordCommand("auth login")
+
+	entries := recorder.Transcript().Entries
+	if entries[0].Line != "AUTH PLAIN ***" {
+		t.Fatalf("Expected redacted AUTH line, got %q", entries[0].Line)
+	}
+	if entries[1].Line != "auth login" {
+		t.Fatalf("Expected AUTH without initial response to be kept, got %q", entries[1].Line)
+	}
+}
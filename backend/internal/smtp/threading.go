@@ -0,0 +1,146 @@
+// Package smtp provides SMTP server functionality
+// Conversation threading at ingest
+package smtp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ThreadSubjectWindow limits subject-based threading to recent conversations
+const ThreadSubjectWindow = 30 * 24 * time.Hour
+
+// resolveThread assigns email.ThreadID within the alias's mailbox
+// Parents, siblings and children that arrived first are found through Message-ID and References;
+// when the email links several threads they are merged into the oldest one.
+// A reply without a known ancestor falls back to the latest thread with the same base subject.
+// The caller must hold the alias's threading lock (see lockAliasThreads).
+func (r *PgxEmailRepository) resolveThread(ctx context.Context, tx pgx.Tx, email *Email) error {
+	email.ThreadID = email.ID
+
+	var messageID *string
+	if email.MessageID != nil && *email.MessageID != "" {
+		messageID = email.MessageID
+	}
+
+	if messageID != nil || len(email.ThreadReferences) > 0 {
+		threads, err := relatedThreads(ctx, tx, email.AliasID, messageID, nonNilReferences(email.ThreadReferences))
+		if err != nil {
+			return err
+		}
+
+		if len(threads) > 0 {
+			email.ThreadID = threads[0]
+			if len(threads) > 1 {
+				if err := mergeThreads(ctx, tx, email.AliasID, threads[0], threads[1:]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	if email.IsReply && email.ThreadSubject != nil && *email.ThreadSubject != "" {
+		threadID, err := threadBySubject(ctx, tx, email.AliasID, *email.ThreadSubject, email.ReceivedAt.UTC().Add(-ThreadSubjectWindow))
+		if err != nil {
+			return err
+		}
+		if threadID != uuid.Nil {
+			email.ThreadID = threadID
+		}
+	}
+
+	return nil
+}
+
+// lockAliasThreads serializes thread resolution for one alias until the transaction ends,
+// so two replies arriving together cannot start separate threads
+func lockAliasThreads(ctx context.Context, tx pgx.Tx, aliasID uuid.UUID) error {
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, aliasID.String()); err != nil {
+		return fmt.Errorf("failed to lock alias threads: %w", err)
+	}
+	return nil
+}
+
+// relatedThreads returns the threads containing the email's ancestors, siblings, children or duplicates, oldest first
+func relatedThreads(ctx context.Context, tx pgx.Tx, aliasID uuid.UUID, messageID *string, references []string) ([]uuid.UUID, error) {
+	query := `
+		SELECT thread_id
+		FROM emails
+		WHERE alias_id = $1
+		  AND (message_id = ANY($2::text[])
+		       OR thread_references && $2::text[]
+		       OR message_id = $3::text
+		       OR $3::text = ANY(thread_references))
+		GROUP BY thread_id
+		ORDER BY MIN(received_at), thread_id
+	`
+
+	rows, err := tx.Query(ctx, query, aliasID, references, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find related threads: %w", err)
+	}
+	defer rows.Close()
+
+	var threads []uuid.UUID
+	for rows.Next() {
+		var threadID uuid.UUID
+		if err := rows.Scan(&threadID); err != nil {
+			return nil, fmt.Errorf("failed to scan thread: %w", err)
+		}
+		threads = append(threads, threadID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to find related threads: %w", err)
+	}
+
+	return threads, nil
+}
+
+// mergeThreads moves every email of the merged threads into the target thread
+func mergeThreads(ctx context.Context, tx pgx.Tx, aliasID, target uuid.UUID, merged []uuid.UUID) error {
+	ids := make([]string, len(merged))
+	for i, id := range merged {
+		ids[i] = id.String()
+	}
+
+	query := `UPDATE emails SET thread_id = $1 WHERE alias_id = $2 AND thread_id = ANY($3::uuid[])`
+	if _, err := tx.Exec(ctx, query, target, aliasID, ids); err != nil {
+		return fmt.Errorf("failed to merge threads: %w", err)
+	}
+	return nil
+}
+
+// threadBySubject returns the most recent thread with the base subject received since the cutoff, or uuid.Nil
+func threadBySubject(ctx context.Context, tx pgx.Tx, aliasID uuid.UUID, subject string, since time.Time) (uuid.UUID, error) {
+	query := `
+		SELECT thread_id
+		FROM emails
+		WHERE alias_id = $1 AND thread_subject = $2 AND received_at >= $3
+		ORDER BY received_at DESC
+		LIMIT 1
+	`
+
+	var threadID uuid.UUID
+	err := tx.QueryRow(ctx, query, aliasID, subject, since).Scan(&threadID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, nil
+	}
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to find thread by subject: %w", err)
+	}
+	return threadID, nil
+}
+
+// nonNilReferences returns an empty slice for nil so it is stored as '{}' rather than NULL
+func nonNilReferences(references []string) []string {
+	if references == nil {
+		return []string{}
+	}
+	return references
+}
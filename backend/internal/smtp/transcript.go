@@ -0,0 +1,294 @@
+package smtp
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Transcript limits keep per-session memory bounded even for chatty or hostile clients
+const (
+	MaxTranscriptEntries    = 500
+	MaxTranscriptLineLength = 512
+)
+
+// Transcript entry directions
+const (
+	TranscriptDirectionClient = "C"
+	TranscriptDirectionServer = "S"
+)
+
+// TranscriptEntry is a single command or response line in a session transcript
+type TranscriptEntry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Direction string    `json:"direction"`
+	Line      string    `json:"line"`
+
+	// RCPT TO commands and their replies belong to the recipient's domain; other domains never see them
+	recipient       bool
+	recipientDomain string
+}
+
+// SessionTranscript is the recorded command/response exchange of one SMTP session
+// Message bodies are never recorded; the DATA phase is reduced to a size marker.
+type SessionTranscript struct {
+	SessionID  string
+	RemoteIP   string
+	Helo       string
+	MailFrom   []string
+	Recipients []string
+	QueueIDs   []string
+	TLSEnabled bool
+	StartedAt  time.Time
+	EndedAt    time.Time
+	Entries    []TranscriptEntry
+	Truncated  bool
+}
+
+// CaptureTarget is a recipient domain that opted in to capture a session
+type CaptureTarget struct {
+	DomainID string
+	Domain   string // Lowercase domain name
+}
+
+// TranscriptStore persists transcripts for sessions that matched an opt-in rule
+type TranscriptStore interface {
+	// CaptureTargets returns the given recipient domains that opted in to capture,
+	// narrowed by their sender IP rules
+	CaptureTargets(ctx context.Context, remoteIP string, recipientDomains []string) ([]CaptureTarget, error)
+	// Save stores the transcript for one target domain
+	Save(ctx context.Context, transcript *SessionTranscript, domainID string) error
+}
+
+// TranscriptRecorder buffers a session transcript in memory until the session ends
+// Whether the transcript is kept is only decided at the end, because recipient
+// domains are not known until RCPT TO.
+type TranscriptRecorder struct {
+	store            TranscriptStore
+	transcript       *SessionTranscript
+	recipientDomains map[string]struct{}
+
+	// The next reply answers a RCPT TO command for this domain
+	pendingRecipient       bool
+	pendingRecipientDomain string
+}
+
+// NewTranscriptRecorder creates a recorder for a session from remoteIP
+func NewTranscriptRecorder(store TranscriptStore, remoteIP string) *TranscriptRecorder {
+	now := time.Now().UTC()
+	return &TranscriptRecorder{
+		store: store,
+		transcript: &SessionTranscript{
+			SessionID: GenerateQueueID(),
+			RemoteIP:  remoteIP,
+			StartedAt: now,
+			Entries:   make([]TranscriptEntry, 0, 16),
+		},
+		recipientDomains: make(map[string]struct{}),
+	}
+}
+
+// RecordCommand records a line received from the client
+// AUTH credentials are redacted; only the mechanism is kept
+func (r *TranscriptRecorder) RecordCommand(line string) {
+	if r == nil {
+		return
+	}
+	entry := r.newEntry(TranscriptDirectionClient, redactAuthCommand(line))
+	if len(line) >= 4 && strings.EqualFold(line[:4], "RCPT") {
+		entry.recipient = true
+		entry.recipientDomain = recipientDomain(line)
+		r.pendingRecipient, r.pendingRecipientDomain = true, entry.recipientDomain
+	}
+	r.append(entry)
+}
+
+// RecordResponse records a line sent to the client
+func (r *TranscriptRecorder) RecordResponse(line string) {
+	if r == nil {
+		return
+	}
+	entry := r.newEntry(TranscriptDirectionServer, strings.TrimRight(line, "\r\n"))
+	if r.pendingRecipient {
+		entry.recipient = true
+		entry.recipientDomain = r.pendingRecipientDomain
+		r.pendingRecipient, r.pendingRecipientDomain = false, ""
+	}
+	r.append(entry)
+}
+
+// RecordDataOmitted records a marker in place of the message body
+func (r *TranscriptRecorder) RecordDataOmitted(size int) {
+	if r == nil {
+		return
+	}
+	r.append(r.newEntry(TranscriptDirectionClient, fmt.Sprintf("[message body omitted: %d bytes]", size)))
+}
+
+// SetHelo records the EHLO/HELO identity
+func (r *TranscriptRecorder) SetHelo(helo string) {
+	if r == nil {
+		return
+	}
+	r.transcript.Helo = truncateTranscriptLine(helo)
+}
+
+// AddMailFrom records an envelope sender
+func (r *TranscriptRecorder) AddMailFrom(address string) {
+	if r == nil {
+		return
+	}
+	r.transcript.MailFrom = appendUnique(r.transcript.MailFrom, strings.ToLower(address))
+}
+
+// AddRecipient records an attempted recipient, accepted or not
+func (r *TranscriptRecorder) AddRecipient(address string) {
+	if r == nil {
+		return
+	}
+	address = strings.ToLower(address)
+	r.transcript.Recipients = appendUnique(r.transcript.Recipients, address)
+	if at := strings.LastIndex(address, "@"); at != -1 && at < len(address)-1 {
+		r.recipientDomains[address[at+1:]] = struct{}{}
+	}
+}
+
+// AddQueueID records the queue ID of an accepted message
+func (r *TranscriptRecorder) AddQueueID(queueID string) {
+	if r == nil {
+		return
+	}
+	r.transcript.QueueIDs = append(r.transcript.QueueIDs, queueID)
+}
+
+// SetTLSEnabled marks the session as upgraded to TLS
+func (r *TranscriptRecorder) SetTLSEnabled() {
+	if r == nil {
+		return
+	}
+	r.transcript.TLSEnabled = true
+}
+
+// Transcript returns the transcript recorded so far
+func (r *TranscriptRecorder) Transcript() *SessionTranscript {
+	if r == nil {
+		return nil
+	}
+	return r.transcript
+}
+
+// Finish checks the opt-in rules and persists the transcript for each domain that matched
+// Each domain gets its own copy limited to its recipients, so it never sees another domain's addresses.
+func (r *TranscriptRecorder) Finish(ctx context.Context) {
+	if r == nil || r.store == nil {
+		return
+	}
+	r.transcript.EndedAt = time.Now().UTC()
+
+	domains := make([]string, 0, len(r.recipientDomains))
+	for d := range r.recipientDomains {
+		domains = append(domains, d)
+	}
+
+	targets, err := r.store.CaptureTargets(ctx, r.transcript.RemoteIP, domains)
+	if err != nil {
+		log.Printf("Failed to resolve transcript capture targets for %s: %v", r.transcript.RemoteIP, err)
+		return
+	}
+
+	for _, target := range targets {
+		if err := r.store.Save(ctx, r.transcript.forDomain(target.Domain), target.DomainID); err != nil {
+			log.Printf("Failed to save SMTP transcript %s for domain %s: %v", r.transcript.SessionID, target.DomainID, err)
+		}
+	}
+}
+
+// newEntry creates an entry for a line recorded now
+func (r *TranscriptRecorder) newEntry(direction, line string) TranscriptEntry {
+	return TranscriptEntry{
+		Timestamp: time.Now().UTC(),
+		Direction: direction,
+		Line:      truncateTranscriptLine(line),
+	}
+}
+
+// append adds an entry, marking the transcript truncated once the cap is reached
+func (r *TranscriptRecorder) append(entry TranscriptEntry) {
+	if len(r.transcript.Entries) >= MaxTranscriptEntries {
+		r.transcript.Truncated = true
+		return
+	}
+	r.transcript.Entries = append(r.transcript.Entries, entry)
+}
+
+// forDomain returns the copy of a transcript stored for one recipient domain
+// Only that domain's recipients are kept, and RCPT TO commands and replies for other
+// domains, or without a readable address, are dropped.
+func (t *SessionTranscript) forDomain(domain string) *SessionTranscript {
+	scoped := *t
+	scoped.Recipients = nil
+	for _, address := range t.Recipients {
+		if strings.HasSuffix(address, "@"+domain) {
+			scoped.Recipients = append(scoped.Recipients, address)
+		}
+	}
+	scoped.Entries = make([]TranscriptEntry, 0, len(t.Entries))
+	for _, entry := range t.Entries {
+		if entry.recipient && entry.recipientDomain != domain {
+			continue
+		}
+		scoped.Entries = append(scoped.Entries, entry)
+	}
+	return &scoped
+}
+
+// recipientDomain returns the lowercase domain of the address in a RCPT TO command, or "" when there is none
+func recipientDomain(line string) string {
+	_, address, _ := strings.Cut(line, ":")
+	address = strings.TrimPrefix(strings.TrimSpace(address), "<")
+	if end := strings.IndexAny(address, "> "); end != -1 {
+		address = address[:end]
+	}
+	at := strings.LastIndex(address, "@")
+	if at == -1 {
+		return ""
+	}
+	return strings.ToLower(address[at+1:])
+}
+
+// redactAuthCommand replaces any AUTH initial response with a placeholder
+func redactAuthCommand(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) < 3 || !strings.EqualFold(fields[0], "AUTH") {
+		return line
+	}
+	return fields[0] + " " + fields[1] + " ***"
+}
+
+// truncateTranscriptLine caps a line at MaxTranscriptLineLength without splitting a UTF-8 sequence
+func truncateTranscriptLine(line string) string {
+	if len(line) <= MaxTranscriptLineLength {
+		return line
+	}
+	cut := MaxTranscriptLineLength
+	for cut > 0 && !utf8.RuneStart(line[cut]) {
+		cut--
+	}
+	return line[:cut] + "..."
+}
+
+// appendUnique appends value to values if it is not already present
+func appendUnique(values []string, value string) []string {
+	if value == "" {
+		return values
+	}
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,263 @@
+package smtp
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+)
+
+// mockTranscriptStore records CaptureTargets/Save calls for testing
+type mockTranscriptStore struct {
+	mu             sync.Mutex
+	optedInDomains map[string]string
+	ipRules        map[string]string // Recipient domain to the only sender IP captured for it
+	lookupDomains  []string
+	saved          *SessionTranscript
+	savedTargets   []string
+	savedByDomain  map[string]*SessionTranscript
+}
+
+func newMockTranscriptStore() *mockTranscriptStore {
+	return &mockTranscriptStore{
+		optedInDomains: make(map[string]string),
+		ipRules:        make(map[string]string),
+		savedByDomain:  make(map[string]*SessionTranscript),
+	}
+}
+
+func (m *mockTranscriptStore) CaptureTargets(ctx context.Context, remoteIP string, recipientDomains []string) ([]CaptureTarget, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lookupDomains = recipientDomains
+	var targets []CaptureTarget
+	for _, d := range recipientDomains {
+		id, ok := m.optedInDomains[d]
+		if ip, narrowed := m.ipRules[d]; ok && (!narrowed || ip == remoteIP) {
+			targets = append(targets, CaptureTarget{DomainID: id, Domain: d})
+		}
+	}
+	return targets, nil
+}
+
+func (m *mockTranscriptStore) Save(ctx context.Context, transcript *SessionTranscript, domainID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.saved = transcript
+	m.savedTargets = append(m.savedTargets, domainID)
+	m.savedByDomain[domainID] = transcript
+	return nil
+}
+
+// runTranscriptSession runs a full session over a mock connection with transcript capture
+func runTranscriptSession(input string, store TranscriptStore, remoteIP string) *dataTestConn {
+	conn := newDataTestConn(input)
+	config := &SMTPConfig{
+		Hostname:          "test.local",
+		ConnectionTimeout: 5 * time.Minute,
+		MaxMessageSize:    1024 * 1024,
+		MaxRecipients:     100,
+	}
+	repo := NewMockAliasRepository()
+	repo.AddAlias("user@opted.example", true)
+	repo.AddAlias("user@other.example", true)
+
+	session := NewSMTPSession(conn, config, nil, repo, remoteIP)
+	session.SetTranscriptRecorder(NewTranscriptRecorder(store, remoteIP))
+	session.Run()
+	return conn
+}
+
+// TestTranscript_BodyNeverRecorded verifies that message bodies never reach the transcript
+func TestTranscript_BodyNeverRecorded(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		secret := "SECRET-" + rapid.StringMatching(`[A-Za-z0-9]{8,32}`).Draw(t, "secret")
+
+		store := newMockTranscriptStore()
+		store.optedInDomains["opted.example"] = "domain-1"
+
+		input := "EHLO client.example\r\n" +
+			"MAIL FROM:<Sender@Example.com>\r\n" +
+			"RCPT TO:<user@opted.example>\r\n" +
+			"DATA\r\n" +
+			"Subject: " + secret + "\r\n\r\n" + secret + "\r\n.\r\n" +
+			"QUIT\r\n"
+		runTranscriptSession(input, store, "203.0.113.7")
+
+		if store.saved == nil {
+			t.Fatal("Expected transcript to be saved for opted-in domain")
+		}
+		for _, e := range store.saved.Entries {
+			if strings.Contains(e.Line, secret) {
+				t.Fatalf("Transcript leaked message body: %q", e.Line)
+			}
+		}
+		if len(store.saved.QueueIDs) != 1 {
+			t.Fatalf("Expected 1 queue ID, got %d", len(store.saved.QueueIDs))
+		}
+		if len(store.saved.MailFrom) != 1 || store.saved.MailFrom[0] != "sender@example.com" {
+			t.Fatalf("Expected lowercased sender, got %v", store.saved.MailFrom)
+		}
+	})
+}
+
+// TestTranscript_RecordsCommandsAndResponses verifies both directions are captured in order
+func TestTranscript_RecordsCommandsAndResponses(t *testing.T) {
+	store := newMockTranscriptStore()
+	store.optedInDomains["opted.example"] = "domain-1"
+
+	runTranscriptSession("EHLO client.example\r\nRCPT TO:<user@opted.example>\r\nMAIL FROM:<a@b.com>\r\nRCPT TO:<user@opted.example>\r\nQUIT\r\n", store, "203.0.113.7")
+
+	if store.saved == nil {
+		t.Fatal("Expected transcript to be saved")
+	}
+	entries := store.saved.Entries
+	if len(entries) == 0 || entries[0].Direction != TranscriptDirectionServer || !strings.HasPrefix(entries[0].Line, "220 ") {
+		t.Fatalf("Expected transcript to start with server greeting, got %+v", entries)
+	}
+
+	var sawCommand, sawReject bool
+	for _, e := range entries {
+		if e.Direction == TranscriptDirectionClient && e.Line == "EHLO client.example" {
+			sawCommand = true
+		}
+		if e.Direction == TranscriptDirectionServer && strings.HasPrefix(e.Line, "500 Send MAIL FROM first") {
+			sawReject = true
+		}
+	}
+	if !sawCommand || !sawReject {
+		t.Fatalf("Expected EHLO command and rejection response in transcript, got %+v", entries)
+	}
+	if store.saved.Helo != "client.example" {
+		t.Fatalf("Expected HELO identity to be recorded, got %q", store.saved.Helo)
+	}
+}
+
+// TestTranscript_NotSavedWithoutOptIn verifies sessions without a matching rule are discarded
+func TestTranscript_NotSavedWithoutOptIn(t *testing.T) {
+	store := newMockTranscriptStore()
+	store.optedInDomains["opted.example"] = "domain-1"
+
+	runTranscriptSession("EHLO client.example\r\nMAIL FROM:<a@b.com>\r\nRCPT TO:<user@other.example>\r\nQUIT\r\n", store, "203.0.113.7")
+
+	if store.saved != nil {
+		t.Fatal("Transcript should not be saved when no domain or IP opted in")
+	}
+	if len(store.lookupDomains) != 1 || store.lookupDomains[0] != "other.example" {
+		t.Fatalf("Expected recipient domain to be checked, got %v", store.lookupDomains)
+	}
+}
+
+// TestTranscript_SenderIPOptIn verifies a sender IP rule captures only sessions addressed to its own domain
+func TestTranscript_SenderIPOptIn(t *testing.T) {
+	store := newMockTranscriptStore()
+	store.optedInDomains["opted.example"] = "domain-2"
+	store.ipRules["opted.example"] = "198.51.100.9"
+
+	runTranscriptSession("EHLO client.example\r\nMAIL FROM:<a@b.com>\r\nRCPT TO:<user@other.example>\r\nQUIT\r\n", store, "198.51.100.9")
+	if store.saved != nil {
+		t.Fatal("Sender IP rule must not capture a session addressed to another domain")
+	}
+
+	runTranscriptSession("EHLO client.example\r\nMAIL FROM:<a@b.com>\r\nRCPT TO:<user@opted.example>\r\nQUIT\r\n", store, "198.51.100.9")
+	if store.saved == nil {
+		t.Fatal("Expected transcript to be saved for a matching sender IP")
+	}
+	if len(store.savedTargets) != 1 || store.savedTargets[0] != "domain-2" {
+		t.Fatalf("Expected target domain-2, got %v", store.savedTargets)
+	}
+}
+
+// TestTranscript_ScopedPerDomain verifies a session addressed to two opted-in domains of different users
+// is stored for each domain without the other domain's recipients or RCPT TO exchange
+func TestTranscript_ScopedPerDomain(t *testing.T) {
+	store := newMockTranscriptStore()
+	store.optedInDomains["opted.example"] = "domain-user-x"
+	store.optedInDomains["other.example"] = "domain-user-y"
+
+	runTranscriptSession("EHLO client.example\r\nMAIL FROM:<a@b.com>\r\n"+
+		"RCPT TO:<User@Opted.example>\r\nRCPT TO:<user@other.example>\r\nRCPT TO:<nobody@other.example>\r\n"+
+		"RCPT TO:<broken>\r\nQUIT\r\n", store, "203.0.113.7")
+
+	tests := []struct {
+		domainID   string
+		domain     string
+		recipients []string
+	}{
+		{"domain-user-x", "opted.example", []string{"user@opted.example"}},
+		{"domain-user-y", "other.example", []string{"user@other.example", "nobody@other.example"}},
+	}
+	for _, tt := range tests {
+		saved := store.savedByDomain[tt.domainID]
+		if saved == nil {
+			t.Fatalf("Expected transcript to be saved for %s", tt.domainID)
+		}
+		if strings.Join(saved.Recipients, ",") != strings.Join(tt.recipients, ",") {
+			t.Errorf("%s: expected recipients %v, got %v", tt.domainID, tt.recipients, saved.Recipients)
+		}
+
+		var sawEHLO bool
+		var rcptCommands, rcptReplies int
+		for i, e := range saved.Entries {
+			sawEHLO = sawEHLO || e.Line == "EHLO client.example"
+			lower := strings.ToLower(e.Line)
+			if strings.Contains(lower, "broken") {
+				t.Errorf("%s: transcript kept an unaddressed RCPT TO exchange: %q", tt.domainID, e.Line)
+			}
+			if strings.Contains(lower, "@") && !strings.Contains(lower, "@"+tt.domain) && !strings.Contains(lower, "@b.com") {
+				t.Errorf("%s: transcript leaked another domain's line: %q", tt.domainID, e.Line)
+			}
+			if e.Direction == TranscriptDirectionClient && strings.HasPrefix(e.Line, "RCPT") {
+				rcptCommands++
+				if i+1 < len(saved.Entries) && saved.Entries[i+1].Direction == TranscriptDirectionServer {
+					rcptReplies++
+				}
+			}
+		}
+		if !sawEHLO {
+			t.Errorf("%s: expected the rest of the session to be kept, got %+v", tt.domainID, saved.Entries)
+		}
+		if rcptCommands != len(tt.recipients) || rcptReplies != rcptCommands {
+			t.Errorf("%s: expected %d RCPT TO commands with replies, got %d commands and %d replies",
+				tt.domainID, len(tt.recipients), rcptCommands, rcptReplies)
+		}
+	}
+
+}
+
+// TestTranscript_EntryCap verifies the recorder stops growing at MaxTranscriptEntries
+func TestTranscript_EntryCap(t *testing.T) {
+	recorder := NewTranscriptRecorder(newMockTranscriptStore(), "203.0.113.7")
+	for i := 0; i < MaxTranscriptEntries+50; i++ {
+		recorder.RecordCommand("NOOP")
+	}
+	recorder.RecordCommand(strings.Repeat("x", MaxTranscriptLineLength*2))
+
+	transcript := recorder.Transcript()
+	if len(transcript.Entries) != MaxTranscriptEntries {
+		t.Fatalf("Expected %d entries, got %d", MaxTranscriptEntries, len(transcript.Entries))
+	}
+	if !transcript.Truncated {
+		t.Fatal("Expected transcript to be marked truncated")
+	}
+
+	long := truncateTranscriptLine(strings.Repeat("é", MaxTranscriptLineLength))
+	if len(long) > MaxTranscriptLineLength+3 || !strings.HasSuffix(long, "...") {
+		t.Fatalf("Expected long line to be truncated, got length %d", len(long))
+	}
+}
+
+// TestTranscript_NilRecorderIsNoOp verifies sessions without capture are unaffected
+func TestTranscript_NilRecorderIsNoOp(t *testing.T) {
+	var recorder *TranscriptRecorder
+	recorder.RecordCommand("EHLO x")
+	recorder.RecordResponse("250 OK")
+	recorder.AddRecipient("a@b.com")
+	recorder.Finish(context.Background())
+	if recorder.Transcript() != nil {
+		t.Fatal("Expected nil transcript from nil recorder")
+	}
+}
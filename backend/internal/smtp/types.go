@@ -17,6 +17,7 @@ type SMTPConfig struct {
 	MaxRecipients       int
 	RateLimitPerMinute  int
 	TLSConfig           *tls.Config
+	DrainTimeout        time.Duration // How long in-flight transactions may run after shutdown starts
 }
 
 // SessionState represents the current state of an SMTP session
@@ -59,17 +60,24 @@ func (e *SMTPError) Error() string {
 
 // SMTP Response Codes
 const (
-	CodeServiceReady       = 220
-	CodeServiceClosing     = 221
-	CodeOK                 = 250
-	CodeStartMailInput     = 354
-	CodeServiceUnavailable = 421
-	CodeTempFailure        = 451
-	CodeTLSNotAvailable    = 454
-	CodeSyntaxError        = 500
-	CodeSyntaxErrorParams  = 501
-	CodeUserNotFound       = 550
-	CodeMessageTooLarge    = 552
+	CodeServiceReady          = 220
+	CodeServiceClosing        = 221
+	CodeOK                    = 250
+	CodeAuthSuccess           = 235
+	CodeAuthContinue          = 334
+	CodeStartMailInput        = 354
+	CodeServiceUnavailable    = 421
+	CodeTempFailure           = 451
+	CodeTLSNotAvailable       = 454
+	CodeSyntaxError           = 500
+	CodeSyntaxErrorParams     = 501
+	CodeBadSequence           = 503
+	CodeParamNotImplemented   = 504
+	CodeAuthRequired          = 530
+	CodeAuthFailed            = 535
+	CodeUserNotFound          = 550
+	CodeMessageTooLarge       = 552
+	CodeMailboxNameNotAllowed = 553
 )
 
 // SMTP Response Messages
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// UploadPartSize is the size of the parts UploadStream sends
+// Multipart uploads are limited to 10,000 parts, so streams up to about 80 GB can be stored.
+const UploadPartSize = 8 * 1024 * 1024
+
+// UploadStream stores everything read from r under key without knowing its size in advance
+// Streams that fit in one part are stored with a single PutObject; larger ones with a multipart
+// upload that is aborted on any error. It returns the number of bytes stored.
+func (s *StorageService) UploadStream(ctx context.Context, key, contentType string, r io.Reader) (int64, error) {
+	buf := make([]byte, UploadPartSize)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return 0, fmt.Errorf("failed to read upload %s: %w", key, err)
+	}
+	if n < len(buf) {
+		_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:        aws.String(s.bucket),
+			Key:           aws.String(key),
+			Body:          bytes.NewReader(buf[:n]),
+			ContentLength: aws.Int64(int64(n)),
+			ContentType:   aws.String(contentType),
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to upload %s: %w", key, err)
+		}
+		return int64(n), nil
+	}
+
+	out, err := s.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to start upload %s: %w", key, err)
+	}
+
+	var parts []types.CompletedPart
+	var size int64
+	chunk := buf[:n]
+	for partNumber := int32(1); len(chunk) > 0; partNumber++ {
+		part, err := s.client.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket:     aws.String(s.bucket),
+			Key:        aws.String(key),
+			UploadId:   out.UploadId,
+			PartNumber: aws.Int32(partNumber),
+			Body:       bytes.NewReader(chunk),
+		})
+		if err != nil {
+			s.abortUpload(ctx, key, out.UploadId)
+			return 0, fmt.Errorf("failed to upload part %d of %s: %w", partNumber, key, err)
+		}
+		parts = append(parts, types.CompletedPart{ETag: part.ETag, PartNumber: aws.Int32(partNumber)})
+		size += int64(len(chunk))
+
+		n, err := io.ReadFull(r, buf)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			s.abortUpload(ctx, key, out.UploadId)
+			return 0, fmt.Errorf("failed to read upload %s: %w", key, err)
+		}
+		chunk = buf[:n]
+	}
+
+	_, err = s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.bucket),
+		Key:             aws.String(key),
+		UploadId:        out.UploadId,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		s.abortUpload(ctx, key, out.UploadId)
+		return 0, fmt.Errorf("failed to complete upload %s: %w", key, err)
+	}
+	return size, nil
+}
+
+// GetObject opens an object for reading; the caller must close it
+func (s *StorageService) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object %s: %w", key, err)
+	}
+	return out.Body, nil
+}
+
+// abortUpload discards the parts of an unfinished multipart upload so they are not billed
+func (s *StorageService) abortUpload(ctx context.Context, key string, uploadID *string) {
+	_, err := s.client.AbortMultipartUpload(context.WithoutCancel(ctx), &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(s.bucket),
+		Key:      aws.String(key),
+		UploadId: uploadID,
+	})
+	if err != nil {
+		log.Printf("Failed to abort multipart upload of %s: %v", key, err)
+	}
+}
@@ -0,0 +1,284 @@
+package transcript
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/smtp"
+)
+
+// PostgresRepository stores transcripts in PostgreSQL as a capped ring per domain
+// It implements both Repository (API side) and smtp.TranscriptStore (capture side).
+type PostgresRepository struct {
+	pool         *pgxpool.Pool
+	maxPerDomain int
+}
+
+// NewPostgresRepository creates a new PostgresRepository
+// maxPerDomain bounds how many transcripts are kept per domain; older ones are trimmed on insert.
+func NewPostgresRepository(pool *pgxpool.Pool, maxPerDomain int) *PostgresRepository {
+	if maxPerDomain <= 0 {
+		maxPerDomain = DefaultMaxPerDomain
+	}
+	return &PostgresRepository{pool: pool, maxPerDomain: maxPerDomain}
+}
+
+// CaptureTargets returns the recipient domains that opted in to capture the session
+// Only domains the session was addressed to are loaded; sender IP rules narrow their capture.
+func (r *PostgresRepository) CaptureTargets(ctx context.Context, remoteIP string, recipientDomains []string) ([]smtp.CaptureTarget, error) {
+	if len(recipientDomains) == 0 {
+		return nil, nil
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT d.id::text, d.domain_name, d.transcript_enabled,
+			COALESCE(array_agg(ir.ip_cidr::text) FILTER (WHERE ir.id IS NOT NULL), '{}')
+		FROM domains d
+		LEFT JOIN smtp_transcript_ip_rules ir ON ir.domain_id = d.id
+		WHERE d.transcript_enabled = true AND LOWER(d.domain_name) = ANY($1)
+		GROUP BY d.id
+	`, recipientDomains)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transcript targets: %w", err)
+	}
+	defer rows.Close()
+
+	var domains []CaptureDomain
+	for rows.Next() {
+		var d CaptureDomain
+		if err := rows.Scan(&d.ID, &d.Name, &d.Enabled, &d.IPRules); err != nil {
+			return nil, err
+		}
+		domains = append(domains, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return selectCaptureTargets(remoteIP, recipientDomains, domains), nil
+}
+
+// Save stores the transcript for a domain and trims the domain's ring
+func (r *PostgresRepository) Save(ctx context.Context, t *smtp.SessionTranscript, domainID string) error {
+	entriesJSON, err := json.Marshal(t.Entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode transcript entries: %w", err)
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO smtp_transcripts (domain_id, session_id, remote_ip, helo, mail_from, recipients, queue_ids, tls_enabled, truncated, entries, started_at, ended_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`,
+		domainID,
+		t.SessionID,
+		t.RemoteIP,
+		t.Helo,
+		nonNil(t.MailFrom),
+		nonNil(t.Recipients),
+		nonNil(t.QueueIDs),
+		t.TLSEnabled,
+		t.Truncated,
+		entriesJSON,
+		t.StartedAt,
+		t.EndedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert transcript: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		DELETE FROM smtp_transcripts
+		WHERE id IN (
+			SELECT id FROM smtp_transcripts
+			WHERE domain_id = $1
+			ORDER BY started_at DESC, id DESC
+			OFFSET $2
+		)
+	`, domainID, r.maxPerDomain)
+	if err != nil {
+		return fmt.Errorf("failed to trim transcripts: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Search returns transcripts for a domain matching the filters, newest first
+func (r *PostgresRepository) Search(ctx context.Context, domainID uuid.UUID, params SearchParams) ([]Transcript, int, error) {
+	conditions := []string{"domain_id = $1"}
+	args := []interface{}{domainID}
+	argIndex := 2
+
+	if params.Since != nil {
+		conditions = append(conditions, fmt.Sprintf("started_at >= $%d", argIndex))
+		args = append(args, params.Since.UTC())
+		argIndex++
+	}
+	if params.Until != nil {
+		conditions = append(conditions, fmt.Sprintf("started_at <= $%d", argIndex))
+		args = append(args, params.Until.UTC())
+		argIndex++
+	}
+	if params.Sender != "" {
+		conditions = append(conditions, fmt.Sprintf("array_to_string(mail_from, ' ') LIKE $%d ESCAPE '\\'", argIndex))
+		args = append(args, "%"+escapeLike(params.Sender)+"%")
+		argIndex++
+	}
+	if params.QueueID != "" {
+		conditions = append(conditions, fmt.Sprintf("queue_ids @> ARRAY[$%d::text]", argIndex))
+		args = append(args, params.QueueID)
+		argIndex++
+	}
+
+	where := strings.Join(conditions, " AND ")
+
+	var total int
+	countQuery := "SELECT COUNT(*) FROM smtp_transcripts WHERE " + where
+	if err := r.pool.QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count transcripts: %w", err)
+	}
+
+	offset := (params.Page - 1) * params.Limit
+	listQuery := fmt.Sprintf(`
+		SELECT id, domain_id, session_id, remote_ip, COALESCE(helo, ''), mail_from, recipients, queue_ids,
+		       tls_enabled, truncated, started_at, ended_at, created_at
+		FROM smtp_transcripts
+		WHERE %s
+		ORDER BY started_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, where, argIndex, argIndex+1)
+	args = append(args, params.Limit, offset)
+
+	rows, err := r.pool.Query(ctx, listQuery, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search transcripts: %w", err)
+	}
+	defer rows.Close()
+
+	transcripts := make([]Transcript, 0)
+	for rows.Next() {
+		var t Transcript
+		if err := rows.Scan(
+			&t.ID, &t.DomainID, &t.SessionID, &t.RemoteIP, &t.Helo,
+			&t.MailFrom, &t.Recipients, &t.QueueIDs,
+			&t.TLSEnabled, &t.Truncated, &t.StartedAt, &t.EndedAt, &t.CreatedAt,
+		); err != nil {
+			return nil, 0, err
+		}
+		transcripts = append(transcripts, t)
+	}
+
+	return transcripts, total, rows.Err()
+}
+
+// GetByID returns a transcript with entries, scoped to the domain
+func (r *PostgresRepository) GetByID(ctx context.Context, domainID, id uuid.UUID) (*Transcript, error) {
+	query := `
+		SELECT id, domain_id, session_id, remote_ip, COALESCE(helo, ''), mail_from, recipients, queue_ids,
+		       tls_enabled, truncated, entries, started_at, ended_at, created_at
+		FROM smtp_transcripts
+		WHERE id = $1 AND domain_id = $2
+	`
+
+	var t Transcript
+	var entriesJSON []byte
+	err := r.pool.QueryRow(ctx, query, id, domainID).Scan(
+		&t.ID, &t.DomainID, &t.SessionID, &t.RemoteIP, &t.Helo,
+		&t.MailFrom, &t.Recipients, &t.QueueIDs,
+		&t.TLSEnabled, &t.Truncated, &entriesJSON, &t.StartedAt, &t.EndedAt, &t.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTranscriptNotFound
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(entriesJSON, &t.Entries); err != nil {
+		return nil, fmt.Errorf("failed to decode transcript entries: %w", err)
+	}
+
+	return &t, nil
+}
+
+// GetSettings returns the domain's opt-in flag and sender IP rules
+func (r *PostgresRepository) GetSettings(ctx context.Context, domainID uuid.UUID) (*Settings, error) {
+	settings := &Settings{SenderIPs: []string{}}
+
+	err := r.pool.QueryRow(ctx, `SELECT transcript_enabled FROM domains WHERE id = $1`, domainID).Scan(&settings.Enabled)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrDomainNotFound
+		}
+		return nil, err
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT ip_cidr::text FROM smtp_transcript_ip_rules
+		WHERE domain_id = $1
+		ORDER BY created_at, ip_cidr
+	`, domainID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rule string
+		if err := rows.Scan(&rule); err != nil {
+			return nil, err
+		}
+		settings.SenderIPs = append(settings.SenderIPs, rule)
+	}
+
+	return settings, rows.Err()
+}
+
+// UpdateSettings replaces the domain's opt-in flag and sender IP rules atomically
+func (r *PostgresRepository) UpdateSettings(ctx context.Context, domainID uuid.UUID, settings Settings) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, `UPDATE domains SET transcript_enabled = $2 WHERE id = $1`, domainID, settings.Enabled)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrDomainNotFound
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM smtp_transcript_ip_rules WHERE domain_id = $1`, domainID); err != nil {
+		return err
+	}
+
+	for _, rule := range settings.SenderIPs {
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO smtp_transcript_ip_rules (domain_id, ip_cidr) VALUES ($1, $2::cidr)`,
+			domainID, rule,
+		); err != nil {
+			return fmt.Errorf("failed to insert sender IP rule: %w", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// escapeLike escapes LIKE wildcards in user input
+func escapeLike(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(s)
+}
@@ -0,0 +1,20 @@
+package transcript
+
+import (
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterDomainRoutes registers transcript routes under an authenticated /domains router
+func RegisterDomainRoutes(r chi.Router, handler *Handler) {
+	// GET /api/v1/domains/:id/transcripts/settings - Get capture opt-in settings
+	r.Get("/{id}/transcripts/settings", handler.GetSettings)
+
+	// PUT /api/v1/domains/:id/transcripts/settings - Replace capture opt-in settings
+	r.Put("/{id}/transcripts/settings", handler.UpdateSettings)
+
+	// GET /api/v1/domains/:id/transcripts - Search transcripts (since, until, sender, queue_id)
+	r.Get("/{id}/transcripts", handler.List)
+
+	// GET /api/v1/domains/:id/transcripts/:transcriptId - Get transcript with all lines
+	r.Get("/{id}/transcripts/{transcriptId}", handler.Get)
+}
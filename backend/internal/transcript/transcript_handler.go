@@ -0,0 +1,232 @@
+package transcript
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	appctx "github.com/welldanyogia/persistent-temp-mail/backend/internal/context"
+)
+
+// APIResponse represents the standard API response format
+type APIResponse struct {
+	Success   bool        `json:"success"`
+	Data      interface{} `json:"data,omitempty"`
+	Error     *APIError   `json:"error,omitempty"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// APIError represents the error detail in API response
+type APIError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Handler handles HTTP requests for transcript endpoints
+type Handler struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+// NewHandler creates a new Handler instance
+func NewHandler(service *Service, logger *slog.Logger) *Handler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// List handles GET /api/v1/domains/:id/transcripts
+// Query params: since, until (RFC3339), sender, queue_id, page, limit
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	userID, domainID, ok := h.parseIDs(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	params := SearchParams{
+		Sender:  query.Get("sender"),
+		QueueID: query.Get("queue_id"),
+	}
+
+	if pageStr := query.Get("page"); pageStr != "" {
+		if page, err := strconv.Atoi(pageStr); err == nil && page > 0 {
+			params.Page = page
+		}
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 {
+			params.Limit = limit
+		}
+	}
+
+	if sinceStr := query.Get("since"); sinceStr != "" {
+		since, err := time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, CodeValidationError, "since must be an RFC3339 timestamp")
+			return
+		}
+		params.Since = &since
+	}
+	if untilStr := query.Get("until"); untilStr != "" {
+		until, err := time.Parse(time.RFC3339, untilStr)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, CodeValidationError, "until must be an RFC3339 timestamp")
+			return
+		}
+		params.Until = &until
+	}
+
+	response, err := h.service.Search(r.Context(), userID, domainID, params)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, response)
+}
+
+// Get handles GET /api/v1/domains/:id/transcripts/:transcriptId
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, domainID, ok := h.parseIDs(w, r)
+	if !ok {
+		return
+	}
+
+	transcriptID, err := uuid.Parse(chi.URLParam(r, "transcriptId"))
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "Invalid transcript ID")
+		return
+	}
+
+	response, err := h.service.GetByID(r.Context(), userID, domainID, transcriptID)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, response)
+}
+
+// GetSettings handles GET /api/v1/domains/:id/transcripts/settings
+func (h *Handler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	userID, domainID, ok := h.parseIDs(w, r)
+	if !ok {
+		return
+	}
+
+	settings, err := h.service.GetSettings(r.Context(), userID, domainID)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, settings)
+}
+
+// UpdateSettings handles PUT /api/v1/domains/:id/transcripts/settings
+func (h *Handler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	userID, domainID, ok := h.parseIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "Invalid request body")
+		return
+	}
+
+	settings, err := h.service.UpdateSettings(r.Context(), userID, domainID, req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, http.StatusOK, settings)
+}
+
+// parseIDs extracts the authenticated user ID and the domain ID path parameter
+func (h *Handler) parseIDs(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	userIDStr, ok := appctx.ExtractUserID(r.Context())
+	if !ok {
+		h.writeError(w, http.StatusUnauthorized, CodeAuthTokenInvalid, "Invalid or expired token")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		h.writeError(w, http.StatusUnauthorized, CodeAuthTokenInvalid, "Invalid user ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	domainID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "Invalid domain ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, domainID, true
+}
+
+// handleError maps service errors to HTTP responses
+func (h *Handler) handleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrDomainNotFound):
+		h.writeError(w, http.StatusNotFound, CodeDomainNotFound, "Domain not found")
+	case errors.Is(err, ErrAccessDenied):
+		h.writeError(w, http.StatusForbidden, CodeAccessDenied, "You don't have access to this domain")
+	case errors.Is(err, ErrTranscriptNotFound):
+		h.writeError(w, http.StatusNotFound, CodeTranscriptNotFound, "Transcript not found")
+	case errors.Is(err, ErrInvalidIPRule):
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "sender_ips must contain IP addresses or CIDR ranges no wider than /24 (IPv4) or /48 (IPv6)")
+	case errors.Is(err, ErrTooManyIPRules):
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "Too many sender IP rules (max 50)")
+	case errors.Is(err, ErrInvalidTimeRange):
+		h.writeError(w, http.StatusBadRequest, CodeValidationError, "until must not be before since")
+	default:
+		h.logger.Error("Unexpected transcript error", "error", err)
+		h.writeError(w, http.StatusInternalServerError, CodeInternalError, "An unexpected error occurred")
+	}
+}
+
+// writeSuccess writes a successful JSON response
+func (h *Handler) writeSuccess(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := APIResponse{
+		Success:   true,
+		Data:      data,
+		Timestamp: time.Now().UTC(),
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// writeError writes an error JSON response
+func (h *Handler) writeError(w http.ResponseWriter, statusCode int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := APIResponse{
+		Success: false,
+		Error: &APIError{
+			Code:    code,
+			Message: message,
+		},
+		Timestamp: time.Now().UTC(),
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
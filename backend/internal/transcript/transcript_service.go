@@ -0,0 +1,398 @@
+// Package transcript provides opt-in SMTP session transcript capture and search
+// Feature: smtp-transcripts
+// Requirements: Capture per domain, optionally narrowed by sender IP, capped ring store, owner-only search API
+package transcript
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/domain"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/smtp"
+)
+
+const (
+	// DefaultMaxPerDomain is the default ring size of stored transcripts per domain
+	DefaultMaxPerDomain = 500
+	// MaxIPRules is the maximum number of sender IP rules per domain
+	MaxIPRules = 50
+	// MinIPv4Prefix is the shortest IPv4 prefix allowed in a sender IP rule
+	MinIPv4Prefix = 24
+	// MinIPv6Prefix is the shortest IPv6 prefix allowed in a sender IP rule
+	MinIPv6Prefix = 48
+	// DefaultPageLimit is the default number of transcripts per page
+	DefaultPageLimit = 20
+	// MaxPageLimit is the maximum number of transcripts per page
+	MaxPageLimit = 100
+)
+
+// Service errors
+var (
+	ErrTranscriptNotFound = errors.New("transcript not found")
+	ErrDomainNotFound     = errors.New("domain not found")
+	ErrAccessDenied       = errors.New("access denied")
+	ErrInvalidIPRule      = errors.New("invalid sender IP rule")
+	ErrTooManyIPRules     = errors.New("too many sender IP rules")
+	ErrInvalidTimeRange   = errors.New("invalid time range")
+)
+
+// Error codes for API responses
+const (
+	CodeValidationError    = "VALIDATION_ERROR"
+	CodeTranscriptNotFound = "TRANSCRIPT_NOT_FOUND"
+	CodeDomainNotFound     = "DOMAIN_NOT_FOUND"
+	CodeAccessDenied       = "RESOURCE_ACCESS_DENIED"
+	CodeInternalError      = "INTERNAL_ERROR"
+	CodeAuthTokenInvalid   = "AUTH_TOKEN_INVALID"
+)
+
+// Transcript is a stored session transcript scoped to one domain
+type Transcript struct {
+	ID         uuid.UUID
+	DomainID   uuid.UUID
+	SessionID  string
+	RemoteIP   string
+	Helo       string
+	MailFrom   []string
+	Recipients []string
+	QueueIDs   []string
+	TLSEnabled bool
+	Truncated  bool
+	Entries    []smtp.TranscriptEntry
+	StartedAt  time.Time
+	EndedAt    time.Time
+	CreatedAt  time.Time
+}
+
+// SearchParams holds filters for searching a domain's transcripts
+type SearchParams struct {
+	Since   *time.Time
+	Until   *time.Time
+	Sender  string
+	QueueID string
+	Page    int
+	Limit   int
+}
+
+// CaptureDomain is the capture opt-in of a domain a session was addressed to
+type CaptureDomain struct {
+	ID      string
+	Name    string
+	Enabled bool
+	IPRules []string // CIDR ranges; when set, only sessions from these senders are captured
+}
+
+// Settings holds a domain's capture opt-in configuration
+// With sender IPs, only sessions addressed to the domain from those IPs are captured.
+type Settings struct {
+	Enabled   bool     `json:"enabled"`
+	SenderIPs []string `json:"sender_ips"`
+}
+
+// UpdateSettingsRequest represents the request to change capture settings
+type UpdateSettingsRequest struct {
+	Enabled   bool     `json:"enabled"`
+	SenderIPs []string `json:"sender_ips"`
+}
+
+// TranscriptSummary represents a transcript in list responses (without entries)
+type TranscriptSummary struct {
+	ID         string    `json:"id"`
+	SessionID  string    `json:"session_id"`
+	RemoteIP   string    `json:"remote_ip"`
+	Helo       string    `json:"helo,omitempty"`
+	MailFrom   []string  `json:"mail_from"`
+	Recipients []string  `json:"recipients"`
+	QueueIDs   []string  `json:"queue_ids"`
+	TLSEnabled bool      `json:"tls_enabled"`
+	Truncated  bool      `json:"truncated"`
+	StartedAt  time.Time `json:"started_at"`
+	EndedAt    time.Time `json:"ended_at"`
+}
+
+// TranscriptDetailResponse represents a full transcript with its command/response lines
+type TranscriptDetailResponse struct {
+	TranscriptSummary
+	Entries []smtp.TranscriptEntry `json:"entries"`
+}
+
+// TranscriptListResponse represents a page of transcripts
+type TranscriptListResponse struct {
+	Transcripts []TranscriptSummary `json:"transcripts"`
+	Pagination  Pagination          `json:"pagination"`
+}
+
+// Pagination represents pagination metadata
+type Pagination struct {
+	CurrentPage int `json:"current_page"`
+	PerPage     int `json:"per_page"`
+	TotalPages  int `json:"total_pages"`
+	TotalCount  int `json:"total_count"`
+}
+
+// Repository defines transcript data access used by the service
+type Repository interface {
+	Search(ctx context.Context, domainID uuid.UUID, params SearchParams) ([]Transcript, int, error)
+	GetByID(ctx context.Context, domainID, id uuid.UUID) (*Transcript, error)
+	GetSettings(ctx context.Context, domainID uuid.UUID) (*Settings, error)
+	UpdateSettings(ctx context.Context, domainID uuid.UUID, settings Settings) error
+}
+
+// DomainRepository defines the domain lookup used for ownership checks
+type DomainRepository interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Domain, error)
+}
+
+// Service handles transcript business logic
+type Service struct {
+	repo       Repository
+	domainRepo DomainRepository
+	logger     *slog.Logger
+}
+
+// ServiceConfig holds configuration for the transcript service
+type ServiceConfig struct {
+	Repository Repository
+	DomainRepo DomainRepository
+	Logger     *slog.Logger
+}
+
+// NewService creates a new transcript service
+func NewService(cfg ServiceConfig) *Service {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Service{
+		repo:       cfg.Repository,
+		domainRepo: cfg.DomainRepo,
+		logger:     logger,
+	}
+}
+
+// Search returns a page of transcripts for a domain owned by the user
+func (s *Service) Search(ctx context.Context, userID, domainID uuid.UUID, params SearchParams) (*TranscriptListResponse, error) {
+	if err := s.checkOwnership(ctx, userID, domainID); err != nil {
+		return nil, err
+	}
+
+	params = normalizeSearchParams(params)
+	if params.Since != nil && params.Until != nil && params.Until.Before(*params.Since) {
+		return nil, ErrInvalidTimeRange
+	}
+
+	transcripts, total, err := s.repo.Search(ctx, domainID, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search transcripts: %w", err)
+	}
+
+	summaries := make([]TranscriptSummary, len(transcripts))
+	for i := range transcripts {
+		summaries[i] = toSummary(&transcripts[i])
+	}
+
+	totalPages := (total + params.Limit - 1) / params.Limit
+	return &TranscriptListResponse{
+		Transcripts: summaries,
+		Pagination: Pagination{
+			CurrentPage: params.Page,
+			PerPage:     params.Limit,
+			TotalPages:  totalPages,
+			TotalCount:  total,
+		},
+	}, nil
+}
+
+// GetByID returns a single transcript with all entries
+func (s *Service) GetByID(ctx context.Context, userID, domainID, transcriptID uuid.UUID) (*TranscriptDetailResponse, error) {
+	if err := s.checkOwnership(ctx, userID, domainID); err != nil {
+		return nil, err
+	}
+
+	t, err := s.repo.GetByID(ctx, domainID, transcriptID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := t.Entries
+	if entries == nil {
+		entries = []smtp.TranscriptEntry{}
+	}
+	return &TranscriptDetailResponse{
+		TranscriptSummary: toSummary(t),
+		Entries:           entries,
+	}, nil
+}
+
+// GetSettings returns the capture settings for a domain owned by the user
+func (s *Service) GetSettings(ctx context.Context, userID, domainID uuid.UUID) (*Settings, error) {
+	if err := s.checkOwnership(ctx, userID, domainID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetSettings(ctx, domainID)
+}
+
+// UpdateSettings replaces the capture settings for a domain owned by the user
+// Sender IPs may be plain addresses or CIDR ranges; they are stored normalized.
+func (s *Service) UpdateSettings(ctx context.Context, userID, domainID uuid.UUID, req UpdateSettingsRequest) (*Settings, error) {
+	if err := s.checkOwnership(ctx, userID, domainID); err != nil {
+		return nil, err
+	}
+
+	if len(req.SenderIPs) > MaxIPRules {
+		return nil, ErrTooManyIPRules
+	}
+
+	rules := make([]string, 0, len(req.SenderIPs))
+	seen := make(map[string]struct{}, len(req.SenderIPs))
+	for _, raw := range req.SenderIPs {
+		rule, err := NormalizeIPRule(raw)
+		if err != nil {
+			return nil, err
+		}
+		if _, dup := seen[rule]; dup {
+			continue
+		}
+		seen[rule] = struct{}{}
+		rules = append(rules, rule)
+	}
+
+	settings := Settings{Enabled: req.Enabled, SenderIPs: rules}
+	if err := s.repo.UpdateSettings(ctx, domainID, settings); err != nil {
+		return nil, fmt.Errorf("failed to update transcript settings: %w", err)
+	}
+
+	s.logger.Info("Transcript capture settings updated",
+		"domain_id", domainID,
+		"enabled", settings.Enabled,
+		"sender_ip_rules", len(settings.SenderIPs),
+	)
+
+	return &settings, nil
+}
+
+// checkOwnership verifies the domain exists and belongs to the user
+func (s *Service) checkOwnership(ctx context.Context, userID, domainID uuid.UUID) error {
+	d, err := s.domainRepo.GetByID(ctx, domainID)
+	if err != nil {
+		if errors.Is(err, domain.ErrDomainNotFound) {
+			return ErrDomainNotFound
+		}
+		return err
+	}
+	if d.UserID != userID {
+		return ErrAccessDenied
+	}
+	return nil
+}
+
+// NormalizeIPRule converts an IP address or CIDR range to canonical CIDR notation
+// Ranges wider than MinIPv4Prefix or MinIPv6Prefix are rejected.
+func NormalizeIPRule(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", ErrInvalidIPRule
+	}
+	if strings.Contains(raw, "/") {
+		_, network, err := net.ParseCIDR(raw)
+		if err != nil {
+			return "", ErrInvalidIPRule
+		}
+		ones, bits := network.Mask.Size()
+		if (bits == 32 && ones < MinIPv4Prefix) || (bits == 128 && ones < MinIPv6Prefix) {
+			return "", fmt.Errorf("%w: %s is too wide", ErrInvalidIPRule, network)
+		}
+		return network.String(), nil
+	}
+	ip := net.ParseIP(raw)
+	if ip == nil {
+		return "", ErrInvalidIPRule
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return v4.String() + "/32", nil
+	}
+	return ip.String() + "/128", nil
+}
+
+// selectCaptureTargets returns the domains that capture a session
+// A domain captures only sessions with a recipient in that domain, and only when capture is enabled;
+// when the domain has sender IP rules, the remote IP must also match one of them.
+func selectCaptureTargets(remoteIP string, recipientDomains []string, domains []CaptureDomain) []smtp.CaptureTarget {
+	addressed := make(map[string]bool, len(recipientDomains))
+	for _, d := range recipientDomains {
+		addressed[strings.ToLower(d)] = true
+	}
+	ip := net.ParseIP(remoteIP)
+
+	var targets []smtp.CaptureTarget
+	for _, d := range domains {
+		if !d.Enabled || !addressed[strings.ToLower(d.Name)] {
+			continue
+		}
+		if len(d.IPRules) > 0 && !matchesIPRule(ip, d.IPRules) {
+			continue
+		}
+		targets = append(targets, smtp.CaptureTarget{DomainID: d.ID, Domain: strings.ToLower(d.Name)})
+	}
+	return targets
+}
+
+// matchesIPRule reports whether ip is inside any of the CIDR rules
+func matchesIPRule(ip net.IP, rules []string) bool {
+	if ip == nil {
+		return false
+	}
+	for _, rule := range rules {
+		if _, network, err := net.ParseCIDR(rule); err == nil && network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeSearchParams applies pagination defaults and lowercases text filters
+func normalizeSearchParams(params SearchParams) SearchParams {
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	if params.Limit < 1 {
+		params.Limit = DefaultPageLimit
+	}
+	if params.Limit > MaxPageLimit {
+		params.Limit = MaxPageLimit
+	}
+	params.Sender = strings.ToLower(strings.TrimSpace(params.Sender))
+	params.QueueID = strings.TrimSpace(params.QueueID)
+	return params
+}
+
+// toSummary converts a stored transcript to its list representation
+func toSummary(t *Transcript) TranscriptSummary {
+	return TranscriptSummary{
+		ID:         t.ID.String(),
+		SessionID:  t.SessionID,
+		RemoteIP:   t.RemoteIP,
+		Helo:       t.Helo,
+		MailFrom:   nonNil(t.MailFrom),
+		Recipients: nonNil(t.Recipients),
+		QueueIDs:   nonNil(t.QueueIDs),
+		TLSEnabled: t.TLSEnabled,
+		Truncated:  t.Truncated,
+		StartedAt:  t.StartedAt,
+		EndedAt:    t.EndedAt,
+	}
+}
+
+// nonNil returns an empty slice instead of nil so JSON renders []
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,242 @@
+package transcript
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/welldanyogia/persistent-temp-mail/backend/internal/domain"
+	"pgregory.net/rapid"
+)
+
+// mockDomainRepository implements DomainRepository for testing
+type mockDomainRepository struct {
+	domains map[uuid.UUID]*domain.Domain
+}
+
+func (m *mockDomainRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Domain, error) {
+	d, ok := m.domains[id]
+	if !ok {
+		return nil, domain.ErrDomainNotFound
+	}
+	return d, nil
+}
+
+// mockRepository implements Repository for testing
+type mockRepository struct {
+	settings    map[uuid.UUID]Settings
+	transcripts map[uuid.UUID]*Transcript
+	lastParams  SearchParams
+}
+
+func newMockRepository() *mockRepository {
+	return &mockRepository{
+		settings:    make(map[uuid.UUID]Settings),
+		transcripts: make(map[uuid.UUID]*Transcript),
+	}
+}
+
+func (m *mockRepository) Search(ctx context.Context, domainID uuid.UUID, params SearchParams) ([]Transcript, int, error) {
+	m.lastParams = params
+	var result []Transcript
+	for _, t := range m.transcripts {
+		if t.DomainID == domainID {
+			result = append(result, *t)
+		}
+	}
+	return result, len(result), nil
+}
+
+func (m *mockRepository) GetByID(ctx context.Context, domainID, id uuid.UUID) (*Transcript, error) {
+	t, ok := m.transcripts[id]
+	if !ok || t.DomainID != domainID {
+		return nil, ErrTranscriptNotFound
+	}
+	return t, nil
+}
+
+func (m *mockRepository) GetSettings(ctx context.Context, domainID uuid.UUID) (*Settings, error) {
+	s := m.settings[domainID]
+	return &s, nil
+}
+
+func (m *mockRepository) UpdateSettings(ctx context.Context, domainID uuid.UUID, settings Settings) error {
+	m.settings[domainID] = settings
+	return nil
+}
+
+func newTestService() (*Service, *mockRepository, uuid.UUID, uuid.UUID) {
+	ownerID := uuid.New()
+	domainID := uuid.New()
+	domainRepo := &mockDomainRepository{domains: map[uuid.UUID]*domain.Domain{
+		domainID: {ID: domainID, UserID: ownerID, DomainName: "example.com"},
+	}}
+	repo := newMockRepository()
+	return NewService(ServiceConfig{Repository: repo, DomainRepo: domainRepo}), repo, ownerID, domainID
+}
+
+// TestOwnershipRequired verifies only the domain owner can read transcripts or change settings
+func TestOwnershipRequired(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		service, repo, ownerID, domainID := newTestService()
+		otherUser := uuid.New()
+
+		tid := uuid.New()
+		repo.transcripts[tid] = &Transcript{ID: tid, DomainID: domainID}
+
+		ctx := context.Background()
+		if _, err := service.Search(ctx, otherUser, domainID, SearchParams{}); !errors.Is(err, ErrAccessDenied) {
+			t.Fatalf("Expected ErrAccessDenied for search, got %v", err)
+		}
+		if _, err := service.GetByID(ctx, otherUser, domainID, tid); !errors.Is(err, ErrAccessDenied) {
+			t.Fatalf("Expected ErrAccessDenied for get, got %v", err)
+		}
+		if _, err := service.UpdateSettings(ctx, otherUser, domainID, UpdateSettingsRequest{Enabled: true}); !errors.Is(err, ErrAccessDenied) {
+			t.Fatalf("Expected ErrAccessDenied for update, got %v", err)
+		}
+		if repo.settings[domainID].Enabled {
+			t.Fatal("Settings must not change for non-owner")
+		}
+
+		if _, err := service.Search(ctx, ownerID, uuid.New(), SearchParams{}); !errors.Is(err, ErrDomainNotFound) {
+			t.Fatalf("Expected ErrDomainNotFound, got %v", err)
+		}
+
+		detail, err := service.GetByID(ctx, ownerID, domainID, tid)
+		if err != nil {
+			t.Fatalf("Owner should get transcript: %v", err)
+		}
+		if detail.Entries == nil || detail.MailFrom == nil {
+			t.Fatal("Expected empty slices rather than nil in response")
+		}
+	})
+}
+
+// TestSearchParamsNormalized verifies pagination defaults and limits
+func TestSearchParamsNormalized(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		service, repo, ownerID, domainID := newTestService()
+		page := rapid.IntRange(-5, 50).Draw(t, "page")
+		limit := rapid.IntRange(-5, 500).Draw(t, "limit")
+
+		resp, err := service.Search(context.Background(), ownerID, domainID, SearchParams{Page: page, Limit: limit, Sender: "  Foo@Bar.COM "})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if repo.lastParams.Page < 1 || repo.lastParams.Limit < 1 || repo.lastParams.Limit > MaxPageLimit {
+			t.Fatalf("Params not normalized: %+v", repo.lastParams)
+		}
+		if repo.lastParams.Sender != "foo@bar.com" {
+			t.Fatalf("Expected normalized sender, got %q", repo.lastParams.Sender)
+		}
+		if resp.Pagination.PerPage != repo.lastParams.Limit {
+			t.Fatalf("Pagination mismatch: %+v", resp.Pagination)
+		}
+	})
+}
+
+// TestNormalizeIPRule verifies IPs become host CIDRs and CIDRs are canonicalized
+func TestNormalizeIPRule(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		ip := net.IPv4(
+			byte(rapid.IntRange(0, 255).Draw(t, "a")),
+			byte(rapid.IntRange(0, 255).Draw(t, "b")),
+			byte(rapid.IntRange(0, 255).Draw(t, "c")),
+			byte(rapid.IntRange(0, 255).Draw(t, "d")),
+		)
+		rule, err := NormalizeIPRule(ip.String())
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if rule != ip.To4().String()+"/32" {
+			t.Fatalf("Expected host CIDR, got %q", rule)
+		}
+
+		bits := rapid.IntRange(MinIPv4Prefix, 32).Draw(t, "bits")
+		network, err := NormalizeIPRule(ip.String() + "/" + strconv.Itoa(bits))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		_, parsed, _ := net.ParseCIDR(network)
+		if !parsed.Contains(ip) {
+			t.Fatalf("Normalized network %s should contain %s", network, ip)
+		}
+	})
+
+	for _, invalid := range []string{"", "not-an-ip", "10.0.0.1/33", "300.1.1.1", "0.0.0.0/0", "10.0.0.0/23", "::/0", "2001:db8::/47"} {
+		if _, err := NormalizeIPRule(invalid); !errors.Is(err, ErrInvalidIPRule) {
+			t.Errorf("Expected ErrInvalidIPRule for %q, got %v", invalid, err)
+		}
+	}
+	if rule, _ := NormalizeIPRule("2001:db8::1"); rule != "2001:db8::1/128" {
+		t.Errorf("Expected IPv6 host CIDR, got %q", rule)
+	}
+}
+
+// TestSelectCaptureTargets verifies sessions are captured only for enabled domains they were addressed to
+func TestSelectCaptureTargets(t *testing.T) {
+	domains := []CaptureDomain{
+		{ID: "all", Name: "all.example", Enabled: true},
+		{ID: "ip", Name: "ip.example", Enabled: true, IPRules: []string{"198.51.100.0/24"}},
+		{ID: "disabled", Name: "disabled.example", Enabled: false, IPRules: []string{"198.51.100.0/24"}},
+	}
+
+	tests := []struct {
+		name       string
+		remoteIP   string
+		recipients []string
+		want       []string
+	}{
+		{"addressed domain without IP rules", "203.0.113.7", []string{"all.example"}, []string{"all"}},
+		{"IP rule matches the sender", "198.51.100.9", []string{"IP.example"}, []string{"ip"}},
+		{"IP rule does not match the sender", "203.0.113.7", []string{"ip.example"}, nil},
+		{"IP rule never captures mail to another domain", "198.51.100.9", []string{"other.example"}, nil},
+		{"IP rule never captures a session without recipients", "198.51.100.9", nil, nil},
+		{"disabled domain", "198.51.100.9", []string{"disabled.example"}, nil},
+		{"unparseable sender", "unknown", []string{"ip.example", "all.example"}, []string{"all"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, target := range selectCaptureTargets(tt.remoteIP, tt.recipients, domains) {
+				got = append(got, target.DomainID)
+				if want := strings.ToLower(target.DomainID) + ".example"; target.Domain != want {
+					t.Errorf("Expected domain name %q for %s, got %q", want, target.DomainID, target.Domain)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Expected targets %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestUpdateSettingsValidation verifies rule limits and de-duplication
+func TestUpdateSettingsValidation(t *testing.T) {
+	service, repo, ownerID, domainID := newTestService()
+	ctx := context.Background()
+
+	tooMany := make([]string, MaxIPRules+1)
+	for i := range tooMany {
+		tooMany[i] = "10.0.0." + strconv.Itoa(i%250)
+	}
+	if _, err := service.UpdateSettings(ctx, ownerID, domainID, UpdateSettingsRequest{SenderIPs: tooMany}); !errors.Is(err, ErrTooManyIPRules) {
+		t.Fatalf("Expected ErrTooManyIPRules, got %v", err)
+	}
+
+	settings, err := service.UpdateSettings(ctx, ownerID, domainID, UpdateSettingsRequest{
+		Enabled:   true,
+		SenderIPs: []string{"10.0.0.1", "10.0.0.1/32", "192.168.1.0/24"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(settings.SenderIPs) != 2 || !repo.settings[domainID].Enabled {
+		t.Fatalf("Expected de-duplicated rules and enabled flag, got %+v", repo.settings[domainID])
+	}
+}
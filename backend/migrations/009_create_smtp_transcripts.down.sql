@@ -0,0 +1,9 @@
+-- Rollback migration 009_create_smtp_transcripts
+
+BEGIN;
+
+DROP TABLE IF EXISTS smtp_transcripts CASCADE;
+DROP TABLE IF EXISTS smtp_transcript_ip_rules CASCADE;
+ALTER TABLE domains DROP COLUMN IF EXISTS transcript_enabled;
+
+COMMIT;
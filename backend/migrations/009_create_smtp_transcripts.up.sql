@@ -0,0 +1,69 @@
+-- Migration: 009_create_smtp_transcripts
+-- Description: Opt-in SMTP session transcript capture for debugging delivery problems
+-- Requirements: Session transcripts per domain or sender IP, capped ring store
+
+BEGIN;
+
+-- Per-domain opt-in flag
+ALTER TABLE domains
+    ADD COLUMN transcript_enabled BOOLEAN NOT NULL DEFAULT false;
+
+-- Sender IP opt-in rules (capture every session from a matching IP for the domain)
+CREATE TABLE smtp_transcript_ip_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    domain_id UUID NOT NULL,
+    ip_cidr CIDR NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+
+    -- Foreign Keys
+    CONSTRAINT fk_transcript_ip_rules_domain FOREIGN KEY (domain_id)
+        REFERENCES domains (id)
+        ON DELETE CASCADE,
+
+    -- Constraints
+    CONSTRAINT transcript_ip_rules_unique UNIQUE (domain_id, ip_cidr)
+);
+
+-- Captured transcripts (trimmed to a per-domain cap on insert)
+CREATE TABLE smtp_transcripts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    domain_id UUID NOT NULL,
+    session_id VARCHAR(32) NOT NULL,
+    remote_ip VARCHAR(45) NOT NULL,
+    helo VARCHAR(512),
+    mail_from TEXT[] NOT NULL DEFAULT '{}',
+    recipients TEXT[] NOT NULL DEFAULT '{}',
+    queue_ids TEXT[] NOT NULL DEFAULT '{}',
+    tls_enabled BOOLEAN NOT NULL DEFAULT false,
+    truncated BOOLEAN NOT NULL DEFAULT false,
+    entries JSONB NOT NULL DEFAULT '[]',
+    started_at TIMESTAMP NOT NULL,
+    ended_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+
+    -- Foreign Keys
+    CONSTRAINT fk_smtp_transcripts_domain FOREIGN KEY (domain_id)
+        REFERENCES domains (id)
+        ON DELETE CASCADE
+);
+
+-- Indexes
+-- Domain transcript listing and ring trimming (newest first)
+CREATE INDEX idx_smtp_transcripts_domain_started ON smtp_transcripts (domain_id, started_at DESC);
+
+-- Queue ID lookup
+CREATE INDEX idx_smtp_transcripts_queue_ids ON smtp_transcripts USING GIN (queue_ids);
+
+-- IP rule lookup per domain
+CREATE INDEX idx_transcript_ip_rules_domain ON smtp_transcript_ip_rules (domain_id);
+
+-- Comments
+COMMENT ON COLUMN domains.transcript_enabled IS 'Capture SMTP session transcripts for mail addressed to this domain';
+COMMENT ON TABLE smtp_transcript_ip_rules IS 'Sender IP ranges whose SMTP sessions are captured for a domain';
+COMMENT ON TABLE smtp_transcripts IS 'SMTP session transcripts (commands and responses only, never message bodies)';
+COMMENT ON COLUMN smtp_transcripts.session_id IS 'Server-generated SMTP session identifier';
+COMMENT ON COLUMN smtp_transcripts.queue_ids IS 'Queue IDs of messages accepted during the session';
+COMMENT ON COLUMN smtp_transcripts.entries IS 'Ordered command/response lines: [{timestamp, direction, line}]';
+COMMENT ON COLUMN smtp_transcripts.truncated IS 'Whether the session exceeded the per-transcript line cap';
+
+COMMIT;
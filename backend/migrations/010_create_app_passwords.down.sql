@@ -0,0 +1,7 @@
+-- Rollback migration 010_create_app_passwords
+
+BEGIN;
+
+DROP TABLE IF EXISTS app_passwords CASCADE;
+
+COMMIT;
@@ -0,0 +1,33 @@
+-- Migration: 010_create_app_passwords
+-- Description: Create app_passwords table for per-user mail client credentials (SMTP submission)
+-- Requirements: Submission listener authenticates AUTH PLAIN/LOGIN against bcrypt-hashed app passwords
+
+BEGIN;
+
+CREATE TABLE app_passwords (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    password_hash VARCHAR(255) NOT NULL,
+    last_used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+
+    -- Foreign Keys
+    CONSTRAINT fk_app_passwords_user FOREIGN KEY (user_id)
+        REFERENCES users (id)
+        ON DELETE CASCADE,
+
+    -- Constraints
+    CONSTRAINT app_passwords_name_not_empty CHECK (LENGTH(TRIM(name)) > 0)
+);
+
+-- Indexes
+CREATE INDEX idx_app_passwords_user_id ON app_passwords (user_id, created_at DESC);
+
+-- Comments
+COMMENT ON TABLE app_passwords IS 'Per-user app passwords for mail client authentication';
+COMMENT ON COLUMN app_passwords.name IS 'User supplied label, e.g. "Thunderbird laptop"';
+COMMENT ON COLUMN app_passwords.password_hash IS 'bcrypt hash of the generated app password';
+COMMENT ON COLUMN app_passwords.last_used_at IS 'Timestamp of the last successful authentication (UTC)';
+
+COMMIT;
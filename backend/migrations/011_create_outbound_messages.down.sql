@@ -0,0 +1,7 @@
+-- Rollback migration 011_create_outbound_messages
+
+BEGIN;
+
+DROP TABLE IF EXISTS outbound_messages CASCADE;
+
+COMMIT;
@@ -0,0 +1,47 @@
+-- Migration: 011_create_outbound_messages
+-- Description: Create outbound_messages queue for mail accepted on the submission listener
+-- Requirements: Authenticated submission enqueues accepted messages for outbound delivery
+
+BEGIN;
+
+CREATE TABLE outbound_messages (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    queue_id VARCHAR(32) NOT NULL,
+    mail_from VARCHAR(255) NOT NULL,
+    recipients TEXT[] NOT NULL,
+    raw_message BYTEA NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'queued',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+    updated_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+
+    -- Foreign Keys
+    CONSTRAINT fk_outbound_messages_user FOREIGN KEY (user_id)
+        REFERENCES users (id)
+        ON DELETE CASCADE,
+
+    -- Constraints
+    CONSTRAINT outbound_messages_status_valid CHECK (
+        status IN ('queued', 'sending', 'sent', 'failed')
+    ),
+    CONSTRAINT outbound_messages_recipients_not_empty CHECK (cardinality(recipients) > 0),
+    CONSTRAINT outbound_messages_size_positive CHECK (size_bytes > 0)
+);
+
+-- Indexes
+CREATE UNIQUE INDEX idx_outbound_messages_queue_id ON outbound_messages (queue_id);
+CREATE INDEX idx_outbound_messages_pending ON outbound_messages (status, next_attempt_at);
+CREATE INDEX idx_outbound_messages_user_id ON outbound_messages (user_id, created_at DESC);
+
+-- Comments
+COMMENT ON TABLE outbound_messages IS 'Queue of messages accepted by the authenticated submission listener';
+COMMENT ON COLUMN outbound_messages.queue_id IS 'Queue ID returned to the client in the 250 reply';
+COMMENT ON COLUMN outbound_messages.mail_from IS 'Envelope sender, always an alias owned by user_id';
+COMMENT ON COLUMN outbound_messages.status IS 'Delivery status: queued, sending, sent, failed';
+COMMENT ON COLUMN outbound_messages.next_attempt_at IS 'Earliest time the delivery worker may pick the message up (UTC)';
+
+COMMIT;
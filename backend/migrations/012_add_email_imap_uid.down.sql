@@ -0,0 +1,10 @@
+-- Rollback migration 012_add_email_imap_uid
+
+BEGIN;
+
+DROP INDEX IF EXISTS idx_emails_alias_imap_uid;
+DROP INDEX IF EXISTS idx_emails_imap_uid;
+ALTER TABLE emails DROP COLUMN IF EXISTS imap_uid;
+DROP SEQUENCE IF EXISTS emails_imap_uid_seq;
+
+COMMIT;
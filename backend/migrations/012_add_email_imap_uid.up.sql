@@ -0,0 +1,37 @@
+-- Migration: 012_add_email_imap_uid
+-- Description: Add a stable, strictly ascending numeric UID to emails for IMAP access
+-- Requirements: IMAP4rev1 UID FETCH/SEARCH/STORE need per-mailbox ascending UIDs that are never reused
+
+BEGIN;
+
+-- A single global sequence keeps UIDs unique and ascending in every mailbox (alias or INBOX),
+-- so UIDVALIDITY never has to change
+CREATE SEQUENCE emails_imap_uid_seq AS BIGINT;
+
+ALTER TABLE emails ADD COLUMN imap_uid BIGINT;
+
+-- Back-fill existing mail in arrival order
+UPDATE emails e
+SET imap_uid = o.rn
+FROM (
+    SELECT id, ROW_NUMBER() OVER (ORDER BY received_at, created_at, id) AS rn
+    FROM emails
+) o
+WHERE e.id = o.id;
+
+SELECT setval('emails_imap_uid_seq', COALESCE((SELECT MAX(imap_uid) FROM emails), 0) + 1, false);
+
+ALTER TABLE emails
+    ALTER COLUMN imap_uid SET DEFAULT nextval('emails_imap_uid_seq'),
+    ALTER COLUMN imap_uid SET NOT NULL;
+
+ALTER SEQUENCE emails_imap_uid_seq OWNED BY emails.imap_uid;
+
+-- Indexes
+CREATE UNIQUE INDEX idx_emails_imap_uid ON emails (imap_uid);
+CREATE INDEX idx_emails_alias_imap_uid ON emails (alias_id, imap_uid);
+
+-- Comments
+COMMENT ON COLUMN emails.imap_uid IS 'IMAP UID, assigned from a global ascending sequence and never reused';
+
+COMMIT;
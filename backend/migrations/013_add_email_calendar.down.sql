@@ -0,0 +1,7 @@
+-- Rollback migration 013_add_email_calendar
+
+BEGIN;
+
+ALTER TABLE emails DROP COLUMN IF EXISTS calendar;
+
+COMMIT;
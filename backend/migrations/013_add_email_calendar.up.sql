@@ -0,0 +1,12 @@
+-- Migration: 013_add_email_calendar
+-- Description: Store meeting invitations parsed from text/calendar parts as structured data
+-- Requirements: Email detail returns VEVENT summary, organizer, attendees, times, location and recurrence
+
+BEGIN;
+
+ALTER TABLE emails ADD COLUMN calendar JSONB;
+
+-- Comments
+COMMENT ON COLUMN emails.calendar IS 'Parsed iCalendar invitation (METHOD and VEVENTs), NULL when the email has none';
+
+COMMIT;
@@ -0,0 +1,7 @@
+-- Rollback migration 014_add_email_codes
+
+BEGIN;
+
+ALTER TABLE emails DROP COLUMN IF EXISTS codes;
+
+COMMIT;
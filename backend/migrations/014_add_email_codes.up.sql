@@ -0,0 +1,12 @@
+-- Migration: 014_add_email_codes
+-- Description: Store one-time codes and verification, magic sign-in and password reset links found at ingest
+-- Requirements: New email events and GET /emails/{id}/codes return the extracted codes and links
+
+BEGIN;
+
+ALTER TABLE emails ADD COLUMN codes JSONB;
+
+-- Comments
+COMMENT ON COLUMN emails.codes IS 'One-time codes and account links extracted from the subject and body, NULL when none were found';
+
+COMMIT;
@@ -0,0 +1,18 @@
+-- Rollback migration 015_add_email_threads
+
+BEGIN;
+
+DROP TRIGGER IF EXISTS emails_thread_id ON emails;
+DROP FUNCTION IF EXISTS set_email_thread_id();
+
+DROP INDEX IF EXISTS idx_emails_thread_subject;
+DROP INDEX IF EXISTS idx_emails_thread_references;
+DROP INDEX IF EXISTS idx_emails_message_id;
+DROP INDEX IF EXISTS idx_emails_thread_id;
+
+ALTER TABLE emails DROP COLUMN IF EXISTS thread_subject;
+ALTER TABLE emails DROP COLUMN IF EXISTS thread_references;
+ALTER TABLE emails DROP COLUMN IF EXISTS thread_id;
+ALTER TABLE emails DROP COLUMN IF EXISTS message_id;
+
+COMMIT;
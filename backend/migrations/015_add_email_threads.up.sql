@@ -0,0 +1,51 @@
+-- Migration: 015_add_email_threads
+-- Description: Group emails into conversations by Message-ID, In-Reply-To, References and base subject
+-- Requirements: GET /threads lists conversations; thread deletes and read-marking cover every message
+
+BEGIN;
+
+ALTER TABLE emails ADD COLUMN message_id TEXT;
+ALTER TABLE emails ADD COLUMN thread_id UUID;
+ALTER TABLE emails ADD COLUMN thread_references TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE emails ADD COLUMN thread_subject TEXT;
+
+-- Existing emails start as single-message threads
+UPDATE emails
+SET thread_id = id,
+    message_id = NULLIF(TRIM(BOTH '<> ' FROM headers->>'Message-Id'), '');
+
+ALTER TABLE emails ALTER COLUMN thread_id SET NOT NULL;
+
+-- Emails inserted without a thread start their own
+CREATE OR REPLACE FUNCTION set_email_thread_id()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.thread_id IS NULL THEN
+        NEW.thread_id = NEW.id;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER emails_thread_id
+    BEFORE INSERT ON emails
+    FOR EACH ROW
+    EXECUTE FUNCTION set_email_thread_id();
+
+-- Index for listing a thread's messages in order
+CREATE INDEX idx_emails_thread_id ON emails (thread_id, received_at DESC);
+
+-- Indexes for resolving parents, siblings and children at ingest
+CREATE INDEX idx_emails_message_id ON emails (alias_id, message_id) WHERE message_id IS NOT NULL;
+CREATE INDEX idx_emails_thread_references ON emails USING gin (thread_references);
+
+-- Index for the subject fallback
+CREATE INDEX idx_emails_thread_subject ON emails (alias_id, thread_subject, received_at DESC) WHERE thread_subject IS NOT NULL;
+
+-- Comments
+COMMENT ON COLUMN emails.message_id IS 'Message-ID header without angle brackets';
+COMMENT ON COLUMN emails.thread_id IS 'Conversation the email belongs to, the ID of its first email unless threads were merged';
+COMMENT ON COLUMN emails.thread_references IS 'Ancestor Message-IDs from References and In-Reply-To, oldest first';
+COMMENT ON COLUMN emails.thread_subject IS 'Lowercased subject without Re:/Fwd: prefixes, used when threading headers are missing';
+
+COMMIT;
@@ -0,0 +1,7 @@
+-- Rollback migration 016_add_email_header_list
+
+BEGIN;
+
+ALTER TABLE emails DROP COLUMN IF EXISTS header_list;
+
+COMMIT;
@@ -0,0 +1,59 @@
+-- Migration: 016_add_email_header_list
+-- Description: Store every header field in message order, including repeated Received and DKIM-Signature fields
+-- Requirements: GET /emails/{id} returns the ordered header list next to the headers map
+
+BEGIN;
+
+ALTER TABLE emails ADD COLUMN header_list JSONB;
+
+-- Returns the header block of a raw message as text, NULL if it cannot be decoded
+-- Non-UTF-8 header bytes are read as Latin-1 so every stored message can be back-filled
+CREATE FUNCTION email_raw_header_block(raw BYTEA) RETURNS TEXT AS $$
+DECLARE
+    block BYTEA := substring(raw FROM 1 FOR 262144);
+    crlf INT := position('\x0d0a0d0a'::bytea IN block);
+    lf INT := position('\x0a0a'::bytea IN block);
+BEGIN
+    IF crlf > 0 AND (lf = 0 OR crlf < lf) THEN
+        block := substring(block FROM 1 FOR crlf - 1);
+    ELSIF lf > 0 THEN
+        block := substring(block FROM 1 FOR lf - 1);
+    END IF;
+
+    BEGIN
+        RETURN convert_from(block, 'UTF8');
+    EXCEPTION WHEN others THEN
+        BEGIN
+            RETURN convert_from(block, 'LATIN1');
+        EXCEPTION WHEN others THEN
+            RETURN NULL;
+        END;
+    END;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Back-fill from raw_email: unfold continuation lines, split into fields and keep their order
+-- Values are stored as written (not MIME-decoded) and truncated to 1000 characters, like at ingest
+UPDATE emails e
+SET header_list = COALESCE((
+    SELECT jsonb_agg(jsonb_build_object('name', f.name, 'value', left(f.value, 1000)) ORDER BY f.ord)
+    FROM (
+        SELECT l.ord,
+               rtrim(substring(l.line FROM '^([^:]*):'), E' \t') AS name,
+               btrim(substring(l.line FROM '^[^:]*:(.*)$'), E' \t\r') AS value
+        FROM regexp_split_to_table(
+                 regexp_replace(
+                     replace(email_raw_header_block(e.raw_email), E'\r\n', E'\n'),
+                     E'\n(?=[ \t])', '', 'g'),
+                 E'\n') WITH ORDINALITY AS l(line, ord)
+    ) f
+    WHERE f.name ~ '^[!-9;-~]+$'
+), '[]'::jsonb)
+WHERE e.raw_email IS NOT NULL;
+
+DROP FUNCTION email_raw_header_block(BYTEA);
+
+-- Comments
+COMMENT ON COLUMN emails.header_list IS 'Every header field in message order as [{"name", "value"}], values unfolded but not MIME-decoded';
+
+COMMIT;
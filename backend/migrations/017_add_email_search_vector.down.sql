@@ -0,0 +1,9 @@
+-- Rollback migration 017_add_email_search_vector
+
+BEGIN;
+
+DROP INDEX IF EXISTS idx_emails_search_vector;
+
+ALTER TABLE emails DROP COLUMN IF EXISTS search_vector;
+
+COMMIT;
@@ -0,0 +1,22 @@
+-- Migration: 017_add_email_search_vector
+-- Description: Full-text index over subject, sender and bodies for ranked email search
+-- Requirements: GET /emails?search= matches the index instead of scanning with LIKE
+
+BEGIN;
+
+-- Subject ranks above sender, sender above bodies
+-- Sender addresses are indexed whole and split at separators so "example" matches sender@example.com
+-- Bodies are capped so large messages stay within the tsvector size limit
+ALTER TABLE emails ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', COALESCE(subject, '')), 'A') ||
+    setweight(to_tsvector('english', sender_address || ' ' || translate(sender_address, '@.+_-', '     ') || ' ' || COALESCE(sender_name, '')), 'B') ||
+    setweight(to_tsvector('english', left(COALESCE(body_text, ''), 262144)), 'C') ||
+    setweight(to_tsvector('english', left(COALESCE(body_html, ''), 262144)), 'D')
+) STORED;
+
+CREATE INDEX idx_emails_search_vector ON emails USING gin (search_vector);
+
+-- Comments
+COMMENT ON COLUMN emails.search_vector IS 'Weighted full-text vector of subject (A), sender (B), text body (C) and HTML body (D)';
+
+COMMIT;
@@ -0,0 +1,7 @@
+-- Rollback migration 018_create_export_jobs
+
+BEGIN;
+
+DROP TABLE IF EXISTS export_jobs CASCADE;
+
+COMMIT;
@@ -0,0 +1,56 @@
+-- Migration: 018_create_export_jobs
+-- Description: Create export_jobs for asynchronous mailbox exports to object storage
+-- Requirements: Users can export their mail as mbox or a Maildir zip, filtered by alias, date range or search
+
+BEGIN;
+
+CREATE TABLE export_jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    format VARCHAR(10) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    alias_id UUID,
+    from_date TIMESTAMP,
+    to_date TIMESTAMP,
+    search TEXT,
+    total_emails INTEGER NOT NULL DEFAULT 0,
+    processed_emails INTEGER NOT NULL DEFAULT 0,
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    storage_key VARCHAR(512),
+    error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    expires_at TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+
+    -- Foreign Keys
+    CONSTRAINT fk_export_jobs_user FOREIGN KEY (user_id)
+        REFERENCES users (id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_export_jobs_alias FOREIGN KEY (alias_id)
+        REFERENCES aliases (id)
+        ON DELETE SET NULL,
+
+    -- Constraints
+    CONSTRAINT export_jobs_format_valid CHECK (format IN ('mbox', 'maildir')),
+    CONSTRAINT export_jobs_status_valid CHECK (
+        status IN ('pending', 'running', 'completed', 'failed', 'expired')
+    )
+);
+
+-- Indexes
+CREATE INDEX idx_export_jobs_user_id ON export_jobs (user_id, created_at DESC);
+CREATE INDEX idx_export_jobs_status ON export_jobs (status, created_at);
+CREATE INDEX idx_export_jobs_expires_at ON export_jobs (expires_at) WHERE status = 'completed';
+
+-- Comments
+COMMENT ON TABLE export_jobs IS 'Asynchronous mailbox exports written to object storage';
+COMMENT ON COLUMN export_jobs.format IS 'Archive format: mbox (mboxrd) or maildir (zip with one Maildir per alias)';
+COMMENT ON COLUMN export_jobs.status IS 'Job status: pending, running, completed, failed, expired';
+COMMENT ON COLUMN export_jobs.search IS 'Search query in the email search language, empty for all emails';
+COMMENT ON COLUMN export_jobs.storage_key IS 'Object storage key of the finished archive';
+COMMENT ON COLUMN export_jobs.expires_at IS 'When the archive is deleted from storage (UTC)';
+COMMENT ON COLUMN export_jobs.updated_at IS 'Last progress update; running jobs not updated for a while are requeued (UTC)';
+
+COMMIT;
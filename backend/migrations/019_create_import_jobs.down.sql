@@ -0,0 +1,8 @@
+-- Rollback migration 019_create_import_jobs
+
+BEGIN;
+
+DROP TABLE IF EXISTS import_job_errors CASCADE;
+DROP TABLE IF EXISTS import_jobs CASCADE;
+
+COMMIT;
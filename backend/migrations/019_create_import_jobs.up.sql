@@ -0,0 +1,73 @@
+-- Migration: 019_create_import_jobs
+-- Description: Create import_jobs and import_job_errors for background mailbox imports
+-- Requirements: Users can import mbox files or zips of .eml files into an alias, resuming after restarts
+
+BEGIN;
+
+CREATE TABLE import_jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    alias_id UUID NOT NULL,
+    format VARCHAR(10) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    filename VARCHAR(255) NOT NULL,
+    storage_key VARCHAR(512) NOT NULL,
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    total_messages INTEGER,
+    processed_messages INTEGER NOT NULL DEFAULT 0,
+    imported_messages INTEGER NOT NULL DEFAULT 0,
+    duplicate_messages INTEGER NOT NULL DEFAULT 0,
+    failed_messages INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+
+    -- Foreign Keys
+    CONSTRAINT fk_import_jobs_user FOREIGN KEY (user_id)
+        REFERENCES users (id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_import_jobs_alias FOREIGN KEY (alias_id)
+        REFERENCES aliases (id)
+        ON DELETE CASCADE,
+
+    -- Constraints
+    CONSTRAINT import_jobs_format_valid CHECK (format IN ('mbox', 'zip')),
+    CONSTRAINT import_jobs_status_valid CHECK (
+        status IN ('pending', 'running', 'completed', 'failed')
+    )
+);
+
+CREATE TABLE import_job_errors (
+    job_id UUID NOT NULL,
+    message_index INTEGER NOT NULL,
+    entry_name TEXT,
+    message_id TEXT,
+    error TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+
+    PRIMARY KEY (job_id, message_index),
+
+    -- Foreign Keys
+    CONSTRAINT fk_import_job_errors_job FOREIGN KEY (job_id)
+        REFERENCES import_jobs (id)
+        ON DELETE CASCADE
+);
+
+-- Indexes
+CREATE INDEX idx_import_jobs_user_id ON import_jobs (user_id, created_at DESC);
+CREATE INDEX idx_import_jobs_status ON import_jobs (status, created_at);
+
+-- Comments
+COMMENT ON TABLE import_jobs IS 'Background imports of uploaded mailbox archives into an alias';
+COMMENT ON COLUMN import_jobs.format IS 'Archive format: mbox or zip (of .eml files)';
+COMMENT ON COLUMN import_jobs.status IS 'Job status: pending, running, completed, failed';
+COMMENT ON COLUMN import_jobs.storage_key IS 'Object storage key of the uploaded archive, deleted when the job ends';
+COMMENT ON COLUMN import_jobs.total_messages IS 'Messages in the archive, NULL until known';
+COMMENT ON COLUMN import_jobs.processed_messages IS 'Messages handled so far; a resumed job continues after them';
+COMMENT ON COLUMN import_jobs.updated_at IS 'Last progress update; running jobs not updated for a while are requeued (UTC)';
+COMMENT ON TABLE import_job_errors IS 'Messages of an import that could not be stored, by position in the archive';
+COMMENT ON COLUMN import_job_errors.entry_name IS 'File name of the message within zip archives';
+
+COMMIT;
@@ -0,0 +1,8 @@
+-- Rollback migration 020_create_labels
+
+BEGIN;
+
+DROP TABLE IF EXISTS email_labels CASCADE;
+DROP TABLE IF EXISTS labels CASCADE;
+
+COMMIT;
@@ -0,0 +1,52 @@
+-- Migration: 020_create_labels
+-- Description: Create labels and email_labels for user-defined email labels
+-- Requirements: Users organize emails with named, colored labels across aliases
+
+BEGIN;
+
+CREATE TABLE labels (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    color VARCHAR(7) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+    updated_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+
+    -- Foreign Keys
+    CONSTRAINT fk_labels_user FOREIGN KEY (user_id)
+        REFERENCES users (id)
+        ON DELETE CASCADE,
+
+    -- Constraints
+    CONSTRAINT labels_name_not_blank CHECK (btrim(name) <> ''),
+    CONSTRAINT labels_color_valid CHECK (color ~ '^#[0-9a-f]{6}$')
+);
+
+CREATE TABLE email_labels (
+    email_id UUID NOT NULL,
+    label_id UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+
+    PRIMARY KEY (email_id, label_id),
+
+    -- Foreign Keys
+    CONSTRAINT fk_email_labels_email FOREIGN KEY (email_id)
+        REFERENCES emails (id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_email_labels_label FOREIGN KEY (label_id)
+        REFERENCES labels (id)
+        ON DELETE CASCADE
+);
+
+-- Indexes
+-- Label names are unique per user regardless of case
+CREATE UNIQUE INDEX idx_labels_user_name ON labels (user_id, LOWER(name));
+CREATE INDEX idx_email_labels_label_id ON email_labels (label_id);
+
+-- Comments
+COMMENT ON TABLE labels IS 'User-defined labels applied to emails of any of the user''s aliases';
+COMMENT ON COLUMN labels.name IS 'Label name, unique per user ignoring case';
+COMMENT ON COLUMN labels.color IS 'Display color as lowercase #rrggbb';
+COMMENT ON TABLE email_labels IS 'Labels applied to emails';
+
+COMMIT;
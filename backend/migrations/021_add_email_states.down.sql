@@ -0,0 +1,12 @@
+-- Rollback migration 021_add_email_states
+
+BEGIN;
+
+DROP INDEX IF EXISTS idx_emails_alias_starred;
+DROP INDEX IF EXISTS idx_emails_snoozed_until;
+
+ALTER TABLE emails DROP COLUMN IF EXISTS snoozed_until;
+ALTER TABLE emails DROP COLUMN IF EXISTS is_archived;
+ALTER TABLE emails DROP COLUMN IF EXISTS is_starred;
+
+COMMIT;
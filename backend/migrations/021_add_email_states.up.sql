@@ -0,0 +1,21 @@
+-- Migration: 021_add_email_states
+-- Description: Star, archive and snooze states for emails
+-- Requirements: Flag important emails, hide processed ones without deleting them, and resurface snoozed ones
+
+BEGIN;
+
+ALTER TABLE emails ADD COLUMN is_starred BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE emails ADD COLUMN is_archived BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE emails ADD COLUMN snoozed_until TIMESTAMP NULL;
+
+-- Indexes
+-- The snooze scheduler scans only snoozed emails, oldest wake-up first
+CREATE INDEX idx_emails_snoozed_until ON emails (snoozed_until) WHERE snoozed_until IS NOT NULL;
+CREATE INDEX idx_emails_alias_starred ON emails (alias_id, received_at DESC) WHERE is_starred;
+
+-- Comments
+COMMENT ON COLUMN emails.is_starred IS 'Flagged as important by the user';
+COMMENT ON COLUMN emails.is_archived IS 'Hidden from the inbox without being deleted';
+COMMENT ON COLUMN emails.snoozed_until IS 'Hidden until this time, then returned to the inbox as unread; NULL when not snoozed';
+
+COMMIT;
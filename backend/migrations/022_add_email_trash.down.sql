@@ -0,0 +1,9 @@
+-- Rollback migration 022_add_email_trash
+
+BEGIN;
+
+DROP INDEX IF EXISTS idx_emails_deleted_at;
+
+ALTER TABLE emails DROP COLUMN IF EXISTS deleted_at;
+
+COMMIT;
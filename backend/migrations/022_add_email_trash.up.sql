@@ -0,0 +1,16 @@
+-- Migration: 022_add_email_trash
+-- Description: Soft delete of emails into a trash that is purged after a retention period
+-- Requirements: Recover accidentally deleted emails before they are permanently removed
+
+BEGIN;
+
+ALTER TABLE emails ADD COLUMN deleted_at TIMESTAMP NULL;
+
+-- Indexes
+-- The trash purge scans only trashed emails, oldest first
+CREATE INDEX idx_emails_deleted_at ON emails (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Comments
+COMMENT ON COLUMN emails.deleted_at IS 'Moved to the trash at this time, permanently deleted after the trash retention period; NULL when not trashed';
+
+COMMIT;
@@ -0,0 +1,13 @@
+-- Rollback migration 023_add_retention_policies
+
+BEGIN;
+
+ALTER TABLE aliases DROP CONSTRAINT IF EXISTS chk_aliases_retention;
+ALTER TABLE aliases DROP COLUMN IF EXISTS retention_value;
+ALTER TABLE aliases DROP COLUMN IF EXISTS retention_mode;
+
+ALTER TABLE domains DROP CONSTRAINT IF EXISTS chk_domains_retention;
+ALTER TABLE domains DROP COLUMN IF EXISTS retention_value;
+ALTER TABLE domains DROP COLUMN IF EXISTS retention_mode;
+
+COMMIT;
@@ -0,0 +1,31 @@
+-- Migration: 023_add_retention_policies
+-- Description: Email retention policies per alias, with domain-level defaults
+-- Requirements: Expire mail after N hours or N days, or keep only the latest N messages
+
+BEGIN;
+
+-- A policy is a mode with a value for every mode but forever
+-- Aliases without a policy inherit their domain's; domains without one keep mail forever
+ALTER TABLE domains ADD COLUMN retention_mode VARCHAR(10) NULL;
+ALTER TABLE domains ADD COLUMN retention_value INTEGER NULL;
+ALTER TABLE domains ADD CONSTRAINT chk_domains_retention CHECK (
+    (retention_mode IS NULL AND retention_value IS NULL)
+    OR (retention_mode = 'forever' AND retention_value IS NULL)
+    OR (retention_mode IN ('hours', 'days', 'latest') AND retention_value > 0)
+);
+
+ALTER TABLE aliases ADD COLUMN retention_mode VARCHAR(10) NULL;
+ALTER TABLE aliases ADD COLUMN retention_value INTEGER NULL;
+ALTER TABLE aliases ADD CONSTRAINT chk_aliases_retention CHECK (
+    (retention_mode IS NULL AND retention_value IS NULL)
+    OR (retention_mode = 'forever' AND retention_value IS NULL)
+    OR (retention_mode IN ('hours', 'days', 'latest') AND retention_value > 0)
+);
+
+-- Comments
+COMMENT ON COLUMN domains.retention_mode IS 'Default retention of the domain''s aliases: forever, hours, days or latest; NULL keeps mail forever';
+COMMENT ON COLUMN domains.retention_value IS 'Hours or days mail is kept, or number of latest messages kept';
+COMMENT ON COLUMN aliases.retention_mode IS 'Retention of the alias: forever, hours, days or latest; NULL inherits the domain default';
+COMMENT ON COLUMN aliases.retention_value IS 'Hours or days mail is kept, or number of latest messages kept';
+
+COMMIT;
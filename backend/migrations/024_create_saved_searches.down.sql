@@ -0,0 +1,7 @@
+-- Rollback migration 024_create_saved_searches
+
+BEGIN;
+
+DROP TABLE IF EXISTS saved_searches CASCADE;
+
+COMMIT;
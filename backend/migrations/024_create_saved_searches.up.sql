@@ -0,0 +1,38 @@
+-- Migration: 024_create_saved_searches
+-- Description: Create saved_searches for named, reusable email filters
+-- Requirements: Users save filter combinations and search queries, and pin them with unread counts
+
+BEGIN;
+
+CREATE TABLE saved_searches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    query TEXT NOT NULL DEFAULT '',
+    params JSONB NOT NULL DEFAULT '{}',
+    is_pinned BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+    updated_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+
+    -- Foreign Keys
+    CONSTRAINT fk_saved_searches_user FOREIGN KEY (user_id)
+        REFERENCES users (id)
+        ON DELETE CASCADE,
+
+    -- Constraints
+    CONSTRAINT saved_searches_name_not_blank CHECK (btrim(name) <> ''),
+    CONSTRAINT saved_searches_query_length CHECK (char_length(query) <= 500)
+);
+
+-- Indexes
+-- Saved search names are unique per user regardless of case
+CREATE UNIQUE INDEX idx_saved_searches_user_name ON saved_searches (user_id, LOWER(name));
+
+-- Comments
+COMMENT ON TABLE saved_searches IS 'Named email filters of a user, run on demand and matched against new emails';
+COMMENT ON COLUMN saved_searches.name IS 'Saved search name, unique per user ignoring case';
+COMMENT ON COLUMN saved_searches.query IS 'Search query in the syntax of the email list search parameter';
+COMMENT ON COLUMN saved_searches.params IS 'Email list filters as JSON, combined with the query';
+COMMENT ON COLUMN saved_searches.is_pinned IS 'Shown first, with an unread count';
+
+COMMIT;
@@ -0,0 +1,8 @@
+-- Rollback migration 025_add_email_charset
+
+BEGIN;
+
+ALTER TABLE emails DROP COLUMN IF EXISTS charset_warnings;
+ALTER TABLE emails DROP COLUMN IF EXISTS body_charset;
+
+COMMIT;
@@ -0,0 +1,14 @@
+-- Migration: 025_add_email_charset
+-- Description: Store the charset the body was decoded from and the charset problems found at ingest
+-- Requirements: GET /emails/{id} returns the detected body charset and charset warnings
+
+BEGIN;
+
+ALTER TABLE emails ADD COLUMN body_charset VARCHAR(64);
+ALTER TABLE emails ADD COLUMN charset_warnings JSONB;
+
+-- Comments
+COMMENT ON COLUMN emails.body_charset IS 'Canonical name of the charset the body was decoded from, NULL when the email has no text body';
+COMMENT ON COLUMN emails.charset_warnings IS 'Unknown charsets and replaced invalid sequences found while decoding, NULL when none were found';
+
+COMMIT;
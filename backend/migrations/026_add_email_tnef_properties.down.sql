@@ -0,0 +1,7 @@
+-- Rollback migration 026_add_email_tnef_properties
+
+BEGIN;
+
+ALTER TABLE emails DROP COLUMN IF EXISTS tnef_properties;
+
+COMMIT;
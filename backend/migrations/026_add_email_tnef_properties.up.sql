@@ -0,0 +1,12 @@
+-- Migration: 026_add_email_tnef_properties
+-- Description: Store the message properties of Outlook winmail.dat (TNEF) parts found at ingest
+-- Requirements: GET /emails/{id} returns the TNEF message properties, such as the original sender and sent time
+
+BEGIN;
+
+ALTER TABLE emails ADD COLUMN tnef_properties JSONB;
+
+-- Comments
+COMMENT ON COLUMN emails.tnef_properties IS 'Message properties read from a winmail.dat part, NULL when the email has none';
+
+COMMIT;
@@ -0,0 +1,7 @@
+-- Rollback migration 027_add_email_embedded
+
+BEGIN;
+
+ALTER TABLE emails DROP COLUMN IF EXISTS embedded;
+
+COMMIT;
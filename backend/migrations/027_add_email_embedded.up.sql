@@ -0,0 +1,12 @@
+-- Migration: 027_add_email_embedded
+-- Description: Store summaries of forwarded messages (message/rfc822 parts) found at ingest
+-- Requirements: GET /emails/{id} lists forwarded messages without parsing the raw email
+
+BEGIN;
+
+ALTER TABLE emails ADD COLUMN embedded JSONB;
+
+-- Comments
+COMMENT ON COLUMN emails.embedded IS 'Path, filename, sender, subject and date of each top-level forwarded message, NULL when none were found';
+
+COMMIT;
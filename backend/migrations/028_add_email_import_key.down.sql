@@ -0,0 +1,9 @@
+-- Rollback migration 028_add_email_import_key
+
+BEGIN;
+
+DROP INDEX IF EXISTS idx_emails_alias_import_message_id;
+DROP INDEX IF EXISTS idx_emails_alias_import_key;
+ALTER TABLE emails DROP COLUMN IF EXISTS import_key;
+
+COMMIT;
@@ -0,0 +1,19 @@
+-- Migration: 028_add_email_import_key
+-- Description: Deduplicate imported messages per alias by content hash and Message-ID, enforced by the database
+-- Requirements: Mailbox imports skip messages the alias already has, also without a Message-ID and across concurrent jobs
+
+BEGIN;
+
+ALTER TABLE emails ADD COLUMN import_key CHAR(64);
+
+-- One copy of an imported message per alias, whether or not it has a Message-ID
+CREATE UNIQUE INDEX idx_emails_alias_import_key ON emails (alias_id, import_key) WHERE import_key IS NOT NULL;
+
+-- One imported message per alias and Message-ID, so concurrent jobs cannot both store the same message
+CREATE UNIQUE INDEX idx_emails_alias_import_message_id ON emails (alias_id, message_id)
+    WHERE import_key IS NOT NULL AND message_id IS NOT NULL;
+
+-- Comments
+COMMENT ON COLUMN emails.import_key IS 'Hex SHA-256 of the raw message for emails imported from a mailbox archive, NULL for received mail';
+
+COMMIT;
@@ -0,0 +1,7 @@
+-- Rollback migration 029_add_email_retention_index
+
+BEGIN;
+
+DROP INDEX IF EXISTS idx_emails_alias_live_received;
+
+COMMIT;
@@ -0,0 +1,11 @@
+-- Migration: 029_add_email_retention_index
+-- Description: Index the live emails of each alias newest first for the latest-N retention policy
+-- Requirements: Finding emails beyond an alias's latest N reads only that alias's newest N emails
+
+BEGIN;
+
+-- Indexes
+-- The retention run seeks to the Nth newest live email of an alias and deletes everything older
+CREATE INDEX idx_emails_alias_live_received ON emails (alias_id, received_at DESC, id DESC) WHERE deleted_at IS NULL;
+
+COMMIT;